@@ -0,0 +1,296 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+	"goodclips-server/internal/synonyms"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// adminAuthMiddleware guards admin routes with the bearer token ADMIN_TOKEN. Without a token the
+// admin routes are disabled rather than left open.
+func adminAuthMiddleware() gin.HandlerFunc {
+	token := getEnvOrDefault("ADMIN_TOKEN", "")
+	if token == "" {
+		log.Println("Warning: ADMIN_TOKEN not set; admin endpoints are disabled")
+	}
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Admin endpoints are disabled: ADMIN_TOKEN is not set"})
+			return
+		}
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Synonym dictionary, cached in-process. Every synonymCheckInterval the stored version is compared
+// with the cached one, so an edit made through any API replica reaches all of them.
+
+// synonymCheckInterval is how long the cached dictionary is used before its version is checked
+const synonymCheckInterval = 10 * time.Second
+
+var (
+	synonymMu        sync.Mutex
+	synonymDict      *synonyms.Dictionary
+	synonymVersion   string
+	synonymCheckedAt time.Time
+)
+
+// currentSynonyms returns the cached dictionary, loading it from the database on first use and
+// again once the stored dictionary changed. When loading fails the last loaded dictionary is kept.
+func currentSynonyms() *synonyms.Dictionary {
+	synonymMu.Lock()
+	defer synonymMu.Unlock()
+	if synonymDict != nil && time.Since(synonymCheckedAt) < synonymCheckInterval {
+		return synonymDict
+	}
+	if err := loadSynonymsLocked(); err != nil {
+		log.Printf("Warning: failed to load synonym dictionary: %v", err)
+	}
+	if synonymDict == nil {
+		return synonyms.New(nil)
+	}
+	return synonymDict
+}
+
+// reloadSynonyms rebuilds the cached dictionary from the synonyms table
+func reloadSynonyms() error {
+	synonymMu.Lock()
+	defer synonymMu.Unlock()
+	synonymVersion = ""
+	return loadSynonymsLocked()
+}
+
+// loadSynonymsLocked rebuilds the cached dictionary unless the stored version is the cached one;
+// synonymMu must be held
+func loadSynonymsLocked() error {
+	synonymCheckedAt = time.Now()
+	version, err := db.SynonymsVersion()
+	if err != nil {
+		return err
+	}
+	if synonymDict != nil && version == synonymVersion {
+		return nil
+	}
+	rows, err := db.ListSynonyms()
+	if err != nil {
+		return err
+	}
+	entries := make([]synonyms.Entry, 0, len(rows))
+	for _, r := range rows {
+		entries = append(entries, synonyms.Entry{Term: r.Term, Aliases: r.Aliases})
+	}
+	synonymDict = synonyms.New(entries)
+	synonymVersion = version
+	return nil
+}
+
+// getSynonyms returns the synonym dictionary
+func getSynonyms(c *gin.Context) {
+	rows, err := db.ListSynonyms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load synonyms", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": rows, "count": len(rows)})
+}
+
+// putSynonyms replaces the synonym dictionary with the uploaded entries
+func putSynonyms(c *gin.Context) {
+	var req struct {
+		Entries []synonyms.Entry `json:"entries"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	rows := make([]models.Synonym, 0, len(req.Entries))
+	seen := map[string]bool{}
+	for _, e := range req.Entries {
+		term := strings.TrimSpace(e.Term)
+		if term == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Every entry needs a term"})
+			return
+		}
+		if seen[strings.ToLower(term)] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate term", "details": term})
+			return
+		}
+		seen[strings.ToLower(term)] = true
+		rows = append(rows, models.Synonym{Term: term, Aliases: models.JSONStringArray(e.Aliases)})
+	}
+	if err := db.ReplaceSynonyms(rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store synonyms", "details": err.Error()})
+		return
+	}
+	if err := reloadSynonyms(); err != nil {
+		log.Printf("Warning: failed to reload synonym dictionary: %v", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Synonyms updated", "count": len(rows)})
+}
+
+// lockVideo places a video under legal hold
+func lockVideo(c *gin.Context) {
+	setVideoLock(c, true)
+}
+
+// unlockVideo lifts a video's legal hold
+func unlockVideo(c *gin.Context) {
+	setVideoLock(c, false)
+}
+
+func setVideoLock(c *gin.Context, locked bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	var req struct {
+		Reason *string `json:"reason"`
+	}
+	if locked && c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	if err := db.SetVideoLock(uint(id), locked, req.Reason); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update lock", "details": err.Error()})
+		return
+	}
+	log.Printf("Video %d legal hold set to %v", id, locked)
+	c.JSON(http.StatusOK, gin.H{"video_id": id, "locked": locked, "reason": req.Reason})
+}
+
+// getConsistencyReport runs the pipeline consistency checker and returns the anomalies found
+func getConsistencyReport(c *gin.Context) {
+	report, err := videoProcessor.CheckConsistency(false, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Consistency check failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// repairConsistency enqueues a consistency check job that repairs the selected anomaly categories
+func repairConsistency(c *gin.Context) {
+	var req struct {
+		Categories []string `json:"categories"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	for _, cat := range req.Categories {
+		switch cat {
+		case models.AnomalyMissingEmbeddings, models.AnomalyUnlinkedCaptions, models.AnomalySceneCountMismatch, models.AnomalyZombieProcessing:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown anomaly category", "details": cat})
+			return
+		}
+	}
+	categories := make([]interface{}, 0, len(req.Categories))
+	for _, cat := range req.Categories {
+		categories = append(categories, cat)
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeConsistencyCheck, map[string]interface{}{
+		"repair":     true,
+		"categories": categories,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Consistency repair job created", "job": job})
+}
+
+// listVectorIndexes reports the ANN index of each scene embedding column against its configuration
+func listVectorIndexes(c *gin.Context) {
+	indexes, err := db.VectorIndexStatuses()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read vector indexes", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"indexes": indexes})
+}
+
+// rebuildVectorIndexes enqueues a vector_index job for some modalities ({"modalities": ["text"]},
+// default all); missing and outdated indexes are built, current ones too with {"force": true}
+func rebuildVectorIndexes(c *gin.Context) {
+	var req struct {
+		Modalities []string `json:"modalities"`
+		Force      bool     `json:"force"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	for _, m := range req.Modalities {
+		if _, ok := database.ModalityColumn(m); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid modality", "details": m})
+			return
+		}
+	}
+	payload := map[string]interface{}{"force": req.Force}
+	if len(req.Modalities) > 0 {
+		payload["modalities"] = req.Modalities
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeVectorIndex, payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Vector index job created", "job": job})
+}
+
+// reconcileCounts enqueues a count reconciliation job for one video ({"video_id": n}) or the library
+func reconcileCounts(c *gin.Context) {
+	var req struct {
+		VideoID uint `json:"video_id"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	payload := map[string]interface{}{}
+	if req.VideoID != 0 {
+		if _, err := db.GetVideoByID(req.VideoID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+			return
+		}
+		payload["video_id"] = req.VideoID
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeCountReconciliation, payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Count reconciliation job created", "job": job})
+}
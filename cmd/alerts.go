@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/notify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+// defaultAlertThreshold is the minimum similarity of semantic alert matches when none is given
+const defaultAlertThreshold = 0.8
+
+// alertRequest is the body of alert create and update requests; omitted fields keep their value on update
+type alertRequest struct {
+	Name       *string  `json:"name"`
+	AlertType  *string  `json:"alert_type"`
+	Query      *string  `json:"query"`
+	Level      *string  `json:"level"`
+	Threshold  *float64 `json:"threshold"`
+	WebhookURL *string  `json:"webhook_url"`
+	Emails     []string `json:"emails"`
+	Active     *bool    `json:"active"`
+}
+
+// applyTo validates the request and copies its fields onto alert, writing a 400 when invalid. The
+// query embedding of semantic alerts is (re)computed when the query or type changes.
+func (r alertRequest) applyTo(c *gin.Context, alert *models.Alert) bool {
+	reembed := false
+	if r.Name != nil {
+		alert.Name = strings.TrimSpace(*r.Name)
+	}
+	if r.AlertType != nil {
+		reembed = reembed || *r.AlertType != alert.AlertType
+		alert.AlertType = *r.AlertType
+	}
+	if r.Query != nil {
+		q := strings.TrimSpace(*r.Query)
+		reembed = reembed || q != alert.Query
+		alert.Query = q
+	}
+	if r.Level != nil {
+		alert.Level = database.SceneLevelOrDefault(*r.Level)
+	}
+	if r.Threshold != nil {
+		alert.Threshold = *r.Threshold
+	}
+	if r.WebhookURL != nil {
+		if u := strings.TrimSpace(*r.WebhookURL); u != "" {
+			alert.WebhookURL = &u
+		} else {
+			alert.WebhookURL = nil
+		}
+	}
+	if r.Emails != nil {
+		alert.Emails = models.JSONStringArray{}
+		for _, e := range r.Emails {
+			if e = strings.TrimSpace(e); e != "" {
+				alert.Emails = append(alert.Emails, e)
+			}
+		}
+	}
+	if r.Active != nil {
+		alert.Active = *r.Active
+	}
+
+	switch {
+	case alert.Name == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing name"})
+		return false
+	case alert.Query == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing query"})
+		return false
+	case alert.AlertType != models.AlertTypeKeyword && alert.AlertType != models.AlertTypeSemantic:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert_type", "details": "alert_type must be keyword or semantic"})
+		return false
+	case !models.ValidSceneLevel(alert.Level):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return false
+	case alert.Threshold < 0 || alert.Threshold > 1:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid threshold", "details": "must be between 0 and 1"})
+		return false
+	}
+	if alert.WebhookURL != nil {
+		u, err := url.Parse(*alert.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook_url", "details": "must be an http(s) URL"})
+			return false
+		}
+	}
+	for _, e := range alert.Emails {
+		if _, err := mail.ParseAddress(e); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email", "details": e})
+			return false
+		}
+	}
+	if len(alert.Emails) > 0 && !notify.EmailConfigured() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email notifications are not configured", "details": "set SMTP_HOST"})
+		return false
+	}
+
+	if alert.AlertType != models.AlertTypeSemantic {
+		alert.Embedding = nil
+		return true
+	}
+	if reembed || alert.Embedding == nil {
+		vec, err := embedTextQuery(alert.Query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+			return false
+		}
+		v := pgvector.NewVector(vec)
+		alert.Embedding = &v
+	}
+	return true
+}
+
+// createAlert registers a standing alert evaluated against every new ingest:
+// {"name": "Port strikes", "alert_type": "semantic", "query": "dock workers on strike", "webhook_url": "https://..."}
+func createAlert(c *gin.Context) {
+	var req alertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	alert := &models.Alert{Level: models.SceneLevelShot, Active: true, Emails: models.JSONStringArray{}}
+	if req.AlertType != nil && *req.AlertType == models.AlertTypeSemantic && req.Threshold == nil {
+		alert.Threshold = defaultAlertThreshold
+	}
+	if !req.applyTo(c, alert) {
+		return
+	}
+	if err := db.CreateAlert(alert); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alert", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"alert": alert})
+}
+
+// alertFromParam loads the alert addressed by :id, writing an error response when it cannot
+func alertFromParam(c *gin.Context) (*models.Alert, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return nil, false
+	}
+	alert, err := db.GetAlert(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load alert", "details": err.Error()})
+		return nil, false
+	}
+	return alert, true
+}
+
+// getAlert returns one alert
+func getAlert(c *gin.Context) {
+	alert, ok := alertFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alert": alert})
+}
+
+// listAlerts returns all alerts, or only active ones with ?active=true
+func listAlerts(c *gin.Context) {
+	activeOnly, _ := strconv.ParseBool(c.Query("active"))
+	alerts, err := db.ListAlerts(activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list alerts", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts, "count": len(alerts)})
+}
+
+// updateAlert changes an alert's definition, recipients or active state
+func updateAlert(c *gin.Context) {
+	alert, ok := alertFromParam(c)
+	if !ok {
+		return
+	}
+	var req alertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if !req.applyTo(c, alert) {
+		return
+	}
+	if err := db.UpdateAlert(alert); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alert": alert})
+}
+
+// deleteAlert removes an alert and its recorded matches
+func deleteAlert(c *gin.Context) {
+	alert, ok := alertFromParam(c)
+	if !ok {
+		return
+	}
+	if err := db.DeleteAlert(alert.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert deleted successfully", "id": alert.ID})
+}
+
+// listAlertMatches returns an alert's matches newest first with their delivery state
+func listAlertMatches(c *gin.Context) {
+	alert, ok := alertFromParam(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	matches, total, err := db.ListAlertMatches(alert.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list alert matches", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"alert_id": alert.ID,
+		"matches":  matches,
+		"pagination": gin.H{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(matches),
+		},
+	})
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordSearchEvent logs an executed search for analytics and returns its ID (0 if logging failed).
+// Failures are logged only; analytics must never break search.
+func recordSearchEvent(modality, query string, filters map[string]any, started time.Time, sceneIDs []uint) uint {
+	ev := &models.SearchEvent{
+		Modality:       modality,
+		Query:          query,
+		Filters:        models.JSONObject(filters),
+		LatencyMs:      float64(time.Since(started).Microseconds()) / 1000.0,
+		ResultCount:    len(sceneIDs),
+		ResultSceneIDs: models.JSONUintArray(sceneIDs),
+	}
+	if err := db.CreateSearchEvent(ev); err != nil {
+		log.Printf("Warning: failed to record search event: %v", err)
+		return 0
+	}
+	return ev.ID
+}
+
+// sceneIDsOf returns the IDs of scenes in result order
+func sceneIDsOf(scenes []models.Scene) []uint {
+	ids := make([]uint, 0, len(scenes))
+	for _, s := range scenes {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// getSearchAnalytics returns top queries, zero-result queries and per-modality CTR/latency
+func getSearchAnalytics(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	analytics, err := db.GetSearchAnalytics(since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch search analytics", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, analytics)
+}
+
+// maxTimeseriesDays bounds the date range of one time-series request
+const maxTimeseriesDays = 731
+
+// getStatsTimeseries returns daily library snapshots (size, hours, embedding coverage, ingest and
+// search volume) for ?from=YYYY-MM-DD&to=YYYY-MM-DD (default the last 30 days). Today's point is
+// recomputed on each request and marked partial.
+func getStatsTimeseries(c *gin.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, from := today, today.AddDate(0, 0, -29)
+	var err error
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to", "details": "use YYYY-MM-DD"})
+			return
+		}
+		from = to.AddDate(0, 0, -29)
+	}
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from", "details": "use YYYY-MM-DD"})
+			return
+		}
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "details": "from is after to"})
+		return
+	}
+	if to.Sub(from) >= maxTimeseriesDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "details": "at most 731 days"})
+		return
+	}
+	if !to.Before(today) && !from.After(today) {
+		if _, err := db.SnapshotLibrary(today); err != nil {
+			log.Printf("Warning: failed to snapshot library: %v", err)
+		}
+	}
+	snaps, err := db.ListLibrarySnapshots(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stats time series", "details": err.Error()})
+		return
+	}
+	points := make([]gin.H, len(snaps))
+	for i, s := range snaps {
+		points[i] = gin.H{"date": s.Day.Format("2006-01-02"), "partial": !s.Day.Before(today), "snapshot": s}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":   from.Format("2006-01-02"),
+		"to":     to.Format("2006-01-02"),
+		"points": points,
+		"count":  len(points),
+	})
+}
+
+// postSearchFeedback records which result a user opened or exported for a query. With a search_id
+// the query defaults to the one of that search.
+func postSearchFeedback(c *gin.Context) {
+	var req struct {
+		SearchID *uint  `json:"search_id"`
+		Query    string `json:"query"`
+		SceneID  uint   `json:"scene_id"`
+		Action   string `json:"action"`
+		Position *int   `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if req.SceneID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scene_id is required"})
+		return
+	}
+	if req.Action == "" {
+		req.Action = "open"
+	}
+	if req.Action != "open" && req.Action != "export" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be one of: open, export"})
+		return
+	}
+	if req.SearchID != nil && *req.SearchID == 0 {
+		req.SearchID = nil
+	}
+	if req.SearchID != nil {
+		ev, err := db.GetSearchEvent(*req.SearchID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Search not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch search", "details": err.Error()})
+			return
+		}
+		if req.Query == "" {
+			req.Query = ev.Query
+		}
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query or search_id is required"})
+		return
+	}
+	if _, err := db.GetSceneByID(req.SceneID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scene", "details": err.Error()})
+		return
+	}
+	fb := &models.SearchFeedback{
+		SearchEventID: req.SearchID,
+		Query:         req.Query,
+		SceneID:       req.SceneID,
+		Action:        req.Action,
+		Position:      req.Position,
+	}
+	if err := db.CreateSearchFeedback(fb); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record feedback", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Feedback recorded", "feedback": fb})
+}
+
+// popularityWeight resolves the popularity prior weight from the request or
+// SEARCH_POPULARITY_WEIGHT (default 0, which disables the prior)
+func popularityWeight(override *float64) float64 {
+	if override != nil {
+		return *override
+	}
+	if v := os.Getenv("SEARCH_POPULARITY_WEIGHT"); v != "" {
+		if w, err := strconv.ParseFloat(v, 64); err == nil {
+			return w
+		}
+	}
+	return 0
+}
+
+// popularityOrder returns the result order after adding weight*log(1+feedback count) to each
+// higher-is-better score, plus the feedback counts used. With weight <= 0 the order is unchanged.
+func popularityOrder(query string, scenes []models.Scene, scores []float64, weight float64) ([]int, map[uint]int) {
+	order := make([]int, len(scenes))
+	for i := range order {
+		order[i] = i
+	}
+	if weight <= 0 || query == "" || len(scenes) == 0 {
+		return order, nil
+	}
+	counts, err := db.GetScenePopularity(query, sceneIDsOf(scenes))
+	if err != nil {
+		log.Printf("Warning: failed to load popularity prior: %v", err)
+		return order, nil
+	}
+	boosted := make([]float64, len(scenes))
+	for i, s := range scenes {
+		boosted[i] = scores[i] + weight*math.Log1p(float64(counts[s.ID]))
+	}
+	sort.SliceStable(order, func(a, b int) bool { return boosted[order[a]] > boosted[order[b]] })
+	return order, counts
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// annotationRequest is the body of annotation create and update requests; omitted fields keep their
+// value on update. The range is either start_time/end_time or the current range of scene_index.
+type annotationRequest struct {
+	StartTime  *float64 `json:"start_time"`
+	EndTime    *float64 `json:"end_time"`
+	SceneIndex *int     `json:"scene_index"`
+	Level      *string  `json:"level"`
+	Kind       *string  `json:"kind"`
+	Collection *string  `json:"collection"`
+	Text       *string  `json:"text"`
+	Author     *string  `json:"author"`
+}
+
+// optionalText trims s, mapping an empty result to nil
+func optionalText(s string) *string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return &s
+}
+
+// applyTo validates the request and copies its fields onto a, writing an error response when invalid
+func (r annotationRequest) applyTo(c *gin.Context, a *models.Annotation, video *models.Video) bool {
+	if r.Level != nil {
+		a.Level = database.SceneLevelOrDefault(*r.Level)
+	}
+	if !models.ValidSceneLevel(a.Level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return false
+	}
+	if r.SceneIndex != nil {
+		scene, err := db.GetSceneByVideoAndIndex(video.ID, a.Level, *r.SceneIndex)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found"})
+			return false
+		}
+		a.StartTime, a.EndTime = scene.StartTime, scene.EndTime
+	}
+	if r.StartTime != nil {
+		a.StartTime = *r.StartTime
+	}
+	if r.EndTime != nil {
+		a.EndTime = *r.EndTime
+	} else if a.EndTime < a.StartTime {
+		a.EndTime = a.StartTime // a point annotation
+	}
+	if r.Kind != nil {
+		a.Kind = *r.Kind
+	}
+	if r.Collection != nil {
+		a.Collection = optionalText(*r.Collection)
+	}
+	if r.Text != nil {
+		a.Text = optionalText(*r.Text)
+	}
+	if r.Author != nil {
+		a.Author = optionalText(*r.Author)
+	}
+
+	if a.StartTime < 0 || a.EndTime < a.StartTime || (video.Duration > 0 && a.StartTime > video.Duration) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "details": "need 0 <= start_time <= end_time, within the video"})
+		return false
+	}
+	switch a.Kind {
+	case models.AnnotationKindNote:
+		if a.Text == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "text is required for notes"})
+			return false
+		}
+	case models.AnnotationKindFavorite:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind", "details": "kind must be note or favorite"})
+		return false
+	}
+	return true
+}
+
+// createAnnotation anchors a note or favorite to a time range of a video:
+// {"scene_index": 4, "kind": "favorite", "collection": "opening montage"} or
+// {"start_time": 12.5, "end_time": 18, "kind": "note", "text": "logo visible"}
+func createAnnotation(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	var req annotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if req.SceneIndex == nil && req.StartTime == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scene_index or start_time is required"})
+		return
+	}
+	a := &models.Annotation{VideoID: video.ID, Level: models.SceneLevelShot, Kind: models.AnnotationKindNote}
+	if !req.applyTo(c, a, video) {
+		return
+	}
+	if err := db.CreateAnnotation(a); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create annotation", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"annotation": a})
+}
+
+// annotationFilterFromQuery reads the kind and collection filters
+func annotationFilterFromQuery(c *gin.Context) database.AnnotationFilter {
+	return database.AnnotationFilter{Kind: c.Query("kind"), Collection: strings.TrimSpace(c.Query("collection"))}
+}
+
+// listVideoAnnotations returns a video's annotations in time order (?kind=, ?collection=)
+func listVideoAnnotations(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	filter := annotationFilterFromQuery(c)
+	filter.VideoID = video.ID
+	list, err := db.ListAnnotations(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list annotations", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotations": list, "count": len(list)})
+}
+
+// listAnnotations returns annotations across the library (?kind=favorite, ?collection=, ?video_id=)
+func listAnnotations(c *gin.Context) {
+	filter := annotationFilterFromQuery(c)
+	if v := c.Query("video_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		filter.VideoID = uint(id)
+	}
+	list, err := db.ListAnnotations(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list annotations", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotations": list, "count": len(list)})
+}
+
+// listAnnotationCollections returns the collection names in use with their sizes
+func listAnnotationCollections(c *gin.Context) {
+	collections, err := db.ListAnnotationCollections()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list collections", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collections": collections, "count": len(collections)})
+}
+
+// annotationFromParam loads the annotation addressed by :id, writing an error response when it cannot
+func annotationFromParam(c *gin.Context) (*models.Annotation, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid annotation ID"})
+		return nil, false
+	}
+	a, err := db.GetAnnotation(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Annotation not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load annotation", "details": err.Error()})
+		return nil, false
+	}
+	return a, true
+}
+
+// getAnnotation returns one annotation with its current scene
+func getAnnotation(c *gin.Context) {
+	a, ok := annotationFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotation": a})
+}
+
+// updateAnnotation changes an annotation's range, kind, collection or text
+func updateAnnotation(c *gin.Context) {
+	a, ok := annotationFromParam(c)
+	if !ok {
+		return
+	}
+	var req annotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	video, err := db.GetVideoByID(a.VideoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	if !req.applyTo(c, a, video) {
+		return
+	}
+	if err := db.UpdateAnnotation(a); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update annotation", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotation": a})
+}
+
+// deleteAnnotation removes an annotation
+func deleteAnnotation(c *gin.Context) {
+	a, ok := annotationFromParam(c)
+	if !ok {
+		return
+	}
+	if err := db.DeleteAnnotation(a.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete annotation", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Annotation deleted successfully", "id": a.ID})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/processor"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// artifactRestoreRetryAfter is the Retry-After, in seconds, of a download waiting for its file to
+// come back from cold storage
+const artifactRestoreRetryAfter = 30
+
+// artifactRestoreTimeout is how long a restore may take before the next download claims a new one
+// (ARTIFACT_RESTORE_TIMEOUT_MINS, default 60), e.g. when its job was lost before it ran
+func artifactRestoreTimeout() time.Duration {
+	if mins, err := strconv.Atoi(os.Getenv("ARTIFACT_RESTORE_TIMEOUT_MINS")); err == nil && mins > 0 {
+		return time.Duration(mins) * time.Minute
+	}
+	return time.Hour
+}
+
+// restoreJobSettled reports whether the job restoring an artifact can no longer bring it back: it
+// finished, failed or was cancelled, or is gone
+func restoreJobSettled(a *models.Artifact) bool {
+	if a.RestoreJobID == nil {
+		return false
+	}
+	job, err := jobQueue.GetJob(*a.RestoreJobID)
+	if err != nil {
+		return true
+	}
+	return job.Status != queue.JobStatusPending && job.Status != queue.JobStatusRunning
+}
+
+// serveArtifact serves an export's file, recording the download for storage tiering. A file moved to
+// cold storage is not served: the first download queues an artifact_restore job and every download
+// answers 202 with that job until the file is back. A restore whose job settled without restoring
+// the file, or that exceeded artifactRestoreTimeout, is replaced by a new one.
+func serveArtifact(c *gin.Context, kind string, ownerID uint, path, filename string) {
+	a, err := db.TouchArtifact(kind, ownerID, path)
+	if err != nil {
+		log.Printf("Warning: failed to record download of %s %d: %v", kind, ownerID, err)
+		c.FileAttachment(path, filename)
+		return
+	}
+	if a.Tier == models.ArtifactTierHot {
+		c.FileAttachment(path, filename)
+		return
+	}
+	if a.Tier == models.ArtifactTierRestoring && restoreJobSettled(a) {
+		if err := db.ReleaseArtifactRestore(a.ID, *a.RestoreJobID); err != nil {
+			log.Printf("Warning: failed to release restore of artifact %d: %v", a.ID, err)
+		}
+	}
+	claimed, err := db.ClaimArtifactRestore(a.ID, time.Now().Add(-artifactRestoreTimeout()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore file", "details": err.Error()})
+		return
+	}
+	if claimed {
+		job, err := jobQueue.Enqueue(queue.JobTypeArtifactRestore, map[string]interface{}{"artifact_id": a.ID})
+		if err != nil {
+			db.ReleaseArtifactRestore(a.ID, "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+			return
+		}
+		if err := db.SetArtifactRestoreJob(a.ID, job.ID); err != nil {
+			log.Printf("Warning: failed to record restore job of artifact %d: %v", a.ID, err)
+		}
+		a.RestoreJobID = &job.ID
+	} else if reloaded, err := db.GetArtifact(a.ID); err == nil {
+		// Its restore may have finished meanwhile
+		if reloaded.Tier == models.ArtifactTierHot {
+			c.FileAttachment(path, filename)
+			return
+		}
+		a = reloaded
+	}
+	resp := gin.H{"status": models.ArtifactTierRestoring, "message": "File is being restored from cold storage; retry later"}
+	if a.RestoreJobID != nil {
+		resp["job_id"] = *a.RestoreJobID
+		resp["events_url"] = fmt.Sprintf("/api/v1/jobs/%s/events", *a.RestoreJobID)
+	}
+	c.Header("Retry-After", strconv.Itoa(artifactRestoreRetryAfter))
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// getStorageTiers counts artifacts and their sizes by kind and storage tier (admin)
+func getStorageTiers(c *gin.Context) {
+	stats, err := db.ArtifactTierStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read artifacts", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tiers": stats, "cold_storage": processor.ColdStorageEnabled()})
+}
+
+// runStorageTiering enqueues a storage_tiering job now, outside the nightly schedule (admin)
+func runStorageTiering(c *gin.Context) {
+	if !processor.ColdStorageEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cold storage is not configured", "details": "set COLD_STORAGE_DIR"})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeStorageTiering, map[string]interface{}{"manual": true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Storage tiering job created", "job": job})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"goodclips-server/internal/processor"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// captionTimeParam reads an optional time bound in seconds, writing an error response when it is invalid
+func captionTimeParam(c *gin.Context, name string) (*float64, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	t, err := strconv.ParseFloat(v, 64)
+	if err != nil || t < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name, "details": "must be a non-negative number of seconds"})
+		return nil, false
+	}
+	return &t, true
+}
+
+// listVideoCaptions pages through a video's captions by start time, optionally only those overlapping
+// a playback window (?start=120&end=180, ?limit=200, ?offset=0)
+func listVideoCaptions(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	start, ok := captionTimeParam(c, "start")
+	if !ok {
+		return
+	}
+	end, ok := captionTimeParam(c, "end")
+	if !ok {
+		return
+	}
+	if start != nil && end != nil && *end <= *start {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "details": "end must be after start"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if err != nil || limit <= 0 {
+		limit = 200
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	captions, total, err := db.ListCaptions(video.ID, start, end, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list captions", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"video_id":      video.ID,
+		"start":         start,
+		"end":           end,
+		"captions":      captions,
+		"subtitles_url": videoSubtitlesURL(requestedVideoID(c), ""),
+		"pagination": gin.H{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(captions),
+		},
+	})
+}
+
+// syncVideoCaptions enqueues a caption_sync job that shifts the video's captions onto its speech:
+// {"mode": "piecewise"} (or "global"; the body is optional)
+func syncVideoCaptions(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	if req.Mode != "" && !processor.ValidCaptionSyncMode(req.Mode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode", "details": "mode must be global or piecewise"})
+		return
+	}
+	if video.Locked {
+		c.JSON(http.StatusLocked, gin.H{"error": "Video is locked", "details": "captions cannot be retimed under a legal hold"})
+		return
+	}
+	if video.CaptionCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video has no captions"})
+		return
+	}
+	payload := map[string]interface{}{"video_id": video.ID}
+	if req.Mode != "" {
+		payload["mode"] = req.Mode
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeCaptionSync, payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Caption sync job created", "job": job})
+}
+
+// getCaptionQAReport returns a video's caption quality report, computing it when the video has none
+// yet or ?refresh=true
+func getCaptionQAReport(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	report, err := db.GetCaptionQAReport(video.ID)
+	if err == nil && c.Query("refresh") != "true" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load caption QA report", "details": err.Error()})
+		return
+	}
+	report, err = videoProcessor.RefreshCaptionQA(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute caption QA report", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// listCaptionQAReports lists videos by caption quality, worst first, so curators know where better
+// subtitles are needed (?limit=50, ?offset=0)
+func listCaptionQAReports(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	reports, total, err := db.ListCaptionQAReports(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list caption QA reports", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"videos": reports,
+		"pagination": gin.H{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(reports),
+		},
+	})
+}
+
+// refreshCaptionQA enqueues a caption_qa job recomputing every video's report
+func refreshCaptionQA(c *gin.Context) {
+	job, err := jobQueue.Enqueue(queue.JobTypeCaptionQA, map[string]interface{}{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Caption QA job created", "job": job})
+}
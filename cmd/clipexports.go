@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// clipExportResponse adds a signed download URL to completed clip exports
+func clipExportResponse(e *models.ClipExport) gin.H {
+	resp := gin.H{"clip": e}
+	if e.ExportPath != nil {
+		resp["download_url"] = signedArtifactURL(fmt.Sprintf("/api/v1/clips/%d/video", e.ID))
+	}
+	exportProgress(resp, e.Status, e.JobID)
+	return resp
+}
+
+// exportSceneClip enqueues a clip_export job cutting a scene out of its video into an MP4. The cut
+// edges take the pad_before, pad_after, snap and max_snap query parameters of the clip bounds
+// endpoint; the optional body picks the method and censoring: {"mode": "auto", "censor": "bleep"}
+func exportSceneClip(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scene ID"})
+		return
+	}
+	var req struct {
+		// Mode is auto (default: stream copy when possible), copy or reencode
+		Mode   string `json:"mode"`
+		Censor string `json:"censor"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	if req.Mode == "" {
+		req.Mode = models.ClipExportAuto
+	}
+	if !models.ValidClipExportMode(req.Mode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode", "details": "mode must be auto, copy or reencode"})
+		return
+	}
+	if !ffmpeg.ValidCensorMode(req.Censor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid censor", "details": "censor must be mute or bleep"})
+		return
+	}
+	if req.Mode == models.ClipExportCopy && req.Censor != ffmpeg.CensorNone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode", "details": "censoring requires a re-encode"})
+		return
+	}
+	opts, ok := clipBoundsOptions(c)
+	if !ok {
+		return
+	}
+	scene, err := db.GetSceneByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scene", "details": err.Error()})
+		return
+	}
+	video, err := db.GetVideoByID(scene.VideoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	bounds, _, err := refineSceneClip(scene, video, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refine clip bounds", "details": err.Error()})
+		return
+	}
+
+	e := &models.ClipExport{
+		SceneID:   scene.ID,
+		VideoID:   video.ID,
+		StartTime: bounds.Start,
+		EndTime:   bounds.End,
+		Mode:      req.Mode,
+		Censor:    req.Censor,
+		Status:    models.HighlightStatusPending,
+	}
+	if err := db.CreateClipExport(e); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create clip export", "details": err.Error()})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeClipExport, map[string]interface{}{"clip_id": e.ID})
+	if err != nil {
+		msg := err.Error()
+		e.Status = models.HighlightStatusFailed
+		e.ErrorMessage = &msg
+		db.UpdateClipExport(e)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue clip export job", "details": err.Error()})
+		return
+	}
+	e.JobID = job.ID
+	if err := db.UpdateClipExport(e); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update clip export", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, clipExportResponse(e))
+}
+
+// clipExportFromParam loads the clip export addressed by :id, writing an error response when it cannot
+func clipExportFromParam(c *gin.Context) (*models.ClipExport, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid clip ID"})
+		return nil, false
+	}
+	e, err := db.GetClipExport(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Clip not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load clip", "details": err.Error()})
+		return nil, false
+	}
+	return e, true
+}
+
+// getClipExport returns a clip export's status and, once cut, its download URL
+func getClipExport(c *gin.Context) {
+	e, ok := clipExportFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, clipExportResponse(e))
+}
+
+// downloadClipExport serves an exported clip (signed URL required)
+func downloadClipExport(c *gin.Context) {
+	e, ok := clipExportFromParam(c)
+	if !ok {
+		return
+	}
+	if e.ExportPath == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Clip has not been exported"})
+		return
+	}
+	serveArtifact(c, models.ArtifactClipExport, e.ID, *e.ExportPath, fmt.Sprintf("video_%d_scene_%d.mp4", e.VideoID, e.SceneID))
+}
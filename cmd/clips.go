@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clipBoundsOptions starts from the CLIP_* environment defaults and applies the pad_before, pad_after,
+// snap and max_snap query overrides, writing an error response when one is invalid
+func clipBoundsOptions(c *gin.Context) (ffmpeg.ClipBoundsOptions, bool) {
+	opts := ffmpeg.ClipBoundsOptionsFromEnv()
+	for name, dst := range map[string]*float64{
+		"pad_before": &opts.PadBefore,
+		"pad_after":  &opts.PadAfter,
+		"max_snap":   &opts.MaxSnapShift,
+	} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 60 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name, "details": "must be between 0 and 60 seconds"})
+			return opts, false
+		}
+		*dst = f
+	}
+	if v := c.Query("snap"); v != "" {
+		if !ffmpeg.ValidSnapMode(v) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snap", "details": "snap must be none, captions or silence"})
+			return opts, false
+		}
+		opts.Snap = v
+	}
+	return opts, true
+}
+
+// silenceDetectSettings reads CLIP_SILENCE_DB (default -35) and CLIP_SILENCE_MIN_SECS (default 0.2)
+func silenceDetectSettings() (float64, float64) {
+	noise, minDur := -35.0, 0.2
+	if v := os.Getenv("CLIP_SILENCE_DB"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			noise = f
+		}
+	}
+	if v := os.Getenv("CLIP_SILENCE_MIN_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			minDur = f
+		}
+	}
+	return noise, minDur
+}
+
+// refineSceneClip pads the scene's range and snaps its edges to the guides opts.Snap selects,
+// falling back to padding alone (and SnapNone in the returned options) when silence detection fails
+func refineSceneClip(scene *models.Scene, video *models.Video, opts ffmpeg.ClipBoundsOptions) (ffmpeg.ClipBounds, ffmpeg.ClipBoundsOptions, error) {
+	// Guides only matter within reach of the padded edges
+	lo := scene.StartTime - opts.PadBefore - opts.MaxSnapShift
+	hi := scene.EndTime + opts.PadAfter + opts.MaxSnapShift
+	if lo < 0 {
+		lo = 0
+	}
+	if video.Duration > 0 && hi > video.Duration {
+		hi = video.Duration
+	}
+	var guides []ffmpeg.Interval
+	switch opts.Snap {
+	case ffmpeg.SnapCaptions:
+		captions, err := db.GetCaptionsInRange(video.ID, lo, hi)
+		if err != nil {
+			return ffmpeg.ClipBounds{}, opts, fmt.Errorf("failed to load captions: %v", err)
+		}
+		for _, cp := range captions {
+			guides = append(guides, ffmpeg.Interval{Start: cp.StartTime, End: cp.EndTime})
+		}
+	case ffmpeg.SnapSilence:
+		noise, minDur := silenceDetectSettings()
+		var err error
+		guides, err = ffmpeg.NewFFmpegClient().DetectSilences(video.Filepath, lo, hi, noise, minDur)
+		if err != nil {
+			// Padding alone still gives a usable cut
+			log.Printf("Warning: silence detection failed for video %d: %v", video.ID, err)
+			opts.Snap = ffmpeg.SnapNone
+		}
+	}
+	return ffmpeg.RefineClipBounds(scene.StartTime, scene.EndTime, video.Duration, opts, guides), opts, nil
+}
+
+// getSceneClipBounds returns the padded, optionally snapped edges an export of the scene should cut at
+func getSceneClipBounds(c *gin.Context) {
+	scene, ok := sceneFromParams(c)
+	if !ok {
+		return
+	}
+	opts, ok := clipBoundsOptions(c)
+	if !ok {
+		return
+	}
+	video, err := db.GetVideoByID(scene.VideoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	bounds, opts, err := refineSceneClip(scene, video, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refine clip bounds", "details": err.Error()})
+		return
+	}
+
+	// Flagged ranges inside the clip, for exporters that mute or bleep them
+	flags, err := db.GetContentFlagsInRange(video.ID, bounds.Start, bounds.End, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load content flags", "details": err.Error()})
+		return
+	}
+	muteRanges := make([]gin.H, 0, len(flags))
+	for _, f := range flags {
+		muteRanges = append(muteRanges, gin.H{"start": f.StartTime, "end": f.EndTime, "category": f.Category})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"video_id":    video.ID,
+		"scene_id":    scene.ID,
+		"scene_index": scene.SceneIndex,
+		"level":       scene.Level,
+		"scene_start": scene.StartTime,
+		"scene_end":   scene.EndTime,
+		"options":     opts,
+		"clip":        bounds,
+		"mute_ranges": muteRanges,
+	})
+}
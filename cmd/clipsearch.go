@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchClipBytes caps video clips uploaded to /search/video-clip
+const maxSearchClipBytes = 200 << 20
+
+// searchVideoClip finds the scenes a short uploaded clip comes from, e.g. the source of a meme:
+// the clip is embedded as one scene by the visual embedding runner (IV2 or InternVL3.5) and
+// matched against scenes.visual_embedding. It takes a multipart form with a "video" file and the
+// search options as JSON in an optional "options" field.
+func searchVideoClip(c *gin.Context) {
+	started := time.Now()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSearchClipBytes+1<<20)
+	fh, err := c.FormFile("video")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "video file is required", "details": err.Error()})
+		return
+	}
+	if fh.Size > maxSearchClipBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Clip too large", "details": fmt.Sprintf("clips are limited to %d bytes", maxSearchClipBytes)})
+		return
+	}
+	var opts sceneSearchOptions
+	if raw := c.PostForm("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid options", "details": err.Error()})
+			return
+		}
+	}
+	filter, limit, ok := opts.filter(c)
+	if !ok {
+		return
+	}
+
+	// The runners read from disk; keep the extension so the container is recognised
+	tmp, err := os.CreateTemp("", "query_clip_*"+filepath.Ext(fh.Filename))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stage clip", "details": err.Error()})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	src, err := fh.Open()
+	if err == nil {
+		_, err = io.Copy(tmp, src)
+		src.Close()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stage clip", "details": err.Error()})
+		return
+	}
+
+	vec, err := videoProcessor.WithContext(c.Request.Context()).EmbedQueryClip(tmp.Name())
+	if errors.Is(err, processor.ErrQueryClipTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Clip too long", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed clip", "details": err.Error()})
+		return
+	}
+	sceneVectorSearch{
+		modality: "video_clip",
+		column:   database.ColumnVisual,
+		search: func(vec []float32, k int, filter database.SceneFilter) ([]models.Scene, []float64, error) {
+			return db.SearchScenesByVector(database.ColumnVisual, vec, k, filter)
+		},
+	}.respond(c, started, "", vec, opts, filter, limit)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/notify"
+	"goodclips-server/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startedAt is when this process started, reported by the config endpoint
+var startedAt = time.Now()
+
+// configured reports whether a secret is set without revealing it
+func configured(env string) bool {
+	return os.Getenv(env) != ""
+}
+
+// getAdminConfig returns the effective configuration of this API process with secrets reduced to
+// whether they are set (admin). Workers read the same variables; values differ only when their
+// environment does.
+func getAdminConfig(c *gin.Context) {
+	dbc := database.GetDefaultConfig()
+	addr := strings.TrimPrefix(getEnvOrDefault("REDIS_URL", "localhost:6379"), "redis://")
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		addr = addr[i+1:]
+	}
+	failures, window := jobFailureThreshold()
+	c.JSON(http.StatusOK, gin.H{
+		"started_at": startedAt.UTC(),
+		"pipeline":   processor.Settings(),
+		"models":     processor.Models(),
+		"storage": gin.H{
+			"backend":  "local",
+			"database": gin.H{"driver": "postgres", "host": dbc.Host, "port": dbc.Port, "name": dbc.DBName, "user": dbc.User, "sslmode": dbc.SSLMode},
+		},
+		"queue": gin.H{
+			"backend": "redis",
+			"addr":    addr,
+		},
+		"worker": gin.H{
+			// Jobs each worker process runs at once, and the caps on some job types among them
+			"concurrency":     workerConcurrency(),
+			"job_type_limits": jobTypeLimitsFromEnv(),
+		},
+		"search": gin.H{
+			"shortlist_size":      shortlistSize(0),
+			"query_language_mode": getEnvOrDefault("QUERY_LANGUAGE_MODE", "translate"),
+			"popularity_weight":   popularityWeight(nil),
+		},
+		"notifications": gin.H{
+			"public_base_url":    notify.PublicURL(""),
+			"email":              notify.EmailConfigured(),
+			"smtp_host":          os.Getenv("SMTP_HOST"),
+			"webhook_signing":    configured("WEBHOOK_SECRET"),
+			"job_failure_notify": gin.H{"threshold": failures, "window": window.String()},
+		},
+		"security": gin.H{
+			"admin_token":             configured("ADMIN_TOKEN"),
+			"signed_url_keys":         configured("SIGNED_URL_KEYS"),
+			"signed_url_ttl":          signedURLTTL().String(),
+			"idempotency_ttl":         idempotencyTTL().String(),
+			"idempotency_pending_ttl": idempotencyPendingTTL().String(),
+			"error_reporting":         configured("SENTRY_DSN"),
+		},
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listVideoContentFlags returns a video's content flags and the scenes (at ?level=) they fall in
+func listVideoContentFlags(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	level := database.SceneLevelOrDefault(c.Query("level"))
+	if !models.ValidSceneLevel(level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return
+	}
+	video, err := db.GetVideoByID(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	var categories []string
+	if v := c.Query("categories"); v != "" {
+		categories = strings.Split(v, ",")
+	}
+	end := video.Duration
+	if end <= 0 {
+		end = math.MaxFloat32
+	}
+	flags, err := db.GetContentFlagsInRange(video.ID, 0, end, categories)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load content flags", "details": err.Error()})
+		return
+	}
+	scenes, err := db.FlaggedSceneIndexes(video.ID, level)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load flagged scenes", "details": err.Error()})
+		return
+	}
+	flagged := make([]gin.H, 0, len(scenes))
+	for idx, cats := range scenes {
+		flagged = append(flagged, gin.H{"scene_index": idx, "categories": cats})
+	}
+	sort.Slice(flagged, func(i, j int) bool { return flagged[i]["scene_index"].(int) < flagged[j]["scene_index"].(int) })
+	c.JSON(http.StatusOK, gin.H{
+		"video_id":       video.ID,
+		"level":          level,
+		"flags":          flags,
+		"count":          len(flags),
+		"flagged_scenes": flagged,
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"log"
+
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSceneContext caps the number of neighbouring scenes returned on each side of a hit
+const maxSceneContext = 5
+
+// clampSceneContext bounds a requested context window to [0, maxSceneContext]
+func clampSceneContext(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > maxSceneContext {
+		return maxSceneContext
+	}
+	return n
+}
+
+// sceneWithCaptions describes a scene and the captions overlapping it
+func sceneWithCaptions(s models.Scene, captions []models.Caption) gin.H {
+	lines := make([]gin.H, 0)
+	for _, cp := range captions {
+		if cp.StartTime < s.EndTime && cp.EndTime > s.StartTime {
+			lines = append(lines, gin.H{"start_time": cp.StartTime, "end_time": cp.EndTime, "text": cp.Text})
+		}
+	}
+	return gin.H{
+		"id":          s.ID,
+		"scene_index": s.SceneIndex,
+		"start_time":  s.StartTime,
+		"end_time":    s.EndTime,
+		"duration":    s.Duration,
+		"captions":    lines,
+	}
+}
+
+// sceneContext returns the ±n neighbouring scenes (same video and level) around a hit with their
+// captions, plus the hit's own captions, so clients can show lead-in/lead-out without extra calls. A
+// hit reported under a video sharing another's content is looked up in that content.
+func sceneContext(hit models.Scene, n int) (gin.H, error) {
+	contentID, err := db.ContentVideoID(hit.VideoID)
+	if err != nil {
+		return nil, err
+	}
+	window, err := db.GetSceneWindow(contentID, hit.Level, hit.SceneIndex-n, hit.SceneIndex+n)
+	if err != nil {
+		return nil, err
+	}
+	start, end := hit.StartTime, hit.EndTime
+	for _, s := range window {
+		if s.StartTime < start {
+			start = s.StartTime
+		}
+		if s.EndTime > end {
+			end = s.EndTime
+		}
+	}
+	captions, err := db.GetCaptionsInRange(contentID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	before := make([]gin.H, 0, n)
+	after := make([]gin.H, 0, n)
+	for _, s := range window {
+		switch {
+		case s.SceneIndex < hit.SceneIndex:
+			before = append(before, sceneWithCaptions(s, captions))
+		case s.SceneIndex > hit.SceneIndex:
+			after = append(after, sceneWithCaptions(s, captions))
+		}
+	}
+	return gin.H{
+		"before":     before,
+		"after":      after,
+		"captions":   sceneWithCaptions(hit, captions)["captions"],
+		"start_time": start,
+		"end_time":   end,
+	}, nil
+}
+
+// attachSceneContext adds a "context" entry to each result item; items and scenes are parallel
+func attachSceneContext(items []gin.H, scenes []models.Scene, n int) {
+	if n <= 0 {
+		return
+	}
+	for i := range items {
+		ctx, err := sceneContext(scenes[i], n)
+		if err != nil {
+			log.Printf("Warning: failed to load context for scene %d: %v", scenes[i].ID, err)
+			continue
+		}
+		items[i]["context"] = ctx
+	}
+}
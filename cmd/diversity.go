@@ -0,0 +1,205 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// diversityMMR re-ranks results by maximal marginal relevance
+const diversityMMR = "mmr"
+
+// diversityCandidateFactor is how many candidates per result a diversified search fetches, so that
+// enough remain once near-duplicates and capped videos are passed over
+const diversityCandidateFactor = 4
+
+// mmrLambda is the default weight of relevance against novelty in MMR re-ranking
+// (DIVERSITY_MMR_LAMBDA, default 0.7): 1 keeps the relevance order, 0 only seeks variety
+func mmrLambda() float64 {
+	if l, err := strconv.ParseFloat(os.Getenv("DIVERSITY_MMR_LAMBDA"), 64); err == nil && l >= 0 && l <= 1 {
+		return l
+	}
+	return 0.7
+}
+
+// diversityQuery holds the result diversity options shared by scene searches; it is embedded in
+// their request bodies
+type diversityQuery struct {
+	// Diversity is "mmr" to re-rank by maximal marginal relevance: each next result is the candidate
+	// best balancing its relevance against its similarity to the results already picked
+	Diversity string `json:"diversity"`
+	// MMRLambda weighs relevance against novelty (0 to 1, default DIVERSITY_MMR_LAMBDA)
+	MMRLambda *float64 `json:"mmr_lambda"`
+	// MaxPerVideo caps the results from any one video (0: no cap)
+	MaxPerVideo int `json:"max_per_video"`
+}
+
+// validate checks the options, writing a 400 when invalid
+func (dq diversityQuery) validate(c *gin.Context) bool {
+	if dq.Diversity != "" && dq.Diversity != "none" && dq.Diversity != diversityMMR {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid diversity", "details": "diversity must be none or mmr"})
+		return false
+	}
+	if dq.MMRLambda != nil && (*dq.MMRLambda < 0 || *dq.MMRLambda > 1) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mmr_lambda", "details": "mmr_lambda must be between 0 and 1"})
+		return false
+	}
+	if dq.MaxPerVideo < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_per_video", "details": "must not be negative"})
+		return false
+	}
+	return true
+}
+
+// active reports whether the results are diversified at all
+func (dq diversityQuery) active() bool {
+	return dq.Diversity == diversityMMR || dq.MaxPerVideo > 0
+}
+
+// lambda is the effective MMR lambda
+func (dq diversityQuery) lambda() float64 {
+	if dq.MMRLambda != nil {
+		return *dq.MMRLambda
+	}
+	return mmrLambda()
+}
+
+// candidates is how many hits a search with limit results fetches before diversifying
+func (dq diversityQuery) candidates(limit int) int {
+	if !dq.active() {
+		return limit
+	}
+	return limit * diversityCandidateFactor
+}
+
+// diversify picks up to limit of the candidate scenes, given in order as indexes into scenes, best
+// first. Without diversity options that is the first limit. Scenes over the per-video cap are passed
+// over; with MMR each next pick maximizes lambda * relevance - (1 - lambda) * its highest cosine
+// similarity to a scene already picked, in any of the embedding columns both scenes have.
+// relevance holds each scene's score, e.g. 0–1 scores or fused scores, and is scaled by its maximum.
+// If the vectors cannot be loaded the order is only capped per video.
+func (dq diversityQuery) diversify(scenes []models.Scene, order []int, relevance []float64, columns []string, limit int) []int {
+	if !dq.active() {
+		if len(order) > limit {
+			order = order[:limit]
+		}
+		return order
+	}
+	perVideo := map[uint]int{}
+	capped := func(i int) bool {
+		return dq.MaxPerVideo > 0 && perVideo[scenes[i].VideoID] >= dq.MaxPerVideo
+	}
+	picked := make([]int, 0, limit)
+	if dq.Diversity != diversityMMR {
+		for _, i := range order {
+			if len(picked) == limit {
+				break
+			}
+			if !capped(i) {
+				picked = append(picked, i)
+				perVideo[scenes[i].VideoID]++
+			}
+		}
+		return picked
+	}
+
+	ids := make([]uint, 0, len(order))
+	for _, i := range order {
+		ids = append(ids, scenes[i].ID)
+	}
+	var vectors []map[uint][]float32
+	for _, col := range columns {
+		vecs, err := db.SceneVectors(col, ids)
+		if err != nil {
+			log.Printf("Warning: failed to load %s for diversity re-ranking: %v", col, err)
+			return diversityQuery{MaxPerVideo: dq.MaxPerVideo}.diversify(scenes, order, relevance, columns, limit)
+		}
+		vectors = append(vectors, vecs)
+	}
+	similarity := func(a, b uint) float64 {
+		best := 0.0
+		for _, vecs := range vectors {
+			va, ok1 := vecs[a]
+			vb, ok2 := vecs[b]
+			if ok1 && ok2 {
+				best = math.Max(best, cosineSimilarity(va, vb))
+			}
+		}
+		return best
+	}
+
+	maxRel := 0.0
+	for _, i := range order {
+		maxRel = math.Max(maxRel, relevance[i])
+	}
+	lambda := dq.lambda()
+	// redundancy[i] is the candidate's highest similarity to a picked scene
+	redundancy := make(map[int]float64, len(order))
+	remaining := append([]int(nil), order...)
+	for len(picked) < limit && len(remaining) > 0 {
+		best, bestScore := -1, math.Inf(-1)
+		for n, i := range remaining {
+			if capped(i) {
+				continue
+			}
+			rel := relevance[i]
+			if maxRel > 0 {
+				rel /= maxRel
+			}
+			if score := lambda*rel - (1-lambda)*redundancy[i]; score > bestScore {
+				best, bestScore = n, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		i := remaining[best]
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		picked = append(picked, i)
+		perVideo[scenes[i].VideoID]++
+		for _, j := range remaining {
+			redundancy[j] = math.Max(redundancy[j], similarity(scenes[i].ID, scenes[j].ID))
+		}
+	}
+	return picked
+}
+
+// info adds the diversity options and the number of candidates they chose from to a search response
+func (dq diversityQuery) info(resp gin.H, candidates int) {
+	if !dq.active() {
+		return
+	}
+	d := gin.H{"candidates": candidates}
+	if dq.Diversity == diversityMMR {
+		d["mode"] = diversityMMR
+		d["mmr_lambda"] = dq.lambda()
+	}
+	if dq.MaxPerVideo > 0 {
+		d["max_per_video"] = dq.MaxPerVideo
+	}
+	resp["diversity"] = d
+}
+
+// cosineSimilarity is the cosine of the angle between two vectors (0 when either is zero or their
+// lengths differ)
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/processor"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// sceneTextDimensions is the width of scenes.text_embedding; replacement models must match it
+const sceneTextDimensions = 768
+
+// listEmbeddingModels returns the registered models and the model currently serving text search (admin)
+func listEmbeddingModels(c *gin.Context) {
+	list, err := db.ListEmbeddingModels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list embedding models", "details": err.Error()})
+		return
+	}
+	active, err := db.ActiveEmbeddingModel(processor.ModalityText)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load active model", "details": err.Error()})
+		return
+	}
+	if active == nil {
+		builtin := processor.BuiltinTextModel()
+		builtin.Status = models.EmbeddingModelActive
+		active = &builtin
+	}
+	c.JSON(http.StatusOK, gin.H{"models": list, "count": len(list), "active": active})
+}
+
+// registerEmbeddingModel registers a candidate text model (admin): {"name": "e5-large", "model_id": "intfloat/e5-large-v2"}.
+// The model is probed once to check it loads and produces vectors of the scene column's width.
+func registerEmbeddingModel(c *gin.Context) {
+	var req struct {
+		Name     string `json:"name"`
+		ModelID  string `json:"model_id"`
+		Modality string `json:"modality"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	req.Name, req.ModelID = strings.TrimSpace(req.Name), strings.TrimSpace(req.ModelID)
+	if req.Name == "" || req.ModelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and model_id are required"})
+		return
+	}
+	if req.Modality != "" && req.Modality != processor.ModalityText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid modality", "details": "only text embedding models can be upgraded"})
+		return
+	}
+	vec, err := embedTextQueryWithModel("model registration probe", req.ModelID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Model could not be loaded", "details": err.Error()})
+		return
+	}
+	if len(vec) != sceneTextDimensions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incompatible model", "details": "model produces " + strconv.Itoa(len(vec)) + " dimensions; scene text embeddings have " + strconv.Itoa(sceneTextDimensions)})
+		return
+	}
+	m := &models.EmbeddingModel{Name: req.Name, Modality: processor.ModalityText, ModelID: req.ModelID, Dimensions: len(vec), Status: models.EmbeddingModelRegistered}
+	if err := db.CreateEmbeddingModel(m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register model", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"model": m})
+}
+
+// embeddingModelFromParam loads the model addressed by :id, writing an error response when it cannot
+func embeddingModelFromParam(c *gin.Context) (*models.EmbeddingModel, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid model ID"})
+		return nil, false
+	}
+	m, err := db.GetEmbeddingModel(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Embedding model not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load embedding model", "details": err.Error()})
+		return nil, false
+	}
+	return m, true
+}
+
+// getEmbeddingModel returns one model with its backfill progress (admin)
+func getEmbeddingModel(c *gin.Context) {
+	m, ok := embeddingModelFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"model": m})
+}
+
+// backfillEmbeddingModel starts (or restarts) embedding every text-embedded scene with a model in
+// background jobs (admin)
+func backfillEmbeddingModel(c *gin.Context) {
+	m, ok := embeddingModelFromParam(c)
+	if !ok {
+		return
+	}
+	switch m.Status {
+	case models.EmbeddingModelRegistered, models.EmbeddingModelFailed, models.EmbeddingModelValidating:
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Model cannot be backfilled", "details": "model is " + m.Status})
+		return
+	}
+	total, err := db.CountTextEmbeddedScenes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count scenes", "details": err.Error()})
+		return
+	}
+	m.Status, m.Error = models.EmbeddingModelBackfilling, nil
+	m.BackfillCursor, m.BackfilledScenes, m.TotalScenes = 0, 0, total
+	if err := db.UpdateEmbeddingModel(m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start backfill", "details": err.Error()})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeModelBackfill, map[string]interface{}{"embedding_model_id": m.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue backfill", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"model": m, "job_id": job.ID})
+}
+
+// compareEmbeddingModel runs a query against the active model and a candidate side by side
+// (dual read) so curators can judge the candidate before cutover (admin):
+// {"query": "crowd cheering", "limit": 10, "level": "shot"}
+func compareEmbeddingModel(c *gin.Context) {
+	m, ok := embeddingModelFromParam(c)
+	if !ok {
+		return
+	}
+	if m.Status != models.EmbeddingModelValidating && m.Status != models.EmbeddingModelBackfilling && m.Status != models.EmbeddingModelRetired {
+		c.JSON(http.StatusConflict, gin.H{"error": "Model has no vectors to compare", "details": "model is " + m.Status})
+		return
+	}
+	var req struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+		Level string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "query is required"})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 10
+	}
+	filter := database.SceneFilter{Level: req.Level}
+
+	activeVec, err := embedTextQuery(req.Query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+		return
+	}
+	candidateVec, err := embedTextQueryWithModel(req.Query, m.ModelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query with candidate", "details": err.Error()})
+		return
+	}
+	activeScenes, activeDists, err := db.SearchScenesByTextVector(activeVec, req.Limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+		return
+	}
+	candScenes, candDists, err := db.SearchScenesByModelVector(m.ID, candidateVec, req.Limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Candidate search failed", "details": err.Error()})
+		return
+	}
+
+	metric := database.MetricForColumn(database.ColumnText)
+	results := func(scenes []models.Scene, dists []float64) []gin.H {
+		out := make([]gin.H, len(scenes))
+		for i, s := range scenes {
+			out[i] = gin.H{"scene_id": s.ID, "video_id": s.VideoID, "scene_index": s.SceneIndex, "start_time": s.StartTime, "end_time": s.EndTime, "similarity": metric.Similarity(dists[i])}
+		}
+		return out
+	}
+	inActive := make(map[uint]bool, len(activeScenes))
+	for _, s := range activeScenes {
+		inActive[s.ID] = true
+	}
+	overlap := 0
+	for _, s := range candScenes {
+		if inActive[s.ID] {
+			overlap++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"query":     req.Query,
+		"active":    results(activeScenes, activeDists),
+		"candidate": results(candScenes, candDists),
+		"overlap":   overlap,
+		"limit":     req.Limit,
+	})
+}
+
+// activateEmbeddingModel cuts text search over to a validated model atomically and schedules the
+// follow-up re-embedding and the retirement of the replaced model's vectors (admin). Activating a
+// retired model whose vectors are not yet purged rolls back to it.
+func activateEmbeddingModel(c *gin.Context) {
+	m, ok := embeddingModelFromParam(c)
+	if !ok {
+		return
+	}
+	if m.Status != models.EmbeddingModelValidating && m.Status != models.EmbeddingModelRetired {
+		c.JSON(http.StatusConflict, gin.H{"error": "Model cannot be activated", "details": "model is " + m.Status + "; backfill it first"})
+		return
+	}
+	retired, err := db.ActivateEmbeddingModel(m.ID, processor.BuiltinTextModel())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cutover failed", "details": err.Error()})
+		return
+	}
+	processor.InvalidateActiveTextModel()
+	job, err := jobQueue.Enqueue(queue.JobTypeModelCutover, map[string]interface{}{"embedding_model_id": m.ID, "retired_model_id": retired.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue cutover follow-up", "details": err.Error()})
+		return
+	}
+	m, _ = db.GetEmbeddingModel(m.ID)
+	c.JSON(http.StatusOK, gin.H{"model": m, "retired": retired, "job_id": job.ID})
+}
+
+// deleteEmbeddingModel removes a model that is not active, with its vectors (admin)
+func deleteEmbeddingModel(c *gin.Context) {
+	m, ok := embeddingModelFromParam(c)
+	if !ok {
+		return
+	}
+	if m.Status == models.EmbeddingModelActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "The active model cannot be deleted"})
+		return
+	}
+	if err := db.DeleteEmbeddingModel(m.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete embedding model", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Embedding model deleted successfully", "id": m.ID})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSceneSnippets caps the caption snippets returned with each search hit
+const maxSceneSnippets = 5
+
+// sceneVideo describes a hit's video, as joined by the scene vector searches
+func sceneVideo(v models.Video) gin.H {
+	return gin.H{
+		"id": v.ID, "uuid": v.UUID, "filename": v.Filename, "title": v.Title,
+		"duration": v.Duration, "asset_type": v.AssetType, "status": v.Status,
+	}
+}
+
+// pickSnippets keeps at most maxSceneSnippets captions, those matching the query first, in time order
+func pickSnippets(snippets []database.CaptionSnippet) []database.CaptionSnippet {
+	if len(snippets) <= maxSceneSnippets {
+		return snippets
+	}
+	picked := make([]database.CaptionSnippet, 0, maxSceneSnippets)
+	for _, s := range snippets {
+		if s.Matched && len(picked) < maxSceneSnippets {
+			picked = append(picked, s)
+		}
+	}
+	for _, s := range snippets {
+		if !s.Matched && len(picked) < maxSceneSnippets {
+			picked = append(picked, s)
+		}
+	}
+	sort.SliceStable(picked, func(i, j int) bool { return picked[i].StartTime < picked[j].StartTime })
+	return picked
+}
+
+// attachSceneResults completes scene search results the way models.SearchResult describes them:
+// each item gets its "scene_id", its "video" and, in "matched_captions", the captions overlapping
+// the scene, highlighted against query when it is a text query. Items and hits are parallel.
+func attachSceneResults(items []gin.H, hits []models.Scene, query string) {
+	ids := make([]uint, len(hits))
+	for i, s := range hits {
+		ids[i] = s.ID
+	}
+	snippets, err := db.GetSceneCaptionSnippets(ids, query)
+	if err != nil {
+		log.Printf("Warning: failed to load caption snippets: %v", err)
+	}
+	for i, s := range hits {
+		items[i]["scene_id"] = s.ID
+		if s.Video.ID != 0 {
+			items[i]["video"] = sceneVideo(s.Video)
+		}
+		if snippets != nil {
+			items[i]["matched_captions"] = append([]database.CaptionSnippet{}, pickSnippets(snippets[s.ID])...)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entityFacetQuery parses the ?type=, ?q= (name prefix) and ?limit= facet parameters, writing a 400
+// when invalid
+func entityFacetQuery(c *gin.Context) (database.EntityFacetQuery, bool) {
+	fq := database.EntityFacetQuery{EntityType: c.Query("type"), Prefix: c.Query("q"), Limit: 50}
+	if fq.EntityType != "" && !models.ValidEntityType(fq.EntityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type", "details": "type must be person, place, organization or misc"})
+		return fq, false
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return fq, false
+		}
+		if n > 500 {
+			n = 500
+		}
+		fq.Limit = n
+	}
+	return fq, true
+}
+
+// listEntities returns entity facets across the library
+func listEntities(c *gin.Context) {
+	fq, ok := entityFacetQuery(c)
+	if !ok {
+		return
+	}
+	facets, err := db.ListEntityFacets(fq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list entities", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entities": facets, "count": len(facets)})
+}
+
+// listVideoEntities returns the entity facets of one video
+func listVideoEntities(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	fq, ok := entityFacetQuery(c)
+	if !ok {
+		return
+	}
+	id := uint(videoID)
+	fq.VideoID = &id
+	facets, err := db.ListEntityFacets(fq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list entities", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"video_id": id, "entities": facets, "count": len(facets)})
+}
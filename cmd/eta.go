@@ -0,0 +1,210 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/queue"
+)
+
+// etaMinProgress is the progress (percent) from which a running job's ETA extrapolates its own
+// progress instead of its type's historical throughput
+const etaMinProgress = 10
+
+// etaWorkers is the number of jobs of a type assumed to run at once when estimating how long a
+// queued job waits (JOB_ETA_WORKERS, default 1)
+func etaWorkers() int {
+	if n, err := strconv.Atoi(os.Getenv("JOB_ETA_WORKERS")); err == nil && n >= 1 {
+		return n
+	}
+	return 1
+}
+
+// jobETA is the estimated time left for a pending or running job
+type jobETA struct {
+	// RemainingSecs covers the queue wait and the run time left
+	RemainingSecs float64 `json:"remaining_secs"`
+	// ExpectedSecs is the job's total expected run time
+	ExpectedSecs float64 `json:"expected_secs"`
+	// Basis is "progress" when extrapolated from the running job's progress, else "throughput"
+	Basis         string    `json:"basis"`
+	JobsAhead     int       `json:"jobs_ahead,omitempty"`
+	QueueWaitSecs float64   `json:"queue_wait_secs,omitempty"`
+	FinishesAt    time.Time `json:"finishes_at"`
+}
+
+// videoETA is the estimated time left for a video's unsettled jobs
+type videoETA struct {
+	RemainingSecs float64   `json:"remaining_secs"`
+	FinishesAt    time.Time `json:"finishes_at"`
+	// PendingJobs counts the unsettled jobs; UnestimatedJobs those without an estimate (no
+	// throughput recorded for their type yet), which RemainingSecs leaves out
+	PendingJobs     int `json:"pending_jobs"`
+	UnestimatedJobs int `json:"unestimated_jobs,omitempty"`
+}
+
+// jobSourceSeconds returns the seconds of video a job processes: its chunk for chunked jobs, else
+// the whole video. Jobs not about a video have none.
+func jobSourceSeconds(job *queue.Job, durations map[uint]float64) float64 {
+	start, ok1 := job.Payload["chunk_start"].(float64)
+	end, ok2 := job.Payload["chunk_end"].(float64)
+	if ok1 && ok2 && end > start {
+		return end - start
+	}
+	videoID, ok := queue.PayloadVideoID(job.Payload)
+	if !ok {
+		return 0
+	}
+	if d, ok := durations[videoID]; ok {
+		return d
+	}
+	d := 0.0
+	if video, err := db.GetVideoByID(videoID); err == nil {
+		d = video.Duration
+	}
+	durations[videoID] = d
+	return d
+}
+
+// recordJobThroughput adds a completed job to its type's throughput. Jobs that only split their
+// work into other jobs say nothing about the rate and are skipped.
+func recordJobThroughput(job *queue.Job, elapsed time.Duration, result map[string]interface{}) {
+	if _, split := result["scene_chunks"]; split {
+		return
+	}
+	if err := jobQueue.RecordThroughput(job.Type, elapsed, jobSourceSeconds(job, map[uint]float64{})); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// etaEstimator estimates job ETAs, caching throughputs and video durations across the jobs of one
+// request
+type etaEstimator struct {
+	now         time.Time
+	workers     int
+	throughputs map[queue.JobType]*queue.Throughput
+	durations   map[uint]float64
+}
+
+func newETAEstimator() *etaEstimator {
+	return &etaEstimator{
+		now:         time.Now(),
+		workers:     etaWorkers(),
+		throughputs: map[queue.JobType]*queue.Throughput{},
+		durations:   map[uint]float64{},
+	}
+}
+
+func (e *etaEstimator) throughput(jobType queue.JobType) *queue.Throughput {
+	if t, ok := e.throughputs[jobType]; ok {
+		return t
+	}
+	t, err := jobQueue.Throughput(jobType)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	e.throughputs[jobType] = t
+	return t
+}
+
+// expectedSecs returns the job's expected run time from its type's throughput
+func (e *etaEstimator) expectedSecs(job *queue.Job) (float64, bool) {
+	t := e.throughput(job.Type)
+	source := jobSourceSeconds(job, e.durations)
+	if t == nil || source <= 0 {
+		return 0, false
+	}
+	return t.SecsPerSourceMinute * source / 60, true
+}
+
+// jobETA estimates a pending or running job; it returns nil for settled jobs and jobs it cannot
+// estimate. A queued job waits for the jobs ahead of it, each taken to run as long as it will.
+func (e *etaEstimator) jobETA(job *queue.Job) *jobETA {
+	switch job.Status {
+	case queue.JobStatusRunning:
+		if job.StartedAt == nil {
+			return nil
+		}
+		elapsed := e.now.Sub(*job.StartedAt).Seconds()
+		if job.Progress >= etaMinProgress && job.Progress < 100 {
+			expected := elapsed * 100 / float64(job.Progress)
+			return e.finish(&jobETA{RemainingSecs: expected - elapsed, ExpectedSecs: expected, Basis: "progress"})
+		}
+		expected, ok := e.expectedSecs(job)
+		if !ok {
+			return nil
+		}
+		remaining := expected - elapsed
+		if remaining < 0 {
+			remaining = 0
+		}
+		return e.finish(&jobETA{RemainingSecs: remaining, ExpectedSecs: expected, Basis: "throughput"})
+	case queue.JobStatusPending:
+		expected, ok := e.expectedSecs(job)
+		if !ok {
+			return nil
+		}
+		eta := &jobETA{ExpectedSecs: expected, Basis: "throughput"}
+		ahead, err := jobQueue.JobsAhead(job)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		eta.JobsAhead = ahead
+		eta.QueueWaitSecs = float64(ahead) * expected / float64(e.workers)
+		if job.RunAt != nil {
+			if wait := job.RunAt.Sub(e.now).Seconds(); wait > eta.QueueWaitSecs {
+				eta.QueueWaitSecs = wait
+			}
+		}
+		eta.RemainingSecs = eta.QueueWaitSecs + expected
+		return e.finish(eta)
+	}
+	return nil
+}
+
+func (e *etaEstimator) finish(eta *jobETA) *jobETA {
+	eta.FinishesAt = e.now.Add(time.Duration(eta.RemainingSecs * float64(time.Second))).UTC()
+	return eta
+}
+
+// videoETA sums the run time left of a video's unsettled jobs, plus the longest queue wait among
+// them; the stages of a video mostly run one after another. It returns nil when nothing is
+// pending.
+func (e *etaEstimator) videoETA(jobs []*queue.Job, etas map[string]*jobETA) *videoETA {
+	out := &videoETA{}
+	wait := 0.0
+	for _, j := range jobs {
+		if j.Status != queue.JobStatusPending && j.Status != queue.JobStatusRunning {
+			continue
+		}
+		out.PendingJobs++
+		eta := etas[j.ID]
+		if eta == nil {
+			out.UnestimatedJobs++
+			continue
+		}
+		out.RemainingSecs += eta.RemainingSecs - eta.QueueWaitSecs
+		if eta.QueueWaitSecs > wait {
+			wait = eta.QueueWaitSecs
+		}
+	}
+	if out.PendingJobs == 0 {
+		return nil
+	}
+	out.RemainingSecs += wait
+	out.FinishesAt = e.now.Add(time.Duration(out.RemainingSecs * float64(time.Second))).UTC()
+	return out
+}
+
+// jobETAs estimates each of the jobs by ID
+func (e *etaEstimator) jobETAs(jobs []*queue.Job) map[string]*jobETA {
+	etas := make(map[string]*jobETA, len(jobs))
+	for _, j := range jobs {
+		if eta := e.jobETA(j); eta != nil {
+			etas[j.ID] = eta
+		}
+	}
+	return etas
+}
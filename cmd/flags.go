@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+
+	"goodclips-server/internal/flags"
+	"goodclips-server/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// initFeatureFlags loads feature flag overrides from the database
+func initFeatureFlags() {
+	flags.Init(db.FeatureFlagOverrides)
+}
+
+// listFeatureFlags returns every pipeline feature flag with its default and effective value (admin)
+func listFeatureFlags(c *gin.Context) {
+	flags.Invalidate()
+	c.JSON(http.StatusOK, gin.H{"flags": processor.FeatureFlags()})
+}
+
+// featureFlagFromParam validates the :name parameter, writing a 404 for unknown flags
+func featureFlagFromParam(c *gin.Context) (string, bool) {
+	name := c.Param("name")
+	if !processor.IsFeatureFlag(name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feature flag not found", "details": name})
+		return "", false
+	}
+	return name, true
+}
+
+// setFeatureFlag overrides a flag: {"enabled": false}. Workers pick the change up within seconds (admin).
+func setFeatureFlag(c *gin.Context) {
+	name, ok := featureFlagFromParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "enabled (bool) is required"})
+		return
+	}
+	if err := db.SetFeatureFlag(name, *req.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set feature flag", "details": err.Error()})
+		return
+	}
+	flags.Invalidate()
+	c.JSON(http.StatusOK, gin.H{"flags": processor.FeatureFlags()})
+}
+
+// resetFeatureFlag removes a flag's override so it reverts to its default (admin)
+func resetFeatureFlag(c *gin.Context) {
+	name, ok := featureFlagFromParam(c)
+	if !ok {
+		return
+	}
+	if err := db.DeleteFeatureFlag(name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset feature flag", "details": err.Error()})
+		return
+	}
+	flags.Invalidate()
+	c.JSON(http.StatusOK, gin.H{"flags": processor.FeatureFlags()})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxHighlightDuration caps the requested reel length (one hour)
+const maxHighlightDuration = 3600
+
+// highlightReelResponse adds a signed download URL to exported reels
+func highlightReelResponse(reel *models.HighlightReel) gin.H {
+	resp := gin.H{"reel": reel}
+	if reel.ExportPath != nil {
+		resp["download_url"] = signedArtifactURL(fmt.Sprintf("/api/v1/highlights/%d/video", reel.ID))
+	}
+	exportProgress(resp, reel.Status, reel.JobID)
+	return resp
+}
+
+// createHighlightReel registers a highlight reel and enqueues the job that builds it:
+// {"prompt": "goals and celebrations", "target_duration": 90, "video_id": 3, "export": true}
+func createHighlightReel(c *gin.Context) {
+	var req struct {
+		Prompt           string   `json:"prompt" binding:"required"`
+		TargetDuration   float64  `json:"target_duration" binding:"required,gt=0"`
+		VideoID          *uint    `json:"video_id"`
+		Level            string   `json:"level"`
+		SimilarityWeight *float64 `json:"similarity_weight"`
+		EnergyWeight     *float64 `json:"energy_weight"`
+		MinSceneDuration *float64 `json:"min_scene_duration"`
+		MaxSceneDuration *float64 `json:"max_scene_duration"`
+		Export           bool     `json:"export"`
+		ExcludeFlagged   bool     `json:"exclude_flagged"`
+		Censor           string   `json:"censor"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing prompt"})
+		return
+	}
+	if req.TargetDuration > maxHighlightDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target_duration", "details": fmt.Sprintf("must be at most %d seconds", maxHighlightDuration)})
+		return
+	}
+	if !ffmpeg.ValidCensorMode(req.Censor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid censor", "details": "censor must be mute or bleep"})
+		return
+	}
+	level := database.SceneLevelOrDefault(req.Level)
+	if !models.ValidSceneLevel(level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return
+	}
+	if req.VideoID != nil {
+		if _, err := db.GetVideoByID(*req.VideoID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+			return
+		}
+	}
+
+	options := models.JSONObject{"export": req.Export, "exclude_flagged": req.ExcludeFlagged, "censor": req.Censor}
+	for key, v := range map[string]*float64{
+		"similarity_weight":  req.SimilarityWeight,
+		"energy_weight":      req.EnergyWeight,
+		"min_scene_duration": req.MinSceneDuration,
+		"max_scene_duration": req.MaxSceneDuration,
+	} {
+		if v == nil {
+			continue
+		}
+		if *v < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key, "details": "must not be negative"})
+			return
+		}
+		options[key] = *v
+	}
+
+	reel := &models.HighlightReel{
+		VideoID:        req.VideoID,
+		Prompt:         strings.TrimSpace(req.Prompt),
+		Level:          level,
+		TargetDuration: req.TargetDuration,
+		Options:        options,
+		Status:         models.HighlightStatusPending,
+	}
+	if err := db.CreateHighlightReel(reel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create highlight reel", "details": err.Error()})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeHighlightReel, map[string]interface{}{"reel_id": reel.ID})
+	if err != nil {
+		msg := err.Error()
+		reel.Status = models.HighlightStatusFailed
+		reel.ErrorMessage = &msg
+		db.UpdateHighlightReel(reel)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue highlight reel job", "details": err.Error()})
+		return
+	}
+	reel.JobID = job.ID
+	if err := db.UpdateHighlightReel(reel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update highlight reel", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, highlightReelResponse(reel))
+}
+
+// highlightReelFromParam loads the reel addressed by :id, writing an error response when it cannot
+func highlightReelFromParam(c *gin.Context) (*models.HighlightReel, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid highlight reel ID"})
+		return nil, false
+	}
+	reel, err := db.GetHighlightReel(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Highlight reel not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load highlight reel", "details": err.Error()})
+		return nil, false
+	}
+	return reel, true
+}
+
+// getHighlightReel returns a reel's status and, once built, its scenes in playback order
+func getHighlightReel(c *gin.Context) {
+	reel, ok := highlightReelFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, highlightReelResponse(reel))
+}
+
+// listHighlightReels returns reels newest first, optionally for one video (?video_id=)
+func listHighlightReels(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	var videoID *uint
+	if v := c.Query("video_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video_id"})
+			return
+		}
+		vid := uint(id)
+		videoID = &vid
+	}
+	reels, total, err := db.ListHighlightReels(videoID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list highlight reels", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reels": reels,
+		"pagination": gin.H{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(reels),
+		},
+	})
+}
+
+// downloadHighlightReel serves an exported reel (signed URL required)
+func downloadHighlightReel(c *gin.Context) {
+	reel, ok := highlightReelFromParam(c)
+	if !ok {
+		return
+	}
+	if reel.ExportPath == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Highlight reel has not been exported"})
+		return
+	}
+	serveArtifact(c, models.ArtifactHighlightReel, reel.ID, *reel.ExportPath, fmt.Sprintf("highlights_%d.mp4", reel.ID))
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Hybrid search fusion methods
+const (
+	// fusionRRF sums weight / (k + rank) over the rankings a scene appears in
+	fusionRRF = "rrf"
+	// fusionWeighted sums weight * similarity, like /search/multimodal without normalization
+	fusionWeighted = "weighted"
+)
+
+// hybridCandidateFactor is how many candidates per result each modality contributes to fusion
+const hybridCandidateFactor = 3
+
+// hybridRRFK is the RRF rank constant (HYBRID_RRF_K, default 60): larger values flatten the
+// advantage of top ranks
+func hybridRRFK() float64 {
+	if k, err := strconv.Atoi(os.Getenv("HYBRID_RRF_K")); err == nil && k > 0 {
+		return float64(k)
+	}
+	return 60
+}
+
+// hybridHit is a scene found by the text and/or the visual search
+type hybridHit struct {
+	scene       models.Scene
+	textRank    int // 1-based, 0 when the text search missed it
+	visualRank  int
+	textSim     *float64
+	visualSim   *float64
+	textScore   *float64
+	visualScore *float64
+	fused       float64
+}
+
+// fuseHybrid merges the text and visual rankings into one list ordered by fused score. A scene
+// missing from one ranking contributes nothing for it.
+func fuseHybrid(text, visual []models.Scene, textSims, visualSims, textScores, visualScores []float64, method string, wText, wVisual, rrfK float64) []*hybridHit {
+	byID := map[uint]*hybridHit{}
+	var order []*hybridHit
+	hit := func(s models.Scene) *hybridHit {
+		h := byID[s.ID]
+		if h == nil {
+			h = &hybridHit{scene: s}
+			byID[s.ID] = h
+			order = append(order, h)
+		}
+		return h
+	}
+	for i, s := range text {
+		h := hit(s)
+		h.textRank, h.textSim, h.textScore = i+1, &textSims[i], &textScores[i]
+	}
+	for i, s := range visual {
+		h := hit(s)
+		h.visualRank, h.visualSim, h.visualScore = i+1, &visualSims[i], &visualScores[i]
+	}
+	for _, h := range order {
+		if method == fusionWeighted {
+			if h.textSim != nil {
+				h.fused += wText * *h.textSim
+			}
+			if h.visualSim != nil {
+				h.fused += wVisual * *h.visualSim
+			}
+			continue
+		}
+		if h.textRank > 0 {
+			h.fused += wText / (rrfK + float64(h.textRank))
+		}
+		if h.visualRank > 0 {
+			h.fused += wVisual / (rrfK + float64(h.visualRank))
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].fused > order[j].fused })
+	return order
+}
+
+// searchHybrid runs the e5 text search and the CLIP visual search for the same query and fuses
+// the two rankings, so queries describing either dialogue or what is on screen find their scenes.
+// If the CLIP query embedding fails the text ranking is returned alone, with a warning. With
+// similarity_threshold each ranking's candidates scoring below it are dropped before fusion.
+func searchHybrid(c *gin.Context) {
+	started := time.Now()
+	var req struct {
+		Query      string   `json:"query"`
+		VideoIDs   []uint   `json:"video_ids"`
+		Limit      int      `json:"limit"`
+		Level      string   `json:"level"`
+		AssetTypes []string `json:"asset_types"`
+		// Fusion is "rrf" (default) or "weighted"
+		Fusion string `json:"fusion"`
+		// Weights scale each modality's contribution: {"text": 1, "visual": 1}
+		Weights map[string]float64 `json:"weights"`
+		// RRFK overrides HYBRID_RRF_K
+		RRFK           int      `json:"rrf_k"`
+		Context        int      `json:"context"`
+		Entities       []string `json:"entities"`
+		ExcludeFlagged bool     `json:"exclude_flagged"`
+		FlagCategories []string `json:"flag_categories"`
+		// SimilarityThreshold drops candidates scoring below it (0 to 1; 0 keeps everything)
+		SimilarityThreshold float64 `json:"similarity_threshold"`
+		toneQuery
+		videoQuery
+		diversityQuery
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	method := req.Fusion
+	if method == "" {
+		method = fusionRRF
+	}
+	if method != fusionRRF && method != fusionWeighted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fusion", "details": "fusion must be rrf or weighted"})
+		return
+	}
+	wText, wVisual := 1.0, 1.0
+	for name, w := range req.Weights {
+		switch {
+		case w < 0:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weights", "details": "weights must not be negative"})
+			return
+		case name == "text":
+			wText = w
+		case name == "visual":
+			wVisual = w
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weights", "details": "unknown modality " + name})
+			return
+		}
+	}
+	if !similarityThresholdParam(c, req.SimilarityThreshold) || !req.diversityQuery.validate(c) {
+		return
+	}
+	rrfK := hybridRRFK()
+	if req.RRFK > 0 {
+		rrfK = float64(req.RRFK)
+	}
+
+	filter, ok := sceneFilterParam(c, req.VideoIDs, req.Level, req.AssetTypes)
+	if !ok {
+		return
+	}
+	filter.Entities = req.Entities
+	filter.ExcludeFlagged, filter.FlagCategories = req.ExcludeFlagged, req.FlagCategories
+	if !req.toneQuery.apply(c, &filter) || !req.videoQuery.apply(c, &filter) {
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	candidates := limit * hybridCandidateFactor
+
+	textVec, err := embedTextQuery(req.Query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed text query", "details": err.Error()})
+		return
+	}
+	textScenes, textDists, err := db.SearchScenesByTextVector(textVec, candidates, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+		return
+	}
+	textMetric := database.MetricForColumn(database.ColumnText)
+	kept := aboveThreshold(textMetric, textDists, req.SimilarityThreshold)
+	dropped := len(textScenes) - kept
+	textScenes, textDists = textScenes[:kept], textDists[:kept]
+	textSims := make([]float64, len(textDists))
+	textScores := make([]float64, len(textDists))
+	for i, d := range textDists {
+		textSims[i], textScores[i] = textMetric.Similarity(d), textMetric.Score(d)
+	}
+
+	var warnings []string
+	var visualScenes []models.Scene
+	var visualSims, visualScores []float64
+	if clipVec, err := embedCLIPTextQuery(req.Query); err != nil {
+		log.Printf("Warning: CLIP text embed failed: %v", err)
+		warnings = append(warnings, "visual search unavailable: "+err.Error())
+	} else if scenes, dists, err := db.SearchScenesByClipVector(clipVec, candidates, filter); err != nil {
+		log.Printf("Warning: CLIP vector search failed: %v", err)
+		warnings = append(warnings, "visual search failed: "+err.Error())
+	} else {
+		visualMetric := database.MetricForColumn(database.ColumnVisualClip)
+		kept := aboveThreshold(visualMetric, dists, req.SimilarityThreshold)
+		dropped += len(scenes) - kept
+		visualScenes, dists = scenes[:kept], dists[:kept]
+		visualSims = make([]float64, len(dists))
+		visualScores = make([]float64, len(dists))
+		for i, d := range dists {
+			visualSims[i], visualScores[i] = visualMetric.Similarity(d), visualMetric.Score(d)
+		}
+	}
+
+	fused := fuseHybrid(textScenes, visualScenes, textSims, visualSims, textScores, visualScores, method, wText, wVisual, rrfK)
+	candidateScenes := make([]models.Scene, len(fused))
+	fusedScores := make([]float64, len(fused))
+	ranked := make([]int, len(fused))
+	for i, h := range fused {
+		candidateScenes[i], fusedScores[i], ranked[i] = h.scene, h.fused, i
+	}
+	picked := req.diversify(candidateScenes, ranked, fusedScores, []string{database.ColumnText, database.ColumnVisualClip}, limit)
+	items := make([]gin.H, 0, len(picked))
+	hits := make([]models.Scene, 0, len(picked))
+	for _, i := range picked {
+		h := fused[i]
+		s := h.scene
+		hits = append(hits, s)
+		items = append(items, gin.H{
+			"scene": gin.H{
+				"id": s.ID, "uuid": s.UUID, "video_id": s.VideoID, "level": s.Level, "scene_index": s.SceneIndex, "beat_index": s.BeatIndex,
+				"start_time": s.StartTime, "end_time": s.EndTime, "duration": s.Duration,
+				"has_captions": s.HasCaptions, "caption_count": s.CaptionCount, "created_at": s.CreatedAt,
+			},
+			"scores": gin.H{
+				"text_rank": h.textRank, "visual_rank": h.visualRank,
+				"text_similarity": h.textSim, "visual_similarity": h.visualSim,
+				"text_score": h.textScore, "visual_score": h.visualScore,
+			},
+			"fused_score": h.fused,
+		})
+	}
+	attachSceneTones(items, hits, req.SortBy)
+	attachSceneThumbnails(items, hits)
+	attachSceneContext(items, hits, clampSceneContext(req.Context))
+	attachSceneResults(items, hits, req.Query)
+
+	weights := map[string]float64{"text": wText, "visual": wVisual}
+	searchID := recordSearchEvent("hybrid", req.Query, map[string]any{
+		"video_ids":            req.VideoIDs,
+		"limit":                limit,
+		"fusion":               method,
+		"weights":              weights,
+		"level":                filter.Level,
+		"asset_types":          req.AssetTypes,
+		"entities":             req.Entities,
+		"exclude_flagged":      req.ExcludeFlagged,
+		"emotions":             req.Emotions,
+		"sort_by":              req.SortBy,
+		"similarity_threshold": req.SimilarityThreshold,
+		"diversity":            req.Diversity,
+		"max_per_video":        req.MaxPerVideo,
+	}, started, sceneIDsOf(hits))
+	resp := gin.H{
+		"search_id": searchID,
+		"query":     req.Query,
+		"limit":     limit,
+		"level":     filter.Level,
+		"fusion":    method,
+		"weights":   weights,
+		"count":     len(items),
+		"results":   items,
+	}
+	if method == fusionRRF {
+		resp["rrf_k"] = rrfK
+	}
+	thresholdInfo(resp, req.SimilarityThreshold, dropped)
+	req.diversityQuery.info(resp, len(fused))
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
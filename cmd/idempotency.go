@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// capturingWriter records the response body while writing it through
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyTTL is how long responses are kept for replay (IDEMPOTENCY_TTL_SECS, default 24h)
+func idempotencyTTL() time.Duration {
+	if secs, err := strconv.Atoi(getEnvOrDefault("IDEMPOTENCY_TTL_SECS", "86400")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// idempotencyPendingTTL bounds how long a key stays reserved by a request still being processed
+// (IDEMPOTENCY_PENDING_SECS, default 5 minutes), so a process dying mid-request does not block
+// retries for the whole idempotencyTTL
+func idempotencyPendingTTL() time.Duration {
+	if secs, err := strconv.Atoi(getEnvOrDefault("IDEMPOTENCY_PENDING_SECS", "300")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// idempotencyMiddleware makes mutating endpoints safe to retry: a request carrying an Idempotency-Key
+// header is processed once, and retries with the same key and body replay the stored response.
+// Reusing a key with a different request is rejected with 422; a retry while the original is still
+// running gets 409. Server errors and panics release the key so the client can retry.
+func idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key too long"})
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		h := sha256.New()
+		h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		h.Write(body)
+		fingerprint := hex.EncodeToString(h.Sum(nil))
+		scopedKey := c.Request.Method + ":" + c.FullPath() + ":" + key
+
+		existing, err := jobQueue.ReserveIdempotencyKey(scopedKey, fingerprint, idempotencyPendingTTL())
+		if err != nil {
+			log.Printf("Warning: idempotency store unavailable, processing without it: %v", err)
+			c.Next()
+			return
+		}
+		if existing != nil {
+			if existing.Fingerprint != fingerprint {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+				return
+			}
+			if existing.Pending {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.Status, existing.ContentType, existing.Body)
+			c.Abort()
+			return
+		}
+
+		// Released unless a response is stored, including when the handler panics
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := jobQueue.ReleaseIdempotencyKey(scopedKey); err != nil {
+				log.Printf("Warning: failed to release idempotency key: %v", err)
+			}
+		}()
+
+		w := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		status := w.Status()
+		if status >= 500 {
+			return
+		}
+		resp := queue.IdempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		}
+		if err := jobQueue.StoreIdempotentResponse(scopedKey, resp, idempotencyTTL()); err != nil {
+			log.Printf("Warning: failed to store idempotent response: %v", err)
+			return
+		}
+		stored = true
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchImageBytes caps query images uploaded to /search/image
+const maxSearchImageBytes = 20 << 20
+
+// searchImage finds the scenes that look like an uploaded image, e.g. to trace a screenshot back
+// to its source: the image is embedded with CLIP's image encoder and matched against
+// scenes.visual_clip_embedding. It takes either a multipart form with an "image" file (and the
+// search options as JSON in an optional "options" field) or a JSON body with "image_base64"
+// (plain or a data: URL) next to the options.
+func searchImage(c *gin.Context) {
+	started := time.Now()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 2*maxSearchImageBytes)
+	var opts sceneSearchOptions
+	var img []byte
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fh, err := c.FormFile("image")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required", "details": err.Error()})
+			return
+		}
+		if fh.Size > maxSearchImageBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image too large", "details": fmt.Sprintf("images are limited to %d bytes", maxSearchImageBytes)})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image", "details": err.Error()})
+			return
+		}
+		img, err = io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image", "details": err.Error()})
+			return
+		}
+		if raw := c.PostForm("options"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid options", "details": err.Error()})
+				return
+			}
+		}
+	} else {
+		var req struct {
+			ImageBase64 string `json:"image_base64"`
+			sceneSearchOptions
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+			return
+		}
+		encoded := req.ImageBase64
+		if strings.HasPrefix(encoded, "data:") {
+			_, encoded, _ = strings.Cut(encoded, ",")
+		}
+		var err error
+		if img, err = base64.StdEncoding.DecodeString(strings.TrimSpace(encoded)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image_base64", "details": err.Error()})
+			return
+		}
+		opts = req.sceneSearchOptions
+	}
+	if len(img) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+	if len(img) > maxSearchImageBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image too large", "details": fmt.Sprintf("images are limited to %d bytes", maxSearchImageBytes)})
+		return
+	}
+	if ct := http.DetectContentType(img); !strings.HasPrefix(ct, "image/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported image", "details": "content type " + ct})
+		return
+	}
+	filter, limit, ok := opts.filter(c)
+	if !ok {
+		return
+	}
+
+	vec, err := embedCLIPImageQuery(img)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed image", "details": err.Error()})
+		return
+	}
+	sceneVectorSearch{
+		modality: "image",
+		column:   database.ColumnVisualClip,
+		search:   db.SearchScenesByClipVector,
+	}.respond(c, started, "", vec, opts, filter, limit)
+}
+
+// embedCLIPImageQuery embeds an image with CLIP's image encoder, in the space of
+// scenes.visual_clip_embedding. The runner reads images from disk, so it goes through a temp file.
+func embedCLIPImageQuery(img []byte) ([]float32, error) {
+	f, err := os.CreateTemp("", "query_image_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage image: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(img); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stage image: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to stage image: %w", err)
+	}
+
+	b, _ := json.Marshal(map[string]any{"image_path": f.Name()})
+	cmd := exec.Command("python3", "/root/internal/embeddings/clip_runner.py")
+	cmd.Stdin = bytes.NewReader(b)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("clip_runner failed: %v; stderr: %s", err, stderr.String())
+	}
+	var resp struct {
+		Vectors []struct {
+			Vector []float32 `json:"vector"`
+		} `json:"vectors"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse clip_runner output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("runner error: %s", resp.Error)
+	}
+	if len(resp.Vectors) == 0 || len(resp.Vectors[0].Vector) == 0 {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
+	return resp.Vectors[0].Vector, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobEventsPollInterval is how often a job event stream checks its job; progress lives in Redis,
+// written by whichever worker runs the job
+const jobEventsPollInterval = time.Second
+
+// jobEventsKeepAlive is how often an idle job event stream writes a comment so proxies keep it open
+const jobEventsKeepAlive = 15 * time.Second
+
+// eventStreamsDone is closed when the server shuts down, ending open job event streams so they do not
+// hold up draining; clients reconnect to another instance
+var (
+	eventStreamsDone      = make(chan struct{})
+	closeEventStreamsOnce sync.Once
+)
+
+// closeEventStreams ends the open job event streams (registered with the server's RegisterOnShutdown)
+func closeEventStreams() {
+	closeEventStreamsOnce.Do(func() { close(eventStreamsDone) })
+}
+
+// streamJobEvents streams a job's progress as server-sent events: a "progress" event
+// {"status", "progress", "eta"} whenever its status or progress changes, then a "done" event with
+// the job once it completed, failed or was cancelled, which ends the stream. Export UIs use it for
+// progress bars (see the export responses' events_url).
+func streamJobEvents(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := jobQueue.GetJob(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found", "details": err.Error()})
+		return
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(jobEventsPollInterval)
+	defer ticker.Stop()
+	var lastStatus queue.JobStatus
+	lastProgress := -1
+	lastWrite := time.Now()
+	c.Stream(func(w io.Writer) bool {
+		job, err := jobQueue.GetJob(id)
+		if err != nil {
+			c.SSEvent("error", gin.H{"error": "Job not found", "details": err.Error()})
+			return false
+		}
+		switch job.Status {
+		case queue.JobStatusCompleted, queue.JobStatusFailed, queue.JobStatusCancelled:
+			c.SSEvent("done", gin.H{"job": job})
+			return false
+		}
+		if job.Status != lastStatus || job.Progress != lastProgress {
+			ev := gin.H{"status": job.Status, "progress": job.Progress}
+			if eta := newETAEstimator().jobETA(job); eta != nil {
+				ev["eta"] = eta
+			}
+			c.SSEvent("progress", ev)
+			lastStatus, lastProgress, lastWrite = job.Status, job.Progress, time.Now()
+		} else if time.Since(lastWrite) >= jobEventsKeepAlive {
+			io.WriteString(w, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-eventStreamsDone:
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// exportProgress adds the progress of an export's job while it is pending or processing, with the
+// URL of its event stream
+func exportProgress(resp gin.H, status, jobID string) {
+	if jobID == "" || (status != models.HighlightStatusPending && status != models.HighlightStatusProcessing) {
+		return
+	}
+	resp["events_url"] = fmt.Sprintf("/api/v1/jobs/%s/events", jobID)
+	if job, err := jobQueue.GetJob(jobID); err == nil {
+		resp["progress"] = job.Progress
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// sceneFromParams resolves the scene addressed by :id, :index and the optional level query parameter,
+// writing an error response when it cannot
+func sceneFromParams(c *gin.Context) (*models.Scene, bool) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return nil, false
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scene index"})
+		return nil, false
+	}
+	level := database.SceneLevelOrDefault(c.Query("level"))
+	if !models.ValidSceneLevel(level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return nil, false
+	}
+	scene, err := db.GetSceneByVideoAndIndex(uint(videoID), level, index)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found"})
+		return nil, false
+	}
+	return scene, true
+}
+
+// listSceneKeyframes returns a scene's candidate keyframes, best first, and the selected display frame
+func listSceneKeyframes(c *gin.Context) {
+	scene, ok := sceneFromParams(c)
+	if !ok {
+		return
+	}
+	frames, err := db.ListSceneKeyframes(scene.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list keyframes", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"scene_id":      scene.ID,
+		"scene_index":   scene.SceneIndex,
+		"level":         scene.Level,
+		"keyframe_time": scene.KeyframeTime,
+		"keyframes":     frames,
+	})
+}
+
+// selectSceneKeyframe sets a scene's display frame to one of its candidates: {"keyframe_id": 12}
+func selectSceneKeyframe(c *gin.Context) {
+	scene, ok := sceneFromParams(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		KeyframeID uint `json:"keyframe_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	frame, err := db.SelectSceneKeyframe(scene.ID, req.KeyframeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Keyframe not found for this scene"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select keyframe", "details": err.Error()})
+		return
+	}
+
+	// The display image is what thumbnails serve; point it at the chosen candidate
+	display := filepath.Join(filepath.Dir(frame.Path), fmt.Sprintf("scene_%04d_keyframe.jpg", scene.SceneIndex))
+	if err := copyFile(frame.Path, display); err != nil {
+		log.Printf("Warning: failed to update display keyframe for scene %d: %v", scene.ID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Keyframe selected", "keyframe": frame})
+}
+
+// thumbnailMaxAge is how long clients may cache a scene thumbnail (THUMBNAIL_MAX_AGE_SECS, default a day)
+func thumbnailMaxAge() int {
+	if secs, err := strconv.Atoi(os.Getenv("THUMBNAIL_MAX_AGE_SECS")); err == nil && secs >= 0 {
+		return secs
+	}
+	return 86400
+}
+
+// sceneKeyframePath locates the image of a scene's display keyframe. Scenes detected before keyframe
+// paths were stored fall back to the keyframes directory next to the video; beats there use the
+// keyframe of the shot their keyframe time falls in.
+func sceneKeyframePath(scene *models.Scene) (string, error) {
+	if scene.KeyframePath != nil {
+		return *scene.KeyframePath, nil
+	}
+	if scene.KeyframeTime == nil {
+		return "", errors.New("scene has no keyframe")
+	}
+	video, err := db.GetVideoByID(scene.VideoID)
+	if err != nil {
+		return "", fmt.Errorf("video not found: %v", err)
+	}
+	shotIndex := scene.SceneIndex
+	if scene.Level != models.SceneLevelShot {
+		shot, err := db.GetShotAt(video.ID, *scene.KeyframeTime)
+		if err != nil {
+			return "", fmt.Errorf("keyframe shot not found: %v", err)
+		}
+		shotIndex = shot.SceneIndex
+	}
+	return filepath.Join(filepath.Dir(video.Filepath), fmt.Sprintf("video_%d_keyframes", video.ID), fmt.Sprintf("scene_%04d_keyframe.jpg", shotIndex)), nil
+}
+
+// serveKeyframe writes a scene's keyframe JPEG with caching headers. The ETag follows the file's
+// size and modification time, so selecting another keyframe invalidates cached copies.
+func serveKeyframe(c *gin.Context, scene *models.Scene) {
+	path, err := sceneKeyframePath(scene)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Keyframe not found", "details": err.Error()})
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Keyframe image not found"})
+		return
+	}
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", thumbnailMaxAge()))
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	c.File(path)
+}
+
+// getSceneKeyframeImage serves a scene's display keyframe (signed URL required)
+func getSceneKeyframeImage(c *gin.Context) {
+	scene, ok := sceneFromParams(c)
+	if !ok {
+		return
+	}
+	serveKeyframe(c, scene)
+}
+
+// sceneThumbnailURL returns the signed URL of a scene's thumbnail
+func sceneThumbnailURL(sceneID uint) string {
+	return signedArtifactURL(fmt.Sprintf("/api/v1/scenes/%d/thumbnail", sceneID))
+}
+
+// getSceneThumbnail serves the keyframe of the scene with ID :id (signed URL required)
+func getSceneThumbnail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scene ID"})
+		return
+	}
+	scene, err := db.GetSceneByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scene not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scene", "details": err.Error()})
+		return
+	}
+	serveKeyframe(c, scene)
+}
+
+// attachSceneThumbnails adds the signed thumbnail_url of each search hit that has a keyframe, looking
+// the keyframes up in one query
+func attachSceneThumbnails(items []gin.H, hits []models.Scene) {
+	ids := make([]uint, len(hits))
+	for i, s := range hits {
+		ids[i] = s.ID
+	}
+	has, err := db.SceneIDsWithKeyframes(ids)
+	if err != nil {
+		log.Printf("Warning: failed to load scene keyframes: %v", err)
+		return
+	}
+	for i, s := range hits {
+		if has[s.ID] {
+			if scene, ok := items[i]["scene"].(gin.H); ok {
+				scene["thumbnail_url"] = sceneThumbnailURL(s.ID)
+			}
+		}
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessLogger writes structured (JSON) access logs to stdout
+var accessLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// sensitiveFields are redacted from logged query strings and JSON bodies (matched case-insensitively):
+// user search text, credentials, webhook URLs (which carry their own credentials), email addresses,
+// uploads and the viewer and watermark of share links
+var sensitiveFields = map[string]bool{
+	"query": true, "q": true, "text": true, "prompt": true, "phrase": true,
+	"password": true, "token": true, "secret": true, "authorization": true,
+	"sig": true, "image": true, "image_base64": true,
+	"target": true, "webhook_url": true, "emails": true,
+	"viewer": true, "wm": true, "watermark": true,
+}
+
+const maxLoggedValueLen = 256
+
+// sampleRule keeps a fraction of successful requests for routes matching a prefix
+type sampleRule struct {
+	prefix string
+	rate   float64
+}
+
+// parseSampleRules parses ACCESS_LOG_SAMPLE_RATES, e.g. "/health=0,/api/v1/search=0.1".
+// A route uses the longest matching prefix; unmatched routes are always logged.
+func parseSampleRules(spec string) []sampleRule {
+	var rules []sampleRule
+	for _, part := range strings.Split(spec, ",") {
+		prefix, rateStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || prefix == "" {
+			continue
+		}
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			continue
+		}
+		rules = append(rules, sampleRule{prefix: prefix, rate: rate})
+	}
+	return rules
+}
+
+func sampleRate(rules []sampleRule, path string) float64 {
+	rate, best := 1.0, -1
+	for _, r := range rules {
+		if strings.HasPrefix(path, r.prefix) && len(r.prefix) > best {
+			rate, best = r.rate, len(r.prefix)
+		}
+	}
+	return rate
+}
+
+// accessLogMiddleware logs method, route, status, latency and response size for each request.
+// Successful requests on high-volume routes are sampled (ACCESS_LOG_SAMPLE_RATES); 4xx/5xx are always
+// logged. Request bodies are only included when ACCESS_LOG_BODIES=true, with sensitive and large fields redacted.
+func accessLogMiddleware() gin.HandlerFunc {
+	rules := parseSampleRules(getEnvOrDefault("ACCESS_LOG_SAMPLE_RATES", "/health=0"))
+	logBodies := strings.EqualFold(getEnvOrDefault("ACCESS_LOG_BODIES", "false"), "true")
+	return func(c *gin.Context) {
+		start := time.Now()
+		var body []byte
+		if logBodies && c.Request.Body != nil && strings.HasPrefix(c.ContentType(), "application/json") {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		if status < 400 {
+			if rate := sampleRate(rules, route); rate < 1 && rand.Float64() >= rate {
+				return
+			}
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000.0,
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if q := redactQuery(c.Request.URL.Query()); len(q) > 0 {
+			attrs = append(attrs, "params", q)
+		}
+		if len(body) > 0 {
+			attrs = append(attrs, "body", redactBody(body))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+		accessLogger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+func redactQuery(values map[string][]string) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, vs := range values {
+		out[k] = redactValue(k, strings.Join(vs, ","))
+	}
+	return out
+}
+
+func redactBody(body []byte) any {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "[unparseable body]"
+	}
+	return redactJSON("", v)
+}
+
+// redactJSON walks a decoded JSON value replacing sensitive fields, including whole objects and
+// arrays under a sensitive key, and truncating large values
+func redactJSON(key string, v any) any {
+	if sensitiveFields[strings.ToLower(key)] {
+		return "[redacted]"
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			t[k] = redactJSON(k, child)
+		}
+		return t
+	case []any:
+		if len(t) > 20 {
+			return "[" + strconv.Itoa(len(t)) + " items]"
+		}
+		for i, child := range t {
+			t[i] = redactJSON(key, child)
+		}
+		return t
+	case string:
+		return redactValue(key, t)
+	default:
+		return t
+	}
+}
+
+func redactValue(key, v string) string {
+	if sensitiveFields[strings.ToLower(key)] {
+		return "[redacted]"
+	}
+	if len(v) > maxLoggedValueLen {
+		return v[:maxLoggedValueLen] + "...[truncated]"
+	}
+	return v
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logRequest serves one request through accessLogMiddleware and returns the access log line
+func logRequest(t *testing.T, method, target, body string) string {
+	t.Helper()
+	t.Setenv("ACCESS_LOG_BODIES", "true")
+	var out bytes.Buffer
+	saved := accessLogger
+	accessLogger = slog.New(slog.NewJSONHandler(&out, nil))
+	t.Cleanup(func() { accessLogger = saved })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(accessLogMiddleware())
+	r.Handle(method, strings.SplitN(target, "?", 2)[0], func(c *gin.Context) { c.Status(http.StatusCreated) })
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if out.Len() == 0 {
+		t.Fatal("request was not logged")
+	}
+	return out.String()
+}
+
+func TestAccessLogRedactsNotificationTarget(t *testing.T) {
+	const webhook = "https://hooks.slack.com/services/T000/B000/XXXXSECRET"
+	line := logRequest(t, http.MethodPost, "/api/v1/admin/notifications/channels",
+		`{"name":"ops","kind":"slack","target":"`+webhook+`","events":["job.failed_repeatedly"]}`)
+	if strings.Contains(line, "XXXXSECRET") {
+		t.Fatalf("webhook URL logged: %s", line)
+	}
+	if !strings.Contains(line, `"name":"ops"`) {
+		t.Fatalf("body not logged: %s", line)
+	}
+}
+
+func TestAccessLogRedactsSensitiveFields(t *testing.T) {
+	line := logRequest(t, http.MethodPost, "/api/v1/alerts?viewer=alice&wm=%7B%22text%22%3A%22alice%22%7D",
+		`{"phrase":"private words","webhook_url":"https://example.com/hook?key=abc123","emails":["bob@example.com"]}`)
+	for _, leaked := range []string{"alice", "private words", "abc123", "bob@example.com"} {
+		if strings.Contains(line, leaked) {
+			t.Fatalf("%q logged: %s", leaked, line)
+		}
+	}
+}
@@ -2,18 +2,26 @@ package main
 
 import (
     "bytes"
+    "context"
+    "encoding/hex"
     "encoding/json"
+    "errors"
+    "expvar"
     "fmt"
     "io"
     "log"
     "net/http"
     "os"
     "os/exec"
-    "sort"
+    "os/signal"
     "strconv"
     "strings"
+    "sync"
+    "syscall"
+    "time"
 
     "goodclips-server/internal/database"
+    "goodclips-server/internal/errorreport"
     "goodclips-server/internal/models"
     "goodclips-server/internal/queue"
     "goodclips-server/internal/processor"
@@ -73,18 +81,33 @@ func main() {
     videoProcessor = processor.NewVideoProcessor(db, jobQueue)
     log.Println("✅ Video processor initialized")
 
+    // Panic reporting (SENTRY_DSN)
+    if err := errorreport.Init("goodclips-api"); err != nil {
+        log.Printf("Warning: error reporting disabled: %v", err)
+    }
+
+    // Signed URLs for artifact-serving endpoints
+    initURLSigner()
+
+    // Pipeline event notifications (webhook, Slack, email channels)
+    initNotifications()
+
+    // Runtime feature flags for optional pipeline stages
+    initFeatureFlags()
+
     // Run auto-migration (optional - comment out in production)
     // if err := db.AutoMigrate(); err != nil {
     //     log.Fatalf("Failed to run auto-migration: %v", err)
     // }
     log.Println("⏭️ Skipping auto-migration (using existing schema)")
 
-    // Initialize Gin router
-    r := gin.Default()
+    // Initialize Gin router (gin.New: the default logger is replaced by structured access logs)
+    r := gin.New()
 
     // Middleware
+    r.Use(accessLogMiddleware())
     r.Use(corsMiddleware())
-    r.Use(gin.Recovery())
+    r.Use(recoveryMiddleware())
 
     // Health check endpoint
     r.GET("/health", healthCheck)
@@ -92,25 +115,154 @@ func main() {
     // API v1 routes
     v1 := r.Group("/api/v1")
     {
+        // Content routes of a video sharing another project's content serve that video's
+        shared := sharedContentMiddleware()
+
         // Video management
         v1.GET("/videos", listVideos)
-        v1.POST("/videos", createVideo)
+        v1.POST("/videos", idempotencyMiddleware(), createVideo)
         v1.GET("/videos/:id", getVideo)
         v1.DELETE("/videos/:id", deleteVideo)
+        v1.GET("/videos/:id/jobs", listVideoJobs)
+        v1.GET("/videos/:id/pipeline", getVideoPipeline)
+        v1.GET("/videos/:id/warnings", getVideoWarnings)
+        v1.POST("/videos/:id/live/stop", stopLiveVideo)
+        v1.GET("/videos/:id/captions", shared, listVideoCaptions)
+        v1.POST("/videos/:id/captions/sync", syncVideoCaptions)
+        v1.GET("/videos/:id/captions/qa", shared, getCaptionQAReport)
+        v1.POST("/videos/:id/translations", translateVideoCaptions)
+        v1.GET("/videos/:id/translations", shared, listVideoTranslations)
+        v1.GET("/videos/:id/subtitles", signedURLMiddleware(), shared, downloadVideoSubtitles)
+        v1.GET("/videos/:id/scenes", shared, listVideoScenes)
+        v1.GET("/videos/:id/scenes/:index/keyframe", signedURLMiddleware(), shared, getSceneKeyframeImage)
+        v1.GET("/videos/:id/scenes/:index/keyframes", shared, listSceneKeyframes)
+        v1.PUT("/videos/:id/scenes/:index/keyframe", selectSceneKeyframe)
+        v1.GET("/videos/:id/scenes/:index/clip", shared, getSceneClipBounds)
+        v1.GET("/videos/:id/cuts/:range", signedURLMiddleware(), shared, downloadVideoCut)
+        v1.GET("/videos/:id/scene-previews", listScenePreviews)
+        v1.POST("/videos/:id/scene-previews", createScenePreview)
+        v1.GET("/videos/:id/scene-previews/:preview", getScenePreview)
+        v1.DELETE("/videos/:id/scene-previews/:preview", discardScenePreview)
+        v1.POST("/videos/:id/scene-previews/:preview/commit", commitScenePreview)
+        v1.GET("/videos/:id/annotations", listVideoAnnotations)
+        v1.POST("/videos/:id/annotations", createAnnotation)
+        v1.POST("/videos/:id/search", shared, searchVideoMoments)
+        v1.GET("/videos/:id/topics", shared, getVideoTopics)
+        v1.GET("/videos/:id/entities", shared, listVideoEntities)
+        v1.GET("/videos/:id/flags", shared, listVideoContentFlags)
+        v1.GET("/videos/:id/tone", shared, getVideoTones)
+        v1.GET("/entities", listEntities)
+
+        // Annotations, favorites and collections
+        v1.GET("/annotations", listAnnotations)
+        v1.GET("/annotations/collections", listAnnotationCollections)
+        v1.GET("/annotations/:id", getAnnotation)
+        v1.PUT("/annotations/:id", updateAnnotation)
+        v1.DELETE("/annotations/:id", deleteAnnotation)
 
         // Search endpoints
         v1.POST("/search/scenes", searchScenesByAnchor)
         v1.POST("/search/semantic", searchSemantic)
         v1.POST("/search/multimodal", searchMultiModal)
+        v1.POST("/search/hybrid", searchHybrid)
+        v1.POST("/search/visual", searchVisual)
+        v1.POST("/search/audio", searchAudio)
+        v1.POST("/search/image", searchImage)
+        v1.POST("/search/video-clip", searchVideoClip)
         v1.POST("/search/text", searchText)
+        v1.POST("/search/passages", searchPassages)
+        v1.POST("/search/phrase", searchPhrase)
+        v1.POST("/search/videos", searchVideos)
+        v1.POST("/search/feedback", postSearchFeedback)
+
+        // Caption quality, worst videos first
+        v1.GET("/captions/qa", listCaptionQAReports)
+
+        // Highlight reels
+        v1.GET("/highlights", listHighlightReels)
+        v1.POST("/highlights", idempotencyMiddleware(), createHighlightReel)
+        v1.GET("/highlights/:id", getHighlightReel)
+        v1.GET("/highlights/:id/video", signedURLMiddleware(), downloadHighlightReel)
+
+        // Clip exports: scenes cut into MP4s
+        v1.POST("/scenes/batch-get", batchGetScenes)
+        v1.POST("/scenes/:id/export", idempotencyMiddleware(), exportSceneClip)
+        v1.GET("/scenes/:id/thumbnail", signedURLMiddleware(), getSceneThumbnail)
+        v1.GET("/clips/:id", getClipExport)
+        v1.GET("/clips/:id/video", signedURLMiddleware(), downloadClipExport)
+
+        // Supercuts: every utterance of a phrase, concatenated
+        v1.POST("/supercut", idempotencyMiddleware(), createSupercut)
+        v1.GET("/supercut/:id", getSupercut)
+        v1.GET("/supercut/:id/video", signedURLMiddleware(), downloadSupercut)
+
+        // Standing alerts on new ingests
+        v1.GET("/alerts", listAlerts)
+        v1.POST("/alerts", idempotencyMiddleware(), createAlert)
+        v1.GET("/alerts/:id", getAlert)
+        v1.PUT("/alerts/:id", updateAlert)
+        v1.DELETE("/alerts/:id", deleteAlert)
+        v1.GET("/alerts/:id/matches", listAlertMatches)
 
         // Statistics
         v1.GET("/stats", getStats)
+        v1.GET("/stats/search", getSearchAnalytics)
+        v1.GET("/stats/timeseries", getStatsTimeseries)
 
         // Processing jobs
         v1.GET("/jobs", listJobs)
         v1.GET("/jobs/:id", getJob)
-        v1.POST("/jobs", createJob)
+        v1.GET("/jobs/:id/events", streamJobEvents)
+        v1.POST("/jobs/:id/retry", retryJob)
+        v1.POST("/jobs/:id/cancel", cancelJob)
+        v1.POST("/jobs", idempotencyMiddleware(), createJob)
+        v1.POST("/jobs/status", getJobStatuses)
+
+        // Administration
+        admin := v1.Group("/admin", adminAuthMiddleware())
+        admin.GET("/synonyms", getSynonyms)
+        admin.PUT("/synonyms", putSynonyms)
+        admin.PUT("/videos/:id/lock", lockVideo)
+        admin.DELETE("/videos/:id/lock", unlockVideo)
+        admin.POST("/signed-urls", signURL)
+        admin.GET("/metrics", gin.WrapH(expvar.Handler()))
+        admin.GET("/config", getAdminConfig)
+        admin.GET("/flags", listFeatureFlags)
+        admin.PUT("/flags/:name", setFeatureFlag)
+        admin.DELETE("/flags/:name", resetFeatureFlag)
+        admin.GET("/scene-text-filters", listSceneTextFilters)
+        admin.PUT("/scene-text-filters", putSceneTextFilter)
+        admin.DELETE("/scene-text-filters", deleteSceneTextFilter)
+        admin.GET("/watermarks", listProjectWatermarks)
+        admin.PUT("/watermarks", putProjectWatermark)
+        admin.DELETE("/watermarks", deleteProjectWatermark)
+        admin.GET("/queue", getQueueState)
+        admin.POST("/queue/pause", pauseQueue)
+        admin.POST("/queue/resume", resumeQueue)
+        admin.POST("/queue/breakers/:name/reset", resetBreaker)
+        admin.GET("/queue/windows", listQueueWindows)
+        admin.PUT("/queue/windows/:name", putQueueWindow)
+        admin.DELETE("/queue/windows/:name", deleteQueueWindow)
+        admin.GET("/models", listEmbeddingModels)
+        admin.POST("/models", registerEmbeddingModel)
+        admin.GET("/models/:id", getEmbeddingModel)
+        admin.DELETE("/models/:id", deleteEmbeddingModel)
+        admin.POST("/models/:id/backfill", backfillEmbeddingModel)
+        admin.POST("/models/:id/compare", compareEmbeddingModel)
+        admin.POST("/models/:id/activate", activateEmbeddingModel)
+        admin.GET("/consistency", getConsistencyReport)
+        admin.POST("/consistency/repair", repairConsistency)
+        admin.POST("/counts/reconcile", reconcileCounts)
+        admin.GET("/vector-indexes", listVectorIndexes)
+        admin.POST("/vector-indexes/rebuild", rebuildVectorIndexes)
+        admin.GET("/storage", getStorageTiers)
+        admin.POST("/storage/tiering", runStorageTiering)
+        admin.POST("/captions/qa", refreshCaptionQA)
+        admin.GET("/notifications/channels", listNotificationChannels)
+        admin.POST("/notifications/channels", createNotificationChannel)
+        admin.PUT("/notifications/channels/:id", updateNotificationChannel)
+        admin.DELETE("/notifications/channels/:id", deleteNotificationChannel)
+        admin.POST("/notifications/channels/:id/test", testNotificationChannel)
     }
 
     // Get port from environment or default to 8080
@@ -119,8 +271,48 @@ func main() {
         port = "8080"
     }
 
-    fmt.Printf("🚀 GoodCLIPS Server starting on port %s\n", port)
-    log.Fatal(r.Run(":" + port))
+    srv := &http.Server{
+        Addr:    ":" + port,
+        Handler: r,
+    }
+    // Shutdown does not cancel request contexts; end the long-lived event streams explicitly
+    srv.RegisterOnShutdown(closeEventStreams)
+
+    // Stop accepting connections on SIGINT/SIGTERM and drain in-flight requests before the
+    // deferred DB/queue closes run.
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    serveErr := make(chan error, 1)
+    go func() {
+        fmt.Printf("🚀 GoodCLIPS Server starting on port %s\n", port)
+        if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            serveErr <- err
+        }
+        close(serveErr)
+    }()
+
+    select {
+    case err := <-serveErr:
+        if err != nil {
+            log.Printf("HTTP server failed: %v", err)
+            return
+        }
+    case <-ctx.Done():
+    }
+
+    drainTimeout := 30 * time.Second
+    if secs, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT_SECS")); err == nil && secs > 0 {
+        drainTimeout = time.Duration(secs) * time.Second
+    }
+    log.Printf("🛑 Shutting down; draining in-flight requests (timeout %s)", drainTimeout)
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+    defer cancel()
+    if err := srv.Shutdown(shutdownCtx); err != nil {
+        log.Printf("Warning: graceful shutdown incomplete: %v", err)
+    } else {
+        log.Println("✅ HTTP server drained")
+    }
 }
 
 // searchScenesByAnchor returns top-K nearest scenes to the anchor scene's visual embedding
@@ -131,14 +323,58 @@ func searchScenesByAnchor(c *gin.Context) {
     }
     type Req struct {
         Anchor         Anchor `json:"anchor"`
+        // EmbeddingType is the embedding the anchor is compared by: "visual" (default, InternVideo2),
+        // "clip", "text" (dialogue), "audio" (soundtrack) or "combined"
+        EmbeddingType  string `json:"embedding_type"`
         K              int    `json:"k"`
         FilterVideoIDs []uint `json:"filter_video_ids"`
-    }
+        // Level selects scene granularity: "shot" (default) or "beat"
+        Level string `json:"level"`
+        // AssetTypes restricts results to video, audio and/or image assets
+        AssetTypes []string `json:"asset_types"`
+        // Context returns ±N neighbouring scenes with captions around each hit
+        Context int `json:"context"`
+        // Entities restricts results to scenes whose captions mention any of these names
+        Entities []string `json:"entities"`
+        // ExcludeFlagged drops scenes with content flags (in FlagCategories, any when empty)
+        ExcludeFlagged bool     `json:"exclude_flagged"`
+        FlagCategories []string `json:"flag_categories"`
+        // SimilarityThreshold drops results scoring below it (0 to 1; 0 keeps everything)
+        SimilarityThreshold float64 `json:"similarity_threshold"`
+        // Emotional tone filters and ordering (emotions, min/max_sentiment, min_intensity, sort_by)
+        toneQuery
+        // Video tag, status and project filters and scene duration bounds
+        videoQuery
+        // Result diversity: MMR re-ranking (diversity, mmr_lambda) and max_per_video
+        diversityQuery
+    }
+    started := time.Now()
     var req Req
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
         return
     }
+    if !similarityThresholdParam(c, req.SimilarityThreshold) || !req.diversityQuery.validate(c) {
+        return
+    }
+    if req.EmbeddingType == "" {
+        req.EmbeddingType = "visual"
+    }
+    column, ok := database.ModalityColumn(req.EmbeddingType)
+    if !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid embedding_type", "details": "embedding_type must be visual, clip, text, audio or combined"})
+        return
+    }
+    filter, ok := sceneFilterParam(c, req.FilterVideoIDs, req.Level, req.AssetTypes)
+    if !ok {
+        return
+    }
+    filter.Entities = req.Entities
+    filter.ExcludeFlagged, filter.FlagCategories = req.ExcludeFlagged, req.FlagCategories
+    if !req.toneQuery.apply(c, &filter) || !req.videoQuery.apply(c, &filter) {
+        return
+    }
+    level := filter.Level
     k := req.K
     if k <= 0 {
         k = 10
@@ -146,19 +382,35 @@ func searchScenesByAnchor(c *gin.Context) {
     if k > 100 {
         k = 100
     }
-    scenes, dists, err := db.SearchSimilarScenesByAnchor(req.Anchor.VideoID, req.Anchor.SceneIndex, k, req.FilterVideoIDs)
+    scenes, dists, err := db.SearchSimilarScenesByAnchor(column, req.Anchor.VideoID, req.Anchor.SceneIndex, req.candidates(k), filter)
     if err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Search failed", "details": err.Error()})
         return
     }
-    items := make([]gin.H, 0, len(scenes))
-    for i, s := range scenes {
+    metric := database.MetricForColumn(column)
+    kept := aboveThreshold(metric, dists, req.SimilarityThreshold)
+    dropped := len(scenes) - kept
+    scenes, dists = scenes[:kept], dists[:kept]
+    candidates := len(scenes)
+    order := make([]int, len(scenes))
+    scores := make([]float64, len(scenes))
+    for i, d := range dists {
+        order[i], scores[i] = i, metric.Score(d)
+    }
+    order = req.diversify(scenes, order, scores, []string{column}, k)
+    items := make([]gin.H, 0, len(order))
+    ordered := make([]models.Scene, 0, len(order))
+    for _, i := range order {
+        s := scenes[i]
+        ordered = append(ordered, s)
         items = append(items, gin.H{
             "scene": gin.H{
                 "id":            s.ID,
                 "uuid":          s.UUID,
                 "video_id":      s.VideoID,
+                "level":         s.Level,
                 "scene_index":   s.SceneIndex,
+                "beat_index":    s.BeatIndex,
                 "start_time":    s.StartTime,
                 "end_time":      s.EndTime,
                 "duration":      s.Duration,
@@ -166,29 +418,183 @@ func searchScenesByAnchor(c *gin.Context) {
                 "caption_count": s.CaptionCount,
                 "created_at":    s.CreatedAt,
             },
-            "distance": dists[i],
+            "distance":   dists[i],
+            "similarity": metric.Similarity(dists[i]),
+            "score":      scores[i],
         })
     }
-    c.JSON(http.StatusOK, gin.H{
-        "anchor": gin.H{"video_id": req.Anchor.VideoID, "scene_index": req.Anchor.SceneIndex},
-        "k":       k,
-        "results": items,
-        "count":   len(items),
-    })
+    attachSceneTones(items, ordered, req.SortBy)
+    attachSceneThumbnails(items, ordered)
+    attachSceneContext(items, ordered, clampSceneContext(req.Context))
+    attachSceneResults(items, ordered, "")
+    searchID := recordSearchEvent("anchor", "", map[string]any{
+        "anchor_video_id":    req.Anchor.VideoID,
+        "anchor_scene_index": req.Anchor.SceneIndex,
+        "embedding_type":     req.EmbeddingType,
+        "filter_video_ids":   req.FilterVideoIDs,
+        "k":                  k,
+        "level":              level,
+        "asset_types":        req.AssetTypes,
+        "entities":           req.Entities,
+        "exclude_flagged":    req.ExcludeFlagged,
+        "emotions":           req.Emotions,
+        "sort_by":            req.SortBy,
+        "similarity_threshold": req.SimilarityThreshold,
+        "diversity":          req.Diversity,
+        "max_per_video":      req.MaxPerVideo,
+    }, started, sceneIDsOf(ordered))
+    resp := gin.H{
+        "search_id":      searchID,
+        "anchor":         gin.H{"video_id": req.Anchor.VideoID, "scene_index": req.Anchor.SceneIndex},
+        "embedding_type": req.EmbeddingType,
+        "metric":         metric,
+        "k":              k,
+        "level":          level,
+        "results":        items,
+        "count":          len(items),
+    }
+    thresholdInfo(resp, req.SimilarityThreshold, dropped)
+    req.diversityQuery.info(resp, candidates)
+    c.JSON(http.StatusOK, resp)
 }
 
-// searchText is a simple placeholder for keyword caption search (not implemented yet)
+// sceneFilterParam validates the common scene search filters (level defaults to shots) and builds
+// the database filter; it writes a 400 when invalid
+func sceneFilterParam(c *gin.Context, videoIDs []uint, level string, assetTypes []string) (database.SceneFilter, bool) {
+    level = database.SceneLevelOrDefault(level)
+    if !models.ValidSceneLevel(level) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+        return database.SceneFilter{}, false
+    }
+    for _, t := range assetTypes {
+        if !models.ValidAssetType(t) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset type", "details": t})
+            return database.SceneFilter{}, false
+        }
+    }
+    return database.SceneFilter{VideoIDs: videoIDs, Level: level, AssetTypes: assetTypes}, true
+}
+
+// searchText is keyword search over captions (Postgres full-text search): every query word must
+// appear in the caption, with synonym dictionary aliases accepted for each word. With "language" the
+// caption tracks translated into that language are searched instead.
 func searchText(c *gin.Context) {
+    started := time.Now()
     var req struct {
         Query    string `json:"query"`
         VideoIDs []uint `json:"video_ids"`
         Limit    int    `json:"limit"`
+        // Level selects the scene granularity hits are mapped to: "shot" (default) or "beat"
+        Level string `json:"level"`
+        // Language searches the captions translated into this language (e.g. "es")
+        Language string `json:"language"`
+        // Cross-encoder re-ranking of the top hits by their text (rerank, rerank_candidates)
+        rerankQuery
     }
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
         return
     }
-    c.JSON(http.StatusNotImplemented, gin.H{"error": "caption keyword search not implemented yet"})
+    if !req.rerankQuery.validate(c) {
+        return
+    }
+    level := database.SceneLevelOrDefault(req.Level)
+    if !models.ValidSceneLevel(level) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+        return
+    }
+    lang := strings.ToLower(strings.TrimSpace(req.Language))
+    if lang != "" && !processor.ValidLanguageCode(lang) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language", "details": "language must be a two- or three-letter language code"})
+        return
+    }
+    tsquery := currentSynonyms().TSQuery(req.Query)
+    if lang != "" {
+        tsquery = translatedTSQuery(req.Query)
+    }
+    if tsquery == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": "query has no searchable words"})
+        return
+    }
+    limit := req.Limit
+    if limit <= 0 {
+        limit = 20
+    }
+    if limit > 100 {
+        limit = 100
+    }
+
+    var hits []database.CaptionHit
+    var err error
+    fetch := req.rerankQuery.candidates(limit, limit)
+    if lang != "" {
+        hits, err = db.SearchCaptionTranslations(tsquery, lang, req.VideoIDs, level, fetch)
+    } else {
+        hits, err = db.SearchCaptions(tsquery, req.VideoIDs, level, fetch)
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+        return
+    }
+
+    // Optional cross-encoder pass over the keyword hits, which then rank by its score
+    var warnings []string
+    var rerank *rerankResult
+    if req.Rerank && len(hits) > 0 {
+        order := make([]int, len(hits))
+        texts := make([]string, len(hits))
+        for i, h := range hits {
+            order[i], texts[i] = i, h.Text
+        }
+        reranked, scores, info, err := rerankOrder(req.Query, order, texts)
+        if err != nil {
+            warnings = append(warnings, rerankFailed(err))
+        } else {
+            rerank = info
+            out := make([]database.CaptionHit, 0, len(hits))
+            for _, i := range reranked {
+                h := hits[i]
+                if s, ok := scores[i]; ok {
+                    h.RerankScore = &s
+                }
+                out = append(out, h)
+            }
+            hits = out
+        }
+    }
+    if len(hits) > limit {
+        hits = hits[:limit]
+    }
+    var sceneIDs []uint
+    for _, h := range hits {
+        if h.SceneID != nil {
+            sceneIDs = append(sceneIDs, *h.SceneID)
+        }
+    }
+    searchID := recordSearchEvent("text", req.Query, map[string]any{
+        "video_ids": req.VideoIDs,
+        "limit":     limit,
+        "level":     level,
+        "language":  lang,
+        "rerank":    req.Rerank,
+    }, started, sceneIDs)
+    resp := gin.H{
+        "search_id": searchID,
+        "query":     req.Query,
+        "tsquery":   tsquery,
+        "limit":     limit,
+        "level":     level,
+        "language":  lang,
+        "count":     len(hits),
+        "results":   hits,
+    }
+    if rerank != nil {
+        resp["rerank"] = rerank
+    }
+    if len(warnings) > 0 {
+        resp["warnings"] = warnings
+    }
+    c.JSON(http.StatusOK, resp)
 }
 
 // getStats returns aggregate DB stats
@@ -225,7 +631,145 @@ func getJob(c *gin.Context) {
         c.JSON(http.StatusNotFound, gin.H{"error": "Job not found", "details": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"job": job})
+    resp := gin.H{"job": job}
+    if eta := newETAEstimator().jobETA(job); eta != nil {
+        resp["eta"] = eta
+    }
+    c.JSON(http.StatusOK, resp)
+}
+
+// retryJob re-runs a failed or cancelled job now, resetting its attempt count
+func retryJob(c *gin.Context) {
+    job, err := jobQueue.GetJob(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Job not found", "details": err.Error()})
+        return
+    }
+    if destructiveJobTypes[job.Type] {
+        if videoID, ok := queue.PayloadVideoID(job.Payload); ok {
+            locked, err := db.IsVideoLocked(videoID)
+            if err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check legal hold", "details": err.Error()})
+                return
+            }
+            if locked {
+                c.JSON(http.StatusLocked, gin.H{"error": "Video is locked", "details": "destructive reprocessing is blocked by a legal hold"})
+                return
+            }
+        }
+    }
+    job, err = jobQueue.RetryJob(job.ID)
+    if errors.Is(err, queue.ErrJobNotRetryable) {
+        c.JSON(http.StatusConflict, gin.H{"error": "Job cannot be retried", "details": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job", "details": err.Error()})
+        return
+    }
+    c.JSON(http.StatusAccepted, gin.H{"message": "Job requeued", "job": job})
+}
+
+// cancelJob stops a pending or running job; a running job's ffmpeg and runner processes are
+// killed by its worker within a few seconds
+func cancelJob(c *gin.Context) {
+    job, err := jobQueue.GetJob(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Job not found", "details": err.Error()})
+        return
+    }
+    job, err = jobQueue.CancelJob(job.ID)
+    if errors.Is(err, queue.ErrJobFinished) {
+        c.JSON(http.StatusConflict, gin.H{"error": "Job cannot be cancelled", "details": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel job", "details": err.Error()})
+        return
+    }
+    if job.Status == queue.JobStatusRunning {
+        c.JSON(http.StatusAccepted, gin.H{"message": "Job cancellation requested", "job": job})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Job cancelled", "job": job})
+}
+
+// maxBulkJobStatusIDs caps the number of job IDs accepted by POST /jobs/status
+const maxBulkJobStatusIDs = 500
+
+// getJobStatuses returns the status of many jobs in one call
+func getJobStatuses(c *gin.Context) {
+    var req struct {
+        JobIDs []string `json:"job_ids"`
+    }
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+        return
+    }
+    if len(req.JobIDs) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "job_ids must not be empty"})
+        return
+    }
+    if len(req.JobIDs) > maxBulkJobStatusIDs {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Too many job IDs", "details": fmt.Sprintf("at most %d per request", maxBulkJobStatusIDs)})
+        return
+    }
+    jobs, err := jobQueue.GetJobs(req.JobIDs)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch jobs", "details": err.Error()})
+        return
+    }
+
+    type jobStatus struct {
+        Type         queue.JobType   `json:"type"`
+        Status       queue.JobStatus `json:"status"`
+        Progress     int             `json:"progress"`
+        Attempts     int             `json:"attempts"`
+        ErrorMessage *string         `json:"error_message,omitempty"`
+        StartedAt    *time.Time      `json:"started_at,omitempty"`
+        CompletedAt  *time.Time      `json:"completed_at,omitempty"`
+        ETA          *jobETA         `json:"eta,omitempty"`
+    }
+    statuses := make(map[string]jobStatus, len(jobs))
+    estimator := newETAEstimator()
+    // The batch finishes with its last job; unestimated jobs leave it open
+    var finishesAt *time.Time
+    unestimated := 0
+    counts := map[queue.JobStatus]int{}
+    notFound := []string{}
+    for _, id := range req.JobIDs {
+        job, ok := jobs[id]
+        if !ok {
+            notFound = append(notFound, id)
+            continue
+        }
+        if _, dup := statuses[id]; dup {
+            continue
+        }
+        counts[job.Status]++
+        eta := estimator.jobETA(job)
+        if eta != nil && (finishesAt == nil || eta.FinishesAt.After(*finishesAt)) {
+            finishesAt = &eta.FinishesAt
+        } else if eta == nil && (job.Status == queue.JobStatusPending || job.Status == queue.JobStatusRunning) {
+            unestimated++
+        }
+        statuses[id] = jobStatus{
+            Type:         job.Type,
+            Status:       job.Status,
+            Progress:     job.Progress,
+            Attempts:     job.Attempts,
+            ErrorMessage: job.ErrorMessage,
+            StartedAt:    job.StartedAt,
+            CompletedAt:  job.CompletedAt,
+            ETA:          eta,
+        }
+    }
+    resp := gin.H{"jobs": statuses, "counts": counts, "not_found": notFound}
+    if finishesAt != nil {
+        resp["finishes_at"] = finishesAt
+        resp["unestimated_jobs"] = unestimated
+    }
+    c.JSON(http.StatusOK, resp)
 }
 
 // createJob enqueues a processing job
@@ -242,6 +786,19 @@ func createJob(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Missing job type"})
         return
     }
+    if destructiveJobTypes[queue.JobType(req.Type)] {
+        if videoID, ok := queue.PayloadVideoID(req.Payload); ok {
+            locked, err := db.IsVideoLocked(videoID)
+            if err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check legal hold", "details": err.Error()})
+                return
+            }
+            if locked {
+                c.JSON(http.StatusLocked, gin.H{"error": "Video is locked", "details": "destructive reprocessing is blocked by a legal hold"})
+                return
+            }
+        }
+    }
     job, err := jobQueue.Enqueue(queue.JobType(req.Type), req.Payload)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
@@ -251,6 +808,13 @@ func createJob(c *gin.Context) {
 }
 
 
+// destructiveJobTypes rewrite a video's derived data and are refused for locked videos
+var destructiveJobTypes = map[queue.JobType]bool{
+    queue.JobTypeVideoIngestion:    true,
+    queue.JobTypeSceneDetection:    true,
+    queue.JobTypeCaptionExtraction: true,
+}
+
 // Worker function to process jobs
 func runWorker() {
     log.Println("🔧 Starting GoodCLIPS worker...")
@@ -283,75 +847,437 @@ func runWorker() {
     // Initialize video processor
     videoProcessor = processor.NewVideoProcessor(db, jobQueue)
 
-    log.Println("✅ Worker initialized, waiting for jobs...")
+    // Panic reporting (SENTRY_DSN)
+    if err := errorreport.Init("goodclips-worker"); err != nil {
+        log.Printf("Warning: error reporting disabled: %v", err)
+    }
 
-    // Worker loop
-    for {
-        // Try to dequeue a job
-        job, err := jobQueue.DequeueAny(nil)
-        if err != nil {
-            log.Printf("Error dequeuing job: %v", err)
-            continue
-        }
+    // Runtime feature flags; jobs of disabled stages are skipped
+    initFeatureFlags()
 
-        if job == nil {
-            // No jobs available, continue loop
-            continue
-        }
+    // Pipeline event notifications and the weekly library digest
+    initNotifications()
+    if err := videoProcessor.ScheduleNotificationDigest(); err != nil {
+        log.Printf("Warning: %v", err)
+    }
 
-        log.Printf("📥 Processing job %s of type %s", job.ID, job.Type)
+    // Daily library snapshots for the stats time series
+    if err := videoProcessor.ScheduleLibrarySnapshot(); err != nil {
+        log.Printf("Warning: %v", err)
+    }
 
-        // Update job status to running
-        err = jobQueue.UpdateJobStatus(job.ID, queue.JobStatusRunning, 0, nil)
-        if err != nil {
-            log.Printf("Error updating job status: %v", err)
-            continue
+    // Nightly reconciliation of denormalized scene/caption counts
+    if err := videoProcessor.ScheduleCountReconciliation(); err != nil {
+        log.Printf("Warning: %v", err)
+    }
+
+    // Missing or outdated ANN indexes on the scene embedding columns (VECTOR_INDEX_AUTO)
+    if err := videoProcessor.ScheduleVectorIndexes(); err != nil {
+        log.Printf("Warning: %v", err)
+    }
+
+    // Nightly moves of rarely downloaded exports to cold storage (COLD_STORAGE_DIR)
+    if err := videoProcessor.ScheduleStorageTiering(); err != nil {
+        log.Printf("Warning: %v", err)
+    }
+
+    // Failed jobs are retried with exponential backoff (JOB_MAX_ATTEMPTS, JOB_RETRY_BASE_SECS, JOB_RETRY_MAX_SECS)
+    retryPolicy := queue.RetryPolicyFromEnv()
+
+    // WORKER_CONCURRENCY jobs run at once, with per-type limits (WORKER_JOB_LIMITS)
+    concurrency := workerConcurrency()
+    limiter := newJobTypeLimiter(jobTypeLimitsFromEnv())
+    log.Printf("✅ Worker initialized (concurrency %d), waiting for jobs...", concurrency)
+
+    // SIGINT/SIGTERM stops dequeuing; in-flight jobs get WORKER_DRAIN_TIMEOUT_SECS to finish before
+    // they are killed and requeued
+    stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    jobCtx, abortJobs := context.WithCancel(context.Background())
+    defer abortJobs()
+
+    // Jobs whose worker died (lease not renewed for JOB_LEASE_SECS) are requeued
+    go runLeaseReaper(stopCtx, retryPolicy)
+
+    // Runners whose circuit breaker is open are health-probed (RUNNER_PROBE_INTERVAL_SECS)
+    go runBreakerProbes(stopCtx)
+
+    var wg sync.WaitGroup
+    for i := 0; i < concurrency; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            runWorkerLoop(stopCtx, jobCtx, limiter, retryPolicy)
+        }()
+    }
+    drained := make(chan struct{})
+    go func() {
+        wg.Wait()
+        close(drained)
+    }()
+
+    <-stopCtx.Done()
+    // A second signal kills the worker outright
+    stop()
+    drainTimeout := workerDrainTimeout()
+    log.Printf("🛑 Shutting down; draining in-flight jobs (timeout %s)", drainTimeout)
+    select {
+    case <-drained:
+        log.Println("✅ Worker drained")
+        return
+    case <-time.After(drainTimeout):
+    }
+    log.Println("⏱️  Drain timeout reached; cancelling and requeuing in-flight jobs")
+    abortJobs()
+    select {
+    case <-drained:
+        log.Println("✅ In-flight jobs requeued")
+    case <-time.After(workerAbortGrace):
+        log.Println("Warning: in-flight jobs did not stop in time and stay running")
+    }
+}
+
+// handleJob runs one dequeued job and records its outcome: skipped when cancelled or its stage is
+// disabled, else completed, failed, scheduled for a retry or cancelled while running. A job killed
+// because jobCtx was cancelled by a shutdown is requeued.
+func handleJob(jobCtx context.Context, job *queue.Job, retryPolicy queue.RetryPolicy) {
+    // Once the job's outcome is recorded, its video leaves processing if this settled the pipeline
+    defer func() {
+        if err := videoProcessor.AdvancePipeline(job); err != nil {
+            log.Printf("Warning: failed to advance pipeline after job %s: %v", job.ID, err)
         }
+    }()
 
-        // Process the job based on its type
-        switch job.Type {
-        case queue.JobTypeVideoIngestion:
-            err = processVideoIngestionJob(job)
-        case queue.JobTypeSceneDetection:
-            err = processSceneDetectionJob(job)
-        case queue.JobTypeCaptionExtraction:
-            err = processCaptionExtractionJob(job)
-        case queue.JobTypeEmbeddingGeneration:
-            err = processEmbeddingGenerationJob(job)
-        default:
-            errMsg := fmt.Sprintf("Unknown job type: %s", job.Type)
-            jobQueue.UpdateJobStatus(job.ID, queue.JobStatusFailed, 0, &errMsg)
-            continue
+    // A copy of a job that is no longer pending is stale: the job was cancelled while queued, or
+    // retried and run from another copy
+    if stored, err := jobQueue.GetJob(job.ID); err == nil && stored.Status != queue.JobStatusPending {
+        log.Printf("⏭️  Job %s of type %s skipped: already %s", job.ID, job.Type, stored.Status)
+        return
+    }
+    // Jobs cancelled while queued were already marked cancelled
+    if jobQueue.CancelRequested(job.ID) {
+        log.Printf("⏭️  Job %s of type %s skipped: cancelled", job.ID, job.Type)
+        return
+    }
+
+    if flag, enabled := processor.JobTypeEnabled(job.Type); !enabled {
+        msg := fmt.Sprintf("skipped: feature flag %s is off", flag)
+        jobQueue.UpdateJobStatus(job.ID, queue.JobStatusCancelled, 0, &msg)
+        log.Printf("⏭️  Job %s of type %s %s", job.ID, job.Type, msg)
+        return
+    }
+
+    log.Printf("📥 Processing job %s of type %s", job.ID, job.Type)
+
+    // Update job status to running
+    if err := jobQueue.UpdateJobStatus(job.ID, queue.JobStatusRunning, 0, nil); err != nil {
+        log.Printf("Error updating job status: %v", err)
+        return
+    }
+
+    // Process the job based on its type; a cancellation request kills it
+    result := processor.NewJobResult()
+    ctx, cancel := context.WithCancel(context.WithValue(jobCtx, jobResultKey{}, result))
+    stopWatch := watchJobCancellation(job.ID, cancel)
+    started := time.Now()
+    err := processJob(ctx, job)
+    stopWatch()
+    // Whatever the outcome, record what the attempt did
+    if res := result.Map(); res != nil {
+        if rerr := jobQueue.SetJobResult(job.ID, res); rerr != nil {
+            log.Printf("Warning: failed to record result of job %s: %v", job.ID, rerr)
+        }
+    }
+    // A job that finished anyway counts as done
+    cancelled := ctx.Err() != nil && err != nil
+    cancel()
+    if cancelled && jobCtx.Err() != nil {
+        if rerr := jobQueue.RequeueJob(job.ID); rerr != nil {
+            log.Printf("Error requeuing job %s: %v", job.ID, rerr)
+        } else {
+            log.Printf("↩️  Job %s interrupted by shutdown, requeued", job.ID)
         }
+        return
+    }
+    if cancelled {
+        msg := "cancelled by request"
+        jobQueue.UpdateJobStatus(job.ID, queue.JobStatusCancelled, 0, &msg)
+        log.Printf("🛑 Job %s cancelled", job.ID)
+        return
+    }
+    // A runner behind an open circuit breaker refused the job; it waits for the runner to recover
+    // without using up an attempt
+    var unavailable *processor.RunnerUnavailableError
+    if errors.As(err, &unavailable) {
+        if derr := jobQueue.DeferJob(job.ID, unavailable.RetryAt, err.Error()); derr != nil {
+            log.Printf("Error deferring job %s: %v", job.ID, derr)
+        } else {
+            log.Printf("⏸️  Job %s deferred until %s: %v", job.ID, unavailable.RetryAt.Format(time.RFC3339), err)
+        }
+        return
+    }
+    trackJobOutcome(job, err)
 
-        // Update job status based on processing result
-        if err != nil {
-            errMsg := err.Error()
-            jobQueue.UpdateJobStatus(job.ID, queue.JobStatusFailed, 0, &errMsg)
-            log.Printf("❌ Job %s failed: %v", job.ID, err)
+    // Update job status based on processing result; failures are retried with backoff
+    if err != nil {
+        retried, ferr := jobQueue.FailJob(job.ID, err, retryPolicy)
+        if ferr != nil {
+            log.Printf("Error updating job status: %v", ferr)
+        }
+        if retried {
+            log.Printf("🔁 Job %s failed, retry scheduled: %v", job.ID, err)
         } else {
-            jobQueue.UpdateJobStatus(job.ID, queue.JobStatusCompleted, 100, nil)
-            log.Printf("✅ Job %s completed successfully", job.ID)
+            log.Printf("❌ Job %s failed: %v", job.ID, err)
         }
+    } else {
+        jobQueue.UpdateJobStatus(job.ID, queue.JobStatusCompleted, 100, nil)
+        recordJobThroughput(job, time.Since(started), result.Map())
+        log.Printf("✅ Job %s completed successfully", job.ID)
+    }
+}
+
+// jobCancelPollInterval is how often a running job's cancellation flag is checked
+const jobCancelPollInterval = 2 * time.Second
+
+// watchJobCancellation calls cancel once cancellation of the job is requested; the returned func
+// stops watching
+func watchJobCancellation(jobID string, cancel context.CancelFunc) func() {
+    done := make(chan struct{})
+    go func() {
+        ticker := time.NewTicker(jobCancelPollInterval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-done:
+                return
+            case <-ticker.C:
+                if jobQueue.CancelRequested(jobID) {
+                    cancel()
+                    return
+                }
+            }
+        }
+    }()
+    return func() { close(done) }
+}
+
+// processJob dispatches a job to its handler. Cancelling ctx kills the job's subprocesses. A panic
+// in a handler is captured, reported and turned into a job failure so the worker loop survives it.
+func processJob(ctx context.Context, job *queue.Job) (err error) {
+    defer func() {
+        if rec := recover(); rec != nil {
+            errorreport.CapturePanic("worker", rec, map[string]any{
+                "job_id":   job.ID,
+                "job_type": job.Type,
+                "payload":  job.Payload,
+            })
+            err = fmt.Errorf("panic while processing job: %v", rec)
+        }
+    }()
+
+    switch job.Type {
+    case queue.JobTypeVideoIngestion:
+        return processVideoIngestionJob(ctx, job)
+    case queue.JobTypeSceneDetection:
+        return processSceneDetectionJob(ctx, job)
+    case queue.JobTypeCaptionExtraction:
+        return processCaptionExtractionJob(ctx, job)
+    case queue.JobTypeEmbeddingGeneration:
+        return processEmbeddingGenerationJob(ctx, job)
+    case queue.JobTypeConsistencyCheck:
+        return processConsistencyCheckJob(ctx, job)
+    case queue.JobTypeLiveIngest:
+        return processLiveIngestJob(ctx, job)
+    case queue.JobTypeHighlightReel:
+        return processHighlightReelJob(ctx, job)
+    case queue.JobTypeTopicTimeline:
+        return processTopicTimelineJob(ctx, job)
+    case queue.JobTypeEntityExtraction:
+        return processEntityExtractionJob(ctx, job)
+    case queue.JobTypeContentFlagging:
+        return processContentFlaggingJob(ctx, job)
+    case queue.JobTypeToneAnalysis:
+        return processToneAnalysisJob(ctx, job)
+    case queue.JobTypeAlertEvaluation:
+        return processAlertEvaluationJob(ctx, job)
+    case queue.JobTypeNotificationDigest:
+        return processNotificationDigestJob(ctx, job)
+    case queue.JobTypeLibrarySnapshot:
+        return processLibrarySnapshotJob(ctx, job)
+    case queue.JobTypeModelBackfill:
+        return processModelBackfillJob(ctx, job)
+    case queue.JobTypeModelCutover:
+        return processModelCutoverJob(ctx, job)
+    case queue.JobTypeModelRetire:
+        return processModelRetireJob(ctx, job)
+    case queue.JobTypeScenePreview:
+        return processScenePreviewJob(ctx, job)
+    case queue.JobTypeCaptionEmbedding:
+        return processCaptionEmbeddingJob(ctx, job)
+    case queue.JobTypeWordAlignment:
+        return processWordAlignmentJob(ctx, job)
+    case queue.JobTypeSupercut:
+        return processSupercutJob(ctx, job)
+    case queue.JobTypeCaptionSync:
+        return processCaptionSyncJob(ctx, job)
+    case queue.JobTypeClipExport:
+        return processClipExportJob(ctx, job)
+    case queue.JobTypeCaptionQA:
+        return processCaptionQAJob(ctx, job)
+    case queue.JobTypeCaptionTranslation:
+        return processCaptionTranslationJob(ctx, job)
+    case queue.JobTypeCountReconciliation:
+        return processCountReconciliationJob(ctx, job)
+    case queue.JobTypeCaptionLinking:
+        return processCaptionLinkingJob(ctx, job)
+    case queue.JobTypeVectorIndex:
+        return processVectorIndexJob(ctx, job)
+    case queue.JobTypeStorageTiering:
+        return processStorageTieringJob(ctx, job)
+    case queue.JobTypeArtifactRestore:
+        return processArtifactRestoreJob(ctx, job)
+    default:
+        return queue.WithCode(queue.ErrorInvalidPayload, fmt.Errorf("unknown job type: %s", job.Type))
     }
 }
 
 // Job processing functions
 
-func processVideoIngestionJob(job *queue.Job) error {
-    return videoProcessor.ProcessVideoIngestion(job.Payload)
+// jobProcessor is the processor a job runs on: cancelling ctx kills its subprocesses, and its
+// progress is recorded on the job as it goes
+func jobProcessor(ctx context.Context, job *queue.Job) *processor.VideoProcessor {
+    vp := videoProcessor.WithContext(ctx).WithJob(job.ID).WithProgress(processor.ProgressFunc(func(percent int) {
+        if err := jobQueue.UpdateJobProgress(job.ID, percent); err != nil {
+            log.Printf("Warning: failed to record progress of job %s: %v", job.ID, err)
+        }
+    }))
+    if result, ok := ctx.Value(jobResultKey{}).(*processor.JobResult); ok {
+        vp = vp.WithResult(result)
+    }
+    return vp
+}
+
+// jobResultKey carries the *processor.JobResult collecting the outputs of the job a context runs
+type jobResultKey struct{}
+
+func processVideoIngestionJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessVideoIngestion(job.Payload)
+}
+
+func processSceneDetectionJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessSceneDetection(job.Payload)
+}
+
+func processCaptionExtractionJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCaptionExtraction(job.Payload)
+}
+
+func processEmbeddingGenerationJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessEmbeddingGeneration(job.Payload)
+}
+
+func processConsistencyCheckJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessConsistencyCheck(job.Payload)
+}
+
+func processLiveIngestJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessLiveIngest(job.Payload)
+}
+
+func processHighlightReelJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessHighlightReel(job.Payload)
+}
+
+func processTopicTimelineJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessTopicTimeline(job.Payload)
+}
+
+func processEntityExtractionJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessEntityExtraction(job.Payload)
+}
+
+func processContentFlaggingJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessContentFlagging(job.Payload)
+}
+
+func processToneAnalysisJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessToneAnalysis(job.Payload)
+}
+
+func processAlertEvaluationJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessAlertEvaluation(job.Payload)
+}
+
+func processNotificationDigestJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessNotificationDigest(job.Payload)
+}
+
+func processLibrarySnapshotJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessLibrarySnapshot(job.Payload)
+}
+
+func processModelBackfillJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessModelBackfill(job.Payload)
+}
+
+func processModelCutoverJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessModelCutover(job.Payload)
 }
 
-func processSceneDetectionJob(job *queue.Job) error {
-    return videoProcessor.ProcessSceneDetection(job.Payload)
+func processModelRetireJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessModelRetire(job.Payload)
 }
 
-func processCaptionExtractionJob(job *queue.Job) error {
-    return videoProcessor.ProcessCaptionExtraction(job.Payload)
+func processScenePreviewJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessScenePreview(job.Payload)
 }
 
-func processEmbeddingGenerationJob(job *queue.Job) error {
-    return videoProcessor.ProcessEmbeddingGeneration(job.Payload)
+func processCaptionEmbeddingJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCaptionEmbedding(job.Payload)
+}
+
+func processWordAlignmentJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessWordAlignment(job.Payload)
+}
+
+func processSupercutJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessSupercut(job.Payload)
+}
+
+func processCaptionSyncJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCaptionSync(job.Payload)
+}
+
+func processClipExportJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessClipExport(job.Payload)
+}
+
+func processCaptionQAJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCaptionQA(job.Payload)
+}
+
+func processCaptionTranslationJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCaptionTranslation(job.Payload)
+}
+
+func processCountReconciliationJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCountReconciliation(job.Payload)
+}
+
+func processCaptionLinkingJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessCaptionLinking(job.Payload)
+}
+
+func processVectorIndexJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessVectorIndex(job.Payload)
+}
+
+func processStorageTieringJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessStorageTiering(job.Payload)
+}
+
+func processArtifactRestoreJob(ctx context.Context, job *queue.Job) error {
+    return jobProcessor(ctx, job).ProcessArtifactRestore(job.Payload)
 }
 
 // Middleware
@@ -360,7 +1286,7 @@ func corsMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
         c.Header("Access-Control-Allow-Origin", "*")
         c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-        c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+        c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 
         if c.Request.Method == "OPTIONS" {
             c.AbortWithStatus(204)
@@ -371,6 +1297,25 @@ func corsMiddleware() gin.HandlerFunc {
     }
 }
 
+// recoveryMiddleware turns handler panics into 500 responses and structured panic reports
+// (stack trace, request context, panics_total metric, optional Sentry forwarding)
+func recoveryMiddleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        defer func() {
+            if rec := recover(); rec != nil {
+                errorreport.CapturePanic("http", rec, map[string]any{
+                    "method":    c.Request.Method,
+                    "route":     c.FullPath(),
+                    "path":      c.Request.URL.Path,
+                    "client_ip": c.ClientIP(),
+                })
+                c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+            }
+        }()
+        c.Next()
+    }
+}
+
 // Handlers
 
 func healthCheck(c *gin.Context) {
@@ -454,14 +1399,74 @@ func createVideo(c *gin.Context) {
 		return
 	}
 
-	// TODO: Calculate file hash
-	// TODO: Check if video already exists
+	// Creation is idempotent: a source that is already registered (in the same project, with shared
+	// ingestion) returns the existing video. The SHA-256 is computed by the ingestion worker, which
+	// retires content duplicates under other paths and links other projects' copies to the content.
+	req.FileHash = strings.ToLower(strings.TrimSpace(req.FileHash))
+	if req.FileHash != "" && !isSHA256Hex(req.FileHash) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+			"details": "file_hash must be a hex SHA-256",
+		})
+		return
+	}
+	project, _ := req.Metadata["project"].(string)
+	existing, err := db.FindExistingVideo(req.Filepath, req.FileHash, project, processor.SharedIngestEnabled())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check for an existing video",
+			"details": err.Error(),
+		})
+		return
+	}
+	if existing != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"video": existing,
+			"duplicate": true,
+			"message": "Video already exists",
+		})
+		return
+	}
+	
+	assetType := req.AssetType
+	if assetType == "" {
+		assetType = models.AssetTypeForPath(req.Filepath)
+	}
+	if !models.ValidAssetType(assetType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid asset type",
+			"details": "asset_type must be video, audio or image",
+		})
+		return
+	}
+	
+	if req.SourceURL != "" && !req.Live {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+			"details": "source_url requires live: true",
+		})
+		return
+	}
+	if req.Live && assetType != models.AssetTypeVideo {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+			"details": "live ingest is only supported for video assets",
+		})
+		return
+	}
+	if req.SourceURL != "" {
+		if req.Metadata == nil {
+			req.Metadata = map[string]any{}
+		}
+		req.Metadata["live_source"] = req.SourceURL
+	}
 	
 	// Create video record
 	video := &models.Video{
 		Filename: req.Filename,
 		Filepath: req.Filepath,
-		FileHash: "temp_hash_" + req.Filename, // TODO: Calculate real hash
+		AssetType: assetType,
+		Live:     req.Live,
 		Title:    req.Title,
 		Tags:     models.JSONStringArray(req.Tags),
 		Metadata: models.JSONObject(req.Metadata),
@@ -482,8 +1487,16 @@ func createVideo(c *gin.Context) {
 		"filename": video.Filename,
 		"filepath": video.Filepath,
 	}
+	if req.RefreshScenes {
+		jobPayload["refresh_scenes"] = true
+	}
 	
-	job, err := jobQueue.Enqueue(queue.JobTypeVideoIngestion, jobPayload)
+	// Live videos are ingested incrementally by a self-rescheduling job instead of the one-shot pipeline
+	jobType := queue.JobTypeVideoIngestion
+	if video.Live {
+		jobType = queue.JobTypeLiveIngest
+	}
+	job, err := jobQueue.Enqueue(jobType, jobPayload)
 	if err != nil {
 		log.Printf("Warning: Failed to create processing job for video %d: %v", video.ID, err)
 	}
@@ -495,6 +1508,15 @@ func createVideo(c *gin.Context) {
 	})
 }
 
+// isSHA256Hex reports whether s is a lowercase hex SHA-256 digest
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
 func getVideo(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -513,15 +1535,215 @@ func getVideo(c *gin.Context) {
 		return
 	}
 
+	// Live counts are two indexed COUNTs; the stored ones may lag behind partial failures
+	contentID := video.ID
+	if video.ContentVideoID != nil {
+		contentID = *video.ContentVideoID
+	}
+	if scenes, captions, err := db.CountVideoScenesAndCaptions(contentID); err == nil {
+		video.SceneCount, video.CaptionCount = scenes, captions
+	}
+
 	// Get processing jobs for this video
 	jobs, _ := db.GetProcessingJobsByVideoID(video.ID)
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"video": video,
 		"processing_jobs": jobs,
+	}
+	if queued, err := jobQueue.ListJobsForVideo(video.ID); err == nil {
+		estimator := newETAEstimator()
+		if eta := estimator.videoETA(queued, estimator.jobETAs(queued)); eta != nil {
+			resp["eta"] = eta
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// stopLiveVideo ends live ingestion of a video; the next live tick processes what remains and finalizes it
+func stopLiveVideo(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	video, err := db.GetVideoByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	if !video.Live {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video is not live"})
+		return
+	}
+	if err := db.StopLiveVideo(video.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop live ingest", "details": err.Error()})
+		return
+	}
+	video.Live = false
+	c.JSON(http.StatusOK, gin.H{"video": video, "message": "Live ingest stopping"})
+}
+
+// listVideoJobs returns the queue jobs and persisted processing jobs related to a video
+func listVideoJobs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	if _, err := db.GetVideoByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+
+	queueJobs, err := jobQueue.ListJobsForVideo(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs", "details": err.Error()})
+		return
+	}
+	processingJobs, err := db.GetProcessingJobsByVideoID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list processing jobs", "details": err.Error()})
+		return
+	}
+
+	type queueJobView struct {
+		*queue.Job
+		DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	}
+	type processingJobView struct {
+		models.ProcessingJob
+		DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	}
+	qViews := make([]queueJobView, 0, len(queueJobs))
+	for _, j := range queueJobs {
+		qViews = append(qViews, queueJobView{Job: j, DurationSeconds: jobDuration(j.StartedAt, j.CompletedAt)})
+	}
+	pViews := make([]processingJobView, 0, len(processingJobs))
+	for _, j := range processingJobs {
+		pViews = append(pViews, processingJobView{ProcessingJob: j, DurationSeconds: jobDuration(j.StartedAt, j.CompletedAt)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"video_id":        id,
+		"jobs":            qViews,
+		"processing_jobs": pViews,
+		"count":           len(qViews) + len(pViews),
 	})
 }
 
+// getVideoPipeline returns a video's processing pipeline as a DAG of stages with their jobs,
+// statuses and timings, for rendering a progress view
+func getVideoPipeline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	video, err := db.GetVideoByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	jobs, err := jobQueue.ListJobsForVideo(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs", "details": err.Error()})
+		return
+	}
+
+	byStage := map[queue.JobType][]*queue.Job{}
+	for _, j := range jobs {
+		byStage[j.Type] = append(byStage[j.Type], j)
+	}
+
+	type jobNode struct {
+		ID              string          `json:"id"`
+		Status          queue.JobStatus `json:"status"`
+		Progress        int             `json:"progress"`
+		CreatedAt       time.Time       `json:"created_at"`
+		StartedAt       *time.Time      `json:"started_at,omitempty"`
+		CompletedAt     *time.Time      `json:"completed_at,omitempty"`
+		DurationSeconds *float64        `json:"duration_seconds,omitempty"`
+		ErrorMessage    *string         `json:"error_message,omitempty"`
+		ErrorCode       queue.ErrorCode `json:"error_code,omitempty"`
+		WarningCount    int             `json:"warning_count,omitempty"`
+		ETA             *jobETA         `json:"eta,omitempty"`
+	}
+	type stageNode struct {
+		ID              queue.JobType   `json:"id"`
+		DependsOn       []queue.JobType `json:"depends_on"`
+		Status          string          `json:"status"`
+		StartedAt       *time.Time      `json:"started_at,omitempty"`
+		CompletedAt     *time.Time      `json:"completed_at,omitempty"`
+		DurationSeconds *float64        `json:"duration_seconds,omitempty"`
+		Jobs            []jobNode       `json:"jobs"`
+	}
+	type edge struct {
+		From queue.JobType `json:"from"`
+		To   queue.JobType `json:"to"`
+	}
+
+	estimator := newETAEstimator()
+	etas := estimator.jobETAs(jobs)
+	nodes := make([]stageNode, 0, len(queue.PipelineStages))
+	edges := []edge{}
+	for _, stage := range queue.PipelineStages {
+		stageJobs := byStage[stage.Type]
+		node := stageNode{ID: stage.Type, DependsOn: stage.DependsOn, Status: queue.StageStatus(stageJobs), Jobs: []jobNode{}}
+		for _, j := range stageJobs {
+			warnings, dropped := j.Warnings()
+			node.Jobs = append(node.Jobs, jobNode{
+				ID:              j.ID,
+				Status:          j.Status,
+				Progress:        j.Progress,
+				CreatedAt:       j.CreatedAt,
+				StartedAt:       j.StartedAt,
+				CompletedAt:     j.CompletedAt,
+				DurationSeconds: jobDuration(j.StartedAt, j.CompletedAt),
+				ErrorMessage:    j.ErrorMessage,
+				ErrorCode:       j.ErrorCode,
+				WarningCount:    len(warnings) + dropped,
+				ETA:             etas[j.ID],
+			})
+			if j.StartedAt != nil && (node.StartedAt == nil || j.StartedAt.Before(*node.StartedAt)) {
+				node.StartedAt = j.StartedAt
+			}
+		}
+		if n := len(stageJobs); n > 0 && node.Status == string(queue.JobStatusCompleted) {
+			node.CompletedAt = stageJobs[n-1].CompletedAt
+		}
+		node.DurationSeconds = jobDuration(node.StartedAt, node.CompletedAt)
+		nodes = append(nodes, node)
+		for _, dep := range stage.DependsOn {
+			edges = append(edges, edge{From: dep, To: stage.Type})
+		}
+	}
+
+	resp := gin.H{
+		"video_id":     video.ID,
+		"video_status": video.Status,
+		"stages":       nodes,
+		"edges":        edges,
+	}
+	if eta := estimator.videoETA(jobs, etas); eta != nil {
+		resp["eta"] = eta
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// jobDuration returns the elapsed seconds of a job; running jobs are measured up to now
+func jobDuration(startedAt, completedAt *time.Time) *float64 {
+	if startedAt == nil {
+		return nil
+	}
+	end := time.Now()
+	if completedAt != nil {
+		end = *completedAt
+	}
+	d := end.Sub(*startedAt).Seconds()
+	return &d
+}
+
 func deleteVideo(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -533,6 +1755,20 @@ func deleteVideo(c *gin.Context) {
 	}
 
 	if err := db.DeleteVideo(uint(id)); err != nil {
+		if errors.Is(err, database.ErrVideoLocked) {
+			c.JSON(http.StatusLocked, gin.H{
+				"error": "Video is locked",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, database.ErrVideoShared) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Video content is shared",
+				"details": err.Error() + "; delete the sharing videos first",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete video",
 			"details": err.Error(),
@@ -546,11 +1782,40 @@ func deleteVideo(c *gin.Context) {
 }
 
 func searchSemantic(c *gin.Context) {
+    started := time.Now()
     // Local request type to avoid strict validator tags in models.SearchRequest
     var req struct {
-        Query    string `json:"query"`
-        VideoIDs []uint `json:"video_ids"`
-        Limit    int    `json:"limit"`
+        Query        string `json:"query"`
+        VideoIDs     []uint `json:"video_ids"`
+        Limit        int    `json:"limit"`
+        Language     string `json:"language"`
+        LanguageMode string `json:"language_mode"`
+        // PopularityWeight boosts scenes users frequently chose for the same query
+        PopularityWeight *float64 `json:"popularity_weight"`
+        // Level selects scene granularity: "shot" (default) or "beat"
+        Level string `json:"level"`
+        // AssetTypes restricts results to video, audio and/or image assets
+        AssetTypes []string `json:"asset_types"`
+        // TwoStage first shortlists the top Shortlist videos by video-level embedding, then searches their scenes
+        TwoStage  bool `json:"two_stage"`
+        Shortlist int  `json:"shortlist"`
+        // Context returns ±N neighbouring scenes with captions around each hit
+        Context int `json:"context"`
+        // Entities restricts results to scenes whose captions mention any of these names
+        Entities []string `json:"entities"`
+        // ExcludeFlagged drops scenes with content flags (in FlagCategories, any when empty)
+        ExcludeFlagged bool     `json:"exclude_flagged"`
+        FlagCategories []string `json:"flag_categories"`
+        // SimilarityThreshold drops results scoring below it (0 to 1; 0 keeps everything)
+        SimilarityThreshold float64 `json:"similarity_threshold"`
+        // Emotional tone filters and ordering (emotions, min/max_sentiment, min_intensity, sort_by)
+        toneQuery
+        // Video tag, status and project filters and scene duration bounds
+        videoQuery
+        // Result diversity: MMR re-ranking (diversity, mmr_lambda) and max_per_video
+        diversityQuery
+        // Cross-encoder re-ranking of the top candidates by their captions (rerank, rerank_candidates)
+        rerankQuery
     }
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{
@@ -559,6 +1824,20 @@ func searchSemantic(c *gin.Context) {
         })
         return
     }
+    if !similarityThresholdParam(c, req.SimilarityThreshold) || !req.diversityQuery.validate(c) || !req.rerankQuery.validate(c) {
+        return
+    }
+
+    filter, ok := sceneFilterParam(c, req.VideoIDs, req.Level, req.AssetTypes)
+    if !ok {
+        return
+    }
+    filter.Entities = req.Entities
+    filter.ExcludeFlagged, filter.FlagCategories = req.ExcludeFlagged, req.FlagCategories
+    if !req.toneQuery.apply(c, &filter) || !req.videoQuery.apply(c, &filter) {
+        return
+    }
+    level := filter.Level
 
     // Defaults
     limit := req.Limit
@@ -569,8 +1848,25 @@ func searchSemantic(c *gin.Context) {
         limit = 100
     }
 
+    // Translate or switch to the multilingual embedder for non-English queries
+    queryText, modelID, langInfo, err := prepareTextQuery(req.Query, req.Language, req.LanguageMode)
+    if err != nil {
+        status := http.StatusInternalServerError
+        if errors.Is(err, errLanguageMode) {
+            status = http.StatusBadRequest
+        }
+        c.JSON(status, gin.H{
+            "error":   "Failed to prepare query",
+            "details": err.Error(),
+        })
+        return
+    }
+
+    // Expand domain aliases (nicknames, locations) from the synonym dictionary
+    expanded := currentSynonyms().ExpandQuery(queryText)
+
     // Embed the query in text space (e5-base-v2)
-    vec, err := embedTextQuery(req.Query)
+    vec, err := embedTextQueryWithModel(expanded, modelID)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "error":   "Failed to embed query",
@@ -579,8 +1875,24 @@ func searchSemantic(c *gin.Context) {
         return
     }
 
+    // Two-stage: shortlist videos by their video-level (e5-base-v2) embedding first
+    var shortlist gin.H
+    if req.TwoStage {
+        shortlistVec := vec
+        if modelID != "" {
+            if shortlistVec, err = embedTextQuery(expanded); err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+                return
+            }
+        }
+        if shortlist, err = applyVideoShortlist(&filter, shortlistVec, shortlistSize(req.Shortlist)); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Video shortlist failed", "details": err.Error()})
+            return
+        }
+    }
+
     // DB vector search on scenes.text_embedding
-    scenes, dists, err := db.SearchScenesByTextVector(vec, limit, req.VideoIDs)
+    scenes, dists, err := db.SearchScenesByTextVector(vec, req.rerankQuery.candidates(limit, req.diversityQuery.candidates(limit)), filter)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "error":   "Search failed",
@@ -589,14 +1901,59 @@ func searchSemantic(c *gin.Context) {
         return
     }
 
+    // Weak matches are dropped before the popularity prior can reorder them
+    metric := database.MetricForColumn(database.ColumnText)
+    kept := aboveThreshold(metric, dists, req.SimilarityThreshold)
+    dropped := len(scenes) - kept
+    scenes, dists = scenes[:kept], dists[:kept]
+
+    // Optional learned popularity prior from click feedback on the same query
+    sims := make([]float64, len(dists))
+    for i, d := range dists {
+        sims[i] = metric.Similarity(d)
+    }
+    order, popularity := popularityOrder(req.Query, scenes, sims, popularityWeight(req.PopularityWeight))
+
+    scores := make([]float64, len(dists))
+    for i, d := range dists {
+        scores[i] = metric.Score(d)
+    }
+
+    // Optional cross-encoder pass over the candidates' captions; its scores then rank them
+    var warnings []string
+    var rerankScores map[int]float64
+    var rerank *rerankResult
+    relevance := scores
+    if req.Rerank {
+        reranked, rs, info, err := rerankScenes(queryText, scenes, order)
+        if err != nil {
+            warnings = append(warnings, rerankFailed(err))
+        } else {
+            order, rerankScores, rerank = reranked, rs, info
+            relevance = make([]float64, len(scenes))
+            for i, s := range rs {
+                relevance[i] = s
+            }
+        }
+    }
+
+    // Near-duplicates and scenes over the per-video cap give way to the next candidates
+    candidates := len(scenes)
+    order = req.diversify(scenes, order, relevance, []string{database.ColumnText}, limit)
+
     items := make([]gin.H, 0, len(scenes))
-    for i, s := range scenes {
-        items = append(items, gin.H{
+    ordered := make([]models.Scene, 0, len(scenes))
+    for _, i := range order {
+        s := scenes[i]
+        ordered = append(ordered, s)
+        item := gin.H{
             "scene": gin.H{
                 "id":            s.ID,
                 "uuid":          s.UUID,
                 "video_id":      s.VideoID,
+                "level":         s.Level,
                 "scene_index":   s.SceneIndex,
+                "beat_index":    s.BeatIndex,
                 "start_time":    s.StartTime,
                 "end_time":      s.EndTime,
                 "duration":      s.Duration,
@@ -604,16 +1961,65 @@ func searchSemantic(c *gin.Context) {
                 "caption_count": s.CaptionCount,
                 "created_at":    s.CreatedAt,
             },
-            "distance": dists[i],
-        })
-    }
-
-    c.JSON(http.StatusOK, gin.H{
-        "query":   req.Query,
-        "limit":   limit,
-        "count":   len(items),
-        "results": items,
-    })
+            "distance":   dists[i],
+            "similarity": sims[i],
+            "score":      metric.Score(dists[i]),
+        }
+        if popularity != nil {
+            item["feedback_count"] = popularity[s.ID]
+        }
+        if rs, ok := rerankScores[i]; ok {
+            item["rerank_score"] = rs
+        }
+        items = append(items, item)
+    }
+    attachSceneTones(items, ordered, req.SortBy)
+    attachSceneThumbnails(items, ordered)
+    attachSceneContext(items, ordered, clampSceneContext(req.Context))
+    attachSceneResults(items, ordered, queryText)
+
+    searchID := recordSearchEvent("semantic", req.Query, map[string]any{
+        "video_ids": req.VideoIDs,
+        "limit":     limit,
+        "language":    req.Language,
+        "level":       level,
+        "asset_types": req.AssetTypes,
+        "two_stage":   req.TwoStage,
+        "entities":    req.Entities,
+        "exclude_flagged": req.ExcludeFlagged,
+        "emotions":    req.Emotions,
+        "sort_by":     req.SortBy,
+        "similarity_threshold": req.SimilarityThreshold,
+        "diversity":   req.Diversity,
+        "max_per_video": req.MaxPerVideo,
+        "rerank":      req.Rerank,
+    }, started, sceneIDsOf(ordered))
+    resp := gin.H{
+        "search_id": searchID,
+        "query":     req.Query,
+        "limit":     limit,
+        "level":     level,
+        "count":     len(items),
+        "results":   items,
+    }
+    if langInfo != nil {
+        resp["language"] = langInfo
+    }
+    if expanded != queryText {
+        resp["expanded_query"] = expanded
+    }
+    if shortlist != nil {
+        resp["shortlist"] = shortlist
+    }
+    thresholdInfo(resp, req.SimilarityThreshold, dropped)
+    req.diversityQuery.info(resp, candidates)
+    if rerank != nil {
+        resp["rerank"] = rerank
+    }
+    if len(warnings) > 0 {
+        resp["warnings"] = warnings
+    }
+    c.JSON(http.StatusOK, resp)
 }
 // Helper function to get environment variable or default value
 func getEnvOrDefault(key, defaultValue string) string {
@@ -625,10 +2031,22 @@ func getEnvOrDefault(key, defaultValue string) string {
 
 // embedTextQuery runs the e5-base-v2 text embedding runner to obtain a 768-D vector for the query
 func embedTextQuery(query string) ([]float32, error) {
+    return embedTextQueryWithModel(query, "")
+}
+
+// embedTextQueryWithModel embeds the query with the text runner, overriding the model when modelID is
+// set; otherwise the active text model (see the rolling model upgrade endpoints) is used
+func embedTextQueryWithModel(query, modelID string) ([]float32, error) {
     payload := map[string]any{
         "text": query,
         "mode": "query",
     }
+    if modelID == "" {
+        modelID = processor.ActiveTextModelID()
+    }
+    if modelID != "" {
+        payload["model_id"] = modelID
+    }
     b, _ := json.Marshal(payload)
     cmd := exec.Command("python3", "/root/internal/embeddings/text_embed_runner.py")
     cmd.Stdin = bytes.NewReader(b)
@@ -660,6 +2078,86 @@ func embedTextQuery(query string) ([]float32, error) {
     return resp.Vector, nil
 }
 
+// translateQuery translates a query into English with the translation runner
+func translateQuery(query, sourceLang string) (string, error) {
+    payload := map[string]any{"text": query, "source_lang": sourceLang, "target_lang": "en"}
+    b, _ := json.Marshal(payload)
+    cmd := exec.Command("python3", "/root/internal/embeddings/translate_runner.py")
+    cmd.Stdin = bytes.NewReader(b)
+    stdout, _ := cmd.StdoutPipe()
+    stderr, _ := cmd.StderrPipe()
+    if err := cmd.Start(); err != nil {
+        return "", fmt.Errorf("failed to start translate_runner: %w", err)
+    }
+    outBytes, _ := io.ReadAll(stdout)
+    errBytes, _ := io.ReadAll(stderr)
+    if err := cmd.Wait(); err != nil {
+        return "", fmt.Errorf("translate_runner failed: %v; stderr: %s", err, string(errBytes))
+    }
+    var resp struct {
+        Model       string `json:"model"`
+        Translation string `json:"translation"`
+        Error       string `json:"error"`
+    }
+    if err := json.Unmarshal(outBytes, &resp); err != nil {
+        return "", fmt.Errorf("failed to parse translate_runner output: %v; raw: %s", err, string(outBytes))
+    }
+    if resp.Error != "" {
+        return "", fmt.Errorf("runner error: %s", resp.Error)
+    }
+    if strings.TrimSpace(resp.Translation) == "" {
+        return "", fmt.Errorf("empty translation returned")
+    }
+    return resp.Translation, nil
+}
+
+// errLanguageMode is returned by prepareTextQuery for an unknown language mode
+var errLanguageMode = errors.New("unknown language_mode")
+
+// prepareTextQuery applies the cross-language strategy for a query written in language.
+// Modes: "translate" (translate to English, then embed with the library model), "multilingual"
+// (embed as-is with TEXT_EMBED_MULTILINGUAL_MODEL_ID), or "none". Multilingual falls back to
+// translate unless the library's text embeddings were made with the multilingual model, since
+// vectors of different models do not compare. It returns the text to embed, a model override and
+// response details describing what was done; errors other than errLanguageMode are failures of the
+// translation runner.
+func prepareTextQuery(query, language, mode string) (string, string, gin.H, error) {
+    lang := strings.ToLower(strings.TrimSpace(language))
+    if lang == "" || lang == "en" || lang == "eng" {
+        return query, "", nil, nil
+    }
+    if mode == "" {
+        mode = getEnvOrDefault("QUERY_LANGUAGE_MODE", "translate")
+    }
+    switch mode {
+    case "translate":
+        translated, err := translateQuery(query, lang)
+        if err != nil {
+            return "", "", nil, err
+        }
+        return translated, "", gin.H{"mode": mode, "language": lang, "translated_query": translated}, nil
+    case "multilingual":
+        modelID := getEnvOrDefault("TEXT_EMBED_MULTILINGUAL_MODEL_ID", "intfloat/multilingual-e5-base")
+        library := processor.ActiveTextModelID()
+        if library == "" {
+            library = processor.BuiltinTextModel().ModelID
+        }
+        if modelID != library {
+            translated, err := translateQuery(query, lang)
+            if err != nil {
+                return "", "", nil, err
+            }
+            return translated, "", gin.H{"mode": "translate", "requested_mode": mode, "language": lang, "translated_query": translated,
+                "reason": "scene text embeddings were made with " + library + ", not " + modelID}, nil
+        }
+        return query, modelID, gin.H{"mode": mode, "language": lang, "model": modelID}, nil
+    case "none":
+        return query, "", gin.H{"mode": mode, "language": lang}, nil
+    default:
+        return "", "", nil, fmt.Errorf("%w: %s", errLanguageMode, mode)
+    }
+}
+
 // embedCLIPTextQuery embeds a text query with CLIP (text tower)
 func embedCLIPTextQuery(query string) ([]float32, error) {
     payload := map[string]any{"text": query, "mode": "text"}
@@ -727,96 +2225,3 @@ func embedCLAPTextQuery(query string) ([]float32, error) {
     }
     return resp.Vector, nil
 }
-
-// searchMultiModal embeds the query in text (e5), CLIP text, and CLAP text spaces, searches each modality,
-// and fuses scores via weighted sum. Weights default to 1.0 for text/clip and 0.5 for audio.
-func searchMultiModal(c *gin.Context) {
-    var req struct {
-        Query    string             `json:"query"`
-        VideoIDs []uint             `json:"video_ids"`
-        Limit    int                `json:"limit"`
-        Weights  map[string]float64 `json:"weights"`
-    }
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
-        return
-    }
-    if req.Query == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
-        return
-    }
-    k := req.Limit
-    if k <= 0 { k = 10 }
-    if k > 100 { k = 100 }
-    wText, wClip, wAudio := 1.0, 1.0, 0.5
-    if req.Weights != nil {
-        if v, ok := req.Weights["text"]; ok { wText = v }
-        if v, ok := req.Weights["clip"]; ok { wClip = v }
-        if v, ok := req.Weights["audio"]; ok { wAudio = v }
-    }
-    // Embed per modality
-    textVec, err := embedTextQuery(req.Query)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed text query", "details": err.Error()})
-        return
-    }
-    clipVec, err := embedCLIPTextQuery(req.Query)
-    if err != nil { log.Printf("Warning: CLIP text embed failed: %v", err); clipVec = nil }
-    clapVec, err := embedCLAPTextQuery(req.Query)
-    if err != nil { log.Printf("Warning: CLAP text embed failed: %v", err); clapVec = nil }
-
-    type agg struct {
-        scene  models.Scene
-        textD  *float64
-        clipD  *float64
-        audioD *float64
-    }
-    byID := map[uint]*agg{}
-    if textVec != nil {
-        ts, td, err := db.SearchScenesByTextVector(textVec, k, req.VideoIDs)
-        if err == nil {
-            for i, s := range ts { d := td[i]; a := byID[s.ID]; if a == nil { a = &agg{scene: s}; byID[s.ID] = a }; a.textD = &d }
-        } else { log.Printf("Warning: text vector search failed: %v", err) }
-    }
-    if clipVec != nil {
-        cs, cd, err := db.SearchScenesByClipVector(clipVec, k, req.VideoIDs)
-        if err == nil {
-            for i, s := range cs { d := cd[i]; a := byID[s.ID]; if a == nil { a = &agg{scene: s}; byID[s.ID] = a }; a.clipD = &d }
-        } else { log.Printf("Warning: CLIP vector search failed: %v", err) }
-    }
-    if clapVec != nil {
-        as, ad, err := db.SearchScenesByAudioVector(clapVec, k, req.VideoIDs)
-        if err == nil {
-            for i, s := range as { d := ad[i]; a := byID[s.ID]; if a == nil { a = &agg{scene: s}; byID[s.ID] = a }; a.audioD = &d }
-        } else { log.Printf("Warning: audio vector search failed: %v", err) }
-    }
-    type item struct { Scene models.Scene; Scores map[string]any; Fused float64 }
-    items := make([]item, 0, len(byID))
-    for _, a := range byID {
-        var simText, simClip, simAudio float64
-        if a.textD != nil { simText = 1.0 - *a.textD }
-        if a.clipD != nil { simClip = 1.0 - *a.clipD }
-        if a.audioD != nil { simAudio = 1.0 - *a.audioD }
-        fused := wText*simText + wClip*simClip + wAudio*simAudio
-        items = append(items, item{ Scene: a.scene, Fused: fused, Scores: map[string]any{
-            "text_distance": a.textD, "clip_distance": a.clipD, "audio_distance": a.audioD,
-            "text_similarity": simText, "clip_similarity": simClip, "audio_similarity": simAudio,
-        }})
-    }
-    sort.Slice(items, func(i, j int) bool { return items[i].Fused > items[j].Fused })
-    if len(items) > k { items = items[:k] }
-    out := make([]gin.H, 0, len(items))
-    for _, it := range items {
-        s := it.Scene
-        out = append(out, gin.H{
-            "scene": gin.H{
-                "id": s.ID, "uuid": s.UUID, "video_id": s.VideoID, "scene_index": s.SceneIndex,
-                "start_time": s.StartTime, "end_time": s.EndTime, "duration": s.Duration,
-                "has_captions": s.HasCaptions, "caption_count": s.CaptionCount, "created_at": s.CreatedAt,
-            },
-            "scores": it.Scores, "fused_score": it.Fused,
-        })
-    }
-    c.JSON(http.StatusOK, gin.H{"query": req.Query, "limit": k, "count": len(out),
-        "weights": gin.H{"text": wText, "clip": wClip, "audio": wAudio}, "results": out})
-}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queueControlRequest names the job types to pause or resume; none means all of them
+type queueControlRequest struct {
+	JobTypes []queue.JobType `json:"job_types"`
+	Reason   string          `json:"reason"`
+}
+
+// bind parses the optional body and validates its job types, writing a 400 when invalid
+func (r *queueControlRequest) bind(c *gin.Context) bool {
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return false
+		}
+	}
+	for _, jt := range r.JobTypes {
+		known := false
+		for _, k := range queue.AllJobTypes {
+			known = known || jt == k
+		}
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job type", "details": string(jt)})
+			return false
+		}
+	}
+	return true
+}
+
+// getQueueState reports paused job types, the queue windows in force, the runner circuit breakers
+// that are not closed and the backlog: pending jobs per type, delayed jobs and jobs still running. The pipeline is drained once
+// nothing is running (admin).
+func getQueueState(c *gin.Context) {
+	pauses, err := jobQueue.Pauses()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read queue state", "details": err.Error()})
+		return
+	}
+	status, err := jobQueue.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read queue state", "details": err.Error()})
+		return
+	}
+	breakers, err := jobQueue.Breakers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read queue state", "details": err.Error()})
+		return
+	}
+	windows, err := jobQueue.Windows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read queue state", "details": err.Error()})
+		return
+	}
+	throughput, err := jobQueue.Throughputs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read queue state", "details": err.Error()})
+		return
+	}
+	active := []queue.QueueWindow{}
+	now := time.Now()
+	for _, w := range windows {
+		if w.ActiveAt(now) {
+			active = append(active, w)
+		}
+	}
+	global := false
+	for _, p := range pauses {
+		global = global || p.JobType == ""
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"paused":     global,
+		"pauses":     pauses,
+		"windows":    active,
+		"breakers":   breakers,
+		"throughput": throughput,
+		"pending":    status.Pending,
+		"delayed":    status.Delayed,
+		"running":    status.Running,
+		"drained":    len(status.Running) == 0,
+	})
+}
+
+// resetBreaker closes a runner's circuit breaker, e.g. after fixing the GPU host, so deferred jobs
+// run when they come due without waiting for a health probe (admin)
+func resetBreaker(c *gin.Context) {
+	if err := jobQueue.RecordCallSuccess(c.Param("name")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset breaker", "details": err.Error()})
+		return
+	}
+	getQueueState(c)
+}
+
+// pauseQueue stops workers taking jobs, globally or for the given types:
+// {"job_types": ["embedding_generation"], "reason": "model upgrade"} (admin)
+func pauseQueue(c *gin.Context) {
+	var req queueControlRequest
+	if !req.bind(c) {
+		return
+	}
+	types := req.JobTypes
+	if len(types) == 0 {
+		types = []queue.JobType{""}
+	}
+	for _, jt := range types {
+		if err := jobQueue.Pause(jt, req.Reason); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pause queue", "details": err.Error()})
+			return
+		}
+	}
+	getQueueState(c)
+}
+
+// resumeQueue lets workers take jobs again, for the given types or (with no body) everything (admin)
+func resumeQueue(c *gin.Context) {
+	var req queueControlRequest
+	if !req.bind(c) {
+		return
+	}
+	types := req.JobTypes
+	if len(types) == 0 {
+		types = []queue.JobType{""}
+	}
+	for _, jt := range types {
+		if err := jobQueue.Resume(jt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume queue", "details": err.Error()})
+			return
+		}
+	}
+	getQueueState(c)
+}
+
+// windowResponse is a queue window with whether it is in force now
+func windowResponse(w queue.QueueWindow) gin.H {
+	return gin.H{"window": w, "active": w.ActiveAt(time.Now())}
+}
+
+// listQueueWindows lists the recurring windows that pause or throttle job types (admin)
+func listQueueWindows(c *gin.Context) {
+	windows, err := jobQueue.Windows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list queue windows", "details": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(windows))
+	for _, w := range windows {
+		out = append(out, windowResponse(w))
+	}
+	c.JSON(http.StatusOK, gin.H{"windows": out, "count": len(out)})
+}
+
+// putQueueWindow creates or replaces a window, e.g. pausing embedding jobs on weekday evenings:
+// {"job_types": ["embedding_generation"], "days": ["mon","tue","wed","thu","fri"], "start": "17:00",
+// "end": "23:00", "timezone": "America/New_York", "action": "pause"} (admin)
+func putQueueWindow(c *gin.Context) {
+	var w queue.QueueWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	w.Name = c.Param("name")
+	if err := w.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid queue window", "details": err.Error()})
+		return
+	}
+	if err := jobQueue.SetWindow(&w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save queue window", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, windowResponse(w))
+}
+
+// deleteQueueWindow removes a window; jobs it held back are taken again at once (admin)
+func deleteQueueWindow(c *gin.Context) {
+	found, err := jobQueue.DeleteWindow(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete queue window", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Queue window not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Queue window deleted"})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sceneVectorSearch is a single-modality scene search: a query embedded into the space of one
+// scene embedding column and matched against it
+type sceneVectorSearch struct {
+	// modality names the search in analytics
+	modality string
+	column   string
+	// embed embeds text queries; nil for searches whose query is not text
+	embed  func(query string) ([]float32, error)
+	search func(vec []float32, k int, filter database.SceneFilter) ([]models.Scene, []float64, error)
+}
+
+// sceneSearchOptions are the filters and result options of a single-modality scene search
+type sceneSearchOptions struct {
+	VideoIDs       []uint   `json:"video_ids"`
+	Limit          int      `json:"limit"`
+	Level          string   `json:"level"`
+	AssetTypes     []string `json:"asset_types"`
+	Context        int      `json:"context"`
+	Entities       []string `json:"entities"`
+	ExcludeFlagged bool     `json:"exclude_flagged"`
+	FlagCategories []string `json:"flag_categories"`
+	// SimilarityThreshold drops results scoring below it (0 to 1; 0 keeps everything)
+	SimilarityThreshold float64 `json:"similarity_threshold"`
+	toneQuery
+	videoQuery
+	diversityQuery
+}
+
+// filter builds the scene filter and the clamped result limit and validates the similarity
+// threshold and diversity options; false once a 400 has been written
+func (o *sceneSearchOptions) filter(c *gin.Context) (database.SceneFilter, int, bool) {
+	if !similarityThresholdParam(c, o.SimilarityThreshold) || !o.diversityQuery.validate(c) {
+		return database.SceneFilter{}, 0, false
+	}
+	filter, ok := sceneFilterParam(c, o.VideoIDs, o.Level, o.AssetTypes)
+	if !ok {
+		return filter, 0, false
+	}
+	filter.Entities = o.Entities
+	filter.ExcludeFlagged, filter.FlagCategories = o.ExcludeFlagged, o.FlagCategories
+	if !o.toneQuery.apply(c, &filter) || !o.videoQuery.apply(c, &filter) {
+		return filter, 0, false
+	}
+	limit := o.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return filter, limit, true
+}
+
+// searchVisual finds scenes by what is on screen: the query is embedded with CLIP's text encoder
+// and matched against scenes.visual_clip_embedding, so scenes without dialogue are found too
+func searchVisual(c *gin.Context) {
+	sceneVectorSearch{
+		modality: "visual",
+		column:   database.ColumnVisualClip,
+		embed:    embedCLIPTextQuery,
+		search:   db.SearchScenesByClipVector,
+	}.handle(c)
+}
+
+// searchAudio finds scenes by how they sound: the query is embedded with CLAP's text encoder and
+// matched against scenes.audio_embedding
+func searchAudio(c *gin.Context) {
+	sceneVectorSearch{
+		modality: "audio",
+		column:   database.ColumnAudio,
+		embed:    embedCLAPTextQuery,
+		search:   db.SearchScenesByAudioVector,
+	}.handle(c)
+}
+
+func (s sceneVectorSearch) handle(c *gin.Context) {
+	started := time.Now()
+	var req struct {
+		Query string `json:"query"`
+		sceneSearchOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	filter, limit, ok := req.filter(c)
+	if !ok {
+		return
+	}
+
+	vec, err := s.embed(req.Query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+		return
+	}
+	s.respond(c, started, req.Query, vec, req.sceneSearchOptions, filter, limit)
+}
+
+// respond matches an embedded query against the column and writes the results. query is recorded
+// in analytics and echoed back when not empty.
+func (s sceneVectorSearch) respond(c *gin.Context, started time.Time, query string, vec []float32, opts sceneSearchOptions, filter database.SceneFilter, limit int) {
+	scenes, dists, err := s.search(vec, opts.candidates(limit), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+		return
+	}
+
+	metric := database.MetricForColumn(s.column)
+	kept := aboveThreshold(metric, dists, opts.SimilarityThreshold)
+	dropped := len(scenes) - kept
+	scenes, dists = scenes[:kept], dists[:kept]
+	candidates := len(scenes)
+	order := make([]int, len(scenes))
+	scores := make([]float64, len(scenes))
+	for i, d := range dists {
+		order[i], scores[i] = i, metric.Score(d)
+	}
+	order = opts.diversify(scenes, order, scores, []string{s.column}, limit)
+
+	items := make([]gin.H, 0, len(order))
+	ordered := make([]models.Scene, 0, len(order))
+	for _, i := range order {
+		sc := scenes[i]
+		ordered = append(ordered, sc)
+		items = append(items, gin.H{
+			"scene": gin.H{
+				"id": sc.ID, "uuid": sc.UUID, "video_id": sc.VideoID, "level": sc.Level, "scene_index": sc.SceneIndex, "beat_index": sc.BeatIndex,
+				"start_time": sc.StartTime, "end_time": sc.EndTime, "duration": sc.Duration,
+				"has_captions": sc.HasCaptions, "caption_count": sc.CaptionCount, "created_at": sc.CreatedAt,
+			},
+			"distance":   dists[i],
+			"similarity": metric.Similarity(dists[i]),
+			"score":      scores[i],
+		})
+	}
+	attachSceneTones(items, ordered, opts.SortBy)
+	attachSceneThumbnails(items, ordered)
+	attachSceneContext(items, ordered, clampSceneContext(opts.Context))
+	attachSceneResults(items, ordered, query)
+
+	searchID := recordSearchEvent(s.modality, query, map[string]any{
+		"video_ids":            opts.VideoIDs,
+		"limit":                limit,
+		"level":                filter.Level,
+		"asset_types":          opts.AssetTypes,
+		"entities":             opts.Entities,
+		"exclude_flagged":      opts.ExcludeFlagged,
+		"emotions":             opts.Emotions,
+		"sort_by":              opts.SortBy,
+		"similarity_threshold": opts.SimilarityThreshold,
+		"diversity":            opts.Diversity,
+		"max_per_video":        opts.MaxPerVideo,
+	}, started, sceneIDsOf(ordered))
+	resp := gin.H{
+		"search_id": searchID,
+		"limit":     limit,
+		"level":     filter.Level,
+		"metric":    metric,
+		"count":     len(items),
+		"results":   items,
+	}
+	if query != "" {
+		resp["query"] = query
+	}
+	thresholdInfo(resp, opts.SimilarityThreshold, dropped)
+	opts.diversityQuery.info(resp, candidates)
+	c.JSON(http.StatusOK, resp)
+}
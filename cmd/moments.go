@@ -0,0 +1,281 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxMomentLimit caps the moments returned by one video search
+const maxMomentLimit = 50
+
+// Moment orders of POST /videos/:id/search
+const (
+	// momentOrderTime lists the best moments in playback order, for markers on a timeline
+	momentOrderTime = "time"
+	// momentOrderRelevance lists them best first
+	momentOrderRelevance = "relevance"
+)
+
+// momentScene is the scene a moment falls in
+type momentScene struct {
+	ID           uint    `json:"id"`
+	SceneIndex   int     `json:"scene_index"`
+	StartTime    float64 `json:"start_time"`
+	EndTime      float64 `json:"end_time"`
+	ThumbnailURL string  `json:"thumbnail_url,omitempty"`
+}
+
+// videoMoment is a part of a video matching a query: a scene, refined to its best matching caption
+// passage when there is one, or a passage outside any scene
+type videoMoment struct {
+	// Rank is the moment's position by score (1 is best), whatever the order of the list
+	Rank int `json:"rank"`
+	// Offset is where the player should jump to: the best passage's start, else the scene's
+	Offset float64 `json:"offset"`
+	End    float64 `json:"end"`
+	// Score is the best of Scores, each a 0–1 score (see database.Metric.Score) of the moment in
+	// the "text" (scene captions), "visual" (CLIP) or "passage" (caption passages) search
+	Score   float64              `json:"score"`
+	Scores  map[string]float64   `json:"scores"`
+	Scene   *momentScene         `json:"scene,omitempty"`
+	Passage *database.PassageHit `json:"passage,omitempty"`
+}
+
+// score records a modality's score of the moment, keeping the best per modality
+func (m *videoMoment) score(modality string, score float64) {
+	if prev, ok := m.Scores[modality]; !ok || score > prev {
+		m.Scores[modality] = score
+	}
+	if score > m.Score {
+		m.Score = score
+	}
+}
+
+// searchVideoMoments searches within a single video and returns time-coded moments, for "jump to
+// the part where…" in a player:
+// {"query": "they open the vault", "modalities": ["text", "visual"], "limit": 10, "order": "time"}
+// The query is matched against the video's scenes by caption text (e5) and by what is on screen
+// (CLIP), and against its caption passages, which pin a moment to the second. Each scene's score
+// is its best in any of them. The best limit moments are returned in playback order, or best first
+// with "order": "relevance".
+func searchVideoMoments(c *gin.Context) {
+	started := time.Now()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	var req struct {
+		Query string `json:"query"`
+		// Modalities are "text" (scene captions and caption passages) and "visual" (CLIP); both
+		// by default
+		Modalities []string `json:"modalities"`
+		Limit      int      `json:"limit"`
+		// Level selects the scene granularity: "shot" (default) or "beat"
+		Level string `json:"level"`
+		// Order is "time" (default) or "relevance"
+		Order string `json:"order"`
+		// SimilarityThreshold drops each search's candidates scoring below it (0 to 1; 0 keeps everything)
+		SimilarityThreshold float64 `json:"similarity_threshold"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	if !similarityThresholdParam(c, req.SimilarityThreshold) {
+		return
+	}
+	modalities := map[string]bool{}
+	for _, m := range req.Modalities {
+		if m != "text" && m != "visual" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid modalities", "details": "unknown modality " + m})
+			return
+		}
+		modalities[m] = true
+	}
+	if len(modalities) == 0 {
+		modalities["text"], modalities["visual"] = true, true
+	}
+	order := req.Order
+	if order == "" {
+		order = momentOrderTime
+	}
+	if order != momentOrderTime && order != momentOrderRelevance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order", "details": "order must be time or relevance"})
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > maxMomentLimit {
+		limit = maxMomentLimit
+	}
+	filter, ok := sceneFilterParam(c, []uint{uint(id)}, req.Level, nil)
+	if !ok {
+		return
+	}
+	video, err := db.GetVideoByID(uint(id))
+	if err != nil || video.Status == models.VideoStatusDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+
+	byScene := map[uint]*videoMoment{}
+	var moments []*videoMoment
+	sceneMoment := func(s models.Scene) *videoMoment {
+		m := byScene[s.ID]
+		if m == nil {
+			m = &videoMoment{
+				Offset: s.StartTime,
+				End:    s.EndTime,
+				Scores: map[string]float64{},
+				Scene:  &momentScene{ID: s.ID, SceneIndex: s.SceneIndex, StartTime: s.StartTime, EndTime: s.EndTime},
+			}
+			byScene[s.ID] = m
+			moments = append(moments, m)
+		}
+		return m
+	}
+	candidates := limit * hybridCandidateFactor
+	var warnings []string
+	dropped := 0
+	searched := 0
+
+	if modalities["text"] {
+		vec, err := embedTextQuery(currentSynonyms().ExpandQuery(req.Query))
+		if err != nil {
+			log.Printf("Warning: text query embed failed: %v", err)
+			warnings = append(warnings, "text search unavailable: "+err.Error())
+		} else {
+			searched++
+			metric := database.MetricForColumn(database.ColumnText)
+			scenes, dists, err := db.SearchScenesByTextVector(vec, candidates, filter)
+			if err != nil {
+				log.Printf("Warning: text vector search failed: %v", err)
+				warnings = append(warnings, "text search failed: "+err.Error())
+			}
+			kept := aboveThreshold(metric, dists, req.SimilarityThreshold)
+			dropped += len(scenes) - kept
+			for i, s := range scenes[:kept] {
+				sceneMoment(s).score("text", metric.Score(dists[i]))
+			}
+
+			// Passages come nearest first, so the first one of a scene sets its offset
+			hits, err := db.SearchCaptionPassages(vec, candidates, filter.VideoIDs, filter.Level)
+			if err != nil {
+				log.Printf("Warning: passage search failed: %v", err)
+				warnings = append(warnings, "passage search failed: "+err.Error())
+			}
+			pdists := make([]float64, len(hits))
+			for i, h := range hits {
+				pdists[i] = h.Distance
+			}
+			kept = aboveThreshold(metric, pdists, req.SimilarityThreshold)
+			dropped += len(hits) - kept
+			for i := range hits[:kept] {
+				h := hits[i]
+				var m *videoMoment
+				if h.SceneID != nil && h.SceneIndex != nil && h.SceneStart != nil && h.SceneEnd != nil {
+					m = sceneMoment(models.Scene{ID: *h.SceneID, SceneIndex: *h.SceneIndex, StartTime: *h.SceneStart, EndTime: *h.SceneEnd})
+				} else {
+					// Passages outside any scene (e.g. scenes not detected yet) are moments of their own
+					m = &videoMoment{Scores: map[string]float64{}}
+					moments = append(moments, m)
+				}
+				if m.Passage == nil {
+					m.Passage = &h
+					m.Offset, m.End = h.StartTime, h.EndTime
+				}
+				m.score("passage", h.Score)
+			}
+		}
+	}
+	if modalities["visual"] {
+		vec, err := embedCLIPTextQuery(req.Query)
+		if err != nil {
+			log.Printf("Warning: visual query embed failed: %v", err)
+			warnings = append(warnings, "visual search unavailable: "+err.Error())
+		} else {
+			searched++
+			metric := database.MetricForColumn(database.ColumnVisualClip)
+			scenes, dists, err := db.SearchScenesByClipVector(vec, candidates, filter)
+			if err != nil {
+				log.Printf("Warning: visual vector search failed: %v", err)
+				warnings = append(warnings, "visual search failed: "+err.Error())
+			}
+			kept := aboveThreshold(metric, dists, req.SimilarityThreshold)
+			dropped += len(scenes) - kept
+			for i, s := range scenes[:kept] {
+				sceneMoment(s).score("visual", metric.Score(dists[i]))
+			}
+		}
+	}
+	if searched == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": strings.Join(warnings, "; ")})
+		return
+	}
+
+	sort.SliceStable(moments, func(i, j int) bool { return moments[i].Score > moments[j].Score })
+	if len(moments) > limit {
+		moments = moments[:limit]
+	}
+	sceneIDs := []uint{}
+	for i, m := range moments {
+		m.Rank = i + 1
+		if m.Scene != nil {
+			sceneIDs = append(sceneIDs, m.Scene.ID)
+		}
+	}
+	if has, err := db.SceneIDsWithKeyframes(sceneIDs); err != nil {
+		log.Printf("Warning: failed to load scene keyframes: %v", err)
+	} else {
+		for _, m := range moments {
+			if m.Scene != nil && has[m.Scene.ID] {
+				m.Scene.ThumbnailURL = sceneThumbnailURL(m.Scene.ID)
+			}
+		}
+	}
+	if order == momentOrderTime {
+		sort.SliceStable(moments, func(i, j int) bool { return moments[i].Offset < moments[j].Offset })
+	}
+
+	searchID := recordSearchEvent("moments", req.Query, map[string]any{
+		"video_ids":            []uint{video.ID},
+		"modalities":           req.Modalities,
+		"limit":                limit,
+		"level":                filter.Level,
+		"order":                order,
+		"similarity_threshold": req.SimilarityThreshold,
+	}, started, sceneIDs)
+	resp := gin.H{
+		"search_id": searchID,
+		"query":     req.Query,
+		"video": gin.H{
+			"id": video.ID, "uuid": video.UUID, "filename": video.Filename, "title": video.Title, "duration": video.Duration,
+		},
+		"limit":   limit,
+		"level":   filter.Level,
+		"order":   order,
+		"count":   len(moments),
+		"moments": moments,
+	}
+	thresholdInfo(resp, req.SimilarityThreshold, dropped)
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
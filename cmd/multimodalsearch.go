@@ -0,0 +1,272 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Normalizations applied to each modality's distances before /search/multimodal fuses them
+const (
+	// normalizeMinMax rescales a modality's candidate distances onto [0, 1]: its nearest candidate
+	// scores 1 and its farthest 0, so modalities with different distance ranges weigh alike
+	normalizeMinMax = "minmax"
+	// normalizeNone fuses each modality's 0–1 scores (see database.Metric.Score) as they are
+	normalizeNone = "none"
+)
+
+// multimodalModality is one embedding space fused by /search/multimodal
+type multimodalModality struct {
+	name   string
+	column string
+	// weight applies when the request gives none for the modality
+	weight float64
+	embed  func(query string) ([]float32, error)
+}
+
+// multimodalModalities are the fused modalities. visual needs the IV2 text encoder and is off
+// unless weighted.
+var multimodalModalities = []multimodalModality{
+	{name: "text", column: database.ColumnText, weight: 1, embed: embedTextQuery},
+	{name: "clip", column: database.ColumnVisualClip, weight: 1, embed: embedCLIPTextQuery},
+	{name: "audio", column: database.ColumnAudio, weight: 0.5, embed: embedCLAPTextQuery},
+	{name: "visual", column: database.ColumnVisual, weight: 0, embed: embedVisualTextQuery},
+}
+
+// embedVisualTextQuery embeds a query into scenes.visual_embedding with InternVideo2's text encoder
+func embedVisualTextQuery(query string) ([]float32, error) {
+	return videoProcessor.EmbedVisualTextQuery(query)
+}
+
+// normalizeDistances turns one modality's candidate distances into scores where higher is better
+func normalizeDistances(metric database.Metric, dists []float64, method string) []float64 {
+	scores := make([]float64, len(dists))
+	if method == normalizeNone {
+		for i, d := range dists {
+			scores[i] = metric.Score(d)
+		}
+		return scores
+	}
+	if len(dists) == 0 {
+		return scores
+	}
+	lo, hi := dists[0], dists[0]
+	for _, d := range dists {
+		if d < lo {
+			lo = d
+		}
+		if d > hi {
+			hi = d
+		}
+	}
+	for i, d := range dists {
+		if hi == lo {
+			scores[i] = 1
+			continue
+		}
+		scores[i] = (hi - d) / (hi - lo)
+	}
+	return scores
+}
+
+// searchMultiModal embeds the query in each weighted modality's space (e5 text, CLIP text, CLAP
+// text, IV2 text), searches each modality and fuses the normalized scores by weighted sum. A scene
+// missing from a modality's candidates scores 0 there. Modalities whose query embedding or search
+// fails are left out with a warning; the text modality must succeed. With similarity_threshold a
+// modality's candidates scoring below it are dropped before fusion, so every result matches the
+// query well in at least one modality.
+func searchMultiModal(c *gin.Context) {
+	started := time.Now()
+	var req struct {
+		Query string `json:"query"`
+		// Weights scale each modality: {"text": 1, "clip": 1, "audio": 0.5, "visual": 0} by default
+		Weights map[string]float64 `json:"weights"`
+		// Normalization is "minmax" (default) or "none"
+		Normalization string `json:"normalization"`
+		TwoStage      bool   `json:"two_stage"`
+		Shortlist     int    `json:"shortlist"`
+		sceneSearchOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	method := req.Normalization
+	if method == "" {
+		method = normalizeMinMax
+	}
+	if method != normalizeMinMax && method != normalizeNone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid normalization", "details": "normalization must be minmax or none"})
+		return
+	}
+	weights := map[string]float64{}
+	for _, m := range multimodalModalities {
+		weights[m.name] = m.weight
+	}
+	for name, w := range req.Weights {
+		if _, ok := weights[name]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weights", "details": "unknown modality " + name})
+			return
+		}
+		if w < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weights", "details": "weights must not be negative"})
+			return
+		}
+		weights[name] = w
+	}
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weights", "details": "at least one weight must be positive"})
+		return
+	}
+	filter, k, ok := req.filter(c)
+	if !ok {
+		return
+	}
+	candidates := k * hybridCandidateFactor
+
+	// The text embedding also drives the two-stage video shortlist
+	var textVec []float32
+	if weights["text"] > 0 || req.TwoStage {
+		var err error
+		if textVec, err = embedTextQuery(req.Query); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed text query", "details": err.Error()})
+			return
+		}
+	}
+	var shortlist gin.H
+	if req.TwoStage {
+		var err error
+		if shortlist, err = applyVideoShortlist(&filter, textVec, shortlistSize(req.Shortlist)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Video shortlist failed", "details": err.Error()})
+			return
+		}
+	}
+
+	type hit struct {
+		scene  models.Scene
+		scores map[string]any
+		fused  float64
+	}
+	byID := map[uint]*hit{}
+	var order []*hit
+	var warnings []string
+	var columns []string
+	dropped := 0
+	for _, m := range multimodalModalities {
+		w := weights[m.name]
+		if w == 0 {
+			continue
+		}
+		columns = append(columns, m.column)
+		vec := textVec
+		if m.name != "text" {
+			var err error
+			if vec, err = m.embed(req.Query); err != nil {
+				log.Printf("Warning: %s query embed failed: %v", m.name, err)
+				warnings = append(warnings, m.name+" search unavailable: "+err.Error())
+				continue
+			}
+		}
+		scenes, dists, err := db.SearchScenesByVector(m.column, vec, candidates, filter)
+		if err != nil {
+			log.Printf("Warning: %s vector search failed: %v", m.name, err)
+			warnings = append(warnings, m.name+" search failed: "+err.Error())
+			continue
+		}
+		metric := database.MetricForColumn(m.column)
+		kept := aboveThreshold(metric, dists, req.SimilarityThreshold)
+		dropped += len(scenes) - kept
+		scenes, dists = scenes[:kept], dists[:kept]
+		scores := normalizeDistances(metric, dists, method)
+		for i, s := range scenes {
+			h := byID[s.ID]
+			if h == nil {
+				h = &hit{scene: s, scores: map[string]any{}}
+				byID[s.ID] = h
+				order = append(order, h)
+			}
+			h.scores[m.name+"_distance"] = dists[i]
+			h.scores[m.name+"_similarity"] = metric.Similarity(dists[i])
+			h.scores[m.name+"_score"] = scores[i]
+			h.fused += w * scores[i]
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].fused > order[j].fused })
+	candidateScenes := make([]models.Scene, len(order))
+	fused := make([]float64, len(order))
+	ranked := make([]int, len(order))
+	for i, h := range order {
+		candidateScenes[i], fused[i], ranked[i] = h.scene, h.fused, i
+	}
+	picked := req.diversify(candidateScenes, ranked, fused, columns, k)
+
+	out := make([]gin.H, 0, len(picked))
+	hits := make([]models.Scene, 0, len(picked))
+	for _, i := range picked {
+		h := order[i]
+		s := h.scene
+		hits = append(hits, s)
+		out = append(out, gin.H{
+			"scene": gin.H{
+				"id": s.ID, "uuid": s.UUID, "video_id": s.VideoID, "level": s.Level, "scene_index": s.SceneIndex, "beat_index": s.BeatIndex,
+				"start_time": s.StartTime, "end_time": s.EndTime, "duration": s.Duration,
+				"has_captions": s.HasCaptions, "caption_count": s.CaptionCount, "created_at": s.CreatedAt,
+			},
+			"scores": h.scores, "fused_score": h.fused,
+		})
+	}
+	attachSceneTones(out, hits, req.SortBy)
+	attachSceneThumbnails(out, hits)
+	attachSceneContext(out, hits, clampSceneContext(req.Context))
+	attachSceneResults(out, hits, req.Query)
+	searchID := recordSearchEvent("multimodal", req.Query, map[string]any{
+		"video_ids":            req.VideoIDs,
+		"limit":                k,
+		"weights":              weights,
+		"normalization":        method,
+		"level":                filter.Level,
+		"asset_types":          req.AssetTypes,
+		"two_stage":            req.TwoStage,
+		"entities":             req.Entities,
+		"exclude_flagged":      req.ExcludeFlagged,
+		"emotions":             req.Emotions,
+		"sort_by":              req.SortBy,
+		"similarity_threshold": req.SimilarityThreshold,
+		"diversity":            req.Diversity,
+		"max_per_video":        req.MaxPerVideo,
+	}, started, sceneIDsOf(hits))
+	resp := gin.H{
+		"search_id":     searchID,
+		"query":         req.Query,
+		"limit":         k,
+		"level":         filter.Level,
+		"count":         len(out),
+		"weights":       weights,
+		"normalization": method,
+		"metrics":       database.EmbeddingMetrics(),
+		"results":       out,
+	}
+	if shortlist != nil {
+		resp["shortlist"] = shortlist
+	}
+	thresholdInfo(resp, req.SimilarityThreshold, dropped)
+	req.diversityQuery.info(resp, len(order))
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
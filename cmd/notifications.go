@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/notify"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// initNotifications routes published events to the notification channels stored in the database
+func initNotifications() {
+	notify.Init(func(msg notify.Message) ([]notify.Target, error) {
+		channels, err := db.NotificationChannelsFor(msg.Event, msg.Project)
+		if err != nil {
+			return nil, err
+		}
+		targets := make([]notify.Target, 0, len(channels))
+		for _, ch := range channels {
+			if notify.OptInEvents[msg.Event] && len(ch.Events) == 0 {
+				continue
+			}
+			targets = append(targets, notify.Target{Kind: ch.Kind, Address: ch.Target})
+		}
+		return targets, nil
+	})
+}
+
+// jobFailureThreshold is how many failures of one job type for one video, each within
+// NOTIFY_JOB_FAILURE_WINDOW_HOURS (default 24) of the last, raise job.failed_repeatedly
+// (NOTIFY_JOB_FAILURE_THRESHOLD, default 3)
+func jobFailureThreshold() (int, time.Duration) {
+	n, window := 3, 24*time.Hour
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_JOB_FAILURE_THRESHOLD")); err == nil && v > 0 {
+		n = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_JOB_FAILURE_WINDOW_HOURS")); err == nil && v > 0 {
+		window = time.Duration(v) * time.Hour
+	}
+	return n, window
+}
+
+// trackJobOutcome counts consecutive failures per job type and video, publishing
+// job.failed_repeatedly once when a streak reaches the threshold; a success resets the streak
+func trackJobOutcome(job *queue.Job, jobErr error) {
+	videoID, _ := queue.PayloadVideoID(job.Payload)
+	if jobErr == nil {
+		if err := jobQueue.ClearJobFailures(job.Type, videoID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		return
+	}
+	threshold, window := jobFailureThreshold()
+	n, err := jobQueue.RecordJobFailure(job.Type, videoID, window)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if n != threshold {
+		return
+	}
+	msg := notify.Message{
+		Event:   notify.EventJobFailedRepeatedly,
+		Subject: fmt.Sprintf("[goodclips] %s failed %d times", job.Type, n),
+		Text:    fmt.Sprintf("Job type %s failed %d times in a row. Last job %s: %v", job.Type, n, job.ID, jobErr),
+		Data:    map[string]any{"job_id": job.ID, "job_type": job.Type, "failures": n, "error": jobErr.Error()},
+		Time:    time.Now().UTC(),
+	}
+	if videoID != 0 {
+		msg.Text += "\n" + notify.PublicURL(fmt.Sprintf("/api/v1/videos/%d/jobs", videoID))
+		msg.Data["video_id"] = videoID
+		if video, err := db.GetVideoByID(videoID); err == nil {
+			msg.Project, _ = video.Metadata["project"].(string)
+		}
+	}
+	notify.Publish(msg)
+}
+
+// notificationChannelRequest is the body of channel create and update requests; omitted fields keep
+// their value on update
+type notificationChannelRequest struct {
+	Name    *string  `json:"name"`
+	Kind    *string  `json:"kind"`
+	Target  *string  `json:"target"`
+	Events  []string `json:"events"`
+	Project *string  `json:"project"`
+	Active  *bool    `json:"active"`
+}
+
+// applyTo validates the request and copies its fields onto ch, writing a 400 when invalid
+func (r notificationChannelRequest) applyTo(c *gin.Context, ch *models.NotificationChannel) bool {
+	if r.Name != nil {
+		ch.Name = strings.TrimSpace(*r.Name)
+	}
+	if r.Kind != nil {
+		ch.Kind = *r.Kind
+	}
+	if r.Target != nil {
+		ch.Target = strings.TrimSpace(*r.Target)
+	}
+	if r.Events != nil {
+		ch.Events = models.JSONStringArray(r.Events)
+	}
+	if r.Project != nil {
+		if p := strings.TrimSpace(*r.Project); p != "" {
+			ch.Project = &p
+		} else {
+			ch.Project = nil
+		}
+	}
+	if r.Active != nil {
+		ch.Active = *r.Active
+	}
+
+	if ch.Name == "" || ch.Target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and target are required"})
+		return false
+	}
+	for _, e := range ch.Events {
+		known := false
+		for _, k := range notify.Events {
+			known = known || e == k
+		}
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event", "details": fmt.Sprintf("%s (known: %s)", e, strings.Join(notify.Events, ", "))})
+			return false
+		}
+	}
+	switch ch.Kind {
+	case notify.KindWebhook, notify.KindSlack:
+		u, err := url.Parse(ch.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target", "details": "must be an http(s) URL"})
+			return false
+		}
+	case notify.KindEmail:
+		if _, err := mail.ParseAddressList(ch.Target); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target", "details": "must be comma-separated email addresses"})
+			return false
+		}
+		if !notify.EmailConfigured() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email notifications are not configured", "details": "set SMTP_HOST"})
+			return false
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind", "details": "kind must be webhook, slack or email"})
+		return false
+	}
+	return true
+}
+
+// listNotificationChannels returns all channels (admin)
+func listNotificationChannels(c *gin.Context) {
+	channels, err := db.ListNotificationChannels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list notification channels", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channels": channels, "count": len(channels), "events": notify.Events})
+}
+
+// createNotificationChannel adds a channel (admin):
+// {"name": "ops", "kind": "slack", "target": "https://hooks.slack.com/...", "events": ["job.failed_repeatedly"]}
+func createNotificationChannel(c *gin.Context) {
+	var req notificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	ch := &models.NotificationChannel{Active: true, Events: models.JSONStringArray{}}
+	if !req.applyTo(c, ch) {
+		return
+	}
+	if err := db.CreateNotificationChannel(ch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification channel", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"channel": ch})
+}
+
+// notificationChannelFromParam loads the channel addressed by :id, writing an error response when it cannot
+func notificationChannelFromParam(c *gin.Context) (*models.NotificationChannel, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return nil, false
+	}
+	ch, err := db.GetNotificationChannel(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load notification channel", "details": err.Error()})
+		return nil, false
+	}
+	return ch, true
+}
+
+// updateNotificationChannel changes a channel (admin)
+func updateNotificationChannel(c *gin.Context) {
+	ch, ok := notificationChannelFromParam(c)
+	if !ok {
+		return
+	}
+	var req notificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if !req.applyTo(c, ch) {
+		return
+	}
+	if err := db.UpdateNotificationChannel(ch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification channel", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channel": ch})
+}
+
+// deleteNotificationChannel removes a channel (admin)
+func deleteNotificationChannel(c *gin.Context) {
+	ch, ok := notificationChannelFromParam(c)
+	if !ok {
+		return
+	}
+	if err := db.DeleteNotificationChannel(ch.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification channel", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification channel deleted successfully", "id": ch.ID})
+}
+
+// testNotificationChannel sends a test message to a channel synchronously and reports the outcome (admin)
+func testNotificationChannel(c *gin.Context) {
+	ch, ok := notificationChannelFromParam(c)
+	if !ok {
+		return
+	}
+	msg := notify.Message{
+		Event:   "test",
+		Subject: "[goodclips] Test notification",
+		Text:    fmt.Sprintf("Channel %q is configured correctly.", ch.Name),
+		Time:    time.Now().UTC(),
+	}
+	if err := notify.Deliver(notify.Target{Kind: ch.Kind, Address: ch.Target}, msg); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Delivery failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"delivered": true, "channel_id": ch.ID})
+}
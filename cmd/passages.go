@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPassageCandidates caps how many passages are fetched to aggregate into scenes
+const maxPassageCandidates = 500
+
+// passageScene is a scene ranked by the passages inside it: its score is the best passage's score
+type passageScene struct {
+	SceneID       uint                  `json:"scene_id"`
+	SceneIndex    int                   `json:"scene_index"`
+	VideoID       uint                  `json:"video_id"`
+	VideoFilename string                `json:"video_filename"`
+	VideoTitle    *string               `json:"video_title"`
+	StartTime     float64               `json:"start_time"`
+	EndTime       float64               `json:"end_time"`
+	Score         float64               `json:"score"`
+	Hits          int                   `json:"hits"`
+	Passages      []database.PassageHit `json:"passages"`
+}
+
+// aggregatePassageScenes groups passage hits (nearest first) by the scene containing them, best
+// scene first; passages outside any scene are left out
+func aggregatePassageScenes(hits []database.PassageHit) []*passageScene {
+	byID := map[uint]*passageScene{}
+	var scenes []*passageScene
+	for _, h := range hits {
+		if h.SceneID == nil || h.SceneIndex == nil || h.SceneStart == nil || h.SceneEnd == nil {
+			continue
+		}
+		s, ok := byID[*h.SceneID]
+		if !ok {
+			s = &passageScene{
+				SceneID:       *h.SceneID,
+				SceneIndex:    *h.SceneIndex,
+				VideoID:       h.VideoID,
+				VideoFilename: h.VideoFilename,
+				VideoTitle:    h.VideoTitle,
+				StartTime:     *h.SceneStart,
+				EndTime:       *h.SceneEnd,
+				Score:         h.Score,
+			}
+			byID[s.SceneID] = s
+			scenes = append(scenes, s)
+		}
+		s.Hits++
+		s.Passages = append(s.Passages, h)
+	}
+	sort.SliceStable(scenes, func(i, j int) bool {
+		if scenes[i].Score != scenes[j].Score {
+			return scenes[i].Score > scenes[j].Score
+		}
+		return scenes[i].Hits > scenes[j].Hits
+	})
+	return scenes
+}
+
+// searchPassages ranks caption passages (see the caption_embedding job) by similarity to the query,
+// returning exact caption hits with timestamps and the scenes they fall in:
+// {"query": "we need a bigger boat", "video_ids": [6], "limit": 10, "level": "shot"}
+func searchPassages(c *gin.Context) {
+	started := time.Now()
+	var req struct {
+		Query    string `json:"query"`
+		VideoIDs []uint `json:"video_ids"`
+		Limit    int    `json:"limit"`
+		// Level selects the scene granularity passages are aggregated into: "shot" (default) or "beat"
+		Level string `json:"level"`
+		// SimilarityThreshold drops passages scoring below it (0 to 1; 0 keeps everything)
+		SimilarityThreshold float64 `json:"similarity_threshold"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": "query must not be empty"})
+		return
+	}
+	if !similarityThresholdParam(c, req.SimilarityThreshold) {
+		return
+	}
+	level := database.SceneLevelOrDefault(req.Level)
+	if !models.ValidSceneLevel(level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	vec, err := embedTextQuery(currentSynonyms().ExpandQuery(req.Query))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+		return
+	}
+	// Fetch extra passages so that scenes are scored from more than the top few hits
+	candidates := limit * 5
+	if candidates > maxPassageCandidates {
+		candidates = maxPassageCandidates
+	}
+	hits, err := db.SearchCaptionPassages(vec, candidates, req.VideoIDs, level)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+		return
+	}
+
+	dists := make([]float64, len(hits))
+	for i, h := range hits {
+		dists[i] = h.Distance
+	}
+	kept := aboveThreshold(database.MetricForColumn(database.ColumnText), dists, req.SimilarityThreshold)
+	dropped := len(hits) - kept
+	hits = hits[:kept]
+
+	scenes := aggregatePassageScenes(hits)
+	if len(scenes) > limit {
+		scenes = scenes[:limit]
+	}
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	sceneIDs := make([]uint, len(scenes))
+	for i, s := range scenes {
+		sceneIDs[i] = s.SceneID
+	}
+	searchID := recordSearchEvent("passages", req.Query, map[string]any{
+		"video_ids":            req.VideoIDs,
+		"limit":                limit,
+		"level":                level,
+		"similarity_threshold": req.SimilarityThreshold,
+	}, started, sceneIDs)
+	resp := gin.H{
+		"search_id": searchID,
+		"query":     req.Query,
+		"limit":     limit,
+		"level":     level,
+		"count":     len(hits),
+		"passages":  hits,
+		"scenes":    scenes,
+	}
+	thresholdInfo(resp, req.SimilarityThreshold, dropped)
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxPhraseWords caps the length of phrase queries
+	maxPhraseWords = 16
+	// defaultPhrasePad is the padding (seconds) around an utterance when none is requested
+	defaultPhrasePad = 0.15
+	// maxCutSeconds caps the length of a rendered cut
+	maxCutSeconds = 120
+)
+
+// phraseHit is a phrase match with the padded range its clip is cut at
+type phraseHit struct {
+	database.PhraseMatch
+	ClipStart float64 `json:"clip_start"`
+	ClipEnd   float64 `json:"clip_end"`
+	ClipURL   string  `json:"clip_url"`
+}
+
+// padCut widens [start, end] by before and after seconds, within the video when its duration is known
+func padCut(start, end, before, after, duration float64) (float64, float64) {
+	start, end = start-before, end+after
+	if start < 0 {
+		start = 0
+	}
+	if duration > 0 && end > duration {
+		end = duration
+	}
+	return start, end
+}
+
+// videoCutURL returns the signed download URL of a video range rendered as an MP4
+func videoCutURL(videoID uint, start, end float64) string {
+	return signedArtifactURL(fmt.Sprintf("/api/v1/videos/%d/cuts/%.3f-%.3f", videoID, start, end))
+}
+
+// phrasePadding validates a requested padding, defaulting to defaultPhrasePad
+func phrasePadding(c *gin.Context, name string, v *float64) (float64, bool) {
+	if v == nil {
+		return defaultPhrasePad, true
+	}
+	if *v < 0 || *v > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name, "details": "must be between 0 and 10 seconds"})
+		return 0, false
+	}
+	return *v, true
+}
+
+// searchPhrase finds the exact moments a phrase is spoken, using word timings from word_alignment
+// jobs, with a signed URL for each utterance's clip:
+// {"phrase": "we need a bigger boat", "video_ids": [6], "limit": 50, "pad_before": 0.15, "pad_after": 0.15}
+func searchPhrase(c *gin.Context) {
+	started := time.Now()
+	var req struct {
+		Phrase    string   `json:"phrase"`
+		VideoIDs  []uint   `json:"video_ids"`
+		Limit     int      `json:"limit"`
+		PadBefore *float64 `json:"pad_before"`
+		PadAfter  *float64 `json:"pad_after"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	words := processor.PhraseWords(req.Phrase)
+	if len(words) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": "phrase has no words"})
+		return
+	}
+	if len(words) > maxPhraseWords {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": fmt.Sprintf("phrase must have at most %d words", maxPhraseWords)})
+		return
+	}
+	before, ok := phrasePadding(c, "pad_before", req.PadBefore)
+	if !ok {
+		return
+	}
+	after, ok := phrasePadding(c, "pad_after", req.PadAfter)
+	if !ok {
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	matches, err := db.SearchPhrase(words, req.VideoIDs, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+		return
+	}
+	hits := make([]phraseHit, len(matches))
+	for i, m := range matches {
+		start, end := padCut(m.StartTime, m.EndTime, before, after, m.VideoDuration)
+		hits[i] = phraseHit{PhraseMatch: m, ClipStart: start, ClipEnd: end, ClipURL: videoCutURL(m.VideoID, start, end)}
+	}
+	searchID := recordSearchEvent("phrase", req.Phrase, map[string]any{
+		"video_ids": req.VideoIDs,
+		"limit":     limit,
+	}, started, nil)
+	resp := gin.H{
+		"search_id": searchID,
+		"phrase":    strings.Join(words, " "),
+		"limit":     limit,
+		"count":     len(hits),
+		"results":   hits,
+	}
+	// An empty result may only mean that no video has word timings yet
+	if len(hits) == 0 {
+		if n, err := db.CountCaptionWordVideos(req.VideoIDs); err == nil {
+			resp["aligned_videos"] = n
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseCutRange reads a "<start>-<end>" range in seconds
+func parseCutRange(s string) (float64, float64, bool) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err1 := strconv.ParseFloat(from, 64)
+	end, err2 := strconv.ParseFloat(to, 64)
+	if err1 != nil || err2 != nil || start < 0 || end <= start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// downloadVideoCut renders and serves a range of a video as an MP4 (signed URL required), e.g.
+// /api/v1/videos/6/cuts/812.340-815.120
+func downloadVideoCut(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	start, end, ok := parseCutRange(c.Param("range"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "details": "range must be <start>-<end> in seconds"})
+		return
+	}
+	if end-start > maxCutSeconds {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range", "details": fmt.Sprintf("cuts are at most %d seconds", maxCutSeconds)})
+		return
+	}
+	share, viewer, err := shareLinkWatermark(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watermark", "details": err.Error()})
+		return
+	}
+	// The watermark follows the project of the video named in the link, not of the one holding shared content
+	owner := video
+	if id := requestedVideoID(c); id != video.ID {
+		if v, err := db.GetVideoByID(id); err == nil {
+			owner = v
+		}
+	}
+	wm, err := videoProcessor.WatermarkFor(owner, share, viewer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve watermark", "details": err.Error()})
+		return
+	}
+	var path string
+	if wm.Empty() {
+		path, err = videoProcessor.ExportCut(video, start, end)
+	} else if path, err = videoProcessor.ExportWatermarkedCut(video, start, end, wm); err == nil {
+		defer os.Remove(path)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render cut", "details": err.Error()})
+		return
+	}
+	c.FileAttachment(path, fmt.Sprintf("video_%d_%s.mp4", video.ID, c.Param("range")))
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRerankCandidates caps the candidates one search sends to the re-ranker
+const maxRerankCandidates = 200
+
+// maxRerankPassageChars caps the caption text sent per candidate; the model truncates anyway
+const maxRerankPassageChars = 2000
+
+// rerankCandidates is how many of the best candidates a re-ranked search scores by default
+// (RERANK_CANDIDATES, default 50)
+func rerankCandidates() int {
+	if n, err := strconv.Atoi(os.Getenv("RERANK_CANDIDATES")); err == nil && n > 0 {
+		return n
+	}
+	return 50
+}
+
+// rerankQuery holds the re-ranking options shared by text searches; it is embedded in their request
+// bodies
+type rerankQuery struct {
+	// Rerank re-orders the top candidates by a cross-encoder's relevance to the query, trading
+	// latency for precision
+	Rerank bool `json:"rerank"`
+	// RerankCandidates is how many candidates are re-ranked (default RERANK_CANDIDATES, at least limit)
+	RerankCandidates int `json:"rerank_candidates"`
+}
+
+// validate checks the options, writing a 400 when invalid
+func (rq rerankQuery) validate(c *gin.Context) bool {
+	if rq.RerankCandidates < 0 || rq.RerankCandidates > maxRerankCandidates {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rerank_candidates", "details": fmt.Sprintf("rerank_candidates must be between 0 and %d", maxRerankCandidates)})
+		return false
+	}
+	return true
+}
+
+// candidates is how many hits a search with limit results fetches; without rerank that is fetch,
+// what the search fetches otherwise
+func (rq rerankQuery) candidates(limit, fetch int) int {
+	if !rq.Rerank {
+		return fetch
+	}
+	n := rq.RerankCandidates
+	if n == 0 {
+		n = rerankCandidates()
+	}
+	if n < limit {
+		n = limit
+	}
+	if n > maxRerankCandidates {
+		n = maxRerankCandidates
+	}
+	if n < fetch {
+		n = fetch
+	}
+	return n
+}
+
+// rerankResult describes a re-ranking pass for the search response
+type rerankResult struct {
+	Model      string `json:"model"`
+	Candidates int    `json:"candidates"`
+	// Unscored counts candidates without text, kept after the scored ones in their original order
+	Unscored  int   `json:"unscored,omitempty"`
+	LatencyMS int64 `json:"latency_ms"`
+}
+
+// rerankPassages scores each passage's relevance to the query (0–1) with the cross-encoder: a
+// remote re-ranker when RERANK_URL is set, else rerank_runner.py. Both take
+// {"query": ..., "passages": [...]} and answer {"model": ..., "scores": [...]}.
+func rerankPassages(query string, passages []string) ([]float64, string, error) {
+	b, _ := json.Marshal(map[string]any{"query": query, "passages": passages})
+	var out []byte
+	if url := os.Getenv("RERANK_URL"); url != "" {
+		client := &http.Client{Timeout: 30 * time.Second}
+		res, err := client.Post(url, "application/json", bytes.NewReader(b))
+		if err != nil {
+			return nil, "", fmt.Errorf("re-ranker request failed: %w", err)
+		}
+		defer res.Body.Close()
+		out, err = io.ReadAll(res.Body)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read re-ranker response: %w", err)
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("re-ranker returned %s: %s", res.Status, string(out))
+		}
+	} else {
+		cmd := exec.Command("python3", "/root/internal/embeddings/rerank_runner.py")
+		cmd.Stdin = bytes.NewReader(b)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		var err error
+		if out, err = cmd.Output(); err != nil {
+			return nil, "", fmt.Errorf("rerank_runner failed: %v; stderr: %s", err, stderr.String())
+		}
+	}
+	var resp struct {
+		Model  string    `json:"model"`
+		Scores []float64 `json:"scores"`
+		Error  string    `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse re-ranker output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, "", fmt.Errorf("re-ranker error: %s", resp.Error)
+	}
+	if len(resp.Scores) != len(passages) {
+		return nil, "", fmt.Errorf("re-ranker returned %d scores for %d passages", len(resp.Scores), len(passages))
+	}
+	return resp.Scores, resp.Model, nil
+}
+
+// rerankOrder re-orders candidates, given as indexes into texts best first, by the cross-encoder's
+// score of each one's text. Candidates without text are kept after the scored ones. It returns the
+// new order and the scores by index.
+func rerankOrder(query string, order []int, texts []string) ([]int, map[int]float64, *rerankResult, error) {
+	started := time.Now()
+	var scored, unscored []int
+	var passages []string
+	for _, i := range order {
+		text := strings.TrimSpace(texts[i])
+		if text == "" {
+			unscored = append(unscored, i)
+			continue
+		}
+		if r := []rune(text); len(r) > maxRerankPassageChars {
+			text = string(r[:maxRerankPassageChars])
+		}
+		scored = append(scored, i)
+		passages = append(passages, text)
+	}
+	result := &rerankResult{Candidates: len(order), Unscored: len(unscored)}
+	if len(passages) == 0 {
+		result.LatencyMS = time.Since(started).Milliseconds()
+		return order, nil, result, nil
+	}
+	scores, model, err := rerankPassages(query, passages)
+	if err != nil {
+		return order, nil, nil, err
+	}
+	byIndex := make(map[int]float64, len(scored))
+	for n, i := range scored {
+		byIndex[i] = scores[n]
+	}
+	sort.SliceStable(scored, func(a, b int) bool { return byIndex[scored[a]] > byIndex[scored[b]] })
+	result.Model = model
+	result.LatencyMS = time.Since(started).Milliseconds()
+	return append(scored, unscored...), byIndex, result, nil
+}
+
+// rerankScenes re-orders candidate scenes, given as indexes into scenes best first, by the
+// cross-encoder's score of the captions overlapping each scene (see rerankOrder)
+func rerankScenes(query string, scenes []models.Scene, order []int) ([]int, map[int]float64, *rerankResult, error) {
+	ids := make([]uint, 0, len(order))
+	for _, i := range order {
+		ids = append(ids, scenes[i].ID)
+	}
+	snippets, err := db.GetSceneCaptionSnippets(ids, "")
+	if err != nil {
+		return order, nil, nil, fmt.Errorf("failed to load scene captions: %w", err)
+	}
+	texts := make([]string, len(scenes))
+	for _, i := range order {
+		parts := make([]string, 0, len(snippets[scenes[i].ID]))
+		for _, s := range snippets[scenes[i].ID] {
+			parts = append(parts, s.Text)
+		}
+		texts[i] = strings.Join(parts, " ")
+	}
+	return rerankOrder(query, order, texts)
+}
+
+// rerankFailed logs a failed re-ranking; the search keeps its own order
+func rerankFailed(err error) string {
+	log.Printf("Warning: re-ranking failed: %v", err)
+	return "re-ranking failed: " + err.Error()
+}
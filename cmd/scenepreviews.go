@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSceneDiffTolerance is how far (seconds) a boundary may move before a scene counts as shifted
+const defaultSceneDiffTolerance = 0.1
+
+// videoFromParam loads the video addressed by :id, writing an error response when it cannot
+func videoFromParam(c *gin.Context) (*models.Video, bool) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return nil, false
+	}
+	video, err := db.GetVideoByID(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return nil, false
+	}
+	return video, true
+}
+
+// scenePreviewFromParams loads the preview addressed by :id and :preview, writing an error response
+// when it cannot
+func scenePreviewFromParams(c *gin.Context) (*models.ScenePreview, bool) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("preview"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preview ID"})
+		return nil, false
+	}
+	p, err := db.GetScenePreview(uint(videoID), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scene preview not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scene preview", "details": err.Error()})
+		return nil, false
+	}
+	return p, true
+}
+
+// createScenePreview re-runs scene detection with other parameters in a background job without
+// replacing the video's scenes: {"threshold": 24, "min_scene_duration": 0.5}
+func createScenePreview(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	if video.AssetType != models.AssetTypeVideo && video.AssetType != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Scene detection only applies to videos", "details": "asset is " + video.AssetType})
+		return
+	}
+	var req struct {
+		Threshold        *float64 `json:"threshold"`
+		MinSceneDuration *float64 `json:"min_scene_duration"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if req.Threshold != nil && (*req.Threshold <= 0 || *req.Threshold > 255) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid threshold", "details": "threshold must be in (0, 255]"})
+		return
+	}
+	if req.MinSceneDuration != nil && *req.MinSceneDuration < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_scene_duration", "details": "min_scene_duration must not be negative"})
+		return
+	}
+
+	p := &models.ScenePreview{VideoID: video.ID, Status: models.ScenePreviewPending, Threshold: req.Threshold, MinSceneDuration: req.MinSceneDuration}
+	if err := db.CreateScenePreview(p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create scene preview", "details": err.Error()})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeScenePreview, map[string]interface{}{"video_id": video.ID, "preview_id": p.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue scene preview", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"preview": p, "job_id": job.ID})
+}
+
+// listScenePreviews returns a video's previews without their scenes, newest first
+func listScenePreviews(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	previews, err := db.ListScenePreviews(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list scene previews", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"previews": previews, "count": len(previews)})
+}
+
+// getScenePreview returns a preview and, once detected, its diff against the video's current shots:
+// unchanged, shifted, added and removed scenes (?tolerance= seconds, default 0.1)
+func getScenePreview(c *gin.Context) {
+	p, ok := scenePreviewFromParams(c)
+	if !ok {
+		return
+	}
+	tolerance := defaultSceneDiffTolerance
+	if v := c.Query("tolerance"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tolerance"})
+			return
+		}
+		tolerance = t
+	}
+	resp := gin.H{"preview": p}
+	if p.Status == models.ScenePreviewReady || p.Status == models.ScenePreviewCommitting {
+		diff, err := videoProcessor.DiffScenePreview(p, tolerance)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare scenes", "details": err.Error()})
+			return
+		}
+		resp["diff"] = diff
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// commitScenePreview replaces the video's scenes with the preview's shots in a scene_detection job.
+// References to removed scenes move to the new scene they overlap most; embeddings are regenerated.
+func commitScenePreview(c *gin.Context) {
+	p, ok := scenePreviewFromParams(c)
+	if !ok {
+		return
+	}
+	if p.Status != models.ScenePreviewReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "Scene preview cannot be committed", "details": "preview is " + p.Status})
+		return
+	}
+	video, err := db.GetVideoByID(p.VideoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	if video.Locked {
+		c.JSON(http.StatusLocked, gin.H{"error": "Video is locked", "details": "destructive reprocessing is blocked by a legal hold"})
+		return
+	}
+	p.Status, p.Error = models.ScenePreviewCommitting, nil
+	if err := db.UpdateScenePreview(p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit scene preview", "details": err.Error()})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeSceneDetection, map[string]interface{}{
+		"video_id":   video.ID,
+		"filename":   video.Filename,
+		"filepath":   video.Filepath,
+		"preview_id": p.ID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue scene replacement", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"preview": p, "job_id": job.ID})
+}
+
+// discardScenePreview marks a preview discarded so it can no longer be committed
+func discardScenePreview(c *gin.Context) {
+	p, ok := scenePreviewFromParams(c)
+	if !ok {
+		return
+	}
+	if p.Status == models.ScenePreviewCommitting || p.Status == models.ScenePreviewCommitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Scene preview cannot be discarded", "details": "preview is " + p.Status})
+		return
+	}
+	p.Status = models.ScenePreviewDiscarded
+	if err := db.UpdateScenePreview(p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discard scene preview", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preview": p})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sceneListItem is a scene summary with the signed URL of its keyframe image
+type sceneListItem struct {
+	database.SceneSummary
+	KeyframeURL *string `json:"keyframe_url"`
+}
+
+// listVideoScenes pages through a video's scenes by scene_index without their vectors, flagging which
+// embeddings each has (?level=shot|beat, ?order=asc|desc, ?limit=50, ?offset=0)
+func listVideoScenes(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	level := database.SceneLevelOrDefault(c.Query("level"))
+	if !models.ValidSceneLevel(level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return
+	}
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order", "details": "order must be asc or desc"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	scenes, total, err := db.ListSceneSummaries(video.ID, level, order == "desc", limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list scenes", "details": err.Error()})
+		return
+	}
+	items := make([]sceneListItem, len(scenes))
+	for i, s := range scenes {
+		items[i] = sceneListItem{SceneSummary: s}
+		if s.KeyframeTime != nil {
+			u := signedArtifactURL(fmt.Sprintf("/api/v1/videos/%d/scenes/%d/keyframe", video.ID, s.SceneIndex))
+			if level != models.SceneLevelShot {
+				u += "&level=" + level
+			}
+			items[i].KeyframeURL = &u
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"video_id": video.ID,
+		"level":    level,
+		"scenes":   items,
+		"pagination": gin.H{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(items),
+		},
+	})
+}
+
+// maxBatchScenes caps the IDs plus UUIDs of one batch-get request
+const maxBatchScenes = 500
+
+// sceneBatchItem is a scene summary with optional video info and thumbnail URL
+type sceneBatchItem struct {
+	database.SceneSummary
+	Video        gin.H   `json:"video,omitempty"`
+	ThumbnailURL *string `json:"thumbnail_url,omitempty"`
+}
+
+// batchGetScenes returns the scenes with the given IDs and UUIDs in request order (IDs first),
+// without their vectors. Unknown ones are listed under missing. include_video adds each scene's video
+// and include_thumbnails the signed thumbnail_url of scenes with a keyframe.
+func batchGetScenes(c *gin.Context) {
+	var req struct {
+		IDs               []uint   `json:"ids"`
+		UUIDs             []string `json:"uuids"`
+		IncludeVideo      bool     `json:"include_video"`
+		IncludeThumbnails bool     `json:"include_thumbnails"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if len(req.IDs)+len(req.UUIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "ids or uuids is required"})
+		return
+	}
+	if len(req.IDs)+len(req.UUIDs) > maxBatchScenes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": fmt.Sprintf("at most %d ids and uuids", maxBatchScenes)})
+		return
+	}
+	for i, u := range req.UUIDs {
+		u = strings.ToLower(strings.TrimSpace(u))
+		if !validUUID(u) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": fmt.Sprintf("invalid uuid %q", req.UUIDs[i])})
+			return
+		}
+		req.UUIDs[i] = u
+	}
+
+	scenes, err := db.GetSceneSummaries(req.IDs, req.UUIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scenes", "details": err.Error()})
+		return
+	}
+	byID := make(map[uint]database.SceneSummary, len(scenes))
+	byUUID := make(map[string]database.SceneSummary, len(scenes))
+	for _, s := range scenes {
+		byID[s.ID] = s
+		byUUID[strings.ToLower(s.UUID)] = s
+	}
+
+	// Repeated scenes are returned once
+	items := make([]sceneBatchItem, 0, len(scenes))
+	seen := make(map[uint]bool, len(scenes))
+	missingIDs, missingUUIDs := []uint{}, []string{}
+	add := func(s database.SceneSummary) {
+		if !seen[s.ID] {
+			seen[s.ID] = true
+			items = append(items, sceneBatchItem{SceneSummary: s})
+		}
+	}
+	for _, id := range req.IDs {
+		if s, ok := byID[id]; ok {
+			add(s)
+		} else {
+			missingIDs = append(missingIDs, id)
+		}
+	}
+	for _, u := range req.UUIDs {
+		if s, ok := byUUID[u]; ok {
+			add(s)
+		} else {
+			missingUUIDs = append(missingUUIDs, u)
+		}
+	}
+
+	if req.IncludeVideo {
+		attachBatchSceneVideos(items)
+	}
+	if req.IncludeThumbnails {
+		ids := make([]uint, len(items))
+		for i, it := range items {
+			ids[i] = it.ID
+		}
+		if has, err := db.SceneIDsWithKeyframes(ids); err != nil {
+			log.Printf("Warning: failed to load scene keyframes: %v", err)
+		} else {
+			for i := range items {
+				if has[items[i].ID] {
+					u := sceneThumbnailURL(items[i].ID)
+					items[i].ThumbnailURL = &u
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scenes":  items,
+		"count":   len(items),
+		"missing": gin.H{"ids": missingIDs, "uuids": missingUUIDs},
+	})
+}
+
+// attachBatchSceneVideos adds each scene's video, loading the videos in one query
+func attachBatchSceneVideos(items []sceneBatchItem) {
+	ids := make([]uint, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.VideoID)
+	}
+	videos, err := db.GetVideoSummaries(ids)
+	if err != nil {
+		log.Printf("Warning: failed to load scene videos: %v", err)
+		return
+	}
+	for i := range items {
+		if v, ok := videos[items[i].VideoID]; ok {
+			items[i].Video = gin.H{
+				"id":          v.ID,
+				"uuid":        v.UUID,
+				"filename":    v.Filename,
+				"title":       v.Title,
+				"asset_type":  v.AssetType,
+				"duration":    v.Duration,
+				"scene_count": v.SceneCount,
+				"tags":        v.Tags,
+				"status":      v.Status,
+			}
+		}
+	}
+}
+
+// validUUID reports whether s is a lower-case UUID in its 8-4-4-4-12 form
+func validUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listSceneTextFilters returns the stored scene text filters and the built-in default patterns (admin)
+func listSceneTextFilters(c *gin.Context) {
+	filters, err := db.ListSceneTextFilters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list scene text filters", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"filters": filters, "builtin_patterns": processor.DefaultSceneTextPatterns})
+}
+
+// putSceneTextFilter stores the scene text filter of a project ("" for the default set):
+// {"project": "acme", "patterns": ["ACME TV"], "stopwords": ["uh"], "inherit": true}. It applies to
+// scene text embedded from then on; an optional "sample" is returned filtered for checking (admin).
+func putSceneTextFilter(c *gin.Context) {
+	var req struct {
+		Project   string   `json:"project"`
+		Patterns  []string `json:"patterns"`
+		Stopwords []string `json:"stopwords"`
+		Inherit   *bool    `json:"inherit"`
+		Sample    string   `json:"sample"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	req.Project = strings.TrimSpace(req.Project)
+	if len(req.Project) > 128 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project", "details": "project must be at most 128 characters"})
+		return
+	}
+	if _, err := processor.CompileSceneTextFilter(req.Patterns, req.Stopwords); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter", "details": err.Error()})
+		return
+	}
+	f := &models.SceneTextFilter{Project: req.Project, Patterns: req.Patterns, Stopwords: req.Stopwords, Inherit: true}
+	if req.Inherit != nil {
+		f.Inherit = *req.Inherit
+	}
+	if f.Patterns == nil {
+		f.Patterns = models.JSONStringArray{}
+	}
+	if f.Stopwords == nil {
+		f.Stopwords = models.JSONStringArray{}
+	}
+	if err := db.SetSceneTextFilter(f); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store scene text filter", "details": err.Error()})
+		return
+	}
+	resp := gin.H{"filter": f}
+	if req.Sample != "" {
+		resp["sample"] = processor.SceneTextRulesFor(db, req.Project).Apply(req.Sample)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// deleteSceneTextFilter removes the scene text filter of ?project= (the default set when empty), so
+// the project falls back to the default set, or the default set to the built-in patterns (admin)
+func deleteSceneTextFilter(c *gin.Context) {
+	deleted, err := db.DeleteSceneTextFilter(strings.TrimSpace(c.Query("project")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete scene text filter", "details": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scene text filter not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Scene text filter deleted"})
+}
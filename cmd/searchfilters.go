@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFilterValues caps the values of one list filter
+const maxFilterValues = 100
+
+// videoQuery holds the video attribute and scene duration filters shared by searches; it is embedded
+// in their request bodies
+type videoQuery struct {
+	// Tags keeps videos with any of these tags
+	Tags []string `json:"tags"`
+	// Statuses keeps videos in any of these statuses (pending, processing, completed)
+	Statuses []string `json:"statuses"`
+	// Projects keeps videos whose metadata.project is any of these
+	Projects []string `json:"projects"`
+	// MinDuration/MaxDuration bound the scene duration in seconds (scene searches only)
+	MinDuration *float64 `json:"min_duration"`
+	MaxDuration *float64 `json:"max_duration"`
+}
+
+// validate checks the filters, writing a 400 when invalid
+func (vq videoQuery) validate(c *gin.Context) bool {
+	for name, values := range map[string][]string{"tags": vq.Tags, "statuses": vq.Statuses, "projects": vq.Projects} {
+		if len(values) > maxFilterValues {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name, "details": fmt.Sprintf("at most %d values are allowed", maxFilterValues)})
+			return false
+		}
+	}
+	for _, s := range vq.Statuses {
+		switch models.VideoStatus(s) {
+		case models.VideoStatusPending, models.VideoStatusProcessing, models.VideoStatusCompleted, models.VideoStatusError:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "details": s})
+			return false
+		}
+	}
+	for name, v := range map[string]*float64{"min_duration": vq.MinDuration, "max_duration": vq.MaxDuration} {
+		if v != nil && *v < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name, "details": "must not be negative"})
+			return false
+		}
+	}
+	if vq.MinDuration != nil && vq.MaxDuration != nil && *vq.MinDuration > *vq.MaxDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration range", "details": "min_duration exceeds max_duration"})
+		return false
+	}
+	return true
+}
+
+// apply validates the filters and adds them to a scene filter, writing a 400 when invalid
+func (vq videoQuery) apply(c *gin.Context, filter *database.SceneFilter) bool {
+	if !vq.validate(c) {
+		return false
+	}
+	filter.Tags, filter.Statuses, filter.Projects = vq.Tags, vq.Statuses, vq.Projects
+	filter.MinDuration, filter.MaxDuration = vq.MinDuration, vq.MaxDuration
+	return true
+}
+
+// videoFilterOf is the video-level filter matching a scene filter, for two-stage search
+func videoFilterOf(filter database.SceneFilter) database.VideoFilter {
+	return database.VideoFilter{VideoIDs: filter.VideoIDs, AssetTypes: filter.AssetTypes,
+		Tags: filter.Tags, Statuses: filter.Statuses, Projects: filter.Projects}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestedVideoKey holds the video ID a request named when sharedContentMiddleware pointed :id at
+// the video holding its content
+const requestedVideoKey = "requested_video_id"
+
+// requestedVideoID is the video a content route was called for: the :id before
+// sharedContentMiddleware pointed it at the content video
+func requestedVideoID(c *gin.Context) uint {
+	if id, ok := c.Get(requestedVideoKey); ok {
+		return id.(uint)
+	}
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	return uint(id)
+}
+
+// sharedContentMiddleware points the :id of a video content route at the video holding the content
+// when the video shares another project's (see processor.SharedIngestEnabled), and names that video
+// in X-Content-Video-ID. Invalid and unknown IDs are left to the handler.
+func sharedContentMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Next()
+			return
+		}
+		contentID, err := db.ContentVideoID(uint(id))
+		if err != nil || contentID == uint(id) {
+			c.Next()
+			return
+		}
+		c.Set(requestedVideoKey, uint(id))
+		for i, p := range c.Params {
+			if p.Key == "id" {
+				c.Params[i].Value = strconv.FormatUint(uint64(contentID), 10)
+			}
+		}
+		c.Header("X-Content-Video-ID", strconv.FormatUint(uint64(contentID), 10))
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/signedurl"
+
+	"github.com/gin-gonic/gin"
+)
+
+var urlSigner *signedurl.Signer
+
+// initURLSigner configures the signer used for artifact URLs
+func initURLSigner() {
+	s, ephemeral, err := signedurl.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure signed URLs: %v", err)
+	}
+	if ephemeral {
+		log.Println("Warning: SIGNED_URL_KEYS not set; using an ephemeral key. Artifact URLs break on restart and are rejected by every other API replica; set SIGNED_URL_KEYS when running more than one.")
+	}
+	urlSigner = s
+}
+
+// signedURLTTL is the lifetime of issued artifact URLs (SIGNED_URL_TTL_SECS, default 3600)
+func signedURLTTL() time.Duration {
+	if secs, err := strconv.Atoi(getEnvOrDefault("SIGNED_URL_TTL_SECS", "3600")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return time.Hour
+}
+
+// signedArtifactURL returns a signed, expiring URL for an artifact-serving path
+func signedArtifactURL(path string) string {
+	return urlSigner.Sign(path, signedURLTTL())
+}
+
+// signedURLMiddleware rejects artifact requests whose URL signature is missing, expired or invalid
+func signedURLMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := urlSigner.Verify(c.Request.URL.Path, c.Request.URL.Query()); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, signedurl.ErrMissingSignature) {
+				status = http.StatusUnauthorized
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": "Invalid artifact URL", "details": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// signURL issues a signed URL for an artifact path (admin). A share link may name the viewer it is
+// issued to and a watermark burned into the cuts it serves, replacing the project's:
+// {"path": "/api/v1/videos/3/cuts/10-25", "viewer": "reviewer@example.com", "watermark": {"text": "{user_id}"}}
+func signURL(c *gin.Context) {
+	var req struct {
+		Path       string            `json:"path"`
+		TTLSeconds int               `json:"ttl_seconds"`
+		Viewer     string            `json:"viewer"`
+		Watermark  *ffmpeg.Watermark `json:"watermark"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if !strings.HasPrefix(req.Path, "/") || strings.Contains(req.Path, "?") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path must be absolute and carry no query string"})
+		return
+	}
+	if req.Watermark != nil {
+		if err := req.Watermark.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watermark", "details": err.Error()})
+			return
+		}
+	}
+	ttl := signedURLTTL()
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"url":        urlSigner.SignWith(req.Path, shareLinkParams(strings.TrimSpace(req.Viewer), req.Watermark), ttl),
+		"expires_at": time.Now().Add(ttl),
+		"kid":        urlSigner.ActiveKID(),
+	})
+}
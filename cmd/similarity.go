@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+
+	"goodclips-server/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// similarityThresholdParam validates a request's similarity_threshold, a minimum score on [0, 1]
+// (see database.Metric.Score); it writes a 400 when invalid
+func similarityThresholdParam(c *gin.Context, threshold float64) bool {
+	if threshold < 0 || threshold > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid similarity_threshold", "details": "similarity_threshold must be between 0 and 1"})
+		return false
+	}
+	return true
+}
+
+// aboveThreshold returns how many of the leading distances (nearest first, as vector searches return
+// them) score at least threshold under metric; every result passes a zero threshold
+func aboveThreshold(metric database.Metric, dists []float64, threshold float64) int {
+	if threshold <= 0 {
+		return len(dists)
+	}
+	for i, d := range dists {
+		if metric.Score(d) < threshold {
+			return i
+		}
+	}
+	return len(dists)
+}
+
+// thresholdInfo adds the threshold and the number of results it dropped to a search response
+func thresholdInfo(resp gin.H, threshold float64, dropped int) {
+	if threshold > 0 {
+		resp["similarity_threshold"] = threshold
+		resp["below_threshold"] = dropped
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/processor"
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultSupercutClips is how many utterances a supercut holds when max_clips is not given
+	defaultSupercutClips = 200
+	// maxSupercutClips caps max_clips
+	maxSupercutClips = 1000
+)
+
+// supercutResponse adds a signed download URL to exported supercuts
+func supercutResponse(sc *models.Supercut) gin.H {
+	resp := gin.H{"supercut": sc}
+	if sc.ExportPath != nil {
+		resp["download_url"] = signedArtifactURL(fmt.Sprintf("/api/v1/supercut/%d/video", sc.ID))
+	}
+	exportProgress(resp, sc.Status, sc.JobID)
+	return resp
+}
+
+// createSupercut registers a supercut of every utterance of a phrase (see /search/phrase) and enqueues
+// the job that cuts and concatenates them:
+// {"phrase": "i'll be back", "video_ids": [6, 2], "pad_before": 0.2, "pad_after": 0.3, "order": "video", "max_clips": 50}
+func createSupercut(c *gin.Context) {
+	var req struct {
+		Phrase    string   `json:"phrase" binding:"required"`
+		VideoIDs  []uint   `json:"video_ids"`
+		PadBefore *float64 `json:"pad_before"`
+		PadAfter  *float64 `json:"pad_after"`
+		// Order is "video" (default: video_ids order, then time), "duration" (shortest first) or "random"
+		Order    string `json:"order"`
+		MaxClips int    `json:"max_clips"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	words := processor.PhraseWords(req.Phrase)
+	if len(words) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phrase", "details": "phrase has no words"})
+		return
+	}
+	if len(words) > maxPhraseWords {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phrase", "details": fmt.Sprintf("phrase must have at most %d words", maxPhraseWords)})
+		return
+	}
+	before, ok := phrasePadding(c, "pad_before", req.PadBefore)
+	if !ok {
+		return
+	}
+	after, ok := phrasePadding(c, "pad_after", req.PadAfter)
+	if !ok {
+		return
+	}
+	order := req.Order
+	if order == "" {
+		order = models.SupercutOrderVideo
+	}
+	if !models.ValidSupercutOrder(order) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order", "details": "order must be video, duration or random"})
+		return
+	}
+	maxClips := req.MaxClips
+	if maxClips <= 0 {
+		maxClips = defaultSupercutClips
+	}
+	if maxClips > maxSupercutClips {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_clips", "details": fmt.Sprintf("must be at most %d", maxSupercutClips)})
+		return
+	}
+	for _, id := range req.VideoIDs {
+		if _, err := db.GetVideoByID(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Video not found", "details": fmt.Sprintf("video %d", id)})
+			return
+		}
+	}
+
+	sc := &models.Supercut{
+		Phrase:    strings.Join(words, " "),
+		VideoIDs:  req.VideoIDs,
+		PadBefore: before,
+		PadAfter:  after,
+		Ordering:  order,
+		MaxClips:  maxClips,
+		Status:    models.HighlightStatusPending,
+	}
+	if err := db.CreateSupercut(sc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supercut", "details": err.Error()})
+		return
+	}
+	job, err := jobQueue.Enqueue(queue.JobTypeSupercut, map[string]interface{}{"supercut_id": sc.ID})
+	if err != nil {
+		msg := err.Error()
+		sc.Status = models.HighlightStatusFailed
+		sc.ErrorMessage = &msg
+		db.UpdateSupercut(sc)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue supercut job", "details": err.Error()})
+		return
+	}
+	sc.JobID = job.ID
+	if err := db.UpdateSupercut(sc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update supercut", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, supercutResponse(sc))
+}
+
+// supercutFromParam loads the supercut addressed by :id, writing an error response when it cannot
+func supercutFromParam(c *gin.Context) (*models.Supercut, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supercut ID"})
+		return nil, false
+	}
+	sc, err := db.GetSupercut(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supercut not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load supercut", "details": err.Error()})
+		return nil, false
+	}
+	return sc, true
+}
+
+// getSupercut returns a supercut's status and, once built, its utterances in playback order
+func getSupercut(c *gin.Context) {
+	sc, ok := supercutFromParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, supercutResponse(sc))
+}
+
+// downloadSupercut serves an exported supercut (signed URL required)
+func downloadSupercut(c *gin.Context) {
+	sc, ok := supercutFromParam(c)
+	if !ok {
+		return
+	}
+	if sc.ExportPath == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supercut has not been exported"})
+		return
+	}
+	serveArtifact(c, models.ArtifactSupercut, sc.ID, *sc.ExportPath, fmt.Sprintf("supercut_%d.mp4", sc.ID))
+}
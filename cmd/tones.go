@@ -0,0 +1,161 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Result orderings accepted by toneQuery.SortBy
+const (
+	toneSortRelevance = "relevance"
+	toneSortPositive  = "sentiment"
+	toneSortNegative  = "-sentiment"
+	toneSortIntensity = "intensity"
+)
+
+// toneQuery holds the emotional tone filters and ordering shared by scene searches; it is embedded
+// in their request bodies
+type toneQuery struct {
+	// Emotions keeps scenes whose dominant caption or audio emotion is any of these; tone words such as
+	// "tense" expand to their emotions
+	Emotions     []string `json:"emotions"`
+	MinSentiment *float64 `json:"min_sentiment"`
+	MaxSentiment *float64 `json:"max_sentiment"`
+	MinIntensity *float64 `json:"min_intensity"`
+	// SortBy orders results by "relevance" (default), "sentiment" (most positive first), "-sentiment"
+	// (most negative first) or "intensity"
+	SortBy string `json:"sort_by"`
+}
+
+// apply validates the tone filters and adds them to filter, writing a 400 when invalid
+func (tq toneQuery) apply(c *gin.Context, filter *database.SceneFilter) bool {
+	emotions, unknown := models.ExpandEmotions(tq.Emotions)
+	if unknown != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid emotion", "details": unknown})
+		return false
+	}
+	for name, v := range map[string]*float64{"min_sentiment": tq.MinSentiment, "max_sentiment": tq.MaxSentiment} {
+		if v != nil && (*v < -1 || *v > 1) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name, "details": "must be between -1 and 1"})
+			return false
+		}
+	}
+	if tq.MinIntensity != nil && (*tq.MinIntensity < 0 || *tq.MinIntensity > 1) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_intensity", "details": "must be between 0 and 1"})
+		return false
+	}
+	switch tq.SortBy {
+	case "", toneSortRelevance, toneSortPositive, toneSortNegative, toneSortIntensity:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort_by", "details": "sort_by must be relevance, sentiment, -sentiment or intensity"})
+		return false
+	}
+	filter.Emotions = emotions
+	filter.MinSentiment, filter.MaxSentiment, filter.MinIntensity = tq.MinSentiment, tq.MaxSentiment, tq.MinIntensity
+	return true
+}
+
+// attachSceneTones adds each hit's "tone" to its result item and, when sortBy asks for it, reorders
+// items and hits together by tone. Scenes without a score sort last.
+func attachSceneTones(items []gin.H, hits []models.Scene, sortBy string) {
+	ids := make([]uint, len(hits))
+	for i, s := range hits {
+		ids[i] = s.ID
+	}
+	tones, err := db.GetSceneTones(ids)
+	if err != nil {
+		log.Printf("Warning: failed to load scene tones: %v", err)
+		return
+	}
+	for i, s := range hits {
+		if t, ok := tones[s.ID]; ok {
+			items[i]["tone"] = t
+		}
+	}
+	if sortBy != toneSortPositive && sortBy != toneSortNegative && sortBy != toneSortIntensity {
+		return
+	}
+
+	key := func(s models.Scene) (float64, bool) {
+		t, ok := tones[s.ID]
+		switch {
+		case !ok:
+			return 0, false
+		case sortBy == toneSortIntensity:
+			return t.Intensity, true
+		case t.Sentiment == nil:
+			return 0, false
+		case sortBy == toneSortNegative:
+			return -*t.Sentiment, true
+		default:
+			return *t.Sentiment, true
+		}
+	}
+	idx := make([]int, len(hits))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		ka, oka := key(hits[idx[a]])
+		kb, okb := key(hits[idx[b]])
+		if oka != okb {
+			return oka
+		}
+		return ka > kb
+	})
+	sortedItems := make([]gin.H, len(items))
+	sortedHits := make([]models.Scene, len(hits))
+	for i, j := range idx {
+		sortedItems[i], sortedHits[i] = items[j], hits[j]
+	}
+	copy(items, sortedItems)
+	copy(hits, sortedHits)
+}
+
+// getVideoTones returns the tone of each scene of a video at ?level= (shot by default), in order
+func getVideoTones(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	level := database.SceneLevelOrDefault(c.Query("level"))
+	if !models.ValidSceneLevel(level) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level", "details": "level must be shot or beat"})
+		return
+	}
+	if _, err := db.GetVideoByID(uint(videoID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	scenes, err := db.GetScenesByVideoIDAndLevel(uint(videoID), level)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scenes", "details": err.Error()})
+		return
+	}
+	ids := make([]uint, len(scenes))
+	for i, s := range scenes {
+		ids[i] = s.ID
+	}
+	tones, err := db.GetSceneTones(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scene tones", "details": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(tones))
+	for _, s := range scenes {
+		t, ok := tones[s.ID]
+		if !ok {
+			continue
+		}
+		out = append(out, gin.H{"scene_index": s.SceneIndex, "start_time": s.StartTime, "end_time": s.EndTime, "tone": t})
+	}
+	c.JSON(http.StatusOK, gin.H{"video_id": videoID, "level": level, "scenes": out, "count": len(out)})
+}
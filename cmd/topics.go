@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getVideoTopics returns a video's caption topic timeline. With ?query=, the segments that best match
+// the query are also returned (up to ?limit=, default 3) so clients can jump to where it is discussed.
+func getVideoTopics(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	if _, err := db.GetVideoByID(uint(videoID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	topics, err := db.ListVideoTopics(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load topics", "details": err.Error()})
+		return
+	}
+	resp := gin.H{"video_id": videoID, "topics": topics, "count": len(topics)}
+
+	query := strings.TrimSpace(c.Query("query"))
+	if query == "" || len(topics) == 0 {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "3"))
+	if err != nil || limit <= 0 {
+		limit = 3
+	}
+	vec, err := embedTextQuery(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+		return
+	}
+	matched, dists, err := db.SearchVideoTopics(uint(videoID), vec, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search topics", "details": err.Error()})
+		return
+	}
+	metric := database.MetricForColumn(database.ColumnText)
+	matches := make([]gin.H, len(matched))
+	for i, t := range matched {
+		matches[i] = gin.H{
+			"topic_index": t.TopicIndex,
+			"start_time":  t.StartTime,
+			"end_time":    t.EndTime,
+			"label":       t.Label,
+			"similarity":  metric.Similarity(dists[i]),
+		}
+	}
+	resp["query"] = query
+	resp["matches"] = matches
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/processor"
+	"goodclips-server/internal/queue"
+	"goodclips-server/internal/synonyms"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTranslationLanguages caps the languages one translation request may ask for
+const maxTranslationLanguages = 10
+
+// secondsToDuration converts a caption time in seconds to a time.Duration
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// translatedTSQuery builds the tsquery for searching translated captions: the synonym dictionary
+// is English, so words are matched as they are
+func translatedTSQuery(query string) string {
+	return synonyms.New(nil).TSQuery(query)
+}
+
+// translateVideoCaptions enqueues a caption_translation job per requested language:
+// {"languages": ["es", "fr"]}
+func translateVideoCaptions(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Languages []string `json:"languages" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	seen := map[string]bool{}
+	var langs []string
+	for _, l := range req.Languages {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if !processor.ValidLanguageCode(l) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language", "details": fmt.Sprintf("%q is not a two- or three-letter language code", l)})
+			return
+		}
+		if !seen[l] {
+			seen[l] = true
+			langs = append(langs, l)
+		}
+	}
+	if len(langs) == 0 || len(langs) > maxTranslationLanguages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid languages", "details": fmt.Sprintf("between 1 and %d languages are required", maxTranslationLanguages)})
+		return
+	}
+	if video.CaptionCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Video has no captions"})
+		return
+	}
+	jobs := make([]*queue.Job, 0, len(langs))
+	for _, l := range langs {
+		job, err := jobQueue.Enqueue(queue.JobTypeCaptionTranslation, map[string]interface{}{"video_id": video.ID, "language": l})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job", "details": err.Error()})
+			return
+		}
+		jobs = append(jobs, job)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Caption translation jobs created", "jobs": jobs})
+}
+
+// listVideoTranslations lists a video's translated caption tracks
+func listVideoTranslations(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	tracks, err := db.CaptionTranslationTracks(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list translations", "details": err.Error()})
+		return
+	}
+	type trackURL struct {
+		database.TranslationTrack
+		SubtitlesURL string `json:"subtitles_url"`
+	}
+	out := make([]trackURL, len(tracks))
+	for i, t := range tracks {
+		out[i] = trackURL{TranslationTrack: t, SubtitlesURL: videoSubtitlesURL(requestedVideoID(c), t.Language)}
+	}
+	c.JSON(http.StatusOK, gin.H{"video_id": video.ID, "translations": out})
+}
+
+// videoSubtitlesURL returns the signed URL of a video's subtitle file, the translated track of lang
+// when set; ?format=vtt may be appended
+func videoSubtitlesURL(videoID uint, lang string) string {
+	return urlSigner.SignWith(fmt.Sprintf("/api/v1/videos/%d/subtitles", videoID), map[string]string{"language": lang}, signedURLTTL())
+}
+
+// downloadVideoSubtitles serves a video's captions as a subtitle file (?format=srt or vtt), the
+// original captions or, with ?language=es, a translated track (signed URL required)
+func downloadVideoSubtitles(c *gin.Context) {
+	video, ok := videoFromParam(c)
+	if !ok {
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "srt"))
+	if format != "srt" && format != "vtt" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format", "details": "format must be srt or vtt"})
+		return
+	}
+	lang := strings.ToLower(c.Query("language"))
+	if lang != "" && !processor.ValidLanguageCode(lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language", "details": "language must be a two- or three-letter language code"})
+		return
+	}
+
+	var subs []ffmpeg.Subtitle
+	if lang == "" {
+		captions, err := db.GetCaptionsByVideoID(video.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load captions", "details": err.Error()})
+			return
+		}
+		for _, cp := range captions {
+			// Deliver the subtitle file's own text, with its line breaks and formatting
+			text := cp.Text
+			if cp.RawText != nil {
+				text = *cp.RawText
+			}
+			subs = append(subs, ffmpeg.Subtitle{Start: secondsToDuration(cp.StartTime), End: secondsToDuration(cp.EndTime), Text: text})
+		}
+	} else {
+		rows, err := db.ListCaptionTranslations(video.ID, lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load translations", "details": err.Error()})
+			return
+		}
+		for _, t := range rows {
+			subs = append(subs, ffmpeg.Subtitle{Start: secondsToDuration(t.StartTime), End: secondsToDuration(t.EndTime), Text: t.Text})
+		}
+	}
+	if len(subs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No captions found"})
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/x-subrip; charset=utf-8"
+	write := ffmpeg.WriteSRT
+	if format == "vtt" {
+		contentType, write = "text/vtt; charset=utf-8", ffmpeg.WriteVTT
+	}
+	if err := write(&buf, subs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write subtitles", "details": err.Error()})
+		return
+	}
+	name := fmt.Sprintf("video_%d.%s", video.ID, format)
+	if lang != "" {
+		name = fmt.Sprintf("video_%d.%s.%s", video.ID, lang, format)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
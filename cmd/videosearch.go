@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchVideos ranks whole videos by their title/synopsis/tags embedding; useful on its own or as a
+// first-stage filter before scene-level search
+func searchVideos(c *gin.Context) {
+	started := time.Now()
+	var req struct {
+		Query        string   `json:"query"`
+		VideoIDs     []uint   `json:"video_ids"`
+		Limit        int      `json:"limit"`
+		Language     string   `json:"language"`
+		LanguageMode string   `json:"language_mode"`
+		AssetTypes   []string `json:"asset_types"`
+		// SimilarityThreshold drops videos scoring below it (0 to 1; 0 keeps everything)
+		SimilarityThreshold float64 `json:"similarity_threshold"`
+		// Video tag, status and project filters
+		videoQuery
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search request", "details": "query is required"})
+		return
+	}
+	for _, t := range req.AssetTypes {
+		if !models.ValidAssetType(t) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset type", "details": t})
+			return
+		}
+	}
+	if !req.videoQuery.validate(c) || !similarityThresholdParam(c, req.SimilarityThreshold) {
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	queryText, modelID, langInfo, err := prepareTextQuery(req.Query, req.Language, req.LanguageMode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errLanguageMode) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Failed to prepare query", "details": err.Error()})
+		return
+	}
+	expanded := currentSynonyms().ExpandQuery(queryText)
+	vec, err := embedTextQueryWithModel(expanded, modelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to embed query", "details": err.Error()})
+		return
+	}
+
+	videos, dists, err := db.SearchVideosByTextVector(vec, limit, database.VideoFilter{VideoIDs: req.VideoIDs, AssetTypes: req.AssetTypes,
+		Tags: req.Tags, Statuses: req.Statuses, Projects: req.Projects})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
+		return
+	}
+
+	metric := database.MetricForColumn(database.ColumnText)
+	kept := aboveThreshold(metric, dists, req.SimilarityThreshold)
+	dropped := len(videos) - kept
+	videos, dists = videos[:kept], dists[:kept]
+
+	items := make([]gin.H, 0, len(videos))
+	videoIDs := make([]uint, 0, len(videos))
+	for i, v := range videos {
+		videoIDs = append(videoIDs, v.ID)
+		items = append(items, gin.H{
+			"video": gin.H{
+				"id":          v.ID,
+				"uuid":        v.UUID,
+				"filename":    v.Filename,
+				"title":       v.Title,
+				"asset_type":  v.AssetType,
+				"duration":    v.Duration,
+				"scene_count": v.SceneCount,
+				"tags":        v.Tags,
+				"status":      v.Status,
+			},
+			"distance":   dists[i],
+			"similarity": metric.Similarity(dists[i]),
+			"score":      metric.Score(dists[i]),
+		})
+	}
+
+	// Video searches have no scene results; the event records the returned video IDs instead
+	ev := &models.SearchEvent{
+		Modality: "videos",
+		Query:    req.Query,
+		Filters: models.JSONObject{"limit": limit, "language": req.Language, "asset_types": req.AssetTypes, "video_ids": req.VideoIDs, "result_video_ids": videoIDs,
+			"similarity_threshold": req.SimilarityThreshold},
+		LatencyMs:   float64(time.Since(started).Microseconds()) / 1000.0,
+		ResultCount: len(items),
+	}
+	if err := db.CreateSearchEvent(ev); err != nil {
+		log.Printf("Warning: failed to record search event: %v", err)
+	}
+
+	resp := gin.H{
+		"search_id": ev.ID,
+		"query":     req.Query,
+		"limit":     limit,
+		"count":     len(items),
+		"results":   items,
+	}
+	if langInfo != nil {
+		resp["language"] = langInfo
+	}
+	if expanded != queryText {
+		resp["expanded_query"] = expanded
+	}
+	thresholdInfo(resp, req.SimilarityThreshold, dropped)
+	c.JSON(http.StatusOK, resp)
+}
+
+// shortlistSize is the number of videos two-stage search keeps: the request's value, else
+// SEARCH_SHORTLIST_SIZE (default 50)
+func shortlistSize(requested int) int {
+	if requested > 0 {
+		return requested
+	}
+	if n, err := strconv.Atoi(getEnvOrDefault("SEARCH_SHORTLIST_SIZE", "50")); err == nil && n > 0 {
+		return n
+	}
+	return 50
+}
+
+// applyVideoShortlist is the first stage of two-stage search: it narrows filter to the n videos whose
+// video-level text embedding is nearest to vec (an e5-base-v2 query vector), so the scene-level search
+// only scans those. When no video has a video-level embedding the filter is left unchanged.
+func applyVideoShortlist(filter *database.SceneFilter, vec []float32, n int) (gin.H, error) {
+	started := time.Now()
+	videos, _, err := db.SearchVideosByTextVector(vec, n, videoFilterOf(*filter))
+	if err != nil {
+		return nil, err
+	}
+	info := gin.H{"size": n, "latency_ms": float64(time.Since(started).Microseconds()) / 1000.0}
+	if len(videos) == 0 {
+		info["fallback"] = true
+		return info, nil
+	}
+	ids := make([]uint, 0, len(videos))
+	for _, v := range videos {
+		ids = append(ids, v.ID)
+	}
+	filter.VideoIDs = ids
+	info["video_ids"] = ids
+	return info, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getVideoWarnings summarises the data-quality warnings of a video's pipeline: for each job type
+// those of its latest job, so warnings that a re-run resolved drop out. Jobs that warn still
+// complete; this is where the warnings show.
+func getVideoWarnings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+	if _, err := db.GetVideoByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	jobs, err := jobQueue.ListJobsForVideo(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs", "details": err.Error()})
+		return
+	}
+
+	// Jobs are listed oldest first, so the last job of a type wins
+	latest := map[queue.JobType]*queue.Job{}
+	var order []queue.JobType
+	for _, j := range jobs {
+		if latest[j.Type] == nil {
+			order = append(order, j.Type)
+		}
+		latest[j.Type] = j
+	}
+
+	type stageWarnings struct {
+		JobType         queue.JobType   `json:"job_type"`
+		JobID           string          `json:"job_id"`
+		Status          queue.JobStatus `json:"status"`
+		CompletedAt     *time.Time      `json:"completed_at,omitempty"`
+		Warnings        []string        `json:"warnings"`
+		WarningsDropped int             `json:"warnings_dropped,omitempty"`
+	}
+	stages := []stageWarnings{}
+	total := 0
+	for _, t := range order {
+		j := latest[t]
+		warnings, dropped := j.Warnings()
+		if len(warnings)+dropped == 0 {
+			continue
+		}
+		total += len(warnings) + dropped
+		stages = append(stages, stageWarnings{
+			JobType:         t,
+			JobID:           j.ID,
+			Status:          j.Status,
+			CompletedAt:     j.CompletedAt,
+			Warnings:        warnings,
+			WarningsDropped: dropped,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"video_id": id, "warning_count": total, "stages": stages})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/signedurl"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shareLinkParams are the signed parameters of a share link: the viewer it was issued to and its
+// watermark (JSON), both optional
+func shareLinkParams(viewer string, wm *ffmpeg.Watermark) map[string]string {
+	params := map[string]string{"viewer": viewer}
+	if !wm.Empty() {
+		if b, err := json.Marshal(wm); err == nil {
+			params["wm"] = string(b)
+		}
+	}
+	return params
+}
+
+// shareLinkWatermark reads the viewer and watermark of a verified share link. Only values covered by
+// the signature count, so neither can be added to a link; a link issued to no viewer is stamped with
+// a marker of its signature instead, which traces a leaked cut to the URL it was rendered for.
+func shareLinkWatermark(c *gin.Context) (*ffmpeg.Watermark, string, error) {
+	query := c.Request.URL.Query()
+	viewer := signedurl.SignedValue(query, "viewer")
+	if viewer == "" {
+		sig := query.Get("sig")
+		if len(sig) > 12 {
+			sig = sig[:12]
+		}
+		viewer = "link " + sig
+	}
+	raw := signedurl.SignedValue(query, "wm")
+	if raw == "" {
+		return nil, viewer, nil
+	}
+	var wm ffmpeg.Watermark
+	if err := json.Unmarshal([]byte(raw), &wm); err != nil {
+		return nil, "", err
+	}
+	return &wm, viewer, nil
+}
+
+// listProjectWatermarks returns the stored project watermarks and the server default (admin)
+func listProjectWatermarks(c *gin.Context) {
+	rows, err := db.ListProjectWatermarks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list watermarks", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"watermarks": rows, "default": ffmpeg.WatermarkFromEnv()})
+}
+
+// putProjectWatermark stores the watermark of a project ("" for videos without one):
+// {"project": "acme", "enabled": true, "text": "{user_id} {timestamp}", "position": "top-right"}.
+// "enabled": false turns the server default off for the project (admin).
+func putProjectWatermark(c *gin.Context) {
+	var req struct {
+		Project string `json:"project"`
+		Enabled *bool  `json:"enabled"`
+		ffmpeg.Watermark
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	req.Project = strings.TrimSpace(req.Project)
+	if len(req.Project) > 128 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project", "details": "project must be at most 128 characters"})
+		return
+	}
+	if err := req.Watermark.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watermark", "details": err.Error()})
+		return
+	}
+	w := &models.ProjectWatermark{
+		Project:   req.Project,
+		Enabled:   true,
+		Text:      req.Text,
+		ImagePath: req.ImagePath,
+		Position:  req.Position,
+		Opacity:   req.Opacity,
+		FontSize:  req.FontSize,
+	}
+	if req.Enabled != nil {
+		w.Enabled = *req.Enabled
+	}
+	if err := db.SetProjectWatermark(w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store watermark", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"watermark": w})
+}
+
+// deleteProjectWatermark removes the watermark of ?project=, so the project falls back to the server
+// default (admin)
+func deleteProjectWatermark(c *gin.Context) {
+	deleted, err := db.DeleteProjectWatermark(strings.TrimSpace(c.Query("project")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete watermark", "details": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Watermark not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Watermark deleted"})
+}
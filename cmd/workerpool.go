@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/queue"
+)
+
+// defaultJobTypeLimits caps GPU-heavy job types when WORKER_JOB_LIMITS is unset
+var defaultJobTypeLimits = map[queue.JobType]int{
+	queue.JobTypeEmbeddingGeneration: 1,
+}
+
+// limitedPollInterval is how long a worker waits when every job type is at its limit
+const limitedPollInterval = time.Second
+
+// workerAbortGrace is how long in-flight jobs get to stop and requeue once the drain timeout cancels them
+const workerAbortGrace = 15 * time.Second
+
+// workerDrainTimeout is how long a stopping worker waits for in-flight jobs
+// (WORKER_DRAIN_TIMEOUT_SECS, default 60)
+func workerDrainTimeout() time.Duration {
+	if secs, err := strconv.Atoi(os.Getenv("WORKER_DRAIN_TIMEOUT_SECS")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 60 * time.Second
+}
+
+// workerConcurrency is the number of jobs a worker runs at once (WORKER_CONCURRENCY, default 1)
+func workerConcurrency() int {
+	if n, err := strconv.Atoi(os.Getenv("WORKER_CONCURRENCY")); err == nil && n >= 1 {
+		return n
+	}
+	return 1
+}
+
+// jobTypeLimitsFromEnv parses WORKER_JOB_LIMITS ("embedding_generation=1,clip_export=2") into
+// per-type concurrency limits. Unknown types and malformed entries are logged and ignored.
+func jobTypeLimitsFromEnv() map[queue.JobType]int {
+	raw := strings.TrimSpace(os.Getenv("WORKER_JOB_LIMITS"))
+	if raw == "" {
+		return defaultJobTypeLimits
+	}
+	known := make(map[queue.JobType]bool, len(queue.AllJobTypes))
+	for _, t := range queue.AllJobTypes {
+		known[t] = true
+	}
+	limits := make(map[queue.JobType]int)
+	for _, entry := range strings.Split(raw, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		jobType := queue.JobType(strings.TrimSpace(name))
+		if !ok || err != nil || n < 1 || !known[jobType] {
+			log.Printf("Warning: ignoring WORKER_JOB_LIMITS entry %q", entry)
+			continue
+		}
+		limits[jobType] = n
+	}
+	return limits
+}
+
+// jobTypeLimiter bounds how many jobs of each limited type run at once across a worker's goroutines
+type jobTypeLimiter struct {
+	slots map[queue.JobType]chan struct{}
+}
+
+func newJobTypeLimiter(limits map[queue.JobType]int) *jobTypeLimiter {
+	l := &jobTypeLimiter{slots: make(map[queue.JobType]chan struct{}, len(limits))}
+	for t, n := range limits {
+		l.slots[t] = make(chan struct{}, n)
+	}
+	return l
+}
+
+// available lists the job types with a free slot, in AllJobTypes order
+func (l *jobTypeLimiter) available() []queue.JobType {
+	types := make([]queue.JobType, 0, len(queue.AllJobTypes))
+	for _, t := range queue.AllJobTypes {
+		if slots, ok := l.slots[t]; ok && len(slots) == cap(slots) {
+			continue
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// acquire blocks until a slot for the job type is free or stop is cancelled, and reports whether it
+// took a slot; unlimited types never block
+func (l *jobTypeLimiter) acquire(stop context.Context, t queue.JobType) bool {
+	slots, ok := l.slots[t]
+	if !ok {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-stop.Done():
+		return false
+	}
+}
+
+func (l *jobTypeLimiter) release(t queue.JobType) {
+	if slots, ok := l.slots[t]; ok {
+		<-slots
+	}
+}
+
+// runWorkerLoop dequeues and handles jobs until stop is cancelled, only polling the queues of job
+// types below their limit. Another goroutine can take the last slot between polling and dequeuing,
+// so acquire may still wait; the job's lease is renewed from the dequeue on, so a wait longer than
+// the lease does not hand it to another worker. Jobs run under jobCtx.
+func runWorkerLoop(stop, jobCtx context.Context, limiter *jobTypeLimiter, retryPolicy queue.RetryPolicy) {
+	for stop.Err() == nil {
+		types := limiter.available()
+		if len(types) == 0 {
+			// DequeueAny treats an empty list as every type
+			select {
+			case <-stop.Done():
+			case <-time.After(limitedPollInterval):
+			}
+			continue
+		}
+		job, err := jobQueue.DequeueAny(types)
+		if err != nil {
+			log.Printf("Error dequeuing job: %v", err)
+			continue
+		}
+		if job == nil {
+			// No jobs available, continue loop
+			continue
+		}
+		stopLease := keepJobLease(job.ID)
+		acquired := limiter.acquire(stop, job.Type)
+		if stop.Err() != nil {
+			// Dequeued while the stop signal arrived, or waited for a slot until it did; leave it
+			// for the next worker
+			if acquired {
+				limiter.release(job.Type)
+			}
+			stopLease()
+			if err := jobQueue.RequeueJob(job.ID); err != nil {
+				log.Printf("Error requeuing job %s: %v", job.ID, err)
+			}
+			return
+		}
+		handleJob(jobCtx, job, retryPolicy)
+		limiter.release(job.Type)
+		stopLease()
+	}
+}
+
+// keepJobLease renews the job's lease every third of the lease duration until the returned func is
+// called, so a live worker keeps its long jobs and those waiting for a slot
+func keepJobLease(jobID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(queue.LeaseDuration() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := jobQueue.RenewLease(jobID); err != nil {
+					log.Printf("Warning: failed to renew lease of job %s: %v", jobID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// runLeaseReaper requeues the jobs of crashed workers every half lease duration until stop is
+// cancelled
+func runLeaseReaper(stop context.Context, retryPolicy queue.RetryPolicy) {
+	ticker := time.NewTicker(queue.LeaseDuration() / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+			n, err := jobQueue.ReapExpiredLeases(retryPolicy)
+			if err != nil {
+				log.Printf("Warning: failed to reap expired job leases: %v", err)
+			}
+			if n > 0 {
+				log.Printf("♻️  Requeued %d job(s) of lost workers", n)
+			}
+		}
+	}
+}
+
+// breakerProbeInterval is how often open runner breakers are health-probed
+// (RUNNER_PROBE_INTERVAL_SECS, default 30)
+func breakerProbeInterval() time.Duration {
+	if secs, err := strconv.Atoi(os.Getenv("RUNNER_PROBE_INTERVAL_SECS")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// runBreakerProbes health-probes runners whose circuit breaker finished its cooldown until stop is
+// cancelled, closing the breakers of runners that recovered
+func runBreakerProbes(stop context.Context) {
+	ticker := time.NewTicker(breakerProbeInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+			closed, err := videoProcessor.ProbeRunnerBreakers(stop)
+			if err != nil {
+				log.Printf("Warning: failed to probe runner breakers: %v", err)
+			}
+			for _, name := range closed {
+				log.Printf("🔌 Runner %s recovered; deferred jobs resume", name)
+			}
+		}
+	}
+}
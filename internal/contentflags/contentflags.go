@@ -0,0 +1,190 @@
+package contentflags
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Entry is one flagged word or phrase; a trailing * matches any word starting with the stem
+type Entry struct {
+	Term     string `json:"term"`
+	Category string `json:"category"`
+}
+
+// Match is one flagged occurrence in a text; Start and End are byte offsets
+type Match struct {
+	Term     string `json:"term"`
+	Category string `json:"category"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+type pattern struct {
+	words    []string
+	prefix   bool // last word is a stem
+	term     string
+	category string
+}
+
+// Matcher finds wordlist terms in text, case-insensitively and on word boundaries
+type Matcher struct {
+	patterns map[string][]pattern // keyed by first word (or stem)
+	stems    []pattern            // single-word stems, checked against every word
+}
+
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}]+(?:'[\p{L}\p{N}]+)*`)
+
+// DefaultProfanity is the built-in "profanity" wordlist
+var DefaultProfanity = []string{
+	"fuck*", "motherfuck*", "shit*", "bullshit", "bitch*", "bastard*", "asshole*", "dick", "dickhead*",
+	"cunt*", "piss", "pissed", "cock", "cocksucker*", "prick", "twat*", "wanker*", "bollocks",
+	"goddamn*", "damn", "jackass", "douchebag*", "slut*", "whore*",
+}
+
+// New builds a matcher from entries; empty terms are ignored
+func New(entries []Entry) *Matcher {
+	m := &Matcher{patterns: map[string][]pattern{}}
+	for _, e := range entries {
+		words := wordRe.FindAllString(strings.ToLower(e.Term), -1)
+		if len(words) == 0 {
+			continue
+		}
+		p := pattern{words: words, prefix: strings.HasSuffix(strings.TrimSpace(e.Term), "*"), term: e.Term, category: e.Category}
+		if p.category == "" {
+			p.category = "profanity"
+		}
+		if p.prefix && len(words) == 1 {
+			m.stems = append(m.stems, p)
+			continue
+		}
+		m.patterns[words[0]] = append(m.patterns[words[0]], p)
+	}
+	return m
+}
+
+// Len returns the number of terms
+func (m *Matcher) Len() int {
+	if m == nil {
+		return 0
+	}
+	n := len(m.stems)
+	for _, ps := range m.patterns {
+		n += len(ps)
+	}
+	return n
+}
+
+// Find returns the flagged occurrences in text, in order; overlapping terms are reported once
+func (m *Matcher) Find(text string) []Match {
+	if m.Len() == 0 {
+		return nil
+	}
+	locs := wordRe.FindAllStringIndex(text, -1)
+	words := make([]string, len(locs))
+	for i, l := range locs {
+		words[i] = strings.ToLower(text[l[0]:l[1]])
+	}
+	var out []Match
+	for i := 0; i < len(words); {
+		n, p := m.matchAt(words, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		out = append(out, Match{Term: p.term, Category: p.category, Start: locs[i][0], End: locs[i+n-1][1]})
+		i += n
+	}
+	return out
+}
+
+// matchAt returns the length in words and pattern of the longest term starting at words[i]
+func (m *Matcher) matchAt(words []string, i int) (int, pattern) {
+	best, bestP := 0, pattern{}
+	for _, p := range m.patterns[words[i]] {
+		if n := p.matches(words[i:]); n > best {
+			best, bestP = n, p
+		}
+	}
+	if best == 0 {
+		for _, p := range m.stems {
+			if strings.HasPrefix(words[i], p.words[0]) {
+				return 1, p
+			}
+		}
+	}
+	return best, bestP
+}
+
+func (p pattern) matches(words []string) int {
+	if len(words) < len(p.words) {
+		return 0
+	}
+	last := len(p.words) - 1
+	for j, w := range p.words {
+		if j == last && p.prefix {
+			if !strings.HasPrefix(words[j], w) {
+				return 0
+			}
+		} else if words[j] != w {
+			return 0
+		}
+	}
+	return len(p.words)
+}
+
+// FromEnv builds the matcher from the built-in profanity list (unless CONTENT_FLAG_DEFAULTS=false) plus
+// CONTENT_FLAG_WORDLISTS, a comma-separated list of category=path files with one term per line
+// ("#" starts a comment)
+func FromEnv() (*Matcher, error) {
+	var entries []Entry
+	if v, err := strconv.ParseBool(os.Getenv("CONTENT_FLAG_DEFAULTS")); err != nil || v {
+		for _, t := range DefaultProfanity {
+			entries = append(entries, Entry{Term: t, Category: "profanity"})
+		}
+	}
+	for _, spec := range strings.Split(os.Getenv("CONTENT_FLAG_WORDLISTS"), ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		category, path, ok := strings.Cut(spec, "=")
+		if !ok || strings.TrimSpace(category) == "" {
+			return nil, fmt.Errorf("invalid wordlist %q: want category=path", spec)
+		}
+		terms, err := readWordlist(strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range terms {
+			entries = append(entries, Entry{Term: t, Category: strings.TrimSpace(category)})
+		}
+	}
+	return New(entries), nil
+}
+
+func readWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %v", err)
+	}
+	defer f.Close()
+	var terms []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			terms = append(terms, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist %s: %v", path, err)
+	}
+	return terms, nil
+}
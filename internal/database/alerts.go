@@ -0,0 +1,103 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateAlert inserts a new standing alert, normalizing its query embedding on write
+func (db *DB) CreateAlert(alert *models.Alert) error {
+	alert.Embedding = prepareEmbedding(alert.Embedding)
+	return db.Create(alert).Error
+}
+
+// GetAlert loads an alert by ID
+func (db *DB) GetAlert(id uint) (*models.Alert, error) {
+	var alert models.Alert
+	if err := db.First(&alert, id).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// UpdateAlert saves an alert's definition and state, normalizing its query embedding on write
+func (db *DB) UpdateAlert(alert *models.Alert) error {
+	alert.Embedding = prepareEmbedding(alert.Embedding)
+	return db.Save(alert).Error
+}
+
+// DeleteAlert removes an alert and its matches
+func (db *DB) DeleteAlert(id uint) error {
+	return db.Delete(&models.Alert{}, id).Error
+}
+
+// ListAlerts returns alerts oldest first, optionally only active ones
+func (db *DB) ListAlerts(activeOnly bool) ([]models.Alert, error) {
+	q := db.Order("id ASC")
+	if activeOnly {
+		q = q.Where("active = ?", true)
+	}
+	var alerts []models.Alert
+	err := q.Find(&alerts).Error
+	return alerts, err
+}
+
+// RecordAlertMatches stores matches, skipping scenes that already matched the same alert, and
+// returns the newly stored ones. The alert's match count and last trigger time are updated.
+func (db *DB) RecordAlertMatches(alertID uint, matches []models.AlertMatch) ([]models.AlertMatch, error) {
+	var created []models.AlertMatch
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range matches {
+			m.AlertID = alertID
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&m)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected > 0 {
+				created = append(created, m)
+			}
+		}
+		if len(created) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Alert{}).Where("id = ?", alertID).Updates(map[string]interface{}{
+			"match_count":       gorm.Expr("match_count + ?", len(created)),
+			"last_triggered_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// MarkAlertMatchesNotified records the delivery outcome of matches; a nil deliveryErr marks them notified
+func (db *DB) MarkAlertMatchesNotified(ids []uint, deliveryErr error) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	updates := map[string]interface{}{"notified_at": time.Now(), "notify_error": nil}
+	if deliveryErr != nil {
+		updates = map[string]interface{}{"notify_error": deliveryErr.Error()}
+	}
+	return db.Model(&models.AlertMatch{}).Where("id IN ?", ids).Updates(updates).Error
+}
+
+// ListAlertMatches returns an alert's matches newest first with the total count
+func (db *DB) ListAlertMatches(alertID uint, limit, offset int) ([]models.AlertMatch, int, error) {
+	var total int64
+	if err := db.Model(&models.AlertMatch{}).Where("alert_id = ?", alertID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var matches []models.AlertMatch
+	err := db.Where("alert_id = ?", alertID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&matches).Error
+	return matches, int(total), err
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+)
+
+// AnnotationFilter narrows annotation listings; zero values match everything
+type AnnotationFilter struct {
+	VideoID    uint
+	Kind       string
+	Collection string
+}
+
+// resolveAnnotationScenes points annotations matching where at the scene of their level that overlaps
+// their range most (for point annotations and ties, the scene whose midpoint is closest)
+func (db *DB) resolveAnnotationScenes(where string, arg interface{}) error {
+	return db.Exec(`UPDATE annotations a SET scene_id = (
+		SELECT s.id FROM scenes s WHERE s.video_id = a.video_id AND s.level = a.level
+		ORDER BY GREATEST(0, LEAST(s.end_time, a.end_time) - GREATEST(s.start_time, a.start_time)) DESC,
+			ABS((s.start_time + s.end_time) - (a.start_time + a.end_time)) ASC
+		LIMIT 1)
+		WHERE `+where, arg).Error
+}
+
+// ReassociateAnnotations re-resolves the scenes of a video's annotations after its scenes changed
+func (db *DB) ReassociateAnnotations(videoID uint) error {
+	return db.resolveAnnotationScenes("a.video_id = ?", videoID)
+}
+
+// CreateAnnotation stores an annotation and resolves its scene
+func (db *DB) CreateAnnotation(a *models.Annotation) error {
+	if err := db.Create(a).Error; err != nil {
+		return err
+	}
+	return db.reloadAnnotation(a)
+}
+
+// UpdateAnnotation saves an annotation and re-resolves its scene
+func (db *DB) UpdateAnnotation(a *models.Annotation) error {
+	if err := db.Save(a).Error; err != nil {
+		return err
+	}
+	return db.reloadAnnotation(a)
+}
+
+// reloadAnnotation resolves a stored annotation's scene and reads it back with its scene index
+func (db *DB) reloadAnnotation(a *models.Annotation) error {
+	if err := db.resolveAnnotationScenes("a.id = ?", a.ID); err != nil {
+		return err
+	}
+	fresh, err := db.GetAnnotation(a.ID)
+	if err != nil {
+		return err
+	}
+	*a = *fresh
+	return nil
+}
+
+// GetAnnotation loads an annotation with its scene index
+func (db *DB) GetAnnotation(id uint) (*models.Annotation, error) {
+	var a models.Annotation
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	list := []models.Annotation{a}
+	if err := db.fillAnnotationSceneIndexes(list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// DeleteAnnotation removes an annotation
+func (db *DB) DeleteAnnotation(id uint) error {
+	return db.Delete(&models.Annotation{}, id).Error
+}
+
+// ListAnnotations returns the annotations matching the filter by video and time, with their scene indexes
+func (db *DB) ListAnnotations(filter AnnotationFilter) ([]models.Annotation, error) {
+	q := db.Model(&models.Annotation{})
+	if filter.VideoID != 0 {
+		q = q.Where("video_id = ?", filter.VideoID)
+	}
+	if filter.Kind != "" {
+		q = q.Where("kind = ?", filter.Kind)
+	}
+	if filter.Collection != "" {
+		q = q.Where("collection = ?", filter.Collection)
+	}
+	var out []models.Annotation
+	if err := q.Order("video_id, start_time, id").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, db.fillAnnotationSceneIndexes(out)
+}
+
+// fillAnnotationSceneIndexes sets SceneIndex from each annotation's resolved scene
+func (db *DB) fillAnnotationSceneIndexes(list []models.Annotation) error {
+	var ids []uint
+	for _, a := range list {
+		if a.SceneID != nil {
+			ids = append(ids, *a.SceneID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var scenes []models.Scene
+	if err := db.Select("id, scene_index").Where("id IN ?", ids).Find(&scenes).Error; err != nil {
+		return err
+	}
+	index := make(map[uint]int, len(scenes))
+	for _, s := range scenes {
+		index[s.ID] = s.SceneIndex
+	}
+	for i := range list {
+		if list[i].SceneID == nil {
+			continue
+		}
+		if idx, ok := index[*list[i].SceneID]; ok {
+			list[i].SceneIndex = &idx
+		}
+	}
+	return nil
+}
+
+// AnnotationCollection is a collection name with how many annotations it holds
+type AnnotationCollection struct {
+	Collection string `json:"collection"`
+	Count      int    `json:"count"`
+}
+
+// ListAnnotationCollections returns every collection in use, by name
+func (db *DB) ListAnnotationCollections() ([]AnnotationCollection, error) {
+	var out []AnnotationCollection
+	err := db.Model(&models.Annotation{}).Select("collection, COUNT(*) AS count").
+		Where("collection IS NOT NULL").Group("collection").Order("collection").Scan(&out).Error
+	return out, err
+}
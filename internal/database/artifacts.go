@@ -0,0 +1,145 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+)
+
+// ArtifactTierStat counts the artifacts of one kind in one storage tier
+type ArtifactTierStat struct {
+	Kind      string `json:"kind"`
+	Tier      string `json:"tier"`
+	Count     int64  `json:"count"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// TrackArtifact records a freshly rendered export file as a hot artifact
+func (db *DB) TrackArtifact(kind string, ownerID uint, path string, size int64) error {
+	return db.Exec(`INSERT INTO artifacts (kind, owner_id, path, size_bytes) VALUES (?, ?, ?, ?)
+		ON CONFLICT (kind, owner_id) DO UPDATE SET path = EXCLUDED.path, size_bytes = EXCLUDED.size_bytes,
+			tier = ?, cold_path = NULL, archived_at = NULL, restore_job_id = NULL, restore_claimed_at = NULL,
+			last_accessed_at = NOW(), updated_at = NOW()`, kind, ownerID, path, size, models.ArtifactTierHot).Error
+}
+
+// SyncArtifacts tracks the exported files of clip exports, highlight reels and supercuts that have no
+// artifact yet, e.g. rendered before tiering was enabled, as last accessed when their export was last
+// updated. It returns how many artifacts were added.
+func (db *DB) SyncArtifacts() (int64, error) {
+	res := db.Exec(`INSERT INTO artifacts (kind, owner_id, path, size_bytes, last_accessed_at)
+		SELECT ?, id, export_path, COALESCE(file_size, 0), updated_at FROM clip_exports WHERE export_path IS NOT NULL
+		UNION ALL
+		SELECT ?, id, export_path, 0, updated_at FROM highlight_reels WHERE export_path IS NOT NULL
+		UNION ALL
+		SELECT ?, id, export_path, 0, updated_at FROM supercuts WHERE export_path IS NOT NULL
+		ON CONFLICT (kind, owner_id) DO NOTHING`,
+		models.ArtifactClipExport, models.ArtifactHighlightReel, models.ArtifactSupercut)
+	return res.RowsAffected, res.Error
+}
+
+// PruneArtifacts stops tracking artifacts whose export was deleted and returns them, so that their
+// cold copies can be removed
+func (db *DB) PruneArtifacts() ([]models.Artifact, error) {
+	var pruned []models.Artifact
+	err := db.Raw(`DELETE FROM artifacts a
+		WHERE (a.kind = ? AND NOT EXISTS (SELECT 1 FROM clip_exports e WHERE e.id = a.owner_id AND e.export_path IS NOT NULL))
+		   OR (a.kind = ? AND NOT EXISTS (SELECT 1 FROM highlight_reels e WHERE e.id = a.owner_id AND e.export_path IS NOT NULL))
+		   OR (a.kind = ? AND NOT EXISTS (SELECT 1 FROM supercuts e WHERE e.id = a.owner_id AND e.export_path IS NOT NULL))
+		RETURNING a.*`, models.ArtifactClipExport, models.ArtifactHighlightReel, models.ArtifactSupercut).Scan(&pruned).Error
+	return pruned, err
+}
+
+// TouchArtifact records a download of an export's file at path, tracking it when it is new, and
+// returns its artifact
+func (db *DB) TouchArtifact(kind string, ownerID uint, path string) (*models.Artifact, error) {
+	var a models.Artifact
+	err := db.Raw(`INSERT INTO artifacts (kind, owner_id, path) VALUES (?, ?, ?)
+		ON CONFLICT (kind, owner_id) DO UPDATE SET last_accessed_at = NOW(), updated_at = NOW()
+		RETURNING *`, kind, ownerID, path).Scan(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetArtifact loads an artifact by ID
+func (db *DB) GetArtifact(id uint) (*models.Artifact, error) {
+	var a models.Artifact
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ArtifactsToArchive returns up to limit hot artifacts of a kind not accessed since cutoff, least
+// recently accessed first
+func (db *DB) ArtifactsToArchive(kind string, cutoff time.Time, limit int) ([]models.Artifact, error) {
+	var artifacts []models.Artifact
+	err := db.Where("kind = ? AND tier = ? AND last_accessed_at < ?", kind, models.ArtifactTierHot, cutoff).
+		Order("last_accessed_at").Limit(limit).Find(&artifacts).Error
+	return artifacts, err
+}
+
+// MarkArtifactCold records that an artifact was copied to coldPath. It reports false, changing
+// nothing, when the artifact was accessed since cutoff or is no longer hot, in which case the copy is
+// not needed.
+func (db *DB) MarkArtifactCold(id uint, coldPath string, size int64, cutoff time.Time) (bool, error) {
+	res := db.Model(&models.Artifact{}).Where("id = ? AND tier = ? AND last_accessed_at < ?", id, models.ArtifactTierHot, cutoff).
+		Updates(map[string]interface{}{
+			"tier":        models.ArtifactTierCold,
+			"cold_path":   coldPath,
+			"size_bytes":  size,
+			"archived_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// ClaimArtifactRestore moves a cold artifact to restoring, also taking over a restore claimed before
+// staleBefore; it reports false when another download holds a current claim or the artifact is hot
+func (db *DB) ClaimArtifactRestore(id uint, staleBefore time.Time) (bool, error) {
+	res := db.Model(&models.Artifact{}).
+		Where("id = ? AND (tier = ? OR (tier = ? AND (restore_claimed_at IS NULL OR restore_claimed_at < ?)))",
+			id, models.ArtifactTierCold, models.ArtifactTierRestoring, staleBefore).
+		Updates(map[string]interface{}{
+			"tier":               models.ArtifactTierRestoring,
+			"restore_job_id":     nil,
+			"restore_claimed_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// SetArtifactRestoreJob records the job restoring an artifact
+func (db *DB) SetArtifactRestoreJob(id uint, jobID string) error {
+	return db.Model(&models.Artifact{}).Where("id = ?", id).Update("restore_job_id", jobID).Error
+}
+
+// MarkArtifactHot records that a restoring artifact is back at its path
+func (db *DB) MarkArtifactHot(id uint) error {
+	now := time.Now()
+	return db.Model(&models.Artifact{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"tier":               models.ArtifactTierHot,
+		"cold_path":          nil,
+		"restore_job_id":     nil,
+		"restore_claimed_at": nil,
+		"restored_at":        now,
+		"last_accessed_at":   now,
+	}).Error
+}
+
+// ReleaseArtifactRestore returns a restoring artifact to cold, e.g. when its restore failed, so the
+// next download retries it. Only the claim of jobID (or a claim without a job yet) is released, so a
+// superseded restore does not release its successor's.
+func (db *DB) ReleaseArtifactRestore(id uint, jobID string) error {
+	return db.Model(&models.Artifact{}).
+		Where("id = ? AND tier = ? AND (restore_job_id = ? OR restore_job_id IS NULL)", id, models.ArtifactTierRestoring, jobID).
+		Updates(map[string]interface{}{"tier": models.ArtifactTierCold, "restore_job_id": nil, "restore_claimed_at": nil}).Error
+}
+
+// ArtifactTierStats counts artifacts and their sizes by kind and tier
+func (db *DB) ArtifactTierStats() ([]ArtifactTierStat, error) {
+	var stats []ArtifactTierStat
+	err := db.Model(&models.Artifact{}).
+		Select("kind, tier, COUNT(*) AS count, COALESCE(SUM(size_bytes), 0) AS size_bytes").
+		Group("kind, tier").Order("kind, tier").Scan(&stats).Error
+	return stats, err
+}
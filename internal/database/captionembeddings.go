@@ -0,0 +1,86 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+// ReplaceCaptionEmbeddings atomically replaces a video's caption passage embeddings, normalizing them
+// on write like scene embeddings
+func (db *DB) ReplaceCaptionEmbeddings(videoID uint, rows []models.CaptionEmbedding) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.CaptionEmbedding{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for i := range rows {
+			rows[i].Embedding = prepareEmbedding(rows[i].Embedding)
+		}
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+}
+
+// VideoIDsWithCaptionEmbeddings returns the videos that have caption passage embeddings
+func (db *DB) VideoIDsWithCaptionEmbeddings() ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.CaptionEmbedding{}).Distinct("video_id").Order("video_id").Pluck("video_id", &ids).Error
+	return ids, err
+}
+
+// PassageHit is a caption passage near a query embedding, with its video and the scene containing it
+type PassageHit struct {
+	PassageID     uint     `json:"passage_id"`
+	CaptionID     uint     `json:"caption_id"`
+	CaptionCount  int      `json:"caption_count"`
+	VideoID       uint     `json:"video_id"`
+	StartTime     float64  `json:"start_time"`
+	EndTime       float64  `json:"end_time"`
+	Text          string   `json:"text"`
+	Distance      float64  `json:"-"`
+	Similarity    float64  `json:"similarity"`
+	Score         float64  `json:"score"`
+	VideoFilename string   `json:"video_filename"`
+	VideoTitle    *string  `json:"video_title"`
+	SceneID       *uint    `json:"scene_id"`
+	SceneIndex    *int     `json:"scene_index"`
+	SceneStart    *float64 `json:"scene_start_time"`
+	SceneEnd      *float64 `json:"scene_end_time"`
+}
+
+// SearchCaptionPassages ranks caption passages by distance to a text embedding (same metric as scene
+// text search), nearest first. Hits carry the passage's scene of level (see CaptionSceneStrategy).
+func (db *DB) SearchCaptionPassages(vec []float32, k int, videoIDs []uint, level string) ([]PassageHit, error) {
+	metric := MetricForColumn(ColumnText)
+	q := vectorQueryOn("captions_embeddings p JOIN videos v ON v.id = p.video_id",
+		"p.id AS passage_id, p.caption_id, p.caption_count, p.video_id, p.start_time, p.end_time, p.text",
+		"p.embedding", metric, pgvector.NewVector(prepareVector(vec)))
+	q.where("v.status <> ?", models.VideoStatusDeleted)
+	if len(videoIDs) > 0 {
+		q.where("p.video_id IN ("+contentVideoIDs+")", videoIDs)
+	}
+
+	// The video and scene joins wrap the nearest-neighbour query, so only the k hits are joined. Hits
+	// are reported under the searched video sharing the content (see hitVideoJoin).
+	join, joinArgs := hitVideoJoin("h", hitVideoConds(videoIDs))
+	var hits []PassageHit
+	err := db.Table("(?) AS h", q.build(db.DB, k)).
+		Select(`h.passage_id, h.caption_id, h.caption_count, v.id AS video_id, h.start_time, h.end_time, h.text, h.distance,
+			v.filename AS video_filename, v.title AS video_title,
+			s.id AS scene_id, s.scene_index, s.start_time AS scene_start, s.end_time AS scene_end`).
+		Joins(join, joinArgs...).
+		Joins(captionSceneJoin("h"), SceneLevelOrDefault(level)).
+		Order("h.distance ASC").
+		Scan(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Similarity = metric.Similarity(hits[i].Distance)
+		hits[i].Score = metric.Score(hits[i].Distance)
+	}
+	return hits, nil
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListCaptions pages through a video's captions by start time, limited to those overlapping
+// [start, end) when either bound is set, and returns how many captions match
+func (db *DB) ListCaptions(videoID uint, start, end *float64, limit, offset int) ([]models.Caption, int, error) {
+	scope := func() *gorm.DB {
+		q := db.Model(&models.Caption{}).Where("video_id = ?", videoID)
+		if start != nil {
+			q = q.Where("end_time > ?", *start)
+		}
+		if end != nil {
+			q = q.Where("start_time < ?", *end)
+		}
+		return q
+	}
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var captions []models.Caption
+	err := scope().Order("start_time ASC, id ASC").Limit(limit).Offset(offset).Find(&captions).Error
+	return captions, int(total), err
+}
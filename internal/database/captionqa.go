@@ -0,0 +1,54 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveCaptionQAReport inserts or replaces a video's caption QA report
+func (db *DB) SaveCaptionQAReport(r *models.CaptionQAReport) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "video_id"}},
+		UpdateAll: true,
+	}).Create(r).Error
+}
+
+// GetCaptionQAReport loads a video's caption QA report
+func (db *DB) GetCaptionQAReport(videoID uint) (*models.CaptionQAReport, error) {
+	var r models.CaptionQAReport
+	if err := db.First(&r, "video_id = ?", videoID).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CaptionQARow is a caption QA report with the video it describes; Issues is left empty in listings
+type CaptionQARow struct {
+	models.CaptionQAReport
+	VideoFilename string  `json:"video_filename"`
+	VideoTitle    *string `json:"video_title"`
+}
+
+// ListCaptionQAReports pages through the caption QA reports of videos that are not deleted, worst
+// score first, and returns how many there are
+func (db *DB) ListCaptionQAReports(limit, offset int) ([]CaptionQARow, int, error) {
+	scope := func() *gorm.DB {
+		return db.Table("caption_qa_reports r").
+			Joins("JOIN videos v ON v.id = r.video_id").
+			Where("v.status <> ?", models.VideoStatusDeleted)
+	}
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []CaptionQARow
+	err := scope().Select(`r.video_id, r.caption_count, r.duration, r.captioned_seconds, r.coverage, r.avg_confidence,
+			r.low_confidence_count, r.gap_count, r.longest_gap, r.overlap_count, r.mojibake_count, r.fast_count,
+			r.zero_duration_count, r.score, r.computed_at, v.filename AS video_filename, v.title AS video_title`).
+		Order("r.score ASC, r.video_id ASC").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error
+	return rows, int(total), err
+}
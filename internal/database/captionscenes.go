@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Caption-to-scene strategies, deciding which scenes a caption spanning several belongs to
+const (
+	// CaptionSceneOverlap gives the whole caption to every scene it overlaps; its scene is the one
+	// containing its midpoint
+	CaptionSceneOverlap = "overlap"
+	// CaptionSceneMajority gives the whole caption to the scene it overlaps most
+	CaptionSceneMajority = "majority"
+	// CaptionSceneSplit splits the caption's words across the scenes it overlaps, in proportion to
+	// the overlap; its scene is the one it overlaps most
+	CaptionSceneSplit = "split"
+)
+
+// CaptionSceneStrategy is the caption-to-scene strategy (CAPTION_SCENE_STRATEGY: overlap, the
+// default, majority or split)
+func CaptionSceneStrategy() string {
+	switch s := strings.ToLower(strings.TrimSpace(os.Getenv("CAPTION_SCENE_STRATEGY"))); s {
+	case CaptionSceneMajority, CaptionSceneSplit:
+		return s
+	}
+	return CaptionSceneOverlap
+}
+
+// captionSceneJoin is the LATERAL join attributing each row of alias (a caption-timed row with
+// video_id, start_time and end_time) to its scene s of the level bound as the first argument,
+// following CaptionSceneStrategy
+func captionSceneJoin(alias string) string {
+	if CaptionSceneStrategy() == CaptionSceneOverlap {
+		return fmt.Sprintf(`LEFT JOIN LATERAL (
+			SELECT id, scene_index, start_time, end_time FROM scenes
+			WHERE video_id = %[1]s.video_id AND level = ? AND start_time <= (%[1]s.start_time + %[1]s.end_time) / 2
+			ORDER BY start_time DESC LIMIT 1
+		) s ON true`, alias)
+	}
+	return fmt.Sprintf(`LEFT JOIN LATERAL (
+			SELECT id, scene_index, start_time, end_time FROM scenes
+			WHERE video_id = %[1]s.video_id AND level = ? AND start_time <= %[1]s.end_time AND end_time > %[1]s.start_time
+			ORDER BY LEAST(end_time, %[1]s.end_time) - GREATEST(start_time, %[1]s.start_time) DESC, start_time LIMIT 1
+		) s ON true`, alias)
+}
+
+// captionLinkBatch is how many captions one UPDATE of LinkCaptionScenes links
+const captionLinkBatch = 1000
+
+// LinkCaptionScenes sets the scene_id of a video's captions (a nil scene unlinks the caption) and
+// recomputes the caption_count/has_captions of the video's shots and beats, in one transaction
+func (db *DB) LinkCaptionScenes(videoID uint, links map[uint]*uint) error {
+	defer db.scenes.invalidateVideo(videoID)
+	ids := make([]uint, 0, len(links))
+	for id := range links {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += captionLinkBatch {
+			end := start + captionLinkBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			rows := make([]string, 0, end-start)
+			for _, id := range ids[start:end] {
+				scene := "NULL"
+				if s := links[id]; s != nil {
+					scene = strconv.FormatUint(uint64(*s), 10)
+				}
+				rows = append(rows, fmt.Sprintf("(%d, %s)", id, scene))
+			}
+			err := tx.Exec(`UPDATE captions c SET scene_id = m.scene_id::integer
+				FROM (VALUES `+strings.Join(rows, ", ")+`) AS m(id, scene_id)
+				WHERE c.id = m.id AND c.video_id = ? AND c.scene_id IS DISTINCT FROM m.scene_id::integer`, videoID).Error
+			if err != nil {
+				return fmt.Errorf("failed to link captions: %v", err)
+			}
+		}
+		if _, err := updateSceneCaptionCounts(tx, videoID); err != nil {
+			return fmt.Errorf("failed to update scene caption counts: %v", err)
+		}
+		return nil
+	})
+}
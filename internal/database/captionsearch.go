@@ -0,0 +1,51 @@
+package database
+
+// CaptionHit is a caption matching a keyword search, with its video and the scene containing it
+type CaptionHit struct {
+	CaptionID     uint     `json:"caption_id"`
+	VideoID       uint     `json:"video_id"`
+	StartTime     float64  `json:"start_time"`
+	EndTime       float64  `json:"end_time"`
+	Text          string   `json:"text"`
+	Language      string   `json:"language"`
+	Headline      string   `json:"headline"`
+	Rank          float64  `json:"rank"`
+	VideoFilename string   `json:"video_filename"`
+	VideoTitle    *string  `json:"video_title"`
+	SceneID       *uint    `json:"scene_id"`
+	SceneIndex    *int     `json:"scene_index"`
+	SceneStart    *float64 `json:"scene_start_time"`
+	SceneEnd      *float64 `json:"scene_end_time"`
+	// RerankScore is the cross-encoder's relevance (0–1) when the search was re-ranked
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+}
+
+// SearchCaptions runs a Postgres full-text search over caption text. tsquery must be a to_tsquery
+// expression (see synonyms.Dictionary.TSQuery); the expression matches the GIN index on captions.
+// Hits are ranked by ts_rank_cd and carry the caption's scene of level (see CaptionSceneStrategy); a
+// hit on shared content is reported under the searched video sharing it (see hitVideoJoin).
+func (db *DB) SearchCaptions(tsquery string, videoIDs []uint, level string, limit int) ([]CaptionHit, error) {
+	join, args := hitVideoJoin("c", hitVideoConds(videoIDs))
+	where := "to_tsvector('english', c.text) @@ q.query"
+	args = append(args, SceneLevelOrDefault(level), tsquery)
+	if len(videoIDs) > 0 {
+		where += " AND c.video_id IN (" + contentVideoIDs + ")"
+		args = append(args, videoIDs)
+	}
+	args = append(args, limit)
+
+	var hits []CaptionHit
+	err := db.Raw(`SELECT c.id AS caption_id, v.id AS video_id, c.start_time, c.end_time, c.text, c.language,
+			ts_headline('english', c.text, q.query, 'StartSel=<b>, StopSel=</b>, MaxFragments=2') AS headline,
+			ts_rank_cd(to_tsvector('english', c.text), q.query) AS rank,
+			v.filename AS video_filename, v.title AS video_title,
+			s.id AS scene_id, s.scene_index, s.start_time AS scene_start, s.end_time AS scene_end
+		FROM captions c
+		`+join+`
+		`+captionSceneJoin("c")+`
+		CROSS JOIN to_tsquery('english', ?) AS q(query)
+		WHERE `+where+`
+		ORDER BY rank DESC, c.video_id, c.start_time
+		LIMIT ?`, args...).Scan(&hits).Error
+	return hits, err
+}
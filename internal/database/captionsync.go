@@ -0,0 +1,21 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UpdateCaptionTimes atomically stores new start and end times for captions
+func (db *DB) UpdateCaptionTimes(captions []models.Caption) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range captions {
+			err := tx.Model(&models.Caption{}).Where("id = ?", c.ID).
+				Updates(map[string]interface{}{"start_time": c.StartTime, "end_time": c.EndTime}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceCaptionTranslations atomically replaces a video's caption track in language
+func (db *DB) ReplaceCaptionTranslations(videoID uint, language string, rows []models.CaptionTranslation) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ? AND language = ?", videoID, language).Delete(&models.CaptionTranslation{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// ListCaptionTranslations returns a video's caption track in language, in time order
+func (db *DB) ListCaptionTranslations(videoID uint, language string) ([]models.CaptionTranslation, error) {
+	var rows []models.CaptionTranslation
+	err := db.Where("video_id = ? AND language = ?", videoID, language).Order("start_time, id").Find(&rows).Error
+	return rows, err
+}
+
+// TranslationTrack is a translated caption track of a video
+type TranslationTrack struct {
+	Language string `json:"language"`
+	Captions int    `json:"captions"`
+	Model    string `json:"model"`
+}
+
+// CaptionTranslationTracks lists the translated caption tracks of a video
+func (db *DB) CaptionTranslationTracks(videoID uint) ([]TranslationTrack, error) {
+	var tracks []TranslationTrack
+	err := db.Model(&models.CaptionTranslation{}).
+		Select("language, COUNT(*) AS captions, MAX(model) AS model").
+		Where("video_id = ?", videoID).
+		Group("language").Order("language").
+		Scan(&tracks).Error
+	return tracks, err
+}
+
+// SearchCaptionTranslations is SearchCaptions over the caption tracks translated into language. The
+// 'simple' configuration (no stemming or stop words) is used since tracks may be in any language;
+// CaptionID of each hit is the source caption.
+func (db *DB) SearchCaptionTranslations(tsquery, language string, videoIDs []uint, level string, limit int) ([]CaptionHit, error) {
+	join, args := hitVideoJoin("t", hitVideoConds(videoIDs))
+	where := "t.language = ? AND to_tsvector('simple', t.text) @@ q.query"
+	args = append(args, SceneLevelOrDefault(level), tsquery, language)
+	if len(videoIDs) > 0 {
+		where += " AND t.video_id IN (" + contentVideoIDs + ")"
+		args = append(args, videoIDs)
+	}
+	args = append(args, limit)
+
+	var hits []CaptionHit
+	err := db.Raw(`SELECT t.caption_id, v.id AS video_id, t.start_time, t.end_time, t.text, t.language,
+			ts_headline('simple', t.text, q.query, 'StartSel=<b>, StopSel=</b>, MaxFragments=2') AS headline,
+			ts_rank_cd(to_tsvector('simple', t.text), q.query) AS rank,
+			v.filename AS video_filename, v.title AS video_title,
+			s.id AS scene_id, s.scene_index, s.start_time AS scene_start, s.end_time AS scene_end
+		FROM caption_translations t
+		`+join+`
+		`+captionSceneJoin("t")+`
+		CROSS JOIN to_tsquery('simple', ?) AS q(query)
+		WHERE `+where+`
+		ORDER BY rank DESC, t.video_id, t.start_time
+		LIMIT ?`, args...).Scan(&hits).Error
+	return hits, err
+}
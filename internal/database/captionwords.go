@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceCaptionWords atomically replaces a video's word timings
+func (db *DB) ReplaceCaptionWords(videoID uint, words []models.CaptionWord) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.CaptionWord{}).Error; err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&words, 1000).Error
+	})
+}
+
+// PhraseMatch is one utterance of a phrase, timed from its first to its last word
+type PhraseMatch struct {
+	VideoID       uint    `json:"video_id"`
+	VideoFilename string  `json:"video_filename"`
+	VideoTitle    *string `json:"video_title"`
+	VideoDuration float64 `json:"-"`
+	CaptionID     uint    `json:"caption_id"`
+	CaptionText   string  `json:"caption_text"`
+	Phrase        string  `json:"phrase"`
+	StartTime     float64 `json:"start_time"`
+	EndTime       float64 `json:"end_time"`
+	// Aligned is false when any word of the match was timed by interpolation
+	Aligned bool `json:"aligned"`
+}
+
+// SearchPhrase finds every utterance of a phrase, given as normalized words (see
+// processor.PhraseWords), as consecutive words of a video, possibly across captions. Matches are
+// ordered by video and time; a match in shared content is reported under the searched video sharing
+// it (see hitVideoJoin).
+func (db *DB) SearchPhrase(words []string, videoIDs []uint, limit int) ([]PhraseMatch, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	last := fmt.Sprintf("w%d", len(words)-1)
+	var joins strings.Builder
+	cols := []string{"w0.word"}
+	aligned := []string{"w0.aligned"}
+	var args []interface{}
+	for i := 1; i < len(words); i++ {
+		fmt.Fprintf(&joins, " JOIN caption_words w%d ON w%d.video_id = w0.video_id AND w%d.position = w0.position + %d AND w%d.norm = ?", i, i, i, i, i)
+		cols = append(cols, fmt.Sprintf("w%d.word", i))
+		aligned = append(aligned, fmt.Sprintf("w%d.aligned", i))
+		args = append(args, words[i])
+	}
+	join, joinArgs := hitVideoJoin("w0", hitVideoConds(videoIDs))
+	args = append(args, joinArgs...)
+	where := "w0.norm = ?"
+	args = append(args, words[0])
+	if len(videoIDs) > 0 {
+		where += " AND w0.video_id IN (" + contentVideoIDs + ")"
+		args = append(args, videoIDs)
+	}
+	args = append(args, limit)
+
+	var matches []PhraseMatch
+	err := db.Raw(`SELECT v.id AS video_id, v.filename AS video_filename, v.title AS video_title, v.duration AS video_duration,
+			w0.caption_id, c.text AS caption_text, concat_ws(' ', `+strings.Join(cols, ", ")+`) AS phrase,
+			w0.start_time, `+last+`.end_time, (`+strings.Join(aligned, " AND ")+`) AS aligned
+		FROM caption_words w0`+joins.String()+`
+		JOIN captions c ON c.id = w0.caption_id
+		`+join+`
+		WHERE `+where+`
+		ORDER BY w0.video_id, w0.start_time
+		LIMIT ?`, args...).Scan(&matches).Error
+	return matches, err
+}
+
+// CountCaptionWordVideos returns how many of the given videos (all videos when empty) have word timings
+func (db *DB) CountCaptionWordVideos(videoIDs []uint) (int64, error) {
+	q := db.Model(&models.CaptionWord{})
+	if len(videoIDs) > 0 {
+		q = q.Where("video_id IN ("+contentVideoIDs+")", videoIDs)
+	}
+	var n int64
+	err := q.Distinct("video_id").Count(&n).Error
+	return n, err
+}
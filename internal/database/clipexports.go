@@ -0,0 +1,22 @@
+package database
+
+import "goodclips-server/internal/models"
+
+// CreateClipExport inserts a new clip export request
+func (db *DB) CreateClipExport(e *models.ClipExport) error {
+	return db.Create(e).Error
+}
+
+// GetClipExport loads a clip export by ID
+func (db *DB) GetClipExport(id uint) (*models.ClipExport, error) {
+	var e models.ClipExport
+	if err := db.First(&e, id).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// UpdateClipExport saves a clip export's status and output
+func (db *DB) UpdateClipExport(e *models.ClipExport) error {
+	return db.Save(e).Error
+}
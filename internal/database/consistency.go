@@ -0,0 +1,115 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"goodclips-server/internal/models"
+)
+
+// FindConsistencyAnomalies scans for videos whose pipeline outputs are missing or inconsistent:
+// scenes without any embedding, captions not linked to a scene, scene_count disagreeing with the
+// scene rows, and videos stuck in processing since before zombieBefore.
+func (db *DB) FindConsistencyAnomalies(zombieBefore time.Time) ([]models.ConsistencyAnomaly, error) {
+	var anomalies []models.ConsistencyAnomaly
+
+	var missing []struct {
+		VideoID uint
+		Scenes  int
+	}
+	if err := db.Raw(`
+		SELECT s.video_id, COUNT(*) AS scenes
+		FROM scenes s
+		GROUP BY s.video_id
+		HAVING COUNT(s.visual_embedding) + COUNT(s.text_embedding) + COUNT(s.audio_embedding) + COUNT(s.visual_clip_embedding) = 0
+		ORDER BY s.video_id`).Scan(&missing).Error; err != nil {
+		return nil, fmt.Errorf("missing embeddings check: %w", err)
+	}
+	for _, r := range missing {
+		anomalies = append(anomalies, models.ConsistencyAnomaly{
+			VideoID:  r.VideoID,
+			Category: models.AnomalyMissingEmbeddings,
+			Details:  fmt.Sprintf("%d scenes, none with embeddings", r.Scenes),
+		})
+	}
+
+	var unlinked []struct {
+		VideoID  uint
+		Captions int
+	}
+	if err := db.Raw(`
+		SELECT c.video_id, COUNT(*) AS captions
+		FROM captions c
+		WHERE c.scene_id IS NULL
+		  AND EXISTS (SELECT 1 FROM scenes s WHERE s.video_id = c.video_id)
+		GROUP BY c.video_id
+		ORDER BY c.video_id`).Scan(&unlinked).Error; err != nil {
+		return nil, fmt.Errorf("unlinked captions check: %w", err)
+	}
+	for _, r := range unlinked {
+		anomalies = append(anomalies, models.ConsistencyAnomaly{
+			VideoID:  r.VideoID,
+			Category: models.AnomalyUnlinkedCaptions,
+			Details:  fmt.Sprintf("%d captions without a scene", r.Captions),
+		})
+	}
+
+	var mismatched []struct {
+		VideoID    uint
+		SceneCount int
+		Actual     int
+	}
+	if err := db.Raw(`
+		SELECT v.id AS video_id, v.scene_count, COUNT(s.id) AS actual
+		FROM videos v
+		LEFT JOIN scenes s ON s.video_id = v.id AND s.level = 'shot'
+		GROUP BY v.id
+		HAVING v.scene_count <> COUNT(s.id)
+		ORDER BY v.id`).Scan(&mismatched).Error; err != nil {
+		return nil, fmt.Errorf("scene count check: %w", err)
+	}
+	for _, r := range mismatched {
+		anomalies = append(anomalies, models.ConsistencyAnomaly{
+			VideoID:  r.VideoID,
+			Category: models.AnomalySceneCountMismatch,
+			Details:  fmt.Sprintf("scene_count=%d but %d scene rows", r.SceneCount, r.Actual),
+		})
+	}
+
+	var zombies []models.Video
+	if err := db.Select("id", "updated_at").
+		Where("status = ? AND updated_at < ?", models.VideoStatusProcessing, zombieBefore).
+		Order("id").Find(&zombies).Error; err != nil {
+		return nil, fmt.Errorf("zombie processing check: %w", err)
+	}
+	for _, v := range zombies {
+		anomalies = append(anomalies, models.ConsistencyAnomaly{
+			VideoID:  v.ID,
+			Category: models.AnomalyZombieProcessing,
+			Details:  fmt.Sprintf("processing since %s", v.UpdatedAt.UTC().Format(time.RFC3339)),
+		})
+	}
+
+	return anomalies, nil
+}
+
+// RecountScenes sets a video's scene_count from its shot rows and returns the new count
+func (db *DB) RecountScenes(videoID uint) (int, error) {
+	var n int64
+	if err := db.Model(&models.Scene{}).Where("video_id = ? AND level = ?", videoID, models.SceneLevelShot).Count(&n).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&models.Video{}).Where("id = ?", videoID).Update("scene_count", n).Error; err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// CountScenesWithEmbeddings returns how many of a video's scenes have at least one embedding
+func (db *DB) CountScenesWithEmbeddings(videoID uint) (int, error) {
+	var n int64
+	err := db.Model(&models.Scene{}).
+		Where("video_id = ? AND (visual_embedding IS NOT NULL OR text_embedding IS NOT NULL OR audio_embedding IS NOT NULL OR visual_clip_embedding IS NOT NULL)", videoID).
+		Count(&n).Error
+	return int(n), err
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceContentFlags atomically replaces a video's content flags
+func (db *DB) ReplaceContentFlags(videoID uint, flags []models.ContentFlag) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.ContentFlag{}).Error; err != nil {
+			return err
+		}
+		if len(flags) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&flags, 500).Error
+	})
+}
+
+// GetContentFlagsInRange returns a video's flags overlapping [start, end) in the given categories
+// (all when empty), ordered by time
+func (db *DB) GetContentFlagsInRange(videoID uint, start, end float64, categories []string) ([]models.ContentFlag, error) {
+	q := db.Where("video_id = ? AND start_time < ? AND end_time > ?", videoID, end, start)
+	if len(categories) > 0 {
+		q = q.Where("category IN ?", categories)
+	}
+	var flags []models.ContentFlag
+	err := q.Order("start_time ASC").Find(&flags).Error
+	return flags, err
+}
+
+// FlaggedSceneIndexes returns the scene_index of every scene at a level overlapping a content flag,
+// with the flag categories found in it
+func (db *DB) FlaggedSceneIndexes(videoID uint, level string) (map[int][]string, error) {
+	var rows []struct {
+		SceneIndex int
+		Category   string
+	}
+	err := db.Table("scenes s").
+		Select("DISTINCT s.scene_index, cf.category").
+		Joins("JOIN content_flags cf ON cf.video_id = s.video_id AND cf.start_time < s.end_time AND cf.end_time > s.start_time").
+		Where("s.video_id = ? AND s.level = ?", videoID, SceneLevelOrDefault(level)).
+		Order("s.scene_index, cf.category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int][]string)
+	for _, r := range rows {
+		out[r.SceneIndex] = append(out[r.SceneIndex], r.Category)
+	}
+	return out, nil
+}
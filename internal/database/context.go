@@ -0,0 +1,71 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+)
+
+// sceneSummaryColumns are the scene columns needed to describe a scene, without its embeddings
+const sceneSummaryColumns = "id, uuid, video_id, level, scene_index, beat_index, keyframe_time, keyframe_path, start_time, end_time, duration, has_captions, caption_count, created_at"
+
+// GetSceneWindow returns a video's scenes at one level with scene_index in [from, to], without embeddings
+func (db *DB) GetSceneWindow(videoID uint, level string, from, to int) ([]models.Scene, error) {
+	var scenes []models.Scene
+	err := db.Select(sceneSummaryColumns).
+		Where("video_id = ? AND level = ? AND scene_index BETWEEN ? AND ?", videoID, SceneLevelOrDefault(level), from, to).
+		Order("scene_index ASC").
+		Find(&scenes).Error
+	return scenes, err
+}
+
+// GetCaptionsInRange returns a video's captions overlapping [start, end), ordered by start time
+func (db *DB) GetCaptionsInRange(videoID uint, start, end float64) ([]models.Caption, error) {
+	var captions []models.Caption
+	err := db.Where("video_id = ? AND start_time < ? AND end_time > ?", videoID, end, start).
+		Order("start_time ASC").
+		Find(&captions).Error
+	return captions, err
+}
+
+// CaptionSnippet is a caption overlapping a search hit's scene. With a text query, Headline is the
+// caption with the query's words wrapped in <b></b> and Matched tells whether any of them occur.
+type CaptionSnippet struct {
+	SceneID   uint    `json:"-"`
+	CaptionID uint    `json:"caption_id"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Text      string  `json:"text"`
+	Headline  string  `json:"headline,omitempty"`
+	Matched   bool    `json:"matched,omitempty"`
+}
+
+// GetSceneCaptionSnippets returns the captions overlapping each scene in one query, by scene ID and
+// in time order. When query is not empty the captions are highlighted against it (English
+// full-text search, like caption keyword search).
+func (db *DB) GetSceneCaptionSnippets(sceneIDs []uint, query string) (map[uint][]CaptionSnippet, error) {
+	out := make(map[uint][]CaptionSnippet, len(sceneIDs))
+	if len(sceneIDs) == 0 {
+		return out, nil
+	}
+	highlight := "'' AS headline, false AS matched"
+	var args []interface{}
+	if query != "" {
+		highlight = `ts_headline('english', c.text, plainto_tsquery('english', ?), 'StartSel=<b>, StopSel=</b>, HighlightAll=true') AS headline,
+			to_tsvector('english', c.text) @@ plainto_tsquery('english', ?) AS matched`
+		args = append(args, query, query)
+	}
+	args = append(args, sceneIDs)
+
+	var rows []CaptionSnippet
+	err := db.Raw(`SELECT s.id AS scene_id, c.id AS caption_id, c.start_time, c.end_time, c.text, `+highlight+`
+		FROM scenes s
+		JOIN captions c ON c.video_id = s.video_id AND c.start_time < s.end_time AND c.end_time > s.start_time
+		WHERE s.id IN ?
+		ORDER BY s.id, c.start_time`, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.SceneID] = append(out[r.SceneID], r)
+	}
+	return out, nil
+}
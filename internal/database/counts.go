@@ -0,0 +1,108 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CountDrift is how many rows ReconcileCounts corrected, per denormalized count
+type CountDrift struct {
+	VideoSceneCounts   int64 `json:"video_scene_counts"`
+	VideoCaptionCounts int64 `json:"video_caption_counts"`
+	SceneCaptionCounts int64 `json:"scene_caption_counts"`
+}
+
+// Total is the number of corrected rows
+func (d CountDrift) Total() int64 {
+	return d.VideoSceneCounts + d.VideoCaptionCounts + d.SceneCaptionCounts
+}
+
+// ReconcileCounts recomputes the denormalized counts of one video (all videos when videoID is 0)
+// and corrects the rows that drifted: videos.scene_count (shots), videos.caption_count, and each
+// scene's caption_count/has_captions (captions linked to a shot; a beat sums its shots).
+func (db *DB) ReconcileCounts(videoID uint) (CountDrift, error) {
+	var drift CountDrift
+	scope, args := "", []interface{}{}
+	if videoID != 0 {
+		scope, args = " WHERE v2.id = ?", []interface{}{videoID}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`UPDATE videos v SET scene_count = c.n
+			FROM (SELECT v2.id, COUNT(s.id) AS n FROM videos v2
+				LEFT JOIN scenes s ON s.video_id = v2.id AND s.level = 'shot'`+scope+`
+				GROUP BY v2.id) c
+			WHERE v.id = c.id AND v.scene_count IS DISTINCT FROM c.n`, args...)
+		if res.Error != nil {
+			return res.Error
+		}
+		drift.VideoSceneCounts = res.RowsAffected
+
+		res = tx.Exec(`UPDATE videos v SET caption_count = c.n
+			FROM (SELECT v2.id, COUNT(cap.id) AS n FROM videos v2
+				LEFT JOIN captions cap ON cap.video_id = v2.id`+scope+`
+				GROUP BY v2.id) c
+			WHERE v.id = c.id AND v.caption_count IS DISTINCT FROM c.n`, args...)
+		if res.Error != nil {
+			return res.Error
+		}
+		drift.VideoCaptionCounts = res.RowsAffected
+
+		n, err := updateSceneCaptionCounts(tx, videoID)
+		drift.SceneCaptionCounts = n
+		return err
+	})
+	if err == nil && drift.SceneCaptionCounts > 0 {
+		if videoID != 0 {
+			db.scenes.invalidateVideo(videoID)
+		} else {
+			db.scenes.purge()
+		}
+	}
+	return drift, err
+}
+
+// updateSceneCaptionCounts sets caption_count/has_captions of one video's scenes (all videos when
+// videoID is 0) from the captions linked to each shot; a beat sums its shots. It returns the
+// number of scenes that changed.
+func updateSceneCaptionCounts(tx *gorm.DB, videoID uint) (int64, error) {
+	scope, args := "", []interface{}{}
+	if videoID != 0 {
+		scope, args = " AND s2.video_id = ?", []interface{}{videoID}
+	}
+	res := tx.Exec(`UPDATE scenes s SET caption_count = c.n, has_captions = c.n > 0
+		FROM (SELECT s2.id, COUNT(cap.id) AS n FROM scenes s2
+			LEFT JOIN captions cap ON cap.scene_id = s2.id
+			WHERE s2.level = 'shot'`+scope+`
+			GROUP BY s2.id) c
+		WHERE s.id = c.id AND (s.caption_count IS DISTINCT FROM c.n OR s.has_captions IS DISTINCT FROM c.n > 0)`, args...)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	changed := res.RowsAffected
+
+	// Beats after shots, so they sum the updated shot counts
+	res = tx.Exec(`UPDATE scenes s SET caption_count = c.n, has_captions = c.n > 0
+		FROM (SELECT s2.id, COALESCE(SUM(sh.caption_count), 0) AS n FROM scenes s2
+			LEFT JOIN scenes sh ON sh.video_id = s2.video_id AND sh.level = 'shot' AND sh.beat_index = s2.scene_index
+			WHERE s2.level = 'beat'`+scope+`
+			GROUP BY s2.id) c
+		WHERE s.id = c.id AND (s.caption_count IS DISTINCT FROM c.n OR s.has_captions IS DISTINCT FROM c.n > 0)`, args...)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return changed + res.RowsAffected, nil
+}
+
+// CountVideoScenesAndCaptions counts a video's shots and captions, for responses that prefer live
+// counts over the denormalized ones
+func (db *DB) CountVideoScenesAndCaptions(videoID uint) (scenes, captions int, err error) {
+	var n, m int64
+	if err = db.Model(&models.Scene{}).Where("video_id = ? AND level = ?", videoID, models.SceneLevelShot).Count(&n).Error; err != nil {
+		return 0, 0, err
+	}
+	if err = db.Model(&models.Caption{}).Where("video_id = ?", videoID).Count(&m).Error; err != nil {
+		return 0, 0, err
+	}
+	return int(n), int(m), nil
+}
@@ -12,196 +12,52 @@ import (
     "gorm.io/gorm"
     "gorm.io/gorm/clause"
     "gorm.io/gorm/logger"
-    "github.com/pgvector/pgvector-go"
 )
 
 // DB represents the database connection
 type DB struct {
     *gorm.DB
+    // scenes caches hot scene lookups (SCENE_CACHE_SIZE, SCENE_CACHE_TTL_SECS)
+    scenes *sceneCache
 }
 
-// SearchScenesByClipVector finds top-K nearest scenes by cosine distance to a provided CLIP text/image embedding vector.
-// Optionally filter by a set of video IDs.
-func (db *DB) SearchScenesByClipVector(vec []float32, k int, filterVideoIDs []uint) ([]models.Scene, []float64, error) {
-    v := pgvector.NewVector(vec)
-
-    type row struct {
-        ID           uint
-        UUID         string
-        VideoID      uint
-        SceneIndex   int
-        StartTime    float64
-        EndTime      float64
-        Duration     float64
-        HasCaptions  bool
-        CaptionCount int
-        CreatedAt    time.Time
-        Distance     float64 `gorm:"column:distance"`
-    }
-
-    q := db.Table("scenes").
-        Select("id, uuid, video_id, scene_index, start_time, end_time, duration, has_captions, caption_count, created_at, visual_clip_embedding <=> ? as distance", v).
-        Where("visual_clip_embedding IS NOT NULL")
-    if len(filterVideoIDs) > 0 {
-        q = q.Where("video_id IN ?", filterVideoIDs)
-    }
-
-    var rows []row
-    if err := q.Order("distance ASC").Limit(k).Scan(&rows).Error; err != nil {
-        return nil, nil, err
-    }
-
-    scenes := make([]models.Scene, 0, len(rows))
-    dists := make([]float64, 0, len(rows))
-    for _, r := range rows {
-        scenes = append(scenes, models.Scene{
-            ID:           r.ID,
-            UUID:         r.UUID,
-            VideoID:      r.VideoID,
-            SceneIndex:   r.SceneIndex,
-            StartTime:    r.StartTime,
-            EndTime:      r.EndTime,
-            Duration:     r.Duration,
-            HasCaptions:  r.HasCaptions,
-            CaptionCount: r.CaptionCount,
-            CreatedAt:    r.CreatedAt,
-        })
-        dists = append(dists, r.Distance)
-    }
-    return scenes, dists, nil
-}
-
-// SearchScenesByAudioVector finds top-K nearest scenes by cosine distance to a provided CLAP audio/text embedding vector.
-// Optionally filter by a set of video IDs.
-func (db *DB) SearchScenesByAudioVector(vec []float32, k int, filterVideoIDs []uint) ([]models.Scene, []float64, error) {
-    v := pgvector.NewVector(vec)
-
-    type row struct {
-        ID           uint
-        UUID         string
-        VideoID      uint
-        SceneIndex   int
-        StartTime    float64
-        EndTime      float64
-        Duration     float64
-        HasCaptions  bool
-        CaptionCount int
-        CreatedAt    time.Time
-        Distance     float64 `gorm:"column:distance"`
-    }
-
-    q := db.Table("scenes").
-        Select("id, uuid, video_id, scene_index, start_time, end_time, duration, has_captions, caption_count, created_at, audio_embedding <=> ? as distance", v).
-        Where("audio_embedding IS NOT NULL")
-    if len(filterVideoIDs) > 0 {
-        q = q.Where("video_id IN ?", filterVideoIDs)
-    }
-
-    var rows []row
-    if err := q.Order("distance ASC").Limit(k).Scan(&rows).Error; err != nil {
-        return nil, nil, err
-    }
-
-    scenes := make([]models.Scene, 0, len(rows))
-    dists := make([]float64, 0, len(rows))
-    for _, r := range rows {
-        scenes = append(scenes, models.Scene{
-            ID:           r.ID,
-            UUID:         r.UUID,
-            VideoID:      r.VideoID,
-            SceneIndex:   r.SceneIndex,
-            StartTime:    r.StartTime,
-            EndTime:      r.EndTime,
-            Duration:     r.Duration,
-            HasCaptions:  r.HasCaptions,
-            CaptionCount: r.CaptionCount,
-            CreatedAt:    r.CreatedAt,
-        })
-        dists = append(dists, r.Distance)
-    }
-    return scenes, dists, nil
-}
-
-// GetSceneByVideoAndIndex fetches a single scene by (video_id, scene_index)
-func (db *DB) GetSceneByVideoAndIndex(videoID uint, sceneIndex int) (*models.Scene, error) {
+// SceneLevelOrDefault maps an empty scene level to shots
+func SceneLevelOrDefault(level string) string {
+    if level == "" {
+        return models.SceneLevelShot
+    }
+    return level
+}
+
+// GetSceneByVideoAndIndex fetches a single scene by (video_id, level, scene_index)
+func (db *DB) GetSceneByVideoAndIndex(videoID uint, level string, sceneIndex int) (*models.Scene, error) {
+    key := sceneIndexKey(videoID, SceneLevelOrDefault(level), sceneIndex)
+    if s, ok := db.scenes.get(key); ok {
+        return s, nil
+    }
     var s models.Scene
-    if err := db.Where("video_id = ? AND scene_index = ?", videoID, sceneIndex).First(&s).Error; err != nil {
+    if err := db.Where("video_id = ? AND level = ? AND scene_index = ?", videoID, SceneLevelOrDefault(level), sceneIndex).First(&s).Error; err != nil {
         return nil, err
     }
+    db.scenes.put(key, &s)
     return &s, nil
 }
 
-// SearchSimilarScenesByAnchor finds top-K nearest scenes by cosine distance to the anchor scene's visual embedding.
-// It excludes the anchor itself and can optionally filter by a list of video IDs.
-func (db *DB) SearchSimilarScenesByAnchor(anchorVideoID uint, anchorSceneIndex int, k int, filterVideoIDs []uint) ([]models.Scene, []float64, error) {
-    // Load anchor
-    anchor, err := db.GetSceneByVideoAndIndex(anchorVideoID, anchorSceneIndex)
-    if err != nil {
-        return nil, nil, err
-    }
-    if anchor.VisualEmbedding == nil {
-        return nil, nil, errors.New("anchor scene has no visual_embedding")
-    }
-
-    // Build query using GORM
-    type row struct {
-        ID           uint
-        UUID         string
-        VideoID      uint
-        SceneIndex   int
-        StartTime    float64
-        EndTime      float64
-        Duration     float64
-        HasCaptions  bool
-        CaptionCount int
-        CreatedAt    time.Time
-        Distance     float64 `gorm:"column:distance"`
-    }
-
-    q := db.Table("scenes").
-        Select("id, uuid, video_id, scene_index, start_time, end_time, duration, has_captions, caption_count, created_at, visual_embedding <=> ? as distance", *anchor.VisualEmbedding).
-        Where("visual_embedding IS NOT NULL").
-        Where("NOT (video_id = ? AND scene_index = ?)", anchorVideoID, anchorSceneIndex)
-    if len(filterVideoIDs) > 0 {
-        q = q.Where("video_id IN ?", filterVideoIDs)
-    }
-    var rows []row
-    if err := q.Order("distance ASC").Limit(k).Scan(&rows).Error; err != nil {
-        return nil, nil, err
-    }
-
-    scenes := make([]models.Scene, 0, len(rows))
-    dists := make([]float64, 0, len(rows))
-    for _, r := range rows {
-        scenes = append(scenes, models.Scene{
-            ID:           r.ID,
-            UUID:         r.UUID,
-            VideoID:      r.VideoID,
-            SceneIndex:   r.SceneIndex,
-            StartTime:    r.StartTime,
-            EndTime:      r.EndTime,
-            Duration:     r.Duration,
-            HasCaptions:  r.HasCaptions,
-            CaptionCount: r.CaptionCount,
-            CreatedAt:    r.CreatedAt,
-        })
-        dists = append(dists, r.Distance)
-    }
-    return scenes, dists, nil
-}
-
 // Scene service methods
 
 // CreateScene creates a new scene record
 func (db *DB) CreateScene(scene *models.Scene) error {
-    // upsert by (video_id, scene_index) to keep scene insertion idempotent.
+    scene.Level = SceneLevelOrDefault(scene.Level)
+    defer db.scenes.invalidateVideo(scene.VideoID)
+    // upsert by (video_id, level, scene_index) to keep scene insertion idempotent.
     // Only update timing/count flags so embeddings/captions remain intact if present.
     return db.DB.Clauses(
         clause.OnConflict{
-            Columns:   []clause.Column{{Name: "video_id"}, {Name: "scene_index"}},
+            Columns:   []clause.Column{{Name: "video_id"}, {Name: "level"}, {Name: "scene_index"}},
             DoUpdates: clause.Assignments(map[string]interface{}{
                 "start_time":    scene.StartTime,
                 "end_time":      scene.EndTime,
+                "beat_index":    scene.BeatIndex,
                 // duration is derived; keep it in sync in case it's stored
                 "has_captions":  scene.HasCaptions,
                 "caption_count": scene.CaptionCount,
@@ -210,10 +66,15 @@ func (db *DB) CreateScene(scene *models.Scene) error {
     ).Create(scene).Error
 }
 
-// GetScenesByVideoID retrieves scenes for a video
+// GetScenesByVideoID retrieves a video's shots
 func (db *DB) GetScenesByVideoID(videoID uint) ([]models.Scene, error) {
+    return db.GetScenesByVideoIDAndLevel(videoID, models.SceneLevelShot)
+}
+
+// GetScenesByVideoIDAndLevel retrieves a video's scenes at one level (shot or beat)
+func (db *DB) GetScenesByVideoIDAndLevel(videoID uint, level string) ([]models.Scene, error) {
     var scenes []models.Scene
-    err := db.Where("video_id = ?", videoID).Order("scene_index ASC").Find(&scenes).Error
+    err := db.Where("video_id = ? AND level = ?", videoID, SceneLevelOrDefault(level)).Order("scene_index ASC").Find(&scenes).Error
     return scenes, err
 }
 
@@ -250,7 +111,9 @@ func (db *DB) UpdateProcessingJob(job *models.ProcessingJob) error {
 
 // Video service methods
 
-// GetVideoByID returns a video by its primary key ID
+// GetVideoByID returns a video by its primary key ID. It loads the video row only, never its Scenes
+// or Captions, so workers can call it per job on long videos; scenes and captions are fetched by
+// level or page (GetScenesByVideoIDAndLevel, ListCaptions) where needed.
 func (db *DB) GetVideoByID(id uint) (*models.Video, error) {
     var v models.Video
     if err := db.First(&v, id).Error; err != nil {
@@ -259,9 +122,110 @@ func (db *DB) GetVideoByID(id uint) (*models.Video, error) {
     return &v, nil
 }
 
-// UpdateVideo persists changes to a video
+// ErrVideoConflict is returned by UpdateVideo when the video changed since it was loaded
+var ErrVideoConflict = errors.New("video was modified concurrently")
+
+// videoUpdateAttempts bounds the reload-and-reapply rounds of UpdateVideoWithRetry
+const videoUpdateAttempts = 5
+
+// UpdateVideo saves every column of a video loaded at video.Version, failing with ErrVideoConflict
+// when another update got there first. On success video.Version is the new version.
 func (db *DB) UpdateVideo(video *models.Video) error {
-    return db.Save(video).Error
+    res := db.Model(video).Where("version = ?", video.Version).
+        Select("*").Omit("id", "uuid", "created_at", "version", clause.Associations).
+        Updates(video)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        var n int64
+        if err := db.Model(&models.Video{}).Where("id = ?", video.ID).Count(&n).Error; err == nil && n == 0 {
+            return gorm.ErrRecordNotFound
+        }
+        return ErrVideoConflict
+    }
+    // The bump_video_version trigger incremented it
+    video.Version++
+    return nil
+}
+
+// UpdateVideoWithRetry loads a video, applies mutate and saves it, reloading and reapplying on
+// ErrVideoConflict. mutate must only set the fields it owns; it may run several times. It returns
+// the saved video.
+func (db *DB) UpdateVideoWithRetry(id uint, mutate func(v *models.Video) error) (*models.Video, error) {
+    var err error
+    for i := 0; i < videoUpdateAttempts; i++ {
+        var v *models.Video
+        if v, err = db.GetVideoByID(id); err != nil {
+            return nil, err
+        }
+        if err = mutate(v); err != nil {
+            return nil, err
+        }
+        if err = db.UpdateVideo(v); !errors.Is(err, ErrVideoConflict) {
+            if err != nil {
+                return nil, err
+            }
+            return v, nil
+        }
+    }
+    return nil, err
+}
+
+// UpdateVideoColumns sets the given columns of a video in one targeted UPDATE, leaving the others
+// (and concurrent changes to them) alone
+func (db *DB) UpdateVideoColumns(id uint, columns map[string]interface{}) error {
+    res := db.Model(&models.Video{}).Where("id = ?", id).Updates(columns)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
+}
+
+// UpdateVideoStatus sets a video's status
+func (db *DB) UpdateVideoStatus(id uint, status models.VideoStatus) error {
+    return db.UpdateVideoColumns(id, map[string]interface{}{"status": status})
+}
+
+// SetSceneCount sets a video's scene_count after its shots were replaced
+func (db *DB) SetSceneCount(id uint, n int) error {
+    return db.UpdateVideoColumns(id, map[string]interface{}{"scene_count": n})
+}
+
+// IncrementSceneCount adds delta to a video's scene_count in the database, for shots appended
+// without reloading the video
+func (db *DB) IncrementSceneCount(id uint, delta int) error {
+    return db.UpdateVideoColumns(id, map[string]interface{}{"scene_count": gorm.Expr("scene_count + ?", delta)})
+}
+
+// SetCaptionCount sets a video's caption_count
+func (db *DB) SetCaptionCount(id uint, n int) error {
+    return db.UpdateVideoColumns(id, map[string]interface{}{"caption_count": n})
+}
+
+// SetEmbeddingModel records the model a video's scene embeddings were computed with
+func (db *DB) SetEmbeddingModel(id uint, model string) error {
+    return db.UpdateVideoColumns(id, map[string]interface{}{"embedding_model": model})
+}
+
+// SetVideoPipelineOutcome records the outcome of a video's processing pipeline: its status, the
+// error message (cleared when nil) and last_processed_at, which videos sharing its content take
+// over. Deleted videos are left alone.
+func (db *DB) SetVideoPipelineOutcome(id uint, status models.VideoStatus, errorCode, errorMessage *string) error {
+    err := db.Model(&models.Video{}).Where("id = ? AND status <> ?", id, models.VideoStatusDeleted).
+        Updates(map[string]interface{}{
+            "status":            status,
+            "error_code":        errorCode,
+            "error_message":     errorMessage,
+            "last_processed_at": time.Now(),
+        }).Error
+    if err != nil {
+        return err
+    }
+    return db.SyncSharedVideos(id)
 }
 
 // Connection & config helpers
@@ -297,12 +261,13 @@ func NewConnection(cfg Config) (*DB, error) {
         " dbname=" + cfg.DBName +
         " port=" + strconv.Itoa(cfg.Port) +
         " sslmode=" + cfg.SSLMode +
-        " TimeZone=UTC"
+        " TimeZone=UTC" +
+        vectorSearchParams()
     gdb, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
     if err != nil {
         return nil, err
     }
-    return &DB{gdb}, nil
+    return &DB{DB: gdb, scenes: newSceneCacheFromEnv()}, nil
 }
 
 // Close closes the underlying sql.DB
@@ -339,11 +304,11 @@ func (db *DB) GetStats() (models.DatabaseStats, error) {
         stats.CompletedVideos = int(n)
     }
     n = 0
-    if err := db.Model(&models.Scene{}).Count(&n).Error; err == nil {
+    if err := db.Model(&models.Scene{}).Where("level = ?", models.SceneLevelShot).Count(&n).Error; err == nil {
         stats.TotalScenes = int(n)
     }
     n = 0
-    if err := db.Model(&models.Scene{}).Where("visual_embedding IS NOT NULL").Count(&n).Error; err == nil {
+    if err := db.Model(&models.Scene{}).Where("level = ? AND visual_embedding IS NOT NULL", models.SceneLevelShot).Count(&n).Error; err == nil {
         stats.ScenesWithEmbeddings = int(n)
     }
     f = 0
@@ -354,6 +319,9 @@ func (db *DB) GetStats() (models.DatabaseStats, error) {
     if err := db.Model(&models.ProcessingJob{}).Where("status IN ?", []models.JobStatus{models.JobStatusPending, models.JobStatusRunning}).Count(&n).Error; err == nil {
         stats.ActiveJobs = int(n)
     }
+    if indexes, err := db.VectorIndexStatuses(); err == nil {
+        stats.VectorIndexes = indexes
+    }
     return stats, nil
 }
 
@@ -375,106 +343,81 @@ func (db *DB) CreateVideo(video *models.Video) error {
     return db.Create(video).Error
 }
 
-// DeleteVideo deletes a video by ID
+// ErrVideoLocked is returned when an operation is blocked by a video's legal hold
+var ErrVideoLocked = errors.New("video is locked (legal hold)")
+
+// DeleteVideo deletes a video by ID; locked videos are refused with ErrVideoLocked and videos whose
+// content other live videos share with ErrVideoShared
 func (db *DB) DeleteVideo(id uint) error {
-    return db.Delete(&models.Video{}, id).Error
+    if err := db.checkNotShared(id); err != nil {
+        return err
+    }
+    defer db.scenes.invalidateVideo(id)
+    res := db.Where("locked = ?", false).Delete(&models.Video{}, id)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        if locked, err := db.IsVideoLocked(id); err == nil && locked {
+            return ErrVideoLocked
+        }
+    }
+    return nil
 }
 
-// helper
-func getEnv(key, def string) string {
-    if v := os.Getenv(key); v != "" {
-        return v
+// IsVideoLocked reports whether a video is under legal hold
+func (db *DB) IsVideoLocked(id uint) (bool, error) {
+    var locked bool
+    if err := db.Model(&models.Video{}).Select("locked").Where("id = ?", id).Scan(&locked).Error; err != nil {
+        return false, err
     }
-    return def
+    return locked, nil
 }
 
-// UpdateSceneVisualEmbeddingByIndex sets the visual embedding for a scene identified by (video_id, scene_index)
-func (db *DB) UpdateSceneVisualEmbeddingByIndex(videoID uint, sceneIndex int, vec []float32) error {
-    v := pgvector.NewVector(vec)
-    return db.Model(&models.Scene{}).
-        Where("video_id = ? AND scene_index = ?", videoID, sceneIndex).
-        Updates(map[string]interface{}{
-            "visual_embedding": &v,
-        }).Error
+// SetVideoLock places or lifts a legal hold on a video
+func (db *DB) SetVideoLock(id uint, locked bool, reason *string) error {
+    updates := map[string]interface{}{
+        "locked":      locked,
+        "lock_reason": reason,
+        "locked_at":   nil,
+    }
+    if locked {
+        updates["locked_at"] = time.Now()
+    }
+    res := db.Model(&models.Video{}).Where("id = ?", id).Updates(updates)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
 }
 
-// UpdateSceneTextEmbeddingByIndex sets the text embedding for a scene identified by (video_id, scene_index)
-func (db *DB) UpdateSceneTextEmbeddingByIndex(videoID uint, sceneIndex int, vec []float32) error {
-    v := pgvector.NewVector(vec)
-    return db.Model(&models.Scene{}).
-        Where("video_id = ? AND scene_index = ?", videoID, sceneIndex).
-        Updates(map[string]interface{}{
-            "text_embedding": &v,
-        }).Error
+// StopLiveVideo clears a video's live flag so its ingest job finalizes on the next tick
+func (db *DB) StopLiveVideo(id uint) error {
+    res := db.Model(&models.Video{}).Where("id = ?", id).Update("live", false)
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
 }
 
-// UpdateSceneAudioEmbeddingByIndex sets the audio embedding for a scene identified by (video_id, scene_index)
-func (db *DB) UpdateSceneAudioEmbeddingByIndex(videoID uint, sceneIndex int, vec []float32) error {
-    v := pgvector.NewVector(vec)
-    return db.Model(&models.Scene{}).
-        Where("video_id = ? AND scene_index = ?", videoID, sceneIndex).
-        Updates(map[string]interface{}{
-            "audio_embedding": &v,
-        }).Error
+// helper
+func getEnv(key, def string) string {
+    if v := os.Getenv(key); v != "" {
+        return v
+    }
+    return def
 }
 
-// UpdateSceneVisualClipEmbeddingByIndex sets the CLIP visual (text-aligned) embedding for a scene identified by (video_id, scene_index)
-func (db *DB) UpdateSceneVisualClipEmbeddingByIndex(videoID uint, sceneIndex int, vec []float32) error {
-    v := pgvector.NewVector(vec)
+// UpdateSceneKeyframeByIndex stores the timestamp and image path of a scene's representative frame
+func (db *DB) UpdateSceneKeyframeByIndex(videoID uint, level string, sceneIndex int, t float64, path string) error {
+    defer db.scenes.invalidateVideo(videoID)
     return db.Model(&models.Scene{}).
-        Where("video_id = ? AND scene_index = ?", videoID, sceneIndex).
-        Updates(map[string]interface{}{
-            "visual_clip_embedding": &v,
-        }).Error
+        Where("video_id = ? AND level = ? AND scene_index = ?", videoID, SceneLevelOrDefault(level), sceneIndex).
+        Updates(map[string]interface{}{"keyframe_time": t, "keyframe_path": path}).Error
 }
-
-// SearchScenesByTextVector finds top-K nearest scenes by cosine distance to a provided text embedding vector.
-// Optionally filter by a set of video IDs.
-func (db *DB) SearchScenesByTextVector(vec []float32, k int, filterVideoIDs []uint) ([]models.Scene, []float64, error) {
-    v := pgvector.NewVector(vec)
-
-    type row struct {
-        ID           uint
-        UUID         string
-        VideoID      uint
-        SceneIndex   int
-        StartTime    float64
-        EndTime      float64
-        Duration     float64
-        HasCaptions  bool
-        CaptionCount int
-        CreatedAt    time.Time
-        Distance     float64 `gorm:"column:distance"`
-    }
-
-    q := db.Table("scenes").
-        Select("id, uuid, video_id, scene_index, start_time, end_time, duration, has_captions, caption_count, created_at, text_embedding <=> ? as distance", v).
-        Where("text_embedding IS NOT NULL")
-    if len(filterVideoIDs) > 0 {
-        q = q.Where("video_id IN ?", filterVideoIDs)
-    }
-
-    var rows []row
-    if err := q.Order("distance ASC").Limit(k).Scan(&rows).Error; err != nil {
-        return nil, nil, err
-    }
-
-    scenes := make([]models.Scene, 0, len(rows))
-    dists := make([]float64, 0, len(rows))
-    for _, r := range rows {
-        scenes = append(scenes, models.Scene{
-            ID:           r.ID,
-            UUID:         r.UUID,
-            VideoID:      r.VideoID,
-            SceneIndex:   r.SceneIndex,
-            StartTime:    r.StartTime,
-            EndTime:      r.EndTime,
-            Duration:     r.Duration,
-            HasCaptions:  r.HasCaptions,
-            CaptionCount: r.CaptionCount,
-            CreatedAt:    r.CreatedAt,
-        })
-        dists = append(dists, r.Distance)
-    }
-    return scenes, dists, nil
-}
\ No newline at end of file
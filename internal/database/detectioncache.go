@@ -0,0 +1,37 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CachedSceneDetection returns the cached shot boundaries of a detection run over the content with
+// the given SHA-256 and parameters, counting the hit, or nil when there are none
+func (db *DB) CachedSceneDetection(hash, params string) (models.SceneBounds, error) {
+	var entries []models.SceneDetectionCache
+	if err := db.Where("file_hash = ? AND params = ?", hash, params).Limit(1).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	err := db.Model(&models.SceneDetectionCache{}).
+		Where("file_hash = ? AND params = ?", hash, params).
+		Updates(map[string]interface{}{"hits": gorm.Expr("hits + 1"), "last_hit_at": time.Now()}).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries[0].Scenes, nil
+}
+
+// StoreSceneDetection caches the shot boundaries of a detection run, replacing an earlier entry for
+// the same content and parameters
+func (db *DB) StoreSceneDetection(hash, params string, scenes models.SceneBounds) error {
+	return db.Exec(`INSERT INTO scene_detection_cache (file_hash, params, scenes, scene_count, hits, created_at)
+		VALUES (?, ?, ?, ?, 0, NOW())
+		ON CONFLICT (file_hash, params) DO UPDATE SET scenes = EXCLUDED.scenes, scene_count = EXCLUDED.scene_count,
+			hits = 0, created_at = NOW(), last_hit_at = NULL`, hash, params, scenes, len(scenes)).Error
+}
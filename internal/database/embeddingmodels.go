@@ -0,0 +1,191 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateEmbeddingModel registers a model
+func (db *DB) CreateEmbeddingModel(m *models.EmbeddingModel) error {
+	return db.Create(m).Error
+}
+
+// GetEmbeddingModel loads a model by ID
+func (db *DB) GetEmbeddingModel(id uint) (*models.EmbeddingModel, error) {
+	var m models.EmbeddingModel
+	if err := db.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// UpdateEmbeddingModel saves a model's status and progress
+func (db *DB) UpdateEmbeddingModel(m *models.EmbeddingModel) error {
+	return db.Save(m).Error
+}
+
+// ListEmbeddingModels returns all registered models, newest first
+func (db *DB) ListEmbeddingModels() ([]models.EmbeddingModel, error) {
+	var out []models.EmbeddingModel
+	err := db.Order("created_at DESC").Find(&out).Error
+	return out, err
+}
+
+// DeleteEmbeddingModel removes a model and its vectors
+func (db *DB) DeleteEmbeddingModel(id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("embedding_model_id = ?", id).Delete(&models.SceneEmbedding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.EmbeddingModel{}, id).Error
+	})
+}
+
+// ActiveEmbeddingModel returns the active model of a modality, or nil while the built-in default is in use
+func (db *DB) ActiveEmbeddingModel(modality string) (*models.EmbeddingModel, error) {
+	var m models.EmbeddingModel
+	err := db.Where("modality = ? AND status = ?", modality, models.EmbeddingModelActive).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// TrackingEmbeddingModels returns the models that are being backfilled or validated; newly ingested
+// scenes are embedded with them too so they stay complete until cutover
+func (db *DB) TrackingEmbeddingModels(modality string) ([]models.EmbeddingModel, error) {
+	var out []models.EmbeddingModel
+	err := db.Where("modality = ? AND status IN ?", modality, []string{models.EmbeddingModelBackfilling, models.EmbeddingModelValidating}).Find(&out).Error
+	return out, err
+}
+
+// CountTextEmbeddedScenes counts the scenes (all levels) that have a text embedding
+func (db *DB) CountTextEmbeddedScenes() (int, error) {
+	var n int64
+	err := db.Model(&models.Scene{}).Where("text_embedding IS NOT NULL").Count(&n).Error
+	return int(n), err
+}
+
+// TextEmbeddedScenesAfter returns up to limit scenes with a text embedding and an ID above afterID, by ID
+func (db *DB) TextEmbeddedScenesAfter(afterID uint, limit int) ([]models.Scene, error) {
+	var scenes []models.Scene
+	err := db.Select("id, video_id, level, scene_index, start_time, end_time").
+		Where("text_embedding IS NOT NULL AND id > ?", afterID).
+		Order("id").Limit(limit).Find(&scenes).Error
+	return scenes, err
+}
+
+// UpsertSceneEmbeddings stores scene vectors (by scene ID) under a model
+func (db *DB) UpsertSceneEmbeddings(modelID uint, vecs map[uint][]float32) error {
+	if len(vecs) == 0 {
+		return nil
+	}
+	rows := make([]models.SceneEmbedding, 0, len(vecs))
+	for sceneID, vec := range vecs {
+		rows = append(rows, models.SceneEmbedding{SceneID: sceneID, EmbeddingModelID: modelID, Embedding: pgvector.NewVector(prepareVector(vec))})
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scene_id"}, {Name: "embedding_model_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"embedding"}),
+	}).Create(&rows).Error
+}
+
+// CountSceneEmbeddings counts the vectors stored under a model
+func (db *DB) CountSceneEmbeddings(modelID uint) (int, error) {
+	var n int64
+	err := db.Model(&models.SceneEmbedding{}).Where("embedding_model_id = ?", modelID).Count(&n).Error
+	return int(n), err
+}
+
+// PurgeSceneEmbeddings deletes the vectors stored under a model
+func (db *DB) PurgeSceneEmbeddings(modelID uint) error {
+	return db.Where("embedding_model_id = ?", modelID).Delete(&models.SceneEmbedding{}).Error
+}
+
+// SearchScenesByModelVector is SearchScenesByTextVector over a non-active model's vectors in scene_embeddings
+func (db *DB) SearchScenesByModelVector(modelID uint, vec []float32, k int, filter SceneFilter) ([]models.Scene, []float64, error) {
+	v := pgvector.NewVector(prepareVector(vec))
+	q := vectorQueryOn("scenes", sceneHitColumns,
+		"(SELECT se.embedding FROM scene_embeddings se WHERE se.scene_id = scenes.id AND se.embedding_model_id = ?)",
+		MetricForColumn(ColumnText), v, modelID)
+	return db.scanSceneHits(q, filter, k)
+}
+
+// ActivateEmbeddingModel cuts scene text search over to model next in one transaction: the current
+// vectors in scenes.text_embedding are kept in scene_embeddings under the outgoing model (current,
+// created from the built-in default when no model is active), next's vectors replace them, and the
+// outgoing model is retired. It returns the retired model.
+func (db *DB) ActivateEmbeddingModel(nextID uint, builtin models.EmbeddingModel) (*models.EmbeddingModel, error) {
+	defer db.scenes.purge()
+	var retired models.EmbeddingModel
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var next models.EmbeddingModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&next, nextID).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("modality = ? AND status = ?", next.Modality, models.EmbeddingModelActive).First(&retired).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			retired = builtin
+			retired.Status = models.EmbeddingModelActive
+			err = tx.Create(&retired).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		// Keep the outgoing vectors, then swap in the new ones; scenes next has no vector for lose theirs
+		// so the column never mixes vector spaces
+		if err := tx.Exec(`INSERT INTO scene_embeddings (scene_id, embedding_model_id, embedding)
+			SELECT id, ?, text_embedding FROM scenes WHERE text_embedding IS NOT NULL
+			ON CONFLICT (scene_id, embedding_model_id) DO UPDATE SET embedding = EXCLUDED.embedding`, retired.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE scenes SET text_embedding = NULL WHERE text_embedding IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM scene_embeddings se WHERE se.scene_id = scenes.id AND se.embedding_model_id = ?)`, next.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE scenes SET text_embedding = se.embedding FROM scene_embeddings se
+			WHERE se.scene_id = scenes.id AND se.embedding_model_id = ?`, next.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("embedding_model_id = ?", next.ID).Delete(&models.SceneEmbedding{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		retired.Status, retired.RetiredAt = models.EmbeddingModelRetired, &now
+		if err := tx.Save(&retired).Error; err != nil {
+			return err
+		}
+		next.Status, next.ActivatedAt, next.RetiredAt, next.Error = models.EmbeddingModelActive, &now, nil, nil
+		return tx.Save(&next).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &retired, nil
+}
+
+// AllVideoIDs returns the IDs of every video that is not deleted
+func (db *DB) AllVideoIDs() ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.Video{}).Where("status <> ?", models.VideoStatusDeleted).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// VideoIDsWithTopics returns the videos that have a topic timeline
+func (db *DB) VideoIDsWithTopics() ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.VideoTopic{}).Distinct("video_id").Order("video_id").Pluck("video_id", &ids).Error
+	return ids, err
+}
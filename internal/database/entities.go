@@ -0,0 +1,77 @@
+package database
+
+import (
+	"strings"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeEntity folds an entity name for matching: lowercase with collapsed whitespace
+func NormalizeEntity(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// NormalizeEntities normalizes a list of entity names, dropping empty ones
+func NormalizeEntities(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		if n = NormalizeEntity(n); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// ReplaceVideoEntities atomically replaces the entities extracted for a video's scenes
+func (db *DB) ReplaceVideoEntities(videoID uint, entities []models.SceneEntity) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.SceneEntity{}).Error; err != nil {
+			return err
+		}
+		if len(entities) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&entities, 500).Error
+	})
+}
+
+// EntityFacetQuery selects entity facets
+type EntityFacetQuery struct {
+	// VideoID limits facets to one video (whole library when nil)
+	VideoID *uint
+	// EntityType limits facets to one type (all when empty)
+	EntityType string
+	// Prefix matches the start of the normalized entity name
+	Prefix string
+	Limit  int
+}
+
+// ListEntityFacets returns entities with their mention, scene and video counts, most mentioned first.
+// Counts are over shots so scenes are not counted twice through their beats.
+func (db *DB) ListEntityFacets(fq EntityFacetQuery) ([]models.EntityFacet, error) {
+	q := db.Table("scene_entities e").
+		Select("MIN(e.entity) AS entity, e.normalized, e.entity_type, SUM(e.mentions) AS mentions, COUNT(DISTINCT e.scene_id) AS scene_count, COUNT(DISTINCT e.video_id) AS video_count").
+		Joins("JOIN scenes s ON s.id = e.scene_id AND s.level = ?", models.SceneLevelShot)
+	if fq.VideoID != nil {
+		q = q.Where("e.video_id = ?", *fq.VideoID)
+	}
+	if fq.EntityType != "" {
+		q = q.Where("e.entity_type = ?", fq.EntityType)
+	}
+	if p := NormalizeEntity(fq.Prefix); p != "" {
+		q = q.Where("e.normalized LIKE ?", escapeLike(p)+"%")
+	}
+	var facets []models.EntityFacet
+	err := q.Group("e.normalized, e.entity_type").
+		Order("mentions DESC, e.normalized ASC").
+		Limit(fq.Limit).
+		Scan(&facets).Error
+	return facets, err
+}
+
+// escapeLike escapes LIKE wildcards in a literal
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"strconv"
+
+	"goodclips-server/internal/models"
+)
+
+// FindExistingVideo returns the live video already registered for a source, matching its SHA-256
+// when hash is set or else its path, and nil when there is none. With perProject only videos of that
+// project (metadata.project, "" for none) are considered, since other projects share content rather
+// than registrations.
+func (db *DB) FindExistingVideo(filepath, hash, project string, perProject bool) (*models.Video, error) {
+	q := db.Where("status <> ?", models.VideoStatusDeleted)
+	if perProject {
+		q = q.Where("COALESCE(metadata->>'project', '') = ?", project)
+	}
+	if hash != "" {
+		q = q.Where("file_hash = ? OR filepath = ?", hash, filepath)
+	} else {
+		q = q.Where("filepath = ?", filepath)
+	}
+	var videos []models.Video
+	if err := q.Order("id").Limit(1).Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	if len(videos) == 0 {
+		return nil, nil
+	}
+	return &videos[0], nil
+}
+
+// GetVideoByFileHash returns the live video other than excludeID whose source has the given SHA-256
+// and that holds its own content (see LinkSharedVideo), or nil when there is none
+func (db *DB) GetVideoByFileHash(hash string, excludeID uint) (*models.Video, error) {
+	var videos []models.Video
+	err := db.Where("file_hash = ? AND id <> ? AND status <> ? AND content_video_id IS NULL", hash, excludeID, models.VideoStatusDeleted).
+		Order("id").Limit(1).Find(&videos).Error
+	if err != nil || len(videos) == 0 {
+		return nil, err
+	}
+	return &videos[0], nil
+}
+
+// SetVideoFileHash stores the SHA-256 of a video's source; it fails when a live video already has it
+func (db *DB) SetVideoFileHash(id uint, hash string) error {
+	return db.Model(&models.Video{}).Where("id = ?", id).Update("file_hash", hash).Error
+}
+
+// MarkVideoDuplicate retires a video whose source duplicates another video's, keeping its hash and
+// recording the original in metadata.duplicate_of
+func (db *DB) MarkVideoDuplicate(id, originalID uint, hash string) error {
+	return db.Exec(`UPDATE videos SET status = ?, file_hash = ?, error_message = ?,
+		metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('duplicate_of', ?::int), updated_at = NOW()
+		WHERE id = ?`, models.VideoStatusDeleted, hash, "duplicate of video "+strconv.FormatUint(uint64(originalID), 10), originalID, id).Error
+}
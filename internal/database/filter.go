@@ -0,0 +1,83 @@
+package database
+
+// SceneFilter restricts scene searches
+type SceneFilter struct {
+	// VideoIDs limits results to these videos (all when empty); a video sharing another's content
+	// stands for that video
+	VideoIDs []uint
+	// Level is the scene granularity ("shot" when empty)
+	Level string
+	// AssetTypes limits results to scenes of these asset types (video, audio, image; all when empty)
+	AssetTypes []string
+	// Tags, Statuses and Projects limit results to videos with any of these tags, statuses or
+	// metadata.project values (all when empty)
+	Tags     []string
+	Statuses []string
+	Projects []string
+	// MinDuration/MaxDuration bound the scene duration in seconds
+	MinDuration *float64
+	MaxDuration *float64
+	// Entities limits results to scenes whose captions mention any of these entities (case-insensitive)
+	Entities []string
+	// ExcludeFlagged drops scenes overlapping a content flag in FlagCategories (any category when empty)
+	ExcludeFlagged bool
+	FlagCategories []string
+	// Emotions limits results to scenes whose dominant caption or audio emotion is any of these
+	Emotions []string
+	// MinSentiment/MaxSentiment bound caption sentiment ([-1, 1]); MinIntensity bounds tone intensity ([0, 1])
+	MinSentiment *float64
+	MaxSentiment *float64
+	MinIntensity *float64
+}
+
+// conds are the filter's conditions on the scenes table
+func (f SceneFilter) conds() []sqlCond {
+	conds := []sqlCond{{"level = ?", []interface{}{SceneLevelOrDefault(f.Level)}}}
+	add := func(sql string, args ...interface{}) {
+		conds = append(conds, sqlCond{sql, args})
+	}
+	if len(f.VideoIDs) > 0 {
+		add("video_id IN ("+contentVideoIDs+")", f.VideoIDs)
+	}
+	conds = append(conds, videoSubquery(f.videoConds())...)
+	if f.MinDuration != nil {
+		add("end_time - start_time >= ?", *f.MinDuration)
+	}
+	if f.MaxDuration != nil {
+		add("end_time - start_time <= ?", *f.MaxDuration)
+	}
+	if len(f.Entities) > 0 {
+		add("id IN (SELECT scene_id FROM scene_entities WHERE normalized IN ?)", NormalizeEntities(f.Entities))
+	}
+	if f.ExcludeFlagged {
+		flagged := "SELECT 1 FROM content_flags cf WHERE cf.video_id = scenes.video_id AND cf.start_time < scenes.end_time AND cf.end_time > scenes.start_time"
+		if len(f.FlagCategories) > 0 {
+			add("NOT EXISTS ("+flagged+" AND cf.category IN ?)", f.FlagCategories)
+		} else {
+			add("NOT EXISTS (" + flagged + ")")
+		}
+	}
+	if len(f.Emotions) > 0 {
+		add("id IN (SELECT scene_id FROM scene_tones WHERE emotion IN ? OR audio_emotion IN ?)", f.Emotions, f.Emotions)
+	}
+	if f.MinSentiment != nil {
+		add("id IN (SELECT scene_id FROM scene_tones WHERE sentiment >= ?)", *f.MinSentiment)
+	}
+	if f.MaxSentiment != nil {
+		add("id IN (SELECT scene_id FROM scene_tones WHERE sentiment <= ?)", *f.MaxSentiment)
+	}
+	if f.MinIntensity != nil {
+		add("id IN (SELECT scene_id FROM scene_tones WHERE intensity >= ?)", *f.MinIntensity)
+	}
+	return conds
+}
+
+// videoConds are the filter's conditions on the videos table, the video IDs included; a hit is
+// reported under a video matching them (see hitVideoJoin)
+func (f SceneFilter) videoConds() []sqlCond {
+	conds := videoConds(f.AssetTypes, f.Tags, f.Statuses, f.Projects)
+	if len(f.VideoIDs) > 0 {
+		conds = append(conds, sqlCond{"id IN ?", []interface{}{f.VideoIDs}})
+	}
+	return conds
+}
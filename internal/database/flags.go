@@ -0,0 +1,35 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// FeatureFlagOverrides returns the stored feature flag overrides by name
+func (db *DB) FeatureFlagOverrides() (map[string]bool, error) {
+	var rows []models.FeatureFlag
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		out[r.Name] = r.Enabled
+	}
+	return out, nil
+}
+
+// SetFeatureFlag stores an override for a flag
+func (db *DB) SetFeatureFlag(name string, enabled bool) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&models.FeatureFlag{Name: name, Enabled: enabled, UpdatedAt: time.Now()}).Error
+}
+
+// DeleteFeatureFlag removes a flag's override so it falls back to its default
+func (db *DB) DeleteFeatureFlag(name string) error {
+	return db.Where("name = ?", name).Delete(&models.FeatureFlag{}).Error
+}
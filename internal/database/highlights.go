@@ -0,0 +1,46 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateHighlightReel inserts a new highlight reel request
+func (db *DB) CreateHighlightReel(reel *models.HighlightReel) error {
+	return db.Create(reel).Error
+}
+
+// GetHighlightReel loads a highlight reel by ID
+func (db *DB) GetHighlightReel(id uint) (*models.HighlightReel, error) {
+	var reel models.HighlightReel
+	if err := db.First(&reel, id).Error; err != nil {
+		return nil, err
+	}
+	return &reel, nil
+}
+
+// UpdateHighlightReel saves a highlight reel's status, items and export
+func (db *DB) UpdateHighlightReel(reel *models.HighlightReel) error {
+	return db.Save(reel).Error
+}
+
+// ListHighlightReels returns highlight reels newest first, optionally only those of one video
+func (db *DB) ListHighlightReels(videoID *uint, limit, offset int) ([]models.HighlightReel, int, error) {
+	scope := func() *gorm.DB {
+		q := db.Model(&models.HighlightReel{})
+		if videoID != nil {
+			q = q.Where("video_id = ?", *videoID)
+		}
+		return q
+	}
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var reels []models.HighlightReel
+	if err := scope().Order("created_at DESC").Limit(limit).Offset(offset).Find(&reels).Error; err != nil {
+		return nil, 0, err
+	}
+	return reels, int(total), nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceSceneKeyframes atomically replaces a scene's candidate keyframes
+func (db *DB) ReplaceSceneKeyframes(sceneID uint, frames []models.SceneKeyframe) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scene_id = ?", sceneID).Delete(&models.SceneKeyframe{}).Error; err != nil {
+			return err
+		}
+		if len(frames) == 0 {
+			return nil
+		}
+		return tx.Create(&frames).Error
+	})
+}
+
+// ListSceneKeyframes returns a scene's candidate keyframes, best first
+func (db *DB) ListSceneKeyframes(sceneID uint) ([]models.SceneKeyframe, error) {
+	var frames []models.SceneKeyframe
+	err := db.Where("scene_id = ?", sceneID).Order("rank ASC").Find(&frames).Error
+	return frames, err
+}
+
+// SelectSceneKeyframe makes one candidate the scene's display frame and mirrors its timestamp into
+// the scene's keyframe_time. It returns gorm.ErrRecordNotFound when the candidate is not the scene's.
+func (db *DB) SelectSceneKeyframe(sceneID, keyframeID uint) (*models.SceneKeyframe, error) {
+	var frame models.SceneKeyframe
+	defer db.scenes.invalidateScene(sceneID)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND scene_id = ?", keyframeID, sceneID).First(&frame).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SceneKeyframe{}).Where("scene_id = ? AND selected", sceneID).Update("selected", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&frame).Update("selected", true).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Scene{}).Where("id = ?", sceneID).Update("keyframe_time", frame.Timestamp).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// KeyframeCandidateTimes returns the candidate keyframe timestamps of a video's scenes at one level,
+// keyed by scene_index
+func (db *DB) KeyframeCandidateTimes(videoID uint, level string) (map[int][]float64, error) {
+	var rows []struct {
+		SceneIndex int
+		Timestamp  float64
+	}
+	err := db.Table("scene_keyframes k").
+		Select("s.scene_index, k.timestamp").
+		Joins("JOIN scenes s ON s.id = k.scene_id").
+		Where("s.video_id = ? AND s.level = ?", videoID, SceneLevelOrDefault(level)).
+		Order("s.scene_index, k.rank").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[int][]float64)
+	for _, r := range rows {
+		times[r.SceneIndex] = append(times[r.SceneIndex], r.Timestamp)
+	}
+	return times, nil
+}
+
+// SceneIDsWithKeyframes returns which of the given scenes have a keyframe to serve as a thumbnail
+func (db *DB) SceneIDsWithKeyframes(ids []uint) (map[uint]bool, error) {
+	out := make(map[uint]bool)
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var found []uint
+	err := db.Model(&models.Scene{}).
+		Where("id IN ? AND (keyframe_path IS NOT NULL OR keyframe_time IS NOT NULL)", ids).
+		Pluck("id", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		out[id] = true
+	}
+	return out, nil
+}
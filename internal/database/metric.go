@@ -0,0 +1,162 @@
+package database
+
+import (
+	"math"
+	"os"
+	"strings"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// Metric is the distance function used to compare vectors of one embedding column
+type Metric string
+
+const (
+	MetricCosine       Metric = "cosine"
+	MetricL2           Metric = "l2"
+	MetricInnerProduct Metric = "inner_product"
+)
+
+// Embedding columns on the scenes table
+const (
+	ColumnVisual     = "visual_embedding"
+	ColumnText       = "text_embedding"
+	ColumnAudio      = "audio_embedding"
+	ColumnVisualClip = "visual_clip_embedding"
+	ColumnCombined   = "combined_embedding"
+)
+
+// modalityColumns maps the modality names used in configuration and API responses to scene columns
+var modalityColumns = map[string]string{
+	"visual":   ColumnVisual,
+	"text":     ColumnText,
+	"audio":    ColumnAudio,
+	"clip":     ColumnVisualClip,
+	"combined": ColumnCombined,
+}
+
+// ModalityColumn returns the scene column of a modality name ("visual", "text", "audio", "clip",
+// "combined")
+func ModalityColumn(modality string) (string, bool) {
+	col, ok := modalityColumns[modality]
+	return col, ok
+}
+
+// Operator returns the pgvector distance operator for the metric
+func (m Metric) Operator() string {
+	switch m {
+	case MetricL2:
+		return "<->"
+	case MetricInnerProduct:
+		return "<#>"
+	default:
+		return "<=>"
+	}
+}
+
+// OpClass is the pgvector operator class indexing the metric's operator
+func (m Metric) OpClass() string {
+	switch m {
+	case MetricL2:
+		return "vector_l2_ops"
+	case MetricInnerProduct:
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// Similarity converts a pgvector distance for this metric into a similarity where higher is better.
+// For L2 the conversion assumes unit-length vectors (cos = 1 - d²/2).
+func (m Metric) Similarity(distance float64) float64 {
+	switch m {
+	case MetricL2:
+		return 1.0 - (distance*distance)/2.0
+	case MetricInnerProduct:
+		// pgvector returns the negative inner product
+		return -distance
+	default:
+		return 1.0 - distance
+	}
+}
+
+// Score converts a pgvector distance for this metric into a score on [0, 1], 1 for identical vectors:
+// the similarity, which lies in [-1, 1] for cosine and for unit-length vectors under the other
+// metrics, mapped onto [0, 1] and clamped. Scores compare alike across embedding columns whatever
+// their metric, so one similarity threshold fits every search.
+func (m Metric) Score(distance float64) float64 {
+	s := (m.Similarity(distance) + 1) / 2
+	return math.Max(0, math.Min(1, s))
+}
+
+// ParseMetric parses a metric name, falling back to cosine for unknown values
+func ParseMetric(s string) Metric {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "l2", "euclidean":
+		return MetricL2
+	case "ip", "inner_product", "dot":
+		return MetricInnerProduct
+	default:
+		return MetricCosine
+	}
+}
+
+// MetricForColumn returns the configured metric for an embedding column.
+// Each modality can be overridden via EMBEDDING_METRIC_<MODALITY> (e.g. EMBEDDING_METRIC_AUDIO=l2).
+func MetricForColumn(column string) Metric {
+	for modality, col := range modalityColumns {
+		if col == column {
+			return ParseMetric(os.Getenv("EMBEDDING_METRIC_" + strings.ToUpper(modality)))
+		}
+	}
+	return MetricCosine
+}
+
+// EmbeddingMetrics returns the effective metric per modality, for recording alongside stored vectors
+func EmbeddingMetrics() map[string]string {
+	out := make(map[string]string, len(modalityColumns))
+	for modality, col := range modalityColumns {
+		out[modality] = string(MetricForColumn(col))
+	}
+	return out
+}
+
+// NormalizeOnWrite reports whether vectors are L2-normalized before persistence (EMBEDDING_NORMALIZE, default true)
+func NormalizeOnWrite() bool {
+	v := strings.ToLower(os.Getenv("EMBEDDING_NORMALIZE"))
+	return v != "false" && v != "0"
+}
+
+// NormalizeL2 returns a unit-length copy of vec; zero vectors are returned unchanged
+func NormalizeL2(vec []float32) []float32 {
+	var sum float64
+	for _, x := range vec {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(vec))
+	for i, x := range vec {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+// prepareVector applies normalize-on-write to vectors headed for storage or comparison
+func prepareVector(vec []float32) []float32 {
+	if NormalizeOnWrite() {
+		return NormalizeL2(vec)
+	}
+	return vec
+}
+
+// prepareEmbedding applies normalize-on-write to an embedding set on a row about to be stored
+func prepareEmbedding(v *pgvector.Vector) *pgvector.Vector {
+	if v == nil {
+		return nil
+	}
+	p := pgvector.NewVector(prepareVector(v.Slice()))
+	return &p
+}
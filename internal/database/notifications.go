@@ -0,0 +1,91 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateNotificationChannel inserts a notification channel
+func (db *DB) CreateNotificationChannel(ch *models.NotificationChannel) error {
+	return db.Create(ch).Error
+}
+
+// GetNotificationChannel loads a notification channel by ID
+func (db *DB) GetNotificationChannel(id uint) (*models.NotificationChannel, error) {
+	var ch models.NotificationChannel
+	if err := db.First(&ch, id).Error; err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// UpdateNotificationChannel saves a notification channel
+func (db *DB) UpdateNotificationChannel(ch *models.NotificationChannel) error {
+	return db.Save(ch).Error
+}
+
+// DeleteNotificationChannel removes a notification channel
+func (db *DB) DeleteNotificationChannel(id uint) error {
+	return db.Delete(&models.NotificationChannel{}, id).Error
+}
+
+// ListNotificationChannels returns all notification channels, oldest first
+func (db *DB) ListNotificationChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	err := db.Order("id ASC").Find(&channels).Error
+	return channels, err
+}
+
+// NotificationChannelsFor returns the active channels subscribed to event for project: channels with
+// no events listed take every event, channels without a project take every project
+func (db *DB) NotificationChannelsFor(event, project string) ([]models.NotificationChannel, error) {
+	ev, _ := json.Marshal([]string{event})
+	var channels []models.NotificationChannel
+	err := db.Where("active = ?", true).
+		Where("(jsonb_array_length(events) = 0 OR events @> ?::jsonb)", string(ev)).
+		Where("(project IS NULL OR project = ?)", project).
+		Order("id ASC").
+		Find(&channels).Error
+	return channels, err
+}
+
+// GetLibraryDigest summarises what was added to and searched in the library since the given time
+func (db *DB) GetLibraryDigest(since time.Time) (models.LibraryDigest, error) {
+	d := models.LibraryDigest{Since: since}
+	var n int64
+	var secs float64
+	live := db.Model(&models.Video{}).Where("status <> ?", models.VideoStatusDeleted)
+	if err := live.Session(&gorm.Session{}).Where("created_at >= ?", since).Count(&n).Error; err != nil {
+		return d, err
+	}
+	d.NewVideos = int(n)
+	if err := live.Session(&gorm.Session{}).Where("created_at >= ?", since).Select("COALESCE(SUM(duration), 0)").Scan(&secs).Error; err != nil {
+		return d, err
+	}
+	d.HoursIngested = secs / 3600
+	if err := live.Session(&gorm.Session{}).Count(&n).Error; err != nil {
+		return d, err
+	}
+	d.TotalVideos = int(n)
+	if err := live.Session(&gorm.Session{}).Select("COALESCE(SUM(duration), 0)").Scan(&secs).Error; err != nil {
+		return d, err
+	}
+	d.TotalHours = secs / 3600
+	if err := db.Model(&models.Scene{}).Where("level = ? AND created_at >= ?", models.SceneLevelShot, since).Count(&n).Error; err != nil {
+		return d, err
+	}
+	d.NewScenes = int(n)
+	if err := db.Model(&models.SearchEvent{}).Where("created_at >= ?", since).Count(&n).Error; err != nil {
+		return d, err
+	}
+	d.Searches = int(n)
+	if err := db.Model(&models.AlertMatch{}).Where("created_at >= ?", since).Count(&n).Error; err != nil {
+		return d, err
+	}
+	d.AlertMatches = int(n)
+	return d, nil
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"goodclips-server/internal/models"
+)
+
+// sceneCache is an in-process LRU of scene rows fetched by ID (summary columns) or by
+// (video_id, level, scene_index) (full rows), for scenes looked up repeatedly by search anchors,
+// keyframe pages and thumbnails. Writes through this DB invalidate the affected entries once they
+// return; writes by other processes (workers) become visible once an entry's TTL expires.
+type sceneCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type sceneCacheEntry struct {
+	key     string
+	scene   models.Scene
+	expires time.Time
+}
+
+// newSceneCacheFromEnv sizes the cache from SCENE_CACHE_SIZE (entries, default 5000; 0 disables it)
+// and SCENE_CACHE_TTL_SECS (default 60). A disabled cache is nil; its methods are no-ops.
+func newSceneCacheFromEnv() *sceneCache {
+	size := 5000
+	if n, err := strconv.Atoi(os.Getenv("SCENE_CACHE_SIZE")); err == nil && n >= 0 {
+		size = n
+	}
+	ttl := 60 * time.Second
+	if secs, err := strconv.Atoi(os.Getenv("SCENE_CACHE_TTL_SECS")); err == nil && secs > 0 {
+		ttl = time.Duration(secs) * time.Second
+	}
+	if size == 0 {
+		return nil
+	}
+	return &sceneCache{size: size, ttl: ttl, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func sceneIDKey(id uint) string {
+	return fmt.Sprintf("id:%d", id)
+}
+
+func sceneIndexKey(videoID uint, level string, sceneIndex int) string {
+	return fmt.Sprintf("idx:%d:%s:%d", videoID, level, sceneIndex)
+}
+
+// get returns a copy of the cached scene, so callers may modify it
+func (c *sceneCache) get(key string) (*models.Scene, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*sceneCacheEntry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	s := e.scene
+	return &s, true
+}
+
+func (c *sceneCache) put(key string, s *models.Scene) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &sceneCacheEntry{key: key, scene: *s, expires: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *sceneCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*sceneCacheEntry).key)
+}
+
+// invalidate drops the cached scenes matching the predicate
+func (c *sceneCache) invalidate(match func(s *models.Scene) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		if match(&el.Value.(*sceneCacheEntry).scene) {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+// invalidateVideo drops a video's cached scenes
+func (c *sceneCache) invalidateVideo(videoID uint) {
+	c.invalidate(func(s *models.Scene) bool { return s.VideoID == videoID })
+}
+
+// invalidateScene drops the cached entries of one scene
+func (c *sceneCache) invalidateScene(id uint) {
+	c.invalidate(func(s *models.Scene) bool { return s.ID == id })
+}
+
+// purge drops every cached scene, after writes spanning the whole library
+func (c *sceneCache) purge() {
+	c.invalidate(func(*models.Scene) bool { return true })
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SceneSummary is a scene without its vectors, with which embeddings it has
+type SceneSummary struct {
+	ID                 uint      `json:"id"`
+	UUID               string    `json:"uuid"`
+	VideoID            uint      `json:"video_id"`
+	Level              string    `json:"level"`
+	SceneIndex         int       `json:"scene_index"`
+	BeatIndex          *int      `json:"beat_index,omitempty"`
+	StartTime          float64   `json:"start_time"`
+	EndTime            float64   `json:"end_time"`
+	Duration           float64   `json:"duration"`
+	KeyframeTime       *float64  `json:"keyframe_time"`
+	HasCaptions        bool      `json:"has_captions"`
+	CaptionCount       int       `json:"caption_count"`
+	HasVisualEmbedding bool      `json:"has_visual_embedding"`
+	HasTextEmbedding   bool      `json:"has_text_embedding"`
+	HasAudioEmbedding  bool      `json:"has_audio_embedding"`
+	HasClipEmbedding   bool      `json:"has_clip_embedding"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// ListSceneSummaries pages through a video's scenes of one level by scene_index (descending when
+// desc is set) and returns the total number of scenes at that level
+func (db *DB) ListSceneSummaries(videoID uint, level string, desc bool, limit, offset int) ([]SceneSummary, int, error) {
+	scope := func() *gorm.DB {
+		return db.Model(&models.Scene{}).Where("video_id = ? AND level = ?", videoID, SceneLevelOrDefault(level))
+	}
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	order := "scene_index ASC"
+	if desc {
+		order = "scene_index DESC"
+	}
+	var scenes []SceneSummary
+	err := scope().Select(sceneSummarySelect).Order(order).Limit(limit).Offset(offset).Scan(&scenes).Error
+	return scenes, int(total), err
+}
+
+// sceneSummarySelect selects the columns of a SceneSummary
+const sceneSummarySelect = `id, uuid, video_id, level, scene_index, beat_index, start_time, end_time,
+	end_time - start_time AS duration, keyframe_time, has_captions, caption_count, created_at,
+	visual_embedding IS NOT NULL AS has_visual_embedding,
+	text_embedding IS NOT NULL AS has_text_embedding,
+	audio_embedding IS NOT NULL AS has_audio_embedding,
+	visual_clip_embedding IS NOT NULL AS has_clip_embedding`
+
+// GetSceneSummaries loads the scenes with any of the given IDs or UUIDs, in no particular order.
+// UUIDs must be well-formed.
+func (db *DB) GetSceneSummaries(ids []uint, uuids []string) ([]SceneSummary, error) {
+	var scenes []SceneSummary
+	if len(ids) == 0 && len(uuids) == 0 {
+		return scenes, nil
+	}
+	q := db.Model(&models.Scene{}).Select(sceneSummarySelect)
+	switch {
+	case len(uuids) == 0:
+		q = q.Where("id IN ?", ids)
+	case len(ids) == 0:
+		q = q.Where("uuid IN ?", uuids)
+	default:
+		q = q.Where("id IN ? OR uuid IN ?", ids, uuids)
+	}
+	err := q.Scan(&scenes).Error
+	return scenes, err
+}
+
+// GetVideoSummaries loads the given videos' descriptive columns, keyed by ID; missing IDs are absent
+func (db *DB) GetVideoSummaries(ids []uint) (map[uint]models.Video, error) {
+	out := make(map[uint]models.Video, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var videos []models.Video
+	err := db.Select("id, uuid, filename, title, asset_type, duration, scene_count, tags, status").
+		Where("id IN ?", ids).Find(&videos).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range videos {
+		out[v.ID] = v
+	}
+	return out, nil
+}
+
+// GetShotAt returns the shot of a video containing time t, the last one starting at or before it
+func (db *DB) GetShotAt(videoID uint, t float64) (*models.Scene, error) {
+	var s models.Scene
+	err := db.Select("id, video_id, level, scene_index, start_time, end_time").
+		Where("video_id = ? AND level = ? AND start_time <= ?", videoID, models.SceneLevelShot, t).
+		Order("start_time DESC").First(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSceneByID loads a scene by ID, without its embeddings
+func (db *DB) GetSceneByID(id uint) (*models.Scene, error) {
+	if s, ok := db.scenes.get(sceneIDKey(id)); ok {
+		return s, nil
+	}
+	var s models.Scene
+	if err := db.Select(sceneSummaryColumns).First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	db.scenes.put(sceneIDKey(id), &s)
+	return &s, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateScenePreview stores a new preview
+func (db *DB) CreateScenePreview(p *models.ScenePreview) error {
+	return db.Create(p).Error
+}
+
+// GetScenePreview loads one of a video's previews
+func (db *DB) GetScenePreview(videoID, id uint) (*models.ScenePreview, error) {
+	var p models.ScenePreview
+	if err := db.Where("video_id = ?", videoID).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// UpdateScenePreview saves a preview's status and result
+func (db *DB) UpdateScenePreview(p *models.ScenePreview) error {
+	return db.Save(p).Error
+}
+
+// ListScenePreviews returns a video's previews without their scenes, newest first
+func (db *DB) ListScenePreviews(videoID uint) ([]models.ScenePreview, error) {
+	var out []models.ScenePreview
+	err := db.Omit("scenes").Where("video_id = ?", videoID).Order("created_at DESC").Find(&out).Error
+	return out, err
+}
+
+// sceneReferenceTables hold references to scenes (scene_id) that must follow a scene's content when
+// its video is re-segmented rather than stay with the scene row
+var sceneReferenceTables = []string{"search_feedback"}
+
+// RemapSceneReferences moves the scene references in sceneReferenceTables from each key scene to its
+// value scene. All moves happen in one statement per table, so swaps and chains are applied correctly.
+func (db *DB) RemapSceneReferences(mapping map[uint]uint) error {
+	if len(mapping) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(mapping))
+	for from, to := range mapping {
+		values = append(values, fmt.Sprintf("(%d, %d)", from, to))
+	}
+	pairs := strings.Join(values, ", ")
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range sceneReferenceTables {
+			err := tx.Exec(`UPDATE ` + table + ` t SET scene_id = m.to_id FROM (VALUES ` + pairs + `) AS m(from_id, to_id)
+				WHERE t.scene_id = m.from_id`).Error
+			if err != nil {
+				return fmt.Errorf("failed to remap %s: %v", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteScenesFromIndex deletes a video's scenes of one level with scene_index >= from, left over
+// when re-detection finds fewer scenes. Captions linked to them are unlinked, not deleted.
+func (db *DB) DeleteScenesFromIndex(videoID uint, level string, from int) error {
+	defer db.scenes.invalidateVideo(videoID)
+	return db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&models.Scene{}).Select("id").Where("video_id = ? AND level = ? AND scene_index >= ?", videoID, level, from)
+		if err := tx.Model(&models.Caption{}).Where("scene_id IN (?)", stale).Update("scene_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("video_id = ? AND level = ? AND scene_index >= ?", videoID, level, from).Delete(&models.Scene{}).Error
+	})
+}
+
+// CountSceneReferences counts the rows in sceneReferenceTables that reference the given scenes
+func (db *DB) CountSceneReferences(sceneIDs []uint) (int, error) {
+	if len(sceneIDs) == 0 {
+		return 0, nil
+	}
+	total := 0
+	for _, table := range sceneReferenceTables {
+		var n int64
+		if err := db.Table(table).Where("scene_id IN ?", sceneIDs).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += int(n)
+	}
+	return total, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// ListSceneTextFilters returns every stored scene text filter, the default set first
+func (db *DB) ListSceneTextFilters() ([]models.SceneTextFilter, error) {
+	var rows []models.SceneTextFilter
+	err := db.Order("project").Find(&rows).Error
+	return rows, err
+}
+
+// SceneTextFiltersFor returns the stored default set and project's set, nil where none is stored
+func (db *DB) SceneTextFiltersFor(project string) (def, proj *models.SceneTextFilter, err error) {
+	var rows []models.SceneTextFilter
+	if err := db.Where("project IN ?", []string{"", project}).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	for i := range rows {
+		if rows[i].Project == "" {
+			def = &rows[i]
+		} else {
+			proj = &rows[i]
+		}
+	}
+	return def, proj, nil
+}
+
+// SetSceneTextFilter stores a project's scene text filter, replacing any earlier one
+func (db *DB) SetSceneTextFilter(f *models.SceneTextFilter) error {
+	f.UpdatedAt = time.Now()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project"}},
+		DoUpdates: clause.AssignmentColumns([]string{"patterns", "stopwords", "inherit", "updated_at"}),
+	}).Create(f).Error
+}
+
+// DeleteSceneTextFilter removes a project's scene text filter, reporting whether one was stored
+func (db *DB) DeleteSceneTextFilter(project string) (bool, error) {
+	res := db.Where("project = ?", project).Delete(&models.SceneTextFilter{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateSearchEvent stores a search event
+func (db *DB) CreateSearchEvent(ev *models.SearchEvent) error {
+	return db.Create(ev).Error
+}
+
+// GetSearchEvent loads a search event by ID
+func (db *DB) GetSearchEvent(id uint) (*models.SearchEvent, error) {
+	var ev models.SearchEvent
+	if err := db.First(&ev, id).Error; err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// GetSearchAnalytics aggregates search events created since the given time.
+// Queries are grouped case-insensitively; limit bounds the top/zero-result lists.
+func (db *DB) GetSearchAnalytics(since time.Time, limit int) (models.SearchAnalytics, error) {
+	out := models.SearchAnalytics{Since: since}
+
+	var total int64
+	if err := db.Model(&models.SearchEvent{}).Where("created_at >= ?", since).Count(&total).Error; err != nil {
+		return out, err
+	}
+	out.TotalSearches = int(total)
+
+	if err := db.Model(&models.SearchEvent{}).
+		Select("LOWER(TRIM(query)) AS query, COUNT(*) AS count").
+		Where("created_at >= ? AND COALESCE(query, '') <> ''", since).
+		Group("LOWER(TRIM(query))").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&out.TopQueries).Error; err != nil {
+		return out, err
+	}
+
+	if err := db.Model(&models.SearchEvent{}).
+		Select("LOWER(TRIM(query)) AS query, COUNT(*) AS count").
+		Where("created_at >= ? AND result_count = 0 AND COALESCE(query, '') <> ''", since).
+		Group("LOWER(TRIM(query))").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&out.ZeroResultQueries).Error; err != nil {
+		return out, err
+	}
+
+	if err := db.Model(&models.SearchEvent{}).
+		Select(`modality,
+			COUNT(*) AS searches,
+			COUNT(*) FILTER (WHERE result_count = 0) AS zero_results,
+			COUNT(*) FILTER (WHERE click_count > 0) AS clicked,
+			COALESCE(AVG(latency_ms), 0) AS avg_latency_ms`).
+		Where("created_at >= ?", since).
+		Group("modality").
+		Order("searches DESC").
+		Scan(&out.Modalities).Error; err != nil {
+		return out, err
+	}
+	for i := range out.Modalities {
+		if m := &out.Modalities[i]; m.Searches > 0 {
+			m.CTR = float64(m.Clicked) / float64(m.Searches)
+		}
+	}
+	return out, nil
+}
+
+// NormalizeQuery is the grouping key for repeated queries
+func NormalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+// CreateSearchFeedback stores a feedback row and bumps the click counter of its search event
+func (db *DB) CreateSearchFeedback(fb *models.SearchFeedback) error {
+	fb.NormalizedQuery = NormalizeQuery(fb.Query)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(fb).Error; err != nil {
+			return err
+		}
+		if fb.SearchEventID == nil {
+			return nil
+		}
+		return tx.Model(&models.SearchEvent{}).
+			Where("id = ?", *fb.SearchEventID).
+			UpdateColumn("click_count", gorm.Expr("click_count + 1")).Error
+	})
+}
+
+// GetScenePopularity counts feedback per scene for a query, restricted to the given scenes
+func (db *DB) GetScenePopularity(query string, sceneIDs []uint) (map[uint]int, error) {
+	out := map[uint]int{}
+	if len(sceneIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		SceneID uint
+		Count   int
+	}
+	if err := db.Model(&models.SearchFeedback{}).
+		Select("scene_id, COUNT(*) AS count").
+		Where("normalized_query = ? AND scene_id IN ?", NormalizeQuery(query), sceneIDs).
+		Group("scene_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.SceneID] = r.Count
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"goodclips-server/internal/models"
+)
+
+// contentVideoIDs selects the videos holding the content of the videos given as its argument: a video
+// sharing another's content stands for that video
+const contentVideoIDs = "SELECT COALESCE(content_video_id, id) FROM videos WHERE id IN ?"
+
+// hitVideoJoin joins, as v, the video a search hit on the content of alias.video_id is reported
+// under: among that video and the videos sharing its content, one matching conds (conditions on the
+// videos table, e.g. the search's video IDs and projects), the content video itself when it matches.
+// A hit found through another project's copy is so reported under the copy that was searched, never
+// under the project holding the content. v has the columns id, uuid, filename, title, duration,
+// asset_type and status.
+func hitVideoJoin(alias string, conds []sqlCond) (string, []interface{}) {
+	if len(conds) == 0 {
+		return "JOIN videos v ON v.id = " + alias + ".video_id", nil
+	}
+	parts := make([]string, len(conds))
+	var args []interface{}
+	for i, c := range conds {
+		parts[i] = c.sql
+		args = append(args, c.args...)
+	}
+	return fmt.Sprintf(`JOIN LATERAL (
+			SELECT id, uuid, filename, title, duration, asset_type, status FROM videos
+			WHERE COALESCE(content_video_id, id) = %[1]s.video_id AND %[2]s
+			ORDER BY id = %[1]s.video_id DESC, id LIMIT 1
+		) v ON true`, alias, strings.Join(parts, " AND ")), args
+}
+
+// hitVideoConds are the conditions of caption searches on the video a hit is reported under: not
+// deleted and, when given, one of videoIDs
+func hitVideoConds(videoIDs []uint) []sqlCond {
+	conds := []sqlCond{{"status <> ?", []interface{}{models.VideoStatusDeleted}}}
+	if len(videoIDs) > 0 {
+		conds = append(conds, sqlCond{"id IN ?", []interface{}{videoIDs}})
+	}
+	return conds
+}
+
+// ErrVideoShared is returned when deleting a video whose content other videos share
+var ErrVideoShared = errors.New("video content is shared by other videos")
+
+// ContentVideoID returns the video holding a video's scenes, captions and embeddings: the video it
+// shares them with, or itself
+func (db *DB) ContentVideoID(id uint) (uint, error) {
+	var video models.Video
+	if err := db.Select("id, content_video_id").First(&video, id).Error; err != nil {
+		return 0, err
+	}
+	if video.ContentVideoID != nil {
+		return *video.ContentVideoID, nil
+	}
+	return video.ID, nil
+}
+
+// SharedVideoIDs returns the live videos sharing a video's content
+func (db *DB) SharedVideoIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.Video{}).Where("content_video_id = ? AND status <> ?", id, models.VideoStatusDeleted).
+		Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// LinkSharedVideo makes a video share the content of contentID, a video with the same source hash,
+// instead of being ingested. It takes over the content video's status (processing while that is
+// still pending), duration and counts; SyncSharedVideos keeps them current.
+func (db *DB) LinkSharedVideo(id, contentID uint, hash string) error {
+	return db.Exec(`UPDATE videos s SET content_video_id = o.id, file_hash = ?,
+		status = CASE WHEN o.status = ? THEN ? ELSE o.status END,
+		duration = o.duration, scene_count = o.scene_count, caption_count = o.caption_count,
+		embedding_model = o.embedding_model, error_code = o.error_code, error_message = o.error_message,
+		last_processed_at = o.last_processed_at, updated_at = NOW()
+		FROM videos o
+		WHERE s.id = ? AND o.id = ?`, hash, models.VideoStatusPending, models.VideoStatusProcessing, id, contentID).Error
+}
+
+// SyncSharedVideos copies a video's status, outcome, duration and counts to the live videos sharing
+// its content
+func (db *DB) SyncSharedVideos(id uint) error {
+	return db.Exec(`UPDATE videos s SET status = o.status, error_code = o.error_code, error_message = o.error_message,
+		last_processed_at = o.last_processed_at, duration = o.duration, scene_count = o.scene_count,
+		caption_count = o.caption_count, embedding_model = o.embedding_model, updated_at = NOW()
+		FROM videos o
+		WHERE o.id = ? AND s.content_video_id = o.id AND s.status <> ?`, id, models.VideoStatusDeleted).Error
+}
+
+// checkNotShared refuses with ErrVideoShared when live videos share a video's content
+func (db *DB) checkNotShared(id uint) error {
+	ids, err := db.SharedVideoIDs(id)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		return fmt.Errorf("%w: %v", ErrVideoShared, ids)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SnapshotLibrary computes the library snapshot of the UTC day containing day and upserts it. Totals
+// count rows created before the end of that day, so past days can be backfilled; embedding coverage
+// reflects the embeddings present now.
+func (db *DB) SnapshotLibrary(day time.Time) (*models.LibrarySnapshot, error) {
+	day = day.UTC().Truncate(24 * time.Hour)
+	end := day.AddDate(0, 0, 1)
+	s := &models.LibrarySnapshot{Day: day}
+	var n int64
+	var secs float64
+
+	live := db.Model(&models.Video{}).Where("status <> ? AND created_at < ?", models.VideoStatusDeleted, end)
+	if err := live.Session(&gorm.Session{}).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.TotalVideos = int(n)
+	if err := live.Session(&gorm.Session{}).Select("COALESCE(SUM(duration), 0)").Scan(&secs).Error; err != nil {
+		return nil, err
+	}
+	s.TotalHours = secs / 3600
+	if err := live.Session(&gorm.Session{}).Where("created_at >= ?", day).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.VideosIngested = int(n)
+	if err := live.Session(&gorm.Session{}).Where("created_at >= ?", day).Select("COALESCE(SUM(duration), 0)").Scan(&secs).Error; err != nil {
+		return nil, err
+	}
+	s.HoursIngested = secs / 3600
+
+	shots := db.Model(&models.Scene{}).Where("level = ? AND created_at < ?", models.SceneLevelShot, end)
+	if err := shots.Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.TotalScenes = int(n)
+	shots = db.Model(&models.Scene{}).Where("level = ? AND created_at < ? AND visual_embedding IS NOT NULL", models.SceneLevelShot, end)
+	if err := shots.Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.ScenesWithEmbeddings = int(n)
+	if s.TotalScenes > 0 {
+		s.EmbeddingCoverage = float64(s.ScenesWithEmbeddings) / float64(s.TotalScenes)
+	}
+	if err := db.Model(&models.Caption{}).Where("created_at < ?", end).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.TotalCaptions = int(n)
+
+	if err := db.Model(&models.SearchEvent{}).Where("created_at >= ? AND created_at < ?", day, end).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.Searches = int(n)
+	if err := db.Model(&models.SearchEvent{}).Where("created_at >= ? AND created_at < ? AND result_count = 0", day, end).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	s.ZeroResultSearches = int(n)
+
+	s.UpdatedAt = time.Now()
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total_videos", "total_hours", "total_scenes", "scenes_with_embeddings", "embedding_coverage", "total_captions", "videos_ingested", "hours_ingested", "searches", "zero_result_searches", "updated_at"}),
+	}).Create(s).Error
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListLibrarySnapshots returns the snapshots of the days from..to (inclusive), oldest first
+func (db *DB) ListLibrarySnapshots(from, to time.Time) ([]models.LibrarySnapshot, error) {
+	var snaps []models.LibrarySnapshot
+	err := db.Where("day >= ? AND day <= ?", from.Format("2006-01-02"), to.Format("2006-01-02")).Order("day").Find(&snaps).Error
+	return snaps, err
+}
+
+// HasLibrarySnapshot reports whether the UTC day containing day has a snapshot
+func (db *DB) HasLibrarySnapshot(day time.Time) (bool, error) {
+	var n int64
+	err := db.Model(&models.LibrarySnapshot{}).Where("day = ?", day.UTC().Format("2006-01-02")).Count(&n).Error
+	return n > 0, err
+}
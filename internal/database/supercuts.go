@@ -0,0 +1,22 @@
+package database
+
+import "goodclips-server/internal/models"
+
+// CreateSupercut inserts a new supercut request
+func (db *DB) CreateSupercut(sc *models.Supercut) error {
+	return db.Create(sc).Error
+}
+
+// GetSupercut loads a supercut by ID
+func (db *DB) GetSupercut(id uint) (*models.Supercut, error) {
+	var sc models.Supercut
+	if err := db.First(&sc, id).Error; err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// UpdateSupercut saves a supercut's status, items and export
+func (db *DB) UpdateSupercut(sc *models.Supercut) error {
+	return db.Save(sc).Error
+}
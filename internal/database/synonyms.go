@@ -0,0 +1,40 @@
+package database
+
+import (
+	"fmt"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListSynonyms returns the whole synonym dictionary ordered by term
+func (db *DB) ListSynonyms() ([]models.Synonym, error) {
+	var entries []models.Synonym
+	err := db.Order("term ASC").Find(&entries).Error
+	return entries, err
+}
+
+// SynonymsVersion identifies the stored dictionary; it changes whenever ReplaceSynonyms runs, since
+// replaced entries get new IDs
+func (db *DB) SynonymsVersion() (string, error) {
+	var v struct {
+		Count int64
+		MaxID uint
+	}
+	err := db.Model(&models.Synonym{}).Select("COUNT(*) AS count, COALESCE(MAX(id), 0) AS max_id").Scan(&v).Error
+	return fmt.Sprintf("%d:%d", v.Count, v.MaxID), err
+}
+
+// ReplaceSynonyms atomically replaces the synonym dictionary with entries
+func (db *DB) ReplaceSynonyms(entries []models.Synonym) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM synonyms").Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.Create(&entries).Error
+	})
+}
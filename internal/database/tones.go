@@ -0,0 +1,36 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceVideoTones atomically replaces the tone scores of a video's scenes
+func (db *DB) ReplaceVideoTones(videoID uint, tones []models.SceneTone) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.SceneTone{}).Error; err != nil {
+			return err
+		}
+		if len(tones) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&tones, 500).Error
+	})
+}
+
+// GetSceneTones returns the tone scores of the given scenes keyed by scene ID; unscored scenes are absent
+func (db *DB) GetSceneTones(sceneIDs []uint) (map[uint]models.SceneTone, error) {
+	out := map[uint]models.SceneTone{}
+	if len(sceneIDs) == 0 {
+		return out, nil
+	}
+	var tones []models.SceneTone
+	if err := db.Where("scene_id IN ?", sceneIDs).Find(&tones).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range tones {
+		out[t.SceneID] = t
+	}
+	return out, nil
+}
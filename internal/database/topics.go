@@ -0,0 +1,51 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+// ReplaceVideoTopics atomically replaces a video's topic timeline
+func (db *DB) ReplaceVideoTopics(videoID uint, topics []models.VideoTopic) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID).Delete(&models.VideoTopic{}).Error; err != nil {
+			return err
+		}
+		if len(topics) == 0 {
+			return nil
+		}
+		return tx.Create(&topics).Error
+	})
+}
+
+// ListVideoTopics returns a video's topic timeline in time order
+func (db *DB) ListVideoTopics(videoID uint) ([]models.VideoTopic, error) {
+	var topics []models.VideoTopic
+	err := db.Omit("embedding").Where("video_id = ?", videoID).Order("topic_index ASC").Find(&topics).Error
+	return topics, err
+}
+
+// SearchVideoTopics ranks a video's topic segments by distance to a text embedding (same metric as
+// scene text search), nearest first
+func (db *DB) SearchVideoTopics(videoID uint, vec []float32, k int) ([]models.VideoTopic, []float64, error) {
+	v := pgvector.NewVector(prepareVector(vec))
+	var rows []struct {
+		models.VideoTopic
+		Distance float64
+	}
+	q := vectorQueryOn("video_topics", "id, video_id, topic_index, start_time, end_time, label, keywords, excerpt, caption_count, created_at",
+		"embedding", MetricForColumn(ColumnText), v)
+	err := q.where("video_id = ?", videoID).build(db.DB, k).Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	topics := make([]models.VideoTopic, len(rows))
+	dists := make([]float64, len(rows))
+	for i, r := range rows {
+		topics[i] = r.VideoTopic
+		dists[i] = r.Distance
+	}
+	return topics, dists, nil
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// sceneHitColumns are the scene columns returned by nearest-neighbour searches (no vectors)
+const sceneHitColumns = "id, uuid, video_id, level, scene_index, beat_index, start_time, end_time, duration, has_captions, caption_count, created_at"
+
+// isEmbeddingColumn reports whether column is one of the scenes table's embedding columns. Column
+// names are spliced into SQL, so only these are accepted.
+func isEmbeddingColumn(column string) bool {
+	for _, c := range modalityColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchScenesByVector finds the top-K scenes nearest to vec in an embedding column (one of the
+// Column* constants) by the column's configured metric. Results are restricted by filter.
+func (db *DB) SearchScenesByVector(column string, vec []float32, k int, filter SceneFilter) ([]models.Scene, []float64, error) {
+	q, err := newVectorQuery("scenes", sceneHitColumns, column, pgvector.NewVector(prepareVector(vec)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.scanSceneHits(q, filter, k)
+}
+
+// scanSceneHits runs a nearest-neighbour query over scenes selecting sceneHitColumns, restricted by
+// filter. The hits are joined with their videos, so each scene's Video carries the video's ID, UUID,
+// filename, title, duration, asset type and status. A hit on shared content is reported under the
+// video sharing it that matches filter (see hitVideoJoin): its VideoID and Video are that video's.
+func (db *DB) scanSceneHits(q *vectorQuery, filter SceneFilter, k int) ([]models.Scene, []float64, error) {
+	type row struct {
+		ID           uint
+		UUID         string
+		VideoID      uint
+		Level        string
+		SceneIndex   int
+		BeatIndex    *int
+		StartTime    float64
+		EndTime      float64
+		Duration     float64
+		HasCaptions  bool
+		CaptionCount int
+		CreatedAt    time.Time
+		Distance     float64 `gorm:"column:distance"`
+
+		HitVideoID     uint
+		VideoUUID      string
+		VideoFilename  string
+		VideoTitle     *string
+		VideoDuration  float64
+		VideoAssetType string
+		VideoStatus    models.VideoStatus
+	}
+	// The join wraps the nearest-neighbour query, so the index scan and LIMIT run first and only the
+	// k hits are joined
+	var rows []row
+	join, joinArgs := hitVideoJoin("h", filter.videoConds())
+	err := db.Table("(?) AS h", q.whereAll(filter.conds()).build(db.DB, k)).
+		Select(`h.*, v.id AS hit_video_id, v.uuid AS video_uuid, v.filename AS video_filename, v.title AS video_title,
+			v.duration AS video_duration, v.asset_type AS video_asset_type, v.status AS video_status`).
+		Joins(join, joinArgs...).
+		Order("h.distance ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scenes := make([]models.Scene, 0, len(rows))
+	dists := make([]float64, 0, len(rows))
+	for _, r := range rows {
+		scenes = append(scenes, models.Scene{
+			ID:           r.ID,
+			UUID:         r.UUID,
+			VideoID:      r.HitVideoID,
+			Level:        r.Level,
+			SceneIndex:   r.SceneIndex,
+			BeatIndex:    r.BeatIndex,
+			StartTime:    r.StartTime,
+			EndTime:      r.EndTime,
+			Duration:     r.Duration,
+			HasCaptions:  r.HasCaptions,
+			CaptionCount: r.CaptionCount,
+			CreatedAt:    r.CreatedAt,
+			Video: models.Video{
+				ID:        r.HitVideoID,
+				UUID:      r.VideoUUID,
+				Filename:  r.VideoFilename,
+				Title:     r.VideoTitle,
+				Duration:  r.VideoDuration,
+				AssetType: r.VideoAssetType,
+				Status:    r.VideoStatus,
+			},
+		})
+		dists = append(dists, r.Distance)
+	}
+	return scenes, dists, nil
+}
+
+// SearchScenesByClipVector finds top-K nearest scenes by the configured metric distance (cosine by default) to a provided CLIP text/image embedding vector.
+// Results are restricted by filter (video IDs, scene level, asset types).
+func (db *DB) SearchScenesByClipVector(vec []float32, k int, filter SceneFilter) ([]models.Scene, []float64, error) {
+	return db.SearchScenesByVector(ColumnVisualClip, vec, k, filter)
+}
+
+// SearchScenesByAudioVector finds top-K nearest scenes by the configured metric distance (cosine by default) to a provided CLAP audio/text embedding vector.
+// Results are restricted by filter (video IDs, scene level, asset types).
+func (db *DB) SearchScenesByAudioVector(vec []float32, k int, filter SceneFilter) ([]models.Scene, []float64, error) {
+	return db.SearchScenesByVector(ColumnAudio, vec, k, filter)
+}
+
+// SearchScenesByTextVector finds top-K nearest scenes by the configured metric distance (cosine by default) to a provided text embedding vector.
+// Results are restricted by filter (video IDs, scene level, asset types).
+func (db *DB) SearchScenesByTextVector(vec []float32, k int, filter SceneFilter) ([]models.Scene, []float64, error) {
+	return db.SearchScenesByVector(ColumnText, vec, k, filter)
+}
+
+// sceneEmbedding returns the scene's vector in an embedding column, nil when it has none
+func sceneEmbedding(s *models.Scene, column string) *pgvector.Vector {
+	switch column {
+	case ColumnVisual:
+		return s.VisualEmbedding
+	case ColumnText:
+		return s.TextEmbedding
+	case ColumnAudio:
+		return s.AudioEmbedding
+	case ColumnVisualClip:
+		return s.VisualClipEmbedding
+	case ColumnCombined:
+		return s.CombinedEmbedding
+	}
+	return nil
+}
+
+// SearchSimilarScenesByAnchor finds top-K nearest scenes by the column's configured metric distance (cosine by default) to the anchor scene's
+// embedding in an embedding column (one of the Column* constants), e.g. its soundtrack for ColumnAudio or its dialogue for ColumnText.
+// It excludes the anchor itself; the anchor is looked up at filter.Level (in the content of a video sharing another's) and results are
+// restricted by filter.
+func (db *DB) SearchSimilarScenesByAnchor(column string, anchorVideoID uint, anchorSceneIndex int, k int, filter SceneFilter) ([]models.Scene, []float64, error) {
+	if !isEmbeddingColumn(column) {
+		return nil, nil, fmt.Errorf("unknown embedding column %q", column)
+	}
+	contentID, err := db.ContentVideoID(anchorVideoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	anchor, err := db.GetSceneByVideoAndIndex(contentID, filter.Level, anchorSceneIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	vec := sceneEmbedding(anchor, column)
+	if vec == nil {
+		return nil, nil, fmt.Errorf("anchor scene has no %s", column)
+	}
+
+	q, err := newVectorQuery("scenes", sceneHitColumns, column, *vec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.scanSceneHits(q.where("id <> ?", anchor.ID), filter, k)
+}
+
+// SceneVectors returns the scenes' vectors in an embedding column (one of the Column* constants) by
+// scene ID; scenes without one are left out
+func (db *DB) SceneVectors(column string, ids []uint) (map[uint][]float32, error) {
+	if !isEmbeddingColumn(column) {
+		return nil, fmt.Errorf("unknown embedding column %q", column)
+	}
+	out := make(map[uint][]float32, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		ID  uint
+		Vec pgvector.Vector
+	}
+	err := db.Table("scenes").
+		Select("id, "+column+" AS vec").
+		Where("id IN ? AND "+column+" IS NOT NULL", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.ID] = r.Vec.Slice()
+	}
+	return out, nil
+}
+
+// UpdateSceneEmbeddingByIndex sets an embedding column (one of the Column* constants) of the scene
+// identified by (video_id, level, scene_index)
+func (db *DB) UpdateSceneEmbeddingByIndex(column string, videoID uint, level string, sceneIndex int, vec []float32) error {
+	if !isEmbeddingColumn(column) {
+		return fmt.Errorf("unknown embedding column %q", column)
+	}
+	v := pgvector.NewVector(prepareVector(vec))
+	defer db.scenes.invalidateVideo(videoID)
+	return db.Model(&models.Scene{}).
+		Where("video_id = ? AND level = ? AND scene_index = ?", videoID, SceneLevelOrDefault(level), sceneIndex).
+		Updates(map[string]interface{}{column: &v}).Error
+}
+
+// UpdateSceneVisualEmbeddingByIndex sets the visual embedding for a scene identified by (video_id, level, scene_index)
+func (db *DB) UpdateSceneVisualEmbeddingByIndex(videoID uint, level string, sceneIndex int, vec []float32) error {
+	return db.UpdateSceneEmbeddingByIndex(ColumnVisual, videoID, level, sceneIndex, vec)
+}
+
+// UpdateSceneTextEmbeddingByIndex sets the text embedding for a scene identified by (video_id, level, scene_index)
+func (db *DB) UpdateSceneTextEmbeddingByIndex(videoID uint, level string, sceneIndex int, vec []float32) error {
+	return db.UpdateSceneEmbeddingByIndex(ColumnText, videoID, level, sceneIndex, vec)
+}
+
+// UpdateSceneAudioEmbeddingByIndex sets the audio embedding for a scene identified by (video_id, level, scene_index)
+func (db *DB) UpdateSceneAudioEmbeddingByIndex(videoID uint, level string, sceneIndex int, vec []float32) error {
+	return db.UpdateSceneEmbeddingByIndex(ColumnAudio, videoID, level, sceneIndex, vec)
+}
+
+// UpdateSceneVisualClipEmbeddingByIndex sets the CLIP visual (text-aligned) embedding for a scene identified by (video_id, level, scene_index)
+func (db *DB) UpdateSceneVisualClipEmbeddingByIndex(videoID uint, level string, sceneIndex int, vec []float32) error {
+	return db.UpdateSceneEmbeddingByIndex(ColumnVisualClip, videoID, level, sceneIndex, vec)
+}
@@ -0,0 +1,309 @@
+//go:build integration
+
+// Integration tests against a pgvector Postgres started with testcontainers; they need Docker:
+//
+//	go test -tags integration ./internal/database/
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goodclips-server/internal/models"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// skipWithoutDocker skips the test when no container runtime is available; testcontainers panics
+// rather than failing when it finds no Docker host at all
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("docker unavailable: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// newTestDB starts a pgvector Postgres loaded with migrations/init.sql
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	skipWithoutDocker(t)
+	ctx := context.Background()
+	ctr, err := postgres.Run(ctx, "pgvector/pgvector:pg16",
+		postgres.WithInitScripts(filepath.Join("..", "..", "migrations", "init.sql")),
+		postgres.WithDatabase("goodclips"),
+		postgres.WithUsername("goodclips"),
+		postgres.WithPassword("goodclips"),
+		postgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, ctr)
+	if err != nil {
+		t.Fatalf("failed to start postgres: %v", err)
+	}
+	host, err := ctr.Host(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := ctr.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewConnection(Config{Host: host, Port: port.Int(), User: "goodclips", Password: "goodclips", DBName: "goodclips", SSLMode: "disable"})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// vec returns a dim-dimensional vector with the given leading components, zero elsewhere
+func vec(dim int, lead ...float32) []float32 {
+	v := make([]float32, dim)
+	copy(v, lead)
+	return v
+}
+
+// columnDims are the dimensions of the scenes table's embedding columns (see migrations/init.sql)
+var columnDims = map[string]int{
+	ColumnVisual:     1024,
+	ColumnText:       768,
+	ColumnAudio:      512,
+	ColumnVisualClip: 512,
+	ColumnCombined:   768,
+}
+
+// library is the seeded test library: two projects' videos and a video sharing project a's content
+type library struct {
+	a, b, shared uint
+}
+
+func createVideo(t *testing.T, db *DB, name, project string, contentID *uint) uint {
+	t.Helper()
+	v := &models.Video{Filename: name, Filepath: "/videos/" + name, AssetType: models.AssetTypeVideo,
+		Status: models.VideoStatusCompleted, Metadata: models.JSONObject{"project": project}, ContentVideoID: contentID}
+	if err := db.CreateVideo(v); err != nil {
+		t.Fatalf("failed to create video: %v", err)
+	}
+	return v.ID
+}
+
+func createScene(t *testing.T, db *DB, videoID uint, level string, index int, start, end float64) {
+	t.Helper()
+	s := &models.Scene{VideoID: videoID, Level: level, SceneIndex: index, StartTime: start, EndTime: end}
+	if err := db.CreateScene(s); err != nil {
+		t.Fatalf("failed to create scene: %v", err)
+	}
+}
+
+// seedLibrary creates the videos and their scenes: three shots of a (the last one 2s long), two of b
+// and one beat of a. Embeddings are set per test.
+func seedLibrary(t *testing.T, db *DB) library {
+	t.Helper()
+	lib := library{a: createVideo(t, db, "a.mp4", "a", nil), b: createVideo(t, db, "b.mp4", "b", nil)}
+	lib.shared = createVideo(t, db, "a-copy.mp4", "c", &lib.a)
+	createScene(t, db, lib.a, models.SceneLevelShot, 0, 0, 10)
+	createScene(t, db, lib.a, models.SceneLevelShot, 1, 10, 20)
+	createScene(t, db, lib.a, models.SceneLevelShot, 2, 20, 22)
+	createScene(t, db, lib.b, models.SceneLevelShot, 0, 0, 10)
+	createScene(t, db, lib.b, models.SceneLevelShot, 1, 10, 20)
+	createScene(t, db, lib.a, models.SceneLevelBeat, 0, 0, 20)
+	return lib
+}
+
+// embedLibrary sets column of the seeded scenes so that, from the query vector e0, the shots rank
+// a/0, a/1, b/0, a/2, b/1 under every metric; the beat lies between a/0 and a/1
+func embedLibrary(t *testing.T, db *DB, lib library, column string) {
+	t.Helper()
+	dim := columnDims[column]
+	for _, e := range []struct {
+		video uint
+		level string
+		index int
+		vec   []float32
+	}{
+		{lib.a, models.SceneLevelShot, 0, vec(dim, 1)},
+		{lib.a, models.SceneLevelShot, 1, vec(dim, 0.8, 0.6)},
+		{lib.a, models.SceneLevelShot, 2, vec(dim, 0, 1)},
+		{lib.b, models.SceneLevelShot, 0, vec(dim, 0.6, 0.8)},
+		{lib.b, models.SceneLevelShot, 1, vec(dim, -0.6, 0, 0.8)},
+		{lib.a, models.SceneLevelBeat, 0, vec(dim, 0.9, 0.1)},
+	} {
+		if err := db.UpdateSceneEmbeddingByIndex(column, e.video, e.level, e.index, e.vec); err != nil {
+			t.Fatalf("failed to embed scene: %v", err)
+		}
+	}
+}
+
+// hitKeys names hits as video/scene_index for comparison
+func hitKeys(lib library, scenes []models.Scene) []string {
+	names := map[uint]string{lib.a: "a", lib.b: "b", lib.shared: "shared"}
+	keys := make([]string, len(scenes))
+	for i, s := range scenes {
+		keys[i] = fmt.Sprintf("%s/%d", names[s.VideoID], s.SceneIndex)
+	}
+	return keys
+}
+
+func TestSearchScenesByVector(t *testing.T) {
+	db := newTestDB(t)
+	lib := seedLibrary(t, db)
+	minDuration := 5.0
+
+	for _, column := range []string{ColumnText, ColumnAudio, ColumnVisualClip} {
+		embedLibrary(t, db, lib, column)
+		modality := ""
+		for m, c := range modalityColumns {
+			if c == column {
+				modality = m
+			}
+		}
+		for _, metric := range []Metric{MetricCosine, MetricL2, MetricInnerProduct} {
+			for _, tc := range []struct {
+				name   string
+				filter SceneFilter
+				k      int
+				want   []string
+			}{
+				{"all", SceneFilter{}, 3, []string{"a/0", "a/1", "b/0"}},
+				{"video", SceneFilter{VideoIDs: []uint{lib.b}}, 5, []string{"b/0", "b/1"}},
+				{"project", SceneFilter{Projects: []string{"b"}}, 1, []string{"b/0"}},
+				{"level", SceneFilter{Level: models.SceneLevelBeat}, 5, []string{"a/0"}},
+				{"duration", SceneFilter{MinDuration: &minDuration, VideoIDs: []uint{lib.a}}, 5, []string{"a/0", "a/1"}},
+				{"shared by id", SceneFilter{VideoIDs: []uint{lib.shared}}, 2, []string{"shared/0", "shared/1"}},
+				{"shared by project", SceneFilter{Projects: []string{"c"}}, 1, []string{"shared/0"}},
+			} {
+				t.Run(modality+"/"+string(metric)+"/"+tc.name, func(t *testing.T) {
+					t.Setenv("EMBEDDING_METRIC_"+strings.ToUpper(modality), string(metric))
+					scenes, dists, err := db.SearchScenesByVector(column, vec(columnDims[column], 1), tc.k, tc.filter)
+					if err != nil {
+						t.Fatalf("search failed: %v", err)
+					}
+					got := hitKeys(lib, scenes)
+					if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+						t.Fatalf("got %v, want %v", got, tc.want)
+					}
+					for i := 1; i < len(dists); i++ {
+						if dists[i] < dists[i-1] {
+							t.Fatalf("distances out of order: %v", dists)
+						}
+					}
+					if tc.name == "all" {
+						if s := metric.Score(dists[0]); math.Abs(s-1) > 1e-4 {
+							t.Fatalf("score of an identical vector is %v, want 1", s)
+						}
+					}
+					for _, s := range scenes {
+						if s.Video.ID != s.VideoID || s.Video.Filename == "" {
+							t.Fatalf("hit %d carries video %+v, want video %d", s.ID, s.Video, s.VideoID)
+						}
+					}
+				})
+			}
+		}
+	}
+
+	if _, _, err := db.SearchScenesByVector("filepath", vec(768, 1), 1, SceneFilter{}); err == nil {
+		t.Fatal("search on an unknown column succeeded")
+	}
+}
+
+func TestSearchSimilarScenesByAnchor(t *testing.T) {
+	db := newTestDB(t)
+	lib := seedLibrary(t, db)
+	embedLibrary(t, db, lib, ColumnText)
+
+	for _, tc := range []struct {
+		name   string
+		anchor uint
+		filter SceneFilter
+		want   []string
+	}{
+		{"excludes anchor", lib.a, SceneFilter{}, []string{"a/1", "b/0", "a/2"}},
+		{"filtered", lib.a, SceneFilter{VideoIDs: []uint{lib.b}}, []string{"b/0", "b/1"}},
+		{"shared anchor", lib.shared, SceneFilter{VideoIDs: []uint{lib.shared}}, []string{"shared/1", "shared/2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scenes, _, err := db.SearchSimilarScenesByAnchor(ColumnText, tc.anchor, 0, 3, tc.filter)
+			if err != nil {
+				t.Fatalf("search failed: %v", err)
+			}
+			got := hitKeys(lib, scenes)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// b/1 has a text embedding, but the anchor needs one in the searched column
+	if _, _, err := db.SearchSimilarScenesByAnchor(ColumnAudio, lib.b, 1, 3, SceneFilter{}); err == nil {
+		t.Fatal("search from an anchor without an audio embedding succeeded")
+	}
+}
+
+func TestUpdateSceneEmbeddingByIndex(t *testing.T) {
+	db := newTestDB(t)
+	lib := seedLibrary(t, db)
+	scene, err := db.GetSceneByVideoAndIndex(lib.a, models.SceneLevelShot, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		column string
+		update func(videoID uint, level string, index int, v []float32) error
+	}{
+		{ColumnVisual, db.UpdateSceneVisualEmbeddingByIndex},
+		{ColumnText, db.UpdateSceneTextEmbeddingByIndex},
+		{ColumnAudio, db.UpdateSceneAudioEmbeddingByIndex},
+		{ColumnVisualClip, db.UpdateSceneVisualClipEmbeddingByIndex},
+	} {
+		t.Run(tc.column, func(t *testing.T) {
+			t.Setenv("EMBEDDING_NORMALIZE", "true")
+			if err := tc.update(lib.a, "", 1, vec(columnDims[tc.column], 3, 4)); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+			vecs, err := db.SceneVectors(tc.column, []uint{scene.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := vecs[scene.ID]
+			if len(got) != columnDims[tc.column] || math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+				t.Fatalf("stored %v..., want the normalized vector (0.6, 0.8, 0, ...)", got[:2])
+			}
+			// The update is visible through the scene cache
+			cached, err := db.GetSceneByVideoAndIndex(lib.a, models.SceneLevelShot, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sceneEmbedding(cached, tc.column) == nil {
+				t.Fatalf("cached scene has no %s after the update", tc.column)
+			}
+		})
+	}
+
+	t.Run("not normalized", func(t *testing.T) {
+		t.Setenv("EMBEDDING_NORMALIZE", "false")
+		if err := db.UpdateSceneTextEmbeddingByIndex(lib.a, "", 1, vec(768, 3, 4)); err != nil {
+			t.Fatal(err)
+		}
+		vecs, err := db.SceneVectors(ColumnText, []uint{scene.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := vecs[scene.ID]; got[0] != 3 || got[1] != 4 {
+			t.Fatalf("stored %v..., want (3, 4, 0, ...)", got[:2])
+		}
+	})
+
+	if err := db.UpdateSceneEmbeddingByIndex("filepath", lib.a, "", 1, vec(768, 1)); err == nil {
+		t.Fatal("update of an unknown column succeeded")
+	}
+}
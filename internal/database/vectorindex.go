@@ -0,0 +1,325 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ANN index types of the scene embedding columns
+const (
+	IndexHNSW    = "hnsw"
+	IndexIVFFlat = "ivfflat"
+	IndexNone    = "none"
+)
+
+// Index actions reported by EnsureVectorIndexes
+const (
+	indexCreated   = "created"
+	indexRebuilt   = "rebuilt"
+	indexDropped   = "dropped"
+	indexUnchanged = "unchanged"
+	indexSkipped   = "skipped"
+)
+
+// memSetting matches a Postgres memory setting such as 512MB or 2GB
+var memSetting = regexp.MustCompile(`^[0-9]+(kB|MB|GB)$`)
+
+// vectorIndexSpec is the configured ANN index of one embedding column
+type vectorIndexSpec struct {
+	modality       string
+	column         string
+	typ            string
+	metric         Metric
+	m              int
+	efConstruction int
+	// lists is the number of IVFFlat lists; 0 derives it from the row count at build time
+	lists int
+}
+
+// envInt reads a positive integer setting
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// vectorIndexType is the configured index type of a modality: VECTOR_INDEX_TYPE_<MODALITY>, else
+// VECTOR_INDEX_TYPE, else hnsw
+func vectorIndexType(modality string) string {
+	for _, key := range []string{"VECTOR_INDEX_TYPE_" + strings.ToUpper(modality), "VECTOR_INDEX_TYPE"} {
+		switch t := strings.ToLower(strings.TrimSpace(os.Getenv(key))); t {
+		case IndexHNSW, IndexIVFFlat, IndexNone:
+			return t
+		}
+	}
+	return IndexHNSW
+}
+
+// vectorIndexSpecFor returns the configured index of a modality's column. HNSW takes m and
+// ef_construction (VECTOR_INDEX_HNSW_M, default 16; VECTOR_INDEX_HNSW_EF_CONSTRUCTION, default 64);
+// IVFFlat takes its list count (VECTOR_INDEX_IVFFLAT_LISTS, default derived from the row count).
+func vectorIndexSpecFor(modality, column string) vectorIndexSpec {
+	return vectorIndexSpec{
+		modality:       modality,
+		column:         column,
+		typ:            vectorIndexType(modality),
+		metric:         MetricForColumn(column),
+		m:              envInt("VECTOR_INDEX_HNSW_M", 16),
+		efConstruction: envInt("VECTOR_INDEX_HNSW_EF_CONSTRUCTION", 64),
+		lists:          envInt("VECTOR_INDEX_IVFFLAT_LISTS", 0),
+	}
+}
+
+// name is the index's name
+func (s vectorIndexSpec) name() string {
+	return "idx_scenes_" + s.column + "_ann"
+}
+
+// method is the index's access method and column, as Postgres prints it in index definitions
+func (s vectorIndexSpec) method() string {
+	return fmt.Sprintf("USING %s (%s %s)", s.typ, s.column, s.metric.OpClass())
+}
+
+// definition is the part of the index's definition, as Postgres prints it, that must match for the
+// index to be current. The list count of an IVFFlat index derived from the row count is not
+// compared, so that a growing table does not make its index stale.
+func (s vectorIndexSpec) definition() string {
+	switch {
+	case s.typ == IndexHNSW:
+		return fmt.Sprintf("%s WITH (m='%d', ef_construction='%d')", s.method(), s.m, s.efConstruction)
+	case s.typ == IndexIVFFlat && s.lists > 0:
+		return fmt.Sprintf("%s WITH (lists='%d')", s.method(), s.lists)
+	}
+	return s.method()
+}
+
+// ivfflatLists is the IVFFlat list count pgvector recommends for rows vectors: rows/1000 up to a
+// million rows, then their square root
+func ivfflatLists(rows int64) int {
+	lists := int(rows / 1000)
+	if rows > 1000000 {
+		lists = int(math.Sqrt(float64(rows)))
+	}
+	if lists < 1 {
+		lists = 1
+	}
+	return lists
+}
+
+// vectorIndexRow is an index on the scenes table as listed by the catalog
+type vectorIndexRow struct {
+	Name       string
+	Definition string
+	Valid      bool
+	SizeBytes  int64
+}
+
+// sceneIndexes lists the indexes on the scenes table
+func (db *DB) sceneIndexes() ([]vectorIndexRow, error) {
+	var rows []vectorIndexRow
+	err := db.Raw(`SELECT c.relname AS name, pg_get_indexdef(c.oid) AS definition, x.indisvalid AS valid,
+			pg_relation_size(c.oid) AS size_bytes
+		FROM pg_index x
+		JOIN pg_class c ON c.oid = x.indexrelid
+		WHERE x.indrelid = 'scenes'::regclass`).Scan(&rows).Error
+	return rows, err
+}
+
+// vectorIndexStatus describes the ANN index of a spec's column among the scenes table's indexes:
+// the one named after the spec, else any HNSW or IVFFlat index on the column (e.g. created by hand)
+func vectorIndexStatus(spec vectorIndexSpec, indexes []vectorIndexRow) models.VectorIndexStatus {
+	st := models.VectorIndexStatus{
+		Modality: spec.modality,
+		Column:   spec.column,
+		Name:     spec.name(),
+		Type:     spec.typ,
+		Metric:   string(spec.metric),
+	}
+	var found *vectorIndexRow
+	for i, ix := range indexes {
+		if ix.Name == spec.name() {
+			found = &indexes[i]
+			break
+		}
+		onColumn := strings.Contains(ix.Definition, "USING hnsw ("+spec.column+" ") ||
+			strings.Contains(ix.Definition, "USING ivfflat ("+spec.column+" ")
+		if onColumn && found == nil {
+			found = &indexes[i]
+		}
+	}
+	if found == nil {
+		st.Current = spec.typ == IndexNone
+		return st
+	}
+	st.Name = found.Name
+	st.Present = true
+	st.Valid = found.Valid
+	st.SizeBytes = found.SizeBytes
+	st.Definition = found.Definition
+	st.Current = spec.typ != IndexNone && found.Valid && strings.Contains(found.Definition, spec.definition())
+	return st
+}
+
+// sortedModalities returns the modality names in a stable order
+func sortedModalities() []string {
+	names := make([]string, 0, len(modalityColumns))
+	for modality := range modalityColumns {
+		names = append(names, modality)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VectorIndexStatuses reports the ANN index of each scene embedding column against its configuration
+func (db *DB) VectorIndexStatuses() ([]models.VectorIndexStatus, error) {
+	indexes, err := db.sceneIndexes()
+	if err != nil {
+		return nil, err
+	}
+	var out []models.VectorIndexStatus
+	for _, modality := range sortedModalities() {
+		out = append(out, vectorIndexStatus(vectorIndexSpecFor(modality, modalityColumns[modality]), indexes))
+	}
+	return out, nil
+}
+
+// EnsureVectorIndexes brings the ANN index of each scene embedding column in line with its
+// configuration (see vectorIndexSpecFor): missing indexes are created, indexes of another type,
+// metric or parameters and invalid ones are rebuilt, and indexes of columns configured as none are
+// dropped. force rebuilds current indexes too, e.g. after bulk loads skewed IVFFlat lists.
+// modalities limits the columns (all when empty). Indexes are built concurrently under a temporary
+// name and swapped in, so searches keep using the old index meanwhile; VECTOR_INDEX_BUILD_MEM
+// (e.g. 2GB) raises maintenance_work_mem for the build. IVFFlat indexes of columns without vectors
+// are skipped, since their lists are trained on the data. A failure on one column is recorded in its
+// status and the others are still processed.
+func (db *DB) EnsureVectorIndexes(force bool, modalities ...string) ([]models.VectorIndexStatus, error) {
+	if len(modalities) == 0 {
+		modalities = sortedModalities()
+	}
+	for _, modality := range modalities {
+		if _, ok := modalityColumns[modality]; !ok {
+			return nil, fmt.Errorf("unknown modality %q", modality)
+		}
+	}
+	indexes, err := db.sceneIndexes()
+	if err != nil {
+		return nil, err
+	}
+	var out []models.VectorIndexStatus
+	var errs []error
+	for _, modality := range modalities {
+		spec := vectorIndexSpecFor(modality, modalityColumns[modality])
+		st := vectorIndexStatus(spec, indexes)
+		action, err := db.ensureVectorIndex(spec, st, force)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", spec.column, err))
+		}
+		if refreshed, rerr := db.sceneIndexes(); rerr == nil {
+			indexes = refreshed
+			st = vectorIndexStatus(spec, indexes)
+		}
+		st.Action = action
+		if err != nil {
+			st.Error = err.Error()
+		}
+		out = append(out, st)
+	}
+	return out, errors.Join(errs...)
+}
+
+// ensureVectorIndex creates, rebuilds or drops one column's index and returns what it did
+func (db *DB) ensureVectorIndex(spec vectorIndexSpec, st models.VectorIndexStatus, force bool) (string, error) {
+	if spec.typ == IndexNone {
+		if !st.Present {
+			return indexUnchanged, nil
+		}
+		if err := db.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + st.Name).Error; err != nil {
+			return "", err
+		}
+		return indexDropped, nil
+	}
+	if st.Current && !force {
+		return indexUnchanged, nil
+	}
+
+	with := fmt.Sprintf("m = %d, ef_construction = %d", spec.m, spec.efConstruction)
+	if spec.typ == IndexIVFFlat {
+		lists := spec.lists
+		if lists == 0 {
+			var rows int64
+			if err := db.Table("scenes").Where(spec.column + " IS NOT NULL").Count(&rows).Error; err != nil {
+				return "", err
+			}
+			if rows == 0 {
+				return indexSkipped, nil
+			}
+			lists = ivfflatLists(rows)
+		}
+		with = fmt.Sprintf("lists = %d", lists)
+	}
+
+	tmp := spec.name() + "_new"
+	err := db.Connection(func(tx *gorm.DB) error {
+		if mem := os.Getenv("VECTOR_INDEX_BUILD_MEM"); memSetting.MatchString(mem) {
+			if err := tx.Exec("SET maintenance_work_mem = '" + mem + "'").Error; err != nil {
+				return err
+			}
+		}
+		// A failed concurrent build leaves an invalid index behind
+		if err := tx.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + tmp).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("CREATE INDEX CONCURRENTLY %s ON scenes %s WITH (%s)", tmp, spec.method(), with)).Error
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build index: %w", err)
+	}
+	if st.Present {
+		if err := db.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + st.Name).Error; err != nil {
+			return "", fmt.Errorf("failed to drop the old index: %w", err)
+		}
+	}
+	if err := db.Exec("ALTER INDEX " + tmp + " RENAME TO " + spec.name()).Error; err != nil {
+		return "", fmt.Errorf("failed to rename the new index: %w", err)
+	}
+	if st.Present {
+		return indexRebuilt, nil
+	}
+	return indexCreated, nil
+}
+
+// vectorSearchParams are the ANN search settings every connection starts with, as connection string
+// parameters: hnsw.ef_search (VECTOR_INDEX_EF_SEARCH, pgvector's default 40), ivfflat.probes
+// (VECTOR_INDEX_PROBES, default 1) and, with pgvector 0.8, iterative scans that keep filtered
+// searches from returning fewer results than asked for (VECTOR_INDEX_ITERATIVE_SCAN: off,
+// relaxed_order or strict_order)
+func vectorSearchParams() string {
+	var params string
+	if n := envInt("VECTOR_INDEX_EF_SEARCH", 0); n > 0 {
+		params += " hnsw.ef_search=" + strconv.Itoa(n)
+	}
+	if n := envInt("VECTOR_INDEX_PROBES", 0); n > 0 {
+		params += " ivfflat.probes=" + strconv.Itoa(n)
+	}
+	switch mode := os.Getenv("VECTOR_INDEX_ITERATIVE_SCAN"); mode {
+	case "off", "relaxed_order", "strict_order":
+		ivfflat := mode
+		if mode == "strict_order" {
+			// IVFFlat has no strict ordering
+			ivfflat = "relaxed_order"
+		}
+		params += " hnsw.iterative_scan=" + mode + " ivfflat.iterative_scan=" + ivfflat
+	}
+	return params
+}
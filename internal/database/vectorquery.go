@@ -0,0 +1,102 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+// sqlCond is a SQL condition written in code with its bound values
+type sqlCond struct {
+	sql  string
+	args []interface{}
+}
+
+// vectorQuery composes a nearest-neighbour query over one table. Its SQL is put together only from
+// fragments written in code and validated column names; every value, the query vector included, is
+// a bound parameter, so filters combine freely without splicing input into SQL and queries run as
+// prepared statements.
+type vectorQuery struct {
+	table    string
+	columns  string
+	distance sqlCond
+	conds    []sqlCond
+}
+
+// newVectorQuery starts a query returning columns of table ordered by the distance of an embedding
+// column to vec under the column's configured metric. Rows without a vector are skipped.
+func newVectorQuery(table, columns, column string, vec pgvector.Vector) (*vectorQuery, error) {
+	if !isEmbeddingColumn(column) {
+		return nil, fmt.Errorf("unknown embedding column %q", column)
+	}
+	return vectorQueryOn(table, columns, column, MetricForColumn(column), vec), nil
+}
+
+// vectorQueryOn starts a query like newVectorQuery over a vector expression other than a scene
+// embedding column (a passage or topic embedding, a correlated subquery, ...) compared under metric.
+// expr must be written in code, with values passed as exprArgs.
+func vectorQueryOn(table, columns, expr string, metric Metric, vec pgvector.Vector, exprArgs ...interface{}) *vectorQuery {
+	q := &vectorQuery{
+		table:    table,
+		columns:  columns,
+		distance: sqlCond{sql: expr + " " + metric.Operator() + " ?", args: append(append([]interface{}{}, exprArgs...), vec)},
+	}
+	return q.where(expr+" IS NOT NULL", exprArgs...)
+}
+
+// where adds a condition; sql must be written in code, with values passed as args
+func (q *vectorQuery) where(sql string, args ...interface{}) *vectorQuery {
+	q.conds = append(q.conds, sqlCond{sql: sql, args: args})
+	return q
+}
+
+// whereAll adds conditions
+func (q *vectorQuery) whereAll(conds []sqlCond) *vectorQuery {
+	q.conds = append(q.conds, conds...)
+	return q
+}
+
+// build returns the query for the k nearest rows, nearest first
+func (q *vectorQuery) build(db *gorm.DB, k int) *gorm.DB {
+	tx := db.Table(q.table).Select(q.columns+", "+q.distance.sql+" AS distance", q.distance.args...)
+	for _, c := range q.conds {
+		tx = tx.Where(c.sql, c.args...)
+	}
+	return tx.Order("distance ASC").Limit(k)
+}
+
+// videoConds are the conditions on the videos table shared by scene and video searches: asset
+// types, tags (any of), statuses and projects (metadata.project, any of)
+func videoConds(assetTypes, tags, statuses, projects []string) []sqlCond {
+	var conds []sqlCond
+	if len(assetTypes) > 0 {
+		conds = append(conds, sqlCond{"asset_type IN ?", []interface{}{assetTypes}})
+	}
+	if len(tags) > 0 {
+		conds = append(conds, sqlCond{"EXISTS (SELECT 1 FROM jsonb_array_elements_text(tags) AS t(tag) WHERE t.tag IN ?)", []interface{}{tags}})
+	}
+	if len(statuses) > 0 {
+		conds = append(conds, sqlCond{"status IN ?", []interface{}{statuses}})
+	}
+	if len(projects) > 0 {
+		conds = append(conds, sqlCond{"metadata->>'project' IN ?", []interface{}{projects}})
+	}
+	return conds
+}
+
+// videoSubquery turns conditions on the videos table into one condition on a video_id column; videos
+// sharing another's content match its rows
+func videoSubquery(conds []sqlCond) []sqlCond {
+	if len(conds) == 0 {
+		return nil
+	}
+	parts := make([]string, len(conds))
+	var args []interface{}
+	for i, c := range conds {
+		parts[i] = c.sql
+		args = append(args, c.args...)
+	}
+	return []sqlCond{{"video_id IN (SELECT COALESCE(content_video_id, id) FROM videos WHERE " + strings.Join(parts, " AND ") + ")", args}}
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"goodclips-server/internal/models"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// VideoFilter restricts video-level searches
+type VideoFilter struct {
+	// VideoIDs limits results to these videos (all when empty)
+	VideoIDs []uint
+	// AssetTypes limits results to these asset types (all when empty)
+	AssetTypes []string
+	// Tags, Statuses and Projects limit results to videos with any of these tags, statuses or
+	// metadata.project values (all when empty)
+	Tags     []string
+	Statuses []string
+	Projects []string
+}
+
+// UpdateVideoTextEmbedding stores a video's title/synopsis/tags embedding
+func (db *DB) UpdateVideoTextEmbedding(videoID uint, vec []float32) error {
+	v := pgvector.NewVector(prepareVector(vec))
+	return db.Model(&models.Video{}).Where("id = ?", videoID).Update("text_embedding", &v).Error
+}
+
+// SearchVideosByTextVector finds the top-K videos nearest to a text embedding by their video-level
+// text embedding (same metric as scene text search). Videos are returned with their distances in order.
+func (db *DB) SearchVideosByTextVector(vec []float32, k int, filter VideoFilter) ([]models.Video, []float64, error) {
+	q, err := newVectorQuery("videos", "id", ColumnText, pgvector.NewVector(prepareVector(vec)))
+	if err != nil {
+		return nil, nil, err
+	}
+	q.where("status <> ?", models.VideoStatusDeleted)
+	if len(filter.VideoIDs) > 0 {
+		q.where("id IN ?", filter.VideoIDs)
+	}
+	q.whereAll(videoConds(filter.AssetTypes, filter.Tags, filter.Statuses, filter.Projects))
+
+	var rows []struct {
+		ID       uint
+		Distance float64
+	}
+	if err := q.build(db.DB, k).Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	var found []models.Video
+	if err := db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]models.Video, len(found))
+	for _, fv := range found {
+		byID[fv.ID] = fv
+	}
+	videos := make([]models.Video, 0, len(rows))
+	dists := make([]float64, 0, len(rows))
+	for _, r := range rows {
+		if fv, ok := byID[r.ID]; ok {
+			videos = append(videos, fv)
+			dists = append(dists, r.Distance)
+		}
+	}
+	return videos, dists, nil
+}
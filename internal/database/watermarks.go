@@ -0,0 +1,46 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"goodclips-server/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListProjectWatermarks returns every stored project watermark by project
+func (db *DB) ListProjectWatermarks() ([]models.ProjectWatermark, error) {
+	var rows []models.ProjectWatermark
+	err := db.Order("project").Find(&rows).Error
+	return rows, err
+}
+
+// GetProjectWatermark returns a project's watermark, nil when none is stored
+func (db *DB) GetProjectWatermark(project string) (*models.ProjectWatermark, error) {
+	var w models.ProjectWatermark
+	err := db.Where("project = ?", project).First(&w).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SetProjectWatermark stores a project's watermark, replacing any earlier one
+func (db *DB) SetProjectWatermark(w *models.ProjectWatermark) error {
+	w.UpdatedAt = time.Now()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "text", "image_path", "position", "opacity", "font_size", "updated_at"}),
+	}).Create(w).Error
+}
+
+// DeleteProjectWatermark removes a project's watermark, reporting whether one was stored
+func (db *DB) DeleteProjectWatermark(project string) (bool, error) {
+	res := db.Where("project = ?", project).Delete(&models.ProjectWatermark{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -10,6 +10,8 @@ import librosa
 from transformers import ClapModel, ClapProcessor
 import contextlib
 
+from runner_io import VectorWriter
+
 
 def l2_normalize(x: torch.Tensor) -> torch.Tensor:
     return torch.nn.functional.normalize(x, p=2, dim=-1)
@@ -78,8 +80,7 @@ def main():
         print(json.dumps({"error": "invalid input: video_path and scenes are required"}))
         return
 
-    results = []
-    D = None
+    writer = VectorWriter(payload)
 
     for s in scenes:
         try:
@@ -102,19 +103,13 @@ def main():
             inputs = {k: v.to(device) for k, v in inputs.items()}
             feats = model.get_audio_features(**inputs)  # (1, D)
             feats = l2_normalize(feats)
-        if D is None:
-            D = int(feats.shape[1])
-        results.append({"scene_index": si, "vector": to_list(feats[0])})
+        writer.add(si, to_list(feats[0]))
 
-    if D is None:
+    if writer.count == 0:
         print(json.dumps({"error": "no audio embeddings produced"}))
         return
 
-    print(json.dumps({
-        "model": model_id,
-        "embedding_dim": D,
-        "vectors": results,
-    }))
+    writer.finish(model_id)
 
 
 if __name__ == "__main__":
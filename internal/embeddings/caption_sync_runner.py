@@ -0,0 +1,167 @@
+#!/usr/bin/env python3
+import sys
+import json
+import os
+from typing import List, Tuple
+
+import numpy as np
+import librosa
+
+SAMPLE_RATE = 16000
+HOP = 320  # 20 ms activity frames
+FPS = SAMPLE_RATE / HOP
+# Speech band used for voice activity; music and rumble outside it are ignored
+SPEECH_BAND = (300.0, 3400.0)
+
+
+def env_float(name: str, default: float) -> float:
+    try:
+        v = float(os.environ.get(name, str(default)))
+        return v if v >= 0 else default
+    except Exception:
+        return default
+
+
+def speech_activity(video_path: str) -> np.ndarray:
+    # Decode a chunk at a time so long videos never sit in memory as raw samples
+    chunk = float(max(60, int(env_float("CAPTION_SYNC_CHUNK_SECS", 600.0))))
+    n_fft = 1024
+    freqs = librosa.fft_frequencies(sr=SAMPLE_RATE, n_fft=n_fft)
+    band = (freqs >= SPEECH_BAND[0]) & (freqs <= SPEECH_BAND[1])
+    energy: List[np.ndarray] = []
+    offset = 0.0
+    while True:
+        y, _ = librosa.load(video_path, sr=SAMPLE_RATE, mono=True, offset=offset, duration=chunk)
+        if y.size == 0:
+            break
+        spec = np.abs(librosa.stft(y, n_fft=n_fft, hop_length=HOP, center=True)) ** 2
+        energy.append(spec[band].sum(axis=0)[: y.size // HOP])
+        if y.size < int(chunk * SAMPLE_RATE) - HOP:
+            break
+        offset += chunk
+    if not energy:
+        return np.zeros(0, dtype=np.float32)
+    db = 10.0 * np.log10(np.maximum(np.concatenate(energy), 1e-10))
+    # Speech is whatever rises well above the quietest fifth of the soundtrack
+    threshold = np.percentile(db, 20) + env_float("CAPTION_SYNC_VAD_DB", 12.0)
+    active = (db > threshold).astype(np.float32)
+    # Bridge the short pauses between words (200 ms)
+    k = 10
+    smoothed = np.convolve(active, np.ones(k, dtype=np.float32) / k, mode="same")
+    return (smoothed >= 0.3).astype(np.float32)
+
+
+def caption_activity(captions, n: int, pad: int) -> np.ndarray:
+    sig = np.zeros(n, dtype=np.float32)
+    for c in captions:
+        a = max(0, pad + int(round(float(c.get("start", 0.0)) * FPS)))
+        b = min(n, pad + int(round(float(c.get("end", 0.0)) * FPS)))
+        if b > a:
+            sig[a:b] = 1.0
+    return sig
+
+
+def best_lag(speech: np.ndarray, caps: np.ndarray, lo: int, hi: int, lag_min: int, lag_max: int) -> Tuple[int, float]:
+    # Shift (in frames) that best lines up captions in [lo, hi) with speech, scored by normalized correlation
+    a = caps[lo:hi] - caps[lo:hi].mean()
+    a_norm = float(np.sqrt((a * a).sum()))
+    s_lo = max(0, lo + lag_min)
+    s_hi = min(len(speech), hi + lag_max)
+    if a_norm == 0 or s_hi - s_lo < len(a):
+        return 0, 0.0
+    s = speech[s_lo:s_hi] - speech[s_lo:s_hi].mean()
+    nfft = 1 << int(np.ceil(np.log2(len(s) + len(a))))
+    corr = np.fft.irfft(np.fft.rfft(s, nfft) * np.conj(np.fft.rfft(a, nfft)), nfft)
+    # Local speech energy under each placement of the caption window
+    sq = np.concatenate(([0.0], np.cumsum(s * s)))
+    best, best_score = 0, -1.0
+    for lag in range(lag_min, lag_max + 1):
+        k = lo + lag - s_lo
+        if k < 0 or k + len(a) > len(s):
+            continue
+        s_norm = np.sqrt(sq[k + len(a)] - sq[k])
+        if s_norm == 0:
+            continue
+        score = float(corr[k] / (a_norm * s_norm))
+        if score > best_score:
+            best, best_score = lag, score
+    return best, max(best_score, 0.0)
+
+
+def sync(payload):
+    video_path = payload.get("video_path")
+    captions = payload.get("captions", [])
+    if not video_path or not isinstance(captions, list) or not captions:
+        return {"error": "invalid input: video_path and captions are required"}
+
+    try:
+        speech = speech_activity(video_path)
+    except Exception as e:
+        return {"error": f"failed to decode audio: {e}"}
+    if speech.size == 0:
+        return {"error": "video has no decodable audio"}
+
+    max_shift = int(env_float("CAPTION_SYNC_MAX_SHIFT", 10.0) * FPS)
+    local_shift = int(env_float("CAPTION_SYNC_LOCAL_SHIFT", 3.0) * FPS)
+    window = max(30.0, env_float("CAPTION_SYNC_WINDOW_SECS", 300.0))
+    min_captions = int(env_float("CAPTION_SYNC_MIN_CAPTIONS", 5))
+    min_score = env_float("CAPTION_SYNC_MIN_SCORE", 0.1)
+
+    # Silence on both sides leaves room for every shift; frame i of the padded signals is time (i - pad) / FPS
+    pad = max_shift
+    audio_frames = len(speech)
+    silence = np.zeros(pad, dtype=np.float32)
+    speech = np.concatenate((silence, speech, silence))
+    n = len(speech)
+    caps = caption_activity(captions, n, pad)
+
+    def frame(t: float) -> int:
+        return min(n - pad, max(pad, pad + int(t * FPS)))
+
+    first = min(float(c.get("start", 0.0)) for c in captions)
+    last = max(float(c.get("end", 0.0)) for c in captions)
+    g_lag, g_score = best_lag(speech, caps, frame(first), frame(last) + 1, -max_shift, max_shift)
+
+    # Piecewise: each window is searched near the global offset, falling back to it when the window
+    # has too few captions or no clear match
+    segments = []
+    t = first
+    while t < last:
+        end = min(t + window, last)
+        count = sum(1 for c in captions if t <= float(c.get("start", 0.0)) < end)
+        lag, score, fallback = g_lag, g_score, True
+        if count >= min_captions:
+            lo, hi = frame(t), frame(end) + 1
+            l, s = best_lag(speech, caps, lo, hi, max(-max_shift, g_lag - local_shift), min(max_shift, g_lag + local_shift))
+            if s >= min_score:
+                lag, score, fallback = l, s, False
+        segments.append({
+            "start": t,
+            "end": end,
+            "offset": lag / FPS,
+            "score": score,
+            "captions": count,
+            "fallback": fallback,
+        })
+        t = end
+
+    return {
+        "global": {"offset": g_lag / FPS, "score": g_score},
+        "segments": segments,
+        "audio_duration": audio_frames / FPS,
+    }
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"error": f"invalid json input: {e}"}))
+        return
+
+    print(json.dumps(sync(payload)))
+
+
+if __name__ == "__main__":
+    main()
@@ -11,6 +11,8 @@ from decord import VideoReader, cpu
 from PIL import Image
 import contextlib
 
+from runner_io import VectorWriter
+
 try:
     import open_clip  # preferred path
     HAS_OPEN_CLIP = True
@@ -110,6 +112,12 @@ def sample_scene_frame(vr: VideoReader, start: float, end: float) -> np.ndarray:
     return frame
 
 
+def sample_frame_at(vr: VideoReader, t: float) -> np.ndarray:
+    fps = float(vr.get_avg_fps()) if hasattr(vr, 'get_avg_fps') else 30.0
+    idx = time_to_index(vr, fps, t)
+    return vr.get_batch([idx]).asnumpy()[0]
+
+
 def sample_scene_frames_multi(vr: VideoReader, start: float, end: float, target_fps: float = 5.0, max_frames: int = 32) -> List[np.ndarray]:
     total = len(vr)
     fps = float(vr.get_avg_fps()) if hasattr(vr, 'get_avg_fps') else 30.0
@@ -175,6 +183,29 @@ def main():
         print(json.dumps(out))
         return
 
+    # still image asset: embed the image itself for every requested scene index
+    image_path = payload.get("image_path")
+    if image_path:
+        try:
+            img = Image.open(image_path).convert("RGB")
+        except Exception as e:
+            print(json.dumps({"error": f"failed to open image: {e}"}))
+            return
+        with torch.no_grad():
+            if backend == 'open_clip':
+                feats = model.encode_image(proc(img).unsqueeze(0).to(device))
+            else:
+                enc = to_device(proc(images=[img], return_tensors="pt"), device)
+                feats = model.get_image_features(**enc)
+            feats = l2_normalize(feats)
+        vec = to_list(feats[0])
+        indices = [int(s.get("scene_index", 0)) for s in payload.get("scenes", [])] or [0]
+        writer = VectorWriter(payload)
+        for si in indices:
+            writer.add(si, vec)
+        writer.finish(f"{backend}:{model_id}")
+        return
+
     # image mode (per-scene image embedding from multiple frames)
     video_path = payload.get("video_path")
     scenes = payload.get("scenes", [])
@@ -189,8 +220,7 @@ def main():
         print(json.dumps({"error": f"failed to open video: {e}"}))
         return
 
-    results = []
-    D = None
+    writer = VectorWriter(payload)
     for s in scenes:
         try:
             si = int(s.get("scene_index"))
@@ -199,7 +229,16 @@ def main():
         except Exception:
             continue
 
-        frames = sample_scene_frames_multi(vr, st, et, target_fps=target_fps)
+        # Keyframe candidates (averaged for robustness) or the scene's selected keyframe (also its
+        # thumbnail) represent it when available
+        cands = s.get("candidates") or []
+        kf = s.get("keyframe")
+        if cands:
+            frames = [sample_frame_at(vr, float(t)) for t in cands]
+        elif kf is not None:
+            frames = [sample_frame_at(vr, float(kf))]
+        else:
+            frames = sample_scene_frames_multi(vr, st, et, target_fps=target_fps)
         if not frames:
             continue
 
@@ -218,22 +257,15 @@ def main():
         if feats.ndim == 1:
             feats = feats.unsqueeze(0)
 
-        if D is None:
-            D = int(feats.shape[1])
-
         # Average frame embeddings to a single scene vector
         vec = feats.mean(dim=0, keepdim=True)[0]
-        results.append({"scene_index": si, "vector": to_list(vec)})
+        writer.add(si, to_list(vec))
 
-    if not results:
+    if writer.count == 0:
         print(json.dumps({"error": "no valid scenes to process"}))
         return
 
-    print(json.dumps({
-        "model": f"{backend}:{model_id}",
-        "embedding_dim": D if D is not None else 0,
-        "vectors": results,
-    }))
+    writer.finish(f"{backend}:{model_id}")
 
 
 if __name__ == "__main__":
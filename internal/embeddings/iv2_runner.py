@@ -13,6 +13,8 @@ from transformers import AutoModel
 from decord import VideoReader, cpu
 import contextlib
 
+from runner_io import VectorWriter
+
 """
 iv2_runner.py
 - Loads InternVideo2 and generates per-scene visual embeddings.
@@ -32,6 +34,10 @@ OUTPUT (to STDOUT, JSON on success):
   "embedding_dim": <int>,
   "vectors": [ {"scene_index": 0, "vector": [ ... ]}, ... ]
 }
+With "stream": true, vectors are written as JSON lines as they are computed (see runner_io.py).
+
+Text mode ({"mode": "text", "texts": [...]}, "iv2" backend only) embeds queries with InternVideo2's
+text encoder, aligned with its video features; vectors are keyed by "index" (position in "texts").
 """
 
 CLIP_MEAN = [0.48145466, 0.4578275, 0.40821073]
@@ -141,10 +147,59 @@ def extract_scene_frames(vr: VideoReader, fps: float, start: float, end: float,
     return frames
 
 
+def embed_texts(payload) -> None:
+    texts = payload.get("texts")
+    if not texts and payload.get("text"):
+        texts = [payload["text"]]
+    if not texts:
+        print(json.dumps({"error": "missing 'text' or 'texts' in payload"}))
+        return
+    if payload.get("backend", "iv2") != "iv2":
+        print(json.dumps({"error": f"backend {payload.get('backend')} has no text encoder aligned with its video features"}))
+        return
+    model_id = payload.get("model_id", "OpenGVLab/InternVideo2-Stage2_1B-224p-f4")
+    device = payload.get("device", "cuda:0")
+    use_cuda = device.startswith("cuda") and torch.cuda.is_available()
+    torch_device = torch.device(device if use_cuda else "cpu")
+    try:
+        hf_token = os.environ.get("HUGGINGFACE_HUB_TOKEN") or os.environ.get("HF_TOKEN")
+        with contextlib.redirect_stdout(sys.stderr):
+            model = AutoModel.from_pretrained(model_id, trust_remote_code=True, token=hf_token)
+    except Exception as e:
+        print(json.dumps({"error": f"failed to load model: {e}"}))
+        return
+    model.eval().to(torch_device)
+    get_txt_feat = getattr(model, "get_txt_feat", None)
+    if get_txt_feat is None:
+        print(json.dumps({"error": "model does not expose get_txt_feat"}))
+        return
+
+    writer = VectorWriter(payload, key="index")
+    for i, text in enumerate(texts):
+        try:
+            with torch.no_grad():
+                feat = get_txt_feat(str(text))
+        except Exception as e:
+            writer.error(f"text inference failed: {e}")
+            return
+        if isinstance(feat, (list, tuple)):
+            feat = feat[0]
+        vec = feat.detach().cpu().numpy().astype(float).reshape(-1)
+        norm = np.linalg.norm(vec)
+        if norm > 0:
+            vec = vec / norm
+        writer.add(i, vec.tolist())
+    writer.finish(model_id)
+
+
 def main():
+    writer = None
     try:
         raw = sys.stdin.read()
         payload = json.loads(raw)
+        if payload.get("mode") == "text":
+            embed_texts(payload)
+            return
         video_path = payload.get("video_path")
         scenes = payload.get("scenes", [])
         sampling = payload.get("sampling", {})
@@ -184,8 +239,7 @@ def main():
         if math.isfinite(fps) is False or fps <= 0:
             fps = 30.0
 
-        results = []
-        embedding_dim = None
+        writer = VectorWriter(payload)
 
         if backend == "internvl35":
             # Use InternVL3.5 vision encoder per-frame, average to scene vector
@@ -207,9 +261,7 @@ def main():
                     out = vm(pixel_values=x, output_hidden_states=False, return_dict=True)
                     feats = out.pooler_output  # (T, D)
                     scene_vec = feats.mean(dim=0, keepdim=True).detach().cpu().numpy()[0]
-                if embedding_dim is None:
-                    embedding_dim = int(scene_vec.shape[0])
-                results.append({"scene_index": si, "vector": scene_vec.astype(float).tolist()})
+                writer.add(si, scene_vec.astype(float).tolist())
         else:
             # Default IV2 path using get_vid_feat
             tensors = []
@@ -249,20 +301,16 @@ def main():
             vecs = feat.numpy()
             if vecs.ndim == 1:
                 vecs = vecs[None, :]
-            embedding_dim = int(vecs.shape[1])
             for i, si in enumerate(scene_indices):
-                results.append({
-                    "scene_index": int(si),
-                    "vector": vecs[i].astype(float).tolist(),
-                })
-
-        print(json.dumps({
-            "model": model_id,
-            "embedding_dim": embedding_dim,
-            "vectors": results,
-        }))
+                writer.add(si, vecs[i].astype(float).tolist())
+
+        writer.finish(model_id)
     except Exception as e:
-        print(json.dumps({"error": f"runner exception: {e}"}))
+        # Vectors already written stay valid; in binary mode the error must be a frame
+        if writer is not None:
+            writer.error(f"runner exception: {e}")
+        else:
+            print(json.dumps({"error": f"runner exception: {e}"}))
 
 
 if __name__ == "__main__":
@@ -0,0 +1,84 @@
+#!/usr/bin/env python3
+import sys
+import json
+import os
+from typing import List
+
+import torch
+from transformers import pipeline
+import contextlib
+
+# Model labels mapped onto the entity types stored per scene
+ENTITY_TYPES = {
+    "PER": "person",
+    "LOC": "place",
+    "ORG": "organization",
+    "MISC": "misc",
+}
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"error": f"invalid json input: {e}"}))
+        return
+
+    texts: List[str] = []
+    if "texts" in payload and isinstance(payload["texts"], list):
+        texts = [str(t) for t in payload["texts"]]
+    elif "text" in payload:
+        texts = [str(payload["text"])]
+    else:
+        print(json.dumps({"error": "missing 'text' or 'texts' in payload"}))
+        return
+
+    model_id = payload.get("model_id") or os.environ.get("NER_MODEL_ID", "dslim/bert-base-NER")
+    device = os.environ.get("NER_DEVICE") or ("cuda" if torch.cuda.is_available() else "cpu")
+
+    try:
+        # keep stdout clean for JSON only
+        with contextlib.redirect_stdout(sys.stderr):
+            ner = pipeline(
+                "token-classification",
+                model=model_id,
+                aggregation_strategy="simple",
+                device=0 if device == "cuda" else -1,
+            )
+    except Exception as e:
+        print(json.dumps({"error": f"failed to load model: {e}"}))
+        return
+
+    try:
+        batch_size = int(os.environ.get("NER_BATCH_SIZE", "32"))
+        if batch_size <= 0:
+            batch_size = 32
+    except Exception:
+        batch_size = 32
+
+    results = []
+    try:
+        for i in range(0, len(texts), batch_size):
+            batch = texts[i : i + batch_size]
+            # Empty strings trip the tokenizer; they simply have no entities
+            outputs = ner([t if t.strip() else "." for t in batch])
+            for text, found in zip(batch, outputs):
+                ents = []
+                if text.strip():
+                    for ent in found:
+                        label = ENTITY_TYPES.get(str(ent.get("entity_group", "")).upper())
+                        word = str(ent.get("word", "")).strip()
+                        if not label or not word or word.startswith("##"):
+                            continue
+                        ents.append({"text": word, "type": label, "score": float(ent.get("score", 0.0))})
+                results.append(ents)
+    except Exception as e:
+        print(json.dumps({"error": f"failed to extract entities: {e}"}))
+        return
+
+    print(json.dumps({"model": model_id, "entities": results}))
+
+
+if __name__ == "__main__":
+    main()
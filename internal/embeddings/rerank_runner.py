@@ -0,0 +1,70 @@
+#!/usr/bin/env python3
+import sys
+import json
+import os
+from typing import List
+
+import torch
+from transformers import AutoTokenizer, AutoModelForSequenceClassification
+import contextlib
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"error": f"invalid json input: {e}"}))
+        return
+
+    query = str(payload.get("query", "")).strip()
+    if not query:
+        print(json.dumps({"error": "missing 'query' in payload"}))
+        return
+    passages = payload.get("passages")
+    if not isinstance(passages, list):
+        print(json.dumps({"error": "missing 'passages' in payload"}))
+        return
+    passages = [str(p) for p in passages]
+
+    model_id = payload.get("model_id") or os.environ.get("RERANK_MODEL_ID", "BAAI/bge-reranker-base")
+
+    try:
+        # keep stdout clean for JSON only
+        with contextlib.redirect_stdout(sys.stderr):
+            tokenizer = AutoTokenizer.from_pretrained(model_id)
+            model = AutoModelForSequenceClassification.from_pretrained(model_id)
+    except Exception as e:
+        print(json.dumps({"error": f"failed to load model: {e}"}))
+        return
+
+    device = os.environ.get("RERANK_DEVICE") or ("cuda" if torch.cuda.is_available() else "cpu")
+    model.to(device)
+    model.eval()
+
+    try:
+        batch_size = int(os.environ.get("RERANK_BATCH_SIZE", "16"))
+        if batch_size <= 0:
+            batch_size = 16
+    except Exception:
+        batch_size = 16
+
+    scores: List[float] = []
+    try:
+        for i in range(0, len(passages), batch_size):
+            batch = passages[i : i + batch_size]
+            enc = tokenizer([query] * len(batch), batch, return_tensors="pt", padding=True, truncation=True, max_length=512)
+            enc = {k: v.to(device) for k, v in enc.items()}
+            with torch.no_grad():
+                logits = model(**enc).logits.view(-1).float()
+            # Relevance probability in [0, 1]
+            scores.extend(torch.sigmoid(logits).cpu().tolist())
+    except Exception as e:
+        print(json.dumps({"error": f"failed to score passages: {e}"}))
+        return
+
+    print(json.dumps({"model": model_id, "scores": scores}))
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,73 @@
+#!/usr/bin/env python3
+import importlib
+import json
+import sys
+import time
+from typing import Any, Dict, List
+
+"""
+runner_health.py
+- Cheap health probe for an embedding runner's dependencies, used by the worker's circuit breaker
+  to decide whether a runner that kept failing can take jobs again. It loads no model.
+
+Input (stdin JSON):
+{
+  "device": "cuda:0",            # optional; "" or "auto" uses CUDA only when it is available
+  "modules": ["transformers"]    # optional Python modules the runner imports
+}
+
+Output (stdout JSON):
+{"ok": true, "device": "cuda:0", "torch": "2.3.0", "elapsed_ms": 812}
+{"ok": false, "error": "CUDA device cuda:0 not available"}
+"""
+
+
+def probe(payload: Dict[str, Any]) -> Dict[str, Any]:
+    started = time.time()
+    modules: List[str] = [str(m) for m in payload.get("modules") or []]
+    for name in modules:
+        try:
+            importlib.import_module(name)
+        except Exception as e:
+            return {"ok": False, "error": f"failed to import {name}: {e}"}
+
+    import torch
+
+    device = str(payload.get("device") or "auto")
+    if device == "auto":
+        device = "cuda" if torch.cuda.is_available() else "cpu"
+    if device.startswith("cuda") and not torch.cuda.is_available():
+        return {"ok": False, "error": f"CUDA device {device} not available"}
+
+    # A small matmul on the device catches drivers that report a device but cannot run kernels
+    x = torch.ones(64, 64, device=device)
+    y = (x @ x).sum().item()
+    if y != 64.0 * 64 * 64:
+        return {"ok": False, "error": f"unexpected result on {device}: {y}"}
+    return {
+        "ok": True,
+        "device": device,
+        "torch": torch.__version__,
+        "elapsed_ms": int((time.time() - started) * 1000),
+    }
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"ok": False, "error": f"invalid json input: {e}"}))
+        sys.exit(1)
+
+    try:
+        result = probe(payload)
+    except Exception as e:
+        result = {"ok": False, "error": str(e)}
+    print(json.dumps(result))
+    if not result.get("ok"):
+        sys.exit(1)
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,90 @@
+#!/usr/bin/env python3
+import json
+import struct
+import sys
+import zlib
+from typing import Any, Dict, List, Optional
+
+import numpy as np
+
+"""
+runner_io.py
+- Output helper shared by the embedding runners that return one vector per scene or text.
+
+With "stream": true in the payload, each vector is written as a JSON line as soon as it is
+computed, so the caller persists vectors and reports progress while the runner works, and neither
+side holds the whole result in memory:
+
+  {"scene_index": 0, "vector": [ ... ]}      ("index" for text batches: position in "texts")
+  ...
+  {"model": "...", "embedding_dim": 512, "count": 120, "done": true}
+
+An {"error": "..."} line ends the stream early; vectors written before it are valid. Without
+"stream" the runner prints a single JSON object at the end, as before.
+
+With "transport": "binary" (implies "stream") the same records are written as binary frames,
+all integers and floats little-endian, after the 6-byte magic b"GCVEC\\x01":
+
+  b"V" int32 index, uint32 dim, dim x float32, uint32 crc32(index..vector)
+  b"D" uint32 count, uint32 dim, uint16 len, model (utf-8), uint32 crc32(count..model)
+  b"E" uint32 len, message (utf-8)
+
+Errors reported before the first frame are still a JSON line, so the caller checks the first
+byte of the output.
+"""
+
+BINARY_MAGIC = b"GCVEC\x01"
+
+
+class VectorWriter:
+    def __init__(self, payload: Dict[str, Any], key: str = "scene_index"):
+        self.binary = payload.get("transport") == "binary"
+        self.stream = self.binary or bool(payload.get("stream"))
+        self.key = key
+        self.results: List[Dict[str, Any]] = []
+        self.count = 0
+        self.dim: Optional[int] = None
+        self.started = False
+
+    def _frame(self, kind: bytes, body: bytes, checksum: bool = True) -> None:
+        out = sys.stdout.buffer
+        if not self.started:
+            out.write(BINARY_MAGIC)
+            self.started = True
+        out.write(kind + body)
+        if checksum:
+            out.write(struct.pack("<I", zlib.crc32(body) & 0xFFFFFFFF))
+        out.flush()
+
+    def add(self, index: int, vector) -> None:
+        if self.dim is None:
+            self.dim = len(vector)
+        self.count += 1
+        if self.binary:
+            data = np.asarray(vector, dtype="<f4")
+            self._frame(b"V", struct.pack("<iI", int(index), data.shape[0]) + data.tobytes())
+            return
+        record = {self.key: int(index), "vector": vector}
+        if self.stream:
+            sys.stdout.write(json.dumps(record) + "\n")
+            sys.stdout.flush()
+        else:
+            self.results.append(record)
+
+    def finish(self, model: str) -> None:
+        dim = self.dim or 0
+        if self.binary:
+            name = model.encode("utf-8")
+            self._frame(b"D", struct.pack("<IIH", self.count, dim, len(name)) + name)
+        elif self.stream:
+            print(json.dumps({"model": model, "embedding_dim": dim, "count": self.count, "done": True}), flush=True)
+        else:
+            print(json.dumps({"model": model, "embedding_dim": dim, "vectors": self.results}))
+
+    def error(self, message: str) -> None:
+        """Reports a failure; vectors already written stay valid."""
+        if self.binary and self.started:
+            msg = message.encode("utf-8")
+            self._frame(b"E", struct.pack("<I", len(msg)) + msg, checksum=False)
+            return
+        print(json.dumps({"error": message}), flush=True)
@@ -9,6 +9,8 @@ import numpy as np
 from transformers import AutoTokenizer, AutoModel
 import contextlib
 
+from runner_io import VectorWriter
+
 
 def mean_pooling(token_embeddings: torch.Tensor, attention_mask: torch.Tensor) -> torch.Tensor:
     # token_embeddings: [batch, seq, hidden]
@@ -54,7 +56,7 @@ def main():
     prefix = prefixes.get(mode, "query: ")
     texts = [prefix + t for t in texts]
 
-    model_id = os.environ.get("E5_MODEL_ID", "intfloat/e5-base-v2")
+    model_id = payload.get("model_id") or os.environ.get("E5_MODEL_ID", "intfloat/e5-base-v2")
 
     try:
         # keep stdout clean for JSON only
@@ -77,6 +79,8 @@ def main():
     except Exception:
         batch_size = 64
 
+    # Streamed batches are written as they are computed instead of collected
+    writer = VectorWriter(payload, key="index")
     all_embs: List[List[float]] = []
     try:
         for i in range(0, len(texts), batch_size):
@@ -94,9 +98,17 @@ def main():
                 token_embeddings = out.last_hidden_state  # [b, s, h]
                 pooled = mean_pooling(token_embeddings, enc["attention_mask"])  # [b, h]
                 normed = normalize_l2(pooled)
-            all_embs.extend(to_python_floats(normed))
+            if writer.stream:
+                for j, vec in enumerate(to_python_floats(normed)):
+                    writer.add(i + j, vec)
+            else:
+                all_embs.extend(to_python_floats(normed))
     except Exception as e:
-        print(json.dumps({"error": f"failed to compute embeddings: {e}"}))
+        writer.error(f"failed to compute embeddings: {e}")
+        return
+
+    if writer.stream:
+        writer.finish(model_id)
         return
 
     if not all_embs:
@@ -0,0 +1,129 @@
+#!/usr/bin/env python3
+import sys
+import json
+import os
+from typing import Dict, List
+
+import torch
+import librosa
+from transformers import pipeline
+import contextlib
+
+# Speech emotion labels mapped onto the caption emotion vocabulary
+AUDIO_EMOTIONS = {
+    "neu": "neutral",
+    "hap": "joy",
+    "ang": "anger",
+    "sad": "sadness",
+}
+
+
+def batch_size() -> int:
+    try:
+        n = int(os.environ.get("TONE_BATCH_SIZE", "16"))
+        return n if n > 0 else 16
+    except Exception:
+        return 16
+
+
+def load(task: str, model_id: str, device: str):
+    # keep stdout clean for JSON only
+    with contextlib.redirect_stdout(sys.stderr):
+        return pipeline(task, model=model_id, top_k=None, device=0 if device == "cuda" else -1)
+
+
+def scores_of(found) -> Dict[str, float]:
+    return {str(r.get("label", "")).lower(): float(r.get("score", 0.0)) for r in found}
+
+
+def score_texts(payload, device: str):
+    texts: List[str] = []
+    if "texts" in payload and isinstance(payload["texts"], list):
+        texts = [str(t) for t in payload["texts"]]
+    elif "text" in payload:
+        texts = [str(payload["text"])]
+    else:
+        return {"error": "missing 'text' or 'texts' in payload"}
+
+    sentiment_id = os.environ.get("SENTIMENT_MODEL_ID", "cardiffnlp/twitter-roberta-base-sentiment-latest")
+    emotion_id = os.environ.get("EMOTION_MODEL_ID", "j-hartmann/emotion-english-distilroberta-base")
+    try:
+        sentiment = load("text-classification", sentiment_id, device)
+        emotion = load("text-classification", emotion_id, device)
+    except Exception as e:
+        return {"error": f"failed to load model: {e}"}
+
+    results = []
+    n = batch_size()
+    try:
+        for i in range(0, len(texts), n):
+            batch = [t if t.strip() else "." for t in texts[i : i + n]]
+            sents = sentiment(batch, truncation=True)
+            emos = emotion(batch, truncation=True)
+            for s, e in zip(sents, emos):
+                s = scores_of(s)
+                # Positive minus negative probability, in [-1, 1]
+                results.append({
+                    "sentiment": s.get("positive", 0.0) - s.get("negative", 0.0),
+                    "emotions": scores_of(e),
+                })
+    except Exception as e:
+        return {"error": f"failed to score texts: {e}"}
+    return {"model": emotion_id, "results": results}
+
+
+def score_audio(payload, device: str):
+    video_path = payload.get("video_path")
+    scenes = payload.get("scenes", [])
+    if not video_path or not isinstance(scenes, list) or len(scenes) == 0:
+        return {"error": "invalid input: video_path and scenes are required"}
+
+    model_id = os.environ.get("AUDIO_EMOTION_MODEL_ID", "superb/wav2vec2-base-superb-er")
+    try:
+        classifier = load("audio-classification", model_id, device)
+    except Exception as e:
+        return {"error": f"failed to load model: {e}"}
+
+    # Long scenes are scored on their first seconds only
+    max_secs = float(os.environ.get("TONE_AUDIO_MAX_SECS", "30"))
+    results = []
+    for s in scenes:
+        try:
+            si = int(s.get("scene_index"))
+            st = float(s.get("start", 0.0))
+            dur = min(max(0.1, float(s.get("end", st + 0.1)) - st), max_secs)
+        except Exception:
+            continue
+        try:
+            y, sr = librosa.load(video_path, sr=16000, mono=True, offset=st, duration=dur)
+            if y is None or y.size < sr // 2:
+                continue
+            found = classifier({"raw": y, "sampling_rate": sr})
+        except Exception:
+            # skip this scene on decode or model error
+            continue
+        emotions = {}
+        for label, score in scores_of(found).items():
+            name = AUDIO_EMOTIONS.get(label, label)
+            emotions[name] = emotions.get(name, 0.0) + score
+        results.append({"scene_index": si, "emotions": emotions})
+    return {"model": model_id, "results": results}
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"error": f"invalid json input: {e}"}))
+        return
+
+    device = os.environ.get("TONE_DEVICE") or ("cuda" if torch.cuda.is_available() else "cpu")
+    if payload.get("mode", "text") == "audio":
+        print(json.dumps(score_audio(payload, device)))
+    else:
+        print(json.dumps(score_texts(payload, device)))
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,91 @@
+#!/usr/bin/env python3
+import sys
+import json
+import os
+from typing import List
+
+import torch
+from transformers import M2M100ForConditionalGeneration, M2M100Tokenizer
+import contextlib
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"error": f"invalid json input: {e}"}))
+        return
+
+    texts: List[str] = []
+    if "texts" in payload and isinstance(payload["texts"], list):
+        texts = [str(t) for t in payload["texts"]]
+    elif "text" in payload:
+        texts = [str(payload["text"])]
+    else:
+        print(json.dumps({"error": "missing 'text' or 'texts' in payload"}))
+        return
+
+    source_lang = str(payload.get("source_lang", "")).strip().lower()
+    target_lang = str(payload.get("target_lang", "en")).strip().lower() or "en"
+    if not source_lang:
+        print(json.dumps({"error": "missing 'source_lang' in payload"}))
+        return
+
+    model_id = os.environ.get("TRANSLATE_MODEL_ID", "facebook/m2m100_418M")
+
+    try:
+        # keep stdout clean for JSON only
+        with contextlib.redirect_stdout(sys.stderr):
+            tokenizer = M2M100Tokenizer.from_pretrained(model_id)
+            model = M2M100ForConditionalGeneration.from_pretrained(model_id)
+    except Exception as e:
+        print(json.dumps({"error": f"failed to load model: {e}"}))
+        return
+
+    device = os.environ.get("TRANSLATE_DEVICE") or ("cuda" if torch.cuda.is_available() else "cpu")
+    model.to(device)
+    model.eval()
+
+    try:
+        tokenizer.src_lang = source_lang
+        target_id = tokenizer.get_lang_id(target_lang)
+    except Exception as e:
+        print(json.dumps({"error": f"unsupported language pair {source_lang}->{target_lang}: {e}"}))
+        return
+
+    try:
+        batch_size = int(os.environ.get("TRANSLATE_BATCH_SIZE", "16"))
+        if batch_size <= 0:
+            batch_size = 16
+    except Exception:
+        batch_size = 16
+
+    translations: List[str] = []
+    try:
+        for i in range(0, len(texts), batch_size):
+            batch = texts[i : i + batch_size]
+            enc = tokenizer(batch, return_tensors="pt", padding=True, truncation=True, max_length=512)
+            enc = {k: v.to(device) for k, v in enc.items()}
+            with torch.no_grad():
+                generated = model.generate(**enc, forced_bos_token_id=target_id, max_new_tokens=512)
+            translations.extend(tokenizer.batch_decode(generated, skip_special_tokens=True))
+    except Exception as e:
+        print(json.dumps({"error": f"failed to translate: {e}"}))
+        return
+
+    result = {
+        "model": model_id,
+        "source_lang": source_lang,
+        "target_lang": target_lang,
+    }
+    if len(translations) == 1:
+        result["translation"] = translations[0]
+    else:
+        result["translations"] = translations
+
+    print(json.dumps(result))
+
+
+if __name__ == "__main__":
+    main()
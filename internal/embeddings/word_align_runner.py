@@ -0,0 +1,147 @@
+#!/usr/bin/env python3
+import sys
+import json
+import os
+import re
+from typing import List, Tuple
+
+import torch
+import torchaudio
+import librosa
+import contextlib
+
+SAMPLE_RATE = 16000
+# The MMS aligner's dictionary is lowercase romanized letters and the apostrophe
+NON_ALIGNABLE = re.compile(r"[^a-z']")
+
+
+def env_float(name: str, default: float) -> float:
+    try:
+        v = float(os.environ.get(name, str(default)))
+        return v if v >= 0 else default
+    except Exception:
+        return default
+
+
+def alignable(word: str) -> str:
+    return NON_ALIGNABLE.sub("", word.lower())
+
+
+def proportional(words: List[str], start: float, end: float) -> List[Tuple[float, float]]:
+    # Spread words over [start, end] by their length
+    weights = [max(1, len(w)) for w in words]
+    total = float(sum(weights))
+    out, t = [], start
+    for w in weights:
+        d = (end - start) * w / total
+        out.append((t, t + d))
+        t += d
+    return out
+
+
+def fill_gaps(words, timings, aligned, start: float, end: float):
+    # Words that could not be aligned share the gap between their aligned neighbours
+    i = 0
+    while i < len(words):
+        if aligned[i]:
+            i += 1
+            continue
+        j = i
+        while j < len(words) and not aligned[j]:
+            j += 1
+        lo = timings[i - 1][1] if i > 0 else start
+        hi = timings[j][0] if j < len(words) else end
+        timings[i:j] = proportional(words[i:j], lo, max(lo, hi))
+        i = j
+
+
+def align_caption(bundle, audio, audio_start: float, cap, device: str):
+    words = [str(w) for w in cap.get("words", [])]
+    start, end = float(cap.get("start", 0.0)), float(cap.get("end", 0.0))
+    timings = [(start, start)] * len(words)
+    scores = [0.0] * len(words)
+    aligned = [False] * len(words)
+    model, tokenizer, aligner = bundle
+
+    idx = [i for i, w in enumerate(words) if alignable(w)]
+    pad = env_float("WORD_ALIGN_PAD_SECS", 0.25)
+    lo = max(audio_start, start - pad)
+    a = int((lo - audio_start) * SAMPLE_RATE)
+    b = int((end + pad - audio_start) * SAMPLE_RATE)
+    if idx and end > start and b > a:
+        try:
+            waveform = torch.from_numpy(audio[a:b]).unsqueeze(0).to(device)
+            with torch.inference_mode():
+                emission, _ = model(waveform)
+            spans = aligner(emission[0], tokenizer([alignable(words[i]) for i in idx]))
+            ratio = waveform.size(1) / emission.size(1) / SAMPLE_RATE
+            for i, sp in zip(idx, spans):
+                timings[i] = (lo + sp[0].start * ratio, lo + sp[-1].end * ratio)
+                frames = sum(len(s) for s in sp)
+                scores[i] = sum(s.score * len(s) for s in sp) / frames if frames else 0.0
+                aligned[i] = True
+        except Exception:
+            # audio shorter than the transcript or a model error: fall back to interpolation
+            aligned = [False] * len(words)
+    fill_gaps(words, timings, aligned, start, end)
+    return {
+        "caption_id": cap.get("caption_id"),
+        "words": [
+            {"start": t[0], "end": t[1], "score": s, "aligned": al}
+            for t, s, al in zip(timings, scores, aligned)
+        ],
+    }
+
+
+def align(payload, device: str):
+    video_path = payload.get("video_path")
+    captions = payload.get("captions", [])
+    if not video_path or not isinstance(captions, list):
+        return {"error": "invalid input: video_path and captions are required"}
+
+    try:
+        with contextlib.redirect_stdout(sys.stderr):
+            fa = torchaudio.pipelines.MMS_FA
+            model = fa.get_model(with_star=False).to(device)
+            bundle = (model, fa.get_tokenizer(), fa.get_aligner())
+    except Exception as e:
+        return {"error": f"failed to load model: {e}"}
+
+    # Audio is decoded a chunk of captions at a time instead of per caption or all at once
+    chunk = max(30.0, env_float("WORD_ALIGN_CHUNK_SECS", 600.0))
+    pad = env_float("WORD_ALIGN_PAD_SECS", 0.25)
+    captions = sorted(captions, key=lambda c: float(c.get("start", 0.0)))
+    results = []
+    i = 0
+    while i < len(captions):
+        first = float(captions[i].get("start", 0.0))
+        j = i
+        while j < len(captions) and float(captions[j].get("start", 0.0)) < first + chunk:
+            j += 1
+        group = captions[i:j]
+        audio_start = max(0.0, first - pad)
+        audio_end = max(float(c.get("end", 0.0)) for c in group) + pad
+        try:
+            audio, _ = librosa.load(video_path, sr=SAMPLE_RATE, mono=True, offset=audio_start, duration=audio_end - audio_start)
+        except Exception as e:
+            return {"error": f"failed to decode audio: {e}"}
+        for cap in group:
+            results.append(align_caption(bundle, audio, audio_start, cap, device))
+        i = j
+    return {"model": "MMS_FA", "results": results}
+
+
+def main():
+    try:
+        raw = sys.stdin.read()
+        payload = json.loads(raw) if raw.strip() else {}
+    except Exception as e:
+        print(json.dumps({"error": f"invalid json input: {e}"}))
+        return
+
+    device = os.environ.get("WORD_ALIGN_DEVICE") or ("cuda" if torch.cuda.is_available() else "cpu")
+    print(json.dumps(align(payload, device)))
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,137 @@
+package errorreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// panicsTotal counts recovered panics per component ("http", "worker"), exported via expvar
+var panicsTotal = expvar.NewMap("panics_total")
+
+// Report is a structured description of a recovered panic
+type Report struct {
+	Component string         `json:"component"`
+	Message   string         `json:"message"`
+	Stack     string         `json:"stack"`
+	Context   map[string]any `json:"context,omitempty"`
+	Time      time.Time      `json:"time"`
+}
+
+// Reporter forwards reports to an external error tracker
+type Reporter interface {
+	Send(r Report) error
+}
+
+var reporter Reporter
+
+// Init configures the external reporter from SENTRY_DSN (no-op when unset)
+func Init(service string) error {
+	dsn := os.Getenv("SENTRY_DSN")
+	if dsn == "" {
+		return nil
+	}
+	s, err := newSentry(dsn, service)
+	if err != nil {
+		return err
+	}
+	reporter = s
+	return nil
+}
+
+// CapturePanic records a recovered value: it logs the report with its stack, increments the
+// panic metric and forwards it to the external reporter in the background.
+func CapturePanic(component string, recovered any, context map[string]any) Report {
+	r := Report{
+		Component: component,
+		Message:   fmt.Sprint(recovered),
+		Stack:     string(debug.Stack()),
+		Context:   context,
+		Time:      time.Now().UTC(),
+	}
+	panicsTotal.Add(component, 1)
+	if b, err := json.Marshal(r); err == nil {
+		log.Printf("PANIC recovered: %s", b)
+	} else {
+		log.Printf("PANIC recovered in %s: %s\n%s", component, r.Message, r.Stack)
+	}
+	if reporter != nil {
+		go func() {
+			if err := reporter.Send(r); err != nil {
+				log.Printf("Warning: failed to send panic report: %v", err)
+			}
+		}()
+	}
+	return r
+}
+
+// sentry posts events to the Sentry store API using a DSN (https://<key>@<host>/<project>)
+type sentry struct {
+	endpoint string
+	key      string
+	service  string
+	client   *http.Client
+}
+
+func newSentry(dsn, service string) (*sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return nil, fmt.Errorf("invalid SENTRY_DSN")
+	}
+	project := strings.Trim(u.Path, "/")
+	if project == "" {
+		return nil, fmt.Errorf("invalid SENTRY_DSN: missing project id")
+	}
+	return &sentry{
+		endpoint: fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, project),
+		key:      u.User.Username(),
+		service:  service,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func (s *sentry) Send(r Report) error {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	event := map[string]any{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   r.Time.Format(time.RFC3339),
+		"level":       "fatal",
+		"platform":    "go",
+		"logger":      r.Component,
+		"server_name": s.service,
+		"message":     r.Message,
+		"tags":        map[string]string{"component": r.Component},
+		"extra":       map[string]any{"stack": r.Stack, "context": r.Context},
+		"environment": os.Getenv("SENTRY_ENVIRONMENT"),
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=goodclips/0.1, sentry_key=%s", s.key))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned %s", resp.Status)
+	}
+	return nil
+}
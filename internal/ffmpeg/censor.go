@@ -0,0 +1,65 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Audio censoring modes for flagged ranges
+const (
+	CensorNone  = ""
+	CensorMute  = "mute"
+	CensorBleep = "bleep"
+)
+
+// ValidCensorMode reports whether mode names a censoring mode (empty = none)
+func ValidCensorMode(mode string) bool {
+	return mode == CensorNone || mode == CensorMute || mode == CensorBleep
+}
+
+// RelativeRanges shifts absolute [Start, End) ranges into a cut starting at offset and lasting
+// duration seconds, dropping ranges outside it and clipping the rest
+func RelativeRanges(ranges []Interval, offset, duration float64) []Interval {
+	var out []Interval
+	for _, r := range ranges {
+		s, e := r.Start-offset, r.End-offset
+		if e <= 0 || s >= duration {
+			continue
+		}
+		if s < 0 {
+			s = 0
+		}
+		if e > duration {
+			e = duration
+		}
+		out = append(out, Interval{Start: s, End: e})
+	}
+	return out
+}
+
+// censorEnable is a filter timeline expression true inside any of the ranges
+func censorEnable(ranges []Interval) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = fmt.Sprintf("between(t,%.3f,%.3f)", r.Start, r.End)
+	}
+	return strings.Join(parts, "+")
+}
+
+// CensorAudioChain returns filtergraph chains that take the labelled audio in (e.g. "[0:a]"), silence
+// the ranges (relative to the start of the stream) and, for bleep, overlay a 1 kHz tone there,
+// producing the label out. tag keeps intermediate labels unique when several chains share a graph.
+// The stream is resampled to 48 kHz stereo. With no ranges or no mode the audio only passes through.
+func CensorAudioChain(in, out string, ranges []Interval, mode string, duration float64, tag string) string {
+	base := fmt.Sprintf("%saresample=48000,aformat=channel_layouts=stereo", in)
+	if len(ranges) == 0 || mode == CensorNone {
+		return base + out
+	}
+	enable := censorEnable(ranges)
+	muted := fmt.Sprintf("%s,volume=0:enable='%s'", base, enable)
+	if mode != CensorBleep {
+		return muted + out
+	}
+	return fmt.Sprintf("%s[cm%s];aevalsrc=exprs='0.25*sin(2*PI*1000*t)*gt(%s,0)|0.25*sin(2*PI*1000*t)*gt(%s,0)':s=48000:c=stereo:d=%.3f[cb%s];[cm%s][cb%s]amix=inputs=2:duration=first:normalize=0%s",
+		muted, tag, enable, enable, duration, tag, tag, tag, out)
+}
@@ -0,0 +1,55 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// mp4VideoCodecs and mp4AudioCodecs are the source codecs an MP4 can hold without re-encoding
+var (
+	mp4VideoCodecs = map[string]bool{"h264": true, "hevc": true, "mpeg4": true, "av1": true}
+	mp4AudioCodecs = map[string]bool{"aac": true, "mp3": true, "ac3": true, "eac3": true, "alac": true, "opus": true}
+)
+
+// CanStreamCopy reports whether the first video and audio streams of a probed file can be copied
+// into an MP4 as they are
+func CanStreamCopy(meta *FFprobeResult) bool {
+	var video, audio *Stream
+	for i := range meta.Streams {
+		s := &meta.Streams[i]
+		if s.CodecType == "video" && video == nil {
+			video = s
+		}
+		if s.CodecType == "audio" && audio == nil {
+			audio = s
+		}
+	}
+	if video == nil || !mp4VideoCodecs[video.CodecName] {
+		return false
+	}
+	return audio == nil || mp4AudioCodecs[audio.CodecName]
+}
+
+// CopyClip cuts [start, end) out of videoPath into an MP4 at outputPath without re-encoding. The clip
+// starts at the keyframe at or before start, so it may begin slightly early.
+func (f *FFmpegClient) CopyClip(videoPath, outputPath string, start, end float64) error {
+	if end <= start {
+		return fmt.Errorf("invalid clip range %.3f-%.3f", start, end)
+	}
+	args := []string{
+		"-y",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", end-start),
+		"-i", videoPath,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-c", "copy",
+		"-avoid_negative_ts", "make_zero",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+	var stderr bytes.Buffer
+	if err := f.runWithProgress(args, end-start, &stderr); err != nil {
+		return fmt.Errorf("ffmpeg failed to copy clip: %v, stderr: %s", err, stderr.String())
+	}
+	return nil
+}
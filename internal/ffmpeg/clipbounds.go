@@ -0,0 +1,175 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Clip edge snapping modes
+const (
+	SnapNone     = "none"
+	SnapCaptions = "captions"
+	SnapSilence  = "silence"
+)
+
+// Interval is a [Start, End) time range in seconds
+type Interval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// ClipBoundsOptions controls how exported clip edges are placed around a scene
+type ClipBoundsOptions struct {
+	PadBefore float64 `json:"pad_before"`
+	PadAfter  float64 `json:"pad_after"`
+	// Snap moves edges outward to caption boundaries or into silence (none, captions, silence)
+	Snap string `json:"snap"`
+	// MaxSnapShift bounds how far snapping may move an edge beyond the padded position
+	MaxSnapShift float64 `json:"max_snap_shift"`
+}
+
+// ClipBounds are the refined edges of a clip
+type ClipBounds struct {
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	SnappedStart bool    `json:"snapped_start"`
+	SnappedEnd   bool    `json:"snapped_end"`
+}
+
+// ClipBoundsOptionsFromEnv reads CLIP_PAD_BEFORE_SECS and CLIP_PAD_AFTER_SECS (default 0.5),
+// CLIP_SNAP (default none) and CLIP_MAX_SNAP_SECS (default 1.5)
+func ClipBoundsOptionsFromEnv() ClipBoundsOptions {
+	opts := ClipBoundsOptions{PadBefore: 0.5, PadAfter: 0.5, Snap: SnapNone, MaxSnapShift: 1.5}
+	envSeconds := func(name string, dst *float64) {
+		if v := os.Getenv(name); v != "" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+				*dst = f
+			}
+		}
+	}
+	envSeconds("CLIP_PAD_BEFORE_SECS", &opts.PadBefore)
+	envSeconds("CLIP_PAD_AFTER_SECS", &opts.PadAfter)
+	envSeconds("CLIP_MAX_SNAP_SECS", &opts.MaxSnapShift)
+	if v := os.Getenv("CLIP_SNAP"); ValidSnapMode(v) {
+		opts.Snap = v
+	}
+	return opts
+}
+
+// ValidSnapMode reports whether mode names a snapping mode
+func ValidSnapMode(mode string) bool {
+	return mode == SnapNone || mode == SnapCaptions || mode == SnapSilence
+}
+
+// RefineClipBounds pads [start, end) and then snaps its edges outward, never by more than
+// MaxSnapShift: with captions, an edge that cuts through a caption moves to that caption's boundary;
+// with silence, an edge inside speech moves to the nearest silent point. guides holds the caption or
+// silence intervals for the selected mode. The result is clamped to [0, duration] when duration > 0.
+func RefineClipBounds(start, end, duration float64, opts ClipBoundsOptions, guides []Interval) ClipBounds {
+	b := ClipBounds{Start: start - opts.PadBefore, End: end + opts.PadAfter}
+	switch opts.Snap {
+	case SnapCaptions:
+		for _, g := range guides {
+			if g.Start < b.Start && b.Start < g.End && b.Start-g.Start <= opts.MaxSnapShift {
+				b.Start, b.SnappedStart = g.Start, true
+			}
+			if g.Start < b.End && b.End < g.End && g.End-b.End <= opts.MaxSnapShift {
+				b.End, b.SnappedEnd = g.End, true
+			}
+		}
+	case SnapSilence:
+		// latest silent point at or before the start, earliest at or after the end
+		bestStart, bestEnd := -1.0, -1.0
+		for _, g := range guides {
+			if g.Start <= b.Start {
+				if p := minFloat(g.End, b.Start); b.Start-p <= opts.MaxSnapShift && p > bestStart {
+					bestStart = p
+				}
+			}
+			if g.End >= b.End {
+				if p := maxFloat(g.Start, b.End); p-b.End <= opts.MaxSnapShift && (bestEnd < 0 || p < bestEnd) {
+					bestEnd = p
+				}
+			}
+		}
+		if bestStart >= 0 {
+			b.SnappedStart = bestStart != b.Start
+			b.Start = bestStart
+		}
+		if bestEnd >= 0 {
+			b.SnappedEnd = bestEnd != b.End
+			b.End = bestEnd
+		}
+	}
+	if b.Start < 0 {
+		b.Start = 0
+	}
+	if duration > 0 && b.End > duration {
+		b.End = duration
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+var (
+	silenceStartRe = regexp.MustCompile(`silence_start: (-?[0-9.]+)`)
+	silenceEndRe   = regexp.MustCompile(`silence_end: (-?[0-9.]+)`)
+)
+
+// DetectSilences returns the silent intervals of [start, end) of a file's audio, in absolute seconds.
+// noiseDB is the silence threshold (e.g. -35) and minDuration the shortest gap reported.
+func (f *FFmpegClient) DetectSilences(videoPath string, start, end, noiseDB, minDuration float64) ([]Interval, error) {
+	if end <= start {
+		return nil, fmt.Errorf("invalid range %.3f-%.3f", start, end)
+	}
+	cmd := exec.CommandContext(f.context(), f.ffmpegPath,
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", end-start),
+		"-i", videoPath,
+		"-vn",
+		"-af", fmt.Sprintf("silencedetect=noise=%.1fdB:d=%.2f", noiseDB, minDuration),
+		"-f", "null", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg silencedetect failed: %v, stderr: %s", err, stderr.String())
+	}
+
+	// silencedetect reports times relative to the seek point; an unterminated silence runs to the end
+	var silences []Interval
+	open := -1.0
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if m := silenceStartRe.FindStringSubmatch(line); m != nil {
+			if t, err := strconv.ParseFloat(m[1], 64); err == nil {
+				open = maxFloat(t, 0)
+			}
+		} else if m := silenceEndRe.FindStringSubmatch(line); m != nil && open >= 0 {
+			if t, err := strconv.ParseFloat(m[1], 64); err == nil {
+				silences = append(silences, Interval{Start: start + open, End: start + t})
+			}
+			open = -1
+		}
+	}
+	if open >= 0 {
+		silences = append(silences, Interval{Start: start + open, End: end})
+	}
+	return silences, nil
+}
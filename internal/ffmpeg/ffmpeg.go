@@ -2,11 +2,14 @@ package ffmpeg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // VideoMetadata represents basic video metadata
@@ -44,6 +47,11 @@ type FFprobeResult struct {
 type FFmpegClient struct {
 	ffprobePath string
 	ffmpegPath  string
+	// ctx kills running ffmpeg and ffprobe processes when done (see WithContext)
+	ctx context.Context
+	// progress receives the progress of exports, keyframe extractions and stream recordings (see
+	// WithProgress)
+	progress func(Progress)
 }
 
 // NewFFmpegClient creates a new FFmpeg client
@@ -54,10 +62,24 @@ func NewFFmpegClient() *FFmpegClient {
 	}
 }
 
+// WithContext returns a copy of the client whose ffmpeg and ffprobe processes are killed once ctx is done
+func (f *FFmpegClient) WithContext(ctx context.Context) *FFmpegClient {
+	c := *f
+	c.ctx = ctx
+	return &c
+}
+
+func (f *FFmpegClient) context() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
 // GetVideoMetadata extracts metadata from a video file
 func (f *FFmpegClient) GetVideoMetadata(videoPath string) (*FFprobeResult, error) {
 	// Build ffprobe command to get JSON metadata
-	cmd := exec.Command(f.ffprobePath,
+	cmd := exec.CommandContext(f.context(), f.ffprobePath,
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_format",
@@ -86,7 +108,7 @@ func (f *FFmpegClient) GetVideoMetadata(videoPath string) (*FFprobeResult, error
 
 // GetVideoDuration extracts just the duration from a video file
 func (f *FFmpegClient) GetVideoDuration(videoPath string) (float64, error) {
-	cmd := exec.Command(f.ffprobePath,
+	cmd := exec.CommandContext(f.context(), f.ffprobePath,
 		"-v", "quiet",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
@@ -111,6 +133,23 @@ func (f *FFmpegClient) GetVideoDuration(videoPath string) (float64, error) {
 	return duration, nil
 }
 
+// RecordStream copies up to seconds of an HLS/RTMP stream into an MPEG-TS file without re-encoding
+func (f *FFmpegClient) RecordStream(sourceURL, outputPath string, seconds float64) error {
+	args := []string{
+		"-y",
+		"-i", sourceURL,
+		"-t", fmt.Sprintf("%.3f", seconds),
+		"-c", "copy",
+		"-f", "mpegts",
+		outputPath}
+
+	var stderr bytes.Buffer
+	if err := f.runWithProgress(args, seconds, &stderr); err != nil {
+		return fmt.Errorf("ffmpeg failed to record stream: %v, stderr: %s", err, stderr.String())
+	}
+	return nil
+}
+
 // ExtractSubtitles extracts subtitles from a video file
 func (f *FFmpegClient) ExtractSubtitles(videoPath, outputPath string) error {
 	// First, check if there are subtitle streams
@@ -132,7 +171,7 @@ func (f *FFmpegClient) ExtractSubtitles(videoPath, outputPath string) error {
 	}
 
 	// Extract the first subtitle stream
-	cmd := exec.Command(f.ffmpegPath,
+	cmd := exec.CommandContext(f.context(), f.ffmpegPath,
 		"-i", videoPath,
 		"-map", fmt.Sprintf("0:s:%d", subtitleStreams[0]),
 		"-c:s", "srt",
@@ -207,7 +246,7 @@ func (f *FFmpegClient) ExtractSubtitlesToSRT(videoPath, outputPath string) error
 	}
 	best := subs[bestIdx]
 
-	cmd := exec.Command(f.ffmpegPath,
+	cmd := exec.CommandContext(f.context(), f.ffmpegPath,
 		"-y", // overwrite any existing SRT, including empty ones
 		"-i", videoPath,
 		"-map", fmt.Sprintf("0:s:%d", best.idx),
@@ -226,41 +265,54 @@ func (f *FFmpegClient) ExtractSubtitlesToSRT(videoPath, outputPath string) error
 	return nil
 }
 
-// ExtractKeyframes extracts keyframes from a video at specific intervals
-func (f *FFmpegClient) ExtractKeyframes(videoPath, outputDir string, interval int) error {
-	// Create a pattern for output files
-	outputPattern := fmt.Sprintf("%s/frame_%%04d.jpg", outputDir)
-	
-	cmd := exec.Command(f.ffmpegPath,
-		"-i", videoPath,
-		"-vf", fmt.Sprintf("fps=1/%d", interval),
-		"-q:v", "2",
-		outputPattern)
-
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("ffmpeg failed to extract keyframes: %v, stderr: %s", err, stderr.String())
+// ExtractKeyframes writes the frame at each of times (seconds) to the matching path of outputs, each
+// extraction killed after timeout. Frames that fail are logged and skipped; it returns how many were
+// written. With a progress callback (see WithProgress) progress runs across all the frames.
+func (f *FFmpegClient) ExtractKeyframes(videoPath string, times []float64, outputs []string, timeout time.Duration) int {
+	extracted := 0
+	for i, at := range times {
+		ctx, cancel := context.WithTimeout(f.context(), timeout)
+		c := *f
+		c.ctx = ctx
+		if f.progress != nil {
+			done := i
+			c.progress = func(p Progress) {
+				p.Percent = (float64(done)*100 + p.Percent) / float64(len(times))
+				p.Done = p.Done && done == len(times)-1
+				f.progress(p)
+			}
+		}
+		args := []string{
+			"-ss", fmt.Sprintf("%.2f", at),
+			"-i", videoPath,
+			"-vframes", "1",
+			"-q:v", "2",
+			"-y",
+			outputs[i],
+		}
+		var stderr bytes.Buffer
+		err := c.runWithProgress(args, 0, &stderr)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Failed to extract keyframe %d at %.2fs: %v\nOutput: %s", i, at, err, stderr.String())
+			continue
+		}
+		extracted++
 	}
-
-	return nil
+	return extracted
 }
 
 // CheckFFmpeg checks if FFmpeg and FFprobe are available
 func (f *FFmpegClient) CheckFFmpeg() error {
 	// Check ffprobe
-	cmd := exec.Command(f.ffprobePath, "-version")
+	cmd := exec.CommandContext(f.context(), f.ffprobePath, "-version")
 	err := cmd.Run()
 	if err != nil {
 		return fmt.Errorf("ffprobe not found: %v", err)
 	}
 
 	// Check ffmpeg
-	cmd = exec.Command(f.ffmpegPath, "-version")
+	cmd = exec.CommandContext(f.context(), f.ffmpegPath, "-version")
 	err = cmd.Run()
 	if err != nil {
 		return fmt.Errorf("ffmpeg not found: %v", err)
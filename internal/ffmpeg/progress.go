@@ -0,0 +1,144 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// progressBuffer is how many updates a ProgressRun holds for a slow reader before it drops the
+// oldest
+const progressBuffer = 16
+
+// Progress is one report of ffmpeg's -progress output
+type Progress struct {
+	Frame int64 `json:"frame"`
+	// OutTime is the seconds of output written so far
+	OutTime float64 `json:"out_time"`
+	// Speed is the encoding speed as a multiple of real time (0 until ffmpeg knows it)
+	Speed float64 `json:"speed"`
+	// Percent is OutTime as a share (0-100) of the expected output duration
+	Percent float64 `json:"percent"`
+	// Done is set on ffmpeg's last report
+	Done bool `json:"done"`
+}
+
+// ProgressRun is an ffmpeg process started by StartWithProgress
+type ProgressRun struct {
+	// Updates receives the process's progress and is closed once ffmpeg closed its output. A
+	// reader that falls behind misses the oldest updates, never the latest one.
+	Updates <-chan Progress
+	cmd     *exec.Cmd
+	parsed  chan struct{}
+}
+
+// StartWithProgress starts ffmpeg with args, expected to write total seconds of output (0 when
+// unknown, which leaves Percent at 0 until the end). ffmpeg writes its -progress report to stdout,
+// which is parsed into the run's Updates; stderr receives ffmpeg's log. Wait must be called to
+// release the process, whether or not Updates is read.
+func (f *FFmpegClient) StartWithProgress(args []string, total float64, stderr io.Writer) (*ProgressRun, error) {
+	cmd := exec.CommandContext(f.context(), f.ffmpegPath, append([]string{"-progress", "pipe:1", "-nostats"}, args...)...)
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	updates := make(chan Progress, progressBuffer)
+	run := &ProgressRun{Updates: updates, cmd: cmd, parsed: make(chan struct{})}
+	go func() {
+		defer close(run.parsed)
+		defer close(updates)
+		parseProgress(stdout, total, func(p Progress) {
+			for {
+				select {
+				case updates <- p:
+					return
+				default:
+				}
+				// Full: drop the oldest update to make room
+				select {
+				case <-updates:
+				default:
+				}
+			}
+		})
+	}()
+	return run, nil
+}
+
+// Wait waits for ffmpeg to exit, after its progress output has been read to the end
+func (r *ProgressRun) Wait() error {
+	<-r.parsed
+	return r.cmd.Wait()
+}
+
+// WithProgress returns a copy of the client whose exports (clips and reels), keyframe extractions
+// and stream recordings report their progress to fn as ffmpeg writes them
+func (f *FFmpegClient) WithProgress(fn func(Progress)) *FFmpegClient {
+	c := *f
+	c.progress = fn
+	return &c
+}
+
+// runWithProgress runs ffmpeg with args, expected to write total seconds of output. With a progress
+// callback (see WithProgress) the run's progress updates are passed to it.
+func (f *FFmpegClient) runWithProgress(args []string, total float64, stderr *bytes.Buffer) error {
+	if f.progress == nil {
+		cmd := exec.CommandContext(f.context(), f.ffmpegPath, args...)
+		cmd.Stderr = stderr
+		return cmd.Run()
+	}
+	run, err := f.StartWithProgress(args, total, stderr)
+	if err != nil {
+		return err
+	}
+	for p := range run.Updates {
+		f.progress(p)
+	}
+	return run.Wait()
+}
+
+// parseProgress reads ffmpeg's -progress output: blocks of key=value lines, each closed by
+// progress=continue, or progress=end for the last one
+func parseProgress(r io.Reader, total float64, fn func(Progress)) {
+	var p Progress
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "frame":
+			p.Frame, _ = strconv.ParseInt(value, 10, 64)
+		case "out_time_us", "out_time_ms":
+			// Both are in microseconds (out_time_ms is misnamed)
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				p.OutTime = float64(us) / 1e6
+			}
+		case "speed":
+			p.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "x"), 64)
+		case "progress":
+			p.Done = value == "end"
+			p.Percent = 0
+			if total > 0 {
+				p.Percent = p.OutTime / total * 100
+				if p.Percent > 100 {
+					p.Percent = 100
+				}
+			}
+			if p.Done {
+				p.Percent = 100
+			}
+			fn(p)
+		}
+	}
+	// Drain whatever is left so ffmpeg never blocks on a full pipe
+	io.Copy(io.Discard, r)
+}
@@ -0,0 +1,118 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReelSegment is one cut of a highlight reel
+type ReelSegment struct {
+	Path  string
+	Start float64
+	End   float64
+	// Censor ranges, in source time, are muted or bleeped
+	Censor []Interval
+}
+
+// HasAudioStream reports whether the file has at least one audio stream
+func (f *FFmpegClient) HasAudioStream(videoPath string) (bool, error) {
+	meta, err := f.GetVideoMetadata(videoPath)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range meta.Streams {
+		if s.CodecType == "audio" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var meanVolumeRe = regexp.MustCompile(`mean_volume: (-?[0-9.]+|-inf) dB`)
+
+// MeanVolume returns the mean audio level of [start, end) in dBFS (volumedetect). Sources without
+// audio, and digital silence, report -91 dB.
+func (f *FFmpegClient) MeanVolume(videoPath string, start, end float64) (float64, error) {
+	if end <= start {
+		return 0, fmt.Errorf("invalid range %.3f-%.3f", start, end)
+	}
+	cmd := exec.CommandContext(f.context(), f.ffmpegPath,
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", end-start),
+		"-i", videoPath,
+		"-vn",
+		"-af", "volumedetect",
+		"-f", "null", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("ffmpeg volumedetect failed: %v, stderr: %s", err, stderr.String())
+	}
+	m := meanVolumeRe.FindStringSubmatch(stderr.String())
+	if m == nil || m[1] == "-inf" {
+		return -91, nil
+	}
+	return strconv.ParseFloat(m[1], 64)
+}
+
+// RenderHighlightReel concatenates segments, possibly from different sources, into one MP4 at
+// outputPath. Every segment is scaled and padded to width x height so mixed resolutions concatenate.
+// withAudio may only be set when every source has an audio stream (see HasAudioStream); censorMode
+// then applies to each segment's Censor ranges.
+func (f *FFmpegClient) RenderHighlightReel(segments []ReelSegment, outputPath string, width, height int, withAudio bool, censorMode string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("no segments to render")
+	}
+	var args []string
+	var chain, labels []string
+	for i, s := range segments {
+		if s.End <= s.Start {
+			return fmt.Errorf("invalid segment %d range %.3f-%.3f", i, s.Start, s.End)
+		}
+		args = append(args,
+			"-ss", fmt.Sprintf("%.3f", s.Start),
+			"-t", fmt.Sprintf("%.3f", s.End-s.Start),
+			"-i", s.Path,
+		)
+		chain = append(chain, fmt.Sprintf(
+			"[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=30,format=yuv420p[v%d]",
+			i, width, height, width, height, i))
+		labels = append(labels, fmt.Sprintf("[v%d]", i))
+		if withAudio {
+			dur := s.End - s.Start
+			chain = append(chain, CensorAudioChain(fmt.Sprintf("[%d:a]", i), fmt.Sprintf("[a%d]", i),
+				RelativeRanges(s.Censor, s.Start, dur), censorMode, dur, strconv.Itoa(i)))
+			labels = append(labels, fmt.Sprintf("[a%d]", i))
+		}
+	}
+	concat := fmt.Sprintf("%sconcat=n=%d:v=1:a=0[vout]", strings.Join(labels, ""), len(segments))
+	if withAudio {
+		concat = fmt.Sprintf("%sconcat=n=%d:v=1:a=1[vout][aout]", strings.Join(labels, ""), len(segments))
+	}
+	chain = append(chain, concat)
+
+	args = append([]string{"-y"}, args...)
+	args = append(args, "-filter_complex", strings.Join(chain, ";"), "-map", "[vout]")
+	if withAudio {
+		args = append(args, "-map", "[aout]", "-c:a", "aac")
+	}
+	args = append(args,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "20",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	total := 0.0
+	for _, s := range segments {
+		total += s.End - s.Start
+	}
+	var stderr bytes.Buffer
+	if err := f.runWithProgress(args, total, &stderr); err != nil {
+		return fmt.Errorf("ffmpeg failed to render highlight reel: %v, stderr: %s", err, stderr.String())
+	}
+	return nil
+}
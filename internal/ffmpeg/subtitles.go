@@ -3,6 +3,7 @@ package ffmpeg
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -128,4 +129,46 @@ func FormatDurationToSRT(d time.Duration) string {
 	milliseconds := int(d.Milliseconds()) % 1000
 	
 	return fmt.Sprintf("%02d:%02d:%02d,%03d", hours, minutes, seconds, milliseconds)
-}
\ No newline at end of file
+}
+
+// FormatDurationToVTT converts time.Duration to WebVTT time format
+func FormatDurationToVTT(d time.Duration) string {
+	return strings.Replace(FormatDurationToSRT(d), ",", ".", 1)
+}
+
+// cueText drops carriage returns and blank lines, which would end a cue early
+func cueText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r", ""), "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// WriteSRT writes subtitles as an SRT file, numbering them from 1
+func WriteSRT(w io.Writer, subtitles []Subtitle) error {
+	for i, s := range subtitles {
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, FormatDurationToSRT(s.Start), FormatDurationToSRT(s.End), cueText(s.Text))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteVTT writes subtitles as a WebVTT file
+func WriteVTT(w io.Writer, subtitles []Subtitle) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return err
+	}
+	for _, s := range subtitles {
+		_, err := fmt.Fprintf(w, "%s --> %s\n%s\n\n", FormatDurationToVTT(s.Start), FormatDurationToVTT(s.End), cueText(s.Text))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Watermark describes a text and/or image overlay burned into previews and exported clips
+type Watermark struct {
+	// Text may contain {user_id} and {timestamp} placeholders, filled by Render
+	Text      string  `json:"text,omitempty"`
+	ImagePath string  `json:"image_path,omitempty"`
+	Position  string  `json:"position,omitempty"` // top-left, top-right, bottom-left, bottom-right, center
+	Opacity   float64 `json:"opacity,omitempty"`  // 0-1, default 0.5
+	FontSize  int     `json:"font_size,omitempty"`
+	Margin    int     `json:"margin,omitempty"`
+}
+
+// WatermarkFromEnv returns the server default watermark (WATERMARK_TEXT / WATERMARK_IMAGE), or nil if none is configured
+func WatermarkFromEnv() *Watermark {
+	w := &Watermark{
+		Text:      os.Getenv("WATERMARK_TEXT"),
+		ImagePath: os.Getenv("WATERMARK_IMAGE"),
+		Position:  os.Getenv("WATERMARK_POSITION"),
+	}
+	if v := os.Getenv("WATERMARK_OPACITY"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			w.Opacity = f
+		}
+	}
+	if v := os.Getenv("WATERMARK_FONT_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			w.FontSize = n
+		}
+	}
+	if w.Empty() {
+		return nil
+	}
+	return w
+}
+
+// Validate checks the position, opacity and font size
+func (w *Watermark) Validate() error {
+	switch w.Position {
+	case "", "top-left", "top-right", "bottom-left", "bottom-right", "center":
+	default:
+		return fmt.Errorf("position must be top-left, top-right, bottom-left, bottom-right or center")
+	}
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return fmt.Errorf("opacity must be between 0 and 1")
+	}
+	if w.FontSize < 0 || w.FontSize > 200 {
+		return fmt.Errorf("font_size must be between 0 and 200")
+	}
+	return nil
+}
+
+// Empty reports whether the watermark has nothing to draw
+func (w *Watermark) Empty() bool {
+	return w == nil || (strings.TrimSpace(w.Text) == "" && w.ImagePath == "")
+}
+
+// Render returns a copy with {user_id} and {timestamp} placeholders substituted
+func (w *Watermark) Render(userID string, at time.Time) *Watermark {
+	if w == nil {
+		return nil
+	}
+	out := *w
+	out.Text = strings.NewReplacer(
+		"{user_id}", userID,
+		"{timestamp}", at.UTC().Format("2006-01-02 15:04:05Z"),
+	).Replace(w.Text)
+	return &out
+}
+
+// FilterArgs returns the extra ffmpeg inputs and the -filter_complex graph that overlays the watermark
+// on input 0's video stream, producing the labelled output [vout]. imageInputIndex is the ffmpeg input
+// index the watermark image will get when extraInputs are appended after the source input.
+func (w *Watermark) FilterArgs(imageInputIndex int) (extraInputs []string, filterComplex string) {
+	if w.Empty() {
+		return nil, ""
+	}
+	opacity := w.Opacity
+	if opacity <= 0 || opacity > 1 {
+		opacity = 0.5
+	}
+	margin := w.Margin
+	if margin <= 0 {
+		margin = 16
+	}
+
+	var chain []string
+	current := "[0:v]"
+	if w.ImagePath != "" {
+		extraInputs = []string{"-i", w.ImagePath}
+		x, y := overlayPosition(w.Position, margin, "main_w", "main_h", "overlay_w", "overlay_h")
+		chain = append(chain,
+			fmt.Sprintf("[%d:v]format=rgba,colorchannelmixer=aa=%.2f[wm]", imageInputIndex, opacity),
+			fmt.Sprintf("%s[wm]overlay=x=%s:y=%s[wmimg]", current, x, y),
+		)
+		current = "[wmimg]"
+	}
+	if strings.TrimSpace(w.Text) != "" {
+		fontSize := w.FontSize
+		if fontSize <= 0 {
+			fontSize = 24
+		}
+		x, y := overlayPosition(w.Position, margin, "w", "h", "text_w", "text_h")
+		chain = append(chain, fmt.Sprintf(
+			"%sdrawtext=text='%s':x=%s:y=%s:fontsize=%d:fontcolor=white@%.2f:box=1:boxcolor=black@%.2f:boxborderw=6[vout]",
+			current, escapeDrawtext(w.Text), x, y, fontSize, opacity, opacity/2,
+		))
+	} else {
+		chain = append(chain, fmt.Sprintf("%snull[vout]", current))
+	}
+	return extraInputs, strings.Join(chain, ";")
+}
+
+// overlayPosition returns x/y expressions for a named corner using the given size variables
+func overlayPosition(position string, margin int, mainW, mainH, itemW, itemH string) (string, string) {
+	m := strconv.Itoa(margin)
+	switch position {
+	case "top-left":
+		return m, m
+	case "top-right":
+		return fmt.Sprintf("%s-%s-%s", mainW, itemW, m), m
+	case "bottom-left":
+		return m, fmt.Sprintf("%s-%s-%s", mainH, itemH, m)
+	case "center":
+		return fmt.Sprintf("(%s-%s)/2", mainW, itemW), fmt.Sprintf("(%s-%s)/2", mainH, itemH)
+	default: // bottom-right
+		return fmt.Sprintf("%s-%s-%s", mainW, itemW, m), fmt.Sprintf("%s-%s-%s", mainH, itemH, m)
+	}
+}
+
+// escapeDrawtext escapes text for use inside the single-quoted drawtext text option.
+// Straight quotes cannot be escaped inside a quoted filtergraph value, so they become typographic ones.
+func escapeDrawtext(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`'`, "’",
+		`:`, `\:`,
+		`%`, `\%`,
+		"\n", " ",
+	).Replace(s)
+}
+
+// RenderClip cuts [start, end) out of videoPath into an MP4 at outputPath, burning in the watermark
+// (if any) and muting or bleeping the censor ranges, given in source time. The source must have an
+// audio stream when censoring.
+func (f *FFmpegClient) RenderClip(videoPath, outputPath string, start, end float64, wm *Watermark, censor []Interval, censorMode string) error {
+	if end <= start {
+		return fmt.Errorf("invalid clip range %.3f-%.3f", start, end)
+	}
+	args := []string{
+		"-y",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", end-start),
+		"-i", videoPath,
+	}
+	extraInputs, graph := wm.FilterArgs(1)
+	args = append(args, extraInputs...)
+	ranges := RelativeRanges(censor, start, end-start)
+	switch {
+	case censorMode != CensorNone && len(ranges) > 0:
+		audio := CensorAudioChain("[0:a]", "[aout]", ranges, censorMode, end-start, "0")
+		video := "0:v"
+		if graph != "" {
+			audio = graph + ";" + audio
+			video = "[vout]"
+		}
+		args = append(args, "-filter_complex", audio, "-map", video, "-map", "[aout]")
+	case graph != "":
+		args = append(args, "-filter_complex", graph, "-map", "[vout]", "-map", "0:a?")
+	}
+	args = append(args,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "20",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	var stderr bytes.Buffer
+	if err := f.runWithProgress(args, end-start, &stderr); err != nil {
+		return fmt.Errorf("ffmpeg failed to render watermarked clip: %v, stderr: %s", err, stderr.String())
+	}
+	return nil
+}
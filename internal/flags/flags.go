@@ -0,0 +1,64 @@
+// Package flags holds runtime feature flags. Overrides live in the database and are read through a
+// short cache, so a change made through the admin API reaches every process within cacheTTL.
+package flags
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Loader returns the stored overrides by flag name
+type Loader func() (map[string]bool, error)
+
+// cacheTTL is how long loaded overrides are reused before they are read again
+const cacheTTL = 10 * time.Second
+
+var (
+	mu       sync.Mutex
+	loader   Loader
+	cached   map[string]bool
+	loadedAt time.Time
+)
+
+// Init sets where overrides are loaded from; without it every flag has its default
+func Init(l Loader) {
+	mu.Lock()
+	defer mu.Unlock()
+	loader = l
+	cached, loadedAt = nil, time.Time{}
+}
+
+// Overrides returns the stored overrides. When loading fails the last loaded values are kept.
+func Overrides() map[string]bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if loader == nil {
+		return nil
+	}
+	if cached == nil || time.Since(loadedAt) > cacheTTL {
+		m, err := loader()
+		if err != nil {
+			log.Printf("Warning: failed to load feature flags: %v", err)
+		} else {
+			cached = m
+		}
+		loadedAt = time.Now()
+	}
+	return cached
+}
+
+// Enabled reports the flag's override, or def when it has none
+func Enabled(name string, def bool) bool {
+	if v, ok := Overrides()[name]; ok {
+		return v
+	}
+	return def
+}
+
+// Invalidate drops the cached overrides so the next lookup reloads them
+func Invalidate() {
+	mu.Lock()
+	defer mu.Unlock()
+	cached = nil
+}
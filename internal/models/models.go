@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pgvector/pgvector-go"
@@ -14,7 +16,11 @@ type Video struct {
 	UUID              string         `json:"uuid" gorm:"type:uuid;default:uuid_generate_v4();unique;not null"`
 	Filename          string         `json:"filename" gorm:"size:512;not null"`
 	Filepath          string         `json:"filepath" gorm:"size:1024;not null"`
-	FileHash          string         `json:"file_hash" gorm:"type:char(64);not null"`
+	AssetType         string         `json:"asset_type" gorm:"size:16;not null;default:'video'"` // video, audio, image
+	FileHash          *string        `json:"file_hash" gorm:"type:char(64)"` // SHA-256 of the source, set by the ingestion worker
+	// ContentVideoID is set when the video shares the scenes, captions and embeddings of another
+	// project's video with the same source instead of being ingested itself
+	ContentVideoID    *uint          `json:"content_video_id,omitempty"`
 	Title             *string        `json:"title" gorm:"size:256"`
 	Duration          float64        `json:"duration" gorm:"default:0;not null"`
 	SceneCount        int            `json:"scene_count" gorm:"default:0"`
@@ -27,6 +33,22 @@ type Video struct {
 	Status            VideoStatus    `json:"status" gorm:"default:'pending'"`
 	Metadata          JSONObject     `json:"metadata" gorm:"type:jsonb;default:'{}'"`
 	ErrorMessage      *string        `json:"error_message"`
+	// ErrorCode classifies the error of the first failed stage (missing_file, timeout, ...)
+	ErrorCode         *string        `json:"error_code" gorm:"size:32"`
+
+	// Legal hold: locked videos cannot be deleted, have their file replaced, or be destructively reprocessed
+	Locked            bool           `json:"locked" gorm:"default:false;not null"`
+	LockReason        *string        `json:"lock_reason"`
+	LockedAt          *time.Time     `json:"locked_at"`
+
+	// Live: the source is still growing (a file being written or a recorded stream) and is ingested incrementally
+	Live              bool           `json:"live" gorm:"default:false;not null"`
+
+	// TextEmbedding embeds the title, synopsis and tags for video-level search (not serialized: large)
+	TextEmbedding     *pgvector.Vector `json:"-" gorm:"type:vector(768)"`
+
+	// Version is bumped by every update; UpdateVideo only saves over the version it loaded
+	Version           int            `json:"version" gorm:"default:1;not null"`
 	
 	// Relationships
 	Scenes           []Scene           `json:"scenes,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
@@ -34,6 +56,33 @@ type Video struct {
 	ProcessingJobs   []ProcessingJob   `json:"processing_jobs,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
 }
 
+// Asset types: besides videos, audio files (podcasts, music) and still images can be indexed
+const (
+	AssetTypeVideo = "video"
+	AssetTypeAudio = "audio"
+	AssetTypeImage = "image"
+)
+
+var assetTypeByExt = map[string]string{
+	".mp3": AssetTypeAudio, ".wav": AssetTypeAudio, ".flac": AssetTypeAudio, ".m4a": AssetTypeAudio,
+	".aac": AssetTypeAudio, ".ogg": AssetTypeAudio, ".opus": AssetTypeAudio,
+	".jpg": AssetTypeImage, ".jpeg": AssetTypeImage, ".png": AssetTypeImage, ".webp": AssetTypeImage,
+	".bmp": AssetTypeImage, ".gif": AssetTypeImage, ".tif": AssetTypeImage, ".tiff": AssetTypeImage,
+}
+
+// AssetTypeForPath infers the asset type from a file extension, defaulting to video
+func AssetTypeForPath(path string) string {
+	if t, ok := assetTypeByExt[strings.ToLower(filepath.Ext(path))]; ok {
+		return t
+	}
+	return AssetTypeVideo
+}
+
+// ValidAssetType reports whether t names an asset type
+func ValidAssetType(t string) bool {
+	return t == AssetTypeVideo || t == AssetTypeAudio || t == AssetTypeImage
+}
+
 // JSONStringArray is a custom type for handling JSON arrays of strings
 type JSONStringArray []string
 
@@ -60,6 +109,32 @@ func (j JSONStringArray) Value() (driver.Value, error) {
 	return json.Marshal(j)
 }
 
+// JSONUintArray is a custom type for handling JSON arrays of IDs
+type JSONUintArray []uint
+
+// Scan implements the sql.Scanner interface for JSONUintArray
+func (j *JSONUintArray) Scan(value interface{}) error {
+	if value == nil {
+		*j = []uint{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+
+	return json.Unmarshal(bytes, j)
+}
+
+// Value implements the driver.Valuer interface for JSONUintArray
+func (j JSONUintArray) Value() (driver.Value, error) {
+	if j == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(j)
+}
+
 // JSONObject is a custom type for handling JSON objects
 type JSONObject map[string]interface{}
 
@@ -93,6 +168,7 @@ const (
 	VideoStatusPending    VideoStatus = "pending"
 	VideoStatusProcessing VideoStatus = "processing"
 	VideoStatusCompleted  VideoStatus = "completed"
+	VideoStatusError      VideoStatus = "error" // a pipeline stage failed; see ErrorMessage
 	VideoStatusDeleted    VideoStatus = "deleted"
 )
 
@@ -101,10 +177,16 @@ type Scene struct {
     ID         uint      `json:"id" gorm:"primaryKey"`
     UUID       string    `json:"uuid" gorm:"type:uuid;default:uuid_generate_v4();unique;not null"`
     VideoID    uint      `json:"video_id" gorm:"not null;uniqueIndex:idx_scene_video_index"`
+    Level      string    `json:"level" gorm:"size:16;not null;default:'shot';uniqueIndex:idx_scene_video_index"`
     SceneIndex int       `json:"scene_index" gorm:"not null;uniqueIndex:idx_scene_video_index"`
+    BeatIndex  *int      `json:"beat_index,omitempty"` // shots only: scene_index of the containing beat
     StartTime  float64   `json:"start_time" gorm:"not null"`
     EndTime    float64   `json:"end_time" gorm:"not null"`
     Duration   float64   `json:"duration" gorm:"<-:false;computed:end_time - start_time"`
+    // KeyframeTime is the timestamp of the representative frame used for thumbnails and CLIP image embeddings
+    KeyframeTime *float64 `json:"keyframe_time,omitempty"`
+    // KeyframePath is the JPEG of that frame, served as the scene's thumbnail
+    KeyframePath *string `json:"keyframe_path,omitempty" gorm:"size:1024"`
 	
 	HasCaptions   bool `json:"has_captions" gorm:"default:false"`
 	CaptionCount  int  `json:"caption_count" gorm:"default:0"`
@@ -123,6 +205,126 @@ type Scene struct {
 	Captions []Caption `json:"captions,omitempty" gorm:"foreignKey:SceneID;constraint:OnDelete:CASCADE"`
 }
 
+// Scene levels: shots are the detector's cuts, beats group consecutive related shots
+const (
+	SceneLevelShot = "shot"
+	SceneLevelBeat = "beat"
+)
+
+// ValidSceneLevel reports whether level names a scene level
+func ValidSceneLevel(level string) bool {
+	return level == SceneLevelShot || level == SceneLevelBeat
+}
+
+// SceneKeyframe is a candidate representative frame extracted for a scene; the selected one is the
+// scene's display frame (its timestamp is mirrored in Scene.KeyframeTime)
+type SceneKeyframe struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SceneID   uint      `json:"scene_id" gorm:"not null;index"`
+	VideoID   uint      `json:"video_id" gorm:"not null;index"`
+	Rank      int       `json:"rank" gorm:"not null"` // 0 = highest score
+	Timestamp float64   `json:"timestamp" gorm:"not null"`
+	Score     float64   `json:"score"`
+	Sharpness float64   `json:"sharpness"`
+	Faces     int       `json:"faces"`
+	Path      string    `json:"path" gorm:"size:1024;not null"`
+	Selected  bool      `json:"selected" gorm:"default:false;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Scene preview statuses
+const (
+	ScenePreviewPending    = "pending"
+	ScenePreviewReady      = "ready"
+	ScenePreviewCommitting = "committing"
+	ScenePreviewCommitted  = "committed"
+	ScenePreviewDiscarded  = "discarded"
+	ScenePreviewFailed     = "failed"
+)
+
+// ScenePreview is a scene detection run with alternative parameters whose shots are held for review
+// instead of replacing the video's scenes; committing it stores them as the video's scenes
+type ScenePreview struct {
+	ID               uint        `json:"id" gorm:"primaryKey"`
+	VideoID          uint        `json:"video_id" gorm:"not null;index"`
+	Status           string      `json:"status" gorm:"size:16;not null;default:'pending'"`
+	Threshold        *float64    `json:"threshold"`
+	MinSceneDuration *float64    `json:"min_scene_duration"`
+	Scenes           SceneBounds `json:"scenes" gorm:"type:jsonb;default:'[]'"`
+	Error            *string     `json:"error"`
+	CommittedAt      *time.Time  `json:"committed_at"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// SceneBound is the boundary of one detected shot
+type SceneBound struct {
+	Index     int     `json:"index"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// SceneBounds is a JSON array of shot boundaries
+type SceneBounds []SceneBound
+
+// Scan implements the sql.Scanner interface for SceneBounds
+func (b *SceneBounds) Scan(value interface{}) error {
+	if value == nil {
+		*b = SceneBounds{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, b)
+}
+
+// Value implements the driver.Valuer interface for SceneBounds
+func (b SceneBounds) Value() (driver.Value, error) {
+	if b == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(b)
+}
+
+// SceneDetectionCache holds the raw shot boundaries of a detection run, keyed by the SHA-256 of the
+// source and the run's parameters, so re-ingesting the same content reuses them
+type SceneDetectionCache struct {
+	FileHash   string      `json:"file_hash" gorm:"primaryKey;size:64"`
+	Params     string      `json:"params" gorm:"primaryKey;size:128"`
+	Scenes     SceneBounds `json:"scenes" gorm:"type:jsonb;default:'[]'"`
+	SceneCount int         `json:"scene_count" gorm:"not null;default:0"`
+	Hits       int         `json:"hits" gorm:"not null;default:0"`
+	CreatedAt  time.Time   `json:"created_at"`
+	LastHitAt  *time.Time  `json:"last_hit_at"`
+}
+
+// Annotation kinds: notes carry text, favorites mark a moment; either can be filed in a collection
+const (
+	AnnotationKindNote     = "note"
+	AnnotationKindFavorite = "favorite"
+)
+
+// Annotation is a curator's note, favorite or collection entry anchored to a time range of a video.
+// SceneID is the scene of Level that best covers the range; it is re-resolved whenever the video is
+// re-segmented, so annotations survive scene detection reruns.
+type Annotation struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	VideoID    uint      `json:"video_id" gorm:"not null;index"`
+	StartTime  float64   `json:"start_time" gorm:"not null"`
+	EndTime    float64   `json:"end_time" gorm:"not null"`
+	Level      string    `json:"level" gorm:"size:16;not null;default:'shot'"`
+	SceneID    *uint     `json:"scene_id" gorm:"index"`
+	SceneIndex *int      `json:"scene_index,omitempty" gorm:"-"`
+	Kind       string    `json:"kind" gorm:"size:16;not null;default:'note'"`
+	Collection *string   `json:"collection" gorm:"size:128;index"`
+	Text       *string   `json:"text"`
+	Author     *string   `json:"author" gorm:"size:128"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
 // Caption represents subtitle/caption text with timing
 type Caption struct {
 	ID         uint      `json:"id" gorm:"primaryKey"`
@@ -133,6 +335,8 @@ type Caption struct {
 	EndTime    float64   `json:"end_time" gorm:"not null"`
 	Duration   float64   `json:"duration" gorm:"<-:false;computed:end_time - start_time"`
 	Text       string    `json:"text" gorm:"not null"`
+	// RawText is the caption as found in the subtitle file, before normalization
+	RawText    *string   `json:"raw_text,omitempty"`
 	Language   string    `json:"language" gorm:"size:10;default:'en'"`
 	Confidence float64   `json:"confidence" gorm:"default:1.0"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -142,6 +346,114 @@ type Caption struct {
 	Scene *Scene `json:"scene,omitempty" gorm:"foreignKey:SceneID"`
 }
 
+// CaptionEmbedding is the text embedding of one caption, or of a window of CaptionCount consecutive
+// captions starting at CaptionID, used for passage-level retrieval
+type CaptionEmbedding struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	VideoID      uint             `json:"video_id" gorm:"not null;index"`
+	CaptionID    uint             `json:"caption_id" gorm:"not null"`
+	CaptionCount int              `json:"caption_count" gorm:"not null;default:1"`
+	StartTime    float64          `json:"start_time" gorm:"not null"`
+	EndTime      float64          `json:"end_time" gorm:"not null"`
+	Text         string           `json:"text" gorm:"not null"`
+	Embedding    *pgvector.Vector `json:"-" gorm:"type:vector(768)"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// CaptionWord is one spoken word of a caption with its own timing. Position numbers a video's words
+// in speaking order across captions, so phrases spanning caption boundaries can be matched.
+type CaptionWord struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	VideoID   uint    `json:"video_id" gorm:"not null"`
+	CaptionID uint    `json:"caption_id" gorm:"not null;index"`
+	Position  int     `json:"position" gorm:"not null"`
+	WordIndex int     `json:"word_index" gorm:"not null"`
+	Word      string  `json:"word" gorm:"not null"`
+	Norm      string  `json:"norm" gorm:"not null"`
+	StartTime float64 `json:"start_time" gorm:"not null"`
+	EndTime   float64 `json:"end_time" gorm:"not null"`
+	Score     float64 `json:"score"`
+	// Aligned is false for words timed by interpolation (alignment failed or the word has no letters)
+	Aligned bool `json:"aligned"`
+}
+
+// CaptionTranslation is one caption translated into another language, timed like its source caption
+type CaptionTranslation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	VideoID   uint      `json:"video_id" gorm:"not null"`
+	CaptionID uint      `json:"caption_id" gorm:"not null"`
+	Language  string    `json:"language" gorm:"size:10;not null"`
+	Text      string    `json:"text" gorm:"not null"`
+	StartTime float64   `json:"start_time" gorm:"not null"`
+	EndTime   float64   `json:"end_time" gorm:"not null"`
+	Model     string    `json:"model" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Caption QA issue types
+const (
+	CaptionIssueGap           = "gap"            // long stretch without captions
+	CaptionIssueOverlap       = "overlap"        // starts before the previous caption ends
+	CaptionIssueMojibake      = "mojibake"       // text decoded with the wrong encoding
+	CaptionIssueFast          = "fast"           // too many characters per second to read
+	CaptionIssueZeroDuration  = "zero_duration"  // ends at or before its start
+	CaptionIssueLowConfidence = "low_confidence" // recognised with low confidence
+)
+
+// CaptionQAReport is the caption quality of one video, recomputed by caption_qa jobs. Score is
+// between 0 and 1; lower is worse.
+type CaptionQAReport struct {
+	VideoID            uint            `json:"video_id" gorm:"primaryKey;autoIncrement:false"`
+	CaptionCount       int             `json:"caption_count"`
+	Duration           float64         `json:"duration"`
+	CaptionedSeconds   float64         `json:"captioned_seconds"`
+	Coverage           float64         `json:"coverage"`
+	AvgConfidence      float64         `json:"avg_confidence"`
+	LowConfidenceCount int             `json:"low_confidence_count"`
+	GapCount           int             `json:"gap_count"`
+	LongestGap         float64         `json:"longest_gap"`
+	OverlapCount       int             `json:"overlap_count"`
+	MojibakeCount      int             `json:"mojibake_count"`
+	FastCount          int             `json:"fast_count"`
+	ZeroDurationCount  int             `json:"zero_duration_count"`
+	Score              float64         `json:"score"`
+	Issues             CaptionQAIssues `json:"issues" gorm:"type:jsonb;default:'[]'"`
+	ComputedAt         time.Time       `json:"computed_at"`
+}
+
+// CaptionQAIssue is one suspicious caption or gap in a caption QA report
+type CaptionQAIssue struct {
+	Type      string  `json:"type"`
+	CaptionID *uint   `json:"caption_id,omitempty"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+// CaptionQAIssues is a JSON array of caption QA issues
+type CaptionQAIssues []CaptionQAIssue
+
+// Scan implements the sql.Scanner interface for CaptionQAIssues
+func (c *CaptionQAIssues) Scan(value interface{}) error {
+	if value == nil {
+		*c = CaptionQAIssues{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// Value implements the driver.Valuer interface for CaptionQAIssues
+func (c CaptionQAIssues) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c)
+}
+
 // ProcessingJob represents background processing tasks
 type ProcessingJob struct {
 	ID          uint            `json:"id" gorm:"primaryKey"`
@@ -190,6 +502,28 @@ type DatabaseStats struct {
 	TotalCaptions         int     `json:"total_captions"`
 	TotalDurationSeconds  float64 `json:"total_duration_seconds"`
 	ActiveJobs            int     `json:"active_jobs"`
+	// VectorIndexes reports the ANN index of each scene embedding column
+	VectorIndexes         []VectorIndexStatus `json:"vector_indexes"`
+}
+
+// VectorIndexStatus describes the ANN index of a scene embedding column
+type VectorIndexStatus struct {
+	Modality   string `json:"modality"`
+	Column     string `json:"column"`
+	Name       string `json:"name"`
+	// Type is the configured index type: hnsw, ivfflat or none
+	Type       string `json:"type"`
+	Metric     string `json:"metric"`
+	Present    bool   `json:"present"`
+	// Valid is false for an index whose concurrent build failed; Postgres does not use it
+	Valid      bool   `json:"valid"`
+	// Current reports whether the index matches the configured type, metric and parameters
+	Current    bool   `json:"current"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Definition string `json:"definition,omitempty"`
+	// Action is what EnsureVectorIndexes did: created, rebuilt, dropped, unchanged or skipped
+	Action     string `json:"action,omitempty"`
+	Error      string `json:"error,omitempty"`
 }
 
 // SearchRequest represents a search query
@@ -224,9 +558,18 @@ type SearchResponse struct {
 type VideoCreateRequest struct {
 	Filename string            `json:"filename" binding:"required"`
 	Filepath string            `json:"filepath" binding:"required"`
+	// AssetType is video, audio or image; inferred from the file extension when empty
+	AssetType string           `json:"asset_type"`
+	// Live ingests a growing file incrementally; SourceURL optionally names an HLS/RTMP stream recorded into Filepath
+	Live      bool             `json:"live"`
+	SourceURL string           `json:"source_url"`
 	Title    *string           `json:"title"`
 	Tags     []string          `json:"tags"`
 	Metadata map[string]any    `json:"metadata"`
+	// FileHash optionally gives the source's SHA-256 so a known file is recognised before it is hashed
+	FileHash string            `json:"file_hash"`
+	// RefreshScenes runs scene detection even when the same content was detected before
+	RefreshScenes bool         `json:"refresh_scenes"`
 }
 
 // VideoResponse represents a video with additional calculated fields
@@ -239,6 +582,577 @@ type VideoResponse struct {
 	ProcessingStatus   string  `json:"processing_status"`
 }
 
+// Synonym is a domain term with its aliases, used to expand search queries
+type Synonym struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	Term      string          `json:"term" gorm:"size:256;not null;unique"`
+	Aliases   JSONStringArray `json:"aliases" gorm:"type:jsonb;default:'[]'"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// SearchEvent records one executed search for analytics
+type SearchEvent struct {
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	Modality       string        `json:"modality" gorm:"size:32;not null;index"`
+	Query          string        `json:"query"`
+	Filters        JSONObject    `json:"filters" gorm:"type:jsonb;default:'{}'"`
+	LatencyMs      float64       `json:"latency_ms"`
+	ResultCount    int           `json:"result_count"`
+	ResultSceneIDs JSONUintArray `json:"result_scene_ids" gorm:"type:jsonb;default:'[]'"`
+	ClickCount     int           `json:"click_count" gorm:"default:0"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"index"`
+}
+
+// SearchFeedback records a result the user opened or exported for a query
+type SearchFeedback struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	SearchEventID   *uint     `json:"search_event_id" gorm:"index"`
+	Query           string    `json:"query"`
+	NormalizedQuery string    `json:"normalized_query" gorm:"size:512;index"`
+	SceneID         uint      `json:"scene_id" gorm:"not null;index"`
+	Action          string    `json:"action" gorm:"size:32;not null"`
+	Position        *int      `json:"position"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// QueryCount is a normalized query with how often it was searched
+type QueryCount struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+// ModalitySearchStats aggregates search events for one modality
+type ModalitySearchStats struct {
+	Modality     string  `json:"modality"`
+	Searches     int     `json:"searches"`
+	ZeroResults  int     `json:"zero_results"`
+	Clicked      int     `json:"clicked"`
+	CTR          float64 `json:"ctr"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// SearchAnalytics is the aggregate view over search events in a time window
+type SearchAnalytics struct {
+	Since             time.Time             `json:"since"`
+	TotalSearches     int                   `json:"total_searches"`
+	TopQueries        []QueryCount          `json:"top_queries"`
+	ZeroResultQueries []QueryCount          `json:"zero_result_queries"`
+	Modalities        []ModalitySearchStats `json:"modalities"`
+}
+
+// Consistency anomaly categories found by the pipeline consistency checker
+const (
+	AnomalyMissingEmbeddings  = "missing_embeddings"
+	AnomalyUnlinkedCaptions   = "unlinked_captions"
+	AnomalySceneCountMismatch = "scene_count_mismatch"
+	AnomalyZombieProcessing   = "zombie_processing"
+)
+
+// ConsistencyAnomaly is a video whose pipeline outputs are missing or inconsistent
+type ConsistencyAnomaly struct {
+	VideoID  uint   `json:"video_id"`
+	Category string `json:"category"`
+	Details  string `json:"details"`
+}
+
+// ContentFlag is a wordlist hit in a caption; StartTime/EndTime estimate where the term is spoken
+type ContentFlag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	VideoID   uint      `json:"video_id" gorm:"not null;index"`
+	CaptionID uint      `json:"caption_id" gorm:"not null"`
+	StartTime float64   `json:"start_time" gorm:"not null"`
+	EndTime   float64   `json:"end_time" gorm:"not null"`
+	Category  string    `json:"category" gorm:"size:64;not null"`
+	Term      string    `json:"term" gorm:"size:256;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Emotions scored per scene from caption text and audio
+const (
+	EmotionAnger    = "anger"
+	EmotionDisgust  = "disgust"
+	EmotionFear     = "fear"
+	EmotionJoy      = "joy"
+	EmotionNeutral  = "neutral"
+	EmotionSadness  = "sadness"
+	EmotionSurprise = "surprise"
+)
+
+// ToneEmotions maps the tone words accepted by search filters onto the emotions they cover
+var ToneEmotions = map[string][]string{
+	"tense":    {EmotionAnger, EmotionFear, EmotionDisgust},
+	"upbeat":   {EmotionJoy, EmotionSurprise},
+	"somber":   {EmotionSadness},
+	"calm":     {EmotionNeutral},
+	"shocking": {EmotionSurprise, EmotionFear},
+}
+
+// ExpandEmotions resolves emotion and tone names to distinct emotions; it returns the first
+// unknown name, if any
+func ExpandEmotions(names []string) ([]string, string) {
+	var out []string
+	seen := map[string]bool{}
+	add := func(e string) {
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		switch n {
+		case EmotionAnger, EmotionDisgust, EmotionFear, EmotionJoy, EmotionNeutral, EmotionSadness, EmotionSurprise:
+			add(n)
+		default:
+			tone, ok := ToneEmotions[n]
+			if !ok {
+				return nil, n
+			}
+			for _, e := range tone {
+				add(e)
+			}
+		}
+	}
+	return out, ""
+}
+
+// SceneTone is the emotional tone of a scene: caption sentiment and emotion plus the emotion of its audio
+type SceneTone struct {
+	ID      uint `json:"-" gorm:"primaryKey"`
+	VideoID uint `json:"video_id" gorm:"not null;index"`
+	SceneID uint `json:"scene_id" gorm:"not null;uniqueIndex"`
+	// Sentiment is positive minus negative probability over the scene's captions, in [-1, 1]; nil without captions
+	Sentiment     *float64   `json:"sentiment"`
+	Emotion       *string    `json:"emotion"`
+	EmotionScores JSONObject `json:"emotion_scores" gorm:"type:jsonb;default:'{}'"`
+	// AudioEmotion is the dominant emotion of the scene's speech/soundtrack; nil when audio was not scored
+	AudioEmotion       *string    `json:"audio_emotion"`
+	AudioEmotionScores JSONObject `json:"audio_emotion_scores" gorm:"type:jsonb;default:'{}'"`
+	// Intensity is how far from neutral the scene is (1 - neutral probability, the stronger of text and audio)
+	Intensity float64   `json:"intensity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Entity types extracted from captions
+const (
+	EntityPerson       = "person"
+	EntityPlace        = "place"
+	EntityOrganization = "organization"
+	EntityMisc         = "misc"
+)
+
+// ValidEntityType reports whether t names an entity type
+func ValidEntityType(t string) bool {
+	return t == EntityPerson || t == EntityPlace || t == EntityOrganization || t == EntityMisc
+}
+
+// SceneEntity is a named entity mentioned in the captions of a scene
+type SceneEntity struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	VideoID    uint      `json:"video_id" gorm:"not null;index"`
+	SceneID    uint      `json:"scene_id" gorm:"not null;index"`
+	Entity     string    `json:"entity" gorm:"size:256;not null"`
+	Normalized string    `json:"normalized" gorm:"size:256;not null;index"`
+	EntityType string    `json:"entity_type" gorm:"size:32;not null"`
+	Mentions   int       `json:"mentions" gorm:"default:1"`
+	FirstTime  float64   `json:"first_time"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// EntityFacet aggregates the mentions of one entity across scenes
+type EntityFacet struct {
+	Entity     string `json:"entity"`
+	Normalized string `json:"normalized"`
+	EntityType string `json:"entity_type"`
+	Mentions   int    `json:"mentions"`
+	SceneCount int    `json:"scene_count"`
+	VideoCount int    `json:"video_count"`
+}
+
+// VideoTopic is one segment of a video's topic timeline, derived from its captions
+type VideoTopic struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	VideoID      uint             `json:"video_id" gorm:"not null;index"`
+	TopicIndex   int              `json:"topic_index" gorm:"not null"`
+	StartTime    float64          `json:"start_time" gorm:"not null"`
+	EndTime      float64          `json:"end_time" gorm:"not null"`
+	Label        string           `json:"label"`
+	Keywords     JSONStringArray  `json:"keywords" gorm:"type:jsonb;default:'[]'"`
+	Excerpt      string           `json:"excerpt"`
+	CaptionCount int              `json:"caption_count"`
+	Embedding    *pgvector.Vector `json:"-" gorm:"type:vector(768)"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// Highlight reel statuses
+const (
+	HighlightStatusPending    = "pending"
+	HighlightStatusProcessing = "processing"
+	HighlightStatusCompleted  = "completed"
+	HighlightStatusFailed     = "failed"
+)
+
+// HighlightReel is a prompt-driven selection of scenes from one video (or the whole library when
+// VideoID is nil) adding up to roughly TargetDuration seconds
+type HighlightReel struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	VideoID        *uint          `json:"video_id" gorm:"index"`
+	Prompt         string         `json:"prompt" gorm:"not null"`
+	Level          string         `json:"level" gorm:"size:16;not null;default:'shot'"`
+	TargetDuration float64        `json:"target_duration" gorm:"not null"`
+	// Options holds the scoring rubric: weights, scene duration bounds and whether to export
+	Options        JSONObject     `json:"options" gorm:"type:jsonb;default:'{}'"`
+	Status         string         `json:"status" gorm:"size:16;not null;default:'pending'"`
+	Items          HighlightItems `json:"items" gorm:"type:jsonb;default:'[]'"`
+	TotalDuration  float64        `json:"total_duration"`
+	ExportPath     *string        `json:"-" gorm:"size:1024"`
+	ErrorMessage   *string        `json:"error_message"`
+	JobID          string         `json:"job_id" gorm:"size:64"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Supercut orderings
+const (
+	SupercutOrderVideo    = "video"    // by video, in the requested order, then time
+	SupercutOrderDuration = "duration" // shortest utterance first
+	SupercutOrderRandom   = "random"
+)
+
+// ValidSupercutOrder reports whether order names a supercut ordering
+func ValidSupercutOrder(order string) bool {
+	return order == SupercutOrderVideo || order == SupercutOrderDuration || order == SupercutOrderRandom
+}
+
+// Supercut is every utterance of a phrase across videos, each cut with padding and concatenated into
+// one export. Statuses are the highlight reel statuses.
+type Supercut struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	Phrase        string        `json:"phrase" gorm:"not null"`
+	VideoIDs      JSONUintArray `json:"video_ids" gorm:"type:jsonb;default:'[]'"`
+	PadBefore     float64       `json:"pad_before"`
+	PadAfter      float64       `json:"pad_after"`
+	Ordering      string        `json:"order" gorm:"size:16;not null;default:'video'"`
+	MaxClips      int           `json:"max_clips"`
+	Status        string        `json:"status" gorm:"size:16;not null;default:'pending'"`
+	Items         SupercutItems `json:"items" gorm:"type:jsonb;default:'[]'"`
+	TotalDuration float64       `json:"total_duration"`
+	ExportPath    *string       `json:"-" gorm:"size:1024"`
+	ErrorMessage  *string       `json:"error_message"`
+	JobID         string        `json:"job_id" gorm:"size:64"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// Clip export modes: auto stream-copies when the source allows it and re-encodes otherwise; copy
+// and reencode force a method. The method an export used is one of the latter two.
+const (
+	ClipExportAuto     = "auto"
+	ClipExportCopy     = "copy"
+	ClipExportReencode = "reencode"
+)
+
+// ValidClipExportMode reports whether mode names a clip export mode
+func ValidClipExportMode(mode string) bool {
+	return mode == ClipExportAuto || mode == ClipExportCopy || mode == ClipExportReencode
+}
+
+// ClipExport is a scene's time range cut out of its source video into an MP4. Statuses are the
+// highlight reel statuses.
+type ClipExport struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	SceneID   uint    `json:"scene_id" gorm:"not null;index"`
+	VideoID   uint    `json:"video_id" gorm:"not null;index"`
+	StartTime float64 `json:"start_time" gorm:"not null"`
+	EndTime   float64 `json:"end_time" gorm:"not null"`
+	Mode      string  `json:"mode" gorm:"size:16;not null;default:'auto'"`
+	// Method is how the clip was cut (copy or reencode), once exported
+	Method       *string   `json:"method" gorm:"size:16"`
+	Censor       string    `json:"censor" gorm:"size:16"`
+	Status       string    `json:"status" gorm:"size:16;not null;default:'pending'"`
+	FileSize     int64     `json:"file_size"`
+	ExportPath   *string   `json:"-" gorm:"size:1024"`
+	ErrorMessage *string   `json:"error_message"`
+	JobID        string    `json:"job_id" gorm:"size:64"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Artifact kinds: the export tables whose files are tiered
+const (
+	ArtifactClipExport    = "clip_export"
+	ArtifactHighlightReel = "highlight_reel"
+	ArtifactSupercut      = "supercut"
+)
+
+// Artifact storage tiers: restoring artifacts are cold until their restore job copies them back
+const (
+	ArtifactTierHot       = "hot"
+	ArtifactTierCold      = "cold"
+	ArtifactTierRestoring = "restoring"
+)
+
+// Artifact is a rendered file tracked for storage tiering. Files not downloaded for a while move
+// from Path to ColdPath in cold storage and are restored to Path on their next download.
+type Artifact struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Kind string `json:"kind" gorm:"size:32;not null"`
+	// OwnerID is the ID of the artifact's row in its kind's table
+	OwnerID        uint       `json:"owner_id" gorm:"not null"`
+	Path           string     `json:"path" gorm:"size:1024;not null"`
+	ColdPath       *string    `json:"cold_path" gorm:"size:1024"`
+	Tier           string     `json:"tier" gorm:"size:16;not null;default:'hot'"`
+	SizeBytes      int64      `json:"size_bytes"`
+	LastAccessedAt time.Time  `json:"last_accessed_at"`
+	ArchivedAt     *time.Time `json:"archived_at"`
+	RestoredAt     *time.Time `json:"restored_at"`
+	RestoreJobID   *string    `json:"restore_job_id" gorm:"size:64"`
+	// RestoreClaimedAt is when the current restore was claimed
+	RestoreClaimedAt *time.Time `json:"restore_claimed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Alert types: keyword alerts match caption words or phrases, semantic alerts match scene text embeddings
+const (
+	AlertTypeKeyword  = "keyword"
+	AlertTypeSemantic = "semantic"
+)
+
+// Alert is a standing query evaluated against newly ingested videos; matches are sent to its
+// webhook and/or email recipients
+type Alert struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Name      string `json:"name" gorm:"size:256;not null"`
+	AlertType string `json:"alert_type" gorm:"size:16;not null"`
+	Query     string `json:"query" gorm:"not null"`
+	Level     string `json:"level" gorm:"size:16;not null;default:'shot'"`
+	// Threshold is the minimum similarity of a semantic match
+	Threshold  float64          `json:"threshold"`
+	WebhookURL *string          `json:"webhook_url" gorm:"size:1024"`
+	Emails     JSONStringArray  `json:"emails" gorm:"type:jsonb;default:'[]'"`
+	Active     bool             `json:"active" gorm:"default:true"`
+	Embedding  *pgvector.Vector `json:"-" gorm:"type:vector(768)"`
+	// MatchCount and LastTriggeredAt summarise the matches recorded so far
+	MatchCount      int        `json:"match_count" gorm:"default:0"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// AlertMatch is a scene that satisfied an alert; each scene matches an alert at most once
+type AlertMatch struct {
+	ID         uint    `json:"id" gorm:"primaryKey"`
+	AlertID    uint    `json:"alert_id" gorm:"not null;index"`
+	VideoID    uint    `json:"video_id" gorm:"not null"`
+	SceneID    uint    `json:"scene_id" gorm:"not null"`
+	SceneIndex int     `json:"scene_index"`
+	StartTime  float64 `json:"start_time"`
+	EndTime    float64 `json:"end_time"`
+	Score      float64 `json:"score"`
+	Snippet    string  `json:"snippet"`
+	// NotifiedAt is set once the match was delivered; NotifyError holds the last delivery failure
+	NotifiedAt  *time.Time `json:"notified_at"`
+	NotifyError *string    `json:"notify_error"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// NotificationChannel delivers pipeline events (see notify.Events) to a webhook, Slack webhook or
+// email recipients
+type NotificationChannel struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:256;not null"`
+	Kind string `json:"kind" gorm:"size:16;not null"`
+	// Target is the webhook/Slack URL or comma-separated email addresses
+	Target string `json:"target" gorm:"size:2048;not null"`
+	// Events subscribed to (all when empty); Project limits events to videos with metadata.project (all when nil)
+	Events    JSONStringArray `json:"events" gorm:"type:jsonb;default:'[]'"`
+	Project   *string         `json:"project" gorm:"size:128"`
+	Active    bool            `json:"active" gorm:"default:true"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Embedding model lifecycle: a registered model is backfilled into scene_embeddings, validated
+// side by side with the active model, activated (cut over) and, once replaced, retired until its
+// vectors are purged
+const (
+	EmbeddingModelRegistered  = "registered"
+	EmbeddingModelBackfilling = "backfilling"
+	EmbeddingModelValidating  = "validating"
+	EmbeddingModelActive      = "active"
+	EmbeddingModelRetired     = "retired"
+	EmbeddingModelPurged      = "purged"
+	EmbeddingModelFailed      = "failed"
+)
+
+// EmbeddingModel is a model scene text embeddings can be computed with. The active model's vectors
+// live in scenes.text_embedding; other models keep theirs in scene_embeddings.
+type EmbeddingModel struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Name       string `json:"name" gorm:"size:128;uniqueIndex;not null"`
+	Modality   string `json:"modality" gorm:"size:16;not null;default:'text'"`
+	ModelID    string `json:"model_id" gorm:"size:256;not null"`
+	Dimensions int    `json:"dimensions"`
+	Status     string `json:"status" gorm:"size:16;not null;default:'registered'"`
+	// Backfill progress: scenes embedded so far out of the scenes to embed; the cursor is the last scene ID done
+	BackfilledScenes int        `json:"backfilled_scenes"`
+	TotalScenes      int        `json:"total_scenes"`
+	BackfillCursor   uint       `json:"-"`
+	Error            *string    `json:"error"`
+	ActivatedAt      *time.Time `json:"activated_at"`
+	RetiredAt        *time.Time `json:"retired_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// SceneEmbedding is a scene's text embedding under a model other than the active one
+type SceneEmbedding struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	SceneID          uint            `json:"scene_id" gorm:"not null"`
+	EmbeddingModelID uint            `json:"embedding_model_id" gorm:"not null"`
+	Embedding        pgvector.Vector `json:"-" gorm:"type:vector"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// FeatureFlag is a runtime override of a pipeline feature flag (see processor.FeatureFlags)
+type FeatureFlag struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:64"`
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SceneTextFilter is a set of boilerplate rules stripped from captions as they are aggregated into
+// scene text (see processor.SceneTextRulesFor). Project "" is the default set.
+type SceneTextFilter struct {
+	Project string `json:"project" gorm:"primaryKey;size:128"`
+	// Patterns are regular expressions whose matches are removed
+	Patterns JSONStringArray `json:"patterns" gorm:"type:jsonb"`
+	// Stopwords are words removed wherever they occur, ignoring case
+	Stopwords JSONStringArray `json:"stopwords" gorm:"type:jsonb"`
+	// Inherit adds a project's rules to the default set instead of replacing it
+	Inherit   bool      `json:"inherit"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProjectWatermark is a project's watermark for exported clips and shared cuts (see
+// processor.WatermarkFor). It replaces the server default (WATERMARK_*) for the project's videos.
+type ProjectWatermark struct {
+	Project string `json:"project" gorm:"primaryKey;size:128"`
+	// Enabled false turns watermarking off for the project; true with no text or image keeps the
+	// server default
+	Enabled   bool      `json:"enabled"`
+	Text      string    `json:"text"`
+	ImagePath string    `json:"image_path" gorm:"size:1024"`
+	Position  string    `json:"position" gorm:"size:16"`
+	Opacity   float64   `json:"opacity"`
+	FontSize  int       `json:"font_size"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LibrarySnapshot is the state of the library at the end of one UTC day, plus that day's activity,
+// for growth and usage charts
+type LibrarySnapshot struct {
+	ID                   uint      `json:"-" gorm:"primaryKey"`
+	Day                  time.Time `json:"day" gorm:"type:date;uniqueIndex;not null"`
+	TotalVideos          int       `json:"total_videos"`
+	TotalHours           float64   `json:"total_hours"`
+	TotalScenes          int       `json:"total_scenes"`
+	ScenesWithEmbeddings int       `json:"scenes_with_embeddings"`
+	// EmbeddingCoverage is ScenesWithEmbeddings / TotalScenes (0 when there are no scenes)
+	EmbeddingCoverage  float64   `json:"embedding_coverage"`
+	TotalCaptions      int       `json:"total_captions"`
+	VideosIngested     int       `json:"videos_ingested"`
+	HoursIngested      float64   `json:"hours_ingested"`
+	Searches           int       `json:"searches"`
+	ZeroResultSearches int       `json:"zero_result_searches"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// LibraryDigest summarises library activity over a period for digest notifications
+type LibraryDigest struct {
+	Since         time.Time `json:"since"`
+	NewVideos     int       `json:"new_videos"`
+	HoursIngested float64   `json:"hours_ingested"`
+	NewScenes     int       `json:"new_scenes"`
+	Searches      int       `json:"searches"`
+	AlertMatches  int       `json:"alert_matches"`
+	TotalVideos   int       `json:"total_videos"`
+	TotalHours    float64   `json:"total_hours"`
+}
+
+// HighlightItem is one scene of a highlight reel, in playback order
+type HighlightItem struct {
+	SceneID    uint    `json:"scene_id"`
+	VideoID    uint    `json:"video_id"`
+	SceneIndex int     `json:"scene_index"`
+	StartTime  float64 `json:"start_time"`
+	EndTime    float64 `json:"end_time"`
+	Similarity float64 `json:"similarity"`
+	Energy     float64 `json:"energy"`
+	Score      float64 `json:"score"`
+}
+
+// HighlightItems is a JSON array of highlight reel items
+type HighlightItems []HighlightItem
+
+// Scan implements the sql.Scanner interface for HighlightItems
+func (h *HighlightItems) Scan(value interface{}) error {
+	if value == nil {
+		*h = HighlightItems{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, h)
+}
+
+// Value implements the driver.Valuer interface for HighlightItems
+func (h HighlightItems) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(h)
+}
+
+// SupercutItem is one utterance in a supercut: the spoken range and the padded range it is cut at
+type SupercutItem struct {
+	VideoID   uint    `json:"video_id"`
+	CaptionID uint    `json:"caption_id"`
+	Phrase    string  `json:"phrase"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	ClipStart float64 `json:"clip_start"`
+	ClipEnd   float64 `json:"clip_end"`
+}
+
+// SupercutItems is a JSON array of supercut items
+type SupercutItems []SupercutItem
+
+// Scan implements the sql.Scanner interface for SupercutItems
+func (s *SupercutItems) Scan(value interface{}) error {
+	if value == nil {
+		*s = SupercutItems{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// Value implements the driver.Valuer interface for SupercutItems
+func (s SupercutItems) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
 // TableName methods for custom table names if needed
 func (Video) TableName() string {
 	return "videos"
@@ -248,10 +1162,118 @@ func (Scene) TableName() string {
 	return "scenes"
 }
 
+func (SceneKeyframe) TableName() string {
+	return "scene_keyframes"
+}
+
 func (Caption) TableName() string {
 	return "captions"
 }
 
 func (ProcessingJob) TableName() string {
 	return "processing_jobs"
-}
\ No newline at end of file
+}
+
+func (Synonym) TableName() string {
+	return "synonyms"
+}
+
+func (SearchEvent) TableName() string {
+	return "search_events"
+}
+
+func (SearchFeedback) TableName() string {
+	return "search_feedback"
+}
+
+func (ContentFlag) TableName() string {
+	return "content_flags"
+}
+
+func (SceneEntity) TableName() string {
+	return "scene_entities"
+}
+
+func (VideoTopic) TableName() string {
+	return "video_topics"
+}
+
+func (HighlightReel) TableName() string {
+	return "highlight_reels"
+}
+
+func (SceneTone) TableName() string {
+	return "scene_tones"
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+func (AlertMatch) TableName() string {
+	return "alert_matches"
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+func (LibrarySnapshot) TableName() string {
+	return "library_snapshots"
+}
+
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
+
+func (EmbeddingModel) TableName() string {
+	return "embedding_models"
+}
+
+func (SceneEmbedding) TableName() string {
+	return "scene_embeddings"
+}
+
+func (ScenePreview) TableName() string {
+	return "scene_previews"
+}
+
+func (SceneDetectionCache) TableName() string {
+	return "scene_detection_cache"
+}
+
+func (Annotation) TableName() string {
+	return "annotations"
+}
+
+func (CaptionEmbedding) TableName() string {
+	return "captions_embeddings"
+}
+
+func (CaptionWord) TableName() string {
+	return "caption_words"
+}
+
+func (Supercut) TableName() string {
+	return "supercuts"
+}
+
+func (ClipExport) TableName() string {
+	return "clip_exports"
+}
+
+func (Artifact) TableName() string {
+	return "artifacts"
+}
+
+func (CaptionQAReport) TableName() string {
+	return "caption_qa_reports"
+}
+
+func (CaptionTranslation) TableName() string {
+	return "caption_translations"
+}
+
+func (SceneTextFilter) TableName() string {
+	return "scene_text_filters"
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Events published on the bus
+const (
+	EventAlertMatched        = "alert.matched"
+	EventVideoIngested       = "video.ingested"
+	EventJobFailedRepeatedly = "job.failed_repeatedly"
+	EventLibraryDigest       = "library.digest"
+	EventExportProgress      = "export.progress"
+)
+
+// Events lists every event a channel can subscribe to
+var Events = []string{EventAlertMatched, EventVideoIngested, EventJobFailedRepeatedly, EventLibraryDigest, EventExportProgress}
+
+// OptInEvents are only delivered to channels that list them; channels without events (subscribed
+// to everything) don't get them, so chat and email aren't flooded with progress updates
+var OptInEvents = map[string]bool{EventExportProgress: true}
+
+// Channel kinds
+const (
+	KindWebhook = "webhook"
+	KindSlack   = "slack"
+	KindEmail   = "email"
+)
+
+// Target is one delivery destination: a webhook or Slack URL, or comma-separated email addresses
+type Target struct {
+	Kind    string
+	Address string
+}
+
+// Deliver sends msg to one target
+func Deliver(t Target, msg Message) error {
+	switch t.Kind {
+	case KindWebhook:
+		return Webhook(t.Address, msg)
+	case KindSlack:
+		return Slack(t.Address, msg)
+	case KindEmail:
+		var to []string
+		for _, a := range strings.Split(t.Address, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				to = append(to, a)
+			}
+		}
+		return Email(to, msg)
+	default:
+		return fmt.Errorf("unknown channel kind %q", t.Kind)
+	}
+}
+
+// Resolver returns the targets subscribed to a message's event and project
+type Resolver func(msg Message) ([]Target, error)
+
+var resolver Resolver
+
+// Init sets the resolver Publish uses to find subscribed channels
+func Init(r Resolver) {
+	resolver = r
+}
+
+// Publish delivers msg to every subscribed channel in the background; delivery failures are logged.
+// Without a resolver (Init not called) the message is dropped.
+func Publish(msg Message) {
+	if resolver == nil {
+		return
+	}
+	go func() {
+		targets, err := resolver(msg)
+		if err != nil {
+			log.Printf("Warning: failed to resolve notification channels for %s: %v", msg.Event, err)
+			return
+		}
+		for _, t := range targets {
+			if err := Deliver(t, msg); err != nil {
+				log.Printf("Warning: %s notification via %s failed: %v", msg.Event, t.Kind, err)
+			}
+		}
+	}()
+}
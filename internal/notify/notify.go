@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is one notification: webhooks receive it as JSON, email recipients get Subject and Text
+type Message struct {
+	Event string `json:"event"`
+	// Project scopes the event to channels of one project (videos carry it as metadata.project)
+	Project string         `json:"project,omitempty"`
+	Subject string         `json:"subject"`
+	Text    string         `json:"text"`
+	Data    map[string]any `json:"data,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// PublicURL joins path onto PUBLIC_BASE_URL (default http://localhost:8080) for links in notifications
+func PublicURL(path string) string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+// Webhook POSTs msg as JSON to url. When WEBHOOK_SECRET is set the body is signed with HMAC-SHA256
+// in the X-Goodclips-Signature header ("sha256=<hex>").
+func Webhook(url string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goodclips-Event", msg.Event)
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Goodclips-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Slack posts msg to a Slack incoming webhook as a text message
+func Slack(webhookURL string, msg Message) error {
+	body, err := json.Marshal(map[string]string{"text": fmt.Sprintf("*%s*\n%s", msg.Subject, msg.Text)})
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailConfigured reports whether SMTP_HOST is set
+func EmailConfigured() bool {
+	return os.Getenv("SMTP_HOST") != ""
+}
+
+// Email sends msg as a plain-text email through SMTP_HOST:SMTP_PORT (default 587), authenticating with
+// SMTP_USERNAME/SMTP_PASSWORD when set, from SMTP_FROM
+func Email(to []string, msg Message) error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return fmt.Errorf("SMTP_HOST is not set")
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "goodclips@localhost"
+	}
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", msg.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	return smtp.SendMail(net.JoinHostPort(host, port), auth, from, to, []byte(b.String()))
+}
@@ -0,0 +1,246 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/contentflags"
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/notify"
+	"goodclips-server/internal/queue"
+)
+
+// alertMaxMatches caps the matches one alert records per video and run (ALERT_MAX_MATCHES, default 10)
+func alertMaxMatches() int {
+	if v, err := strconv.Atoi(os.Getenv("ALERT_MAX_MATCHES")); err == nil && v > 0 {
+		return v
+	}
+	return 10
+}
+
+// ProcessAlertEvaluation handles alert evaluation jobs: every active alert is run against the video's
+// scenes and new matches are sent to the alert's webhook and email recipients. Incremental (live)
+// runs only consider scenes from scene_index_from on. Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessAlertEvaluation(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	alerts, err := vp.db.ListAlerts(true)
+	if err != nil {
+		return fmt.Errorf("failed to load alerts: %v", err)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load video %d: %v", videoID, err)
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	from := -1
+	if v, ok := payload["scene_index_from"].(float64); ok {
+		from = int(v)
+	}
+
+	limit := alertMaxMatches()
+	total := 0
+	for i := range alerts {
+		alert := &alerts[i]
+		var matches []models.AlertMatch
+		switch alert.AlertType {
+		case models.AlertTypeKeyword:
+			matches, err = vp.keywordAlertMatches(alert, videoID, captions, from, limit)
+		case models.AlertTypeSemantic:
+			matches, err = vp.semanticAlertMatches(alert, videoID, captions, from, limit)
+		default:
+			err = fmt.Errorf("unknown alert type %q", alert.AlertType)
+		}
+		if err != nil {
+			vp.warnf("alert %d evaluation failed for video %d: %v", alert.ID, videoID, err)
+			continue
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		created, err := vp.db.RecordAlertMatches(alert.ID, matches)
+		if err != nil {
+			vp.warnf("failed to record matches of alert %d: %v", alert.ID, err)
+			continue
+		}
+		if len(created) == 0 {
+			continue
+		}
+		total += len(created)
+		ids := make([]uint, len(created))
+		for j, m := range created {
+			ids[j] = m.ID
+		}
+		derr := deliverAlert(alert, video, created)
+		if derr != nil {
+			vp.warnf("alert %d delivery failed: %v", alert.ID, derr)
+		}
+		if err := vp.db.MarkAlertMatchesNotified(ids, derr); err != nil {
+			vp.warnf("failed to record delivery of alert %d: %v", alert.ID, err)
+		}
+	}
+	log.Printf("[alerts] video_id=%d: %d new matches across %d active alerts", videoID, total, len(alerts))
+	return nil
+}
+
+// alertScenes loads the scenes an alert is evaluated on
+func (vp *VideoProcessor) alertScenes(alert *models.Alert, videoID uint, from int) ([]models.Scene, error) {
+	scenes, err := vp.db.GetScenesByVideoIDAndLevel(videoID, database.SceneLevelOrDefault(alert.Level))
+	if err != nil {
+		return nil, err
+	}
+	kept := scenes[:0]
+	for _, s := range scenes {
+		if s.SceneIndex >= from {
+			kept = append(kept, s)
+		}
+	}
+	return kept, nil
+}
+
+// keywordAlertMatches finds captions containing the alert's word or phrase (case-insensitive, on word
+// boundaries, "*" for stems) and returns the scenes containing them
+func (vp *VideoProcessor) keywordAlertMatches(alert *models.Alert, videoID uint, captions []models.Caption, from, limit int) ([]models.AlertMatch, error) {
+	matcher := contentflags.New([]contentflags.Entry{{Term: alert.Query, Category: "alert"}})
+	scenes, err := vp.alertScenes(alert, videoID, from)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.AlertMatch
+	seen := map[uint]bool{}
+	for _, cp := range captions {
+		if len(matcher.Find(cp.Text)) == 0 {
+			continue
+		}
+		mid := (cp.StartTime + cp.EndTime) / 2
+		for _, s := range scenes {
+			if mid < s.StartTime || mid >= s.EndTime || seen[s.ID] {
+				continue
+			}
+			seen[s.ID] = true
+			out = append(out, alertMatch(s, 1, cp.Text))
+			if len(out) >= limit {
+				return out, nil
+			}
+		}
+	}
+	return out, nil
+}
+
+// semanticAlertMatches returns the video's scenes whose text embedding is at least the alert's
+// threshold similar to its query
+func (vp *VideoProcessor) semanticAlertMatches(alert *models.Alert, videoID uint, captions []models.Caption, from, limit int) ([]models.AlertMatch, error) {
+	if alert.Embedding == nil {
+		return nil, fmt.Errorf("alert has no query embedding")
+	}
+	filter := database.SceneFilter{VideoIDs: []uint{videoID}, Level: alert.Level}
+	scenes, dists, err := vp.db.SearchScenesByTextVector(alert.Embedding.Slice(), limit, filter)
+	if err != nil {
+		return nil, err
+	}
+	metric := database.MetricForColumn(database.ColumnText)
+	var out []models.AlertMatch
+	for i, s := range scenes {
+		sim := metric.Similarity(dists[i])
+		if s.SceneIndex < from || sim < alert.Threshold {
+			continue
+		}
+		var lines []string
+		for _, cp := range captions {
+			if cp.StartTime < s.EndTime && cp.EndTime > s.StartTime {
+				lines = append(lines, cp.Text)
+			}
+		}
+		out = append(out, alertMatch(s, sim, strings.Join(lines, " ")))
+	}
+	return out, nil
+}
+
+// alertMatch builds a match for a scene, trimming the snippet to 280 runes
+func alertMatch(s models.Scene, score float64, snippet string) models.AlertMatch {
+	if r := []rune(snippet); len(r) > 280 {
+		snippet = string(r[:279]) + "…"
+	}
+	return models.AlertMatch{
+		VideoID:    s.VideoID,
+		SceneID:    s.ID,
+		SceneIndex: s.SceneIndex,
+		StartTime:  s.StartTime,
+		EndTime:    s.EndTime,
+		Score:      score,
+		Snippet:    snippet,
+	}
+}
+
+// deliverAlert sends one notification about an alert's new matches to its webhook and email recipients
+func deliverAlert(alert *models.Alert, video *models.Video, matches []models.AlertMatch) error {
+	title := videoTitle(video)
+	videoURL := notify.PublicURL(fmt.Sprintf("/api/v1/videos/%d", video.ID))
+	items := make([]map[string]any, 0, len(matches))
+	var text strings.Builder
+	fmt.Fprintf(&text, "Alert %q (%s: %s) matched %d scene(s) in %q.\n%s\n\n", alert.Name, alert.AlertType, alert.Query, len(matches), title, videoURL)
+	for _, m := range matches {
+		clipURL := notify.PublicURL(fmt.Sprintf("/api/v1/videos/%d/scenes/%d/clip?level=%s", video.ID, m.SceneIndex, database.SceneLevelOrDefault(alert.Level)))
+		items = append(items, map[string]any{
+			"scene_id":    m.SceneID,
+			"scene_index": m.SceneIndex,
+			"start_time":  m.StartTime,
+			"end_time":    m.EndTime,
+			"score":       m.Score,
+			"snippet":     m.Snippet,
+			"clip_url":    clipURL,
+		})
+		fmt.Fprintf(&text, "- %s–%s  %s\n  %s\n", formatTimecode(m.StartTime), formatTimecode(m.EndTime), m.Snippet, clipURL)
+	}
+	msg := notify.Message{
+		Event:   notify.EventAlertMatched,
+		Project: videoProject(video),
+		Subject: fmt.Sprintf("[goodclips] %s: %d new match(es) in %s", alert.Name, len(matches), title),
+		Text:    text.String(),
+		Data: map[string]any{
+			"alert":   map[string]any{"id": alert.ID, "name": alert.Name, "alert_type": alert.AlertType, "query": alert.Query},
+			"video":   map[string]any{"id": video.ID, "title": title, "url": videoURL},
+			"matches": items,
+		},
+		Time: time.Now().UTC(),
+	}
+
+	// Channels subscribed to alert.matched get it too; the alert's own recipients are reported on
+	notify.Publish(msg)
+	var targets []notify.Target
+	if alert.WebhookURL != nil && *alert.WebhookURL != "" {
+		targets = append(targets, notify.Target{Kind: notify.KindWebhook, Address: *alert.WebhookURL})
+	}
+	if len(alert.Emails) > 0 {
+		targets = append(targets, notify.Target{Kind: notify.KindEmail, Address: strings.Join(alert.Emails, ",")})
+	}
+	var errs []string
+	for _, t := range targets {
+		if err := notify.Deliver(t, msg); err != nil {
+			errs = append(errs, t.Kind+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// formatTimecode renders seconds as H:MM:SS
+func formatTimecode(secs float64) string {
+	t := int(secs)
+	return fmt.Sprintf("%d:%02d:%02d", t/3600, t/60%60, t%60)
+}
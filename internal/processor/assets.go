@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+	"goodclips-server/internal/scenedetect"
+)
+
+// audioSegmentSeconds is the length of the fixed windows audio assets are split into (AUDIO_SEGMENT_SECS, default 30)
+func audioSegmentSeconds() float64 {
+	if v := os.Getenv("AUDIO_SEGMENT_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return 30
+}
+
+// assetScenes returns the shots of a non-video asset: fixed windows over an audio file, or a single
+// zero-length shot for a still image
+func assetScenes(video *models.Video) ([]scenedetect.Scene, error) {
+	switch video.AssetType {
+	case models.AssetTypeImage:
+		return []scenedetect.Scene{{Index: 0, StartTime: 0, EndTime: 0}}, nil
+	case models.AssetTypeAudio:
+		if video.Duration <= 0 {
+			return nil, fmt.Errorf("audio asset %d has unknown duration", video.ID)
+		}
+		seg := audioSegmentSeconds()
+		n := int(math.Ceil(video.Duration / seg))
+		scenes := make([]scenedetect.Scene, 0, n)
+		for i := 0; i < n; i++ {
+			scenes = append(scenes, scenedetect.Scene{
+				Index:     i,
+				StartTime: float64(i) * seg,
+				EndTime:   math.Min(float64(i+1)*seg, video.Duration),
+			})
+		}
+		return scenes, nil
+	}
+	return nil, fmt.Errorf("asset type %q has no fixed segmentation", video.AssetType)
+}
+
+// createAssetJobs replaces scene detection and caption extraction for audio and image assets: it
+// stores the asset's fixed segments as scenes and enqueues embedding generation
+func (vp *VideoProcessor) createAssetJobs(video *models.Video) error {
+	scenes, err := assetScenes(video)
+	if err != nil {
+		return err
+	}
+	if _, err := vp.storeSceneLevels(video, scenes); err != nil {
+		return err
+	}
+	vp.reassociateAnnotations(video.ID)
+	log.Printf("Stored %d segments for %s asset %d", len(scenes), video.AssetType, video.ID)
+
+	if vp.jobQueue == nil {
+		log.Printf("Queue not available; skipping enqueue of follow-up jobs for video ID %d", video.ID)
+		return nil
+	}
+	if _, err := vp.jobQueue.Enqueue(queue.JobTypeEmbeddingGeneration, map[string]interface{}{"video_id": video.ID}); err != nil {
+		vp.warnf("Failed to enqueue embedding generation job for video %d: %v", video.ID, err)
+	} else {
+		log.Printf("Enqueued embedding generation job for video ID %d", video.ID)
+	}
+	return nil
+}
+
+// generateAssetEmbeddings computes the embeddings applicable to a non-video asset: CLAP audio
+// embeddings for audio files, CLIP image embeddings for still images
+func (vp *VideoProcessor) generateAssetEmbeddings(video *models.Video, level string, scenes []models.Scene) error {
+	srs := sceneRanges(scenes)
+	var err error
+	switch video.AssetType {
+	case models.AssetTypeAudio:
+		if strings.EqualFold(os.Getenv("ENABLE_AUDIO_EMBEDDINGS"), "false") || os.Getenv("ENABLE_AUDIO_EMBEDDINGS") == "0" {
+			log.Printf("Skipping audio embeddings for video %d due to ENABLE_AUDIO_EMBEDDINGS", video.ID)
+			return nil
+		}
+		err = vp.embedScenesCLAP(video, level, map[string]interface{}{
+			"video_path":  video.Filepath,
+			"scenes":      srs,
+			"sample_rate": 48000,
+		})
+	case models.AssetTypeImage:
+		err = vp.embedScenesCLIP(video, level, map[string]interface{}{
+			"image_path": video.Filepath,
+			"scenes":     srs,
+			"mode":       "image",
+		})
+	default:
+		return fmt.Errorf("asset type %q has no asset embedding stage", video.AssetType)
+	}
+	if err != nil {
+		return err
+	}
+
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	video.Metadata["embedding_metrics"] = database.EmbeddingMetrics()
+	video.Metadata["embeddings_normalized"] = database.NormalizeOnWrite()
+	if err := vp.saveVideo(video, []string{"embedding_metrics", "embeddings_normalized"}, nil); err != nil {
+		vp.warnf("failed to update video metadata: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"goodclips-server/internal/queue"
+)
+
+// ErrRunnerUnavailable marks runner calls refused because the runner's circuit breaker is open
+var ErrRunnerUnavailable = errors.New("runner unavailable")
+
+// RunnerUnavailableError is returned instead of running a runner whose breaker is open. The worker
+// defers the job to RetryAt instead of failing it.
+type RunnerUnavailableError struct {
+	Runner    string
+	RetryAt   time.Time
+	LastError string
+}
+
+func (e *RunnerUnavailableError) Error() string {
+	msg := fmt.Sprintf("%s unavailable: circuit breaker open until %s", e.Runner, e.RetryAt.UTC().Format(time.RFC3339))
+	if e.LastError != "" {
+		msg += "; last error: " + e.LastError
+	}
+	return msg
+}
+
+func (e *RunnerUnavailableError) Is(target error) bool { return target == ErrRunnerUnavailable }
+
+// runnerBreakers holds the queue the breakers are kept in, shared by every worker; nil until a
+// processor is created
+var runnerBreakers struct {
+	sync.RWMutex
+	q      *queue.Queue
+	policy queue.BreakerPolicy
+}
+
+// breakerQueue returns the queue holding the breakers and their policy, or nil when breakers are
+// off (RUNNER_BREAKER_THRESHOLD=0) or no queue is set
+func breakerQueue() (*queue.Queue, queue.BreakerPolicy) {
+	runnerBreakers.RLock()
+	defer runnerBreakers.RUnlock()
+	if runnerBreakers.policy.Threshold == 0 {
+		return nil, runnerBreakers.policy
+	}
+	return runnerBreakers.q, runnerBreakers.policy
+}
+
+// allowRunner returns a RunnerUnavailableError when the runner's breaker refuses the call
+func allowRunner(name string) error {
+	q, policy := breakerQueue()
+	if q == nil {
+		return nil
+	}
+	ok, st, err := q.AllowCall(name, policy)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if ok {
+		if st != nil && st.State == queue.BreakerHalfOpen {
+			log.Printf("[breaker] %s: trial call after cooldown", name)
+		}
+		return nil
+	}
+	unavailable := &RunnerUnavailableError{Runner: name, RetryAt: time.Now().Add(policy.Cooldown), LastError: st.LastError}
+	if st.RetryAt != nil {
+		unavailable.RetryAt = *st.RetryAt
+	}
+	return queue.WithCode(queue.ErrorDependency, unavailable)
+}
+
+// breakerFailure reports whether a runner error says the runner or its dependencies are unhealthy:
+// it crashed, timed out or could not load. Errors about the input (a missing or undecodable file)
+// show a working runner.
+func breakerFailure(err error) bool {
+	switch queue.CodeOf(err) {
+	case queue.ErrorRunnerCrash, queue.ErrorTimeout, queue.ErrorDependency:
+		return true
+	}
+	return false
+}
+
+// recordRunnerOutcome updates the runner's breaker after a call. Calls stopped by the caller
+// (cancellation, a rejected vector) say nothing about the runner's health.
+func recordRunnerOutcome(ctx context.Context, name string, err error, callerStopped bool) {
+	q, policy := breakerQueue()
+	if q == nil || ctx.Err() != nil || callerStopped {
+		return
+	}
+	if err == nil || !breakerFailure(err) {
+		if rerr := q.RecordCallSuccess(name); rerr != nil {
+			log.Printf("Warning: %v", rerr)
+		}
+		return
+	}
+	st, rerr := q.RecordCallFailure(name, err, policy)
+	if rerr != nil {
+		log.Printf("Warning: %v", rerr)
+		return
+	}
+	if st.State == queue.BreakerOpen && st.RetryAt != nil {
+		log.Printf("[breaker] %s: open after %d consecutive failures, retry at %s: %v", name, st.Failures, st.RetryAt.Format(time.RFC3339), err)
+	}
+}
+
+// runnerProbes are the health probe payloads of the runners behind breakers: the device each runner
+// uses and the modules it imports
+var runnerProbes = map[string]func() map[string]interface{}{
+	"iv2_runner": func() map[string]interface{} {
+		return map[string]interface{}{"device": iv2Device(), "modules": []string{"transformers", "decord", "cv2"}}
+	},
+	"clip_runner": func() map[string]interface{} {
+		return map[string]interface{}{"device": os.Getenv("CLIP_DEVICE"), "modules": []string{"decord", "PIL"}}
+	},
+	"audio_embed_runner": func() map[string]interface{} {
+		return map[string]interface{}{"device": os.Getenv("CLAP_DEVICE"), "modules": []string{"transformers", "librosa"}}
+	},
+	"text_embed_runner": func() map[string]interface{} {
+		return map[string]interface{}{"device": os.Getenv("E5_DEVICE"), "modules": []string{"transformers"}}
+	},
+}
+
+// runnerProbeTimeout bounds a health probe (RUNNER_PROBE_TIMEOUT_SECS, default 60)
+func runnerProbeTimeout() time.Duration {
+	if secs, err := strconv.Atoi(os.Getenv("RUNNER_PROBE_TIMEOUT_SECS")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return time.Minute
+}
+
+// probeRunner runs runner_health.py with the runner's probe payload
+func probeRunner(ctx context.Context, name string) error {
+	payload := map[string]interface{}{}
+	if probe, ok := runnerProbes[name]; ok {
+		payload = probe()
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, runnerProbeTimeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "python3", "/root/internal/embeddings/runner_health.py")
+	cmd.Stdin = bytes.NewReader(b)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	var res struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &res); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("%s health probe failed: %v; stderr: %s", name, runErr, stderr.String())
+		}
+		return fmt.Errorf("%s health probe returned invalid output: %v", name, err)
+	}
+	if !res.OK {
+		return fmt.Errorf("%s health probe failed: %s", name, res.Error)
+	}
+	return nil
+}
+
+// ProbeRunnerBreakers health-probes each runner whose breaker finished its cooldown and is not
+// already being tried. A passing probe closes the breaker, so deferred jobs run again when they come
+// due; a failing one keeps it open for another cooldown. It returns the runners whose breaker
+// closed.
+func (vp *VideoProcessor) ProbeRunnerBreakers(ctx context.Context) ([]string, error) {
+	q, policy := breakerQueue()
+	if q == nil {
+		return nil, nil
+	}
+	breakers, err := q.Breakers()
+	if err != nil {
+		return nil, err
+	}
+	var closed []string
+	for _, st := range breakers {
+		if st.State == queue.BreakerClosed {
+			continue
+		}
+		ok, _, err := q.AllowCall(st.Name, policy)
+		if err != nil || !ok {
+			continue
+		}
+		if perr := probeRunner(ctx, st.Name); perr != nil {
+			if ctx.Err() != nil {
+				return closed, nil
+			}
+			log.Printf("[breaker] %s: %v", st.Name, perr)
+			if _, rerr := q.RecordCallFailure(st.Name, perr, policy); rerr != nil {
+				log.Printf("Warning: %v", rerr)
+			}
+			continue
+		}
+		if err := q.RecordCallSuccess(st.Name); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		log.Printf("[breaker] %s: health probe passed, breaker closed", st.Name)
+		closed = append(closed, st.Name)
+	}
+	return closed, nil
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// captionEmbedBatch is how many passages are sent to the embedding runner per call
+const captionEmbedBatch = 256
+
+// captionEmbeddingsAuto reports whether caption extraction enqueues a caption embedding job (CAPTION_EMBEDDINGS, default false)
+func captionEmbeddingsAuto() bool {
+	if v, err := strconv.ParseBool(os.Getenv("CAPTION_EMBEDDINGS")); err == nil {
+		return v
+	}
+	return false
+}
+
+// captionWindowFromEnv reads CAPTION_EMBED_WINDOW, the captions per passage (default 1), and
+// CAPTION_EMBED_STRIDE, the captions between passage starts (default the window, i.e. no overlap)
+func captionWindowFromEnv() (window, stride int) {
+	window = 1
+	if v, err := strconv.Atoi(os.Getenv("CAPTION_EMBED_WINDOW")); err == nil && v > 0 {
+		window = v
+	}
+	stride = window
+	if v, err := strconv.Atoi(os.Getenv("CAPTION_EMBED_STRIDE")); err == nil && v > 0 {
+		stride = v
+	}
+	return window, stride
+}
+
+// captionPassages groups time-ordered captions into passages of window captions starting every
+// stride captions; the last passage always reaches the final caption
+func captionPassages(videoID uint, captions []models.Caption, window, stride int) []models.CaptionEmbedding {
+	var out []models.CaptionEmbedding
+	for from := 0; from < len(captions); from += stride {
+		to := from + window
+		if to > len(captions) {
+			to = len(captions)
+		}
+		parts := make([]string, 0, to-from)
+		for _, c := range captions[from:to] {
+			if t := strings.TrimSpace(c.Text); t != "" {
+				parts = append(parts, t)
+			}
+		}
+		if len(parts) > 0 {
+			out = append(out, models.CaptionEmbedding{
+				VideoID:      videoID,
+				CaptionID:    captions[from].ID,
+				CaptionCount: to - from,
+				StartTime:    captions[from].StartTime,
+				EndTime:      captions[to-1].EndTime,
+				Text:         strings.Join(parts, " "),
+			})
+		}
+		if to == len(captions) {
+			break
+		}
+	}
+	return out
+}
+
+// ProcessCaptionEmbedding embeds a video's captions, one by one or in sliding windows, for passage
+// search, replacing any earlier passages. Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessCaptionEmbedding(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	sort.SliceStable(captions, func(i, j int) bool { return captions[i].StartTime < captions[j].StartTime })
+
+	window, stride := captionWindowFromEnv()
+	passages := captionPassages(videoID, captions, window, stride)
+	for from := 0; from < len(passages); from += captionEmbedBatch {
+		to := from + captionEmbedBatch
+		if to > len(passages) {
+			to = len(passages)
+		}
+		texts := make([]string, to-from)
+		for i, p := range passages[from:to] {
+			texts[i] = p.Text
+		}
+		vecs, err := embedTexts(vp.context(), texts, "passage")
+		if err != nil {
+			return fmt.Errorf("failed to embed caption passages: %v", err)
+		}
+		for i, vec := range vecs {
+			v := pgvector.NewVector(vec)
+			passages[from+i].Embedding = &v
+		}
+	}
+
+	if err := vp.db.ReplaceCaptionEmbeddings(videoID, passages); err != nil {
+		return fmt.Errorf("failed to store caption passages: %v", err)
+	}
+	vp.countEmbeddings("caption_passage", len(passages))
+	log.Printf("[passages] video_id=%d: embedded %d passages from %d captions (window %d, stride %d)", videoID, len(passages), len(captions), window, stride)
+	return nil
+}
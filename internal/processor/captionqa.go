@@ -0,0 +1,199 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// mojibakeRe matches the traces UTF-8 text leaves when decoded as Latin-1 or Windows-1252 ("Ã©",
+// "â€™", a BOM read as "ï»¿"), replacement characters and C1 control characters
+var mojibakeRe = regexp.MustCompile(`[ÃÂ][\x{0080}-\x{00BF}]|â€|ï»¿|\x{FFFD}|[\x{0080}-\x{009F}]`)
+
+// maxCaptionQAIssues caps how many example issues a report keeps
+const maxCaptionQAIssues = 100
+
+// captionQASettings are the thresholds of caption QA
+type captionQASettings struct {
+	GapSecs       float64 // CAPTION_QA_GAP_SECS, default 45
+	MaxCPS        float64 // CAPTION_QA_MAX_CPS, default 25 characters per second
+	MinConfidence float64 // CAPTION_QA_MIN_CONFIDENCE, default 0.5
+	MinCoverage   float64 // CAPTION_QA_MIN_COVERAGE, default 0.3 of the runtime
+}
+
+func captionQASettingsFromEnv() captionQASettings {
+	s := captionQASettings{GapSecs: 45, MaxCPS: 25, MinConfidence: 0.5, MinCoverage: 0.3}
+	for name, dst := range map[string]*float64{
+		"CAPTION_QA_GAP_SECS":       &s.GapSecs,
+		"CAPTION_QA_MAX_CPS":        &s.MaxCPS,
+		"CAPTION_QA_MIN_CONFIDENCE": &s.MinConfidence,
+		"CAPTION_QA_MIN_COVERAGE":   &s.MinCoverage,
+	} {
+		if f, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && f > 0 {
+			*dst = f
+		}
+	}
+	return s
+}
+
+// hasMojibake reports whether caption text looks like it was decoded with the wrong encoding
+func hasMojibake(s string) bool {
+	return !utf8.ValidString(s) || mojibakeRe.MatchString(s)
+}
+
+// CaptionQA scores a video's captions: how much of the runtime they cover, their average confidence,
+// long gaps, overlaps, captions too fast to read or without duration, and mis-decoded text. The score
+// multiplies a coverage factor (1 from MinCoverage up), the average confidence and the share of
+// captions without problems; a video without captions scores 0.
+func CaptionQA(video *models.Video, captions []models.Caption) *models.CaptionQAReport {
+	set := captionQASettingsFromEnv()
+	r := &models.CaptionQAReport{
+		VideoID:      video.ID,
+		CaptionCount: len(captions),
+		Duration:     video.Duration,
+		ComputedAt:   time.Now(),
+	}
+	if len(captions) == 0 {
+		if video.Duration > 0 {
+			r.GapCount, r.LongestGap = 1, video.Duration
+			r.Issues = models.CaptionQAIssues{{Type: models.CaptionIssueGap, StartTime: 0, EndTime: video.Duration, Detail: "no captions"}}
+		}
+		return r
+	}
+	sorted := append([]models.Caption(nil), captions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartTime < sorted[j].StartTime })
+
+	issue := func(it models.CaptionQAIssue) {
+		if len(r.Issues) < maxCaptionQAIssues {
+			r.Issues = append(r.Issues, it)
+		}
+	}
+	gap := func(start, end float64) {
+		if end-start < set.GapSecs {
+			return
+		}
+		r.GapCount++
+		r.LongestGap = math.Max(r.LongestGap, end-start)
+		issue(models.CaptionQAIssue{Type: models.CaptionIssueGap, StartTime: start, EndTime: end, Detail: fmt.Sprintf("%.0fs without captions", end-start)})
+	}
+
+	bad := map[uint]bool{}
+	var confidence float64
+	// covered is the end of the union of caption intervals so far
+	covered := 0.0
+	gap(0, sorted[0].StartTime)
+	for i, c := range sorted {
+		id := c.ID
+		confidence += c.Confidence
+		d := c.EndTime - c.StartTime
+		if c.Confidence < set.MinConfidence {
+			r.LowConfidenceCount++
+			bad[c.ID] = true
+			issue(models.CaptionQAIssue{Type: models.CaptionIssueLowConfidence, CaptionID: &id, StartTime: c.StartTime, EndTime: c.EndTime, Detail: fmt.Sprintf("confidence %.2f", c.Confidence)})
+		}
+		if d <= 0 {
+			r.ZeroDurationCount++
+			bad[c.ID] = true
+			issue(models.CaptionQAIssue{Type: models.CaptionIssueZeroDuration, CaptionID: &id, StartTime: c.StartTime, EndTime: c.EndTime})
+		} else if cps := float64(utf8.RuneCountInString(c.Text)) / d; cps > set.MaxCPS {
+			r.FastCount++
+			bad[c.ID] = true
+			issue(models.CaptionQAIssue{Type: models.CaptionIssueFast, CaptionID: &id, StartTime: c.StartTime, EndTime: c.EndTime, Detail: fmt.Sprintf("%.0f characters per second", cps)})
+		}
+		if hasMojibake(c.Text) {
+			r.MojibakeCount++
+			bad[c.ID] = true
+			issue(models.CaptionQAIssue{Type: models.CaptionIssueMojibake, CaptionID: &id, StartTime: c.StartTime, EndTime: c.EndTime, Detail: c.Text})
+		}
+		if i > 0 && c.StartTime < sorted[i-1].EndTime-0.05 {
+			r.OverlapCount++
+			bad[c.ID] = true
+			issue(models.CaptionQAIssue{Type: models.CaptionIssueOverlap, CaptionID: &id, StartTime: c.StartTime, EndTime: sorted[i-1].EndTime,
+				Detail: fmt.Sprintf("overlaps caption %d by %.2fs", sorted[i-1].ID, sorted[i-1].EndTime-c.StartTime)})
+		}
+		if i > 0 {
+			gap(covered, c.StartTime)
+		}
+		if d > 0 {
+			r.CaptionedSeconds += math.Max(0, c.EndTime-math.Max(c.StartTime, covered))
+		}
+		covered = math.Max(covered, c.EndTime)
+	}
+	if video.Duration > covered {
+		gap(covered, video.Duration)
+	}
+	r.AvgConfidence = confidence / float64(len(sorted))
+
+	coverageFactor := 1.0
+	if video.Duration > 0 {
+		r.Coverage = math.Min(1, r.CaptionedSeconds/video.Duration)
+		coverageFactor = math.Min(1, r.Coverage/set.MinCoverage)
+	}
+	clean := 1 - float64(len(bad))/float64(len(sorted))
+	r.Score = coverageFactor * math.Max(0, math.Min(1, r.AvgConfidence)) * clean
+	return r
+}
+
+// enqueueCaptionQA schedules a caption QA report after a video's captions change. Videos without
+// captions get one too: a missing transcript is the worst caption quality.
+func (vp *VideoProcessor) enqueueCaptionQA(videoID uint) {
+	if vp.jobQueue == nil {
+		return
+	}
+	if _, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionQA, map[string]interface{}{"video_id": videoID}); err != nil {
+		vp.warnf("Failed to enqueue caption QA job for video %d: %v", videoID, err)
+	}
+}
+
+// ProcessCaptionQA computes and stores the caption QA report of one video, or of every video that is
+// not deleted when the payload has no video_id. Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessCaptionQA(payload map[string]interface{}) error {
+	var ids []uint
+	if videoID, ok := queue.PayloadVideoID(payload); ok {
+		ids = []uint{videoID}
+	} else {
+		all, err := vp.db.AllVideoIDs()
+		if err != nil {
+			return fmt.Errorf("failed to list videos: %v", err)
+		}
+		ids = all
+	}
+	failed := 0
+	for _, id := range ids {
+		if _, err := vp.RefreshCaptionQA(id); err != nil {
+			if len(ids) == 1 {
+				return err
+			}
+			vp.warnf("caption QA of video %d failed: %v", id, err)
+			failed++
+		}
+	}
+	log.Printf("[captionqa] checked %d videos (%d failed)", len(ids), failed)
+	return nil
+}
+
+// RefreshCaptionQA recomputes and stores a video's caption QA report
+func (vp *VideoProcessor) RefreshCaptionQA(videoID uint) (*models.CaptionQAReport, error) {
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %v", err)
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load captions: %v", err)
+	}
+	r := CaptionQA(video, captions)
+	if err := vp.db.SaveCaptionQAReport(r); err != nil {
+		return nil, fmt.Errorf("failed to store caption QA report: %v", err)
+	}
+	return r, nil
+}
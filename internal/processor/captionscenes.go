@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// captionShare is the part of a caption attributed to one scene
+type captionShare struct {
+	// Scene indexes the scenes the caption was attributed among
+	Scene int
+	// Fraction is the share of the caption the scene gets (1 unless split)
+	Fraction float64
+	Text     string
+}
+
+// captionShares attributes a caption's text to the scenes (of one level) it overlaps under strategy
+// (see database.CaptionSceneStrategy). A
+// zero-length caption belongs to the scene containing it.
+func captionShares(c models.Caption, text string, scenes []models.Scene, strategy string) []captionShare {
+	var shares []captionShare
+	total := 0.0
+	for i, s := range scenes {
+		if ov := math.Min(c.EndTime, s.EndTime) - math.Max(c.StartTime, s.StartTime); ov > 0 {
+			shares = append(shares, captionShare{Scene: i, Fraction: ov})
+			total += ov
+		}
+	}
+	if len(shares) == 0 {
+		for i, s := range scenes {
+			if c.StartTime >= s.StartTime && c.StartTime < s.EndTime {
+				return []captionShare{{Scene: i, Fraction: 1, Text: text}}
+			}
+		}
+		return nil
+	}
+
+	switch strategy {
+	case database.CaptionSceneMajority:
+		best := shares[0]
+		for _, sh := range shares[1:] {
+			if sh.Fraction > best.Fraction {
+				best = sh
+			}
+		}
+		return []captionShare{{Scene: best.Scene, Fraction: 1, Text: text}}
+	case database.CaptionSceneSplit:
+		// Consecutive runs of words, so each scene gets the part of the line spoken during it
+		words := strings.Fields(text)
+		out := shares[:0]
+		cum, from := 0.0, 0
+		for i, sh := range shares {
+			sh.Fraction /= total
+			cum += sh.Fraction
+			to := int(math.Round(cum * float64(len(words))))
+			if i == len(shares)-1 {
+				to = len(words)
+			}
+			if to > from {
+				sh.Text = strings.Join(words[from:to], " ")
+				out = append(out, sh)
+			}
+			from = to
+		}
+		return out
+	}
+	for i := range shares {
+		shares[i].Fraction, shares[i].Text = 1, text
+	}
+	return shares
+}
+
+// CaptionSceneIndex is the index in scenes (of one level) of the scene a caption is linked to by
+// its scene_id, or -1 when it has none: the scene it overlaps most, or under the overlap strategy
+// the scene containing its midpoint, the way caption search attributes hits
+func CaptionSceneIndex(c models.Caption, scenes []models.Scene, strategy string) int {
+	if strategy == database.CaptionSceneOverlap {
+		mid := (c.StartTime + c.EndTime) / 2
+		for i, s := range scenes {
+			if mid >= s.StartTime && mid < s.EndTime {
+				return i
+			}
+		}
+	}
+	shares := captionShares(c, "", scenes, database.CaptionSceneMajority)
+	if len(shares) == 0 {
+		return -1
+	}
+	return shares[0].Scene
+}
+
+// linkCaptionScenes links each of a video's captions to the shot CaptionSceneIndex picks under
+// CaptionSceneStrategy and updates the caption stats of its shots and beats. A video without shots
+// is left alone.
+func (vp *VideoProcessor) linkCaptionScenes(videoID uint) error {
+	shots, err := vp.db.GetScenesByVideoIDAndLevel(videoID, models.SceneLevelShot)
+	if err != nil {
+		return fmt.Errorf("failed to load shots: %v", err)
+	}
+	if len(shots) == 0 {
+		return nil
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	strategy := database.CaptionSceneStrategy()
+	links := make(map[uint]*uint, len(captions))
+	linked := 0
+	for _, c := range captions {
+		var sceneID *uint
+		if i := CaptionSceneIndex(c, shots, strategy); i >= 0 {
+			sceneID = &shots[i].ID
+			linked++
+		}
+		links[c.ID] = sceneID
+	}
+	if err := vp.db.LinkCaptionScenes(videoID, links); err != nil {
+		return err
+	}
+	log.Printf("Linked %d/%d captions of video ID %d to its %d shots", linked, len(captions), videoID, len(shots))
+	return nil
+}
+
+// relinkCaptionScenes re-links a video's captions after its scenes or caption timings changed
+func (vp *VideoProcessor) relinkCaptionScenes(videoID uint) {
+	if err := vp.linkCaptionScenes(videoID); err != nil {
+		vp.warnf("Failed to link captions of video ID %d to scenes: %v", videoID, err)
+	}
+}
+
+// ProcessCaptionLinking handles caption linking jobs, enqueued to run once a video's scene
+// detection and caption extraction have both settled. Payload: {"video_id": n}
+func (vp *VideoProcessor) ProcessCaptionLinking(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	return vp.linkCaptionScenes(videoID)
+}
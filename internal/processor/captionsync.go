@@ -0,0 +1,218 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// Caption sync modes: one offset for the whole video, or offsets per window interpolated over time
+// (for subtitles that drift, e.g. timed for a different frame rate)
+const (
+	CaptionSyncGlobal    = "global"
+	CaptionSyncPiecewise = "piecewise"
+)
+
+// ValidCaptionSyncMode reports whether mode names a caption sync mode
+func ValidCaptionSyncMode(mode string) bool {
+	return mode == CaptionSyncGlobal || mode == CaptionSyncPiecewise
+}
+
+// captionSyncMode is the mode used when a job does not name one (CAPTION_SYNC_MODE, default piecewise)
+func captionSyncMode() string {
+	if m := os.Getenv("CAPTION_SYNC_MODE"); ValidCaptionSyncMode(m) {
+		return m
+	}
+	return CaptionSyncPiecewise
+}
+
+// captionSyncFloat reads a non-negative number from the environment
+func captionSyncFloat(name string, def float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && f >= 0 {
+		return f
+	}
+	return def
+}
+
+// syncOffset is a caption offset estimated by the caption sync runner; positive offsets move captions later
+type syncOffset struct {
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Offset   float64 `json:"offset"`
+	Score    float64 `json:"score"`
+	Captions int     `json:"captions"`
+	// Fallback is set for windows that kept the global offset
+	Fallback bool `json:"fallback"`
+}
+
+// syncResult is the output of the caption sync runner
+type syncResult struct {
+	Global        syncOffset   `json:"global"`
+	Segments      []syncOffset `json:"segments"`
+	AudioDuration float64      `json:"audio_duration"`
+}
+
+// runCaptionSync estimates caption offsets by cross-correlating caption timings with voice activity
+func runCaptionSync(ctx context.Context, req map[string]interface{}) (*syncResult, error) {
+	b, _ := json.Marshal(req)
+	cmd := exec.CommandContext(ctx, "python3", "/root/internal/embeddings/caption_sync_runner.py")
+	cmd.Stdin = bytes.NewReader(b)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("caption_sync_runner failed: %v; stderr: %s", err, stderr.String())
+	}
+	var resp struct {
+		syncResult
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse caption_sync_runner output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("caption_sync_runner error: %s", resp.Error)
+	}
+	return &resp.syncResult, nil
+}
+
+// offsetAt interpolates the offset at time t linearly between window centres, holding the first and
+// last window's offset beyond them
+func offsetAt(segments []syncOffset, t float64) float64 {
+	center := func(s syncOffset) float64 { return (s.Start + s.End) / 2 }
+	if t <= center(segments[0]) {
+		return segments[0].Offset
+	}
+	for i := 1; i < len(segments); i++ {
+		a, b := segments[i-1], segments[i]
+		if t <= center(b) {
+			f := (t - center(a)) / (center(b) - center(a))
+			return a.Offset + f*(b.Offset-a.Offset)
+		}
+	}
+	return segments[len(segments)-1].Offset
+}
+
+// ProcessCaptionSync corrects caption timings that are offset from the speech: the runner finds the
+// shift that best lines captions up with voice activity in the audio, for the whole video and per
+// window, and the captions are moved by the global offset or by the window offsets interpolated over
+// time. Matches scoring under CAPTION_SYNC_MIN_SCORE leave the captions untouched. The outcome is
+// recorded in the video's metadata under caption_sync.
+// Payload: {"video_id": 6, "mode": "piecewise"}
+func (vp *VideoProcessor) ProcessCaptionSync(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	mode, _ := payload["mode"].(string)
+	if mode == "" {
+		mode = captionSyncMode()
+	}
+	if !ValidCaptionSyncMode(mode) {
+		return fmt.Errorf("invalid caption sync mode %q", mode)
+	}
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	if video.Locked {
+		return queue.WithCode(queue.ErrorRefused, fmt.Errorf("video %d is locked (legal hold); refusing to retime captions", video.ID))
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	if len(captions) == 0 {
+		log.Printf("[captionsync] video_id=%d: no captions", videoID)
+		return nil
+	}
+
+	reqCaptions := make([]map[string]interface{}, len(captions))
+	for i, c := range captions {
+		reqCaptions[i] = map[string]interface{}{"start": c.StartTime, "end": c.EndTime}
+	}
+	res, err := runCaptionSync(vp.context(), map[string]interface{}{"video_path": video.Filepath, "captions": reqCaptions})
+	if err != nil {
+		return err
+	}
+
+	minScore := captionSyncFloat("CAPTION_SYNC_MIN_SCORE", 0.1)
+	minOffset := captionSyncFloat("CAPTION_SYNC_MIN_OFFSET", 0.05)
+	report := models.JSONObject{
+		"mode":       mode,
+		"offset":     res.Global.Offset,
+		"score":      res.Global.Score,
+		"segments":   res.Segments,
+		"applied":    false,
+		"checked_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	var shifted []models.Caption
+	if res.Global.Score < minScore {
+		report["reason"] = "no clear match between captions and speech"
+	} else {
+		for _, c := range captions {
+			off := res.Global.Offset
+			if mode == CaptionSyncPiecewise && len(res.Segments) > 0 {
+				off = offsetAt(res.Segments, (c.StartTime+c.EndTime)/2)
+			}
+			if math.Abs(off) < minOffset {
+				continue
+			}
+			start, end := c.StartTime+off, c.EndTime+off
+			if start < 0 {
+				start = 0
+			}
+			if end <= start {
+				continue
+			}
+			c.StartTime, c.EndTime = start, end
+			shifted = append(shifted, c)
+		}
+		if len(shifted) == 0 {
+			report["reason"] = "captions are in sync"
+		}
+	}
+	if len(shifted) > 0 {
+		if err := vp.db.UpdateCaptionTimes(shifted); err != nil {
+			return fmt.Errorf("failed to store caption timings: %v", err)
+		}
+		report["applied"] = true
+		report["captions_shifted"] = len(shifted)
+		vp.relinkCaptionScenes(videoID)
+	}
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	video.Metadata["caption_sync"] = report
+	if err := vp.saveVideo(video, []string{"caption_sync"}, nil); err != nil {
+		return fmt.Errorf("failed to record caption sync: %v", err)
+	}
+	log.Printf("[captionsync] video_id=%d: %s offset %.2fs (score %.2f), shifted %d of %d captions",
+		videoID, mode, res.Global.Offset, res.Global.Score, len(shifted), len(captions))
+
+	// Gaps and overlaps, word timings and passage embeddings depend on caption times; recompute them
+	if len(shifted) > 0 && vp.jobQueue != nil {
+		vp.enqueueCaptionQA(videoID)
+		if StageEnabled(FlagWordAlignment) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeWordAlignment, map[string]interface{}{"video_id": videoID}); err != nil {
+				vp.warnf("Failed to enqueue word alignment job for video %d: %v", videoID, err)
+			}
+		}
+		if StageEnabled(FlagCaptionEmbeddings) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionEmbedding, map[string]interface{}{"video_id": videoID}); err != nil {
+				vp.warnf("Failed to enqueue caption embedding job for video %d: %v", videoID, err)
+			}
+		}
+	}
+	return nil
+}
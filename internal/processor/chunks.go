@@ -0,0 +1,184 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+	"goodclips-server/internal/scenedetect"
+)
+
+// sceneChunkSeconds is the length of the time chunks long videos are split into for scene detection
+// (SCENE_CHUNK_SECS, default 1800; 0 disables chunking)
+func sceneChunkSeconds() float64 {
+	if v := os.Getenv("SCENE_CHUNK_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			return f
+		}
+	}
+	return 1800
+}
+
+// chunkBoundaryEpsilon is how close a scene edge must be to a chunk edge to count as an artificial cut
+const chunkBoundaryEpsilon = 0.05
+
+// sceneChunkRanges splits [0, duration) into consecutive ranges of at most size seconds. A short
+// remainder is folded into the last range rather than becoming a tiny chunk of its own.
+func sceneChunkRanges(duration, size float64) [][2]float64 {
+	if size <= 0 || duration <= size {
+		return [][2]float64{{0, duration}}
+	}
+	var ranges [][2]float64
+	for start := 0.0; start < duration; start += size {
+		end := start + size
+		if duration-end < size/4 {
+			end = duration
+		}
+		ranges = append(ranges, [2]float64{start, end})
+		if end >= duration {
+			break
+		}
+	}
+	return ranges
+}
+
+// sceneChunkResult is what a chunk job records for the stitching job
+type sceneChunkResult struct {
+	Start  float64             `json:"start"`
+	End    float64             `json:"end"`
+	Scenes []scenedetect.Scene `json:"scenes"`
+}
+
+// splitSceneDetection enqueues one scene detection job per time chunk of a long video; with refresh
+// the chunk jobs ignore cached results
+func (vp *VideoProcessor) splitSceneDetection(video *models.Video, filepathStr string, chunks [][2]float64, refresh bool) error {
+	if vp.jobQueue == nil {
+		return fmt.Errorf("queue not available; cannot split scene detection for video %d", video.ID)
+	}
+	group := fmt.Sprintf("scenes:%d:%d", video.ID, time.Now().UnixNano())
+	chunkJobs := make([]string, 0, len(chunks))
+	for i, c := range chunks {
+		payload := map[string]interface{}{
+			"video_id":    video.ID,
+			"filename":    video.Filename,
+			"filepath":    filepathStr,
+			"chunk_group": group,
+			"chunk_index": i,
+			"chunk_count": len(chunks),
+			"chunk_start": c[0],
+			"chunk_end":   c[1],
+		}
+		if refresh {
+			payload["refresh_scenes"] = true
+		}
+		job, err := vp.jobQueue.Enqueue(queue.JobTypeSceneDetection, payload)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue scene detection chunk %d for video %d: %v", i, video.ID, err)
+		}
+		chunkJobs = append(chunkJobs, job.ID)
+	}
+	vp.setResult("scene_chunks", len(chunks))
+	// Jobs waiting for this one need the stitched scenes, which the last chunk stores
+	if vp.jobID != "" {
+		if err := vp.jobQueue.AddDependencies(vp.jobID, chunkJobs); err != nil {
+			return fmt.Errorf("failed to hand dependents off to scene detection chunks of video %d: %v", video.ID, err)
+		}
+	}
+	log.Printf("Split scene detection for video ID %d (%.0fs) into %d chunks", video.ID, video.Duration, len(chunks))
+	return nil
+}
+
+// processSceneChunk detects the scenes of one chunk and records them; the job completing the group
+// stitches all chunks back into a single scene list for the video
+func (vp *VideoProcessor) processSceneChunk(video *models.Video, filepathStr string, payload map[string]interface{}) error {
+	group, _ := payload["chunk_group"].(string)
+	index, ok1 := payload["chunk_index"].(float64)
+	count, ok2 := payload["chunk_count"].(float64)
+	start, ok3 := payload["chunk_start"].(float64)
+	end, ok4 := payload["chunk_end"].(float64)
+	if group == "" || !ok1 || !ok2 || !ok3 || !ok4 {
+		return fmt.Errorf("invalid scene detection chunk payload")
+	}
+	if vp.jobQueue == nil {
+		return fmt.Errorf("queue not available; cannot record scene detection chunk for video %d", video.ID)
+	}
+
+	scenes, err := vp.detectScenesCached(video, filepathStr, start, end, 0, refreshScenes(payload))
+	if err != nil {
+		return fmt.Errorf("failed to detect scenes in chunk %d (%.2f-%.2fs): %v", int(index), start, end, err)
+	}
+	log.Printf("Detected %d scenes in chunk %d/%d (%.2f-%.2fs) for video ID %d", len(scenes), int(index)+1, int(count), start, end, video.ID)
+
+	data, err := json.Marshal(sceneChunkResult{Start: start, End: end, Scenes: scenes})
+	if err != nil {
+		return err
+	}
+	last, err := vp.jobQueue.StoreChunkResult(group, int(index), int(count), data, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if !last {
+		return nil
+	}
+
+	raw, err := vp.jobQueue.ChunkResults(group)
+	if err != nil {
+		return err
+	}
+	results := make([]sceneChunkResult, 0, len(raw))
+	for _, data := range raw {
+		var r sceneChunkResult
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("failed to decode scene chunk result: %v", err)
+		}
+		results = append(results, r)
+	}
+	stitched := stitchSceneChunks(results)
+	log.Printf("Stitched %d chunks into %d scenes for video ID %d", len(results), len(stitched), video.ID)
+
+	// Reload: the video may have changed while the chunks were running
+	video, err = vp.db.GetVideoByID(video.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	video.Metadata["scene_chunks"] = len(results)
+	if err := vp.finishSceneDetection(video, filepathStr, stitched); err != nil {
+		return err
+	}
+	if err := vp.jobQueue.ClearChunkGroup(group); err != nil {
+		vp.warnf("Failed to clear scene chunk results %s: %v", group, err)
+	}
+	return nil
+}
+
+// stitchSceneChunks concatenates per-chunk scenes in time order and renumbers them. A chunk edge is
+// not a real cut, so the scenes on either side of it are joined back into one.
+func stitchSceneChunks(results []sceneChunkResult) []scenedetect.Scene {
+	sort.Slice(results, func(i, j int) bool { return results[i].Start < results[j].Start })
+	var scenes []scenedetect.Scene
+	for i, r := range results {
+		for j, s := range r.Scenes {
+			if i > 0 && j == 0 && len(scenes) > 0 {
+				prev := &scenes[len(scenes)-1]
+				if prev.EndTime >= r.Start-chunkBoundaryEpsilon && s.StartTime <= r.Start+chunkBoundaryEpsilon {
+					prev.EndTime = s.EndTime
+					continue
+				}
+			}
+			scenes = append(scenes, s)
+		}
+	}
+	for i := range scenes {
+		scenes[i].Index = i
+	}
+	return scenes
+}
@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+)
+
+// ProcessClipExport handles clip export jobs. Payload: {"clip_id": 9}
+func (vp *VideoProcessor) ProcessClipExport(payload map[string]interface{}) error {
+	id, ok := payload["clip_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid clip_id in payload")
+	}
+	e, err := vp.db.GetClipExport(uint(id))
+	if err != nil {
+		return fmt.Errorf("failed to load clip export %d: %v", uint(id), err)
+	}
+	e.Status = models.HighlightStatusProcessing
+	e.ErrorMessage = nil
+	if err := vp.db.UpdateClipExport(e); err != nil {
+		return fmt.Errorf("failed to update clip export: %v", err)
+	}
+
+	if err := vp.exportClip(e); err != nil {
+		msg := err.Error()
+		e.Status = models.HighlightStatusFailed
+		e.ErrorMessage = &msg
+		if uerr := vp.db.UpdateClipExport(e); uerr != nil {
+			vp.warnf("failed to record clip export %d failure: %v", e.ID, uerr)
+		}
+		return err
+	}
+	e.Status = models.HighlightStatusCompleted
+	if err := vp.db.UpdateClipExport(e); err != nil {
+		return fmt.Errorf("failed to store clip export: %v", err)
+	}
+	log.Printf("[clips] clip_id=%d: scene %d of video %d, %.2f-%.2fs by %s (%d bytes)", e.ID, e.SceneID, e.VideoID, e.StartTime, e.EndTime, *e.Method, e.FileSize)
+	return nil
+}
+
+// exportClip cuts the clip's range into an MP4 under highlightDir()/clips. In auto mode the source is
+// stream-copied when it has MP4-compatible codecs and no watermark or censoring applies, falling back
+// to a re-encode if the copy fails.
+func (vp *VideoProcessor) exportClip(e *models.ClipExport) error {
+	video, err := vp.db.GetVideoByID(e.VideoID)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	// Exports are requested without a verified user; {user_id} names the export instead
+	wm, err := vp.WatermarkFor(video, nil, fmt.Sprintf("export %d", e.ID))
+	if err != nil {
+		return err
+	}
+	var censor []ffmpeg.Interval
+	if e.Censor != ffmpeg.CensorNone {
+		flags, err := vp.db.GetContentFlagsInRange(video.ID, e.StartTime, e.EndTime, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load content flags: %v", err)
+		}
+		for _, f := range flags {
+			censor = append(censor, ffmpeg.Interval{Start: f.StartTime, End: f.EndTime})
+		}
+		if has, err := vp.ffmpegClient.HasAudioStream(video.Filepath); err != nil || !has {
+			censor = nil
+		}
+	}
+
+	method := models.ClipExportReencode
+	switch e.Mode {
+	case models.ClipExportCopy:
+		if !wm.Empty() || len(censor) > 0 {
+			return fmt.Errorf("stream copy is not possible with a watermark or censoring")
+		}
+		method = models.ClipExportCopy
+	case models.ClipExportAuto:
+		if wm.Empty() && len(censor) == 0 {
+			if meta, err := vp.ffmpegClient.GetVideoMetadata(video.Filepath); err == nil && ffmpeg.CanStreamCopy(meta) {
+				method = models.ClipExportCopy
+			}
+		}
+	}
+
+	dir := filepath.Join(highlightDir(), "clips")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create clips directory: %v", err)
+	}
+	out := filepath.Join(dir, fmt.Sprintf("clip_%d.mp4", e.ID))
+	ff := vp.exportFFmpeg(exportClip, e.ID, videoProject(video))
+	if method == models.ClipExportCopy {
+		err = ff.CopyClip(video.Filepath, out, e.StartTime, e.EndTime)
+		if err != nil && e.Mode == models.ClipExportAuto {
+			vp.warnf("stream copy of clip %d failed, re-encoding: %v", e.ID, err)
+			method = models.ClipExportReencode
+		}
+	}
+	if method == models.ClipExportReencode {
+		err = ff.RenderClip(video.Filepath, out, e.StartTime, e.EndTime, wm, censor, e.Censor)
+	}
+	if err != nil {
+		os.Remove(out)
+		return err
+	}
+	if info, err := os.Stat(out); err == nil {
+		e.FileSize = info.Size()
+	}
+	e.Method = &method
+	e.ExportPath = &out
+	vp.trackArtifact(models.ArtifactClipExport, e.ID, out)
+	return nil
+}
@@ -0,0 +1,179 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// ConsistencyRepair records the action taken for one anomaly
+type ConsistencyRepair struct {
+	VideoID  uint   `json:"video_id"`
+	Category string `json:"category"`
+	Action   string `json:"action"`
+	JobID    string `json:"job_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ConsistencyReport is the result of a consistency check
+type ConsistencyReport struct {
+	CheckedAt time.Time                   `json:"checked_at"`
+	Anomalies []models.ConsistencyAnomaly `json:"anomalies"`
+	Counts    map[string]int              `json:"counts"`
+	Repairs   []ConsistencyRepair         `json:"repairs,omitempty"`
+}
+
+// zombieThreshold is how long a video may sit in processing with no active job (CONSISTENCY_ZOMBIE_MINUTES, default 60)
+func zombieThreshold() time.Duration {
+	if v := os.Getenv("CONSISTENCY_ZOMBIE_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Minute
+		}
+	}
+	return time.Hour
+}
+
+// CheckConsistency finds videos with missing or partial pipeline outputs. When repair is set, anomalies
+// in the given categories (all when empty) are fixed: missing embeddings and zombie videos get their
+// pipeline stage re-enqueued (zombies that already have embeddings are marked completed instead),
+// scene counts are recomputed and unlinked captions are linked to their scenes.
+func (vp *VideoProcessor) CheckConsistency(repair bool, categories []string) (*ConsistencyReport, error) {
+	anomalies, err := vp.db.FindConsistencyAnomalies(time.Now().Add(-zombieThreshold()))
+	if err != nil {
+		return nil, err
+	}
+
+	// A video in processing with a pending or running queue job is slow, not stuck
+	kept := anomalies[:0]
+	for _, a := range anomalies {
+		if a.Category == models.AnomalyZombieProcessing && vp.hasActiveJobs(a.VideoID) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+
+	report := &ConsistencyReport{CheckedAt: time.Now().UTC(), Anomalies: kept, Counts: map[string]int{}}
+	if report.Anomalies == nil {
+		report.Anomalies = []models.ConsistencyAnomaly{}
+	}
+	for _, a := range kept {
+		report.Counts[a.Category]++
+	}
+	if !repair {
+		return report, nil
+	}
+
+	selected := map[string]bool{}
+	for _, c := range categories {
+		selected[c] = true
+	}
+	for _, a := range kept {
+		if len(selected) > 0 && !selected[a.Category] {
+			continue
+		}
+		report.Repairs = append(report.Repairs, vp.repairAnomaly(a))
+	}
+	return report, nil
+}
+
+func (vp *VideoProcessor) hasActiveJobs(videoID uint) bool {
+	if vp.jobQueue == nil {
+		return false
+	}
+	jobs, err := vp.jobQueue.ListJobsForVideo(videoID)
+	if err != nil {
+		return false
+	}
+	for _, j := range jobs {
+		if j.Status == queue.JobStatusPending || j.Status == queue.JobStatusRunning {
+			return true
+		}
+	}
+	return false
+}
+
+func (vp *VideoProcessor) repairAnomaly(a models.ConsistencyAnomaly) ConsistencyRepair {
+	r := ConsistencyRepair{VideoID: a.VideoID, Category: a.Category}
+	enqueue := func(jobType queue.JobType, payload map[string]interface{}) {
+		r.Action = "enqueue_" + string(jobType)
+		if vp.jobQueue == nil {
+			r.Error = "queue not available"
+			return
+		}
+		job, err := vp.jobQueue.Enqueue(jobType, payload)
+		if err != nil {
+			r.Error = err.Error()
+			return
+		}
+		r.JobID = job.ID
+	}
+
+	switch a.Category {
+	case models.AnomalyMissingEmbeddings:
+		enqueue(queue.JobTypeEmbeddingGeneration, map[string]interface{}{"video_id": a.VideoID})
+	case models.AnomalyUnlinkedCaptions:
+		enqueue(queue.JobTypeCaptionLinking, map[string]interface{}{"video_id": a.VideoID})
+	case models.AnomalySceneCountMismatch:
+		r.Action = "recount_scenes"
+		if _, err := vp.db.RecountScenes(a.VideoID); err != nil {
+			r.Error = err.Error()
+		}
+	case models.AnomalyZombieProcessing:
+		video, err := vp.db.GetVideoByID(a.VideoID)
+		if err != nil {
+			r.Action = "enqueue_" + string(queue.JobTypeVideoIngestion)
+			r.Error = err.Error()
+			break
+		}
+		// Outputs already present: the pipeline finished but the status was never advanced
+		if n, err := vp.db.CountScenesWithEmbeddings(video.ID); err == nil && n > 0 {
+			r.Action = "mark_completed"
+			video.Status = models.VideoStatusCompleted
+			if err := vp.db.UpdateVideoStatus(video.ID, video.Status); err != nil {
+				r.Error = err.Error()
+			}
+			break
+		}
+		if video.Locked {
+			r.Action = "skipped"
+			r.Error = "video is locked (legal hold)"
+			break
+		}
+		enqueue(queue.JobTypeVideoIngestion, map[string]interface{}{
+			"video_id": video.ID,
+			"filename": video.Filename,
+			"filepath": video.Filepath,
+		})
+	default:
+		r.Action = "none"
+		r.Error = "no automatic repair for this category"
+	}
+	if r.Error != "" {
+		vp.warnf("consistency repair %s for video %d failed: %s", a.Category, a.VideoID, r.Error)
+	}
+	return r
+}
+
+// ProcessConsistencyCheck handles consistency check jobs. Payload: {"repair": bool, "categories": [..]}
+func (vp *VideoProcessor) ProcessConsistencyCheck(payload map[string]interface{}) error {
+	repair, _ := payload["repair"].(bool)
+	var categories []string
+	if raw, ok := payload["categories"].([]interface{}); ok {
+		for _, c := range raw {
+			if s, ok := c.(string); ok {
+				categories = append(categories, s)
+			}
+		}
+	}
+	report, err := vp.CheckConsistency(repair, categories)
+	if err != nil {
+		return fmt.Errorf("consistency check failed: %v", err)
+	}
+	log.Printf("Consistency check: %d anomalies %v, %d repairs", len(report.Anomalies), report.Counts, len(report.Repairs))
+	return nil
+}
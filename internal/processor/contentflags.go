@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"goodclips-server/internal/contentflags"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// contentFlagPad widens estimated word ranges to absorb timing error (CONTENT_FLAG_PAD_SECS, default 0.2)
+func contentFlagPad() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("CONTENT_FLAG_PAD_SECS"), 64); err == nil && v >= 0 {
+		return v
+	}
+	return 0.2
+}
+
+// flagRange estimates when a match is spoken by its position within the caption text, assuming an
+// even speaking rate. With CONTENT_FLAG_RANGE=caption the whole caption is flagged instead.
+func flagRange(cp models.Caption, m contentflags.Match, pad float64) (float64, float64) {
+	total := utf8.RuneCountInString(cp.Text)
+	if total == 0 || os.Getenv("CONTENT_FLAG_RANGE") == "caption" {
+		return cp.StartTime, cp.EndTime
+	}
+	dur := cp.EndTime - cp.StartTime
+	from := float64(utf8.RuneCountInString(cp.Text[:m.Start])) / float64(total)
+	to := float64(utf8.RuneCountInString(cp.Text[:m.End])) / float64(total)
+	start := cp.StartTime + dur*from - pad
+	end := cp.StartTime + dur*to + pad
+	if start < cp.StartTime {
+		start = cp.StartTime
+	}
+	if end > cp.EndTime {
+		end = cp.EndTime
+	}
+	return start, end
+}
+
+// ProcessContentFlagging handles content flagging jobs: captions are scanned against the configured
+// wordlists and every hit is stored with its estimated time range. Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessContentFlagging(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	matcher, err := contentflags.FromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to load content wordlists: %v", err)
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+
+	pad := contentFlagPad()
+	var flags []models.ContentFlag
+	for _, cp := range captions {
+		for _, m := range matcher.Find(cp.Text) {
+			start, end := flagRange(cp, m, pad)
+			flags = append(flags, models.ContentFlag{
+				VideoID:   videoID,
+				CaptionID: cp.ID,
+				StartTime: start,
+				EndTime:   end,
+				Category:  m.Category,
+				Term:      cp.Text[m.Start:m.End],
+			})
+		}
+	}
+	if err := vp.db.ReplaceContentFlags(videoID, flags); err != nil {
+		return fmt.Errorf("failed to store content flags: %v", err)
+	}
+	log.Printf("[contentflags] video_id=%d: %d flags in %d captions (%d terms)", videoID, len(flags), len(captions), matcher.Len())
+	return nil
+}
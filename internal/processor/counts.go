@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"goodclips-server/internal/queue"
+)
+
+// ScheduleCountReconciliation makes sure a library-wide count reconciliation job is queued for
+// 03:00 UTC; several workers calling it schedule it only once
+func (vp *VideoProcessor) ScheduleCountReconciliation() error {
+	if vp.jobQueue == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	at := now.Truncate(24 * time.Hour).Add(3 * time.Hour)
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	key := fmt.Sprintf("count_reconciliation:%d", at.Unix())
+	if _, err := vp.jobQueue.EnqueueOnce(key, queue.JobTypeCountReconciliation, map[string]interface{}{}, at); err != nil {
+		return fmt.Errorf("failed to schedule count reconciliation: %v", err)
+	}
+	return nil
+}
+
+// ProcessCountReconciliation corrects drifted scene and caption counts of the payload's video_id,
+// or of every video (then scheduling the next nightly run) when it has none
+func (vp *VideoProcessor) ProcessCountReconciliation(payload map[string]interface{}) error {
+	videoID, scoped := queue.PayloadVideoID(payload)
+	if !scoped {
+		defer func() {
+			if err := vp.ScheduleCountReconciliation(); err != nil {
+				vp.warnf("%v", err)
+			}
+		}()
+	}
+	drift, err := vp.db.ReconcileCounts(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile counts: %v", err)
+	}
+	if drift.Total() > 0 {
+		log.Printf("[counts] corrected %d video scene counts, %d video caption counts, %d scene caption counts",
+			drift.VideoSceneCounts, drift.VideoCaptionCounts, drift.SceneCaptionCounts)
+	}
+	return nil
+}
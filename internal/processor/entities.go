@@ -0,0 +1,155 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// captionEntity is one entity mention found by the NER runner
+type captionEntity struct {
+	Text  string  `json:"text"`
+	Type  string  `json:"type"`
+	Score float64 `json:"score"`
+}
+
+// entityMinScore is the NER confidence below which mentions are dropped (NER_MIN_SCORE, default 0.6)
+func entityMinScore() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("NER_MIN_SCORE"), 64); err == nil && v >= 0 {
+		return v
+	}
+	return 0.6
+}
+
+// entityRetryLimit bounds how often an entity job waits for scene detection to finish
+const entityRetryLimit = 10
+
+// extractEntities runs the NER runner over caption texts, returning mentions per text in input order
+func extractEntities(ctx context.Context, texts []string) ([][]captionEntity, error) {
+	req, _ := json.Marshal(map[string]interface{}{"texts": texts})
+	cmd := exec.CommandContext(ctx, "python3", "/root/internal/embeddings/ner_runner.py")
+	cmd.Stdin = bytes.NewReader(req)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ner_runner failed: %v; stderr: %s", err, stderr.String())
+	}
+	var resp struct {
+		Entities [][]captionEntity `json:"entities"`
+		Error    string            `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse ner_runner output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("ner_runner error: %s", resp.Error)
+	}
+	if len(resp.Entities) != len(texts) {
+		return nil, fmt.Errorf("ner_runner returned %d results for %d texts", len(resp.Entities), len(texts))
+	}
+	return resp.Entities, nil
+}
+
+// ProcessEntityExtraction handles entity extraction jobs: people, places and organizations named in
+// the captions are attached to every scene (shots and beats) containing the caption's midpoint.
+// Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessEntityExtraction(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	scenes, err := vp.db.GetScenesByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load scenes: %v", err)
+	}
+	if len(scenes) == 0 {
+		// Captions can finish before scene detection; try again shortly
+		attempt, _ := payload["attempt"].(float64)
+		if int(attempt) >= entityRetryLimit || vp.jobQueue == nil {
+			return fmt.Errorf("video %d has no scenes to attach entities to", videoID)
+		}
+		next := map[string]interface{}{"video_id": videoID, "attempt": int(attempt) + 1}
+		if _, err := vp.jobQueue.EnqueueAt(queue.JobTypeEntityExtraction, next, time.Now().Add(time.Minute)); err != nil {
+			return fmt.Errorf("failed to reschedule entity extraction: %v", err)
+		}
+		log.Printf("[entities] video_id=%d: no scenes yet; retrying in 1m (attempt %d)", videoID, int(attempt)+1)
+		return nil
+	}
+
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	if len(captions) == 0 {
+		return vp.db.ReplaceVideoEntities(videoID, nil)
+	}
+	texts := make([]string, len(captions))
+	for i, cp := range captions {
+		texts[i] = cp.Text
+	}
+	found, err := extractEntities(vp.context(), texts)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		sceneID    uint
+		normalized string
+		entityType string
+	}
+	minScore := entityMinScore()
+	byKey := map[key]*models.SceneEntity{}
+	var order []key
+	for i, cp := range captions {
+		mid := (cp.StartTime + cp.EndTime) / 2
+		for _, e := range found[i] {
+			norm := database.NormalizeEntity(e.Text)
+			if e.Score < minScore || norm == "" || !models.ValidEntityType(e.Type) || len(norm) > 256 {
+				continue
+			}
+			for _, s := range scenes {
+				if mid < s.StartTime || mid >= s.EndTime {
+					continue
+				}
+				k := key{s.ID, norm, e.Type}
+				if se, ok := byKey[k]; ok {
+					se.Mentions++
+					if cp.StartTime < se.FirstTime {
+						se.FirstTime = cp.StartTime
+					}
+					continue
+				}
+				byKey[k] = &models.SceneEntity{
+					VideoID:    videoID,
+					SceneID:    s.ID,
+					Entity:     e.Text,
+					Normalized: norm,
+					EntityType: e.Type,
+					Mentions:   1,
+					FirstTime:  cp.StartTime,
+				}
+				order = append(order, k)
+			}
+		}
+	}
+	entities := make([]models.SceneEntity, 0, len(order))
+	for _, k := range order {
+		entities = append(entities, *byKey[k])
+	}
+	if err := vp.db.ReplaceVideoEntities(videoID, entities); err != nil {
+		return fmt.Errorf("failed to store entities: %v", err)
+	}
+	log.Printf("[entities] video_id=%d: stored %d scene entities from %d captions", videoID, len(entities), len(captions))
+	return nil
+}
@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/notify"
+)
+
+// Export kinds reported in export.progress notifications
+const (
+	exportClip          = "clip"
+	exportHighlightReel = "highlight_reel"
+	exportSupercut      = "supercut"
+)
+
+// exportProgressStep is the progress, in percent, between two export.progress notifications of an
+// export (EXPORT_PROGRESS_STEP, default 10)
+func exportProgressStep() float64 {
+	if n, err := strconv.Atoi(os.Getenv("EXPORT_PROGRESS_STEP")); err == nil && n >= 1 && n <= 100 {
+		return float64(n)
+	}
+	return 10
+}
+
+// exportFFmpeg returns the ffmpeg client an export renders with. The render's progress becomes the
+// job's progress and is published as export.progress every exportProgressStep percent and once the
+// render finishes. kind is one of the export kinds and project routes the notifications.
+func (vp *VideoProcessor) exportFFmpeg(kind string, id uint, project string) *ffmpeg.FFmpegClient {
+	step := exportProgressStep()
+	next := step
+	return vp.ffmpegClient.WithProgress(func(p ffmpeg.Progress) {
+		vp.stepProgress(0, 1, int(p.Percent), 100)
+		if p.Percent < next && !p.Done {
+			return
+		}
+		for next <= p.Percent {
+			next += step
+		}
+		percent := int(p.Percent)
+		notify.Publish(notify.Message{
+			Event:   notify.EventExportProgress,
+			Project: project,
+			Subject: fmt.Sprintf("[goodclips] Exporting %s %d: %d%%", kind, id, percent),
+			Text:    fmt.Sprintf("Export of %s %d is %d%% done (%.1fs written, %.2fx).", kind, id, percent, p.OutTime, p.Speed),
+			Data: map[string]any{
+				"export":   kind,
+				"id":       id,
+				"job_id":   vp.jobID,
+				"percent":  percent,
+				"out_time": p.OutTime,
+				"speed":    p.Speed,
+				"done":     p.Done,
+			},
+			Time: time.Now().UTC(),
+		})
+	})
+}
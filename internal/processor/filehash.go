@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// SharedIngestEnabled reports whether a project's video whose source another project already
+// registered shares that video's content instead of being retired (SHARED_INGEST, default on)
+func SharedIngestEnabled() bool {
+	return os.Getenv("SHARED_INGEST") != "false"
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's contents
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dedupeVideo hashes a video's source and stores the hash. When another live video already has the
+// same content, dedupeVideo reports true so ingestion stops: a video of another project shares that
+// video's scenes, captions and embeddings (content_video_id) while keeping its own metadata, and a
+// video of the same project is retired as its duplicate (status deleted, metadata.duplicate_of).
+// Live sources are still growing and not hashed.
+func (vp *VideoProcessor) dedupeVideo(videoID uint, path string) (bool, error) {
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get video: %v", err)
+	}
+	// The legal hold trigger refuses hash changes on locked videos
+	if video.Live || video.Locked {
+		return false, nil
+	}
+	hash, err := fileSHA256(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash %s: %v", path, err)
+	}
+
+	original, err := vp.db.GetVideoByFileHash(hash, video.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up file hash: %v", err)
+	}
+	if original == nil {
+		if video.FileHash != nil && *video.FileHash == hash {
+			return false, nil
+		}
+		serr := vp.db.SetVideoFileHash(video.ID, hash)
+		if serr == nil {
+			return false, nil
+		}
+		// Another worker may have stored the same hash in the meantime
+		if original, _ = vp.db.GetVideoByFileHash(hash, video.ID); original == nil {
+			return false, fmt.Errorf("failed to store file hash: %v", serr)
+		}
+	}
+
+	if SharedIngestEnabled() && videoProject(video) != videoProject(original) {
+		if err := vp.db.LinkSharedVideo(video.ID, original.ID, hash); err != nil {
+			return false, fmt.Errorf("failed to share video %d's content with video %d: %v", original.ID, video.ID, err)
+		}
+		vp.setResult("shared_content_video_id", original.ID)
+		log.Printf("Video ID %d (%s) shares the content of video ID %d (project %q); skipping ingestion", video.ID, path, original.ID, videoProject(original))
+		return true, nil
+	}
+	if err := vp.db.MarkVideoDuplicate(video.ID, original.ID, hash); err != nil {
+		return false, fmt.Errorf("failed to mark video %d as duplicate of %d: %v", video.ID, original.ID, err)
+	}
+	log.Printf("Video ID %d (%s) duplicates video ID %d; skipping ingestion", video.ID, path, original.ID)
+	return true, nil
+}
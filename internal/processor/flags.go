@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"goodclips-server/internal/flags"
+	"goodclips-server/internal/queue"
+)
+
+// Feature flags for optional pipeline stages, toggled at runtime through the admin API
+const (
+	FlagCaptionExtraction  = "caption_extraction"
+	FlagAudioEmbeddings    = "audio_embeddings"
+	FlagTopicTimeline      = "topic_timeline"
+	FlagEntityExtraction   = "entity_extraction"
+	FlagContentFlagging    = "content_flagging"
+	FlagToneAnalysis       = "tone_analysis"
+	FlagToneAudio          = "tone_audio"
+	FlagAlertEvaluation    = "alert_evaluation"
+	FlagCaptionEmbeddings  = "caption_embeddings"
+	FlagWordAlignment      = "word_alignment"
+	FlagCaptionTranslation = "caption_translation"
+)
+
+// featureFlag is a stage that can be switched off at runtime. Stages with a JobType are neither
+// enqueued nor run while off; the rest are checked where they run.
+type featureFlag struct {
+	Name        string
+	Description string
+	JobType     queue.JobType
+	// Default is the value without an override, usually from the stage's environment variable
+	Default func() bool
+}
+
+func always() bool { return true }
+
+var featureFlags = []featureFlag{
+	{FlagCaptionExtraction, "Extract captions from new videos", queue.JobTypeCaptionExtraction, always},
+	{FlagAudioEmbeddings, "Compute CLAP audio embeddings (ENABLE_AUDIO_EMBEDDINGS)", "", audioEmbeddingsEnabled},
+	{FlagTopicTimeline, "Segment captions into topics (TOPIC_TIMELINE_AUTO)", queue.JobTypeTopicTimeline, topicTimelineAuto},
+	{FlagEntityExtraction, "Extract named entities from captions", queue.JobTypeEntityExtraction, always},
+	{FlagContentFlagging, "Flag listed terms in captions", queue.JobTypeContentFlagging, always},
+	{FlagToneAnalysis, "Score scene sentiment and emotion (TONE_ANALYSIS_AUTO)", queue.JobTypeToneAnalysis, toneAnalysisAuto},
+	{FlagToneAudio, "Include vocal emotion in tone analysis (TONE_AUDIO)", "", toneAudioEnabled},
+	{FlagAlertEvaluation, "Evaluate standing alerts on new footage", queue.JobTypeAlertEvaluation, always},
+	{FlagCaptionEmbeddings, "Embed captions for passage search (CAPTION_EMBEDDINGS)", queue.JobTypeCaptionEmbedding, captionEmbeddingsAuto},
+	{FlagWordAlignment, "Time caption words by forced alignment (WORD_ALIGNMENT_AUTO)", queue.JobTypeWordAlignment, wordAlignmentAuto},
+	{FlagCaptionTranslation, "Translate captions (CAPTION_TRANSLATION_LANGUAGES)", queue.JobTypeCaptionTranslation, captionTranslationAuto},
+}
+
+// FlagState is a feature flag with its current value
+type FlagState struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	JobType     queue.JobType `json:"job_type,omitempty"`
+	Enabled     bool          `json:"enabled"`
+	Default     bool          `json:"default"`
+	Overridden  bool          `json:"overridden"`
+}
+
+// FeatureFlags lists every flag with its effective value
+func FeatureFlags() []FlagState {
+	overrides := flags.Overrides()
+	out := make([]FlagState, len(featureFlags))
+	for i, f := range featureFlags {
+		s := FlagState{Name: f.Name, Description: f.Description, JobType: f.JobType, Default: f.Default()}
+		s.Enabled = s.Default
+		if v, ok := overrides[f.Name]; ok {
+			s.Enabled, s.Overridden = v, true
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// IsFeatureFlag reports whether name is a known flag
+func IsFeatureFlag(name string) bool {
+	for _, f := range featureFlags {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StageEnabled reports whether the stage behind a flag runs; unknown names are always on
+func StageEnabled(name string) bool {
+	for _, f := range featureFlags {
+		if f.Name == name {
+			return flags.Enabled(name, f.Default())
+		}
+	}
+	return true
+}
+
+// JobTypeEnabled reports whether jobs of jobType run, and the flag that decides it ("" when none does)
+func JobTypeEnabled(jobType queue.JobType) (string, bool) {
+	for _, f := range featureFlags {
+		if f.JobType == jobType {
+			return f.Name, flags.Enabled(f.Name, f.Default())
+		}
+	}
+	return "", true
+}
@@ -0,0 +1,258 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+)
+
+// highlightOptions is the scoring rubric of a highlight reel
+type highlightOptions struct {
+	SimilarityWeight float64
+	EnergyWeight     float64
+	MinSceneDuration float64
+	MaxSceneDuration float64 // 0 = unbounded
+	Export           bool
+	// ExcludeFlagged skips scenes with content flags; Censor ("mute" or "bleep") treats them in the export
+	ExcludeFlagged bool
+	Censor         string
+}
+
+// highlightOptionsFrom reads the rubric stored with a reel, defaulting to 0.7 prompt similarity,
+// 0.3 audio energy and scenes between 1 and 20 seconds
+func highlightOptionsFrom(o models.JSONObject) highlightOptions {
+	opts := highlightOptions{SimilarityWeight: 0.7, EnergyWeight: 0.3, MinSceneDuration: 1, MaxSceneDuration: 20}
+	for key, dst := range map[string]*float64{
+		"similarity_weight":  &opts.SimilarityWeight,
+		"energy_weight":      &opts.EnergyWeight,
+		"min_scene_duration": &opts.MinSceneDuration,
+		"max_scene_duration": &opts.MaxSceneDuration,
+	} {
+		if v, ok := o[key].(float64); ok && v >= 0 {
+			*dst = v
+		}
+	}
+	opts.Export, _ = o["export"].(bool)
+	opts.ExcludeFlagged, _ = o["exclude_flagged"].(bool)
+	opts.Censor, _ = o["censor"].(string)
+	return opts
+}
+
+// highlightDir is where exported reels are written (HIGHLIGHTS_DIR, default <tmp>/goodclips_highlights)
+func highlightDir() string {
+	if d := os.Getenv("HIGHLIGHTS_DIR"); d != "" {
+		return d
+	}
+	return filepath.Join(os.TempDir(), "goodclips_highlights")
+}
+
+// energyFromVolume maps a mean level in dBFS onto [0, 1]: -50 dB and quieter is 0, full scale is 1
+func energyFromVolume(db float64) float64 {
+	e := (db + 50) / 50
+	if e < 0 {
+		return 0
+	}
+	if e > 1 {
+		return 1
+	}
+	return e
+}
+
+// ProcessHighlightReel handles highlight reel jobs. Payload: {"reel_id": 12}
+func (vp *VideoProcessor) ProcessHighlightReel(payload map[string]interface{}) error {
+	id, ok := payload["reel_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid reel_id in payload")
+	}
+	reel, err := vp.db.GetHighlightReel(uint(id))
+	if err != nil {
+		return fmt.Errorf("failed to load highlight reel %d: %v", uint(id), err)
+	}
+	reel.Status = models.HighlightStatusProcessing
+	reel.ErrorMessage = nil
+	if err := vp.db.UpdateHighlightReel(reel); err != nil {
+		return fmt.Errorf("failed to update highlight reel: %v", err)
+	}
+
+	if err := vp.buildHighlightReel(reel); err != nil {
+		msg := err.Error()
+		reel.Status = models.HighlightStatusFailed
+		reel.ErrorMessage = &msg
+		if uerr := vp.db.UpdateHighlightReel(reel); uerr != nil {
+			vp.warnf("failed to record highlight reel %d failure: %v", reel.ID, uerr)
+		}
+		return err
+	}
+	reel.Status = models.HighlightStatusCompleted
+	if err := vp.db.UpdateHighlightReel(reel); err != nil {
+		return fmt.Errorf("failed to store highlight reel: %v", err)
+	}
+	log.Printf("[highlights] reel_id=%d: %d scenes, %.1fs of %.1fs requested", reel.ID, len(reel.Items), reel.TotalDuration, reel.TargetDuration)
+	return nil
+}
+
+// buildHighlightReel ranks scenes by prompt similarity and audio energy, picks the best ones that fit
+// the target length, and exports the reel when requested
+func (vp *VideoProcessor) buildHighlightReel(reel *models.HighlightReel) error {
+	opts := highlightOptionsFrom(reel.Options)
+	vec, err := embedText(vp.context(), reel.Prompt, "query")
+	if err != nil {
+		return fmt.Errorf("failed to embed prompt: %v", err)
+	}
+
+	// Rank a pool a few times larger than the reel can hold
+	minDur := opts.MinSceneDuration
+	if minDur < 2 {
+		minDur = 2
+	}
+	k := int(reel.TargetDuration/minDur) * 3
+	if k < 20 {
+		k = 20
+	}
+	if k > 200 {
+		k = 200
+	}
+	filter := database.SceneFilter{Level: reel.Level, AssetTypes: []string{models.AssetTypeVideo}, ExcludeFlagged: opts.ExcludeFlagged}
+	if reel.VideoID != nil {
+		filter.VideoIDs = []uint{*reel.VideoID}
+	}
+	scenes, dists, err := vp.db.SearchScenesByTextVector(vec, k, filter)
+	if err != nil {
+		return fmt.Errorf("failed to rank scenes: %v", err)
+	}
+
+	metric := database.MetricForColumn(database.ColumnText)
+	videos := map[uint]*models.Video{}
+	var candidates []models.HighlightItem
+	for i, s := range scenes {
+		d := s.EndTime - s.StartTime
+		if d < opts.MinSceneDuration || (opts.MaxSceneDuration > 0 && d > opts.MaxSceneDuration) {
+			continue
+		}
+		item := models.HighlightItem{
+			SceneID:    s.ID,
+			VideoID:    s.VideoID,
+			SceneIndex: s.SceneIndex,
+			StartTime:  s.StartTime,
+			EndTime:    s.EndTime,
+			Similarity: metric.Similarity(dists[i]),
+		}
+		if opts.EnergyWeight > 0 {
+			if video := vp.highlightVideo(videos, s.VideoID); video != nil {
+				if level, err := vp.ffmpegClient.MeanVolume(video.Filepath, s.StartTime, s.EndTime); err == nil {
+					item.Energy = energyFromVolume(level)
+				}
+			}
+		}
+		item.Score = opts.SimilarityWeight*item.Similarity + opts.EnergyWeight*item.Energy
+		candidates = append(candidates, item)
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no embedded scenes within the duration bounds to choose from")
+	}
+
+	reel.Items = selectHighlights(candidates, reel.TargetDuration)
+	reel.TotalDuration = 0
+	for _, it := range reel.Items {
+		reel.TotalDuration += it.EndTime - it.StartTime
+	}
+	if !opts.Export {
+		return nil
+	}
+	return vp.exportHighlightReel(reel, videos, opts.Censor)
+}
+
+// selectHighlights greedily takes the best-scoring scenes until the target length is reached, skipping
+// scenes that would overshoot it by more than 10%, and returns them in playback order (per video, by time)
+func selectHighlights(candidates []models.HighlightItem, target float64) models.HighlightItems {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	var chosen models.HighlightItems
+	total := 0.0
+	for _, c := range candidates {
+		d := c.EndTime - c.StartTime
+		if total+d > target*1.1 {
+			continue
+		}
+		chosen = append(chosen, c)
+		total += d
+		if total >= target*0.95 {
+			break
+		}
+	}
+	sort.SliceStable(chosen, func(i, j int) bool {
+		if chosen[i].VideoID != chosen[j].VideoID {
+			return chosen[i].VideoID < chosen[j].VideoID
+		}
+		return chosen[i].StartTime < chosen[j].StartTime
+	})
+	return chosen
+}
+
+// highlightVideo loads a video once per reel build
+func (vp *VideoProcessor) highlightVideo(cache map[uint]*models.Video, id uint) *models.Video {
+	if v, ok := cache[id]; ok {
+		return v
+	}
+	v, err := vp.db.GetVideoByID(id)
+	if err != nil {
+		vp.warnf("highlight reel: failed to load video %d: %v", id, err)
+		v = nil
+	}
+	cache[id] = v
+	return v
+}
+
+// exportHighlightReel renders the reel's scenes into one MP4 under highlightDir(), muting or bleeping
+// flagged content when censor is set
+func (vp *VideoProcessor) exportHighlightReel(reel *models.HighlightReel, videos map[uint]*models.Video, censor string) error {
+	segments := make([]ffmpeg.ReelSegment, 0, len(reel.Items))
+	withAudio := true
+	probed := map[uint]bool{}
+	for _, it := range reel.Items {
+		video := vp.highlightVideo(videos, it.VideoID)
+		if video == nil {
+			return fmt.Errorf("video %d of scene %d is unavailable", it.VideoID, it.SceneID)
+		}
+		if !probed[video.ID] {
+			probed[video.ID] = true
+			if has, err := vp.ffmpegClient.HasAudioStream(video.Filepath); err != nil || !has {
+				withAudio = false
+			}
+		}
+		seg := ffmpeg.ReelSegment{Path: video.Filepath, Start: it.StartTime, End: it.EndTime}
+		if censor != ffmpeg.CensorNone {
+			flags, err := vp.db.GetContentFlagsInRange(video.ID, it.StartTime, it.EndTime, nil)
+			if err != nil {
+				return fmt.Errorf("failed to load content flags: %v", err)
+			}
+			for _, f := range flags {
+				seg.Censor = append(seg.Censor, ffmpeg.Interval{Start: f.StartTime, End: f.EndTime})
+			}
+		}
+		segments = append(segments, seg)
+	}
+
+	dir := highlightDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create highlights directory: %v", err)
+	}
+	out := filepath.Join(dir, fmt.Sprintf("reel_%d.mp4", reel.ID))
+	project := ""
+	if reel.VideoID != nil {
+		if v := videos[*reel.VideoID]; v != nil {
+			project = videoProject(v)
+		}
+	}
+	if err := vp.exportFFmpeg(exportHighlightReel, reel.ID, project).RenderHighlightReel(segments, out, 1280, 720, withAudio, censor); err != nil {
+		return err
+	}
+	reel.ExportPath = &out
+	vp.trackArtifact(models.ArtifactHighlightReel, reel.ID, out)
+	return nil
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// maxResultWarnings caps the warnings kept in a job result; later ones are only counted
+const maxResultWarnings = 50
+
+// JobResult collects the structured outputs of the job a processor runs: counts of what it
+// created or stored, embeddings saved per modality and the warnings it logged. The worker stores
+// a snapshot with the job record when the job settles.
+type JobResult struct {
+	mu         sync.Mutex
+	counts     map[string]int
+	embeddings map[string]int
+	values     map[string]interface{}
+	warnings   []string
+	dropped    int
+}
+
+// NewJobResult returns an empty result
+func NewJobResult() *JobResult {
+	return &JobResult{counts: map[string]int{}, embeddings: map[string]int{}, values: map[string]interface{}{}}
+}
+
+// Map returns a snapshot of the result, or nil when the job recorded nothing
+func (r *JobResult) Map() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := map[string]interface{}{}
+	for k, v := range r.values {
+		out[k] = v
+	}
+	for k, n := range r.counts {
+		out[k] = n
+	}
+	if len(r.embeddings) > 0 {
+		embeddings := make(map[string]int, len(r.embeddings))
+		for k, n := range r.embeddings {
+			embeddings[k] = n
+		}
+		out["embeddings_saved"] = embeddings
+	}
+	if len(r.warnings) > 0 {
+		out["warnings"] = append([]string(nil), r.warnings...)
+	}
+	if r.dropped > 0 {
+		out["warnings_dropped"] = r.dropped
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// WithResult returns a copy of the processor that records what the job it runs does into r
+func (vp *VideoProcessor) WithResult(r *JobResult) *VideoProcessor {
+	c := *vp
+	c.result = r
+	return &c
+}
+
+// countResult adds n to a count in the job's result, e.g. "scenes_created"
+func (vp *VideoProcessor) countResult(key string, n int) {
+	if r := vp.result; r != nil {
+		r.mu.Lock()
+		r.counts[key] += n
+		r.mu.Unlock()
+	}
+}
+
+// countEmbeddings adds n to the embeddings saved for a modality ("visual", "text", "clip", "audio")
+func (vp *VideoProcessor) countEmbeddings(modality string, n int) {
+	if r := vp.result; r != nil {
+		r.mu.Lock()
+		r.embeddings[modality] += n
+		r.mu.Unlock()
+	}
+}
+
+// setResult records a value in the job's result
+func (vp *VideoProcessor) setResult(key string, value interface{}) {
+	if r := vp.result; r != nil {
+		r.mu.Lock()
+		r.values[key] = value
+		r.mu.Unlock()
+	}
+}
+
+// warnf logs a warning and records it in the job's result
+func (vp *VideoProcessor) warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("Warning: %s", msg)
+	if r := vp.result; r != nil {
+		r.mu.Lock()
+		if len(r.warnings) < maxResultWarnings {
+			r.warnings = append(r.warnings, msg)
+		} else {
+			r.dropped++
+		}
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,254 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+	"goodclips-server/internal/scenedetect"
+)
+
+// liveSeconds reads a positive duration in seconds from the environment
+func liveSeconds(name string, def float64) float64 {
+	if v := os.Getenv(name); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return def
+}
+
+// liveIdleTicks is how many polls without growth end a live ingest (LIVE_IDLE_TICKS, default 6)
+func liveIdleTicks() int {
+	if v := os.Getenv("LIVE_IDLE_TICKS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 6
+}
+
+// metaFloat reads a number stored in video metadata (JSON numbers decode as float64)
+func metaFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	}
+	return 0
+}
+
+// ProcessLiveIngest runs one tick of incremental ingestion for a live video: it records the next chunk
+// of a stream source (if any), probes how far the file has grown, detects shots in the newly available
+// range (minus a safety margin for partially written data), appends them and enqueues their embeddings,
+// then schedules the next tick. Once the video is stopped, or has not grown for LIVE_IDLE_TICKS polls,
+// beats are grouped over all shots and the video is marked completed.
+func (vp *VideoProcessor) ProcessLiveIngest(payload map[string]interface{}) error {
+	videoID, ok := payload["video_id"].(float64)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	video, err := vp.db.GetVideoByID(uint(videoID))
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	if !video.Live {
+		return vp.finalizeLive(video)
+	}
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+
+	poll := liveSeconds("LIVE_POLL_SECS", 10)
+	source, _ := video.Metadata["live_source"].(string)
+	if source != "" {
+		if err := vp.appendStreamChunk(source, video.Filepath, poll); err != nil {
+			vp.warnf("Failed to record live stream for video %d: %v", video.ID, err)
+		}
+	}
+
+	duration, err := vp.ffmpegClient.GetVideoDuration(video.Filepath)
+	if err != nil {
+		vp.warnf("Failed to probe live video %d: %v", video.ID, err)
+		duration = video.Duration
+	}
+	idle := int(metaFloat(video.Metadata["live_idle_ticks"]))
+	if duration > video.Duration {
+		idle = 0
+	} else {
+		idle++
+	}
+	video.Metadata["live_idle_ticks"] = idle
+	if idle >= liveIdleTicks() {
+		log.Printf("Live video %d has not grown for %d polls; finalizing", video.ID, idle)
+		return vp.finalizeLive(video)
+	}
+	video.Duration = duration
+
+	// The tail of a growing file may be partially written; leave it for the next tick
+	processed := metaFloat(video.Metadata["live_processed_until"])
+	until := duration - liveSeconds("LIVE_SAFETY_SECS", 5)
+	if until > processed {
+		if err := vp.ingestLiveRange(video, processed, until); err != nil {
+			vp.warnf("Live ingest of %.2f-%.2fs failed for video %d: %v", processed, until, video.ID, err)
+		} else {
+			video.Metadata["live_processed_until"] = until
+		}
+	}
+	// The live flag is not ours to write: stopping the video clears it concurrently
+	if err := vp.saveVideo(video, []string{"live_idle_ticks", "live_processed_until"}, func(v *models.Video) {
+		v.Duration = duration
+		v.Status = models.VideoStatusProcessing
+	}); err != nil {
+		return fmt.Errorf("failed to update live video: %v", err)
+	}
+
+	if vp.jobQueue == nil {
+		return nil
+	}
+	next := map[string]interface{}{"video_id": video.ID}
+	// Recording a stream chunk already takes a poll interval, so streams are re-polled immediately
+	runAt := time.Now().Add(time.Duration(poll * float64(time.Second)))
+	if source != "" {
+		runAt = time.Now()
+	}
+	if _, err := vp.jobQueue.EnqueueAt(queue.JobTypeLiveIngest, next, runAt); err != nil {
+		return fmt.Errorf("failed to schedule next live ingest tick: %v", err)
+	}
+	return nil
+}
+
+// ingestLiveRange detects shots in [start, end) seconds, appends them after the video's existing
+// shots and enqueues embedding of the new ones. A shot spanning end is split at end.
+func (vp *VideoProcessor) ingestLiveRange(video *models.Video, start, end float64) error {
+	scenes, err := vp.sceneDetector.DetectScenesRange(video.Filepath, start, end)
+	if err != nil {
+		return err
+	}
+	scenes, _ = scenedetect.MergeShortScenes(scenes, scenedetect.MinSceneDurationFromEnv())
+	existing, err := vp.db.GetScenesByVideoIDAndLevel(video.ID, models.SceneLevelShot)
+	if err != nil {
+		return fmt.Errorf("failed to load existing shots: %v", err)
+	}
+	first := len(existing)
+	for i, scene := range scenes {
+		shot := &models.Scene{
+			VideoID:    video.ID,
+			Level:      models.SceneLevelShot,
+			SceneIndex: first + i,
+			StartTime:  scene.StartTime,
+			EndTime:    scene.EndTime,
+			Duration:   scene.EndTime - scene.StartTime,
+		}
+		if err := vp.db.CreateScene(shot); err != nil {
+			return fmt.Errorf("failed to store live shot: %v", err)
+		}
+		vp.countResult("scenes_created", 1)
+	}
+	if err := vp.db.IncrementSceneCount(video.ID, len(scenes)); err != nil {
+		return fmt.Errorf("failed to update scene count: %v", err)
+	}
+	video.SceneCount = first + len(scenes)
+	log.Printf("Live video %d: %d new shots in %.2f-%.2fs (%d total)", video.ID, len(scenes), start, end, video.SceneCount)
+
+	if len(scenes) > 0 && vp.jobQueue != nil {
+		embedPayload := map[string]interface{}{
+			"video_id":         video.ID,
+			"level":            models.SceneLevelShot,
+			"scene_index_from": first,
+		}
+		if _, err := vp.jobQueue.Enqueue(queue.JobTypeEmbeddingGeneration, embedPayload); err != nil {
+			vp.warnf("Failed to enqueue embedding generation for live video %d: %v", video.ID, err)
+		}
+	}
+	return nil
+}
+
+// appendStreamChunk records the next chunk of a stream and appends it to the video's MPEG-TS file
+func (vp *VideoProcessor) appendStreamChunk(sourceURL, path string, seconds float64) error {
+	chunk := path + ".chunk.ts"
+	defer os.Remove(chunk)
+	if err := vp.ffmpegClient.RecordStream(sourceURL, chunk, seconds); err != nil {
+		return err
+	}
+	in, err := os.Open(chunk)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// finalizeLive ends live ingestion: the tail held back by the safety margin is ingested, beats are
+// grouped over all stored shots and embedded, and the video is marked completed
+func (vp *VideoProcessor) finalizeLive(video *models.Video) error {
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	if d, err := vp.ffmpegClient.GetVideoDuration(video.Filepath); err == nil && d > video.Duration {
+		video.Duration = d
+	}
+	if processed := metaFloat(video.Metadata["live_processed_until"]); video.Duration > processed {
+		if err := vp.ingestLiveRange(video, processed, 0); err != nil {
+			vp.warnf("Failed to ingest final %.2fs of live video %d: %v", video.Duration-processed, video.ID, err)
+		} else {
+			video.Metadata["live_processed_until"] = video.Duration
+		}
+	}
+
+	shots, err := vp.db.GetScenesByVideoIDAndLevel(video.ID, models.SceneLevelShot)
+	if err != nil {
+		return fmt.Errorf("failed to load shots: %v", err)
+	}
+	scenes := make([]scenedetect.Scene, len(shots))
+	for i, s := range shots {
+		scenes[i] = scenedetect.Scene{Index: s.SceneIndex, StartTime: s.StartTime, EndTime: s.EndTime}
+	}
+
+	now := time.Now()
+	duration := video.Duration
+	if err := vp.saveVideo(video, []string{"live_processed_until"}, func(v *models.Video) {
+		v.Live = false
+		v.Status = models.VideoStatusCompleted
+		v.LastProcessedAt = &now
+		if duration > v.Duration {
+			v.Duration = duration
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to finalize live video: %v", err)
+	}
+	beats, err := vp.storeSceneLevels(video, scenes)
+	if err != nil {
+		return err
+	}
+	vp.reassociateAnnotations(video.ID)
+	vp.relinkCaptionScenes(video.ID)
+	// Keyframes are selected once over the finished recording
+	vp.storeKeyframes(video, video.Filepath, scenes, beats)
+	log.Printf("Finalized live video %d: %d shots, %v beats, %.2fs", video.ID, len(scenes), video.Metadata["beat_count"], video.Duration)
+
+	if vp.jobQueue != nil && len(scenes) > 0 {
+		embedPayload := map[string]interface{}{
+			"video_id": video.ID,
+			"level":    models.SceneLevelBeat,
+		}
+		if _, err := vp.jobQueue.Enqueue(queue.JobTypeEmbeddingGeneration, embedPayload); err != nil {
+			vp.warnf("Failed to enqueue beat embeddings for live video %d: %v", video.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// ModalityText is the modality rolling model upgrades apply to: scene text embeddings, plus the
+// query, video-level, topic and alert vectors compared against them
+const ModalityText = "text"
+
+// activeTextModel caches the model ID text is embedded with ("" while the runner default is in use)
+var activeTextModel struct {
+	sync.Mutex
+	db       *database.DB
+	modelID  string
+	loadedAt time.Time
+}
+
+// ActiveTextModelID is the model text must be embedded with to match stored scene text embeddings
+// ("" for the runner default). It is re-read every 10 seconds so a cutover reaches every process.
+func ActiveTextModelID() string {
+	activeTextModel.Lock()
+	defer activeTextModel.Unlock()
+	if activeTextModel.db == nil || time.Since(activeTextModel.loadedAt) < 10*time.Second {
+		return activeTextModel.modelID
+	}
+	m, err := activeTextModel.db.ActiveEmbeddingModel(ModalityText)
+	if err != nil {
+		log.Printf("Warning: failed to load active text model: %v", err)
+	} else if m != nil {
+		activeTextModel.modelID = m.ModelID
+	} else {
+		activeTextModel.modelID = ""
+	}
+	activeTextModel.loadedAt = time.Now()
+	return activeTextModel.modelID
+}
+
+// InvalidateActiveTextModel makes the next lookup re-read the active model
+func InvalidateActiveTextModel() {
+	activeTextModel.Lock()
+	defer activeTextModel.Unlock()
+	activeTextModel.loadedAt = time.Time{}
+}
+
+// BuiltinTextModel describes the runner's default text model (E5_MODEL_ID), which is active until
+// the first cutover
+func BuiltinTextModel() models.EmbeddingModel {
+	id := modelFromEnv("text_embedding", "E5_MODEL_ID", "intfloat/e5-base-v2").ModelID
+	return models.EmbeddingModel{Name: "default (" + id + ")", Modality: ModalityText, ModelID: id, Dimensions: 768}
+}
+
+// sceneCaptionTexts joins the captions attributed to each of a level's scenes under the
+// caption-to-scene strategy, with rules' boilerplate removed; hasText marks scenes with any text
+func sceneCaptionTexts(scenes []models.Scene, captions []models.Caption, rules *SceneTextRules) ([]string, []bool) {
+	strategy := database.CaptionSceneStrategy()
+	parts := make([][]string, len(scenes))
+	for _, c := range captions {
+		t := rules.Apply(c.Text)
+		if t == "" {
+			continue
+		}
+		for _, sh := range captionShares(c, t, scenes, strategy) {
+			parts[sh.Scene] = append(parts[sh.Scene], sh.Text)
+		}
+	}
+	texts := make([]string, len(scenes))
+	hasText := make([]bool, len(scenes))
+	for i := range scenes {
+		texts[i] = strings.TrimSpace(strings.Join(parts[i], " "))
+		hasText[i] = texts[i] != ""
+	}
+	return texts, hasText
+}
+
+// embedTrackingModels embeds freshly ingested scene texts with every model being backfilled or
+// validated, so a model that started backfilling before the video arrived is complete at cutover
+func (vp *VideoProcessor) embedTrackingModels(videoID uint, scenes []models.Scene, texts []string, hasText []bool) {
+	tracking, err := vp.db.TrackingEmbeddingModels(ModalityText)
+	if err != nil || len(tracking) == 0 {
+		return
+	}
+	var ids []uint
+	var batch []string
+	for i := range scenes {
+		if hasText[i] {
+			ids = append(ids, scenes[i].ID)
+			batch = append(batch, texts[i])
+		}
+	}
+	if len(batch) == 0 {
+		return
+	}
+	for _, m := range tracking {
+		vecs, err := embedTextsWithModel(vp.context(), batch, "passage", m.ModelID)
+		if err != nil {
+			vp.warnf("failed to embed video %d with model %s: %v", videoID, m.Name, err)
+			continue
+		}
+		byScene := make(map[uint][]float32, len(ids))
+		for i, id := range ids {
+			byScene[id] = vecs[i]
+		}
+		if err := vp.db.UpsertSceneEmbeddings(m.ID, byScene); err != nil {
+			vp.warnf("failed to store %s embeddings for video %d: %v", m.Name, videoID, err)
+		}
+	}
+}
+
+// modelBackfillBatch is the number of scenes one backfill job embeds (MODEL_BACKFILL_BATCH, default 256)
+func modelBackfillBatch() int {
+	if v, err := strconv.Atoi(os.Getenv("MODEL_BACKFILL_BATCH")); err == nil && v > 0 {
+		return v
+	}
+	return 256
+}
+
+// modelRetireGrace is how long a replaced model's vectors are kept for rollback (MODEL_RETIRE_GRACE_HOURS, default 72)
+func modelRetireGrace() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("MODEL_RETIRE_GRACE_HOURS")); err == nil && v >= 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return 72 * time.Hour
+}
+
+// embeddingModelFromPayload loads the model named by payload "embedding_model_id"
+func (vp *VideoProcessor) embeddingModelFromPayload(payload map[string]interface{}) (*models.EmbeddingModel, error) {
+	id, ok := payload["embedding_model_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing or invalid embedding_model_id in payload")
+	}
+	m, err := vp.db.GetEmbeddingModel(uint(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedding model %d: %v", uint(id), err)
+	}
+	return m, nil
+}
+
+// ProcessModelBackfill embeds the next batch of text-embedded scenes with a backfilling model and
+// re-enqueues itself until every scene is done, then moves the model to validating.
+// Payload: {"embedding_model_id": 3}
+func (vp *VideoProcessor) ProcessModelBackfill(payload map[string]interface{}) error {
+	m, err := vp.embeddingModelFromPayload(payload)
+	if err != nil {
+		return err
+	}
+	if m.Status != models.EmbeddingModelBackfilling {
+		log.Printf("[models] %s is %s; backfill stopped", m.Name, m.Status)
+		return nil
+	}
+	fail := func(err error) error {
+		msg := err.Error()
+		m.Status, m.Error = models.EmbeddingModelFailed, &msg
+		if uerr := vp.db.UpdateEmbeddingModel(m); uerr != nil {
+			vp.warnf("%v", uerr)
+		}
+		return err
+	}
+
+	scenes, err := vp.db.TextEmbeddedScenesAfter(m.BackfillCursor, modelBackfillBatch())
+	if err != nil {
+		return fmt.Errorf("failed to load scenes: %v", err)
+	}
+	if len(scenes) > 0 {
+		// Scene texts are rebuilt from captions the way embedding generation builds them, against
+		// each level's full scene list so captions are attributed as they were then
+		byVideo := map[uint][]int{}
+		for i, s := range scenes {
+			byVideo[s.VideoID] = append(byVideo[s.VideoID], i)
+		}
+		texts := make([]string, len(scenes))
+		hasText := make([]bool, len(scenes))
+		for videoID, idx := range byVideo {
+			captions, err := vp.db.GetCaptionsByVideoID(videoID)
+			if err != nil {
+				return fmt.Errorf("failed to load captions of video %d: %v", videoID, err)
+			}
+			video, err := vp.db.GetVideoByID(videoID)
+			if err != nil {
+				return fmt.Errorf("failed to load video %d: %v", videoID, err)
+			}
+			rules := vp.sceneTextRules(video)
+			byLevel := map[string]map[uint]string{}
+			for _, i := range idx {
+				level := database.SceneLevelOrDefault(scenes[i].Level)
+				if byLevel[level] == nil {
+					all, err := vp.db.GetScenesByVideoIDAndLevel(videoID, level)
+					if err != nil {
+						return fmt.Errorf("failed to load scenes of video %d: %v", videoID, err)
+					}
+					t, _ := sceneCaptionTexts(all, captions, rules)
+					byLevel[level] = make(map[uint]string, len(all))
+					for j, s := range all {
+						byLevel[level][s.ID] = t[j]
+					}
+				}
+				texts[i] = byLevel[level][scenes[i].ID]
+				hasText[i] = texts[i] != ""
+			}
+		}
+		var ids []uint
+		var batch []string
+		for i, s := range scenes {
+			if hasText[i] {
+				ids = append(ids, s.ID)
+				batch = append(batch, texts[i])
+			}
+		}
+		if len(batch) > 0 {
+			vecs, err := embedTextsWithModel(vp.context(), batch, "passage", m.ModelID)
+			if err != nil {
+				return fail(fmt.Errorf("embedding failed: %v", err))
+			}
+			if len(vecs[0]) != m.Dimensions {
+				return fail(fmt.Errorf("model returned %d dimensions, expected %d", len(vecs[0]), m.Dimensions))
+			}
+			byScene := make(map[uint][]float32, len(ids))
+			for i, id := range ids {
+				byScene[id] = vecs[i]
+			}
+			if err := vp.db.UpsertSceneEmbeddings(m.ID, byScene); err != nil {
+				return fmt.Errorf("failed to store embeddings: %v", err)
+			}
+		}
+		m.BackfillCursor = scenes[len(scenes)-1].ID
+		m.BackfilledScenes += len(scenes)
+	}
+
+	done := len(scenes) < modelBackfillBatch()
+	if done {
+		m.Status = models.EmbeddingModelValidating
+		if n, err := vp.db.CountSceneEmbeddings(m.ID); err == nil {
+			m.BackfilledScenes = n
+		}
+	}
+	if err := vp.db.UpdateEmbeddingModel(m); err != nil {
+		return fmt.Errorf("failed to record backfill progress: %v", err)
+	}
+	if done {
+		log.Printf("[models] %s backfilled (%d scenes); ready for validation", m.Name, m.BackfilledScenes)
+		return nil
+	}
+	if vp.jobQueue == nil {
+		return fmt.Errorf("queue not available to continue backfill")
+	}
+	if _, err := vp.jobQueue.Enqueue(queue.JobTypeModelBackfill, map[string]interface{}{"embedding_model_id": m.ID}); err != nil {
+		return fmt.Errorf("failed to continue backfill: %v", err)
+	}
+	log.Printf("[models] %s: backfilled %d/%d scenes", m.Name, m.BackfilledScenes, m.TotalScenes)
+	return nil
+}
+
+// ProcessModelCutover runs after a model was activated: vectors that live outside the scenes table
+// (video-level text, semantic alert queries, topic timelines, caption passages) are recomputed with the new model,
+// and the replaced model is scheduled for retirement.
+// Payload: {"embedding_model_id": 3, "retired_model_id": 1}
+func (vp *VideoProcessor) ProcessModelCutover(payload map[string]interface{}) error {
+	m, err := vp.embeddingModelFromPayload(payload)
+	if err != nil {
+		return err
+	}
+	InvalidateActiveTextModel()
+
+	videoIDs, err := vp.db.AllVideoIDs()
+	if err != nil {
+		return fmt.Errorf("failed to list videos: %v", err)
+	}
+	reembedded := 0
+	for _, id := range videoIDs {
+		video, err := vp.db.GetVideoByID(id)
+		if err != nil {
+			continue
+		}
+		if err := vp.embedVideoText(video); errors.Is(err, ErrRunnerUnavailable) {
+			return err
+		} else if err != nil {
+			vp.warnf("failed to re-embed video %d: %v", id, err)
+			continue
+		}
+		reembedded++
+	}
+
+	alerts, err := vp.db.ListAlerts(false)
+	if err != nil {
+		return fmt.Errorf("failed to list alerts: %v", err)
+	}
+	for i := range alerts {
+		a := &alerts[i]
+		if a.AlertType != models.AlertTypeSemantic {
+			continue
+		}
+		vec, err := embedText(vp.context(), a.Query, "query")
+		if err != nil {
+			vp.warnf("failed to re-embed alert %d: %v", a.ID, err)
+			continue
+		}
+		v := pgvector.NewVector(vec)
+		a.Embedding = &v
+		if err := vp.db.UpdateAlert(a); err != nil {
+			vp.warnf("failed to store alert %d: %v", a.ID, err)
+		}
+	}
+
+	if vp.jobQueue != nil {
+		if ids, err := vp.db.VideoIDsWithTopics(); err == nil {
+			for _, id := range ids {
+				if _, err := vp.jobQueue.Enqueue(queue.JobTypeTopicTimeline, map[string]interface{}{"video_id": id}); err != nil {
+					vp.warnf("failed to enqueue topic timeline for video %d: %v", id, err)
+				}
+			}
+		}
+		if ids, err := vp.db.VideoIDsWithCaptionEmbeddings(); err == nil {
+			for _, id := range ids {
+				if _, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionEmbedding, map[string]interface{}{"video_id": id}); err != nil {
+					vp.warnf("failed to enqueue caption embedding for video %d: %v", id, err)
+				}
+			}
+		}
+		if retired, ok := payload["retired_model_id"].(float64); ok {
+			at := time.Now().Add(modelRetireGrace())
+			if _, err := vp.jobQueue.EnqueueAt(queue.JobTypeModelRetire, map[string]interface{}{"embedding_model_id": uint(retired)}, at); err != nil {
+				vp.warnf("failed to schedule retirement of model %d: %v", uint(retired), err)
+			}
+		}
+	}
+	log.Printf("[models] cut over to %s: re-embedded %d videos and semantic alerts", m.Name, reembedded)
+	return nil
+}
+
+// ProcessModelRetire deletes the vectors a replaced model kept for rollback, unless it was
+// reactivated in the meantime. Payload: {"embedding_model_id": 1}
+func (vp *VideoProcessor) ProcessModelRetire(payload map[string]interface{}) error {
+	m, err := vp.embeddingModelFromPayload(payload)
+	if err != nil {
+		return err
+	}
+	if m.Status != models.EmbeddingModelRetired {
+		log.Printf("[models] %s is %s; not purging its vectors", m.Name, m.Status)
+		return nil
+	}
+	if err := vp.db.PurgeSceneEmbeddings(m.ID); err != nil {
+		return fmt.Errorf("failed to purge vectors of %s: %v", m.Name, err)
+	}
+	m.Status = models.EmbeddingModelPurged
+	if err := vp.db.UpdateEmbeddingModel(m); err != nil {
+		return fmt.Errorf("failed to update model %s: %v", m.Name, err)
+	}
+	log.Printf("[models] purged vectors of retired model %s", m.Name)
+	return nil
+}
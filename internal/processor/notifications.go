@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/notify"
+	"goodclips-server/internal/queue"
+)
+
+// videoProject is the project a video belongs to (metadata.project), used to route its notifications
+func videoProject(video *models.Video) string {
+	p, _ := video.Metadata["project"].(string)
+	return p
+}
+
+// videoTitle is a video's title, or its file name when untitled
+func videoTitle(video *models.Video) string {
+	if video.Title != nil && *video.Title != "" {
+		return *video.Title
+	}
+	return video.Filename
+}
+
+// publishVideoIngested announces that a video's scenes are embedded and searchable
+func publishVideoIngested(video *models.Video) {
+	url := notify.PublicURL(fmt.Sprintf("/api/v1/videos/%d", video.ID))
+	notify.Publish(notify.Message{
+		Event:   notify.EventVideoIngested,
+		Project: videoProject(video),
+		Subject: fmt.Sprintf("[goodclips] Ingested %s", videoTitle(video)),
+		Text:    fmt.Sprintf("%q finished processing: %d scenes, %d captions.\n%s", videoTitle(video), video.SceneCount, video.CaptionCount, url),
+		Data: map[string]any{
+			"video": map[string]any{"id": video.ID, "title": videoTitle(video), "duration": video.Duration, "scene_count": video.SceneCount, "caption_count": video.CaptionCount, "url": url},
+		},
+		Time: time.Now().UTC(),
+	})
+}
+
+// digestEnabled reports whether the weekly library digest is scheduled (NOTIFY_DIGEST, default true)
+func digestEnabled() bool {
+	if v, err := strconv.ParseBool(os.Getenv("NOTIFY_DIGEST")); err == nil {
+		return v
+	}
+	return true
+}
+
+// nextDigestTime is the next NOTIFY_DIGEST_WEEKDAY (default monday) at NOTIFY_DIGEST_HOUR (default 9) UTC after now
+func nextDigestTime(now time.Time) time.Time {
+	day := time.Monday
+	if v := strings.ToLower(os.Getenv("NOTIFY_DIGEST_WEEKDAY")); v != "" {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.ToLower(d.String()) == v {
+				day = d
+			}
+		}
+	}
+	hour := 9
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_DIGEST_HOUR")); err == nil && v >= 0 && v < 24 {
+		hour = v
+	}
+	now = now.UTC()
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	t = t.AddDate(0, 0, (int(day)-int(t.Weekday())+7)%7)
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 7)
+	}
+	return t
+}
+
+// ScheduleNotificationDigest makes sure the next weekly digest job is queued; several workers calling
+// it schedule the digest only once
+func (vp *VideoProcessor) ScheduleNotificationDigest() error {
+	if vp.jobQueue == nil || !digestEnabled() {
+		return nil
+	}
+	at := nextDigestTime(time.Now())
+	key := fmt.Sprintf("digest:%d", at.Unix())
+	if _, err := vp.jobQueue.EnqueueOnce(key, queue.JobTypeNotificationDigest, map[string]interface{}{}, at); err != nil {
+		return fmt.Errorf("failed to schedule library digest: %v", err)
+	}
+	return nil
+}
+
+// ProcessNotificationDigest publishes the weekly library digest and schedules the next one
+func (vp *VideoProcessor) ProcessNotificationDigest(payload map[string]interface{}) error {
+	defer func() {
+		if err := vp.ScheduleNotificationDigest(); err != nil {
+			vp.warnf("%v", err)
+		}
+	}()
+	d, err := vp.db.GetLibraryDigest(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		return fmt.Errorf("failed to build library digest: %v", err)
+	}
+	text := fmt.Sprintf("Since %s:\n- %d new videos (%.1f hours)\n- %d new scenes\n- %d searches\n- %d alert matches\n\nLibrary: %d videos, %.1f hours.\n%s",
+		d.Since.Format("Mon 2 Jan 2006"), d.NewVideos, d.HoursIngested, d.NewScenes, d.Searches, d.AlertMatches, d.TotalVideos, d.TotalHours,
+		notify.PublicURL("/api/v1/stats"))
+	notify.Publish(notify.Message{
+		Event:   notify.EventLibraryDigest,
+		Subject: fmt.Sprintf("[goodclips] Weekly digest: %d new videos", d.NewVideos),
+		Text:    text,
+		Data:    map[string]any{"digest": d},
+		Time:    time.Now().UTC(),
+	})
+	log.Printf("[notify] published library digest (%d new videos)", d.NewVideos)
+	return nil
+}
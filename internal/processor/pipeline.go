@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"fmt"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// pipelineStages are the stages whose jobs decide whether a video's processing completed
+var pipelineStages = []queue.JobType{
+	queue.JobTypeVideoIngestion,
+	queue.JobTypeSceneDetection,
+	queue.JobTypeCaptionExtraction,
+	queue.JobTypeEmbeddingGeneration,
+}
+
+// AdvancePipeline moves a video out of processing once a job of one of its pipelineStages settles
+// and none of their jobs is pending or running. The video is marked failed (VideoStatusError) with
+// the latest error of each failed stage, otherwise completed. Cancelled stages (by request or
+// feature flag) do not fail it. Jobs of other types, live videos, videos sharing another's content
+// (their status follows that video's) and videos with no completed stage are left alone.
+func (vp *VideoProcessor) AdvancePipeline(job *queue.Job) error {
+	if vp.jobQueue == nil || !isPipelineStage(job.Type) {
+		return nil
+	}
+	videoID, ok := queue.PayloadVideoID(job.Payload)
+	if !ok {
+		return nil
+	}
+	jobs, err := vp.jobQueue.ListJobsForVideo(videoID)
+	if err != nil {
+		return err
+	}
+	byStage := make(map[queue.JobType][]*queue.Job)
+	for _, j := range jobs {
+		byStage[j.Type] = append(byStage[j.Type], j)
+	}
+
+	completed := 0
+	var failures []string
+	var failureCode *string
+	for _, stage := range pipelineStages {
+		stageJobs := byStage[stage]
+		switch queue.StageStatus(stageJobs) {
+		case string(queue.JobStatusPending), string(queue.JobStatusRunning):
+			return nil
+		case string(queue.JobStatusCompleted):
+			completed++
+		case string(queue.JobStatusFailed):
+			msg := "unknown error"
+			latest := stageJobs[len(stageJobs)-1]
+			if latest.ErrorMessage != nil {
+				msg = *latest.ErrorMessage
+			}
+			code := string(latest.ErrorCode)
+			if code == "" {
+				code = string(queue.ErrorInternal)
+			}
+			if failureCode == nil {
+				failureCode = &code
+			}
+			failures = append(failures, fmt.Sprintf("%s failed (%s): %s", stage, code, msg))
+		}
+	}
+	if completed == 0 && len(failures) == 0 {
+		return nil
+	}
+
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	if video.Live || video.Status == models.VideoStatusDeleted || video.ContentVideoID != nil {
+		return nil
+	}
+	if len(failures) > 0 {
+		msg := failures[0]
+		for _, f := range failures[1:] {
+			msg += "; " + f
+		}
+		return vp.db.SetVideoPipelineOutcome(videoID, models.VideoStatusError, failureCode, &msg)
+	}
+	return vp.db.SetVideoPipelineOutcome(videoID, models.VideoStatusCompleted, nil, nil)
+}
+
+func isPipelineStage(t queue.JobType) bool {
+	for _, s := range pipelineStages {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
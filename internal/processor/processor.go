@@ -2,7 +2,9 @@ package processor
 
 import (
     "bytes"
+    "context"
     "encoding/json"
+    "errors"
     "fmt"
     "io"
     "log"
@@ -10,13 +12,13 @@ import (
     "os/exec"
     "path/filepath"
     "strconv"
-    "strings"
 
     "goodclips-server/internal/database"
     "goodclips-server/internal/ffmpeg"
     "goodclips-server/internal/models"
     "goodclips-server/internal/scenedetect"
     "goodclips-server/internal/queue"
+    "goodclips-server/internal/textnorm"
 )
 
 // VideoProcessor handles video processing tasks
@@ -25,10 +27,25 @@ type VideoProcessor struct {
     ffmpegClient   *ffmpeg.FFmpegClient
     sceneDetector  *scenedetect.Detector
     jobQueue       *queue.Queue
+    // ctx is the context of the job being processed; cancelling it kills the job's subprocesses
+    ctx            context.Context
+    // progress reports the progress of the job being processed (nil outside jobs)
+    progress       *progressTracker
+    // jobID is the ID of the job being processed (empty outside jobs)
+    jobID          string
+    // result collects the outputs of the job being processed (nil outside jobs)
+    result         *JobResult
 }
 
 // NewVideoProcessor creates a new video processor instance
 func NewVideoProcessor(db *database.DB, jobQueue *queue.Queue) *VideoProcessor {
+    activeTextModel.Lock()
+    activeTextModel.db = db
+    activeTextModel.Unlock()
+    runnerBreakers.Lock()
+    runnerBreakers.q = jobQueue
+    runnerBreakers.policy = queue.BreakerPolicyFromEnv()
+    runnerBreakers.Unlock()
     return &VideoProcessor{
         db:             db,
         ffmpegClient:   ffmpeg.NewFFmpegClient(),
@@ -37,6 +54,33 @@ func NewVideoProcessor(db *database.DB, jobQueue *queue.Queue) *VideoProcessor {
     }
 }
 
+// WithContext returns a copy of the processor whose subprocesses (ffmpeg, scene detection, Python
+// runners) are killed once ctx is done. The worker processes each job with its own context so the
+// job can be cancelled.
+func (vp *VideoProcessor) WithContext(ctx context.Context) *VideoProcessor {
+    c := *vp
+    c.ctx = ctx
+    c.ffmpegClient = vp.ffmpegClient.WithContext(ctx)
+    c.sceneDetector = vp.sceneDetector.WithContext(ctx)
+    return &c
+}
+
+// WithJob returns a copy of the processor that knows the ID of the job it processes, so work handed
+// off to other jobs can hold back the job's dependents
+func (vp *VideoProcessor) WithJob(jobID string) *VideoProcessor {
+    c := *vp
+    c.jobID = jobID
+    return &c
+}
+
+// context is the processor's job context, or the background context outside jobs
+func (vp *VideoProcessor) context() context.Context {
+    if vp.ctx == nil {
+        return context.Background()
+    }
+    return vp.ctx
+}
+
 // ProcessVideoIngestion handles video ingestion jobs
 func (vp *VideoProcessor) ProcessVideoIngestion(payload map[string]interface{}) error {
     videoID, ok := payload["video_id"]
@@ -56,9 +100,19 @@ func (vp *VideoProcessor) ProcessVideoIngestion(payload map[string]interface{})
 
     log.Printf("Processing video ingestion for video ID %v: %s", videoID, filename)
 
+    // Hash the source first; a duplicate of an existing video shares its content or is retired instead of processed
+    if id, ok := queue.PayloadVideoID(payload); ok {
+        duplicate, err := vp.dedupeVideo(id, filepathStr)
+        if err != nil {
+            vp.warnf("%v", err)
+        } else if duplicate {
+            return nil
+        }
+    }
+
     // Check if FFmpeg is available
     if err := vp.ffmpegClient.CheckFFmpeg(); err != nil {
-        log.Printf("Warning: FFmpeg not available: %v", err)
+        vp.warnf("FFmpeg not available: %v", err)
         // Continue processing but without FFmpeg features
         return vp.processVideoIngestionWithoutFFmpeg(videoID, filepathStr, filename)
     }
@@ -66,7 +120,7 @@ func (vp *VideoProcessor) ProcessVideoIngestion(payload map[string]interface{})
     // Get video metadata using FFmpeg
     metadata, err := vp.ffmpegClient.GetVideoMetadata(filepathStr)
     if err != nil {
-        log.Printf("Warning: Failed to get video metadata with FFmpeg: %v", err)
+        vp.warnf("Failed to get video metadata with FFmpeg: %v", err)
         return vp.processVideoIngestionWithoutFFmpeg(videoID, filepathStr, filename)
     }
 
@@ -88,14 +142,14 @@ func (vp *VideoProcessor) ProcessVideoIngestion(payload map[string]interface{})
     video.Duration = duration
     video.Status = models.VideoStatusProcessing
 
-    if err := vp.db.UpdateVideo(video); err != nil {
+    if err := vp.db.UpdateVideoColumns(video.ID, map[string]interface{}{"duration": duration, "status": video.Status}); err != nil {
         return fmt.Errorf("failed to update video: %v", err)
     }
 
     log.Printf("Successfully processed video ingestion for video ID %v", videoID)
 
     // Create subsequent jobs for scene detection and caption extraction
-    return vp.createSubsequentJobs(video)
+    return vp.createSubsequentJobs(video, refreshScenes(payload))
 }
 
 // processVideoIngestionWithoutFFmpeg updates minimal metadata when FFmpeg isn't available
@@ -121,7 +175,7 @@ func (vp *VideoProcessor) processVideoIngestionWithoutFFmpeg(videoID interface{}
     // Keep duration as-is (likely 0), mark as processing
     video.Status = models.VideoStatusProcessing
 
-    if err := vp.db.UpdateVideo(video); err != nil {
+    if err := vp.db.UpdateVideoStatus(video.ID, video.Status); err != nil {
         return fmt.Errorf("failed to update video without ffmpeg: %v", err)
     }
 
@@ -129,8 +183,14 @@ func (vp *VideoProcessor) processVideoIngestionWithoutFFmpeg(videoID interface{}
     return nil
 }
 
-// createSubsequentJobs creates jobs for scene detection and caption extraction
-func (vp *VideoProcessor) createSubsequentJobs(video *models.Video) error {
+// createSubsequentJobs creates jobs for scene detection and caption extraction; with refresh the scene
+// detection job ignores cached results
+func (vp *VideoProcessor) createSubsequentJobs(video *models.Video, refresh bool) error {
+    // Audio and image assets skip scene detection and caption extraction
+    if video.AssetType == models.AssetTypeAudio || video.AssetType == models.AssetTypeImage {
+        return vp.createAssetJobs(video)
+    }
+
     if vp.jobQueue == nil {
         log.Printf("Queue not available; skipping enqueue of follow-up jobs for video ID %d", video.ID)
         return nil
@@ -142,9 +202,15 @@ func (vp *VideoProcessor) createSubsequentJobs(video *models.Video) error {
         "filename": video.Filename,
         "filepath": video.Filepath,
     }
-    if _, err := vp.jobQueue.Enqueue(queue.JobTypeSceneDetection, scenePayload); err != nil {
-        log.Printf("Warning: Failed to enqueue scene detection job for video %d: %v", video.ID, err)
+    if refresh {
+        scenePayload["refresh_scenes"] = true
+    }
+    var dependsOn []string
+    captionsQueued := false
+    if job, err := vp.jobQueue.Enqueue(queue.JobTypeSceneDetection, scenePayload); err != nil {
+        vp.warnf("Failed to enqueue scene detection job for video %d: %v", video.ID, err)
     } else {
+        dependsOn = append(dependsOn, job.ID)
         log.Printf("Enqueued scene detection job for video ID %d", video.ID)
     }
 
@@ -154,18 +220,29 @@ func (vp *VideoProcessor) createSubsequentJobs(video *models.Video) error {
         "filename": video.Filename,
         "filepath": video.Filepath,
     }
-    if _, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionExtraction, captionPayload); err != nil {
-        log.Printf("Warning: Failed to enqueue caption extraction job for video %d: %v", video.ID, err)
+    if !StageEnabled(FlagCaptionExtraction) {
+        log.Printf("Skipping caption extraction for video ID %d (feature flag %s off)", video.ID, FlagCaptionExtraction)
+    } else if job, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionExtraction, captionPayload); err != nil {
+        vp.warnf("Failed to enqueue caption extraction job for video %d: %v", video.ID, err)
     } else {
+        dependsOn = append(dependsOn, job.ID)
+        captionsQueued = true
         log.Printf("Enqueued caption extraction job for video ID %d", video.ID)
     }
 
-    // Optionally enqueue embedding generation after others
+    // Link captions to scenes once both exist
+    if captionsQueued {
+        if _, err := vp.jobQueue.EnqueueAfter(queue.JobTypeCaptionLinking, map[string]interface{}{"video_id": video.ID}, dependsOn); err != nil {
+            vp.warnf("Failed to enqueue caption linking job for video %d: %v", video.ID, err)
+        }
+    }
+
+    // Enqueue embedding generation, dequeueable once scenes and captions exist
     embedPayload := map[string]interface{}{
         "video_id": video.ID,
     }
-    if _, err := vp.jobQueue.Enqueue(queue.JobTypeEmbeddingGeneration, embedPayload); err != nil {
-        log.Printf("Warning: Failed to enqueue embedding generation job for video %d: %v", video.ID, err)
+    if _, err := vp.jobQueue.EnqueueAfter(queue.JobTypeEmbeddingGeneration, embedPayload, dependsOn); err != nil {
+        vp.warnf("Failed to enqueue embedding generation job for video %d: %v", video.ID, err)
     } else {
         log.Printf("Enqueued embedding generation job for video ID %d", video.ID)
     }
@@ -185,62 +262,212 @@ func (vp *VideoProcessor) ProcessSceneDetection(payload map[string]interface{})
     }
 
     log.Printf("Processing scene detection for video ID %v", videoID)
+    if v, err := vp.db.GetVideoByID(uint(videoID.(float64))); err == nil && v.AssetType != models.AssetTypeVideo && v.AssetType != "" {
+        log.Printf("Skipping scene detection for %s asset %d", v.AssetType, v.ID)
+        return nil
+    }
 
     // Check if scene detection tools are available
 	if err := vp.sceneDetector.CheckDependencies(); err != nil {
-		log.Printf("Warning: Scene detection dependencies not available: %v", err)
+		vp.warnf("Scene detection dependencies not available: %v", err)
 		return fmt.Errorf("scene detection dependencies not available: %v", err)
 	}
 	
-	// Detect scenes
-	scenes, err := vp.sceneDetector.DetectScenes(filepathStr)
+	video, err := vp.db.GetVideoByID(uint(videoID.(float64)))
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	if video.Locked && video.SceneCount > 0 {
+		return queue.WithCode(queue.ErrorRefused, fmt.Errorf("video %d is locked (legal hold); refusing to replace existing scenes", video.ID))
+	}
+	
+	// A reviewed preview supplies the scenes instead of a new detection run
+	if previewID, ok := payload["preview_id"].(float64); ok {
+		return vp.commitScenePreview(video, filepathStr, uint(previewID))
+	}
+	
+	// Long videos are detected in time chunks by separate jobs and stitched by the last one to finish
+	if _, ok := payload["chunk_index"]; ok {
+		return vp.processSceneChunk(video, filepathStr, payload)
+	}
+	if chunks := sceneChunkRanges(video.Duration, sceneChunkSeconds()); len(chunks) > 1 {
+		return vp.splitSceneDetection(video, filepathStr, chunks, refreshScenes(payload))
+	}
+	
+	// Detect scenes, reusing an earlier run over the same content
+	scenes, err := vp.detectScenesCached(video, filepathStr, 0, 0, 0, refreshScenes(payload))
 	if err != nil {
 		return fmt.Errorf("failed to detect scenes: %v", err)
 	}
 	
 	log.Printf("Detected %d scenes for video ID %v", len(scenes), videoID)
+	vp.stepProgress(0, 0.4, 1, 1)
+	return vp.finishSceneDetection(video, filepathStr, scenes)
+}
+
+// finishSceneDetection validates and merges detected scenes, then replaces the video's scenes with them
+func (vp *VideoProcessor) finishSceneDetection(video *models.Video, filepathStr string, scenes []scenedetect.Scene) error {
+	videoID := video.ID
 	
-	// Update video scene count
-	video, err := vp.db.GetVideoByID(uint(videoID.(float64)))
+	// Validate boundaries before storing: downstream sampling breaks on gaps, overlaps and garbage ranges
+	scenes, validation, err := scenedetect.ValidateScenes(scenes, video.Duration, scenedetect.ValidationOptionsFromEnv())
 	if err != nil {
-		return fmt.Errorf("failed to get video: %v", err)
+		return fmt.Errorf("scene detection produced invalid scenes: %v", err)
+	}
+	if len(validation.Gaps) > 0 || validation.SnappedBounds > 0 || validation.DroppedEmpty > 0 {
+		log.Printf("Scene validation for video ID %v: %d -> %d scenes, %d gaps (%d filled), %d snapped, %d dropped",
+			videoID, validation.InputScenes, validation.OutputScenes, len(validation.Gaps), validation.GapsFilled, validation.SnappedBounds, validation.DroppedEmpty)
+	}
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	video.Metadata["scene_validation"] = validation
+	
+	// Merge micro-scenes from rapid cutting: useless as clips and expensive to embed
+	scenes, merge := scenedetect.MergeShortScenes(scenes, scenedetect.MinSceneDurationFromEnv())
+	if merge.Merged > 0 {
+		log.Printf("Merged %d scenes shorter than %.2fs for video ID %v (%d -> %d)", merge.Merged, merge.MinDuration, videoID, merge.InputScenes, merge.OutputScenes)
+	}
+	video.Metadata["scene_merge"] = merge
+	
+	return vp.replaceScenes(video, filepathStr, scenes)
+}
+
+// storeKeyframes selects a representative frame per shot (sharpest, preferring faces), writes it to the
+// video's keyframes directory and stores its timestamp and path; each beat uses the best keyframe of its shots.
+// Failures are logged: keyframes improve thumbnails and CLIP embeddings but are not required.
+func (vp *VideoProcessor) storeKeyframes(video *models.Video, filepathStr string, scenes []scenedetect.Scene, beats []scenedetect.Beat) {
+	keyframesDir := filepath.Join(filepath.Dir(filepathStr), fmt.Sprintf("video_%v_keyframes", video.ID))
+	if err := os.MkdirAll(keyframesDir, 0755); err != nil {
+		vp.warnf("Failed to create keyframes directory: %v", err)
+		return
+	}
+	// The midpoint fallback reports its ffmpeg progress over the selection's share of the stage
+	detector := vp.sceneDetector.WithFFmpeg(vp.ffmpegClient.WithProgress(func(p ffmpeg.Progress) {
+		vp.stepProgress(0, 0.6, int(p.Percent), 100)
+	}))
+	keyframes, err := detector.SelectKeyframes(filepathStr, keyframesDir, scenes, scenedetect.KeyframeOptionsFromEnv())
+	if err != nil {
+		vp.warnf("Failed to extract keyframes: %v", err)
+		return
+	}
+	
+	vp.stepProgress(0.6, 0.85, 1, 1)
+	
+	byShot := make(map[int]scenedetect.Keyframe, len(keyframes))
+	for i, kf := range keyframes {
+		byShot[kf.Index] = kf
+		if err := vp.db.UpdateSceneKeyframeByIndex(video.ID, models.SceneLevelShot, kf.Index, kf.Timestamp, kf.Path); err != nil {
+			vp.warnf("Failed to store keyframe for scene %d: %v", kf.Index, err)
+		}
+		vp.storeKeyframeCandidates(video.ID, kf)
+		vp.stepProgress(0.85, 1, i+1, len(keyframes))
+	}
+	for _, beat := range beats {
+		var best *scenedetect.Keyframe
+		for i := beat.FirstShot; i <= beat.LastShot && i < len(scenes); i++ {
+			if kf, ok := byShot[scenes[i].Index]; ok && (best == nil || kf.Score > best.Score) {
+				best = &kf
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if err := vp.db.UpdateSceneKeyframeByIndex(video.ID, models.SceneLevelBeat, beat.Index, best.Timestamp, best.Path); err != nil {
+			vp.warnf("Failed to store keyframe for beat %d: %v", beat.Index, err)
+		}
+	}
+	log.Printf("Selected %d keyframes for video ID %d", len(keyframes), video.ID)
+}
+
+// storeKeyframeCandidates records a shot's candidate frames; the best one starts out selected
+func (vp *VideoProcessor) storeKeyframeCandidates(videoID uint, kf scenedetect.Keyframe) {
+	if len(kf.Candidates) == 0 {
+		return
+	}
+	scene, err := vp.db.GetSceneByVideoAndIndex(videoID, models.SceneLevelShot, kf.Index)
+	if err != nil {
+		vp.warnf("Failed to load scene %d for keyframe candidates: %v", kf.Index, err)
+		return
+	}
+	frames := make([]models.SceneKeyframe, 0, len(kf.Candidates))
+	for _, c := range kf.Candidates {
+		frames = append(frames, models.SceneKeyframe{
+			SceneID:   scene.ID,
+			VideoID:   videoID,
+			Rank:      c.Rank,
+			Timestamp: c.Timestamp,
+			Score:     c.Score,
+			Sharpness: c.Sharpness,
+			Faces:     c.Faces,
+			Path:      c.Path,
+			Selected:  c.Rank == 0,
+		})
+	}
+	if err := vp.db.ReplaceSceneKeyframes(scene.ID, frames); err != nil {
+		vp.warnf("Failed to store keyframe candidates for scene %d: %v", kf.Index, err)
 	}
+}
+
+// storeSceneLevels groups shots into beats (the coarser scene level clip consumers usually want),
+// updates the video's scene count and stores both levels. It returns the beats.
+func (vp *VideoProcessor) storeSceneLevels(video *models.Video, scenes []scenedetect.Scene) ([]scenedetect.Beat, error) {
+	beats := scenedetect.GroupBeats(scenes, scenedetect.BeatOptionsFromEnv())
+	beatOf := make([]int, len(scenes))
+	for _, b := range beats {
+		for i := b.FirstShot; i <= b.LastShot; i++ {
+			beatOf[i] = b.Index
+		}
+	}
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	video.Metadata["beat_count"] = len(beats)
 	
 	video.SceneCount = len(scenes)
-	if err := vp.db.UpdateVideo(video); err != nil {
-		return fmt.Errorf("failed to update video scene count: %v", err)
+	if err := vp.db.SetSceneCount(video.ID, video.SceneCount); err != nil {
+		return nil, fmt.Errorf("failed to update video scene count: %v", err)
+	}
+	if err := vp.saveVideo(video, []string{"scene_validation", "scene_merge", "scene_chunks", "scene_preview", "beat_count"}, nil); err != nil {
+		return nil, fmt.Errorf("failed to record scene detection metadata: %v", err)
 	}
 	
-	// Store scenes in database
-	for _, scene := range scenes {
+	// Store beats and shots in database
+	for _, beat := range beats {
+		beatModel := &models.Scene{
+			VideoID:    video.ID,
+			Level:      models.SceneLevelBeat,
+			SceneIndex: beat.Index,
+			StartTime:  beat.StartTime,
+			EndTime:    beat.EndTime,
+			Duration:   beat.EndTime - beat.StartTime,
+		}
+		if err := vp.db.CreateScene(beatModel); err != nil {
+			vp.warnf("Failed to store beat: %v", err)
+			continue
+		}
+		vp.countResult("beats_created", 1)
+	}
+	for i, scene := range scenes {
+		beatIndex := beatOf[i]
 		sceneModel := &models.Scene{
 			VideoID:    video.ID,
+			Level:      models.SceneLevelShot,
 			SceneIndex: scene.Index,
+			BeatIndex:  &beatIndex,
 			StartTime:  scene.StartTime,
 			EndTime:    scene.EndTime,
 			Duration:   scene.EndTime - scene.StartTime,
 		}
 		
 		if err := vp.db.CreateScene(sceneModel); err != nil {
-			log.Printf("Warning: Failed to store scene: %v", err)
+			vp.warnf("Failed to store scene: %v", err)
 			continue
 		}
+		vp.countResult("scenes_created", 1)
+		vp.stepProgress(0.4, 0.6, i+1, len(scenes))
 	}
-	
-	// Extract keyframes for scenes
-	dir := filepath.Dir(filepathStr)
-	keyframesDir := filepath.Join(dir, fmt.Sprintf("video_%v_keyframes", videoID))
-	
-	// Create keyframes directory
-	if err := os.MkdirAll(keyframesDir, 0755); err != nil {
-		log.Printf("Warning: Failed to create keyframes directory: %v", err)
-	} else {
-		if err := vp.sceneDetector.ExtractKeyframes(filepathStr, keyframesDir, scenes); err != nil {
-			log.Printf("Warning: Failed to extract keyframes: %v", err)
-		}
-	}
-	
-	return nil
+	return beats, nil
 }
 
 // ProcessCaptionExtraction handles caption extraction jobs
@@ -256,6 +483,10 @@ func (vp *VideoProcessor) ProcessCaptionExtraction(payload map[string]interface{
 	}
 	
 	log.Printf("Processing caption extraction for video ID %v", videoID)
+	if v, err := vp.db.GetVideoByID(uint(videoID.(float64))); err == nil && v.AssetType != models.AssetTypeVideo && v.AssetType != "" {
+		log.Printf("Skipping caption extraction for %s asset %d", v.AssetType, v.ID)
+		return nil
+	}
 	
 	// Check if FFmpeg is available
 	if err := vp.ffmpegClient.CheckFFmpeg(); err != nil {
@@ -276,51 +507,123 @@ func (vp *VideoProcessor) ProcessCaptionExtraction(payload map[string]interface{
 		// Try to extract subtitles
 		err := vp.ffmpegClient.ExtractSubtitlesToSRT(filepathStr, subtitlesPath)
 		if err != nil {
-			log.Printf("Warning: Failed to extract subtitles: %v", err)
+			vp.warnf("Failed to extract subtitles: %v", err)
 			// This is not a critical error, continue processing without captions
+			vp.enqueueCaptionQA(uint(videoID.(float64)))
 			return nil
 		}
 	} else if statErr != nil {
-		log.Printf("Warning: Failed to stat subtitles file %s: %v", subtitlesPath, statErr)
+		vp.warnf("Failed to stat subtitles file %s: %v", subtitlesPath, statErr)
 		return nil
 	}
 	
 	// Parse extracted subtitles
 	subtitles, err := ffmpeg.ParseSRTFile(subtitlesPath)
 	if err != nil {
-		log.Printf("Warning: Failed to parse extracted subtitles: %v", err)
+		vp.warnf("Failed to parse extracted subtitles: %v", err)
+		vp.enqueueCaptionQA(uint(videoID.(float64)))
 		return nil
 	}
 	
 	// Store subtitles in database
 	log.Printf("Successfully extracted %d subtitles for video ID %v", len(subtitles), videoID)
 	
+	// Normalize caption text for embedding and search, keeping the subtitle text as raw_text; cues
+	// that are only markup or music are dropped
+	opts := textnorm.OptionsFromEnv()
+	captions := make([]models.Caption, 0, len(subtitles))
+	for _, subtitle := range subtitles {
+		text := textnorm.Normalize(subtitle.Text, opts)
+		if text == "" {
+			continue
+		}
+		raw := subtitle.Text
+		captions = append(captions, models.Caption{
+			StartTime: subtitle.Start.Seconds(),
+			EndTime:   subtitle.End.Seconds(),
+			Text:      text,
+			RawText:   &raw,
+			Language:  "en", // Default to English, could be detected
+		})
+	}
+	if dropped := len(subtitles) - len(captions); dropped > 0 {
+		log.Printf("Dropped %d subtitles without text for video ID %v", dropped, videoID)
+	}
+	if len(subtitles) == 0 || len(captions) == 0 {
+		vp.warnf("subtitles of video %v contain no caption text (%d cues)", videoID, len(subtitles))
+	}
+	
 	// Update video caption count
 	video, err := vp.db.GetVideoByID(uint(videoID.(float64)))
 	if err != nil {
 		return fmt.Errorf("failed to get video: %v", err)
 	}
+	if video.Locked && video.CaptionCount > 0 {
+		return queue.WithCode(queue.ErrorRefused, fmt.Errorf("video %d is locked (legal hold); refusing to replace existing captions", video.ID))
+	}
 	
-	video.CaptionCount = len(subtitles)
-	if err := vp.db.UpdateVideo(video); err != nil {
+	video.CaptionCount = len(captions)
+	if err := vp.db.SetCaptionCount(video.ID, video.CaptionCount); err != nil {
 		return fmt.Errorf("failed to update video caption count: %v", err)
 	}
 	
 	// Store individual captions
-	for _, subtitle := range subtitles {
-		caption := &models.Caption{
-			VideoID:    video.ID,
-			StartTime:  subtitle.Start.Seconds(),
-			EndTime:    subtitle.End.Seconds(),
-			Text:       subtitle.Text,
-			Language:   "en", // Default to English, could be detected
-		}
+	for i := range captions {
+		caption := &captions[i]
+		caption.VideoID = video.ID
 		
 		if err := vp.db.CreateCaption(caption); err != nil {
-			log.Printf("Warning: Failed to store caption: %v", err)
+			vp.warnf("Failed to store caption: %v", err)
 			continue
 		}
+		vp.countResult("captions_stored", 1)
+	}
+	
+	// Captions re-extracted for a video that already has scenes; on first ingestion the caption
+	// linking job waiting for scene detection does this
+	if len(captions) > 0 {
+		vp.relinkCaptionScenes(video.ID)
+	}
+	
+	// Segment the transcript into topics, extract named entities, flag sensitive terms, embed passages
+	// and time words now that captions are stored
+	if len(captions) > 0 && vp.jobQueue != nil {
+		if StageEnabled(FlagTopicTimeline) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeTopicTimeline, map[string]interface{}{"video_id": video.ID}); err != nil {
+				vp.warnf("Failed to enqueue topic timeline job for video %d: %v", video.ID, err)
+			}
+		}
+		if StageEnabled(FlagEntityExtraction) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeEntityExtraction, map[string]interface{}{"video_id": video.ID}); err != nil {
+				vp.warnf("Failed to enqueue entity extraction job for video %d: %v", video.ID, err)
+			}
+		}
+		if StageEnabled(FlagContentFlagging) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeContentFlagging, map[string]interface{}{"video_id": video.ID}); err != nil {
+				vp.warnf("Failed to enqueue content flagging job for video %d: %v", video.ID, err)
+			}
+		}
+		if StageEnabled(FlagCaptionEmbeddings) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionEmbedding, map[string]interface{}{"video_id": video.ID}); err != nil {
+				vp.warnf("Failed to enqueue caption embedding job for video %d: %v", video.ID, err)
+			}
+		}
+		if StageEnabled(FlagWordAlignment) {
+			if _, err := vp.jobQueue.Enqueue(queue.JobTypeWordAlignment, map[string]interface{}{"video_id": video.ID}); err != nil {
+				vp.warnf("Failed to enqueue word alignment job for video %d: %v", video.ID, err)
+			}
+		}
+		if StageEnabled(FlagCaptionTranslation) {
+			vp.enqueueCaptionTranslations(video.ID)
+		}
+	}
+	// Tone analysis also scores scene audio, so it is enqueued even for an empty transcript
+	if vp.jobQueue != nil && StageEnabled(FlagToneAnalysis) {
+		if _, err := vp.jobQueue.Enqueue(queue.JobTypeToneAnalysis, map[string]interface{}{"video_id": video.ID}); err != nil {
+			vp.warnf("Failed to enqueue tone analysis job for video %d: %v", video.ID, err)
+		}
 	}
+	vp.enqueueCaptionQA(video.ID)
 	
 	return nil
 }
@@ -345,350 +648,259 @@ func (vp *VideoProcessor) ProcessEmbeddingGeneration(payload map[string]interfac
         return fmt.Errorf("unsupported video_id type: %T", videoID)
     }
 
-    // Load video & scenes
+    // Load video
     video, err := vp.db.GetVideoByID(id)
     if err != nil {
         return fmt.Errorf("failed to get video: %v", err)
     }
-    scenes, err := vp.db.GetScenesByVideoID(video.ID)
-    if err != nil {
-        return fmt.Errorf("failed to load scenes: %v", err)
+
+    // Video-level text embedding (title + synopsis + tags); incremental live jobs leave it alone
+    if _, incremental := payload["scene_index_from"]; !incremental {
+        if err := vp.embedVideoText(video); errors.Is(err, ErrRunnerUnavailable) {
+            return err
+        } else if err != nil {
+            vp.warnf("video text embedding failed for video %d: %v", video.ID, err)
+        }
     }
-    if len(scenes) == 0 {
-        log.Printf("No scenes for video %d; skipping embeddings.", video.ID)
+    if only, _ := payload["video_text_only"].(bool); only {
         return nil
     }
 
-    backend := os.Getenv("EMBEDDING_BACKEND")
-    if backend == "" {
-        backend = "iv2"
+    // Embed each scene level; shots first so their IV2 captions feed the beats' text embeddings
+    levels := []string{models.SceneLevelShot, models.SceneLevelBeat}
+    if lvl, ok := payload["level"].(string); ok && lvl != "" {
+        if !models.ValidSceneLevel(lvl) {
+            return fmt.Errorf("invalid scene level: %s", lvl)
+        }
+        levels = []string{lvl}
     }
-
-    log.Printf("[embeddings] video_id=%d: starting embedding generation with backend=%s for %d scenes", video.ID, backend, len(scenes))
-
-    switch backend {
-    case "iv2", "internvl35":
-        // Prepare IV2 runner input
-        getIntEnv := func(key string, def int) int {
-            if v := os.Getenv(key); v != "" {
-                if n, err := strconv.Atoi(v); err == nil {
-                    return n
+    for li, level := range levels {
+        // Each level gets an equal share of the job's progress
+        vp.setProgressBand(float64(li)*100/float64(len(levels)), float64(li+1)*100/float64(len(levels)))
+        scenes, err := vp.db.GetScenesByVideoIDAndLevel(video.ID, level)
+        if err != nil {
+            return fmt.Errorf("failed to load %s scenes: %v", level, err)
+        }
+        // Incremental (live) ingest embeds only the newly appended scenes
+        if from, ok := payload["scene_index_from"].(float64); ok {
+            kept := scenes[:0]
+            for _, sc := range scenes {
+                if sc.SceneIndex >= int(from) {
+                    kept = append(kept, sc)
                 }
             }
-            return def
-        }
-
-        // Defaults vary by backend
-        defaultFrames := 16
-        defaultRes := 224
-        if backend == "internvl35" {
-            defaultFrames = 8
-            defaultRes = 448
-        }
-        frames := getIntEnv("IV2_FRAMES", defaultFrames)
-        stride := getIntEnv("IV2_STRIDE", 4)
-        res := getIntEnv("IV2_RES", defaultRes)
-        device := os.Getenv("IV2_DEVICE")
-        if device == "" {
-            if os.Getenv("CUDA_VISIBLE_DEVICES") != "" {
-                device = "cuda:0"
-            } else {
-                device = "cpu"
-            }
+            scenes = kept
         }
-        modelID := os.Getenv("IV2_MODEL_ID")
-        if modelID == "" {
-            if backend == "internvl35" {
-                modelID = "OpenGVLab/InternVL3_5-2B"
-            } else {
-                modelID = "OpenGVLab/InternVideo2-Stage2_1B-224p-f4"
-            }
+        if len(scenes) == 0 {
+            log.Printf("No %s scenes for video %d; skipping embeddings.", level, video.ID)
+            continue
         }
+        if err := vp.generateSceneEmbeddings(video, level, scenes); err != nil {
+            return err
+        }
+    }
 
-        // Build scenes payload
-        type sceneRange struct {
-            SceneIndex int     `json:"scene_index"`
-            Start      float64 `json:"start"`
-            End        float64 `json:"end"`
+    // Full (non-live) runs finish ingest: announce it; standing alerts run once the scenes are searchable
+    if _, incremental := payload["scene_index_from"]; !incremental && len(levels) == 2 {
+        publishVideoIngested(video)
+    }
+    if vp.jobQueue != nil && StageEnabled(FlagAlertEvaluation) {
+        alertPayload := map[string]interface{}{"video_id": video.ID}
+        if from, ok := payload["scene_index_from"]; ok {
+            alertPayload["scene_index_from"] = from
         }
-        var srs []sceneRange
-        for _, s := range scenes {
-            srs = append(srs, sceneRange{SceneIndex: s.SceneIndex, Start: s.StartTime, End: s.EndTime})
+        if _, err := vp.jobQueue.Enqueue(queue.JobTypeAlertEvaluation, alertPayload); err != nil {
+            vp.warnf("Failed to enqueue alert evaluation job for video %d: %v", video.ID, err)
         }
+    }
+    return nil
+}
 
-        req := map[string]interface{}{
-            "video_path": video.Filepath,
-            "scenes":     srs,
-            "sampling": map[string]int{
-                "frames":     frames,
-                "stride":     stride,
-                "resolution": res,
-            },
-            "device":   device,
-            "model_id": modelID,
-            "backend":  backend,
-        }
-
-        log.Printf("[embeddings] video_id=%d: starting IV2 visual embedding runner (backend=%s, model=%s)", video.ID, backend, modelID)
-
-        payloadBytes, _ := json.Marshal(req)
-        cmd := exec.Command("python3", "/root/internal/embeddings/iv2_runner.py")
-        cmd.Stdin = bytes.NewReader(payloadBytes)
-        stdout, _ := cmd.StdoutPipe()
-        stderr, _ := cmd.StderrPipe()
-        if err := cmd.Start(); err != nil {
-            return fmt.Errorf("failed to start runner: %v", err)
-        }
-        outBytes, _ := io.ReadAll(stdout)
-        errBytes, _ := io.ReadAll(stderr)
-        if err := cmd.Wait(); err != nil {
-            return fmt.Errorf("iv2 runner failed: %v; stderr: %s", err, string(errBytes))
-        }
-        out := outBytes
-
-        var resp struct {
-            Model        string `json:"model"`
-            EmbeddingDim int    `json:"embedding_dim"`
-            Vectors      []struct {
-                SceneIndex int       `json:"scene_index"`
-                Vector     []float32 `json:"vector"`
-            } `json:"vectors"`
-            Error string `json:"error"`
-        }
-        if err := json.Unmarshal(out, &resp); err != nil {
-            return fmt.Errorf("failed to parse iv2 runner output: %v; raw: %s", err, string(out))
-        }
-        if resp.Error != "" {
-            return fmt.Errorf("iv2 runner error: %s", resp.Error)
-        }
-
-        log.Printf("Embedding runner (backend=%s) model=%s returned dim=%d for %d scenes", backend, resp.Model, resp.EmbeddingDim, len(resp.Vectors))
-
-        // Persist vectors only if embedding dim matches our schema
-        expectedDim := 768
-        if backend == "internvl35" {
-            expectedDim = 1024
-        }
-        if resp.EmbeddingDim != expectedDim {
-            log.Printf("Warning: embedding_dim=%d != %d; skipping persistence (update schema or backend)", resp.EmbeddingDim, expectedDim)
-            return nil
+// iv2Request builds the IV2 runner input for scenes of a video with the visual backend ("iv2" or
+// "internvl35") and returns it with the embedding dimension the backend produces
+func iv2Request(backend, videoPath string, srs []sceneRange) (map[string]interface{}, int) {
+    getIntEnv := func(key string, def int) int {
+        if v := os.Getenv(key); v != "" {
+            if n, err := strconv.Atoi(v); err == nil {
+                return n
+            }
         }
+        return def
+    }
 
-        saved := 0
-        for _, v := range resp.Vectors {
-            if err := vp.db.UpdateSceneVisualEmbeddingByIndex(video.ID, v.SceneIndex, v.Vector); err != nil {
-                log.Printf("Failed to persist embedding for scene_index=%d: %v", v.SceneIndex, err)
-                continue
+    // Defaults vary by backend
+    defaultFrames := 16
+    defaultRes := 224
+    dim := 768
+    if backend == "internvl35" {
+        defaultFrames = 8
+        defaultRes = 448
+        dim = 1024
+    }
+    frames := getIntEnv("IV2_FRAMES", defaultFrames)
+    stride := getIntEnv("IV2_STRIDE", 4)
+    res := getIntEnv("IV2_RES", defaultRes)
+
+    return map[string]interface{}{
+        "video_path": videoPath,
+        "scenes":     srs,
+        "sampling": map[string]int{
+            "frames":     frames,
+            "stride":     stride,
+            "resolution": res,
+        },
+        "device":   iv2Device(),
+        "model_id": videoModelID(backend),
+        "backend":  backend,
+    }, dim
+}
+
+// generateSceneEmbeddings computes and stores the embeddings of one scene level of a video
+func (vp *VideoProcessor) generateSceneEmbeddings(video *models.Video, level string, scenes []models.Scene) error {
+    if video.AssetType == models.AssetTypeAudio || video.AssetType == models.AssetTypeImage {
+        log.Printf("[embeddings] video_id=%d: %s asset, embedding %d %s segments", video.ID, video.AssetType, len(scenes), level)
+        return vp.generateAssetEmbeddings(video, level, scenes)
+    }
+
+    backend := embeddingBackend()
+
+    log.Printf("[embeddings] video_id=%d: starting embedding generation with backend=%s for %d %s scenes", video.ID, backend, len(scenes), level)
+
+    switch backend {
+    case "iv2", "internvl35":
+        // Build scenes payload
+        srs := sceneRanges(scenes)
+        req, expectedDim := iv2Request(backend, video.Filepath, srs)
+
+        log.Printf("[embeddings] video_id=%d: starting IV2 visual embedding runner (backend=%s, model=%s)", video.ID, backend, req["model_id"])
+
+        // Vectors are persisted as the runner streams them, if their dimension fits our schema
+        received, saved := 0, 0
+        summary, err := streamRunner(vp.context(), "/root/internal/embeddings/iv2_runner.py", req, func(sceneIndex int, vec []float32) error {
+            if len(vec) != expectedDim {
+                return fmt.Errorf("%w: embedding_dim=%d != %d", errEmbeddingDim, len(vec), expectedDim)
+            }
+            received++
+            vp.stepProgress(0, 0.4, received, len(srs))
+            if err := vp.db.UpdateSceneVisualEmbeddingByIndex(video.ID, level, sceneIndex, vec); err != nil {
+                vp.warnf("Failed to persist embedding for scene_index=%d: %v", sceneIndex, err)
+                return nil
             }
             saved++
+            vp.countEmbeddings("visual", 1)
+            return nil
+        })
+        if errors.Is(err, errEmbeddingDim) {
+            vp.warnf("%v; skipping persistence (update schema or backend)", err)
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        log.Printf("Embedding runner (backend=%s) model=%s returned dim=%d for %d scenes", backend, summary.Model, summary.EmbeddingDim, summary.Count)
+
+        // Update video's embedding model and record the metric each modality is compared with
+        video.EmbeddingModel = summary.Model
+        vp.setResult("embedding_model", summary.Model)
+        if video.Metadata == nil {
+            video.Metadata = models.JSONObject{}
+        }
+        video.Metadata["embedding_metrics"] = database.EmbeddingMetrics()
+        video.Metadata["embeddings_normalized"] = database.NormalizeOnWrite()
+        if err := vp.db.SetEmbeddingModel(video.ID, summary.Model); err != nil {
+            vp.warnf("failed to update video embedding_model: %v", err)
         }
-        // Update video's embedding model
-        video.EmbeddingModel = resp.Model
-        if err := vp.db.UpdateVideo(video); err != nil {
-            log.Printf("Warning: failed to update video embedding_model: %v", err)
+        if err := vp.saveVideo(video, []string{"embedding_metrics", "embeddings_normalized"}, nil); err != nil {
+            vp.warnf("failed to record embedding metrics: %v", err)
+        }
+        log.Printf("Persisted %d/%d scene embeddings for video %d", saved, summary.Count, video.ID)
+        if saved < len(srs) {
+            vp.warnf("visual embeddings stored for %d/%d %s scenes of video %d", saved, len(srs), level, video.ID)
         }
-        log.Printf("Persisted %d/%d scene embeddings for video %d", saved, len(resp.Vectors), video.ID)
 
-        log.Printf("[embeddings] video_id=%d: starting IV2 caption generation for %d scenes", video.ID, len(scenes))
-        if err := vp.generateIV2Captions(video, scenes, frames, stride, res, device, modelID); err != nil {
-            log.Printf("Warning: IV2 caption generation failed for video %d: %v", video.ID, err)
-        } else {
-            log.Printf("[embeddings] video_id=%d: completed IV2 caption generation", video.ID)
+        // Synthetic captions are generated per shot; beats aggregate them through the text stage below
+        if level == models.SceneLevelShot {
+            log.Printf("[embeddings] video_id=%d: starting IV2 caption generation for %d scenes", video.ID, len(scenes))
+            if err := vp.generateIV2Captions(video, scenes, req); err != nil {
+                vp.warnf("IV2 caption generation failed for video %d: %v", video.ID, err)
+            } else {
+                log.Printf("[embeddings] video_id=%d: completed IV2 caption generation", video.ID)
+            }
         }
 
         // --- Compute text embeddings for scenes from captions (e5-base-v2) ---
         captions, err := vp.db.GetCaptionsByVideoID(video.ID)
         if err != nil {
-            log.Printf("Warning: failed to load captions for video %d: %v", video.ID, err)
+            vp.warnf("failed to load captions for video %d: %v", video.ID, err)
             return nil
         }
-        // Aggregate captions per scene time window
-        texts := make([]string, len(scenes))
-        hasText := make([]bool, len(scenes))
-        for i, s := range scenes {
-            var b strings.Builder
-            for _, c := range captions {
-                if c.StartTime < s.EndTime && c.EndTime > s.StartTime { // overlap
-                    if b.Len() > 0 {
-                        b.WriteString(" ")
-                    }
-                    b.WriteString(c.Text)
-                }
-            }
-            txt := strings.TrimSpace(b.String())
-            texts[i] = txt
-            hasText[i] = txt != ""
-        }
+        // Aggregate captions per scene time window, without the project's boilerplate
+        texts, hasText := sceneCaptionTexts(scenes, captions, vp.sceneTextRules(video))
         // Prepare payload for runner with only non-empty texts, but we need ordering; simplest: send all and skip empty on persist
         treq := map[string]interface{}{
             "texts": texts,
             "mode":  "passage",
         }
-        payloadBytes, _ = json.Marshal(treq)
-        tcmd := exec.Command("python3", "/root/internal/embeddings/text_embed_runner.py")
-        tcmd.Stdin = bytes.NewReader(payloadBytes)
-        tStdout, _ := tcmd.StdoutPipe()
-        tStderr, _ := tcmd.StderrPipe()
-        if err := tcmd.Start(); err != nil {
-            log.Printf("Warning: failed to start text_embed_runner: %v", err)
-            return nil
-        }
-        tOut, _ := io.ReadAll(tStdout)
-        tErr, _ := io.ReadAll(tStderr)
-        if err := tcmd.Wait(); err != nil {
-            log.Printf("Warning: text_embed_runner failed: %v; stderr: %s", err, string(tErr))
-            return nil
-        }
-        var tResp struct {
-            Model        string       `json:"model"`
-            EmbeddingDim int          `json:"embedding_dim"`
-            Vectors      [][]float32  `json:"vectors"`
-            Vector       []float32    `json:"vector"`
-            Error        string       `json:"error"`
-        }
-        if err := json.Unmarshal(tOut, &tResp); err != nil {
-            log.Printf("Warning: failed to parse text_embed_runner output: %v; raw: %s", err, string(tOut))
-            return nil
+        if modelID := ActiveTextModelID(); modelID != "" {
+            treq["model_id"] = modelID
         }
-        if tResp.Error != "" {
-            log.Printf("Warning: text_embed_runner error: %s", tResp.Error)
-            return nil
-        }
-        // Normalize single-vector vs vectors output
-        var tVectors [][]float32
-        if len(tResp.Vectors) > 0 {
-            tVectors = tResp.Vectors
-        } else if len(tResp.Vector) > 0 && len(texts) == 1 {
-            tVectors = [][]float32{tResp.Vector}
-        }
-        // Persist per scene
         savedText := 0
-        for i := range scenes {
-            if !hasText[i] {
-                continue
-            }
-            if i >= len(tVectors) || len(tVectors[i]) == 0 {
-                continue
+        _, err = streamRunner(vp.context(), "/root/internal/embeddings/text_embed_runner.py", treq, func(i int, vec []float32) error {
+            if i < 0 || i >= len(scenes) || !hasText[i] || len(vec) == 0 {
+                return nil
             }
-            if err := vp.db.UpdateSceneTextEmbeddingByIndex(video.ID, scenes[i].SceneIndex, tVectors[i]); err != nil {
-                log.Printf("Failed to persist text embedding for scene_index=%d: %v", scenes[i].SceneIndex, err)
-                continue
+            if err := vp.db.UpdateSceneTextEmbeddingByIndex(video.ID, level, scenes[i].SceneIndex, vec); err != nil {
+                vp.warnf("Failed to persist text embedding for scene_index=%d: %v", scenes[i].SceneIndex, err)
+                return nil
             }
             savedText++
+            vp.countEmbeddings("text", 1)
+            vp.stepProgress(0.4, 0.6, i+1, len(scenes))
+            return nil
+        })
+        if errors.Is(err, ErrRunnerUnavailable) {
+            return err
+        }
+        if err != nil {
+            vp.warnf("%v", err)
+            return nil
         }
         log.Printf("Persisted %d/%d text embeddings for video %d", savedText, len(scenes), video.ID)
+        vp.embedTrackingModels(video.ID, scenes, texts, hasText)
         log.Printf("[embeddings] video_id=%d: completed text embedding stage (saved=%d/%d)", video.ID, savedText, len(scenes))
 
         // --- Compute CLIP image embeddings for scenes (ViT-B/32) ---
         log.Printf("[embeddings] video_id=%d: starting CLIP embedding stage for %d scenes", video.ID, len(scenes))
-        // Use the same scene ranges (srs) built earlier.
-        creq := map[string]interface{}{
+        // Use the same scene ranges (srs) built earlier, optionally averaging CLIP over keyframe candidates
+        clipScenes := srs
+        if os.Getenv("CLIP_KEYFRAME_MODE") == "candidates" {
+            clipScenes = vp.withKeyframeCandidates(video.ID, level, srs)
+        }
+        if err := vp.embedScenesCLIP(video, level, map[string]interface{}{
             "video_path": video.Filepath,
-            "scenes":     srs,
+            "scenes":     clipScenes,
             "mode":       "image",
-        }
-        payloadBytes, _ = json.Marshal(creq)
-        ccmd := exec.Command("python3", "/root/internal/embeddings/clip_runner.py")
-        ccmd.Stdin = bytes.NewReader(payloadBytes)
-        cStdout, _ := ccmd.StdoutPipe()
-        cStderr, _ := ccmd.StderrPipe()
-        if err := ccmd.Start(); err != nil {
-            log.Printf("Warning: failed to start clip_runner: %v", err)
-            return nil
-        }
-        cOut, _ := io.ReadAll(cStdout)
-        cErr, _ := io.ReadAll(cStderr)
-        if err := ccmd.Wait(); err != nil {
-            log.Printf("Warning: clip_runner failed: %v; stderr: %s", err, string(cErr))
-            return nil
-        }
-        var cResp struct {
-            Model        string `json:"model"`
-            EmbeddingDim int    `json:"embedding_dim"`
-            Vectors      []struct {
-                SceneIndex int       `json:"scene_index"`
-                Vector     []float32 `json:"vector"`
-            } `json:"vectors"`
-            Error string `json:"error"`
-        }
-        if err := json.Unmarshal(cOut, &cResp); err != nil {
-            log.Printf("Warning: failed to parse clip_runner output: %v; raw: %s", err, string(cOut))
-            return nil
-        }
-        if cResp.Error != "" {
-            log.Printf("Warning: clip_runner error: %s", cResp.Error)
-            return nil
-        }
-        if cResp.EmbeddingDim != 512 {
-            log.Printf("Warning: CLIP embedding_dim=%d != 512; skipping persistence", cResp.EmbeddingDim)
+        }); errors.Is(err, ErrRunnerUnavailable) {
+            return err
+        } else if err != nil {
+            vp.warnf("%v", err)
             return nil
         }
-        savedClip := 0
-        for _, v := range cResp.Vectors {
-            if err := vp.db.UpdateSceneVisualClipEmbeddingByIndex(video.ID, v.SceneIndex, v.Vector); err != nil {
-                log.Printf("Failed to persist CLIP embedding for scene_index=%d: %v", v.SceneIndex, err)
-                continue
-            }
-            savedClip++
-        }
-        log.Printf("Persisted %d/%d CLIP embeddings for video %d", savedClip, len(cResp.Vectors), video.ID)
-        log.Printf("[embeddings] video_id=%d: completed CLIP embedding stage (saved=%d/%d)", video.ID, savedClip, len(cResp.Vectors))
 
         // --- Compute CLAP audio embeddings per scene ---
-        if strings.EqualFold(os.Getenv("ENABLE_AUDIO_EMBEDDINGS"), "false") || os.Getenv("ENABLE_AUDIO_EMBEDDINGS") == "0" {
-            log.Printf("Skipping audio embeddings for video %d due to ENABLE_AUDIO_EMBEDDINGS", video.ID)
+        if !StageEnabled(FlagAudioEmbeddings) {
+            log.Printf("Skipping audio embeddings for video %d (feature flag %s off)", video.ID, FlagAudioEmbeddings)
             return nil
         }
-        areq := map[string]interface{}{
+        if err := vp.embedScenesCLAP(video, level, map[string]interface{}{
             "video_path":  video.Filepath,
             "scenes":      srs,
             "sample_rate": 48000,
+        }); errors.Is(err, ErrRunnerUnavailable) {
+            return err
+        } else if err != nil {
+            vp.warnf("%v", err)
         }
-        payloadBytes, _ = json.Marshal(areq)
-        acmd := exec.Command("python3", "/root/internal/embeddings/audio_embed_runner.py")
-        acmd.Stdin = bytes.NewReader(payloadBytes)
-        aStdout, _ := acmd.StdoutPipe()
-        aStderr, _ := acmd.StderrPipe()
-        if err := acmd.Start(); err != nil {
-            log.Printf("Warning: failed to start audio_embed_runner: %v", err)
-            return nil
-        }
-        aOut, _ := io.ReadAll(aStdout)
-        aErr, _ := io.ReadAll(aStderr)
-        if err := acmd.Wait(); err != nil {
-            log.Printf("Warning: audio_embed_runner failed: %v; stderr: %s", err, string(aErr))
-            return nil
-        }
-        var aResp struct {
-            Model        string `json:"model"`
-            EmbeddingDim int    `json:"embedding_dim"`
-            Vectors      []struct {
-                SceneIndex int       `json:"scene_index"`
-                Vector     []float32 `json:"vector"`
-            } `json:"vectors"`
-            Error string `json:"error"`
-        }
-        if err := json.Unmarshal(aOut, &aResp); err != nil {
-            log.Printf("Warning: failed to parse audio_embed_runner output: %v; raw: %s", err, string(aOut))
-            return nil
-        }
-        if aResp.Error != "" {
-            log.Printf("Warning: audio_embed_runner error: %s", aResp.Error)
-            return nil
-        }
-        if aResp.EmbeddingDim != 512 {
-            log.Printf("Warning: CLAP embedding_dim=%d != 512; skipping persistence", aResp.EmbeddingDim)
-            return nil
-        }
-        savedAudio := 0
-        for _, v := range aResp.Vectors {
-            if err := vp.db.UpdateSceneAudioEmbeddingByIndex(video.ID, v.SceneIndex, v.Vector); err != nil {
-                log.Printf("Failed to persist audio embedding for scene_index=%d: %v", v.SceneIndex, err)
-                continue
-            }
-            savedAudio++
-        }
-        log.Printf("Persisted %d/%d audio embeddings for video %d", savedAudio, len(aResp.Vectors), video.ID)
 
         return nil
 
@@ -701,35 +913,134 @@ func (vp *VideoProcessor) ProcessEmbeddingGeneration(payload map[string]interfac
     }
 }
 
-// generateIV2Captions generates one synthetic caption per scene using an external runner
-// and stores them as Caption rows with language "iv2". These captions will be picked up
-// by the existing text-embedding pipeline when aggregating per-scene text.
-func (vp *VideoProcessor) generateIV2Captions(video *models.Video, scenes []models.Scene, frames, stride, res int, device, modelID string) error {
-    type sceneRange struct {
-        SceneIndex int     `json:"scene_index"`
-        Start      float64 `json:"start"`
-        End        float64 `json:"end"`
-    }
-    var srs []sceneRange
+// iv2Device is the device the IV2 runner uses: IV2_DEVICE, else cuda:0 when CUDA_VISIBLE_DEVICES
+// is set, else cpu
+func iv2Device() string {
+    if device := os.Getenv("IV2_DEVICE"); device != "" {
+        return device
+    }
+    if os.Getenv("CUDA_VISIBLE_DEVICES") != "" {
+        return "cuda:0"
+    }
+    return "cpu"
+}
+
+// sceneRange is the scene time range sent to the embedding runners
+type sceneRange struct {
+    SceneIndex int      `json:"scene_index"`
+    Start      float64  `json:"start"`
+    End        float64  `json:"end"`
+    Keyframe   *float64 `json:"keyframe,omitempty"`
+    // Candidates are keyframe candidate timestamps; the CLIP runner averages over them when set
+    Candidates []float64 `json:"candidates,omitempty"`
+}
+
+func sceneRanges(scenes []models.Scene) []sceneRange {
+    srs := make([]sceneRange, 0, len(scenes))
     for _, s := range scenes {
-        srs = append(srs, sceneRange{SceneIndex: s.SceneIndex, Start: s.StartTime, End: s.EndTime})
+        srs = append(srs, sceneRange{SceneIndex: s.SceneIndex, Start: s.StartTime, End: s.EndTime, Keyframe: s.KeyframeTime})
+    }
+    return srs
+}
+
+// withKeyframeCandidates returns a copy of srs carrying each scene's keyframe candidate timestamps
+func (vp *VideoProcessor) withKeyframeCandidates(videoID uint, level string, srs []sceneRange) []sceneRange {
+    times, err := vp.db.KeyframeCandidateTimes(videoID, level)
+    if err != nil {
+        vp.warnf("Failed to load keyframe candidates for video %d: %v", videoID, err)
+        return srs
+    }
+    out := make([]sceneRange, len(srs))
+    for i, sr := range srs {
+        sr.Candidates = times[sr.SceneIndex]
+        out[i] = sr
+    }
+    return out
+}
+
+// requestScenes is the number of scenes a runner request asks vectors for, for progress reporting
+func requestScenes(req map[string]interface{}) int {
+    if srs, ok := req["scenes"].([]sceneRange); ok {
+        return len(srs)
+    }
+    return 0
+}
+
+// embedScenesCLIP runs the CLIP image runner for one scene level and stores visual_clip_embedding
+// as the runner streams the vectors
+func (vp *VideoProcessor) embedScenesCLIP(video *models.Video, level string, req map[string]interface{}) error {
+    total := requestScenes(req)
+    savedClip := 0
+    summary, err := streamRunner(vp.context(), "/root/internal/embeddings/clip_runner.py", req, func(sceneIndex int, vec []float32) error {
+        if len(vec) != 512 {
+            return fmt.Errorf("%w: CLIP embedding_dim=%d != 512; skipping persistence", errEmbeddingDim, len(vec))
+        }
+        if err := vp.db.UpdateSceneVisualClipEmbeddingByIndex(video.ID, level, sceneIndex, vec); err != nil {
+            vp.warnf("Failed to persist CLIP embedding for scene_index=%d: %v", sceneIndex, err)
+            return nil
+        }
+        savedClip++
+        vp.countEmbeddings("clip", 1)
+        vp.stepProgress(0.6, 0.85, savedClip, total)
+        return nil
+    })
+    if err != nil {
+        return err
     }
+    log.Printf("Persisted %d/%d CLIP embeddings for video %d", savedClip, summary.Count, video.ID)
+    if savedClip < total {
+        vp.warnf("CLIP embeddings stored for %d/%d %s scenes of video %d", savedClip, total, level, video.ID)
+    }
+    log.Printf("[embeddings] video_id=%d: completed CLIP embedding stage (saved=%d/%d)", video.ID, savedClip, summary.Count)
+    return nil
+}
+
+// embedScenesCLAP runs the CLAP audio runner for one scene level and stores audio_embedding as the
+// runner streams the vectors
+func (vp *VideoProcessor) embedScenesCLAP(video *models.Video, level string, req map[string]interface{}) error {
+    total := requestScenes(req)
+    savedAudio := 0
+    summary, err := streamRunner(vp.context(), "/root/internal/embeddings/audio_embed_runner.py", req, func(sceneIndex int, vec []float32) error {
+        if len(vec) != 512 {
+            return fmt.Errorf("%w: CLAP embedding_dim=%d != 512; skipping persistence", errEmbeddingDim, len(vec))
+        }
+        if err := vp.db.UpdateSceneAudioEmbeddingByIndex(video.ID, level, sceneIndex, vec); err != nil {
+            vp.warnf("Failed to persist audio embedding for scene_index=%d: %v", sceneIndex, err)
+            return nil
+        }
+        savedAudio++
+        vp.countEmbeddings("audio", 1)
+        vp.stepProgress(0.85, 1, savedAudio, total)
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    log.Printf("Persisted %d/%d audio embeddings for video %d", savedAudio, summary.Count, video.ID)
+    if savedAudio < total {
+        vp.warnf("audio embeddings stored for %d/%d %s scenes of video %d", savedAudio, total, level, video.ID)
+    }
+    return nil
+}
+
+// generateIV2Captions generates one synthetic caption per scene using an external runner
+// and stores them as Caption rows with language "iv2". These captions will be picked up
+// by the existing text-embedding pipeline when aggregating per-scene text. Sampling, device and
+// model are those of the embedding request ivReq (see iv2Request).
+func (vp *VideoProcessor) generateIV2Captions(video *models.Video, scenes []models.Scene, ivReq map[string]interface{}) error {
+    srs := sceneRanges(scenes)
 
     req := map[string]interface{}{
         "video_path": video.Filepath,
         "scenes":     srs,
         "prompt":     os.Getenv("IV2_CAPTION_PROMPT"),
-        "sampling": map[string]int{
-            "frames":     frames,
-            "stride":     stride,
-            "resolution": res,
-        },
-        "device":   device,
-        "model_id": modelID,
+        "sampling":   ivReq["sampling"],
+        "device":     ivReq["device"],
+        "model_id":   ivReq["model_id"],
     }
 
     payloadBytes, _ := json.Marshal(req)
-    cmd := exec.Command("python3", "/root/internal/embeddings/iv2_caption_runner.py")
+    cmd := exec.CommandContext(vp.context(), "python3", "/root/internal/embeddings/iv2_caption_runner.py")
     cmd.Stdin = bytes.NewReader(payloadBytes)
     stdout, _ := cmd.StdoutPipe()
     stderr, _ := cmd.StderrPipe()
@@ -739,7 +1050,7 @@ func (vp *VideoProcessor) generateIV2Captions(video *models.Video, scenes []mode
     // Stream stderr so per-scene progress logs from the Python runner appear in real time.
     go func() {
         if _, err := io.Copy(os.Stderr, stderr); err != nil {
-            log.Printf("Warning: failed to read iv2_caption_runner stderr for video %d: %v", video.ID, err)
+            vp.warnf("failed to read iv2_caption_runner stderr for video %d: %v", video.ID, err)
         }
     }()
     outBytes, _ := io.ReadAll(stdout)
@@ -769,27 +1080,32 @@ func (vp *VideoProcessor) generateIV2Captions(video *models.Video, scenes []mode
     }
 
     saved := 0
+    opts := textnorm.OptionsFromEnv()
     for _, c := range resp.Captions {
-        if strings.TrimSpace(c.Text) == "" {
+        text := textnorm.Normalize(c.Text, opts)
+        if text == "" {
             continue
         }
         s, ok := sceneByIndex[c.SceneIndex]
         if !ok {
             continue
         }
+        raw := c.Text
         cap := &models.Caption{
             VideoID:   video.ID,
             SceneID:   &s.ID,
             StartTime: s.StartTime,
             EndTime:   s.EndTime,
-            Text:      c.Text,
+            Text:      text,
+            RawText:   &raw,
             Language:  "iv2",
         }
         if err := vp.db.CreateCaption(cap); err != nil {
-            log.Printf("Warning: Failed to store IV2 caption for scene_index=%d: %v", c.SceneIndex, err)
+            vp.warnf("Failed to store IV2 caption for scene_index=%d: %v", c.SceneIndex, err)
             continue
         }
         saved++
+        vp.countResult("captions_generated", 1)
     }
     log.Printf("Persisted %d/%d IV2 captions for video %d", saved, len(resp.Captions), video.ID)
     return nil
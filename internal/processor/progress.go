@@ -0,0 +1,64 @@
+package processor
+
+import "sync"
+
+// ProgressReporter receives the progress (0-100) of the job a processor is running
+type ProgressReporter interface {
+	ReportProgress(percent int)
+}
+
+// ProgressFunc adapts a function to a ProgressReporter
+type ProgressFunc func(percent int)
+
+// ReportProgress calls f
+func (f ProgressFunc) ReportProgress(percent int) { f(percent) }
+
+// progressTracker scales stage progress into the job's current band and reports it, skipping
+// values that do not advance it
+type progressTracker struct {
+	mu       sync.Mutex
+	reporter ProgressReporter
+	lo, hi   float64
+	last     int
+}
+
+// WithProgress returns a copy of the processor that reports the progress of the job it runs to r.
+// Progress only moves forward and stops short of 100, which the worker sets on completion.
+func (vp *VideoProcessor) WithProgress(r ProgressReporter) *VideoProcessor {
+	c := *vp
+	c.progress = &progressTracker{reporter: r, hi: 100}
+	return &c
+}
+
+// setProgressBand maps the stages reported from now on onto [lo, hi] of the job's progress, e.g.
+// one scene level of an embedding job that embeds two
+func (vp *VideoProcessor) setProgressBand(lo, hi float64) {
+	if vp.progress == nil {
+		return
+	}
+	vp.progress.mu.Lock()
+	vp.progress.lo, vp.progress.hi = lo, hi
+	vp.progress.mu.Unlock()
+}
+
+// stepProgress reports done of total steps of a stage spanning [from, to] (fractions) of the band
+func (vp *VideoProcessor) stepProgress(from, to float64, done, total int) {
+	t := vp.progress
+	if t == nil || total <= 0 {
+		return
+	}
+	t.mu.Lock()
+	frac := from + (to-from)*float64(done)/float64(total)
+	pct := int(t.lo + (t.hi-t.lo)*frac)
+	if pct > 99 {
+		pct = 99
+	}
+	advanced := pct > t.last
+	if advanced {
+		t.last = pct
+	}
+	t.mu.Unlock()
+	if advanced {
+		t.reporter.ReportProgress(pct)
+	}
+}
@@ -0,0 +1,268 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// errEmbeddingDim marks vectors whose dimension does not fit the column they were computed for
+var errEmbeddingDim = errors.New("unexpected embedding dimension")
+
+// errRunnerOutput marks output that does not follow the runner protocol; the runner's stderr is
+// attached once it has exited
+var errRunnerOutput = errors.New("malformed runner output")
+
+// Binary runner transport (see runner_io.py): a magic header followed by little-endian frames
+const (
+	binaryMagic = "GCVEC\x01"
+	frameVector = 'V'
+	frameDone   = 'D'
+	frameError  = 'E'
+	// maxFrameDim and maxFrameText bound frame sizes so a corrupt header cannot allocate gigabytes
+	maxFrameDim  = 1 << 16
+	maxFrameText = 1 << 20
+)
+
+// runnerTransport is how runners send vectors (RUNNER_TRANSPORT): "binary" (default), raw float32
+// frames with checksums, or "json" lines, which are slower to encode and parse but readable
+func runnerTransport() string {
+	if strings.EqualFold(os.Getenv("RUNNER_TRANSPORT"), "json") {
+		return "json"
+	}
+	return "binary"
+}
+
+// runnerLine is one line of a streaming embedding runner's output (see runner_io.py): a vector,
+// the closing summary, or an error
+type runnerLine struct {
+	SceneIndex   *int      `json:"scene_index"`
+	Index        *int      `json:"index"`
+	Vector       []float32 `json:"vector"`
+	Model        string    `json:"model"`
+	EmbeddingDim int       `json:"embedding_dim"`
+	Count        int       `json:"count"`
+	Done         bool      `json:"done"`
+	Error        string    `json:"error"`
+}
+
+// runnerSummary closes a runner's stream
+type runnerSummary struct {
+	Model        string
+	EmbeddingDim int
+	Count        int
+}
+
+// streamRunner runs an embedding runner with req and "stream": true on stdin and hands each vector
+// to onVector as soon as it arrives, keyed by scene_index (or, for text batches, the position in
+// "texts"). Only one vector is held in memory at a time. An error from onVector stops the runner
+// and is returned; vectors handled before an error stay handled. Runners answer in the transport
+// asked for by runnerTransport; output starting with a JSON line is read as JSON either way, so
+// runners that fail early or predate the binary transport still work.
+//
+// Calls go through the runner's circuit breaker: while it is open streamRunner returns a
+// RunnerUnavailableError without starting the runner, and crashes, timeouts and load failures
+// count towards opening it.
+func streamRunner(ctx context.Context, script string, req map[string]interface{}, onVector func(index int, vec []float32) error) (*runnerSummary, error) {
+	name := strings.TrimSuffix(filepath.Base(script), ".py")
+	if err := allowRunner(name); err != nil {
+		return nil, err
+	}
+	callerStopped := false
+	summary, err := runStreamRunner(ctx, name, script, req, func(index int, vec []float32) error {
+		err := onVector(index, vec)
+		callerStopped = err != nil
+		return err
+	})
+	recordRunnerOutcome(ctx, name, err, callerStopped)
+	return summary, err
+}
+
+// runStreamRunner runs the runner for streamRunner
+func runStreamRunner(ctx context.Context, name, script string, req map[string]interface{}, onVector func(index int, vec []float32) error) (*runnerSummary, error) {
+	streamed := make(map[string]interface{}, len(req)+2)
+	for k, v := range req {
+		streamed[k] = v
+	}
+	streamed["stream"] = true
+	if runnerTransport() == "binary" {
+		streamed["transport"] = "binary"
+	}
+	payloadBytes, err := json.Marshal(streamed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %v", name, err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, "python3", script)
+	cmd.Stdin = bytes.NewReader(payloadBytes)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %v", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %v", name, err)
+	}
+	// stop kills the runner after a failure on our side and reaps it
+	stop := func(err error) (*runnerSummary, error) {
+		cancel()
+		io.Copy(io.Discard, stdout)
+		cmd.Wait()
+		if errors.Is(err, errRunnerOutput) {
+			return nil, fmt.Errorf("failed to parse %s output: %v; stderr: %s", name, err, stderr.String())
+		}
+		return nil, err
+	}
+
+	out := bufio.NewReader(stdout)
+	read := readRunnerLines
+	if first, err := out.Peek(1); err == nil && first[0] == binaryMagic[0] {
+		read = readRunnerFrames
+	}
+	summary, err := read(out, name, onVector)
+	if err != nil {
+		return stop(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v; stderr: %s", name, err, stderr.String())
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("%s ended without a summary; stderr: %s", name, stderr.String())
+	}
+	return summary, nil
+}
+
+// readRunnerLines reads the JSON-lines transport until EOF
+func readRunnerLines(r *bufio.Reader, name string, onVector func(index int, vec []float32) error) (*runnerSummary, error) {
+	var summary *runnerSummary
+	dec := json.NewDecoder(r)
+	for {
+		var line runnerLine
+		if err := dec.Decode(&line); err == io.EOF {
+			return summary, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", errRunnerOutput, err)
+		}
+		switch {
+		case line.Error != "":
+			return nil, fmt.Errorf("%s error: %s", name, line.Error)
+		case line.Done:
+			summary = &runnerSummary{Model: line.Model, EmbeddingDim: line.EmbeddingDim, Count: line.Count}
+		case line.Vector != nil:
+			index := line.SceneIndex
+			if index == nil {
+				index = line.Index
+			}
+			if index == nil {
+				return nil, fmt.Errorf("%s returned a vector without an index", name)
+			}
+			if err := onVector(*index, line.Vector); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readRunnerFrames reads the binary transport until EOF, verifying each frame's checksum. Vectors
+// are decoded straight from the frame into float32s, with no text in between.
+func readRunnerFrames(r *bufio.Reader, name string, onVector func(index int, vec []float32) error) (*runnerSummary, error) {
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != binaryMagic {
+		return nil, fmt.Errorf("%w: missing binary stream header", errRunnerOutput)
+	}
+	le := binary.LittleEndian
+	var summary *runnerSummary
+	var buf []byte
+	// frame reads a frame body of n bytes followed by its CRC32
+	frame := func(n int) ([]byte, error) {
+		if cap(buf) < n+4 {
+			buf = make([]byte, n+4)
+		}
+		buf = buf[:n+4]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("%w: truncated frame: %v", errRunnerOutput, err)
+		}
+		if crc32.ChecksumIEEE(buf[:n]) != le.Uint32(buf[n:]) {
+			return nil, fmt.Errorf("%w: frame checksum mismatch", errRunnerOutput)
+		}
+		return buf[:n], nil
+	}
+	// peekHeader reads a frame's fixed-size header without consuming it, so it is checksummed with the rest
+	peekHeader := func(n int) ([]byte, error) {
+		head, err := r.Peek(n)
+		if err != nil {
+			return nil, fmt.Errorf("%w: truncated frame header: %v", errRunnerOutput, err)
+		}
+		return head, nil
+	}
+	for {
+		kind, err := r.ReadByte()
+		if err == io.EOF {
+			return summary, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", errRunnerOutput, err)
+		}
+		switch kind {
+		case frameVector:
+			head, err := peekHeader(8)
+			if err != nil {
+				return nil, err
+			}
+			dim := le.Uint32(head[4:8])
+			if dim == 0 || dim > maxFrameDim {
+				return nil, fmt.Errorf("%w: vector frame with dimension %d", errRunnerOutput, dim)
+			}
+			body, err := frame(8 + int(dim)*4)
+			if err != nil {
+				return nil, err
+			}
+			vec := make([]float32, dim)
+			for i := range vec {
+				vec[i] = math.Float32frombits(le.Uint32(body[8+i*4:]))
+			}
+			if err := onVector(int(int32(le.Uint32(body[0:4]))), vec); err != nil {
+				return nil, err
+			}
+		case frameDone:
+			head, err := peekHeader(10)
+			if err != nil {
+				return nil, err
+			}
+			body, err := frame(10 + int(le.Uint16(head[8:10])))
+			if err != nil {
+				return nil, err
+			}
+			summary = &runnerSummary{
+				Model:        string(body[10:]),
+				EmbeddingDim: int(le.Uint32(body[4:8])),
+				Count:        int(le.Uint32(body[0:4])),
+			}
+		case frameError:
+			var n uint32
+			if err := binary.Read(r, le, &n); err != nil || n > maxFrameText {
+				return nil, fmt.Errorf("%w: truncated error frame", errRunnerOutput)
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return nil, fmt.Errorf("%w: truncated error frame", errRunnerOutput)
+			}
+			return nil, fmt.Errorf("%s error: %s", name, msg)
+		default:
+			return nil, fmt.Errorf("%w: unknown frame type %q", errRunnerOutput, kind)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/scenedetect"
+)
+
+// sceneCacheVersion is part of every scene detection cache key; bump it when the detector's cuts
+// change, so earlier results are no longer reused
+const sceneCacheVersion = 1
+
+// sceneCacheEnabled reports whether scene detection results are cached by content (SCENE_CACHE,
+// default true)
+func sceneCacheEnabled() bool {
+	return os.Getenv("SCENE_CACHE") != "false"
+}
+
+// sceneCacheParams is the cache key of a detection run's parameters: the detector version, its cut
+// threshold and the time range (end 0 for the end of the file)
+func sceneCacheParams(start, end, threshold float64) string {
+	if threshold <= 0 {
+		threshold = scenedetect.DefaultThreshold
+	}
+	if end < 0 {
+		end = 0
+	}
+	return fmt.Sprintf("v%d;threshold=%.2f;start=%.3f;end=%.3f", sceneCacheVersion, threshold, start, end)
+}
+
+// refreshScenes reports whether a job's payload asks to detect scenes again instead of reusing cached
+// results ("refresh_scenes": true)
+func refreshScenes(payload map[string]interface{}) bool {
+	refresh, _ := payload["refresh_scenes"].(bool)
+	return refresh
+}
+
+// detectScenesCached detects the scenes within [start, end) of a video's source with the given cut
+// threshold (see scenedetect.Detector.DetectScenesRangeWithThreshold). A run over the same content
+// (by SHA-256) with the same parameters is reused instead of detected again. With refresh the cache
+// is not read but the fresh result replaces the cached one. Videos without a file hash (live or not
+// hashed yet) are never cached. The job result records the "scene_cache" outcome.
+func (vp *VideoProcessor) detectScenesCached(video *models.Video, path string, start, end, threshold float64, refresh bool) ([]scenedetect.Scene, error) {
+	if !sceneCacheEnabled() || video.FileHash == nil || *video.FileHash == "" {
+		return vp.sceneDetector.DetectScenesRangeWithThreshold(path, start, end, threshold)
+	}
+	hash, params := *video.FileHash, sceneCacheParams(start, end, threshold)
+	if !refresh {
+		bounds, err := vp.db.CachedSceneDetection(hash, params)
+		if err != nil {
+			vp.warnf("Failed to read scene detection cache for video %d: %v", video.ID, err)
+		} else if bounds != nil {
+			scenes := make([]scenedetect.Scene, len(bounds))
+			for i, b := range bounds {
+				scenes[i] = scenedetect.Scene{Index: b.Index, StartTime: b.StartTime, EndTime: b.EndTime}
+			}
+			log.Printf("Reusing %d cached scenes for video ID %d (%s)", len(scenes), video.ID, params)
+			vp.setResult("scene_cache", "hit")
+			return scenes, nil
+		}
+	}
+
+	scenes, err := vp.sceneDetector.DetectScenesRangeWithThreshold(path, start, end, threshold)
+	if err != nil {
+		return nil, err
+	}
+	bounds := make(models.SceneBounds, len(scenes))
+	for i, s := range scenes {
+		bounds[i] = models.SceneBound{Index: s.Index, StartTime: s.StartTime, EndTime: s.EndTime}
+	}
+	if err := vp.db.StoreSceneDetection(hash, params, bounds); err != nil {
+		vp.warnf("Failed to cache scene detection for video %d: %v", video.ID, err)
+	}
+	if refresh {
+		vp.setResult("scene_cache", "refreshed")
+	} else {
+		vp.setResult("scene_cache", "miss")
+	}
+	return scenes, nil
+}
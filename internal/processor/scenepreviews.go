@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+	"goodclips-server/internal/scenedetect"
+)
+
+// detectedScenes converts stored scenes to detector scenes, using the scene index as Index
+func detectedScenes(scenes []models.Scene) []scenedetect.Scene {
+	out := make([]scenedetect.Scene, len(scenes))
+	for i, s := range scenes {
+		out[i] = scenedetect.Scene{Index: s.SceneIndex, StartTime: s.StartTime, EndTime: s.EndTime}
+	}
+	return out
+}
+
+// boundsToScenes converts a preview's stored shots to detector scenes
+func boundsToScenes(bounds models.SceneBounds) []scenedetect.Scene {
+	out := make([]scenedetect.Scene, len(bounds))
+	for i, b := range bounds {
+		out[i] = scenedetect.Scene{Index: b.Index, StartTime: b.StartTime, EndTime: b.EndTime}
+	}
+	return out
+}
+
+// ScenePreviewDiff is a preview's shots compared with the video's current shots
+type ScenePreviewDiff struct {
+	scenedetect.SceneDiff
+	// ReferencesMoved counts references (e.g. search feedback) on removed shots that a commit moves
+	// to the shot in MapsTo
+	ReferencesMoved int `json:"references_moved"`
+}
+
+// DiffScenePreview compares a ready preview with the video's current shots; boundaries moving by at
+// most tolerance seconds count as unchanged
+func (vp *VideoProcessor) DiffScenePreview(p *models.ScenePreview, tolerance float64) (*ScenePreviewDiff, error) {
+	current, err := vp.db.GetScenesByVideoIDAndLevel(p.VideoID, models.SceneLevelShot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scenes: %v", err)
+	}
+	diff := &ScenePreviewDiff{SceneDiff: scenedetect.DiffScenes(detectedScenes(current), boundsToScenes(p.Scenes), tolerance)}
+
+	byIndex := make(map[int]uint, len(current))
+	for _, s := range current {
+		byIndex[s.SceneIndex] = s.ID
+	}
+	var removed []uint
+	for _, r := range diff.Removed {
+		removed = append(removed, byIndex[r.Index])
+	}
+	if diff.ReferencesMoved, err = vp.db.CountSceneReferences(removed); err != nil {
+		return nil, fmt.Errorf("failed to count scene references: %v", err)
+	}
+	return diff, nil
+}
+
+// ProcessScenePreview detects a video's shots with the preview's parameters and stores them on the
+// preview without touching the video's scenes. Long videos are detected chunk by chunk in this job.
+// Payload: {"video_id": 6, "preview_id": 2}
+func (vp *VideoProcessor) ProcessScenePreview(payload map[string]interface{}) error {
+	videoID, ok1 := payload["video_id"].(float64)
+	previewID, ok2 := payload["preview_id"].(float64)
+	if !ok1 || !ok2 {
+		return fmt.Errorf("missing or invalid video_id/preview_id in payload")
+	}
+	p, err := vp.db.GetScenePreview(uint(videoID), uint(previewID))
+	if err != nil {
+		return fmt.Errorf("failed to load scene preview %d: %v", uint(previewID), err)
+	}
+	if p.Status != models.ScenePreviewPending {
+		log.Printf("Scene preview %d is %s; skipping detection", p.ID, p.Status)
+		return nil
+	}
+	fail := func(err error) error {
+		msg := err.Error()
+		p.Status, p.Error = models.ScenePreviewFailed, &msg
+		if uerr := vp.db.UpdateScenePreview(p); uerr != nil {
+			vp.warnf("%v", uerr)
+		}
+		return err
+	}
+
+	video, err := vp.db.GetVideoByID(p.VideoID)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get video: %v", err))
+	}
+	if err := vp.sceneDetector.CheckDependencies(); err != nil {
+		return fail(fmt.Errorf("scene detection dependencies not available: %v", err))
+	}
+	threshold := 0.0
+	if p.Threshold != nil {
+		threshold = *p.Threshold
+	}
+
+	var scenes []scenedetect.Scene
+	if chunks := sceneChunkRanges(video.Duration, sceneChunkSeconds()); len(chunks) > 1 {
+		results := make([]sceneChunkResult, 0, len(chunks))
+		for i, c := range chunks {
+			s, err := vp.detectScenesCached(video, video.Filepath, c[0], c[1], threshold, false)
+			if err != nil {
+				return fail(fmt.Errorf("failed to detect scenes in chunk %d (%.2f-%.2fs): %v", i, c[0], c[1], err))
+			}
+			results = append(results, sceneChunkResult{Start: c[0], End: c[1], Scenes: s})
+		}
+		scenes = stitchSceneChunks(results)
+	} else if scenes, err = vp.detectScenesCached(video, video.Filepath, 0, 0, threshold, false); err != nil {
+		return fail(fmt.Errorf("failed to detect scenes: %v", err))
+	}
+
+	scenes, _, err = scenedetect.ValidateScenes(scenes, video.Duration, scenedetect.ValidationOptionsFromEnv())
+	if err != nil {
+		return fail(fmt.Errorf("scene detection produced invalid scenes: %v", err))
+	}
+	minDuration := scenedetect.MinSceneDurationFromEnv()
+	if p.MinSceneDuration != nil {
+		minDuration = *p.MinSceneDuration
+	}
+	scenes, _ = scenedetect.MergeShortScenes(scenes, minDuration)
+
+	p.Scenes = make(models.SceneBounds, len(scenes))
+	for i, s := range scenes {
+		p.Scenes[i] = models.SceneBound{Index: s.Index, StartTime: s.StartTime, EndTime: s.EndTime}
+	}
+	p.Status, p.Error = models.ScenePreviewReady, nil
+	if err := vp.db.UpdateScenePreview(p); err != nil {
+		return fmt.Errorf("failed to store scene preview %d: %v", p.ID, err)
+	}
+	log.Printf("Scene preview %d for video ID %d: %d shots (currently %d)", p.ID, video.ID, len(scenes), video.SceneCount)
+	return nil
+}
+
+// commitScenePreview replaces a video's scenes with a preview's shots, then re-embeds them
+func (vp *VideoProcessor) commitScenePreview(video *models.Video, filepathStr string, previewID uint) error {
+	p, err := vp.db.GetScenePreview(video.ID, previewID)
+	if err != nil {
+		return fmt.Errorf("failed to load scene preview %d: %v", previewID, err)
+	}
+	if p.Status != models.ScenePreviewCommitting {
+		log.Printf("Scene preview %d is %s; not committing", p.ID, p.Status)
+		return nil
+	}
+	if video.Metadata == nil {
+		video.Metadata = models.JSONObject{}
+	}
+	video.Metadata["scene_preview"] = p.ID
+	if err := vp.replaceScenes(video, filepathStr, boundsToScenes(p.Scenes)); err != nil {
+		msg := err.Error()
+		p.Status, p.Error = models.ScenePreviewReady, &msg
+		if uerr := vp.db.UpdateScenePreview(p); uerr != nil {
+			vp.warnf("%v", uerr)
+		}
+		return err
+	}
+	now := time.Now()
+	p.Status, p.Error, p.CommittedAt = models.ScenePreviewCommitted, nil, &now
+	if err := vp.db.UpdateScenePreview(p); err != nil {
+		vp.warnf("failed to mark scene preview %d committed: %v", p.ID, err)
+	}
+	log.Printf("Committed scene preview %d for video ID %d (%d shots)", p.ID, video.ID, len(p.Scenes))
+
+	// Embeddings of the old boundaries no longer describe the scenes
+	if vp.jobQueue != nil {
+		if _, err := vp.jobQueue.Enqueue(queue.JobTypeEmbeddingGeneration, map[string]interface{}{"video_id": video.ID}); err != nil {
+			vp.warnf("Failed to enqueue embedding generation job for video %d: %v", video.ID, err)
+		}
+	}
+	return nil
+}
+
+// replaceScenes stores a video's new shots and beats and extracts keyframes. References to the
+// previous scenes move to the new scene each overlapped most, rows beyond the new counts are
+// deleted, so re-detection with fewer scenes leaves nothing stale behind, and annotations are
+// re-anchored from their time ranges.
+func (vp *VideoProcessor) replaceScenes(video *models.Video, filepathStr string, scenes []scenedetect.Scene) error {
+	oldShots, err := vp.db.GetScenesByVideoIDAndLevel(video.ID, models.SceneLevelShot)
+	if err != nil {
+		return fmt.Errorf("failed to load current scenes: %v", err)
+	}
+	oldBeats, err := vp.db.GetScenesByVideoIDAndLevel(video.ID, models.SceneLevelBeat)
+	if err != nil {
+		return fmt.Errorf("failed to load current beats: %v", err)
+	}
+
+	beats, err := vp.storeSceneLevels(video, scenes)
+	if err != nil {
+		return err
+	}
+	if err := vp.remapSceneLevel(video.ID, models.SceneLevelShot, oldShots, len(scenes)); err != nil {
+		vp.warnf("Failed to remap shots of video ID %d: %v", video.ID, err)
+	}
+	if err := vp.remapSceneLevel(video.ID, models.SceneLevelBeat, oldBeats, len(beats)); err != nil {
+		vp.warnf("Failed to remap beats of video ID %d: %v", video.ID, err)
+	}
+	vp.reassociateAnnotations(video.ID)
+	vp.relinkCaptionScenes(video.ID)
+
+	vp.storeKeyframes(video, filepathStr, scenes, beats)
+	return nil
+}
+
+// remapSceneLevel moves references from the old scenes of one level to the stored scenes with
+// scene_index < count that replaced them, then deletes the rows from count on
+func (vp *VideoProcessor) remapSceneLevel(videoID uint, level string, old []models.Scene, count int) error {
+	if len(old) > 0 {
+		current, err := vp.db.GetScenesByVideoIDAndLevel(videoID, level)
+		if err != nil {
+			return err
+		}
+		if len(current) > count {
+			current = current[:count]
+		}
+		mapping := map[uint]uint{}
+		for i, j := range scenedetect.MapScenes(detectedScenes(old), detectedScenes(current)) {
+			if j >= 0 && old[i].ID != current[j].ID {
+				mapping[old[i].ID] = current[j].ID
+			}
+		}
+		if err := vp.db.RemapSceneReferences(mapping); err != nil {
+			return err
+		}
+	}
+	return vp.db.DeleteScenesFromIndex(videoID, level, count)
+}
+
+// reassociateAnnotations points a video's annotations at its current scenes
+func (vp *VideoProcessor) reassociateAnnotations(videoID uint) {
+	if err := vp.db.ReassociateAnnotations(videoID); err != nil {
+		vp.warnf("Failed to re-associate annotations of video ID %d: %v", videoID, err)
+	}
+}
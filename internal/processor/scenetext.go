@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+)
+
+// DefaultSceneTextPatterns strip the sound and music cues captions carry ("[APPLAUSE]", "(LAUGHS)")
+// while no default scene text filter is stored
+var DefaultSceneTextPatterns = []string{`\[[^\[\]]*\]`, `\([A-Z][A-Z0-9 .,'!?-]*\)`}
+
+// Scene text filter limits
+const (
+	maxSceneTextPatterns  = 100
+	maxSceneTextPattern   = 512
+	maxSceneTextStopwords = 1000
+)
+
+// SceneTextRules strip boilerplate from captions as they are aggregated into scene text
+type SceneTextRules struct {
+	patterns  []*regexp.Regexp
+	stopwords map[string]bool
+}
+
+// CompileSceneTextFilter checks a filter's rules and compiles them
+func CompileSceneTextFilter(patterns, stopwords []string) (*SceneTextRules, error) {
+	if len(patterns) > maxSceneTextPatterns {
+		return nil, fmt.Errorf("at most %d patterns are allowed", maxSceneTextPatterns)
+	}
+	if len(stopwords) > maxSceneTextStopwords {
+		return nil, fmt.Errorf("at most %d stopwords are allowed", maxSceneTextStopwords)
+	}
+	r := &SceneTextRules{stopwords: map[string]bool{}}
+	for _, p := range patterns {
+		if p == "" || len(p) > maxSceneTextPattern {
+			return nil, fmt.Errorf("patterns must be 1 to %d characters", maxSceneTextPattern)
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, w := range stopwords {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			r.stopwords[w] = true
+		}
+	}
+	return r, nil
+}
+
+// Apply removes pattern matches and stopwords from text and collapses the whitespace left behind
+func (r *SceneTextRules) Apply(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, re := range r.patterns {
+		text = re.ReplaceAllString(text, " ")
+	}
+	words := strings.Fields(text)
+	kept := words[:0]
+	for _, w := range words {
+		if len(r.stopwords) > 0 && r.stopwords[strings.ToLower(strings.TrimFunc(w, unicode.IsPunct))] {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return strings.Join(kept, " ")
+}
+
+// SceneTextRulesFor resolves the scene text rules of a project: the stored default set (the
+// built-in DefaultSceneTextPatterns when none is stored) plus the project's set, which replaces
+// the default set instead unless it inherits. Invalid stored rules are skipped with a warning.
+func SceneTextRulesFor(db *database.DB, project string) *SceneTextRules {
+	def, proj, err := db.SceneTextFiltersFor(project)
+	if err != nil {
+		log.Printf("Warning: failed to load scene text filters: %v", err)
+	}
+	patterns, stopwords := DefaultSceneTextPatterns, []string(nil)
+	if def != nil {
+		patterns, stopwords = def.Patterns, def.Stopwords
+	}
+	if proj != nil {
+		if !proj.Inherit {
+			patterns, stopwords = nil, nil
+		}
+		patterns = append(append([]string{}, patterns...), proj.Patterns...)
+		stopwords = append(append([]string{}, stopwords...), proj.Stopwords...)
+	}
+	rules, err := CompileSceneTextFilter(patterns, stopwords)
+	if err != nil {
+		log.Printf("Warning: scene text filters for project %q are invalid, using none: %v", project, err)
+		return nil
+	}
+	return rules
+}
+
+// sceneTextRules are the scene text rules of a video's project
+func (vp *VideoProcessor) sceneTextRules(video *models.Video) *SceneTextRules {
+	return SceneTextRulesFor(vp.db, videoProject(video))
+}
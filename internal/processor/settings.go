@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"os"
+	"strings"
+
+	"goodclips-server/internal/database"
+)
+
+// embeddingBackend is the video embedding backend (EMBEDDING_BACKEND: iv2 (default) or internvl35)
+func embeddingBackend() string {
+	if b := os.Getenv("EMBEDDING_BACKEND"); b != "" {
+		return b
+	}
+	return "iv2"
+}
+
+// videoModelID is the model the video embedding backend loads (IV2_MODEL_ID, defaulting per backend)
+func videoModelID(backend string) string {
+	if id := os.Getenv("IV2_MODEL_ID"); id != "" {
+		return id
+	}
+	if backend == "internvl35" {
+		return "OpenGVLab/InternVL3_5-2B"
+	}
+	return "OpenGVLab/InternVideo2-Stage2_1B-224p-f4"
+}
+
+// audioEmbeddingsEnabled reports whether CLAP audio embeddings are computed (ENABLE_AUDIO_EMBEDDINGS, default true)
+func audioEmbeddingsEnabled() bool {
+	v := os.Getenv("ENABLE_AUDIO_EMBEDDINGS")
+	return !strings.EqualFold(v, "false") && v != "0"
+}
+
+// ModelEntry is one model a pipeline runner loads, with the variable that overrides it ("" for a
+// model that cannot be changed)
+type ModelEntry struct {
+	Name    string `json:"name"`
+	Env     string `json:"env"`
+	ModelID string `json:"model_id"`
+	Default bool   `json:"default"`
+	// Remote is set when a remote service runs the model instead of a local runner (RERANK_URL); the
+	// service may load another model than ModelID
+	Remote bool `json:"remote,omitempty"`
+}
+
+// modelFromEnv resolves a runner model: env when set, def otherwise
+func modelFromEnv(name, env, def string) ModelEntry {
+	if id := os.Getenv(env); id != "" {
+		return ModelEntry{Name: name, Env: env, ModelID: id, Default: id == def}
+	}
+	return ModelEntry{Name: name, Env: env, ModelID: def, Default: true}
+}
+
+// Models lists the models the pipeline runners load, as configured in this process's environment.
+// Defaults mirror those of the Python runners.
+func Models() []ModelEntry {
+	backend := embeddingBackend()
+	video := ModelEntry{Name: "video_embedding", Env: "IV2_MODEL_ID", ModelID: videoModelID(backend)}
+	video.Default = os.Getenv("IV2_MODEL_ID") == ""
+	rerank := modelFromEnv("rerank", "RERANK_MODEL_ID", "BAAI/bge-reranker-base")
+	rerank.Remote = os.Getenv("RERANK_URL") != ""
+	return []ModelEntry{
+		video,
+		modelFromEnv("clip_visual", "CLIP_MODEL_ID", "openai/clip-vit-base-patch32"),
+		modelFromEnv("text_embedding", "E5_MODEL_ID", "intfloat/e5-base-v2"),
+		modelFromEnv("audio_embedding", "CLAP_MODEL_ID", "laion/clap-htsat-fused"),
+		modelFromEnv("ner", "NER_MODEL_ID", "dslim/bert-base-NER"),
+		modelFromEnv("translation", "TRANSLATE_MODEL_ID", "facebook/m2m100_418M"),
+		modelFromEnv("sentiment", "SENTIMENT_MODEL_ID", "cardiffnlp/twitter-roberta-base-sentiment-latest"),
+		modelFromEnv("emotion", "EMOTION_MODEL_ID", "j-hartmann/emotion-english-distilroberta-base"),
+		modelFromEnv("audio_emotion", "AUDIO_EMOTION_MODEL_ID", "superb/wav2vec2-base-superb-er"),
+		modelFromEnv("text_embedding_multilingual", "TEXT_EMBED_MULTILINGUAL_MODEL_ID", "intfloat/multilingual-e5-base"),
+		rerank,
+		{Name: "word_alignment", ModelID: "torchaudio MMS_FA", Default: true},
+	}
+}
+
+// Settings returns the effective pipeline settings of this process: which modalities and follow-up
+// stages run and the tunables they use
+func Settings() map[string]any {
+	topics := topicOptionsFromEnv()
+	return map[string]any{
+		"modalities": map[string]any{
+			"visual":     true,
+			"clip":       true,
+			"text":       true,
+			"audio":      StageEnabled(FlagAudioEmbeddings),
+			"tone_audio": StageEnabled(FlagToneAudio),
+		},
+		"embedding_backend":      embeddingBackend(),
+		"embedding_normalize":    database.NormalizeOnWrite(),
+		"feature_flags":          FeatureFlags(),
+		"scene_chunk_secs":       sceneChunkSeconds(),
+		"audio_segment_secs":     audioSegmentSeconds(),
+		"topic_window_secs":      topics.WindowSecs,
+		"entity_min_score":       entityMinScore(),
+		"content_flag_pad":       contentFlagPad(),
+		"alert_max_matches":      alertMaxMatches(),
+		"highlights_dir":         highlightDir(),
+		"live_idle_ticks":        liveIdleTicks(),
+		"zombie_threshold":       zombieThreshold().String(),
+		"notify_digest":          digestEnabled(),
+		"snapshot_backfill_days": snapshotBackfillDays(),
+		"shared_ingest":          SharedIngestEnabled(),
+		"vector_index_auto":      vectorIndexAuto(),
+		"cold_storage":           ColdStorageEnabled(),
+	}
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/queue"
+)
+
+// snapshotBackfillDays is how many past days a snapshot run fills in when their snapshot is missing
+// (LIBRARY_SNAPSHOT_BACKFILL_DAYS, default 30)
+func snapshotBackfillDays() int {
+	if v, err := strconv.Atoi(os.Getenv("LIBRARY_SNAPSHOT_BACKFILL_DAYS")); err == nil && v >= 0 {
+		return v
+	}
+	return 30
+}
+
+// ScheduleLibrarySnapshot makes sure a snapshot job is queued for just after the next UTC midnight;
+// several workers calling it schedule it only once
+func (vp *VideoProcessor) ScheduleLibrarySnapshot() error {
+	if vp.jobQueue == nil {
+		return nil
+	}
+	at := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1).Add(5 * time.Minute)
+	key := fmt.Sprintf("library_snapshot:%d", at.Unix())
+	if _, err := vp.jobQueue.EnqueueOnce(key, queue.JobTypeLibrarySnapshot, map[string]interface{}{}, at); err != nil {
+		return fmt.Errorf("failed to schedule library snapshot: %v", err)
+	}
+	return nil
+}
+
+// ProcessLibrarySnapshot records the snapshot of the previous UTC day (or of payload "day",
+// YYYY-MM-DD), backfills missing days before it, and schedules the next run
+func (vp *VideoProcessor) ProcessLibrarySnapshot(payload map[string]interface{}) error {
+	defer func() {
+		if err := vp.ScheduleLibrarySnapshot(); err != nil {
+			vp.warnf("%v", err)
+		}
+	}()
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if v, ok := payload["day"].(string); ok && v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return fmt.Errorf("invalid day %q: %v", v, err)
+		}
+		day = t
+	}
+	if _, err := vp.db.SnapshotLibrary(day); err != nil {
+		return fmt.Errorf("failed to snapshot library for %s: %v", day.Format("2006-01-02"), err)
+	}
+	filled := 0
+	for i := 1; i <= snapshotBackfillDays(); i++ {
+		d := day.AddDate(0, 0, -i)
+		if ok, err := vp.db.HasLibrarySnapshot(d); err != nil || ok {
+			continue
+		}
+		if _, err := vp.db.SnapshotLibrary(d); err != nil {
+			vp.warnf("failed to backfill library snapshot for %s: %v", d.Format("2006-01-02"), err)
+			continue
+		}
+		filled++
+	}
+	log.Printf("[stats] library snapshot for %s recorded (%d missing days backfilled)", day.Format("2006-01-02"), filled)
+	return nil
+}
@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// artifactKinds are the artifact kinds storage tiering moves, in the order a tiering job visits them
+var artifactKinds = []string{models.ArtifactClipExport, models.ArtifactHighlightReel, models.ArtifactSupercut}
+
+// coldStorageDir is where cold artifacts are kept (COLD_STORAGE_DIR), typically a mount of cheaper
+// storage such as an object store bucket; tiering is off when it is unset
+func coldStorageDir() string {
+	return os.Getenv("COLD_STORAGE_DIR")
+}
+
+// ColdStorageEnabled reports whether rarely downloaded artifacts are moved to cold storage
+func ColdStorageEnabled() bool {
+	return coldStorageDir() != ""
+}
+
+// coldStorageAfter is how long an artifact of kind goes without downloads before it moves to cold
+// storage: COLD_STORAGE_AFTER_DAYS_<KIND>, else COLD_STORAGE_AFTER_DAYS, else 30 days. 0 keeps the
+// kind hot.
+func coldStorageAfter(kind string) time.Duration {
+	for _, key := range []string{"COLD_STORAGE_AFTER_DAYS_" + strings.ToUpper(kind), "COLD_STORAGE_AFTER_DAYS"} {
+		if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+	}
+	return 30 * 24 * time.Hour
+}
+
+// coldStorageBatch is how many artifacts of each kind one tiering job moves at most
+// (COLD_STORAGE_BATCH, default 500)
+func coldStorageBatch() int {
+	if n, err := strconv.Atoi(os.Getenv("COLD_STORAGE_BATCH")); err == nil && n > 0 {
+		return n
+	}
+	return 500
+}
+
+// ScheduleStorageTiering makes sure a storage tiering job is queued for 04:00 UTC when cold storage is
+// configured; several workers calling it schedule it only once
+func (vp *VideoProcessor) ScheduleStorageTiering() error {
+	if vp.jobQueue == nil || coldStorageDir() == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+	at := now.Truncate(24 * time.Hour).Add(4 * time.Hour)
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	key := fmt.Sprintf("storage_tiering:%d", at.Unix())
+	if _, err := vp.jobQueue.EnqueueOnce(key, queue.JobTypeStorageTiering, map[string]interface{}{}, at); err != nil {
+		return fmt.Errorf("failed to schedule storage tiering: %v", err)
+	}
+	return nil
+}
+
+// ProcessStorageTiering tracks exports rendered before tiering, forgets deleted ones (removing their
+// cold copies) and moves artifacts not downloaded for coldStorageAfter to COLD_STORAGE_DIR. Nightly
+// runs schedule the next one; manual runs ({"manual": true}) do not. The job result counts the
+// artifacts registered, pruned and archived, and the bytes archived.
+func (vp *VideoProcessor) ProcessStorageTiering(payload map[string]interface{}) error {
+	if manual, _ := payload["manual"].(bool); !manual {
+		defer func() {
+			if err := vp.ScheduleStorageTiering(); err != nil {
+				vp.warnf("%v", err)
+			}
+		}()
+	}
+	dir := coldStorageDir()
+	if dir == "" {
+		return fmt.Errorf("cold storage is not configured (COLD_STORAGE_DIR)")
+	}
+
+	registered, err := vp.db.SyncArtifacts()
+	if err != nil {
+		return fmt.Errorf("failed to track exports: %v", err)
+	}
+	vp.setResult("registered", registered)
+	pruned, err := vp.db.PruneArtifacts()
+	if err != nil {
+		return fmt.Errorf("failed to prune artifacts: %v", err)
+	}
+	for _, a := range pruned {
+		if a.ColdPath != nil {
+			if err := os.Remove(*a.ColdPath); err != nil && !os.IsNotExist(err) {
+				vp.warnf("failed to remove cold copy %s: %v", *a.ColdPath, err)
+			}
+		}
+	}
+	vp.setResult("pruned", len(pruned))
+
+	archived := 0
+	var archivedBytes int64
+	for n, kind := range artifactKinds {
+		vp.stepProgress(0, 1, n, len(artifactKinds))
+		after := coldStorageAfter(kind)
+		if after == 0 {
+			continue
+		}
+		cutoff := time.Now().Add(-after)
+		artifacts, err := vp.db.ArtifactsToArchive(kind, cutoff, coldStorageBatch())
+		if err != nil {
+			return fmt.Errorf("failed to list %s artifacts: %v", kind, err)
+		}
+		for _, a := range artifacts {
+			size, ok, err := vp.archiveArtifact(a, dir, cutoff)
+			if err != nil {
+				vp.warnf("failed to archive %s %d: %v", a.Kind, a.OwnerID, err)
+				continue
+			}
+			if ok {
+				archived++
+				archivedBytes += size
+			}
+		}
+	}
+	vp.setResult("archived", archived)
+	vp.setResult("archived_bytes", archivedBytes)
+	if archived > 0 {
+		log.Printf("[storage_tiering] moved %d artifacts (%d bytes) to cold storage", archived, archivedBytes)
+	}
+	return nil
+}
+
+// trackArtifact records a freshly rendered export file for storage tiering
+func (vp *VideoProcessor) trackArtifact(kind string, id uint, path string) {
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	if err := vp.db.TrackArtifact(kind, id, path, size); err != nil {
+		vp.warnf("failed to track %s %d for storage tiering: %v", kind, id, err)
+	}
+}
+
+// archiveArtifact copies an artifact to the cold storage directory and removes the hot file once the
+// artifact is recorded as cold. It reports false, dropping the copy, when the artifact was downloaded
+// in the meantime.
+func (vp *VideoProcessor) archiveArtifact(a models.Artifact, dir string, cutoff time.Time) (int64, bool, error) {
+	info, err := os.Stat(a.Path)
+	if err != nil {
+		return 0, false, err
+	}
+	cold := filepath.Join(dir, a.Kind, fmt.Sprintf("%d_%s", a.OwnerID, filepath.Base(a.Path)))
+	if err := copyFileAtomic(a.Path, cold); err != nil {
+		return 0, false, fmt.Errorf("failed to copy to %s: %v", cold, err)
+	}
+	ok, err := vp.db.MarkArtifactCold(a.ID, cold, info.Size(), cutoff)
+	if err != nil || !ok {
+		os.Remove(cold)
+		return 0, false, err
+	}
+	if err := os.Remove(a.Path); err != nil && !os.IsNotExist(err) {
+		vp.warnf("failed to remove %s after archiving it: %v", a.Path, err)
+	}
+	return info.Size(), true, nil
+}
+
+// ProcessArtifactRestore copies the payload's cold artifact (artifact_id) back to its path so it can be
+// downloaded again, then removes the cold copy. A failed copy returns the artifact to cold and is not
+// retried: the next download claims a new restore. A restore whose claim was taken over by another
+// job (see database.ClaimArtifactRestore) does nothing.
+func (vp *VideoProcessor) ProcessArtifactRestore(payload map[string]interface{}) error {
+	id, ok := payload["artifact_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid artifact_id in payload")
+	}
+	a, err := vp.db.GetArtifact(uint(id))
+	if err != nil {
+		return fmt.Errorf("failed to get artifact: %v", err)
+	}
+	if a.Tier != models.ArtifactTierRestoring || a.ColdPath == nil {
+		return nil
+	}
+	if a.RestoreJobID != nil && vp.jobID != "" && *a.RestoreJobID != vp.jobID {
+		vp.warnf("restore of artifact %d was taken over by job %s", a.ID, *a.RestoreJobID)
+		return nil
+	}
+	if err := copyFileAtomic(*a.ColdPath, a.Path); err != nil {
+		if rerr := vp.db.ReleaseArtifactRestore(a.ID, vp.jobID); rerr != nil {
+			vp.warnf("failed to release restore of artifact %d: %v", a.ID, rerr)
+		}
+		return queue.Permanent(fmt.Errorf("failed to restore %s: %v", *a.ColdPath, err))
+	}
+	if err := vp.db.MarkArtifactHot(a.ID); err != nil {
+		return fmt.Errorf("failed to mark artifact %d restored: %v", a.ID, err)
+	}
+	if err := os.Remove(*a.ColdPath); err != nil && !os.IsNotExist(err) {
+		vp.warnf("failed to remove cold copy %s: %v", *a.ColdPath, err)
+	}
+	return nil
+}
+
+// copyFileAtomic copies src to dst through a temporary file in dst's directory, synced before it is
+// renamed into place, so dst is never seen half-written
+func copyFileAtomic(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
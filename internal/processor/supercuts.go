@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+)
+
+// maxSupercutMatches caps how many utterances are fetched before ordering and truncating to max_clips
+const maxSupercutMatches = 1000
+
+// ProcessSupercut handles supercut jobs. Payload: {"supercut_id": 4}
+func (vp *VideoProcessor) ProcessSupercut(payload map[string]interface{}) error {
+	id, ok := payload["supercut_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid supercut_id in payload")
+	}
+	sc, err := vp.db.GetSupercut(uint(id))
+	if err != nil {
+		return fmt.Errorf("failed to load supercut %d: %v", uint(id), err)
+	}
+	sc.Status = models.HighlightStatusProcessing
+	sc.ErrorMessage = nil
+	if err := vp.db.UpdateSupercut(sc); err != nil {
+		return fmt.Errorf("failed to update supercut: %v", err)
+	}
+
+	if err := vp.buildSupercut(sc); err != nil {
+		msg := err.Error()
+		sc.Status = models.HighlightStatusFailed
+		sc.ErrorMessage = &msg
+		if uerr := vp.db.UpdateSupercut(sc); uerr != nil {
+			vp.warnf("failed to record supercut %d failure: %v", sc.ID, uerr)
+		}
+		return err
+	}
+	sc.Status = models.HighlightStatusCompleted
+	if err := vp.db.UpdateSupercut(sc); err != nil {
+		return fmt.Errorf("failed to store supercut: %v", err)
+	}
+	log.Printf("[supercut] supercut_id=%d: %d utterances of %q, %.1fs", sc.ID, len(sc.Items), sc.Phrase, sc.TotalDuration)
+	return nil
+}
+
+// buildSupercut finds every utterance of the phrase, pads and orders them, and exports the result
+func (vp *VideoProcessor) buildSupercut(sc *models.Supercut) error {
+	words := PhraseWords(sc.Phrase)
+	if len(words) == 0 {
+		return fmt.Errorf("phrase has no words")
+	}
+	matches, err := vp.db.SearchPhrase(words, sc.VideoIDs, maxSupercutMatches)
+	if err != nil {
+		return fmt.Errorf("phrase search failed: %v", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("phrase %q is not spoken in any aligned video", sc.Phrase)
+	}
+
+	opts := ffmpeg.ClipBoundsOptions{PadBefore: sc.PadBefore, PadAfter: sc.PadAfter, Snap: ffmpeg.SnapNone}
+	items := make(models.SupercutItems, len(matches))
+	for i, m := range matches {
+		b := ffmpeg.RefineClipBounds(m.StartTime, m.EndTime, m.VideoDuration, opts, nil)
+		items[i] = models.SupercutItem{
+			VideoID:   m.VideoID,
+			CaptionID: m.CaptionID,
+			Phrase:    m.Phrase,
+			StartTime: m.StartTime,
+			EndTime:   m.EndTime,
+			ClipStart: b.Start,
+			ClipEnd:   b.End,
+		}
+	}
+	items = orderSupercutItems(items, sc.Ordering, sc.VideoIDs, int64(sc.ID))
+	if sc.MaxClips > 0 && len(items) > sc.MaxClips {
+		items = items[:sc.MaxClips]
+	}
+	sc.Items = items
+	sc.TotalDuration = 0
+	for _, it := range items {
+		sc.TotalDuration += it.ClipEnd - it.ClipStart
+	}
+	return vp.exportSupercut(sc)
+}
+
+// orderSupercutItems arranges utterances for playback. In video order, videos follow videoIDs (by ID
+// when empty) and a clip overlapping the previous one in the same video starts where that one ended,
+// so no footage plays twice. Random order is seeded so that rebuilding a supercut is reproducible.
+func orderSupercutItems(items models.SupercutItems, order string, videoIDs []uint, seed int64) models.SupercutItems {
+	switch order {
+	case models.SupercutOrderDuration:
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].EndTime-items[i].StartTime < items[j].EndTime-items[j].StartTime
+		})
+	case models.SupercutOrderRandom:
+		rand.New(rand.NewSource(seed)).Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	default:
+		rank := make(map[uint]int, len(videoIDs))
+		for i, id := range videoIDs {
+			if _, ok := rank[id]; !ok {
+				rank[id] = i
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i], items[j]
+			if a.VideoID != b.VideoID {
+				if len(rank) > 0 {
+					return rank[a.VideoID] < rank[b.VideoID]
+				}
+				return a.VideoID < b.VideoID
+			}
+			return a.StartTime < b.StartTime
+		})
+		out := items[:0]
+		for _, it := range items {
+			if n := len(out); n > 0 && out[n-1].VideoID == it.VideoID && it.ClipStart < out[n-1].ClipEnd {
+				it.ClipStart = out[n-1].ClipEnd
+				if it.ClipStart >= it.ClipEnd {
+					continue
+				}
+			}
+			out = append(out, it)
+		}
+		items = out
+	}
+	return items
+}
+
+// exportSupercut renders the supercut's clips into one MP4 under highlightDir()
+func (vp *VideoProcessor) exportSupercut(sc *models.Supercut) error {
+	videos := map[uint]*models.Video{}
+	segments := make([]ffmpeg.ReelSegment, 0, len(sc.Items))
+	withAudio := true
+	for _, it := range sc.Items {
+		_, probed := videos[it.VideoID]
+		video := vp.highlightVideo(videos, it.VideoID)
+		if video == nil {
+			return fmt.Errorf("video %d of caption %d is unavailable", it.VideoID, it.CaptionID)
+		}
+		if !probed {
+			if has, err := vp.ffmpegClient.HasAudioStream(video.Filepath); err != nil || !has {
+				withAudio = false
+			}
+		}
+		segments = append(segments, ffmpeg.ReelSegment{Path: video.Filepath, Start: it.ClipStart, End: it.ClipEnd})
+	}
+
+	dir := highlightDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create highlights directory: %v", err)
+	}
+	out := filepath.Join(dir, fmt.Sprintf("supercut_%d.mp4", sc.ID))
+	if err := vp.exportFFmpeg(exportSupercut, sc.ID, "").RenderHighlightReel(segments, out, 1280, 720, withAudio, ffmpeg.CensorNone); err != nil {
+		return err
+	}
+	sc.ExportPath = &out
+	vp.trackArtifact(models.ArtifactSupercut, sc.ID, out)
+	return nil
+}
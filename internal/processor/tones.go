@@ -0,0 +1,241 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// toneRetryLimit bounds how often a tone job waits for scene detection to finish
+const toneRetryLimit = 10
+
+// toneResult is one scored text or scene from the tone runner
+type toneResult struct {
+	SceneIndex int                `json:"scene_index"`
+	Sentiment  float64            `json:"sentiment"`
+	Emotions   map[string]float64 `json:"emotions"`
+}
+
+// toneAnalysisAuto reports whether caption extraction enqueues a tone analysis job (TONE_ANALYSIS_AUTO, default true)
+func toneAnalysisAuto() bool {
+	if v, err := strconv.ParseBool(os.Getenv("TONE_ANALYSIS_AUTO")); err == nil {
+		return v
+	}
+	return true
+}
+
+// toneAudioEnabled reports whether scene audio is scored for emotion (TONE_AUDIO, default true)
+func toneAudioEnabled() bool {
+	if v, err := strconv.ParseBool(os.Getenv("TONE_AUDIO")); err == nil {
+		return v
+	}
+	return true
+}
+
+// runToneRunner sends req to the tone runner ("text" or "audio" mode) and returns its results
+func runToneRunner(ctx context.Context, req map[string]interface{}) ([]toneResult, error) {
+	b, _ := json.Marshal(req)
+	cmd := exec.CommandContext(ctx, "python3", "/root/internal/embeddings/tone_runner.py")
+	cmd.Stdin = bytes.NewReader(b)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("tone_runner failed: %v; stderr: %s", err, stderr.String())
+	}
+	var resp struct {
+		Results []toneResult `json:"results"`
+		Error   string       `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse tone_runner output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("tone_runner error: %s", resp.Error)
+	}
+	return resp.Results, nil
+}
+
+// dominantEmotion returns the highest-scoring emotion and how far the scores are from neutral
+func dominantEmotion(scores map[string]float64) (string, float64) {
+	best, bestScore := "", -1.0
+	for e, s := range scores {
+		if s > bestScore || (s == bestScore && e < best) {
+			best, bestScore = e, s
+		}
+	}
+	return best, 1 - scores[models.EmotionNeutral]
+}
+
+// emotionObject converts emotion scores for JSONB storage
+func emotionObject(scores map[string]float64) models.JSONObject {
+	o := models.JSONObject{}
+	for e, s := range scores {
+		o[e] = s
+	}
+	return o
+}
+
+// ProcessToneAnalysis handles tone analysis jobs: each scene's captions are scored for sentiment and
+// emotion and its audio for emotion. Shots are scored directly; beats get their own caption scores
+// and the duration-weighted audio scores of their shots. Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessToneAnalysis(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load video %d: %v", videoID, err)
+	}
+	scenes, err := vp.db.GetScenesByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load scenes: %v", err)
+	}
+	if len(scenes) == 0 {
+		// Captions can finish before scene detection; try again shortly
+		attempt, _ := payload["attempt"].(float64)
+		if int(attempt) >= toneRetryLimit || vp.jobQueue == nil {
+			return fmt.Errorf("video %d has no scenes to score", videoID)
+		}
+		next := map[string]interface{}{"video_id": videoID, "attempt": int(attempt) + 1}
+		if _, err := vp.jobQueue.EnqueueAt(queue.JobTypeToneAnalysis, next, time.Now().Add(time.Minute)); err != nil {
+			return fmt.Errorf("failed to reschedule tone analysis: %v", err)
+		}
+		log.Printf("[tone] video_id=%d: no scenes yet; retrying in 1m (attempt %d)", videoID, int(attempt)+1)
+		return nil
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+
+	// Caption sentiment and emotion for every scene that has dialogue, from the scene text each level
+	// is embedded with
+	sceneTexts := make([]string, len(scenes))
+	byLevel := map[string][]int{}
+	for i, s := range scenes {
+		byLevel[s.Level] = append(byLevel[s.Level], i)
+	}
+	rules := vp.sceneTextRules(video)
+	for _, idx := range byLevel {
+		group := make([]models.Scene, len(idx))
+		for j, i := range idx {
+			group[j] = scenes[i]
+		}
+		t, _ := sceneCaptionTexts(group, captions, rules)
+		for j, i := range idx {
+			sceneTexts[i] = t[j]
+		}
+	}
+	var texts []string
+	var textScenes []int
+	for i, t := range sceneTexts {
+		if t != "" {
+			texts = append(texts, t)
+			textScenes = append(textScenes, i)
+		}
+	}
+	textTone := map[int]toneResult{}
+	if len(texts) > 0 {
+		results, err := runToneRunner(vp.context(), map[string]interface{}{"mode": "text", "texts": texts})
+		if err != nil {
+			return err
+		}
+		if len(results) != len(texts) {
+			return fmt.Errorf("tone_runner returned %d results for %d texts", len(results), len(texts))
+		}
+		for j, r := range results {
+			textTone[textScenes[j]] = r
+		}
+	}
+
+	// Audio emotion for shots, rolled up into their beats
+	shotAudio := map[int]map[string]float64{}
+	if StageEnabled(FlagToneAudio) && video.AssetType != models.AssetTypeImage {
+		var shots []models.Scene
+		for _, s := range scenes {
+			if s.Level == models.SceneLevelShot {
+				shots = append(shots, s)
+			}
+		}
+		if len(shots) > 0 {
+			results, err := runToneRunner(vp.context(), map[string]interface{}{"mode": "audio", "video_path": video.Filepath, "scenes": sceneRanges(shots)})
+			if err != nil {
+				// Caption tone is still useful on its own
+				vp.warnf("audio emotion scoring failed for video %d: %v", videoID, err)
+			}
+			for _, r := range results {
+				shotAudio[r.SceneIndex] = r.Emotions
+			}
+		}
+	}
+	beatAudio := map[int]map[string]float64{}
+	beatWeight := map[int]float64{}
+	for _, s := range scenes {
+		scores, ok := shotAudio[s.SceneIndex]
+		if s.Level != models.SceneLevelShot || s.BeatIndex == nil || !ok {
+			continue
+		}
+		w := s.EndTime - s.StartTime
+		agg := beatAudio[*s.BeatIndex]
+		if agg == nil {
+			agg = map[string]float64{}
+			beatAudio[*s.BeatIndex] = agg
+		}
+		for e, v := range scores {
+			agg[e] += v * w
+		}
+		beatWeight[*s.BeatIndex] += w
+	}
+	for idx, agg := range beatAudio {
+		if w := beatWeight[idx]; w > 0 {
+			for e := range agg {
+				agg[e] /= w
+			}
+		}
+	}
+
+	tones := make([]models.SceneTone, 0, len(scenes))
+	for i, s := range scenes {
+		tone := models.SceneTone{VideoID: videoID, SceneID: s.ID, EmotionScores: models.JSONObject{}, AudioEmotionScores: models.JSONObject{}}
+		if r, ok := textTone[i]; ok {
+			sentiment := r.Sentiment
+			emotion, intensity := dominantEmotion(r.Emotions)
+			tone.Sentiment = &sentiment
+			tone.Emotion = &emotion
+			tone.EmotionScores = emotionObject(r.Emotions)
+			tone.Intensity = intensity
+		}
+		audio := shotAudio[s.SceneIndex]
+		if s.Level == models.SceneLevelBeat {
+			audio = beatAudio[s.SceneIndex]
+		}
+		if len(audio) > 0 {
+			emotion, intensity := dominantEmotion(audio)
+			tone.AudioEmotion = &emotion
+			tone.AudioEmotionScores = emotionObject(audio)
+			if intensity > tone.Intensity {
+				tone.Intensity = intensity
+			}
+		}
+		if tone.Emotion == nil && tone.AudioEmotion == nil {
+			continue
+		}
+		tones = append(tones, tone)
+	}
+	if err := vp.db.ReplaceVideoTones(videoID, tones); err != nil {
+		return fmt.Errorf("failed to store scene tones: %v", err)
+	}
+	log.Printf("[tone] video_id=%d: scored %d/%d scenes (%d with captions, %d shots with audio)", videoID, len(tones), len(scenes), len(textTone), len(shotAudio))
+	return nil
+}
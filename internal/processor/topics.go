@@ -0,0 +1,360 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"goodclips-server/internal/database"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// topicOptions controls caption topic segmentation
+type topicOptions struct {
+	// WindowSecs is the length of the caption blocks that are embedded and compared
+	WindowSecs float64
+	// ContextBlocks is how many blocks on each side of a gap are averaged when comparing
+	ContextBlocks int
+	// MinSegmentSecs is the shortest topic segment produced
+	MinSegmentSecs float64
+	// Sensitivity is the boundary cutoff in standard deviations above the mean depth (lower = more topics)
+	Sensitivity float64
+}
+
+// topicOptionsFromEnv reads TOPIC_WINDOW_SECS (default 30), TOPIC_CONTEXT_WINDOWS (2),
+// TOPIC_MIN_SECS (90) and TOPIC_SENSITIVITY (0.5)
+func topicOptionsFromEnv() topicOptions {
+	opts := topicOptions{WindowSecs: 30, ContextBlocks: 2, MinSegmentSecs: 90, Sensitivity: 0.5}
+	if v, err := strconv.ParseFloat(os.Getenv("TOPIC_WINDOW_SECS"), 64); err == nil && v > 0 {
+		opts.WindowSecs = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("TOPIC_CONTEXT_WINDOWS")); err == nil && v > 0 {
+		opts.ContextBlocks = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("TOPIC_MIN_SECS"), 64); err == nil && v >= 0 {
+		opts.MinSegmentSecs = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("TOPIC_SENSITIVITY"), 64); err == nil {
+		opts.Sensitivity = v
+	}
+	return opts
+}
+
+// topicTimelineAuto reports whether caption extraction enqueues a topic timeline job (TOPIC_TIMELINE_AUTO, default true)
+func topicTimelineAuto() bool {
+	if v, err := strconv.ParseBool(os.Getenv("TOPIC_TIMELINE_AUTO")); err == nil {
+		return v
+	}
+	return true
+}
+
+// captionBlock is a run of consecutive captions spanning about one window
+type captionBlock struct {
+	Start    float64
+	End      float64
+	Text     string
+	Captions int
+}
+
+// captionBlocks groups time-ordered captions into blocks of roughly window seconds
+func captionBlocks(captions []models.Caption, window float64) []captionBlock {
+	var blocks []captionBlock
+	for _, cp := range captions {
+		text := strings.TrimSpace(cp.Text)
+		if text == "" {
+			continue
+		}
+		if n := len(blocks); n > 0 && cp.StartTime-blocks[n-1].Start < window {
+			b := &blocks[n-1]
+			b.Text += " " + text
+			b.Captions++
+			if cp.EndTime > b.End {
+				b.End = cp.EndTime
+			}
+			continue
+		}
+		blocks = append(blocks, captionBlock{Start: cp.StartTime, End: cp.EndTime, Text: text, Captions: 1})
+	}
+	return blocks
+}
+
+// topicBoundaries returns the block indexes where a new topic starts. Adjacent context windows are
+// compared by cosine similarity; a gap whose similarity dips well below its neighbouring peaks (its
+// depth is more than Sensitivity standard deviations above the mean depth) is a boundary. Deeper
+// boundaries win when two would leave a segment shorter than MinSegmentSecs.
+func topicBoundaries(blocks []captionBlock, vecs [][]float32, opts topicOptions) []int {
+	n := len(vecs)
+	if n < 2 {
+		return nil
+	}
+	// sims[i] compares the blocks before gap i with the blocks from i on
+	sims := make([]float64, n)
+	for i := 1; i < n; i++ {
+		lo, hi := i-opts.ContextBlocks, i+opts.ContextBlocks
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > n {
+			hi = n
+		}
+		sims[i] = cosine(meanVector(vecs[lo:i]), meanVector(vecs[i:hi]))
+	}
+
+	depths := make([]float64, n)
+	for i := 1; i < n; i++ {
+		left := sims[i]
+		for j := i - 1; j >= 1 && sims[j] >= left; j-- {
+			left = sims[j]
+		}
+		right := sims[i]
+		for j := i + 1; j < n && sims[j] >= right; j++ {
+			right = sims[j]
+		}
+		depths[i] = (left - sims[i]) + (right - sims[i])
+	}
+	mean, std := meanStd(depths[1:])
+	cutoff := mean + opts.Sensitivity*std
+
+	var candidates []int
+	for i := 1; i < n; i++ {
+		if depths[i] <= 0 || depths[i] <= cutoff {
+			continue
+		}
+		if (i > 1 && depths[i-1] > depths[i]) || (i < n-1 && depths[i+1] > depths[i]) {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return depths[candidates[a]] > depths[candidates[b]] })
+
+	start, end := blocks[0].Start, blocks[n-1].End
+	var chosen []int
+	for _, c := range candidates {
+		t := blocks[c].Start
+		if t-start < opts.MinSegmentSecs || end-t < opts.MinSegmentSecs {
+			continue
+		}
+		ok := true
+		for _, b := range chosen {
+			if math.Abs(blocks[b].Start-t) < opts.MinSegmentSecs {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			chosen = append(chosen, c)
+		}
+	}
+	sort.Ints(chosen)
+	return chosen
+}
+
+func meanVector(vecs [][]float32) []float64 {
+	if len(vecs) == 0 {
+		return nil
+	}
+	out := make([]float64, len(vecs[0]))
+	for _, v := range vecs {
+		for i, x := range v {
+			if i < len(out) {
+				out[i] += float64(x)
+			}
+		}
+	}
+	for i := range out {
+		out[i] /= float64(len(vecs))
+	}
+	return out
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func meanStd(xs []float64) (float64, float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(xs)))
+}
+
+// topicStopwords are ignored when labelling topics
+var topicStopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about after again all also am an and any are as at be because been before
+		being but by can could did do does doing don down for from get got had has have having he her here hers him
+		his how i if in into is it its just know like me more most my no not now of off on once one only or other our
+		out over really right so some such than that the their them then there these they thing think this those
+		through to too up us very was we well were what when where which while who why will with would yeah yes you
+		your going gonna want okay oh um uh let lets see say said go way something`) {
+		topicStopwords[w] = true
+	}
+}
+
+// topicWords splits text into lowercase content words
+func topicWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	words := fields[:0]
+	for _, w := range fields {
+		w = strings.Trim(w, "'")
+		if len([]rune(w)) < 3 || topicStopwords[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// topicKeywords picks the n words that best distinguish each segment from the others (TF-IDF)
+func topicKeywords(segments []string, n int) [][]string {
+	tfs := make([]map[string]int, len(segments))
+	df := map[string]int{}
+	for i, text := range segments {
+		tfs[i] = map[string]int{}
+		for _, w := range topicWords(text) {
+			if tfs[i][w] == 0 {
+				df[w]++
+			}
+			tfs[i][w]++
+		}
+	}
+	out := make([][]string, len(segments))
+	for i, tf := range tfs {
+		type scored struct {
+			word  string
+			score float64
+		}
+		var ws []scored
+		for w, c := range tf {
+			idf := math.Log(float64(len(segments)+1)/float64(df[w]+1)) + 1
+			ws = append(ws, scored{w, float64(c) * idf})
+		}
+		sort.Slice(ws, func(a, b int) bool {
+			if ws[a].score != ws[b].score {
+				return ws[a].score > ws[b].score
+			}
+			return ws[a].word < ws[b].word
+		})
+		for j := 0; j < n && j < len(ws); j++ {
+			out[i] = append(out[i], ws[j].word)
+		}
+	}
+	return out
+}
+
+// topicExcerpt shortens segment text to about 200 characters at a word boundary
+func topicExcerpt(text string) string {
+	const max = 200
+	r := []rune(text)
+	if len(r) <= max {
+		return text
+	}
+	cut := string(r[:max])
+	if i := strings.LastIndex(cut, " "); i > max/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// ProcessTopicTimeline handles topic timeline jobs: captions are grouped into windows, embedded, and
+// split where adjacent windows stop resembling each other. Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessTopicTimeline(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	sort.SliceStable(captions, func(i, j int) bool { return captions[i].StartTime < captions[j].StartTime })
+
+	opts := topicOptionsFromEnv()
+	blocks := captionBlocks(captions, opts.WindowSecs)
+	if len(blocks) == 0 {
+		log.Printf("[topics] video_id=%d: no captions; clearing topic timeline", videoID)
+		return vp.db.ReplaceVideoTopics(videoID, nil)
+	}
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = b.Text
+	}
+	vecs, err := embedTexts(vp.context(), texts, "passage")
+	if err != nil {
+		return fmt.Errorf("failed to embed caption windows: %v", err)
+	}
+
+	bounds := append(topicBoundaries(blocks, vecs, opts), len(blocks))
+	var segTexts []string
+	var topics []models.VideoTopic
+	from := 0
+	for _, to := range bounds {
+		seg := blocks[from:to]
+		parts := make([]string, len(seg))
+		count := 0
+		for i, b := range seg {
+			parts[i] = b.Text
+			count += b.Captions
+		}
+		text := strings.Join(parts, " ")
+		mean := meanVector(vecs[from:to])
+		emb := make([]float32, len(mean))
+		for i, x := range mean {
+			emb[i] = float32(x)
+		}
+		v := pgvector.NewVector(database.NormalizeL2(emb))
+		topics = append(topics, models.VideoTopic{
+			VideoID:      videoID,
+			TopicIndex:   len(topics),
+			StartTime:    seg[0].Start,
+			EndTime:      seg[len(seg)-1].End,
+			Excerpt:      topicExcerpt(text),
+			CaptionCount: count,
+			Embedding:    &v,
+		})
+		segTexts = append(segTexts, text)
+		from = to
+	}
+	for i, kw := range topicKeywords(segTexts, 3) {
+		topics[i].Keywords = kw
+		topics[i].Label = strings.Join(kw, ", ")
+	}
+
+	if err := vp.db.ReplaceVideoTopics(videoID, topics); err != nil {
+		return fmt.Errorf("failed to store topic timeline: %v", err)
+	}
+	log.Printf("[topics] video_id=%d: %d topics from %d caption windows", videoID, len(topics), len(blocks))
+	return nil
+}
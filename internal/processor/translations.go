@@ -0,0 +1,143 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// languageCodeRe matches the ISO 639-1 and 639-3 codes the translation model uses
+var languageCodeRe = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// ValidLanguageCode reports whether code is a lowercase two- or three-letter language code
+func ValidLanguageCode(code string) bool {
+	return languageCodeRe.MatchString(code)
+}
+
+// captionTranslationLanguages are the languages caption extraction has captions translated into
+// (CAPTION_TRANSLATION_LANGUAGES, a comma-separated list such as "es,fr,de"; empty by default)
+func captionTranslationLanguages() []string {
+	var langs []string
+	for _, l := range strings.Split(os.Getenv("CAPTION_TRANSLATION_LANGUAGES"), ",") {
+		if l = strings.ToLower(strings.TrimSpace(l)); ValidLanguageCode(l) {
+			langs = append(langs, l)
+		}
+	}
+	return langs
+}
+
+// captionTranslationAuto reports whether caption extraction enqueues translation jobs, i.e. whether
+// CAPTION_TRANSLATION_LANGUAGES names any language
+func captionTranslationAuto() bool {
+	return len(captionTranslationLanguages()) > 0
+}
+
+// runTranslator translates texts from source to target with the translation runner, returning the
+// translations in order and the model that produced them
+func runTranslator(ctx context.Context, texts []string, source, target string) ([]string, string, error) {
+	b, _ := json.Marshal(map[string]interface{}{"texts": texts, "source_lang": source, "target_lang": target})
+	cmd := exec.CommandContext(ctx, "python3", "/root/internal/embeddings/translate_runner.py")
+	cmd.Stdin = bytes.NewReader(b)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, "", fmt.Errorf("translate_runner failed: %v; stderr: %s", err, stderr.String())
+	}
+	var resp struct {
+		Model        string   `json:"model"`
+		Translation  *string  `json:"translation"`
+		Translations []string `json:"translations"`
+		Error        string   `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse translate_runner output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, "", fmt.Errorf("translate_runner error: %s", resp.Error)
+	}
+	// The runner returns a single text as "translation"
+	if resp.Translation != nil {
+		resp.Translations = []string{*resp.Translation}
+	}
+	if len(resp.Translations) != len(texts) {
+		return nil, "", fmt.Errorf("translate_runner returned %d translations for %d texts", len(resp.Translations), len(texts))
+	}
+	return resp.Translations, resp.Model, nil
+}
+
+// enqueueCaptionTranslations schedules a translation job for each language in CAPTION_TRANSLATION_LANGUAGES
+func (vp *VideoProcessor) enqueueCaptionTranslations(videoID uint) {
+	for _, lang := range captionTranslationLanguages() {
+		if _, err := vp.jobQueue.Enqueue(queue.JobTypeCaptionTranslation, map[string]interface{}{"video_id": videoID, "language": lang}); err != nil {
+			vp.warnf("Failed to enqueue caption translation job for video %d (%s): %v", videoID, lang, err)
+		}
+	}
+}
+
+// ProcessCaptionTranslation translates a video's captions into language and stores them as that
+// language's caption track, replacing any earlier one. Captions are translated from their own
+// language (English when unset); captions already in the target language are kept as they are.
+// Payload: {"video_id": 6, "language": "es"}
+func (vp *VideoProcessor) ProcessCaptionTranslation(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	target, _ := payload["language"].(string)
+	target = strings.ToLower(strings.TrimSpace(target))
+	if !ValidLanguageCode(target) {
+		return fmt.Errorf("invalid language %q in payload", target)
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	if len(captions) == 0 {
+		log.Printf("[translate] video_id=%d: no captions", videoID)
+		return nil
+	}
+
+	rows := make([]models.CaptionTranslation, 0, len(captions))
+	bySource := map[string][]models.Caption{}
+	for _, c := range captions {
+		source := strings.ToLower(strings.TrimSpace(c.Language))
+		if source == "" {
+			source = "en"
+		}
+		if source == target {
+			rows = append(rows, models.CaptionTranslation{VideoID: videoID, CaptionID: c.ID, Language: target,
+				Text: c.Text, StartTime: c.StartTime, EndTime: c.EndTime})
+			continue
+		}
+		bySource[source] = append(bySource[source], c)
+	}
+	for source, group := range bySource {
+		texts := make([]string, len(group))
+		for i, c := range group {
+			texts[i] = c.Text
+		}
+		translated, model, err := runTranslator(vp.context(), texts, source, target)
+		if err != nil {
+			return fmt.Errorf("failed to translate captions from %s to %s: %v", source, target, err)
+		}
+		for i, c := range group {
+			rows = append(rows, models.CaptionTranslation{VideoID: videoID, CaptionID: c.ID, Language: target,
+				Text: strings.TrimSpace(translated[i]), StartTime: c.StartTime, EndTime: c.EndTime, Model: model})
+		}
+	}
+	if err := vp.db.ReplaceCaptionTranslations(videoID, target, rows); err != nil {
+		return fmt.Errorf("failed to store caption translations: %v", err)
+	}
+	log.Printf("[translate] video_id=%d: %d captions into %s (%d source languages)", videoID, len(rows), target, len(bySource))
+	return nil
+}
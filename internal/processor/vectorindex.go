@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"goodclips-server/internal/queue"
+)
+
+// vectorIndexAuto reports whether workers check the ANN indexes of the scene embedding columns when
+// they start (VECTOR_INDEX_AUTO, default true)
+func vectorIndexAuto() bool {
+	return os.Getenv("VECTOR_INDEX_AUTO") != "false"
+}
+
+// ScheduleVectorIndexes queues a vector_index job creating missing or outdated ANN indexes; several
+// workers starting within the same hour queue it only once
+func (vp *VideoProcessor) ScheduleVectorIndexes() error {
+	if vp.jobQueue == nil || !vectorIndexAuto() {
+		return nil
+	}
+	now := time.Now().UTC()
+	key := fmt.Sprintf("vector_index:%d", now.Truncate(time.Hour).Unix())
+	if _, err := vp.jobQueue.EnqueueOnce(key, queue.JobTypeVectorIndex, map[string]interface{}{}, now); err != nil {
+		return fmt.Errorf("failed to schedule vector index check: %v", err)
+	}
+	return nil
+}
+
+// ProcessVectorIndex brings the ANN indexes of the payload's modalities (all when empty) in line with
+// their configuration, rebuilding current ones too with force, and records each index's status as
+// the job's indexes result
+func (vp *VideoProcessor) ProcessVectorIndex(payload map[string]interface{}) error {
+	var modalities []string
+	if raw, ok := payload["modalities"].([]interface{}); ok {
+		for _, m := range raw {
+			if s, ok := m.(string); ok {
+				modalities = append(modalities, s)
+			}
+		}
+	}
+	force, _ := payload["force"].(bool)
+	indexes, err := vp.db.EnsureVectorIndexes(force, modalities...)
+	if indexes != nil {
+		vp.setResult("indexes", indexes)
+	}
+	for _, ix := range indexes {
+		if ix.Action != "" && ix.Action != "unchanged" {
+			log.Printf("[vector_index] %s: %s %s", ix.Column, ix.Action, ix.Type)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ensure vector indexes: %v", err)
+	}
+	return nil
+}
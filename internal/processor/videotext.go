@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"goodclips-server/internal/models"
+)
+
+// videoText is the text embedded for video-level search: title (or file name), synopsis/description
+// from metadata, and tags
+func videoText(video *models.Video) string {
+	var parts []string
+	if video.Title != nil && strings.TrimSpace(*video.Title) != "" {
+		parts = append(parts, strings.TrimSpace(*video.Title))
+	} else {
+		parts = append(parts, strings.TrimSuffix(video.Filename, filepath.Ext(video.Filename)))
+	}
+	for _, key := range []string{"synopsis", "description"} {
+		if s, ok := video.Metadata[key].(string); ok && strings.TrimSpace(s) != "" {
+			parts = append(parts, strings.TrimSpace(s))
+			break
+		}
+	}
+	if len(video.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(video.Tags, ", "))
+	}
+	return strings.Join(parts, ". ")
+}
+
+// embedVideoText computes and stores the video-level text embedding
+func (vp *VideoProcessor) embedVideoText(video *models.Video) error {
+	text := videoText(video)
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	vec, err := embedText(vp.context(), text, "passage")
+	if err != nil {
+		return err
+	}
+	if err := vp.db.UpdateVideoTextEmbedding(video.ID, vec); err != nil {
+		return fmt.Errorf("failed to store video text embedding: %v", err)
+	}
+	vp.countEmbeddings("video_text", 1)
+	log.Printf("[embeddings] video_id=%d: stored video-level text embedding (%d chars)", video.ID, len(text))
+	return nil
+}
+
+// embedText runs the text embedding runner on one text; mode is "query" or "passage"
+func embedText(ctx context.Context, text, mode string) ([]float32, error) {
+	vecs, err := embedTexts(ctx, []string{text}, mode)
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// embedTexts embeds a batch of texts with the active text model in one runner invocation, returning
+// vectors in input order
+func embedTexts(ctx context.Context, texts []string, mode string) ([][]float32, error) {
+	return embedTextsWithModel(ctx, texts, mode, ActiveTextModelID())
+}
+
+// embedTextsWithModel is embedTexts with an explicit model ("" for the runner default)
+func embedTextsWithModel(ctx context.Context, texts []string, mode, modelID string) ([][]float32, error) {
+	payload := map[string]interface{}{"texts": texts, "mode": mode}
+	if modelID != "" {
+		payload["model_id"] = modelID
+	}
+	vecs := make([][]float32, len(texts))
+	_, err := streamRunner(ctx, "/root/internal/embeddings/text_embed_runner.py", payload, func(i int, vec []float32) error {
+		if i < 0 || i >= len(vecs) {
+			return fmt.Errorf("text_embed_runner returned an embedding for text %d of %d", i, len(texts))
+		}
+		vecs[i] = vec
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vecs {
+		if v == nil {
+			return nil, fmt.Errorf("text_embed_runner returned no embedding for text %d of %d", i, len(texts))
+		}
+	}
+	return vecs, nil
+}
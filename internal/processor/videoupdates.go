@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"goodclips-server/internal/models"
+)
+
+// saveVideo stores the listed metadata keys of video, plus whatever set changes, over the current
+// row: on a concurrent update it reloads the video and reapplies them rather than overwriting the
+// other writer's changes. video is then refreshed from the saved row.
+func (vp *VideoProcessor) saveVideo(video *models.Video, metadataKeys []string, set func(v *models.Video)) error {
+	values := make(map[string]interface{}, len(metadataKeys))
+	for _, k := range metadataKeys {
+		if val, ok := video.Metadata[k]; ok {
+			values[k] = val
+		}
+	}
+	saved, err := vp.db.UpdateVideoWithRetry(video.ID, func(v *models.Video) error {
+		if len(values) > 0 && v.Metadata == nil {
+			v.Metadata = models.JSONObject{}
+		}
+		for k, val := range values {
+			v.Metadata[k] = val
+		}
+		if set != nil {
+			set(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*video = *saved
+	return nil
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ErrQueryClipTooLong rejects query clips longer than QueryClipMaxSecs
+var ErrQueryClipTooLong = errors.New("query clip too long")
+
+// QueryClipMaxSecs is the longest clip accepted as a search query (QUERY_CLIP_MAX_SECS, default
+// 60): a query clip is embedded as one scene, so longer clips blur into an average of many shots
+func QueryClipMaxSecs() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("QUERY_CLIP_MAX_SECS"), 64); err == nil && v > 0 {
+		return v
+	}
+	return 60
+}
+
+// EmbedQueryClip embeds a short video file as a single scene with the configured visual backend,
+// in the space of scenes.visual_embedding, so the clip can be searched for in the library
+func (vp *VideoProcessor) EmbedQueryClip(path string) ([]float32, error) {
+	backend := embeddingBackend()
+	if backend != "iv2" && backend != "internvl35" {
+		return nil, fmt.Errorf("embedding backend %s does not embed video", backend)
+	}
+	duration, err := vp.ffmpegClient.GetVideoDuration(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clip duration: %v", err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("clip has no duration")
+	}
+	if limit := QueryClipMaxSecs(); duration > limit {
+		return nil, fmt.Errorf("%w: %.1fs (limit %.0fs)", ErrQueryClipTooLong, duration, limit)
+	}
+
+	req, dim := iv2Request(backend, path, []sceneRange{{SceneIndex: 0, Start: 0, End: duration}})
+	var vec []float32
+	if _, err := streamRunner(vp.context(), "/root/internal/embeddings/iv2_runner.py", req, func(_ int, v []float32) error {
+		if len(v) != dim {
+			return fmt.Errorf("%w: embedding_dim=%d != %d", errEmbeddingDim, len(v), dim)
+		}
+		vec = v
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if vec == nil {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
+	return vec, nil
+}
+
+// EmbedVisualTextQuery embeds a text query with InternVideo2's text encoder, in the space of
+// scenes.visual_embedding. Only the iv2 backend has such an encoder; InternVL3.5 vision features
+// have no aligned text side.
+func (vp *VideoProcessor) EmbedVisualTextQuery(text string) ([]float32, error) {
+	backend := embeddingBackend()
+	if backend != "iv2" {
+		return nil, fmt.Errorf("embedding backend %s cannot embed text into visual_embedding", backend)
+	}
+	req, dim := iv2Request(backend, "", nil)
+	delete(req, "video_path")
+	delete(req, "scenes")
+	req["mode"] = "text"
+	req["texts"] = []string{text}
+	var vec []float32
+	if _, err := streamRunner(vp.context(), "/root/internal/embeddings/iv2_runner.py", req, func(_ int, v []float32) error {
+		if len(v) != dim {
+			return fmt.Errorf("%w: embedding_dim=%d != %d", errEmbeddingDim, len(v), dim)
+		}
+		vec = v
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if vec == nil {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
+	return vec, nil
+}
@@ -0,0 +1,35 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+)
+
+// WatermarkFor returns the watermark burned into an export or shared cut of video for viewer, with
+// {user_id} and {timestamp} filled in, or nil for none. A share link's watermark (share) wins; else
+// the video's project watermark (see models.ProjectWatermark) replaces the server default
+// (WATERMARK_*), or turns it off.
+func (vp *VideoProcessor) WatermarkFor(video *models.Video, share *ffmpeg.Watermark, viewer string) (*ffmpeg.Watermark, error) {
+	wm := ffmpeg.WatermarkFromEnv()
+	if !share.Empty() {
+		wm = share
+	} else {
+		pw, err := vp.db.GetProjectWatermark(videoProject(video))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project watermark: %v", err)
+		}
+		if pw != nil && !pw.Enabled {
+			return nil, nil
+		}
+		if pw != nil && (pw.Text != "" || pw.ImagePath != "") {
+			wm = &ffmpeg.Watermark{Text: pw.Text, ImagePath: pw.ImagePath, Position: pw.Position, Opacity: pw.Opacity, FontSize: pw.FontSize}
+		}
+	}
+	if wm.Empty() {
+		return nil, nil
+	}
+	return wm.Render(viewer, time.Now()), nil
+}
@@ -0,0 +1,210 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"goodclips-server/internal/ffmpeg"
+	"goodclips-server/internal/models"
+	"goodclips-server/internal/queue"
+)
+
+// wordRe matches a spoken word: letters and digits with inner apostrophes
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}]+(?:'[\p{L}\p{N}]+)*`)
+
+// PhraseWords splits text into lowercase words, the form in which word timings are matched
+func PhraseWords(s string) []string {
+	return wordRe.FindAllString(strings.ToLower(s), -1)
+}
+
+// wordAlignmentAuto reports whether caption extraction enqueues a word alignment job (WORD_ALIGNMENT_AUTO, default false)
+func wordAlignmentAuto() bool {
+	if v, err := strconv.ParseBool(os.Getenv("WORD_ALIGNMENT_AUTO")); err == nil {
+		return v
+	}
+	return false
+}
+
+// alignedWord is the timing of one word from the word alignment runner
+type alignedWord struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Score   float64 `json:"score"`
+	Aligned bool    `json:"aligned"`
+}
+
+// alignedCaption is the word timings of one caption, in the order its words were sent
+type alignedCaption struct {
+	CaptionID uint          `json:"caption_id"`
+	Words     []alignedWord `json:"words"`
+}
+
+// runWordAligner force-aligns caption words against the video's audio
+func runWordAligner(ctx context.Context, req map[string]interface{}) ([]alignedCaption, error) {
+	b, _ := json.Marshal(req)
+	cmd := exec.CommandContext(ctx, "python3", "/root/internal/embeddings/word_align_runner.py")
+	cmd.Stdin = bytes.NewReader(b)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("word_align_runner failed: %v; stderr: %s", err, stderr.String())
+	}
+	var resp struct {
+		Results []alignedCaption `json:"results"`
+		Error   string           `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse word_align_runner output: %v; raw: %s", err, string(out))
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("word_align_runner error: %s", resp.Error)
+	}
+	return resp.Results, nil
+}
+
+// ProcessWordAlignment times every caption word of a video by forced alignment against its audio,
+// replacing earlier timings. Words the aligner cannot place are interpolated within their caption.
+// Payload: {"video_id": 6}
+func (vp *VideoProcessor) ProcessWordAlignment(payload map[string]interface{}) error {
+	videoID, ok := queue.PayloadVideoID(payload)
+	if !ok {
+		return fmt.Errorf("missing or invalid video_id in payload")
+	}
+	video, err := vp.db.GetVideoByID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %v", err)
+	}
+	captions, err := vp.db.GetCaptionsByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("failed to load captions: %v", err)
+	}
+	sort.SliceStable(captions, func(i, j int) bool { return captions[i].StartTime < captions[j].StartTime })
+
+	captionWords := make(map[uint][]string, len(captions))
+	reqCaptions := make([]map[string]interface{}, 0, len(captions))
+	for _, c := range captions {
+		ws := wordRe.FindAllString(c.Text, -1)
+		if len(ws) == 0 {
+			continue
+		}
+		captionWords[c.ID] = ws
+		reqCaptions = append(reqCaptions, map[string]interface{}{
+			"caption_id": c.ID,
+			"start":      c.StartTime,
+			"end":        c.EndTime,
+			"words":      ws,
+		})
+	}
+	if len(reqCaptions) == 0 {
+		log.Printf("[words] video_id=%d: no caption words; clearing word timings", videoID)
+		return vp.db.ReplaceCaptionWords(videoID, nil)
+	}
+
+	results, err := runWordAligner(vp.context(), map[string]interface{}{"video_path": video.Filepath, "captions": reqCaptions})
+	if err != nil {
+		return err
+	}
+	timings := make(map[uint][]alignedWord, len(results))
+	for _, r := range results {
+		timings[r.CaptionID] = r.Words
+	}
+
+	var words []models.CaptionWord
+	aligned := 0
+	for _, c := range captions {
+		ws, ts := captionWords[c.ID], timings[c.ID]
+		if len(ws) == 0 {
+			continue
+		}
+		if len(ts) != len(ws) {
+			vp.warnf("word_align_runner returned %d timings for %d words of caption %d", len(ts), len(ws), c.ID)
+			continue
+		}
+		for i, w := range ws {
+			words = append(words, models.CaptionWord{
+				VideoID:   videoID,
+				CaptionID: c.ID,
+				Position:  len(words),
+				WordIndex: i,
+				Word:      w,
+				Norm:      strings.ToLower(w),
+				StartTime: ts[i].Start,
+				EndTime:   ts[i].End,
+				Score:     ts[i].Score,
+				Aligned:   ts[i].Aligned,
+			})
+			if ts[i].Aligned {
+				aligned++
+			}
+		}
+	}
+	if err := vp.db.ReplaceCaptionWords(videoID, words); err != nil {
+		return fmt.Errorf("failed to store word timings: %v", err)
+	}
+	log.Printf("[words] video_id=%d: timed %d words of %d captions (%d aligned)", videoID, len(words), len(captions), aligned)
+	return nil
+}
+
+// ExportCut renders [start, end) of a video into an MP4 under highlightDir(), reusing an earlier
+// render of the same range
+func (vp *VideoProcessor) ExportCut(video *models.Video, start, end float64) (string, error) {
+	dir := filepath.Join(highlightDir(), "cuts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cuts directory: %v", err)
+	}
+	out := filepath.Join(dir, fmt.Sprintf("video_%d_%.3f_%.3f.mp4", video.ID, start, end))
+	if info, err := os.Stat(out); err == nil && info.Size() > 0 {
+		return out, nil
+	}
+	withAudio, err := vp.ffmpegClient.HasAudioStream(video.Filepath)
+	if err != nil {
+		withAudio = false
+	}
+	// Render next to the final name and rename, so concurrent requests never serve a partial file
+	tmp, err := os.CreateTemp(dir, "cut_*.mp4")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cut file: %v", err)
+	}
+	tmp.Close()
+	segments := []ffmpeg.ReelSegment{{Path: video.Filepath, Start: start, End: end}}
+	if err := vp.ffmpegClient.RenderHighlightReel(segments, tmp.Name(), 1280, 720, withAudio, ffmpeg.CensorNone); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), out); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to store cut: %v", err)
+	}
+	return out, nil
+}
+
+// ExportWatermarkedCut renders [start, end) of a video into an MP4 under highlightDir() with wm
+// burned in. The watermark names the viewer and the time, so the render is not reused: the caller
+// removes the file once served.
+func (vp *VideoProcessor) ExportWatermarkedCut(video *models.Video, start, end float64, wm *ffmpeg.Watermark) (string, error) {
+	dir := filepath.Join(highlightDir(), "cuts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cuts directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(dir, "cut_wm_*.mp4")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cut file: %v", err)
+	}
+	tmp.Close()
+	if err := vp.ffmpegClient.RenderClip(video.Filepath, tmp.Name(), start, end, wm, nil, ffmpeg.CensorNone); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// breakersKey is the hash of dependency names (e.g. a runner script) to their BreakerState
+const breakersKey = "breakers"
+
+// Circuit breaker states
+const (
+	// BreakerClosed: the dependency is healthy and calls go through
+	BreakerClosed = "closed"
+	// BreakerOpen: the dependency failed repeatedly; calls are refused until RetryAt
+	BreakerOpen = "open"
+	// BreakerHalfOpen: the cooldown is over and a single trial call (or health probe) decides
+	// whether the breaker closes or opens again
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerState is the circuit breaker of one dependency. It lives in Redis, so every worker sees a
+// breaker another worker opened.
+type BreakerState struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Failures int    `json:"failures"`
+	// OpenedAt is when the breaker last opened
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	// RetryAt is when an open breaker lets a trial call through
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// BreakerPolicy decides when a breaker opens and for how long
+type BreakerPolicy struct {
+	// Threshold is the number of consecutive failures that opens the breaker
+	Threshold int
+	// Cooldown is how long an open breaker refuses calls before a trial call
+	Cooldown time.Duration
+}
+
+// BreakerPolicyFromEnv reads the breaker policy: RUNNER_BREAKER_THRESHOLD (default 3; 0 disables
+// the breakers) and RUNNER_BREAKER_COOLDOWN_SECS (default 120)
+func BreakerPolicyFromEnv() BreakerPolicy {
+	p := BreakerPolicy{Threshold: 3, Cooldown: 2 * time.Minute}
+	if n, err := strconv.Atoi(os.Getenv("RUNNER_BREAKER_THRESHOLD")); err == nil && n >= 0 {
+		p.Threshold = n
+	}
+	if secs, err := strconv.Atoi(os.Getenv("RUNNER_BREAKER_COOLDOWN_SECS")); err == nil && secs > 0 {
+		p.Cooldown = time.Duration(secs) * time.Second
+	}
+	return p
+}
+
+func breakerTrialKey(name string) string {
+	return "breaker:" + name + ":trial"
+}
+
+// Breaker returns the breaker of a dependency; one that never failed is closed
+func (q *Queue) Breaker(name string) (*BreakerState, error) {
+	b, err := q.client.HGet(q.ctx, breakersKey, name).Bytes()
+	if err == redis.Nil {
+		return &BreakerState{Name: name, State: BreakerClosed}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read breaker %s: %w", name, err)
+	}
+	var st BreakerState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, fmt.Errorf("failed to decode breaker %s: %w", name, err)
+	}
+	return &st, nil
+}
+
+// Breakers returns every breaker that has recorded a failure, by name
+func (q *Queue) Breakers() ([]BreakerState, error) {
+	m, err := q.client.HGetAll(q.ctx, breakersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read breakers: %w", err)
+	}
+	out := make([]BreakerState, 0, len(m))
+	for _, v := range m {
+		var st BreakerState
+		if err := json.Unmarshal([]byte(v), &st); err == nil {
+			out = append(out, st)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (q *Queue) saveBreaker(st *BreakerState) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := q.client.HSet(q.ctx, breakersKey, st.Name, b).Err(); err != nil {
+		return fmt.Errorf("failed to update breaker %s: %w", st.Name, err)
+	}
+	return nil
+}
+
+// AllowCall reports whether a call to the dependency may run. An open breaker whose cooldown is
+// over lets exactly one caller through as a trial (half-open) until that call's outcome is
+// recorded or the cooldown passes again; every other caller is refused. The returned state says
+// until when a refused caller should wait.
+func (q *Queue) AllowCall(name string, policy BreakerPolicy) (bool, *BreakerState, error) {
+	st, err := q.Breaker(name)
+	if err != nil || st.State == BreakerClosed {
+		// A breaker that cannot be read does not stop work
+		return true, st, err
+	}
+	now := time.Now()
+	if st.State == BreakerOpen && st.RetryAt != nil && now.Before(*st.RetryAt) {
+		return false, st, nil
+	}
+	won, err := q.client.SetNX(q.ctx, breakerTrialKey(name), 1, policy.Cooldown).Result()
+	if err != nil {
+		return true, st, fmt.Errorf("failed to claim breaker trial %s: %w", name, err)
+	}
+	if !won {
+		retryAt := now.Add(policy.Cooldown)
+		if ttl, err := q.client.TTL(q.ctx, breakerTrialKey(name)).Result(); err == nil && ttl > 0 {
+			retryAt = now.Add(ttl)
+		}
+		st.RetryAt = &retryAt
+		return false, st, nil
+	}
+	st.State = BreakerHalfOpen
+	return true, st, q.saveBreaker(st)
+}
+
+// RecordCallFailure counts a failed call to the dependency. The breaker opens for policy.Cooldown
+// once policy.Threshold calls failed in a row, and reopens at once when a trial call fails. It
+// returns the breaker after the failure.
+func (q *Queue) RecordCallFailure(name string, callErr error, policy BreakerPolicy) (*BreakerState, error) {
+	st, err := q.Breaker(name)
+	if err != nil {
+		return nil, err
+	}
+	st.Failures++
+	if callErr != nil {
+		st.LastError = callErr.Error()
+	}
+	if st.State != BreakerClosed || (policy.Threshold > 0 && st.Failures >= policy.Threshold) {
+		now := time.Now().UTC()
+		retryAt := now.Add(policy.Cooldown)
+		if st.State == BreakerClosed {
+			st.OpenedAt = &now
+		}
+		st.State = BreakerOpen
+		st.RetryAt = &retryAt
+	}
+	if err := q.saveBreaker(st); err != nil {
+		return nil, err
+	}
+	q.client.Del(q.ctx, breakerTrialKey(name))
+	return st, nil
+}
+
+// RecordCallSuccess closes the dependency's breaker and forgets its failures
+func (q *Queue) RecordCallSuccess(name string) error {
+	pipe := q.client.TxPipeline()
+	pipe.HDel(q.ctx, breakersKey, name)
+	pipe.Del(q.ctx, breakerTrialKey(name))
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return fmt.Errorf("failed to close breaker %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeferJob puts a dequeued job back as pending, to run at runAt, because a dependency it needs is
+// unavailable. The attempt is not counted; reason is kept as the job's message.
+func (q *Queue) DeferJob(jobID string, runAt time.Time, reason string) error {
+	job, err := q.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == JobStatusRunning && job.Attempts > 0 {
+		job.Attempts--
+	}
+	job.Status = JobStatusPending
+	job.Progress = 0
+	job.StartedAt = nil
+	job.ErrorMessage = &reason
+	job.RunAt = &runAt
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", jobID), "data", jobBytes).Err(); err != nil {
+		return fmt.Errorf("failed to update job data: %w", err)
+	}
+	q.client.ZRem(q.ctx, runningJobsKey, jobID)
+	q.releaseLease(jobID)
+	if err := q.client.ZAdd(q.ctx, delayedJobsKey, &redis.Z{Score: float64(runAt.Unix()), Member: jobBytes}).Err(); err != nil {
+		return fmt.Errorf("failed to defer job: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// cancelTTL is how long a cancellation request outlives the job it targets
+const cancelTTL = 24 * time.Hour
+
+func jobCancelKey(jobID string) string {
+	return fmt.Sprintf("job_cancel:%s", jobID)
+}
+
+// ErrJobFinished is returned by CancelJob for jobs that completed, failed or were already cancelled
+var ErrJobFinished = errors.New("job has already finished")
+
+// CancelJob requests that a job stop. A pending job is marked cancelled at once and skipped when a
+// worker dequeues it; a running job is flagged, and the worker running it kills its subprocesses
+// and marks it cancelled (see CancelRequested).
+func (q *Queue) CancelJob(jobID string) (*Job, error) {
+	job, err := q.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobStatusPending && job.Status != JobStatusRunning {
+		return nil, ErrJobFinished
+	}
+	if err := q.client.Set(q.ctx, jobCancelKey(jobID), 1, cancelTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to flag job for cancellation: %w", err)
+	}
+	if job.Status == JobStatusPending {
+		msg := "cancelled by request"
+		if err := q.UpdateJobStatus(jobID, JobStatusCancelled, job.Progress, &msg); err != nil {
+			return nil, err
+		}
+		return q.GetJob(jobID)
+	}
+	return job, nil
+}
+
+// CancelRequested reports whether cancellation of a job was requested
+func (q *Queue) CancelRequested(jobID string) bool {
+	n, err := q.client.Exists(q.ctx, jobCancelKey(jobID)).Result()
+	return err == nil && n > 0
+}
+
+// clearCancel withdraws a cancellation request, so a retried job runs
+func (q *Queue) clearCancel(jobID string) error {
+	return q.client.Del(q.ctx, jobCancelKey(jobID)).Err()
+}
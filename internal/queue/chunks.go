@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func chunkGroupKey(group string) string {
+	return fmt.Sprintf("chunks:%s", group)
+}
+
+// StoreChunkResult records the result of one chunk of a job split across several jobs. It reports
+// whether this call completed the group: once all count chunks are stored, exactly one caller gets
+// true and is responsible for stitching the results.
+func (q *Queue) StoreChunkResult(group string, index, count int, result []byte, ttl time.Duration) (bool, error) {
+	key := chunkGroupKey(group)
+	pipe := q.client.TxPipeline()
+	pipe.HSet(q.ctx, key, strconv.Itoa(index), result)
+	pipe.Expire(q.ctx, key, ttl)
+	stored := pipe.HLen(q.ctx, key)
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return false, fmt.Errorf("failed to store chunk result: %w", err)
+	}
+	if int(stored.Val()) < count {
+		return false, nil
+	}
+	won, err := q.client.SetNX(q.ctx, key+":stitched", 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim chunk group: %w", err)
+	}
+	return won, nil
+}
+
+// ChunkResults returns the stored chunk results of a group keyed by chunk index
+func (q *Queue) ChunkResults(group string) (map[int][]byte, error) {
+	raw, err := q.client.HGetAll(q.ctx, chunkGroupKey(group)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunk results: %w", err)
+	}
+	results := make(map[int][]byte, len(raw))
+	for field, data := range raw {
+		index, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		results[index] = []byte(data)
+	}
+	return results, nil
+}
+
+// ClearChunkGroup drops a group's stored results once they have been stitched
+func (q *Queue) ClearChunkGroup(group string) error {
+	return q.client.Del(q.ctx, chunkGroupKey(group)).Err()
+}
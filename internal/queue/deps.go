@@ -0,0 +1,162 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// blockedJobsKey is the set of pending jobs waiting for their dependencies; they are on no queue
+const blockedJobsKey = "jobs:blocked"
+
+// jobDependentsKey is the set of jobs that depend on jobID
+func jobDependentsKey(jobID string) string {
+	return fmt.Sprintf("job_dependents:%s", jobID)
+}
+
+// EnqueueAfter adds a job that becomes dequeueable once every job in dependsOn has settled. A
+// completed or cancelled dependency (including one skipped by a feature flag) satisfies it; a failed
+// one fails the job and, in turn, the jobs depending on it. Without dependencies it is Enqueue.
+func (q *Queue) EnqueueAfter(jobType JobType, payload map[string]interface{}, dependsOn []string) (*Job, error) {
+	if len(dependsOn) == 0 {
+		return q.Enqueue(jobType, payload)
+	}
+	job := &Job{
+		ID:        generateJobID(),
+		Type:      jobType,
+		Payload:   payload,
+		Status:    JobStatusPending,
+		CreatedAt: time.Now(),
+		DependsOn: dependsOn,
+	}
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", job.ID), "data", jobBytes).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store job data: %w", err)
+	}
+	if videoID, ok := PayloadVideoID(payload); ok {
+		if err := q.client.SAdd(q.ctx, videoJobsKey(videoID), job.ID).Err(); err != nil {
+			return nil, fmt.Errorf("failed to index job: %w", err)
+		}
+	}
+	if err := q.block(job.ID, dependsOn); err != nil {
+		return nil, err
+	}
+	// Dependencies may have settled already
+	if err := q.releaseIfReady(job.ID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// AddDependencies makes the jobs depending on jobID also wait for extra, e.g. when a job hands its
+// work off to jobs it enqueued. It must be called before jobID settles.
+func (q *Queue) AddDependencies(jobID string, extra []string) error {
+	if len(extra) == 0 {
+		return nil
+	}
+	dependents, err := q.client.SMembers(q.ctx, jobDependentsKey(jobID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list dependents: %w", err)
+	}
+	for _, id := range dependents {
+		job, err := q.GetJob(id)
+		if err != nil {
+			continue
+		}
+		job.DependsOn = append(job.DependsOn, extra...)
+		jobBytes, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job: %w", err)
+		}
+		if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", id), "data", jobBytes).Err(); err != nil {
+			return fmt.Errorf("failed to update job data: %w", err)
+		}
+		if err := q.block(id, extra); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// block records that jobID waits for dependsOn
+func (q *Queue) block(jobID string, dependsOn []string) error {
+	pipe := q.client.TxPipeline()
+	pipe.SAdd(q.ctx, blockedJobsKey, jobID)
+	for _, dep := range dependsOn {
+		pipe.SAdd(q.ctx, jobDependentsKey(dep), jobID)
+	}
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return fmt.Errorf("failed to record job dependencies: %w", err)
+	}
+	return nil
+}
+
+// settleDependents re-examines the jobs depending on a job that just completed, failed or was cancelled
+func (q *Queue) settleDependents(jobID string) error {
+	key := jobDependentsKey(jobID)
+	dependents, err := q.client.SMembers(q.ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list dependents: %w", err)
+	}
+	for _, id := range dependents {
+		if err := q.releaseIfReady(id); err != nil {
+			return err
+		}
+	}
+	return q.client.Del(q.ctx, key).Err()
+}
+
+// releaseIfReady puts a blocked job on its queue once all its dependencies settled, or fails it when
+// one of them failed. Removing the job from blockedJobsKey decides which caller releases it, so
+// dependencies settling concurrently never push it twice. Dependencies whose data expired count as
+// settled.
+func (q *Queue) releaseIfReady(jobID string) error {
+	job, err := q.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status != JobStatusPending {
+		// Cancelled while blocked
+		return q.client.SRem(q.ctx, blockedJobsKey, jobID).Err()
+	}
+	var failed string
+	for _, depID := range job.DependsOn {
+		dep, err := q.GetJob(depID)
+		if err != nil {
+			continue
+		}
+		switch dep.Status {
+		case JobStatusPending, JobStatusRunning:
+			return nil
+		case JobStatusFailed:
+			if failed == "" {
+				failed = depID
+			}
+		}
+	}
+
+	removed, err := q.client.SRem(q.ctx, blockedJobsKey, jobID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to unblock job: %w", err)
+	}
+	if removed == 0 {
+		return nil
+	}
+	if failed != "" {
+		msg := fmt.Sprintf("dependency %s failed", failed)
+		return q.UpdateJobStatus(jobID, JobStatusFailed, 0, &msg)
+	}
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if job.RunAt != nil && job.RunAt.After(time.Now()) {
+		return q.client.ZAdd(q.ctx, delayedJobsKey, &redis.Z{Score: float64(job.RunAt.Unix()), Member: jobBytes}).Err()
+	}
+	return q.client.LPush(q.ctx, fmt.Sprintf("jobs:%s", job.Type), jobBytes).Err()
+}
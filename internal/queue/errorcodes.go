@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrorCode classifies a job failure for triage and decides whether retrying can fix it
+type ErrorCode string
+
+const (
+	// ErrorMissingFile: the source file (or a file derived from it) does not exist
+	ErrorMissingFile ErrorCode = "missing_file"
+	// ErrorDependency: a tool, Python module or model could not be found or loaded
+	ErrorDependency ErrorCode = "dependency_missing"
+	// ErrorTimeout: a step ran out of time
+	ErrorTimeout ErrorCode = "timeout"
+	// ErrorMalformedMedia: the media could not be opened or decoded
+	ErrorMalformedMedia ErrorCode = "malformed_media"
+	// ErrorRunnerCrash: a subprocess exited abnormally or broke its output protocol
+	ErrorRunnerCrash ErrorCode = "runner_crash"
+	// ErrorInvalidPayload: the job's payload or type is invalid
+	ErrorInvalidPayload ErrorCode = "invalid_payload"
+	// ErrorRefused: the operation is not allowed, e.g. under a legal hold
+	ErrorRefused ErrorCode = "refused"
+	// ErrorInternal: anything else, e.g. database or Redis errors
+	ErrorInternal ErrorCode = "internal"
+)
+
+// Retryable reports whether a failure with this code may succeed when the job runs again.
+// Dependencies count as retryable: a model download or a mount can come back.
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case ErrorMissingFile, ErrorMalformedMedia, ErrorInvalidPayload, ErrorRefused:
+		return false
+	}
+	return true
+}
+
+// codedError carries an explicit ErrorCode
+type codedError struct {
+	code ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// WithCode tags err with a code, overriding classification by message
+func WithCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// errorPatterns classify untagged errors by their message (lower-cased), first match wins. Most
+// errors cross subprocess or fmt.Errorf("%v") boundaries that drop their type.
+var errorPatterns = []struct {
+	code     ErrorCode
+	patterns []string
+}{
+	{ErrorTimeout, []string{"deadline exceeded", "timed out", "timeout"}},
+	{ErrorMissingFile, []string{"no such file or directory", "file not found", "does not exist"}},
+	{ErrorInvalidPayload, []string{"in payload", "unknown job type"}},
+	{ErrorDependency, []string{"executable file not found", "no module named", "not available", "failed to load model", "failed to load clip", "failed to load clap"}},
+	{ErrorMalformedMedia, []string{"invalid data found when processing input", "moov atom not found", "failed to open video", "failed to open image", "failed to decode audio", "no decodable audio", "failed to parse duration"}},
+	{ErrorRunnerCrash, []string{"signal: ", "exit status", "runner exception", "malformed runner output", "traceback"}},
+}
+
+// CodeOf classifies a job failure: the code given with WithCode, else one derived from the error's
+// type or message, else ErrorInternal
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorTimeout
+	case errors.Is(err, os.ErrNotExist):
+		return ErrorMissingFile
+	case errors.Is(err, exec.ErrNotFound):
+		return ErrorDependency
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range errorPatterns {
+		for _, s := range p.patterns {
+			if strings.Contains(msg, s) {
+				return p.code
+			}
+		}
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return ErrorRunnerCrash
+	}
+	return ErrorInternal
+}
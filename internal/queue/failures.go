@@ -0,0 +1,46 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+func jobFailuresKey(jobType JobType, videoID uint) string {
+	return fmt.Sprintf("failures:%s:%d", jobType, videoID)
+}
+
+// RecordJobFailure counts a failed job of jobType for a video (0 for jobs without one) and returns the
+// number of failures within window of each other
+func (q *Queue) RecordJobFailure(jobType JobType, videoID uint, window time.Duration) (int, error) {
+	key := jobFailuresKey(jobType, videoID)
+	pipe := q.client.TxPipeline()
+	n := pipe.Incr(q.ctx, key)
+	pipe.Expire(q.ctx, key, window)
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return 0, fmt.Errorf("failed to record job failure: %w", err)
+	}
+	return int(n.Val()), nil
+}
+
+// ClearJobFailures resets the failure count once a job of jobType succeeds for the video
+func (q *Queue) ClearJobFailures(jobType JobType, videoID uint) error {
+	return q.client.Del(q.ctx, jobFailuresKey(jobType, videoID)).Err()
+}
+
+// EnqueueOnce schedules a job at runAt unless one was already scheduled under key, so recurring jobs
+// are not duplicated when several workers start. It reports whether the job was enqueued.
+func (q *Queue) EnqueueOnce(key string, jobType JobType, payload map[string]interface{}, runAt time.Time) (bool, error) {
+	ttl := time.Until(runAt) + time.Hour
+	won, err := q.client.SetNX(q.ctx, "scheduled:"+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule %s: %w", key, err)
+	}
+	if !won {
+		return false, nil
+	}
+	if _, err := q.EnqueueAt(jobType, payload, runAt); err != nil {
+		q.client.Del(q.ctx, "scheduled:"+key)
+		return false, err
+	}
+	return true, nil
+}
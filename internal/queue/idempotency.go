@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotentResponse is a cached response replayed for retried requests with the same Idempotency-Key
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Pending     bool   `json:"pending"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+func idempotencyKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
+
+// ReserveIdempotencyKey claims key for a request with the given fingerprint. It returns nil when the
+// key was free (the caller should process the request), otherwise the existing entry, which is either
+// still pending or a completed response to replay.
+func (q *Queue) ReserveIdempotencyKey(key, fingerprint string, ttl time.Duration) (*IdempotentResponse, error) {
+	pending, err := json.Marshal(IdempotentResponse{Fingerprint: fingerprint, Pending: true})
+	if err != nil {
+		return nil, err
+	}
+	ok, err := q.client.SetNX(q.ctx, idempotencyKey(key), pending, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if ok {
+		return nil, nil
+	}
+	data, err := q.client.Get(q.ctx, idempotencyKey(key)).Result()
+	if err == redis.Nil {
+		// expired between SETNX and GET; let the caller retry the reservation
+		return q.ReserveIdempotencyKey(key, fingerprint, ttl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+	var existing IdempotentResponse
+	if err := json.Unmarshal([]byte(data), &existing); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotent response: %w", err)
+	}
+	return &existing, nil
+}
+
+// StoreIdempotentResponse records the final response for key so retries replay it
+func (q *Queue) StoreIdempotentResponse(key string, resp IdempotentResponse, ttl time.Duration) error {
+	resp.Pending = false
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return q.client.Set(q.ctx, idempotencyKey(key), data, ttl).Err()
+}
+
+// ReleaseIdempotencyKey forgets key so the request can be retried (used when processing failed)
+func (q *Queue) ReleaseIdempotencyKey(key string) error {
+	return q.client.Del(q.ctx, idempotencyKey(key)).Err()
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// leasesKey is the sorted set (scored by lease expiry) of dequeued jobs not yet finished. A worker
+// renews its jobs' leases while they run; a job whose lease expired was lost with its worker and is
+// requeued by ReapExpiredLeases. The lease is taken in the same step as the pop (see
+// popLeaseScript), so every dequeued job is delivered at least once.
+const leasesKey = "jobs:leases"
+
+// popLeaseScript pops the oldest job of the first non-empty queue among KEYS[2..] and leases it in
+// KEYS[1] until ARGV[1] (unix seconds) atomically. It returns the job, or nil when all are empty.
+var popLeaseScript = redis.NewScript(`
+for i = 2, #KEYS do
+	local member = redis.call('RPOP', KEYS[i])
+	if member then
+		local ok, job = pcall(cjson.decode, member)
+		if ok and type(job) == 'table' and job.id then
+			redis.call('ZADD', KEYS[1], ARGV[1], job.id)
+		end
+		return member
+	end
+end
+return false
+`)
+
+// dequeueTimeout is how long Dequeue and DequeueAny wait for a job before returning none
+const dequeueTimeout = 5 * time.Second
+
+// dequeuePollInterval is how often a waiting dequeue retries its queues; a script cannot block like
+// BRPOP, so empty queues are polled
+const dequeuePollInterval = 250 * time.Millisecond
+
+// LeaseDuration is how long a dequeued job is held without renewal (JOB_LEASE_SECS, default 120)
+func LeaseDuration() time.Duration {
+	if secs, err := strconv.Atoi(os.Getenv("JOB_LEASE_SECS")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 120 * time.Second
+}
+
+// popAndLease takes the oldest job of the first non-empty queue among queueNames, leased until now +
+// LeaseDuration, waiting up to dequeueTimeout for one. It returns nil when none arrived.
+func (q *Queue) popAndLease(queueNames []string) (*Job, error) {
+	keys := append([]string{leasesKey}, queueNames...)
+	deadline := time.Now().Add(dequeueTimeout)
+	for {
+		expires := time.Now().Add(LeaseDuration())
+		member, err := popLeaseScript.Run(q.ctx, q.client, keys, expires.Unix()).Text()
+		if err == nil {
+			var job Job
+			if err := json.Unmarshal([]byte(member), &job); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+			}
+			return &job, nil
+		}
+		if err != redis.Nil {
+			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+		if time.Now().Add(dequeuePollInterval).After(deadline) {
+			return nil, nil
+		}
+		time.Sleep(dequeuePollInterval)
+	}
+}
+
+// RenewLease extends the lease of a job the caller is still working on. A lease already reaped is
+// not taken again.
+func (q *Queue) RenewLease(jobID string) error {
+	expires := time.Now().Add(LeaseDuration())
+	return q.client.ZAddXX(q.ctx, leasesKey, &redis.Z{Score: float64(expires.Unix()), Member: jobID}).Err()
+}
+
+func (q *Queue) releaseLease(jobID string) {
+	q.client.ZRem(q.ctx, leasesKey, jobID)
+}
+
+// ReapExpiredLeases requeues jobs whose lease expired while they were pending or running, so jobs of
+// crashed workers run again. The lost run counts as an attempt; a job that has used up
+// policy.MaxAttempts is marked failed instead. ZREM decides which reaper handles a job, so concurrent
+// workers never requeue it twice. It returns the number of jobs requeued or failed.
+func (q *Queue) ReapExpiredLeases(policy RetryPolicy) (int, error) {
+	expired, err := q.client.ZRangeByScore(q.ctx, leasesKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired leases: %w", err)
+	}
+	reaped := 0
+	for _, jobID := range expired {
+		removed, err := q.client.ZRem(q.ctx, leasesKey, jobID).Result()
+		if err != nil {
+			return reaped, fmt.Errorf("failed to release lease: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+		job, err := q.GetJob(jobID)
+		if err != nil {
+			// Expired job data; nothing left to requeue
+			continue
+		}
+		if job.Status != JobStatusPending && job.Status != JobStatusRunning {
+			continue
+		}
+		if job.Attempts >= policy.MaxAttempts {
+			msg := "worker lost: lease expired after the last attempt"
+			if err := q.UpdateJobStatus(jobID, JobStatusFailed, 0, &msg); err != nil {
+				return reaped, err
+			}
+			reaped++
+			continue
+		}
+		msg := "worker lost: lease expired, requeued"
+		job.Status = JobStatusPending
+		job.Progress = 0
+		job.StartedAt = nil
+		job.ErrorMessage = &msg
+		jobBytes, err := json.Marshal(job)
+		if err != nil {
+			return reaped, fmt.Errorf("failed to marshal job: %w", err)
+		}
+		if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", jobID), "data", jobBytes).Err(); err != nil {
+			return reaped, fmt.Errorf("failed to update job data: %w", err)
+		}
+		q.client.ZRem(q.ctx, runningJobsKey, jobID)
+		if err := q.client.RPush(q.ctx, fmt.Sprintf("jobs:%s", job.Type), jobBytes).Err(); err != nil {
+			return reaped, fmt.Errorf("failed to requeue job: %w", err)
+		}
+		reaped++
+	}
+	return reaped, nil
+}
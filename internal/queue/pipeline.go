@@ -0,0 +1,40 @@
+package queue
+
+// Stage is a node of the per-video processing pipeline
+type Stage struct {
+	Type      JobType   `json:"type"`
+	DependsOn []JobType `json:"depends_on"`
+}
+
+// PipelineStages describes the processing DAG a video goes through after ingestion, in topological order
+var PipelineStages = []Stage{
+	{Type: JobTypeVideoIngestion, DependsOn: []JobType{}},
+	{Type: JobTypeSceneDetection, DependsOn: []JobType{JobTypeVideoIngestion}},
+	{Type: JobTypeCaptionExtraction, DependsOn: []JobType{JobTypeVideoIngestion}},
+	{Type: JobTypeEmbeddingGeneration, DependsOn: []JobType{JobTypeSceneDetection, JobTypeCaptionExtraction}},
+	{Type: JobTypeTopicTimeline, DependsOn: []JobType{JobTypeCaptionExtraction}},
+	{Type: JobTypeEntityExtraction, DependsOn: []JobType{JobTypeSceneDetection, JobTypeCaptionExtraction}},
+	{Type: JobTypeContentFlagging, DependsOn: []JobType{JobTypeCaptionExtraction}},
+	{Type: JobTypeToneAnalysis, DependsOn: []JobType{JobTypeSceneDetection, JobTypeCaptionExtraction}},
+	{Type: JobTypeAlertEvaluation, DependsOn: []JobType{JobTypeEmbeddingGeneration}},
+}
+
+// StageStatus summarises the jobs of one stage: failed if the latest job failed, running if any job
+// is running, pending if any job is waiting, completed once the latest job completed, otherwise not_started
+func StageStatus(jobs []*Job) string {
+	if len(jobs) == 0 {
+		return "not_started"
+	}
+	latest := jobs[len(jobs)-1]
+	for _, j := range jobs {
+		if j.Status == JobStatusRunning {
+			return string(JobStatusRunning)
+		}
+	}
+	for _, j := range jobs {
+		if j.Status == JobStatusPending {
+			return string(JobStatusPending)
+		}
+	}
+	return string(latest.Status)
+}
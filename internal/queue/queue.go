@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -20,6 +22,40 @@ type Job struct {
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	ErrorMessage *string               `json:"error_message,omitempty"`
+	// ErrorCode classifies the last failure (see CodeOf); with ErrorMessage it stays after a retry
+	ErrorCode   ErrorCode              `json:"error_code,omitempty"`
+	// RunAt delays a job: it only becomes dequeueable once this time has passed
+	RunAt       *time.Time             `json:"run_at,omitempty"`
+	// Attempts counts the times a worker started the job; failed attempts are retried per RetryPolicy
+	Attempts    int                    `json:"attempts"`
+	// DependsOn lists the jobs that must settle before this one is dequeueable (see EnqueueAfter)
+	DependsOn   []string               `json:"depends_on,omitempty"`
+	// Result holds the structured outputs of the job's last attempt: counts of what it created or
+	// stored, embeddings saved per modality and warnings
+	Result      map[string]interface{} `json:"result,omitempty"`
+}
+
+// Warnings returns the warnings recorded in the job's result and how many more were dropped
+func (j *Job) Warnings() ([]string, int) {
+	var warnings []string
+	switch ws := j.Result["warnings"].(type) {
+	case []string:
+		warnings = ws
+	case []interface{}:
+		for _, w := range ws {
+			if s, ok := w.(string); ok {
+				warnings = append(warnings, s)
+			}
+		}
+	}
+	dropped := 0
+	switch n := j.Result["warnings_dropped"].(type) {
+	case int:
+		dropped = n
+	case float64:
+		dropped = int(n)
+	}
+	return warnings, dropped
 }
 
 // JobType represents the type of processing job
@@ -31,8 +67,69 @@ const (
 	JobTypeCaptionExtraction   JobType = "caption_extraction"
 	JobTypeEmbeddingGeneration JobType = "embedding_generation"
 	JobTypeVideoAnalysis       JobType = "video_analysis"
+	JobTypeConsistencyCheck    JobType = "consistency_check"
+	JobTypeLiveIngest          JobType = "live_ingest"
+	JobTypeHighlightReel       JobType = "highlight_reel"
+	JobTypeTopicTimeline       JobType = "topic_timeline"
+	JobTypeEntityExtraction    JobType = "entity_extraction"
+	JobTypeContentFlagging     JobType = "content_flagging"
+	JobTypeToneAnalysis        JobType = "tone_analysis"
+	JobTypeAlertEvaluation     JobType = "alert_evaluation"
+	JobTypeNotificationDigest  JobType = "notification_digest"
+	JobTypeLibrarySnapshot     JobType = "library_snapshot"
+	JobTypeModelBackfill       JobType = "model_backfill"
+	JobTypeModelCutover        JobType = "model_cutover"
+	JobTypeModelRetire         JobType = "model_retire"
+	JobTypeScenePreview        JobType = "scene_preview"
+	JobTypeCaptionEmbedding    JobType = "caption_embedding"
+	JobTypeWordAlignment       JobType = "word_alignment"
+	JobTypeSupercut            JobType = "supercut"
+	JobTypeCaptionSync         JobType = "caption_sync"
+	JobTypeClipExport          JobType = "clip_export"
+	JobTypeCaptionQA           JobType = "caption_qa"
+	JobTypeCaptionTranslation  JobType = "caption_translation"
+	JobTypeCountReconciliation JobType = "count_reconciliation"
+	JobTypeCaptionLinking      JobType = "caption_linking"
+	JobTypeVectorIndex         JobType = "vector_index"
+	JobTypeStorageTiering      JobType = "storage_tiering"
+	JobTypeArtifactRestore     JobType = "artifact_restore"
 )
 
+// AllJobTypes lists every job type, in the order DequeueAny polls their queues
+var AllJobTypes = []JobType{
+	JobTypeVideoIngestion,
+	JobTypeSceneDetection,
+	JobTypeCaptionExtraction,
+	JobTypeEmbeddingGeneration,
+	JobTypeVideoAnalysis,
+	JobTypeConsistencyCheck,
+	JobTypeLiveIngest,
+	JobTypeHighlightReel,
+	JobTypeTopicTimeline,
+	JobTypeEntityExtraction,
+	JobTypeContentFlagging,
+	JobTypeToneAnalysis,
+	JobTypeAlertEvaluation,
+	JobTypeNotificationDigest,
+	JobTypeLibrarySnapshot,
+	JobTypeModelBackfill,
+	JobTypeModelCutover,
+	JobTypeModelRetire,
+	JobTypeScenePreview,
+	JobTypeCaptionEmbedding,
+	JobTypeWordAlignment,
+	JobTypeSupercut,
+	JobTypeCaptionSync,
+	JobTypeClipExport,
+	JobTypeCaptionQA,
+	JobTypeCaptionTranslation,
+	JobTypeCountReconciliation,
+	JobTypeCaptionLinking,
+	JobTypeVectorIndex,
+	JobTypeStorageTiering,
+	JobTypeArtifactRestore,
+}
+
 // JobStatus represents the processing status of a job
 type JobStatus string
 
@@ -81,6 +178,11 @@ func NewQueue(config Config) (*Queue, error) {
 
 // Enqueue adds a job to the queue
 func (q *Queue) Enqueue(jobType JobType, payload map[string]interface{}) (*Job, error) {
+	return q.EnqueueAt(jobType, payload, time.Time{})
+}
+
+// EnqueueAt adds a job that becomes dequeueable at runAt (immediately when runAt is zero or past)
+func (q *Queue) EnqueueAt(jobType JobType, payload map[string]interface{}, runAt time.Time) (*Job, error) {
 	job := &Job{
 		ID:        generateJobID(),
 		Type:      jobType,
@@ -89,6 +191,10 @@ func (q *Queue) Enqueue(jobType JobType, payload map[string]interface{}) (*Job,
 		Progress:  0,
 		CreatedAt: time.Now(),
 	}
+	delayed := runAt.After(job.CreatedAt)
+	if delayed {
+		job.RunAt = &runAt
+	}
 
 	jobBytes, err := json.Marshal(job)
 	if err != nil {
@@ -101,6 +207,21 @@ func (q *Queue) Enqueue(jobType JobType, payload map[string]interface{}) (*Job,
 		return nil, fmt.Errorf("failed to store job data: %w", err)
 	}
 
+	// Index the job under its video so per-video listings don't scan every job
+	if videoID, ok := PayloadVideoID(payload); ok {
+		if err := q.client.SAdd(q.ctx, videoJobsKey(videoID), job.ID).Err(); err != nil {
+			return nil, fmt.Errorf("failed to index job: %w", err)
+		}
+	}
+
+	// Delayed jobs wait in a sorted set until promoted by a dequeuing worker
+	if delayed {
+		if err := q.client.ZAdd(q.ctx, delayedJobsKey, &redis.Z{Score: float64(runAt.Unix()), Member: jobBytes}).Err(); err != nil {
+			return nil, fmt.Errorf("failed to schedule job: %w", err)
+		}
+		return job, nil
+	}
+
 	// Add job to the queue (visible to worker only after data is stored)
 	queueName := fmt.Sprintf("jobs:%s", jobType)
 	if err := q.client.LPush(q.ctx, queueName, jobBytes).Err(); err != nil {
@@ -109,65 +230,76 @@ func (q *Queue) Enqueue(jobType JobType, payload map[string]interface{}) (*Job,
 	return job, nil
 }
 
-// Dequeue retrieves a job from the queue
-func (q *Queue) Dequeue(jobType JobType) (*Job, error) {
-    queueName := fmt.Sprintf("jobs:%s", jobType)
-    result, err := q.client.BRPop(q.ctx, 5*time.Second, queueName).Result()
-    if err != nil {
-        if err == redis.Nil {
-            return nil, nil // No jobs available
-        }
-        return nil, fmt.Errorf("failed to dequeue job: %w", err)
-    }
-
-    if len(result) < 2 {
-        return nil, fmt.Errorf("invalid dequeue result")
-    }
+// delayedJobsKey is the sorted set (scored by run_at) holding jobs scheduled for later
+const delayedJobsKey = "jobs:delayed"
+
+// promoteDueJobs moves delayed jobs whose run_at has passed onto their queues. ZREM decides which
+// worker promotes a job, so concurrent workers never push it twice.
+func (q *Queue) promoteDueJobs() error {
+	due, err := q.client.ZRangeByScore(q.ctx, delayedJobsKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: 100,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read delayed jobs: %w", err)
+	}
+	for _, member := range due {
+		removed, err := q.client.ZRem(q.ctx, delayedJobsKey, member).Result()
+		if err != nil || removed == 0 {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(member), &job); err != nil {
+			continue
+		}
+		if err := q.client.LPush(q.ctx, fmt.Sprintf("jobs:%s", job.Type), member).Err(); err != nil {
+			return fmt.Errorf("failed to promote delayed job %s: %w", job.ID, err)
+		}
+	}
+	return nil
+}
 
-    var job Job
-    err = json.Unmarshal([]byte(result[1]), &job)
-    if err != nil {
-        return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+// Dequeue retrieves a job from the queue, leased to the caller (blocks with timeout)
+func (q *Queue) Dequeue(jobType JobType) (*Job, error) {
+    if err := q.promoteDueJobs(); err != nil {
+        return nil, err
     }
-
-    return &job, nil
+    return q.popAndLease([]string{fmt.Sprintf("jobs:%s", jobType)})
 }
 
-// DequeueAny retrieves a job from any of the provided job type queues (blocks with timeout)
+// DequeueAny retrieves a job from any of the provided job type queues, leased to the caller (blocks
+// with timeout).
+// Paused job types are skipped, as are types an active queue window pauses or has throttled to
+// its cap; when everything is skipped it waits and returns no job.
 func (q *Queue) DequeueAny(jobTypes []JobType) (*Job, error) {
-    // Build list keys for BRPOP (right pop from any)
-    var keys []string
-    for _, jt := range jobTypes {
-        keys = append(keys, fmt.Sprintf("jobs:%s", jt))
+    if err := q.promoteDueJobs(); err != nil {
+        return nil, err
     }
-    if len(keys) == 0 {
+    if len(jobTypes) == 0 {
         // default to all known queues
-        keys = []string{
-            fmt.Sprintf("jobs:%s", JobTypeVideoIngestion),
-            fmt.Sprintf("jobs:%s", JobTypeSceneDetection),
-            fmt.Sprintf("jobs:%s", JobTypeCaptionExtraction),
-            fmt.Sprintf("jobs:%s", JobTypeEmbeddingGeneration),
-            fmt.Sprintf("jobs:%s", JobTypeVideoAnalysis),
-        }
+        jobTypes = AllJobTypes
     }
-
-    result, err := q.client.BRPop(q.ctx, 5*time.Second, keys...).Result()
+    paused, err := q.pausedSet()
     if err != nil {
-        if err == redis.Nil {
-            return nil, nil // timeout/no jobs
-        }
-        return nil, fmt.Errorf("failed to dequeue job from any: %w", err)
+        return nil, err
     }
-
-    if len(result) < 2 {
-        return nil, fmt.Errorf("invalid dequeue result")
+    blocked, err := q.windowBlocked(time.Now())
+    if err != nil {
+        return nil, err
     }
-
-    var job Job
-    if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
-        return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+    // Queues are popped from the right, in the order given
+    var keys []string
+    for _, jt := range jobTypes {
+        if !paused[pauseAll] && !paused[string(jt)] && !blocked[jt] {
+            keys = append(keys, fmt.Sprintf("jobs:%s", jt))
+        }
     }
-    return &job, nil
+    if len(keys) == 0 {
+        time.Sleep(dequeueTimeout)
+        return nil, nil
+    }
+    return q.popAndLease(keys)
 }
 
 // Ping checks connectivity to Redis
@@ -178,6 +310,11 @@ func (q *Queue) Ping() error {
 
 // UpdateJobStatus updates the status of a job
 func (q *Queue) UpdateJobStatus(jobID string, status JobStatus, progress int, errorMessage *string) error {
+	return q.setJobStatus(jobID, status, progress, errorMessage, nil)
+}
+
+// setJobStatus is UpdateJobStatus with mutate, if not nil, applied to the job before it is saved
+func (q *Queue) setJobStatus(jobID string, status JobStatus, progress int, errorMessage *string, mutate func(*Job)) error {
 	jobKey := fmt.Sprintf("job:%s", jobID)
 	// Get current job data
 	jobData, err := q.client.HGet(q.ctx, jobKey, "data").Result()
@@ -196,16 +333,31 @@ func (q *Queue) UpdateJobStatus(jobID string, status JobStatus, progress int, er
 	if errorMessage != nil {
 		job.ErrorMessage = errorMessage
 	}
+	if status == JobStatusRunning {
+		job.Attempts++
+		job.Result = nil
+	}
+	if mutate != nil {
+		mutate(&job)
+	}
 
 	// Update timestamps
 	now := time.Now()
 	switch status {
 	case JobStatusRunning:
 		job.StartedAt = &now
-	case JobStatusCompleted, JobStatusFailed:
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
 		job.CompletedAt = &now
 	}
 
+	// Track in-flight jobs so operators can tell when a paused pipeline has drained
+	if status == JobStatusRunning {
+		q.client.ZAdd(q.ctx, runningJobsKey, &redis.Z{Score: float64(now.Unix()), Member: jobID})
+	} else {
+		q.client.ZRem(q.ctx, runningJobsKey, jobID)
+		q.releaseLease(jobID)
+	}
+
 	// Save updated job data
 	jobBytes, err := json.Marshal(job)
 	if err != nil {
@@ -217,9 +369,89 @@ func (q *Queue) UpdateJobStatus(jobID string, status JobStatus, progress int, er
 		return fmt.Errorf("failed to update job data: %w", err)
 	}
 
+	// Jobs waiting on this one may now run (or fail with it)
+	switch status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		if err := q.settleDependents(jobID); err != nil {
+			return fmt.Errorf("failed to release dependent jobs: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// UpdateJobProgress records the progress of a running job without touching its status or
+// attempt count; jobs no longer running are left alone
+func (q *Queue) UpdateJobProgress(jobID string, progress int) error {
+	return q.updateJob(jobID, func(job *Job) bool {
+		if job.Status != JobStatusRunning {
+			return false
+		}
+		job.Progress = progress
+		return true
+	})
+}
+
+// maxJobUpdateAttempts bounds how often updateJob re-reads a job written under it
+const maxJobUpdateAttempts = 10
+
+// updateJob changes one job's stored data atomically. The job key is watched from the read to the
+// write, so a status change landing in between (a cancel, completion or failure) is never
+// overwritten: the write is dropped and update runs again on the new data. update returns false to
+// leave the job as it is.
+func (q *Queue) updateJob(jobID string, update func(*Job) bool) error {
+	jobKey := fmt.Sprintf("job:%s", jobID)
+	txf := func(tx *redis.Tx) error {
+		jobData, err := tx.HGet(q.ctx, jobKey, "data").Result()
+		if err == redis.Nil {
+			return fmt.Errorf("job not found: %s", jobID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get job data: %w", err)
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(jobData), &job); err != nil {
+			return fmt.Errorf("failed to unmarshal job: %w", err)
+		}
+		if !update(&job) {
+			return nil
+		}
+		jobBytes, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job: %w", err)
+		}
+		_, err = tx.TxPipelined(q.ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(q.ctx, jobKey, "data", jobBytes)
+			return nil
+		})
+		if err != nil && err != redis.TxFailedErr {
+			return fmt.Errorf("failed to update job data: %w", err)
+		}
+		return err
+	}
+	for i := 0; i < maxJobUpdateAttempts; i++ {
+		err := q.client.Watch(q.ctx, txf, jobKey)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("failed to update job data: job %s kept changing", jobID)
+}
+
+// SetJobResult records the structured outputs of a job's attempt (see Job.Result) without touching
+// its status, attempt count or error. A job already back to pending (retried or requeued) is left
+// alone, so its next attempt does not show this one's result.
+func (q *Queue) SetJobResult(jobID string, result map[string]interface{}) error {
+	return q.updateJob(jobID, func(job *Job) bool {
+		if job.Status == JobStatusPending {
+			return false
+		}
+		job.Result = result
+		return true
+	})
+}
+
 // GetJob retrieves a job by ID
 func (q *Queue) GetJob(jobID string) (*Job, error) {
 	jobKey := fmt.Sprintf("job:%s", jobID)
@@ -241,6 +473,51 @@ func (q *Queue) GetJob(jobID string) (*Job, error) {
 	return &job, nil
 }
 
+// GetJobs retrieves several jobs by ID in a single round trip. IDs with no stored job are omitted
+// from the result map.
+func (q *Queue) GetJobs(jobIDs []string) (map[string]*Job, error) {
+	pipe := q.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(jobIDs))
+	for i, id := range jobIDs {
+		cmds[i] = pipe.HGet(q.ctx, fmt.Sprintf("job:%s", id), "data")
+	}
+	if _, err := pipe.Exec(q.ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get job data: %w", err)
+	}
+
+	jobs := make(map[string]*Job, len(jobIDs))
+	for i, cmd := range cmds {
+		jobData, err := cmd.Result()
+		if err != nil {
+			continue // missing job
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(jobData), &job); err != nil {
+			continue // Skip jobs with unmarshal errors
+		}
+		jobs[jobIDs[i]] = &job
+	}
+	return jobs, nil
+}
+
+// ListJobsForVideo returns the queue jobs whose payload references videoID, oldest first
+func (q *Queue) ListJobsForVideo(videoID uint) ([]*Job, error) {
+	ids, err := q.client.SMembers(q.ctx, videoJobsKey(videoID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list video jobs: %w", err)
+	}
+	byID, err := q.GetJobs(ids)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(byID))
+	for _, job := range byID {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, nil
+}
+
 // ListJobs returns jobs of a specific type
 func (q *Queue) ListJobs(jobType JobType, limit int) ([]*Job, error) {
 	// This is a simplified implementation
@@ -291,6 +568,26 @@ func (q *Queue) Close() error {
 	return q.client.Close()
 }
 
+func videoJobsKey(videoID uint) string {
+	return fmt.Sprintf("video_jobs:%d", videoID)
+}
+
+// PayloadVideoID extracts a numeric video_id from a JSON job payload
+func PayloadVideoID(payload map[string]interface{}) (uint, bool) {
+	switch v := payload["video_id"].(type) {
+	case float64:
+		return uint(v), v > 0
+	case int:
+		return uint(v), v > 0
+	case uint:
+		return v, v > 0
+	case string:
+		id, err := strconv.ParseUint(v, 10, 32)
+		return uint(id), err == nil && id > 0
+	}
+	return 0, false
+}
+
 // generateJobID generates a unique job ID
 func generateJobID() string {
 	// In a real implementation, you might want to use UUID or similar
@@ -0,0 +1,222 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RetryPolicy decides whether and when a failed job runs again. Attempt n (1-based) that fails is
+// retried after BaseDelay * 2^(n-1), capped at MaxDelay, until MaxAttempts attempts have run.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// RetryPolicyFromEnv reads the retry policy: JOB_MAX_ATTEMPTS (default 3; 1 disables retries),
+// JOB_RETRY_BASE_SECS (default 30) and JOB_RETRY_MAX_SECS (default 1800)
+func RetryPolicyFromEnv() RetryPolicy {
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Minute}
+	if n, err := strconv.Atoi(os.Getenv("JOB_MAX_ATTEMPTS")); err == nil && n >= 1 {
+		p.MaxAttempts = n
+	}
+	if secs, err := strconv.Atoi(os.Getenv("JOB_RETRY_BASE_SECS")); err == nil && secs > 0 {
+		p.BaseDelay = time.Duration(secs) * time.Second
+	}
+	if secs, err := strconv.Atoi(os.Getenv("JOB_RETRY_MAX_SECS")); err == nil && secs > 0 {
+		p.MaxDelay = time.Duration(secs) * time.Second
+	}
+	return p
+}
+
+// Backoff is the delay before retrying a job whose attempt-th attempt failed
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// permanentError marks a job failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so a failed job is not retried (e.g. a refused operation or a bad payload)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether retrying cannot fix err: it was wrapped with Permanent or its
+// ErrorCode is not retryable
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p) || !CodeOf(err).Retryable()
+}
+
+// FailJob records a failed attempt of a job with jobErr's message and ErrorCode. Unless jobErr is
+// permanent or the job has used up policy.MaxAttempts, the job goes back to pending and is scheduled
+// again after the policy's backoff; otherwise it is marked failed. It reports whether a retry was
+// scheduled.
+func (q *Queue) FailJob(jobID string, jobErr error, policy RetryPolicy) (bool, error) {
+	job, err := q.GetJob(jobID)
+	if err != nil {
+		return false, err
+	}
+	msg := jobErr.Error()
+	code := CodeOf(jobErr)
+	if IsPermanent(jobErr) || job.Attempts >= policy.MaxAttempts {
+		return false, q.setJobStatus(jobID, JobStatusFailed, 0, &msg, func(j *Job) { j.ErrorCode = code })
+	}
+
+	runAt := time.Now().Add(policy.Backoff(job.Attempts))
+	job.Status = JobStatusPending
+	job.Progress = 0
+	job.ErrorMessage = &msg
+	job.ErrorCode = code
+	job.RunAt = &runAt
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", jobID), "data", jobBytes).Err(); err != nil {
+		return false, fmt.Errorf("failed to update job data: %w", err)
+	}
+	q.client.ZRem(q.ctx, runningJobsKey, jobID)
+	q.releaseLease(jobID)
+	if err := q.client.ZAdd(q.ctx, delayedJobsKey, &redis.Z{Score: float64(runAt.Unix()), Member: jobBytes}).Err(); err != nil {
+		return false, fmt.Errorf("failed to schedule retry: %w", err)
+	}
+	return true, nil
+}
+
+// ErrJobNotRetryable is returned by RetryJob for jobs that have not failed or been cancelled
+var ErrJobNotRetryable = errors.New("only failed or cancelled jobs can be retried")
+
+// RetryJob puts a failed or cancelled job back on its queue to run now, with a fresh attempt count.
+// The job keeps its ID, so clients polling it see it run again. A job cancelled while queued or
+// scheduled leaves its entry behind; that entry is removed so the job does not run twice. A job still
+// waiting for its dependencies is only marked pending and runs once they settle.
+func (q *Queue) RetryJob(jobID string) (*Job, error) {
+	job, err := q.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobStatusFailed && job.Status != JobStatusCancelled {
+		return nil, ErrJobNotRetryable
+	}
+	if err := q.removeQueuedCopies(job); err != nil {
+		return nil, err
+	}
+	blocked, err := q.client.SIsMember(q.ctx, blockedJobsKey, jobID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check job dependencies: %w", err)
+	}
+	if err := q.clearCancel(jobID); err != nil {
+		return nil, fmt.Errorf("failed to clear cancellation: %w", err)
+	}
+	job.Status = JobStatusPending
+	job.Progress = 0
+	job.Attempts = 0
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	job.RunAt = nil
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", jobID), "data", jobBytes).Err(); err != nil {
+		return nil, fmt.Errorf("failed to update job data: %w", err)
+	}
+	if blocked {
+		return job, nil
+	}
+	if err := q.client.LPush(q.ctx, fmt.Sprintf("jobs:%s", job.Type), jobBytes).Err(); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return job, nil
+}
+
+// removeQueuedCopies takes a job's entries off its queue and the delayed set
+func (q *Queue) removeQueuedCopies(job *Job) error {
+	queueName := fmt.Sprintf("jobs:%s", job.Type)
+	queued, err := q.client.LRange(q.ctx, queueName, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read queue: %w", err)
+	}
+	for _, member := range queued {
+		if queuedJobID(member) == job.ID {
+			if err := q.client.LRem(q.ctx, queueName, 0, member).Err(); err != nil {
+				return fmt.Errorf("failed to remove queued copy: %w", err)
+			}
+		}
+	}
+	delayed, err := q.client.ZRange(q.ctx, delayedJobsKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read delayed jobs: %w", err)
+	}
+	for _, member := range delayed {
+		if queuedJobID(member) == job.ID {
+			if err := q.client.ZRem(q.ctx, delayedJobsKey, member).Err(); err != nil {
+				return fmt.Errorf("failed to remove scheduled copy: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// queuedJobID is the ID of the job serialized in a queue entry ("" when it does not parse)
+func queuedJobID(member string) string {
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(member), &job); err != nil {
+		return ""
+	}
+	return job.ID
+}
+
+// RequeueJob puts a job a stopping worker dequeued but did not finish back at the head of its
+// queue as pending. The interrupted attempt is not counted.
+func (q *Queue) RequeueJob(jobID string) error {
+	job, err := q.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == JobStatusRunning && job.Attempts > 0 {
+		job.Attempts--
+	}
+	job.Status = JobStatusPending
+	job.Progress = 0
+	job.StartedAt = nil
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := q.client.HSet(q.ctx, fmt.Sprintf("job:%s", jobID), "data", jobBytes).Err(); err != nil {
+		return fmt.Errorf("failed to update job data: %w", err)
+	}
+	q.client.ZRem(q.ctx, runningJobsKey, jobID)
+	q.releaseLease(jobID)
+	// Workers pop from the right, so the job runs next
+	if err := q.client.RPush(q.ctx, fmt.Sprintf("jobs:%s", job.Type), jobBytes).Err(); err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxThroughputSamples is the number of recent completed jobs kept per job type
+const maxThroughputSamples = 50
+
+func throughputKey(jobType JobType) string {
+	return fmt.Sprintf("throughput:%s", jobType)
+}
+
+// Throughput is the historical processing rate of a job type, in seconds of processing per minute
+// of source video, over its recent completed jobs
+type Throughput struct {
+	JobType JobType `json:"job_type"`
+	Samples int     `json:"samples"`
+	// SecsPerSourceMinute is the median of the samples, so a few outliers (a cold model load, a
+	// stalled GPU) don't skew it
+	SecsPerSourceMinute float64 `json:"secs_per_source_minute"`
+}
+
+// RecordThroughput records a completed job that processed sourceSecs of video in elapsed. Jobs
+// without a source length are ignored.
+func (q *Queue) RecordThroughput(jobType JobType, elapsed time.Duration, sourceSecs float64) error {
+	if sourceSecs <= 0 || elapsed <= 0 {
+		return nil
+	}
+	rate := elapsed.Seconds() / (sourceSecs / 60)
+	key := throughputKey(jobType)
+	pipe := q.client.TxPipeline()
+	pipe.LPush(q.ctx, key, strconv.FormatFloat(rate, 'f', 3, 64))
+	pipe.LTrim(q.ctx, key, 0, maxThroughputSamples-1)
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return fmt.Errorf("failed to record throughput of %s: %w", jobType, err)
+	}
+	return nil
+}
+
+// Throughput returns the rate of a job type, or nil when none of its jobs was recorded yet
+func (q *Queue) Throughput(jobType JobType) (*Throughput, error) {
+	vals, err := q.client.LRange(q.ctx, throughputKey(jobType), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read throughput of %s: %w", jobType, err)
+	}
+	samples := make([]float64, 0, len(vals))
+	for _, v := range vals {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			samples = append(samples, f)
+		}
+	}
+	if len(samples) == 0 {
+		return nil, nil
+	}
+	sort.Float64s(samples)
+	median := samples[len(samples)/2]
+	if len(samples)%2 == 0 {
+		median = (samples[len(samples)/2-1] + median) / 2
+	}
+	return &Throughput{JobType: jobType, Samples: len(samples), SecsPerSourceMinute: median}, nil
+}
+
+// Throughputs returns the rate of every job type with recorded jobs
+func (q *Queue) Throughputs() ([]Throughput, error) {
+	out := []Throughput{}
+	for _, jt := range AllJobTypes {
+		t, err := q.Throughput(jt)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+// JobsAhead returns the number of queued jobs of the job's type a worker takes before it. Jobs not
+// on the queue yet (delayed or waiting on dependencies) are behind everything queued now.
+func (q *Queue) JobsAhead(job *Job) (int, error) {
+	members, err := q.client.LRange(q.ctx, fmt.Sprintf("jobs:%s", job.Type), 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read queue of %s: %w", job.Type, err)
+	}
+	// Workers pop from the right, so the jobs ahead are those to the right of this one
+	for i, m := range members {
+		var queued struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal([]byte(m), &queued) == nil && queued.ID == job.ID {
+			return len(members) - 1 - i, nil
+		}
+	}
+	return len(members), nil
+}
@@ -0,0 +1,279 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// windowsKey is the hash of queue window names to their QueueWindow
+const windowsKey = "queue:windows"
+
+// Queue window actions
+const (
+	// WindowPause stops workers taking jobs of the window's types while it is active
+	WindowPause = "pause"
+	// WindowThrottle caps the jobs of each of the window's types in flight across all workers
+	WindowThrottle = "throttle"
+)
+
+// weekdays are the day names a window's Days accept, indexed by time.Weekday
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// QueueWindow restricts job types during recurring hours, e.g. pausing GPU-heavy embedding jobs
+// while interactive search traffic peaks and letting them run overnight. Windows live in Redis, so
+// every worker enforces them when it dequeues.
+type QueueWindow struct {
+	Name string `json:"name"`
+	// JobTypes are the restricted types; empty means every type
+	JobTypes []JobType `json:"job_types,omitempty"`
+	// Days are the days ("mon".."sun") the window starts on; empty means every day
+	Days []string `json:"days,omitempty"`
+	// Start and End are "HH:MM" in Timezone; an End before Start runs past midnight, an End equal
+	// to Start covers the whole day
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+	// Action is "pause" or "throttle"
+	Action string `json:"action"`
+	// MaxInFlight is the throttle's cap per job type
+	MaxInFlight int       `json:"max_in_flight,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks the window's fields and normalizes its day names
+func (w *QueueWindow) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return errors.New("name is required")
+	}
+	for _, jt := range w.JobTypes {
+		known := false
+		for _, k := range AllJobTypes {
+			known = known || jt == k
+		}
+		if !known {
+			return fmt.Errorf("unknown job type %s", jt)
+		}
+	}
+	for i, d := range w.Days {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) > 3 {
+			d = d[:3]
+		}
+		if dayIndex(d) < 0 {
+			return fmt.Errorf("invalid day %q", w.Days[i])
+		}
+		w.Days[i] = d
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", w.Timezone)
+	}
+	switch w.Action {
+	case WindowPause:
+	case WindowThrottle:
+		if w.MaxInFlight < 1 {
+			return errors.New("throttle windows need max_in_flight of at least 1")
+		}
+	default:
+		return errors.New("action must be pause or throttle")
+	}
+	return nil
+}
+
+func dayIndex(d string) int {
+	for i, name := range weekdays {
+		if name == d {
+			return i
+		}
+	}
+	return -1
+}
+
+// startsOn reports whether the window runs on days starting on weekday wd
+func (w QueueWindow) startsOn(wd time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if dayIndex(d) == int(wd) {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveAt reports whether the window is in force at t
+func (w QueueWindow) ActiveAt(t time.Time) bool {
+	start, err1 := parseClock(w.Start)
+	end, err2 := parseClock(w.End)
+	loc, err3 := time.LoadLocation(w.Timezone)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return false
+	}
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	switch {
+	case start == end:
+		return w.startsOn(local.Weekday())
+	case start < end:
+		return now >= start && now < end && w.startsOn(local.Weekday())
+	default:
+		// Runs past midnight: the early hours belong to the previous day's window
+		if now >= start {
+			return w.startsOn(local.Weekday())
+		}
+		return now < end && w.startsOn(local.AddDate(0, 0, -1).Weekday())
+	}
+}
+
+// covers reports whether the window restricts jobType
+func (w QueueWindow) covers(jobType JobType) bool {
+	if len(w.JobTypes) == 0 {
+		return true
+	}
+	for _, jt := range w.JobTypes {
+		if jt == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWindow creates or replaces the window of the same name
+func (q *Queue) SetWindow(w *QueueWindow) error {
+	w.UpdatedAt = time.Now().UTC()
+	b, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	if err := q.client.HSet(q.ctx, windowsKey, w.Name, b).Err(); err != nil {
+		return fmt.Errorf("failed to save queue window: %w", err)
+	}
+	return nil
+}
+
+// DeleteWindow removes a window, reporting whether it existed
+func (q *Queue) DeleteWindow(name string) (bool, error) {
+	n, err := q.client.HDel(q.ctx, windowsKey, name).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete queue window: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Windows returns the configured windows by name
+func (q *Queue) Windows() ([]QueueWindow, error) {
+	m, err := q.client.HGetAll(q.ctx, windowsKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read queue windows: %w", err)
+	}
+	out := make([]QueueWindow, 0, len(m))
+	for _, v := range m {
+		var w QueueWindow
+		if err := json.Unmarshal([]byte(v), &w); err == nil {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// windowLimits returns, for the job types restricted by a window active at now, the number of
+// their jobs allowed in flight: 0 when paused, else the tightest throttle
+func (q *Queue) windowLimits(now time.Time) (map[JobType]int, error) {
+	windows, err := q.Windows()
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+	limits := map[JobType]int{}
+	for _, w := range windows {
+		if !w.ActiveAt(now) {
+			continue
+		}
+		limit := 0
+		if w.Action == WindowThrottle {
+			limit = w.MaxInFlight
+		}
+		for _, jt := range AllJobTypes {
+			if !w.covers(jt) {
+				continue
+			}
+			if cur, ok := limits[jt]; !ok || limit < cur {
+				limits[jt] = limit
+			}
+		}
+	}
+	return limits, nil
+}
+
+// inFlightByType counts the leased jobs (dequeued by a worker and not yet settled) of each type
+func (q *Queue) inFlightByType() (map[JobType]int, error) {
+	ids, err := q.client.ZRange(q.ctx, leasesKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read in-flight jobs: %w", err)
+	}
+	counts := map[JobType]int{}
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	pipe := q.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGet(q.ctx, fmt.Sprintf("job:%s", id), "data")
+	}
+	pipe.Exec(q.ctx)
+	for _, cmd := range cmds {
+		var job Job
+		if b, err := cmd.Bytes(); err == nil && json.Unmarshal(b, &job) == nil {
+			counts[job.Type]++
+		}
+	}
+	return counts, nil
+}
+
+// windowBlocked returns the job types active windows keep workers from taking now: paused types,
+// and throttled types already at their cap. Throttles are enforced at dequeue time, so workers
+// dequeuing at the same moment can briefly exceed a cap.
+func (q *Queue) windowBlocked(now time.Time) (map[JobType]bool, error) {
+	limits, err := q.windowLimits(now)
+	if err != nil || len(limits) == 0 {
+		return nil, err
+	}
+	blocked := map[JobType]bool{}
+	var inFlight map[JobType]int
+	for jt, limit := range limits {
+		if limit == 0 {
+			blocked[jt] = true
+			continue
+		}
+		if inFlight == nil {
+			if inFlight, err = q.inFlightByType(); err != nil {
+				return nil, err
+			}
+		}
+		if inFlight[jt] >= limit {
+			blocked[jt] = true
+		}
+	}
+	return blocked, nil
+}
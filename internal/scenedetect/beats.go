@@ -0,0 +1,70 @@
+package scenedetect
+
+import (
+	"os"
+	"strconv"
+)
+
+// BeatOptions bounds the duration of beats built by GroupBeats
+type BeatOptions struct {
+	MinDuration float64
+	MaxDuration float64
+}
+
+// BeatOptionsFromEnv reads BEAT_MIN_DURATION_SECS (default 8) and BEAT_MAX_DURATION_SECS (default 45)
+func BeatOptionsFromEnv() BeatOptions {
+	opts := BeatOptions{MinDuration: 8, MaxDuration: 45}
+	if v := os.Getenv("BEAT_MIN_DURATION_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			opts.MinDuration = f
+		}
+	}
+	if v := os.Getenv("BEAT_MAX_DURATION_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			opts.MaxDuration = f
+		}
+	}
+	if opts.MaxDuration < opts.MinDuration {
+		opts.MaxDuration = opts.MinDuration
+	}
+	return opts
+}
+
+// Beat is a run of consecutive shots [FirstShot, LastShot] treated as one coarser scene
+type Beat struct {
+	Scene
+	FirstShot int `json:"first_shot"`
+	LastShot  int `json:"last_shot"`
+}
+
+// GroupBeats groups sorted, contiguous shots into beats: consecutive shots accumulate until the beat
+// reaches MinDuration, without growing past MaxDuration (a single longer shot forms its own beat).
+// A short trailing beat is folded into the previous one when that stays within MaxDuration.
+func GroupBeats(shots []Scene, opts BeatOptions) []Beat {
+	var beats []Beat
+	for i, s := range shots {
+		if n := len(beats); n > 0 {
+			cur := &beats[n-1]
+			curDur := cur.EndTime - cur.StartTime
+			if curDur < opts.MinDuration && s.EndTime-cur.StartTime <= opts.MaxDuration {
+				cur.EndTime = s.EndTime
+				cur.LastShot = i
+				continue
+			}
+		}
+		beats = append(beats, Beat{
+			Scene:     Scene{Index: len(beats), StartTime: s.StartTime, EndTime: s.EndTime},
+			FirstShot: i,
+			LastShot:  i,
+		})
+	}
+	if n := len(beats); n > 1 {
+		last, prev := beats[n-1], &beats[n-2]
+		if last.EndTime-last.StartTime < opts.MinDuration && last.EndTime-prev.StartTime <= opts.MaxDuration {
+			prev.EndTime = last.EndTime
+			prev.LastShot = last.LastShot
+			beats = beats[:n-1]
+		}
+	}
+	return beats
+}
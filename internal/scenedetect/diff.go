@@ -0,0 +1,116 @@
+package scenedetect
+
+import "math"
+
+// ShiftedScene is a scene present in both runs whose boundaries moved by more than the tolerance
+type ShiftedScene struct {
+	OldIndex   int     `json:"old_index"`
+	NewIndex   int     `json:"new_index"`
+	OldStart   float64 `json:"old_start"`
+	OldEnd     float64 `json:"old_end"`
+	NewStart   float64 `json:"new_start"`
+	NewEnd     float64 `json:"new_end"`
+	StartDelta float64 `json:"start_delta"`
+	EndDelta   float64 `json:"end_delta"`
+}
+
+// RemovedScene is a scene of the old run without a counterpart in the new one; references to it move
+// to the new scene it overlaps most (MapsTo, -1 when the new run has no scenes)
+type RemovedScene struct {
+	Index     int     `json:"index"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	MapsTo    int     `json:"maps_to"`
+}
+
+// SceneDiff compares the scene boundaries of two detection runs of the same video
+type SceneDiff struct {
+	OldScenes int            `json:"old_scenes"`
+	NewScenes int            `json:"new_scenes"`
+	Tolerance float64        `json:"tolerance"`
+	Unchanged int            `json:"unchanged"`
+	Shifted   []ShiftedScene `json:"shifted"`
+	Added     []Scene        `json:"added"`
+	Removed   []RemovedScene `json:"removed"`
+}
+
+// overlap returns how many seconds two scenes share
+func overlap(a, b Scene) float64 {
+	return math.Max(0, math.Min(a.EndTime, b.EndTime)-math.Max(a.StartTime, b.StartTime))
+}
+
+// bestMatches returns, for each scene in from, the position in to of the scene it overlaps most; a
+// scene overlapping nothing maps to the nearest scene (-1 only when to is empty). Both lists must be
+// sorted by start time.
+func bestMatches(from, to []Scene) []int {
+	best := make([]int, len(from))
+	j := 0
+	for i, s := range from {
+		best[i] = -1
+		if len(to) == 0 {
+			continue
+		}
+		for j < len(to)-1 && to[j].EndTime <= s.StartTime {
+			j++
+		}
+		most := 0.0
+		for k := j; k < len(to) && to[k].StartTime < s.EndTime; k++ {
+			if ov := overlap(s, to[k]); ov > most {
+				most, best[i] = ov, k
+			}
+		}
+		if best[i] < 0 {
+			best[i] = j
+			if j > 0 && s.StartTime-to[j-1].EndTime < to[j].StartTime-s.EndTime {
+				best[i] = j - 1
+			}
+		}
+	}
+	return best
+}
+
+// MapScenes returns, for each old scene, the position of the new scene that takes over its references
+func MapScenes(old, next []Scene) []int {
+	return bestMatches(old, next)
+}
+
+// DiffScenes compares two runs. An old and a new scene are the same scene when each overlaps the
+// other most; such a pair is unchanged when both boundaries moved by at most tolerance seconds and
+// shifted otherwise. The remaining old scenes were removed (merged into or split across their
+// neighbours) and the remaining new scenes were added.
+func DiffScenes(old, next []Scene, tolerance float64) SceneDiff {
+	diff := SceneDiff{OldScenes: len(old), NewScenes: len(next), Tolerance: tolerance, Shifted: []ShiftedScene{}, Added: []Scene{}, Removed: []RemovedScene{}}
+	forward := bestMatches(old, next)
+	backward := bestMatches(next, old)
+
+	matched := make([]bool, len(next))
+	for i, j := range forward {
+		if j < 0 || backward[j] != i || overlap(old[i], next[j]) == 0 {
+			r := RemovedScene{Index: old[i].Index, StartTime: old[i].StartTime, EndTime: old[i].EndTime, MapsTo: -1}
+			if j >= 0 {
+				r.MapsTo = next[j].Index
+			}
+			diff.Removed = append(diff.Removed, r)
+			continue
+		}
+		matched[j] = true
+		o, n := old[i], next[j]
+		ds, de := n.StartTime-o.StartTime, n.EndTime-o.EndTime
+		if math.Abs(ds) <= tolerance && math.Abs(de) <= tolerance {
+			diff.Unchanged++
+			continue
+		}
+		diff.Shifted = append(diff.Shifted, ShiftedScene{
+			OldIndex: o.Index, NewIndex: n.Index,
+			OldStart: o.StartTime, OldEnd: o.EndTime,
+			NewStart: n.StartTime, NewEnd: n.EndTime,
+			StartDelta: ds, EndDelta: de,
+		})
+	}
+	for j, s := range next {
+		if !matched[j] {
+			diff.Added = append(diff.Added, s)
+		}
+	}
+	return diff
+}
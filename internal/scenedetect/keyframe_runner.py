@@ -0,0 +1,134 @@
+#!/usr/bin/env python3
+"""Pick a representative frame per scene.
+
+Reads JSON on stdin:
+  {"video_path": str, "output_dir": str, "samples": int, "faces": bool, "candidates": int,
+   "scenes": [{"index": int, "start_time": float, "end_time": float}, ...]}
+
+For each scene, samples frames across its interior, scores them by sharpness (variance of the
+Laplacian, normalised within the scene) plus a bonus for detected faces, writes the best frame to
+output_dir/scene_<index>_keyframe.jpg and the top `candidates` frames to
+output_dir/scene_<index>_cand_<rank>.jpg, and prints
+  {"keyframes": [{"index", "timestamp", "sharpness", "faces", "score", "path",
+                  "candidates": [{"rank", "timestamp", "sharpness", "faces", "score", "path"}, ...]}, ...]}
+"""
+
+import sys
+import json
+import os
+
+import cv2
+
+FACE_BONUS = 0.5
+
+
+def read_payload():
+    raw = sys.stdin.read()
+    return json.loads(raw) if raw.strip() else {}
+
+
+def sample_times(start, end, n):
+    """Evenly spaced timestamps inside the scene, avoiding the cut frames at either edge"""
+    duration = end - start
+    if duration <= 0 or n <= 1:
+        return [(start + end) / 2.0]
+    lo = start + duration * 0.1
+    hi = end - duration * 0.1
+    return [lo + (hi - lo) * i / (n - 1) for i in range(n)]
+
+
+def read_frame(cap, t):
+    cap.set(cv2.CAP_PROP_POS_MSEC, t * 1000.0)
+    ok, frame = cap.read()
+    return frame if ok else None
+
+
+def sharpness(gray):
+    return float(cv2.Laplacian(gray, cv2.CV_64F).var())
+
+
+def main():
+    payload = read_payload()
+    video_path = payload.get("video_path")
+    output_dir = payload.get("output_dir")
+    scenes = payload.get("scenes", [])
+    samples = max(1, int(payload.get("samples", 7)))
+    use_faces = bool(payload.get("faces", True))
+    n_candidates = max(1, int(payload.get("candidates", 1)))
+    if not video_path or not output_dir:
+        print(json.dumps({"error": "video_path and output_dir are required"}))
+        sys.exit(1)
+
+    cap = cv2.VideoCapture(video_path)
+    if not cap.isOpened():
+        print(json.dumps({"error": f"failed to open video: {video_path}"}))
+        sys.exit(1)
+    os.makedirs(output_dir, exist_ok=True)
+
+    face_cascade = None
+    if use_faces:
+        cascade_path = os.path.join(cv2.data.haarcascades, "haarcascade_frontalface_default.xml")
+        face_cascade = cv2.CascadeClassifier(cascade_path)
+        if face_cascade.empty():
+            face_cascade = None
+
+    keyframes = []
+    for s in scenes:
+        index = int(s.get("index", 0))
+        start = float(s.get("start_time", 0.0))
+        end = float(s.get("end_time", start))
+
+        candidates = []
+        for t in sample_times(start, end, samples):
+            frame = read_frame(cap, t)
+            if frame is None:
+                continue
+            gray = cv2.cvtColor(frame, cv2.COLOR_BGR2GRAY)
+            faces = 0
+            if face_cascade is not None:
+                small = cv2.resize(gray, (0, 0), fx=0.5, fy=0.5) if gray.shape[1] > 960 else gray
+                faces = len(face_cascade.detectMultiScale(small, scaleFactor=1.2, minNeighbors=5))
+            candidates.append({"timestamp": t, "frame": frame, "sharpness": sharpness(gray), "faces": faces})
+        if not candidates:
+            continue
+
+        top = max(c["sharpness"] for c in candidates) or 1.0
+        for c in candidates:
+            c["score"] = c["sharpness"] / top + (FACE_BONUS if c["faces"] > 0 else 0.0)
+        ranked = sorted(candidates, key=lambda c: c["score"], reverse=True)
+        best = ranked[0]
+
+        path = os.path.join(output_dir, f"scene_{index:04d}_keyframe.jpg")
+        cv2.imwrite(path, best["frame"], [cv2.IMWRITE_JPEG_QUALITY, 92])
+        cands = []
+        for rank, c in enumerate(ranked[:n_candidates]):
+            cand_path = os.path.join(output_dir, f"scene_{index:04d}_cand_{rank:02d}.jpg")
+            cv2.imwrite(cand_path, c["frame"], [cv2.IMWRITE_JPEG_QUALITY, 92])
+            cands.append({
+                "rank": rank,
+                "timestamp": c["timestamp"],
+                "sharpness": c["sharpness"],
+                "faces": c["faces"],
+                "score": c["score"],
+                "path": cand_path,
+            })
+        keyframes.append({
+            "index": index,
+            "timestamp": best["timestamp"],
+            "sharpness": best["sharpness"],
+            "faces": best["faces"],
+            "score": best["score"],
+            "path": path,
+            "candidates": cands,
+        })
+
+    cap.release()
+    print(json.dumps({"keyframes": keyframes}))
+
+
+if __name__ == "__main__":
+    try:
+        main()
+    except Exception as e:
+        print(json.dumps({"error": str(e)}))
+        sys.exit(1)
@@ -0,0 +1,137 @@
+package scenedetect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Keyframe is the representative frame chosen for a scene
+type Keyframe struct {
+	Index     int     `json:"index"`
+	Timestamp float64 `json:"timestamp"`
+	Sharpness float64 `json:"sharpness"`
+	Faces     int     `json:"faces"`
+	Score     float64 `json:"score"`
+	Path      string  `json:"path"`
+	// Candidates are the top-scoring sampled frames, best first (the first matches the keyframe)
+	Candidates []KeyframeCandidate `json:"candidates,omitempty"`
+}
+
+// KeyframeCandidate is one of the alternative frames extracted for a scene
+type KeyframeCandidate struct {
+	Rank      int     `json:"rank"`
+	Timestamp float64 `json:"timestamp"`
+	Sharpness float64 `json:"sharpness"`
+	Faces     int     `json:"faces"`
+	Score     float64 `json:"score"`
+	Path      string  `json:"path"`
+}
+
+// KeyframeOptions controls representative frame selection
+type KeyframeOptions struct {
+	// Samples is the number of frames scored per scene
+	Samples int
+	// Faces adds a bonus for frames with detected faces
+	Faces bool
+	// Candidates is the number of top frames kept per scene as alternatives
+	Candidates int
+}
+
+// KeyframeOptionsFromEnv reads KEYFRAME_SAMPLES (default 7), KEYFRAME_FACES (default true) and
+// KEYFRAME_CANDIDATES (default 3)
+func KeyframeOptionsFromEnv() KeyframeOptions {
+	opts := KeyframeOptions{Samples: 7, Faces: true, Candidates: 3}
+	if v := os.Getenv("KEYFRAME_SAMPLES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts.Samples = n
+		}
+	}
+	if v := os.Getenv("KEYFRAME_CANDIDATES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts.Candidates = n
+		}
+	}
+	if v := os.Getenv("KEYFRAME_FACES"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			opts.Faces = b
+		}
+	}
+	return opts
+}
+
+// SelectKeyframes scores sampled frames of each scene by sharpness and face presence, writes the best
+// one to outputDir/scene_NNNN_keyframe.jpg and returns the chosen timestamps. When the selection runner
+// fails, it falls back to midpoint extraction and returns midpoint keyframes.
+func (d *Detector) SelectKeyframes(videoPath, outputDir string, scenes []Scene, opts KeyframeOptions) ([]Keyframe, error) {
+	keyframes, err := d.runKeyframeSelection(videoPath, outputDir, scenes, opts)
+	if err == nil {
+		return keyframes, nil
+	}
+	log.Printf("Warning: Keyframe selection failed, falling back to scene midpoints: %v", err)
+	if err := d.ExtractKeyframes(videoPath, outputDir, scenes); err != nil {
+		return nil, err
+	}
+	keyframes = make([]Keyframe, len(scenes))
+	for i, s := range scenes {
+		keyframes[i] = Keyframe{
+			Index:     s.Index,
+			Timestamp: (s.StartTime + s.EndTime) / 2.0,
+			Path:      filepath.Join(outputDir, fmt.Sprintf("scene_%04d_keyframe.jpg", i)),
+		}
+	}
+	return keyframes, nil
+}
+
+func (d *Detector) runKeyframeSelection(videoPath, outputDir string, scenes []Scene, opts KeyframeOptions) ([]Keyframe, error) {
+	script := filepath.Join(filepath.Dir(d.scenedetectScript), "keyframe_runner.py")
+	req, err := json.Marshal(map[string]interface{}{
+		"video_path": videoPath,
+		"output_dir": outputDir,
+		"samples":    opts.Samples,
+		"faces":      opts.Faces,
+		"candidates": opts.Candidates,
+		"scenes":     scenes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Scoring samples every scene, so the budget scales with the scene count
+	timeout := 30 * time.Second
+	if v := os.Getenv("KEYFRAME_TIMEOUT_SECS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			timeout = time.Duration(secs) * time.Second
+		}
+	}
+	ctx, cancel := context.WithTimeout(d.context(), timeout*time.Duration(len(scenes)+1))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, d.pythonPath, script)
+	cmd.Stdin = bytes.NewReader(req)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	var result struct {
+		Keyframes []Keyframe `json:"keyframes"`
+		Error     string     `json:"error,omitempty"`
+	}
+	if jerr := json.Unmarshal(out, &result); jerr == nil && result.Error != "" {
+		return nil, fmt.Errorf("keyframe selection error: %s", result.Error)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run keyframe selection: %v; stderr: %s", err, stderr.String())
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse keyframe selection output: %v", err)
+	}
+	return result.Keyframes, nil
+}
@@ -0,0 +1,67 @@
+package scenedetect
+
+import (
+	"os"
+	"strconv"
+)
+
+// MinSceneDurationFromEnv reads SCENE_MIN_DURATION_SECS (default 1.0; 0 disables merging)
+func MinSceneDurationFromEnv() float64 {
+	if v := os.Getenv("SCENE_MIN_DURATION_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			return f
+		}
+	}
+	return 1.0
+}
+
+// MergeReport summarises a MergeShortScenes pass
+type MergeReport struct {
+	MinDuration  float64 `json:"min_duration"`
+	InputScenes  int     `json:"input_scenes"`
+	OutputScenes int     `json:"output_scenes"`
+	Merged       int     `json:"merged"`
+}
+
+// MergeShortScenes folds scenes shorter than minDuration into a neighbour until none remain (or a
+// single scene is left). The shortest scene is merged first, into whichever adjacent scene is shorter,
+// so rapid cutting collapses into balanced clips. Scenes must be sorted and contiguous; the result is
+// re-indexed from 0.
+func MergeShortScenes(scenes []Scene, minDuration float64) ([]Scene, MergeReport) {
+	report := MergeReport{MinDuration: minDuration, InputScenes: len(scenes), OutputScenes: len(scenes)}
+	if minDuration <= 0 || len(scenes) < 2 {
+		return scenes, report
+	}
+	out := append([]Scene(nil), scenes...)
+	dur := func(s Scene) float64 { return s.EndTime - s.StartTime }
+
+	for len(out) > 1 {
+		shortest := -1
+		for i, s := range out {
+			if dur(s) < minDuration && (shortest < 0 || dur(s) < dur(out[shortest])) {
+				shortest = i
+			}
+		}
+		if shortest < 0 {
+			break
+		}
+		// Pick the neighbour to absorb it
+		target := shortest - 1
+		if shortest == 0 || (shortest+1 < len(out) && dur(out[shortest+1]) < dur(out[shortest-1])) {
+			target = shortest + 1
+		}
+		lo, hi := shortest, target
+		if target < shortest {
+			lo, hi = target, shortest
+		}
+		out[lo].EndTime = out[hi].EndTime
+		out = append(out[:hi], out[hi+1:]...)
+		report.Merged++
+	}
+
+	for i := range out {
+		out[i].Index = i
+	}
+	report.OutputScenes = len(out)
+	return out, report
+}
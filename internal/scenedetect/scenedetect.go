@@ -10,8 +10,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"time"
+
+	"goodclips-server/internal/ffmpeg"
 )
 
+// DefaultThreshold is the content detector's cut threshold when none is given
+const DefaultThreshold = 30.0
+
 // Scene represents a detected scene boundary
 type Scene struct {
 	Index     int     `json:"index"`
@@ -23,6 +28,10 @@ type Scene struct {
 type Detector struct {
 	pythonPath        string
 	scenedetectScript string
+	// ctx kills running detection processes when done (see WithContext)
+	ctx context.Context
+	// ffmpeg extracts midpoint keyframes (see WithFFmpeg)
+	ffmpeg *ffmpeg.FFmpegClient
 }
 
 // NewDetector creates a new scene detector instance
@@ -30,11 +39,48 @@ func NewDetector() *Detector {
     return &Detector{
         pythonPath:        "python3",
         scenedetectScript: "/root/internal/scenedetect/sd_runner.py",
+        ffmpeg:            ffmpeg.NewFFmpegClient(),
     }
 }
 
+// WithContext returns a copy of the detector whose processes are killed once ctx is done; their
+// timeouts still apply
+func (d *Detector) WithContext(ctx context.Context) *Detector {
+    c := *d
+    c.ctx = ctx
+    c.ffmpeg = d.ffmpeg.WithContext(ctx)
+    return &c
+}
+
+// WithFFmpeg returns a copy of the detector extracting midpoint keyframes with client, e.g. one
+// reporting progress (see ffmpeg.FFmpegClient.WithProgress)
+func (d *Detector) WithFFmpeg(client *ffmpeg.FFmpegClient) *Detector {
+    c := *d
+    c.ffmpeg = client
+    return &c
+}
+
+func (d *Detector) context() context.Context {
+    if d.ctx == nil {
+        return context.Background()
+    }
+    return d.ctx
+}
+
 // DetectScenes detects scenes in a video file using PySceneDetect
 func (d *Detector) DetectScenes(videoPath string) ([]Scene, error) {
+    return d.DetectScenesRange(videoPath, 0, 0)
+}
+
+// DetectScenesRange detects scenes within [start, end) seconds of a video; end <= 0 means to the end
+// of the file. Returned times are absolute; the last scene is cut at end.
+func (d *Detector) DetectScenesRange(videoPath string, start, end float64) ([]Scene, error) {
+    return d.DetectScenesRangeWithThreshold(videoPath, start, end, 0)
+}
+
+// DetectScenesRangeWithThreshold is DetectScenesRange with the content detector's cut threshold
+// overridden (lower finds more cuts); threshold <= 0 uses the runner default, DefaultThreshold
+func (d *Detector) DetectScenesRangeWithThreshold(videoPath string, start, end, threshold float64) ([]Scene, error) {
     // Check if Python and required dependencies are available
     if err := d.CheckDependencies(); err != nil {
         return nil, fmt.Errorf("dependencies not available: %v", err)
@@ -47,11 +93,22 @@ func (d *Detector) DetectScenes(videoPath string) ([]Scene, error) {
             detectTimeout = time.Duration(secs) * time.Second
         }
     }
-    ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+    ctx, cancel := context.WithTimeout(d.context(), detectTimeout)
     defer cancel()
 
     // Run PySceneDetect script
-    cmd := exec.CommandContext(ctx, d.pythonPath, d.scenedetectScript, videoPath)
+    args := []string{d.scenedetectScript}
+    if start > 0 {
+        args = append(args, fmt.Sprintf("--start=%.3f", start))
+    }
+    if end > 0 {
+        args = append(args, fmt.Sprintf("--end=%.3f", end))
+    }
+    args = append(args, videoPath)
+    if threshold > 0 {
+        args = append(args, fmt.Sprintf("%.2f", threshold))
+    }
+    cmd := exec.CommandContext(ctx, d.pythonPath, args...)
 
     out, err := cmd.CombinedOutput()
     if err != nil {
@@ -64,6 +121,9 @@ func (d *Detector) DetectScenes(videoPath string) ([]Scene, error) {
         if json.Unmarshal(out, &result) == nil && result.Error != "" {
             return nil, fmt.Errorf("scene detection error: %s", result.Error)
         }
+        if ctx.Err() == context.DeadlineExceeded {
+            return nil, fmt.Errorf("scene detection timed out after %s", detectTimeout)
+        }
         return nil, fmt.Errorf("failed to run scene detection: %v; output: %s", err, string(out))
     }
 
@@ -115,40 +175,21 @@ func (d *Detector) ExtractKeyframes(videoPath string, outputDir string, scenes [
         return fmt.Errorf("failed to create keyframes directory: %v", err)
     }
 
-    // Extract keyframes using ffmpeg directly
-    for i, scene := range scenes {
-        // Extract a keyframe from the middle of each scene
-        midTime := (scene.StartTime + scene.EndTime) / 2.0
-
-        outputPath := filepath.Join(outputDir, fmt.Sprintf("scene_%04d_keyframe.jpg", i))
-
-        // Create a context with timeout for keyframe extraction (configurable, default 30s)
-        keyframeTimeout := 30 * time.Second
-        if v := os.Getenv("KEYFRAME_TIMEOUT_SECS"); v != "" {
-            if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
-                keyframeTimeout = time.Duration(secs) * time.Second
-            }
-        }
-        ctx, cancel := context.WithTimeout(context.Background(), keyframeTimeout)
-
-        cmd := exec.CommandContext(ctx, "ffmpeg",
-            "-ss", fmt.Sprintf("%.2f", midTime),
-            "-i", videoPath,
-            "-vframes", "1",
-            "-q:v", "2",
-            "-y",
-            outputPath,
-        )
-
-        stderr, err := cmd.CombinedOutput()
-        cancel() // ensure context is canceled
-        if err != nil {
-            log.Printf("Warning: Failed to extract keyframe for scene %d: %v\nOutput: %s", i, err, string(stderr))
-            continue
+    // Extract a keyframe from the middle of each scene (timeout per frame configurable, default 30s)
+    keyframeTimeout := 30 * time.Second
+    if v := os.Getenv("KEYFRAME_TIMEOUT_SECS"); v != "" {
+        if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+            keyframeTimeout = time.Duration(secs) * time.Second
         }
-
-        log.Printf("Extracted keyframe for scene %d to %s", i, outputPath)
     }
+    times := make([]float64, len(scenes))
+    outputs := make([]string, len(scenes))
+    for i, scene := range scenes {
+        times[i] = (scene.StartTime + scene.EndTime) / 2.0
+        outputs[i] = filepath.Join(outputDir, fmt.Sprintf("scene_%04d_keyframe.jpg", i))
+    }
+    n := d.ffmpeg.ExtractKeyframes(videoPath, times, outputs, keyframeTimeout)
+    log.Printf("Extracted %d of %d keyframes to %s", n, len(scenes), outputDir)
 
     return nil
 }
\ No newline at end of file
@@ -15,18 +15,23 @@ if _script_dir in sys.path:
 from scenedetect import open_video, SceneManager
 from scenedetect.detectors import ContentDetector
 
-def detect_scenes(video_path, threshold=30.0):
-    """Detect scenes in a video file using PySceneDetect"""
+def detect_scenes(video_path, threshold=30.0, start=None, end=None):
+    """Detect scenes in a video file using PySceneDetect, optionally limited to [start, end) seconds"""
     try:
         # Open the video
         video = open_video(video_path)
+        if start:
+            video.seek(start)
         
         # Create a scene manager and add detectors
         scene_manager = SceneManager()
         scene_manager.add_detector(ContentDetector(threshold=threshold))
         
         # Detect scenes
-        scene_manager.detect_scenes(video, show_progress=False)
+        if end:
+            scene_manager.detect_scenes(video, end_time=end, show_progress=False)
+        else:
+            scene_manager.detect_scenes(video, show_progress=False)
         
         # Get the list of scenes
         scenes = scene_manager.get_scene_list()
@@ -82,17 +87,28 @@ def extract_keyframes(video_path, scenes, output_dir):
 
 
 if __name__ == "__main__":
-    if len(sys.argv) < 2:
-        print("Usage: python3 sd_runner.py <video_path> [threshold] [output_dir]")
+    # Optional --start=SECONDS / --end=SECONDS limit detection to a time range
+    start = end = None
+    args = []
+    for arg in sys.argv[1:]:
+        if arg.startswith('--start='):
+            start = float(arg.split('=', 1)[1])
+        elif arg.startswith('--end='):
+            end = float(arg.split('=', 1)[1])
+        else:
+            args.append(arg)
+
+    if len(args) < 1:
+        print("Usage: python3 sd_runner.py [--start=S] [--end=E] <video_path> [threshold] [output_dir]")
         sys.exit(1)
     
-    video_path = sys.argv[1]
-    threshold = float(sys.argv[2]) if len(sys.argv) > 2 else 30.0
-    output_dir = sys.argv[3] if len(sys.argv) > 3 else None
+    video_path = args[0]
+    threshold = float(args[1]) if len(args) > 1 else 30.0
+    output_dir = args[2] if len(args) > 2 else None
     
     try:
         # Detect scenes
-        scenes = detect_scenes(video_path, threshold)
+        scenes = detect_scenes(video_path, threshold, start, end)
         
         # If output directory is provided, extract keyframes
         if output_dir:
@@ -0,0 +1,157 @@
+package scenedetect
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Gap handling modes for ValidateScenes
+const (
+	GapModeFill   = "fill"   // insert a filler scene covering the gap
+	GapModeFlag   = "flag"   // keep the gap, record it in the report
+	GapModeReject = "reject" // fail validation
+)
+
+// ValidationOptions controls scene range validation
+type ValidationOptions struct {
+	// Tolerance is the largest gap/overlap (seconds) silently snapped to the neighbouring boundary
+	Tolerance float64
+	// GapMode decides what happens to gaps larger than Tolerance
+	GapMode string
+}
+
+// ValidationOptionsFromEnv reads SCENE_BOUNDARY_TOLERANCE_SECS (default 0.5) and SCENE_GAP_MODE (default fill)
+func ValidationOptionsFromEnv() ValidationOptions {
+	opts := ValidationOptions{Tolerance: 0.5, GapMode: GapModeFill}
+	if v := os.Getenv("SCENE_BOUNDARY_TOLERANCE_SECS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			opts.Tolerance = f
+		}
+	}
+	switch m := os.Getenv("SCENE_GAP_MODE"); m {
+	case GapModeFill, GapModeFlag, GapModeReject:
+		opts.GapMode = m
+	}
+	return opts
+}
+
+// Gap is an uncovered time range between scenes
+type Gap struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// ValidationReport summarises what ValidateScenes changed
+type ValidationReport struct {
+	InputScenes     int     `json:"input_scenes"`
+	OutputScenes    int     `json:"output_scenes"`
+	DroppedEmpty    int     `json:"dropped_empty"`
+	SnappedBounds   int     `json:"snapped_bounds"`
+	ClampedToLength int     `json:"clamped_to_length"`
+	Gaps            []Gap   `json:"gaps,omitempty"`
+	GapsFilled      int     `json:"gaps_filled"`
+	Coverage        float64 `json:"coverage"`
+}
+
+// ValidateScenes checks detector output before it is stored: ranges must be finite, ordered,
+// non-overlapping and cover [0, duration] within opts.Tolerance. Small gaps and overlaps are snapped,
+// zero-length scenes dropped, and larger gaps filled, flagged or rejected per opts.GapMode. Ranges
+// that cannot be repaired (negative, inverted, non-finite, overlapping beyond tolerance, or starting
+// past the end of the video) are rejected with an error. A duration <= 0 skips the coverage checks.
+// The returned scenes are re-indexed from 0.
+func ValidateScenes(scenes []Scene, duration float64, opts ValidationOptions) ([]Scene, ValidationReport, error) {
+	report := ValidationReport{InputScenes: len(scenes)}
+	tol := opts.Tolerance
+
+	sorted := make([]Scene, 0, len(scenes))
+	for _, s := range scenes {
+		if math.IsNaN(s.StartTime) || math.IsNaN(s.EndTime) || math.IsInf(s.StartTime, 0) || math.IsInf(s.EndTime, 0) {
+			return nil, report, fmt.Errorf("scene %d has a non-finite range", s.Index)
+		}
+		if s.StartTime < -tol || s.EndTime < s.StartTime {
+			return nil, report, fmt.Errorf("scene %d has an invalid range %.3f-%.3f", s.Index, s.StartTime, s.EndTime)
+		}
+		if duration > 0 && s.StartTime > duration+tol {
+			return nil, report, fmt.Errorf("scene %d starts at %.3f, past the video end %.3f", s.Index, s.StartTime, duration)
+		}
+		if s.StartTime < 0 {
+			s.StartTime = 0
+			report.SnappedBounds++
+		}
+		if duration > 0 && s.EndTime > duration {
+			s.EndTime = duration
+			report.ClampedToLength++
+		}
+		if s.EndTime-s.StartTime <= 0 {
+			report.DroppedEmpty++
+			continue
+		}
+		sorted = append(sorted, s)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartTime < sorted[j].StartTime })
+
+	out := make([]Scene, 0, len(sorted)+2)
+	cursor := 0.0
+	handleGap := func(start, end float64) error {
+		report.Gaps = append(report.Gaps, Gap{Start: start, End: end})
+		switch opts.GapMode {
+		case GapModeReject:
+			return fmt.Errorf("gap in scene coverage %.3f-%.3f", start, end)
+		case GapModeFill:
+			out = append(out, Scene{StartTime: start, EndTime: end})
+			report.GapsFilled++
+		}
+		return nil
+	}
+
+	for _, s := range sorted {
+		delta := s.StartTime - cursor
+		switch {
+		case delta < -tol:
+			return nil, report, fmt.Errorf("scene %d (%.3f-%.3f) overlaps the previous scene by %.3fs", s.Index, s.StartTime, s.EndTime, -delta)
+		case delta < 0, delta > 0 && delta <= tol:
+			// Snap to the previous boundary; for the first scene that extends it back to 0
+			if len(out) > 0 && delta > 0 {
+				out[len(out)-1].EndTime = s.StartTime
+			} else {
+				s.StartTime = cursor
+			}
+			report.SnappedBounds++
+		case delta > tol:
+			if err := handleGap(cursor, s.StartTime); err != nil {
+				return nil, report, err
+			}
+		}
+		if s.EndTime-s.StartTime <= 0 {
+			report.DroppedEmpty++
+			continue
+		}
+		out = append(out, s)
+		cursor = s.EndTime
+	}
+
+	if duration > 0 {
+		if tail := duration - cursor; tail > tol {
+			if err := handleGap(cursor, duration); err != nil {
+				return nil, report, err
+			}
+		} else if tail > 0 && len(out) > 0 {
+			out[len(out)-1].EndTime = duration
+			report.SnappedBounds++
+		}
+	}
+
+	covered := 0.0
+	for i := range out {
+		out[i].Index = i
+		covered += out[i].EndTime - out[i].StartTime
+	}
+	if duration > 0 {
+		report.Coverage = math.Min(1, covered/duration)
+	}
+	report.OutputScenes = len(out)
+	return out, report, nil
+}
@@ -0,0 +1,168 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrExpired          = errors.New("signed url expired")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrBadSignature     = errors.New("invalid signature")
+)
+
+// Signer issues and verifies HMAC-SHA256 signed, expiring URLs.
+// Several keys may be configured so old URLs keep verifying while new ones are signed with the active key.
+type Signer struct {
+	keys      map[string][]byte
+	activeKID string
+	now       func() time.Time
+}
+
+// New creates a signer from kid -> secret pairs; activeKID selects the key used for new signatures
+func New(keys map[string][]byte, activeKID string) (*Signer, error) {
+	if _, ok := keys[activeKID]; !ok {
+		return nil, fmt.Errorf("active key %q not configured", activeKID)
+	}
+	return &Signer{keys: keys, activeKID: activeKID, now: time.Now}, nil
+}
+
+// NewFromEnv builds a signer from SIGNED_URL_KEYS ("kid1:secret1,kid2:secret2") and SIGNED_URL_ACTIVE_KID
+// (defaults to the first key). When no keys are configured an ephemeral random key is generated, so
+// issued URLs stop verifying after a restart; the second return value reports that case.
+func NewFromEnv() (*Signer, bool, error) {
+	keys := map[string][]byte{}
+	var first string
+	for _, pair := range strings.Split(os.Getenv("SIGNED_URL_KEYS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(pair, ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, false, fmt.Errorf("invalid SIGNED_URL_KEYS entry %q (want kid:secret)", pair)
+		}
+		keys[kid] = []byte(secret)
+		if first == "" {
+			first = kid
+		}
+	}
+	if len(keys) == 0 {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, false, err
+		}
+		s, err := New(map[string][]byte{"ephemeral": secret}, "ephemeral")
+		return s, true, err
+	}
+	active := os.Getenv("SIGNED_URL_ACTIVE_KID")
+	if active == "" {
+		active = first
+	}
+	s, err := New(keys, active)
+	return s, false, err
+}
+
+// Sign returns path (which must not carry a query string) with exp, kid and sig parameters valid for ttl
+func (s *Signer) Sign(path string, ttl time.Duration) string {
+	return s.SignWith(path, nil, ttl)
+}
+
+// SignWith is Sign for a URL carrying extra parameters, e.g. the viewer and watermark of a share
+// link. They are covered by the signature and listed in sp, so they can be neither changed nor
+// dropped. Empty values are left out.
+func (s *Signer) SignWith(path string, params map[string]string, ttl time.Duration) string {
+	exp := s.now().Add(ttl).Unix()
+	q := url.Values{}
+	var names []string
+	for name, v := range params {
+		if v != "" {
+			q.Set(name, v)
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		q.Set("sp", strings.Join(names, ","))
+	}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("kid", s.activeKID)
+	q.Set("sig", s.mac(s.activeKID, path, exp, signedParams(q)))
+	return path + "?" + q.Encode()
+}
+
+// signedParams are the parameters of query listed in sp, encoded for the signature ("" when none)
+func signedParams(query url.Values) string {
+	sp := query.Get("sp")
+	if sp == "" {
+		return ""
+	}
+	signed := url.Values{"sp": {sp}}
+	for _, name := range strings.Split(sp, ",") {
+		signed.Set(name, query.Get(name))
+	}
+	return signed.Encode()
+}
+
+// SignedValue returns a parameter of a verified query only when the signature covers it (it is
+// listed in sp); a parameter appended to a signed URL afterwards reads as ""
+func SignedValue(query url.Values, name string) string {
+	for _, n := range strings.Split(query.Get("sp"), ",") {
+		if n == name {
+			return query.Get(name)
+		}
+	}
+	return ""
+}
+
+// Verify checks the signature parameters in query against path (without the query string)
+func (s *Signer) Verify(path string, query url.Values) error {
+	sig := query.Get("sig")
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	exp, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if s.now().Unix() > exp {
+		return ErrExpired
+	}
+	kid := query.Get("kid")
+	if _, ok := s.keys[kid]; !ok {
+		return ErrUnknownKey
+	}
+	expected := s.mac(kid, path, exp, signedParams(query))
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// ActiveKID returns the key ID used for new signatures
+func (s *Signer) ActiveKID() string {
+	return s.activeKID
+}
+
+func (s *Signer) mac(kid, path string, exp int64, params string) string {
+	h := hmac.New(sha256.New, s.keys[kid])
+	h.Write([]byte(path))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(strconv.FormatInt(exp, 10)))
+	if params != "" {
+		h.Write([]byte{'\n'})
+		h.Write([]byte(params))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
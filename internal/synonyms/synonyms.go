@@ -0,0 +1,157 @@
+package synonyms
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Entry is one canonical term and its aliases (character nicknames, location aliases, ...)
+type Entry struct {
+	Term    string   `json:"term"`
+	Aliases []string `json:"aliases"`
+}
+
+// Dictionary groups equivalent terms so a query for any of them matches all of them
+type Dictionary struct {
+	groups  [][]string     // each group: canonical term first, then aliases (lower-cased)
+	byTerm  map[string]int // lower-cased term/alias -> group index
+	maxSpan int            // longest term in words, bounds phrase matching
+}
+
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}]+(?:'[\p{L}\p{N}]+)*`)
+
+// New builds a dictionary from entries; terms are matched case-insensitively
+func New(entries []Entry) *Dictionary {
+	d := &Dictionary{byTerm: map[string]int{}, maxSpan: 1}
+	for _, e := range entries {
+		term := normalize(e.Term)
+		if term == "" {
+			continue
+		}
+		group := []string{term}
+		for _, a := range e.Aliases {
+			if a = normalize(a); a != "" && a != term {
+				group = append(group, a)
+			}
+		}
+		idx := len(d.groups)
+		d.groups = append(d.groups, group)
+		for _, t := range group {
+			d.byTerm[t] = idx
+			if n := len(strings.Fields(t)); n > d.maxSpan {
+				d.maxSpan = n
+			}
+		}
+	}
+	return d
+}
+
+// Len returns the number of synonym groups
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.groups)
+}
+
+// ExpandQuery appends the equivalent terms of every dictionary term found in query,
+// so semantic embedders see both the nickname and the canonical name.
+func (d *Dictionary) ExpandQuery(query string) string {
+	if d.Len() == 0 {
+		return query
+	}
+	ws := words(query)
+	// Terms are compared as whole words, so that "bob" is still added to "bobby"
+	present := " " + strings.Join(ws, " ") + " "
+	var extra []string
+	seen := map[string]bool{}
+	for _, g := range d.matchGroups(ws) {
+		for _, t := range d.groups[g] {
+			if !seen[t] && !strings.Contains(present, " "+t+" ") {
+				seen[t] = true
+				extra = append(extra, t)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return query
+	}
+	return query + " (" + strings.Join(extra, ", ") + ")"
+}
+
+// TSQuery builds a to_tsquery expression for query where each word (or multi-word dictionary term)
+// is OR-ed with its aliases and the groups are AND-ed. Only letters, digits and apostrophes survive,
+// so the result is safe to pass as a bind parameter to to_tsquery.
+func (d *Dictionary) TSQuery(query string) string {
+	ws := words(query)
+	var parts []string
+	for i := 0; i < len(ws); {
+		span, group := 1, -1
+		if d.Len() > 0 {
+			span, group = d.longestMatch(ws, i)
+		}
+		if group < 0 {
+			parts = append(parts, tsLexeme(ws[i]))
+			i++
+			continue
+		}
+		alts := make([]string, 0, len(d.groups[group]))
+		for _, t := range d.groups[group] {
+			alts = append(alts, tsPhrase(t))
+		}
+		parts = append(parts, "("+strings.Join(alts, " | ")+")")
+		i += span
+	}
+	return strings.Join(parts, " & ")
+}
+
+// matchGroups returns the indexes of groups whose terms appear in ws
+func (d *Dictionary) matchGroups(ws []string) []int {
+	var out []int
+	seen := map[int]bool{}
+	for i := 0; i < len(ws); {
+		span, g := d.longestMatch(ws, i)
+		if g >= 0 && !seen[g] {
+			seen[g] = true
+			out = append(out, g)
+		}
+		i += span
+	}
+	return out
+}
+
+// longestMatch finds the longest dictionary term starting at ws[i]; it returns span 1 and group -1 on no match
+func (d *Dictionary) longestMatch(ws []string, i int) (int, int) {
+	for n := d.maxSpan; n >= 1; n-- {
+		if i+n > len(ws) {
+			continue
+		}
+		if g, ok := d.byTerm[strings.Join(ws[i:i+n], " ")]; ok {
+			return n, g
+		}
+	}
+	return 1, -1
+}
+
+func words(s string) []string {
+	return wordRe.FindAllString(strings.ToLower(s), -1)
+}
+
+func normalize(s string) string {
+	return strings.Join(words(s), " ")
+}
+
+func tsLexeme(w string) string {
+	return strings.ReplaceAll(w, "'", "")
+}
+
+func tsPhrase(term string) string {
+	ws := strings.Fields(term)
+	for i, w := range ws {
+		ws[i] = tsLexeme(w)
+	}
+	if len(ws) == 1 {
+		return ws[0]
+	}
+	return "(" + strings.Join(ws, " <-> ") + ")"
+}
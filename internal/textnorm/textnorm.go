@@ -0,0 +1,66 @@
+// Package textnorm cleans caption text for storage, embedding and keyword search: subtitle markup,
+// HTML entities, speaker dashes and music notes are removed and whitespace and Unicode forms unified.
+package textnorm
+
+import (
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// tagRe matches HTML-style formatting tags (<i>, </b>, <font color="...">)
+	tagRe = regexp.MustCompile(`</?[a-zA-Z][^<>]*>`)
+	// assRe matches SSA/ASS override blocks such as {\an8} or {\i1}
+	assRe = regexp.MustCompile(`\{\\[^{}]*\}`)
+	// speakerDashRe matches the dash that marks a change of speaker at the start of a line, followed
+	// by a space or a letter (not a digit: "-5 degrees")
+	speakerDashRe = regexp.MustCompile(`(?m)^[ \t]*[-‐‑–—](?:[ \t]+|(\pL))`)
+	// musicRe matches music notes
+	musicRe = regexp.MustCompile(`[♩♪♫♬]+`)
+)
+
+// Options are the optional normalization steps
+type Options struct {
+	// Lowercase folds the text to lower case
+	Lowercase bool
+}
+
+// OptionsFromEnv reads the normalization options: CAPTION_NORMALIZE_LOWERCASE (default false)
+func OptionsFromEnv() Options {
+	lower, _ := strconv.ParseBool(os.Getenv("CAPTION_NORMALIZE_LOWERCASE"))
+	return Options{Lowercase: lower}
+}
+
+// Normalize returns s as plain text: entities are decoded, formatting tags, speaker dashes and music
+// notes removed, the text put in Unicode NFKC form (full-width letters, ligatures), control and
+// zero-width characters dropped and whitespace, line breaks included, collapsed to single spaces.
+// Text that is only markup or music normalizes to "".
+func Normalize(s string, opts Options) string {
+	s = html.UnescapeString(s)
+	s = tagRe.ReplaceAllString(s, "")
+	s = assRe.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, `\N`, "\n")
+	s = speakerDashRe.ReplaceAllString(s, "${1}")
+	s = musicRe.ReplaceAllString(s, " ")
+	s = norm.NFKC.String(s)
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if opts.Lowercase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
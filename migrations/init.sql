@@ -13,7 +13,12 @@ CREATE TABLE videos (
     uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
     filename VARCHAR(512) NOT NULL,
     filepath VARCHAR(1024) NOT NULL,
-    file_hash CHAR(64) UNIQUE NOT NULL,
+    asset_type VARCHAR(16) NOT NULL DEFAULT 'video' CHECK (asset_type IN ('video', 'audio', 'image')),
+    -- SHA-256 of the source file, computed by the ingestion worker (NULL until then and for live sources)
+    file_hash CHAR(64),
+    -- Set when the video shares the scenes, captions and embeddings of another project's video with
+    -- the same source (shared ingestion); its own rows hold only per-project metadata
+    content_video_id INTEGER REFERENCES videos(id) ON DELETE SET NULL,
     title VARCHAR(256),
     duration REAL NOT NULL DEFAULT 0,
     scene_count INTEGER DEFAULT 0,
@@ -25,7 +30,19 @@ CREATE TABLE videos (
     tags JSONB DEFAULT '[]'::jsonb,
     status VARCHAR(32) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'error', 'deleted')),
     metadata JSONB DEFAULT '{}'::jsonb,
-    error_message TEXT
+    error_message TEXT,
+    -- Classification of the first failed stage's error (queue.ErrorCode), for triage
+    error_code VARCHAR(32),
+    -- Legal hold: locked videos cannot be deleted or have their source file replaced
+    locked BOOLEAN NOT NULL DEFAULT FALSE,
+    lock_reason TEXT,
+    locked_at TIMESTAMP WITH TIME ZONE,
+    -- Live: the source is still growing and is ingested incrementally
+    live BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Video-level text embedding of title + synopsis + tags (e5-base-v2)
+    text_embedding vector(768),
+    -- Optimistic locking: bumped by every update (bump_video_version)
+    version INTEGER NOT NULL DEFAULT 1
 );
 
 -- Scenes table - stores individual scene data with embeddings
@@ -33,7 +50,14 @@ CREATE TABLE scenes (
     id SERIAL PRIMARY KEY,
     uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
     video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    -- 'shot' (detector cuts) or 'beat' (groups of consecutive shots); scene_index is per level
+    level VARCHAR(16) NOT NULL DEFAULT 'shot' CHECK (level IN ('shot', 'beat')),
     scene_index INTEGER NOT NULL,
+    -- For shots: scene_index of the containing beat
+    beat_index INTEGER,
+    keyframe_time REAL,
+    -- JPEG of the keyframe, served as the scene's thumbnail
+    keyframe_path VARCHAR(1024),
     start_time REAL NOT NULL,
     end_time REAL NOT NULL,
     duration REAL GENERATED ALWAYS AS (end_time - start_time) STORED,
@@ -49,8 +73,8 @@ CREATE TABLE scenes (
     
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     
-    -- Ensure scene_index is unique within each video
-    UNIQUE(video_id, scene_index)
+    -- Ensure scene_index is unique within each video and level
+    UNIQUE(video_id, level, scene_index)
 );
 
 -- Captions/Subtitles table - stores extracted text with timing
@@ -63,6 +87,8 @@ CREATE TABLE captions (
     end_time REAL NOT NULL,
     duration REAL GENERATED ALWAYS AS (end_time - start_time) STORED,
     text TEXT NOT NULL,
+    -- Caption as found in the subtitle file; text holds the normalized form
+    raw_text TEXT,
     language VARCHAR(10) DEFAULT 'en',
     confidence REAL DEFAULT 1.0,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
@@ -83,32 +109,522 @@ CREATE TABLE processing_jobs (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Scene keyframes table - candidate representative frames per scene; the selected one is the display frame
+CREATE TABLE scene_keyframes (
+    id SERIAL PRIMARY KEY,
+    scene_id INTEGER NOT NULL REFERENCES scenes(id) ON DELETE CASCADE,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL,
+    timestamp REAL NOT NULL,
+    score REAL,
+    sharpness REAL,
+    faces INTEGER DEFAULT 0,
+    path VARCHAR(1024) NOT NULL,
+    selected BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Synonyms table - domain term aliases used for query expansion
+CREATE TABLE synonyms (
+    id SERIAL PRIMARY KEY,
+    term VARCHAR(256) UNIQUE NOT NULL,
+    aliases JSONB DEFAULT '[]'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Search events table - one row per executed search, for analytics
+CREATE TABLE search_events (
+    id SERIAL PRIMARY KEY,
+    modality VARCHAR(32) NOT NULL,
+    query TEXT,
+    filters JSONB DEFAULT '{}'::jsonb,
+    latency_ms REAL,
+    result_count INTEGER DEFAULT 0,
+    result_scene_ids JSONB DEFAULT '[]'::jsonb,
+    click_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Search feedback table - results users opened/exported for a query
+CREATE TABLE search_feedback (
+    id SERIAL PRIMARY KEY,
+    search_event_id INTEGER REFERENCES search_events(id) ON DELETE SET NULL,
+    query TEXT,
+    normalized_query VARCHAR(512),
+    scene_id INTEGER NOT NULL REFERENCES scenes(id) ON DELETE CASCADE,
+    action VARCHAR(32) NOT NULL CHECK (action IN ('open', 'export')),
+    position INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Content flags table - profanity/sensitive wordlist hits in captions, with estimated spoken ranges
+CREATE TABLE content_flags (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    caption_id INTEGER NOT NULL REFERENCES captions(id) ON DELETE CASCADE,
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    term VARCHAR(256) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Scene tones table - caption sentiment/emotion and audio emotion per scene
+CREATE TABLE scene_tones (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    scene_id INTEGER NOT NULL UNIQUE REFERENCES scenes(id) ON DELETE CASCADE,
+    sentiment REAL CHECK (sentiment BETWEEN -1 AND 1),
+    emotion VARCHAR(16),
+    emotion_scores JSONB DEFAULT '{}',
+    audio_emotion VARCHAR(16),
+    audio_emotion_scores JSONB DEFAULT '{}',
+    intensity REAL NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Alerts table - standing keyword/semantic queries evaluated against new ingests
+CREATE TABLE alerts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    alert_type VARCHAR(16) NOT NULL CHECK (alert_type IN ('keyword', 'semantic')),
+    query TEXT NOT NULL,
+    level VARCHAR(16) NOT NULL DEFAULT 'shot',
+    threshold REAL DEFAULT 0,
+    webhook_url VARCHAR(1024),
+    emails JSONB DEFAULT '[]',
+    active BOOLEAN DEFAULT TRUE,
+    embedding vector(768),
+    match_count INTEGER DEFAULT 0,
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Alert matches table - scenes that satisfied an alert, with their delivery state
+CREATE TABLE alert_matches (
+    id SERIAL PRIMARY KEY,
+    alert_id INTEGER NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    scene_id INTEGER NOT NULL REFERENCES scenes(id) ON DELETE CASCADE,
+    scene_index INTEGER,
+    start_time REAL,
+    end_time REAL,
+    score REAL,
+    snippet TEXT,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    notify_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(alert_id, scene_id)
+);
+
+-- Notification channels table - webhook/Slack/email destinations for pipeline events
+CREATE TABLE notification_channels (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('webhook', 'slack', 'email')),
+    target VARCHAR(2048) NOT NULL,
+    events JSONB DEFAULT '[]',
+    project VARCHAR(128),
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Library snapshots table - daily library size and usage for the stats time series
+CREATE TABLE library_snapshots (
+    id SERIAL PRIMARY KEY,
+    day DATE NOT NULL UNIQUE,
+    total_videos INTEGER DEFAULT 0,
+    total_hours DOUBLE PRECISION DEFAULT 0,
+    total_scenes INTEGER DEFAULT 0,
+    scenes_with_embeddings INTEGER DEFAULT 0,
+    embedding_coverage DOUBLE PRECISION DEFAULT 0,
+    total_captions INTEGER DEFAULT 0,
+    videos_ingested INTEGER DEFAULT 0,
+    hours_ingested DOUBLE PRECISION DEFAULT 0,
+    searches INTEGER DEFAULT 0,
+    zero_result_searches INTEGER DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Embedding models table - registry driving rolling upgrades of the scene text embedding model
+CREATE TABLE embedding_models (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) NOT NULL UNIQUE,
+    modality VARCHAR(16) NOT NULL DEFAULT 'text',
+    model_id VARCHAR(256) NOT NULL,
+    dimensions INTEGER,
+    status VARCHAR(16) NOT NULL DEFAULT 'registered' CHECK (status IN ('registered', 'backfilling', 'validating', 'active', 'retired', 'purged', 'failed')),
+    backfilled_scenes INTEGER DEFAULT 0,
+    total_scenes INTEGER DEFAULT 0,
+    backfill_cursor INTEGER DEFAULT 0,
+    error TEXT,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    retired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Scene embeddings table - per-model scene text vectors for models other than the active one
+CREATE TABLE scene_embeddings (
+    id SERIAL PRIMARY KEY,
+    scene_id INTEGER NOT NULL REFERENCES scenes(id) ON DELETE CASCADE,
+    embedding_model_id INTEGER NOT NULL REFERENCES embedding_models(id) ON DELETE CASCADE,
+    embedding vector NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(scene_id, embedding_model_id)
+);
+
+-- Scene previews table - scene detection runs with alternative parameters held for review before commit
+CREATE TABLE scene_previews (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'committing', 'committed', 'discarded', 'failed')),
+    threshold DOUBLE PRECISION,
+    min_scene_duration DOUBLE PRECISION,
+    scenes JSONB DEFAULT '[]'::jsonb,
+    error TEXT,
+    committed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Scene detection cache - raw shot boundaries per source SHA-256 and detector parameters, reused when
+-- the same content is ingested again
+CREATE TABLE scene_detection_cache (
+    file_hash VARCHAR(64) NOT NULL,
+    params VARCHAR(128) NOT NULL,
+    scenes JSONB DEFAULT '[]'::jsonb,
+    scene_count INTEGER NOT NULL DEFAULT 0,
+    hits INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_hit_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (file_hash, params)
+);
+
+-- Annotations table - notes, favorites and collection entries anchored to a video time range; scene_id
+-- is re-resolved from the range after re-segmentation
+CREATE TABLE annotations (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    start_time DOUBLE PRECISION NOT NULL,
+    end_time DOUBLE PRECISION NOT NULL,
+    level VARCHAR(16) NOT NULL DEFAULT 'shot',
+    scene_id INTEGER REFERENCES scenes(id) ON DELETE SET NULL,
+    kind VARCHAR(16) NOT NULL DEFAULT 'note' CHECK (kind IN ('note', 'favorite')),
+    collection VARCHAR(128),
+    text TEXT,
+    author VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (end_time >= start_time)
+);
+
+-- Caption embeddings table - text embeddings of single captions or sliding windows of captions for
+-- passage-level retrieval; caption_id is the first caption of the window
+CREATE TABLE captions_embeddings (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    caption_id INTEGER NOT NULL REFERENCES captions(id) ON DELETE CASCADE,
+    caption_count INTEGER NOT NULL DEFAULT 1,
+    start_time DOUBLE PRECISION NOT NULL,
+    end_time DOUBLE PRECISION NOT NULL,
+    text TEXT NOT NULL,
+    embedding vector(768),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Caption words table - word-level timings from forced alignment; position orders a video's words
+-- across captions
+CREATE TABLE caption_words (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    caption_id INTEGER NOT NULL REFERENCES captions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    word_index INTEGER NOT NULL,
+    word TEXT NOT NULL,
+    norm TEXT NOT NULL,
+    start_time DOUBLE PRECISION NOT NULL,
+    end_time DOUBLE PRECISION NOT NULL,
+    score REAL NOT NULL DEFAULT 0,
+    aligned BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (video_id, position)
+);
+
+-- Supercuts table - every utterance of a phrase across videos, padded and concatenated into one export
+CREATE TABLE supercuts (
+    id SERIAL PRIMARY KEY,
+    phrase TEXT NOT NULL,
+    video_ids JSONB DEFAULT '[]'::jsonb,
+    pad_before REAL NOT NULL DEFAULT 0,
+    pad_after REAL NOT NULL DEFAULT 0,
+    ordering VARCHAR(16) NOT NULL DEFAULT 'video' CHECK (ordering IN ('video', 'duration', 'random')),
+    max_clips INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    items JSONB DEFAULT '[]'::jsonb,
+    total_duration REAL DEFAULT 0,
+    export_path VARCHAR(1024),
+    error_message TEXT,
+    job_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Clip exports table - scene ranges cut out of their source video into MP4s
+CREATE TABLE clip_exports (
+    id SERIAL PRIMARY KEY,
+    scene_id INTEGER NOT NULL REFERENCES scenes(id) ON DELETE CASCADE,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    mode VARCHAR(16) NOT NULL DEFAULT 'auto' CHECK (mode IN ('auto', 'copy', 'reencode')),
+    method VARCHAR(16),
+    censor VARCHAR(16),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    file_size BIGINT DEFAULT 0,
+    export_path VARCHAR(1024),
+    error_message TEXT,
+    job_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Artifacts table - rendered files (exports) tracked for storage tiering: rarely downloaded ones are
+-- moved to cold storage and restored on their next download
+CREATE TABLE artifacts (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('clip_export', 'highlight_reel', 'supercut')),
+    -- ID of the artifact's row in its kind's table
+    owner_id INTEGER NOT NULL,
+    -- Where the file is served from while hot
+    path VARCHAR(1024) NOT NULL,
+    cold_path VARCHAR(1024),
+    tier VARCHAR(16) NOT NULL DEFAULT 'hot' CHECK (tier IN ('hot', 'cold', 'restoring')),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE,
+    restored_at TIMESTAMP WITH TIME ZONE,
+    restore_job_id VARCHAR(64),
+    -- When the current restore was claimed; a claim older than the restore timeout is taken over
+    restore_claimed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (kind, owner_id)
+);
+
+-- Caption QA reports table - per-video caption quality, recomputed by caption_qa jobs
+CREATE TABLE caption_qa_reports (
+    video_id INTEGER PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    caption_count INTEGER NOT NULL DEFAULT 0,
+    duration REAL DEFAULT 0,
+    captioned_seconds REAL DEFAULT 0,
+    coverage REAL DEFAULT 0,
+    avg_confidence REAL DEFAULT 0,
+    low_confidence_count INTEGER DEFAULT 0,
+    gap_count INTEGER DEFAULT 0,
+    longest_gap REAL DEFAULT 0,
+    overlap_count INTEGER DEFAULT 0,
+    mojibake_count INTEGER DEFAULT 0,
+    fast_count INTEGER DEFAULT 0,
+    zero_duration_count INTEGER DEFAULT 0,
+    score REAL NOT NULL DEFAULT 0,
+    issues JSONB DEFAULT '[]'::jsonb,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Caption translations table - captions machine-translated into other languages, timed like their source
+CREATE TABLE caption_translations (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    caption_id INTEGER NOT NULL REFERENCES captions(id) ON DELETE CASCADE,
+    language VARCHAR(10) NOT NULL,
+    text TEXT NOT NULL,
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    model VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(caption_id, language)
+);
+
+-- Scene text filters table - boilerplate rules stripped from captions aggregated into scene text
+-- ('' is the default set; a project's set adds to it when inherit is set, else replaces it)
+CREATE TABLE scene_text_filters (
+    project VARCHAR(128) PRIMARY KEY,
+    patterns JSONB DEFAULT '[]'::jsonb,
+    stopwords JSONB DEFAULT '[]'::jsonb,
+    inherit BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Project watermarks table - per-project overrides of the server watermark on exported clips and
+-- shared cuts
+CREATE TABLE project_watermarks (
+    project VARCHAR(128) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    text TEXT,
+    image_path VARCHAR(1024),
+    position VARCHAR(16),
+    opacity REAL DEFAULT 0,
+    font_size INTEGER DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Feature flags table - runtime overrides of pipeline stage flags
+CREATE TABLE feature_flags (
+    name VARCHAR(64) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Scene entities table - people, places and organizations named in each scene's captions
+CREATE TABLE scene_entities (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    scene_id INTEGER NOT NULL REFERENCES scenes(id) ON DELETE CASCADE,
+    entity VARCHAR(256) NOT NULL,
+    normalized VARCHAR(256) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL CHECK (entity_type IN ('person', 'place', 'organization', 'misc')),
+    mentions INTEGER DEFAULT 1,
+    first_time REAL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(scene_id, normalized, entity_type)
+);
+
+-- Video topics table - caption-derived topic timeline segments per video
+CREATE TABLE video_topics (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    topic_index INTEGER NOT NULL,
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    label TEXT,
+    keywords JSONB DEFAULT '[]'::jsonb,
+    excerpt TEXT,
+    caption_count INTEGER DEFAULT 0,
+    embedding vector(768),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(video_id, topic_index)
+);
+
+-- Highlight reels table - prompt-ranked scene selections of a target length, optionally exported
+CREATE TABLE highlight_reels (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER REFERENCES videos(id) ON DELETE CASCADE,
+    prompt TEXT NOT NULL,
+    level VARCHAR(16) NOT NULL DEFAULT 'shot',
+    target_duration REAL NOT NULL,
+    options JSONB DEFAULT '{}'::jsonb,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    items JSONB DEFAULT '[]'::jsonb,
+    total_duration REAL DEFAULT 0,
+    export_path VARCHAR(1024),
+    error_message TEXT,
+    job_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Indexes for performance
 
 -- Videos indexes
 CREATE INDEX idx_videos_status ON videos(status);
 CREATE INDEX idx_videos_created_at ON videos(created_at DESC);
-CREATE INDEX idx_videos_file_hash ON videos(file_hash);
+CREATE UNIQUE INDEX idx_videos_file_hash ON videos(file_hash) WHERE status <> 'deleted' AND content_video_id IS NULL;
+CREATE INDEX idx_videos_content_video_id ON videos(content_video_id) WHERE content_video_id IS NOT NULL;
 CREATE INDEX idx_videos_tags ON videos USING GIN(tags);
 CREATE INDEX idx_videos_metadata ON videos USING GIN(metadata);
 
 -- Scenes indexes
 CREATE INDEX idx_scenes_video_id ON scenes(video_id);
 CREATE INDEX idx_scenes_start_time ON scenes(video_id, start_time);
+CREATE INDEX idx_scenes_level ON scenes(level);
 CREATE INDEX idx_scenes_has_captions ON scenes(has_captions) WHERE has_captions = true;
 
 -- Vector similarity indexes (using IVFFlat for approximate nearest neighbor)
 -- Note: These will be created after we have some data, as they require training
+-- Workers create and maintain them through database.EnsureVectorIndexes (VECTOR_INDEX_TYPE, vector_index jobs)
 -- CREATE INDEX idx_scenes_visual_embedding ON scenes USING ivfflat (visual_embedding vector_cosine_ops) WITH (lists = 100);
 -- CREATE INDEX idx_scenes_text_embedding ON scenes USING ivfflat (text_embedding vector_cosine_ops) WITH (lists = 100);  
 -- CREATE INDEX idx_scenes_combined_embedding ON scenes USING ivfflat (combined_embedding vector_cosine_ops) WITH (lists = 100);
 
+-- Scene keyframes indexes
+CREATE INDEX idx_scene_keyframes_scene_id ON scene_keyframes(scene_id, rank);
+CREATE UNIQUE INDEX idx_scene_keyframes_selected ON scene_keyframes(scene_id) WHERE selected;
+
 -- Captions indexes
 CREATE INDEX idx_captions_video_id ON captions(video_id);
 CREATE INDEX idx_captions_scene_id ON captions(scene_id);
 CREATE INDEX idx_captions_start_time ON captions(video_id, start_time);
 CREATE INDEX idx_captions_text_search ON captions USING gin(to_tsvector('english', text));
 
+-- Search events indexes
+CREATE INDEX idx_search_events_created_at ON search_events(created_at DESC);
+CREATE INDEX idx_search_events_modality ON search_events(modality);
+
+CREATE INDEX idx_search_feedback_query_scene ON search_feedback(normalized_query, scene_id);
+
+-- Content flags indexes
+CREATE INDEX idx_content_flags_video_time ON content_flags(video_id, start_time);
+
+-- Scene tones indexes
+CREATE INDEX idx_scene_tones_video_id ON scene_tones(video_id);
+CREATE INDEX idx_scene_tones_emotion ON scene_tones(emotion);
+CREATE INDEX idx_scene_tones_audio_emotion ON scene_tones(audio_emotion);
+
+-- Alerts indexes
+CREATE INDEX idx_alerts_active ON alerts(active);
+CREATE INDEX idx_alert_matches_alert_id ON alert_matches(alert_id, created_at DESC);
+
+-- Embedding model indexes (only one active model per modality)
+CREATE UNIQUE INDEX idx_embedding_models_active ON embedding_models(modality) WHERE status = 'active';
+CREATE INDEX idx_scene_embeddings_model ON scene_embeddings(embedding_model_id, scene_id);
+
+-- Scene previews indexes
+CREATE INDEX idx_scene_previews_video_id ON scene_previews(video_id, created_at DESC);
+
+-- Annotations indexes
+CREATE INDEX idx_annotations_video_id ON annotations(video_id, start_time);
+CREATE INDEX idx_annotations_scene_id ON annotations(scene_id);
+CREATE INDEX idx_annotations_collection ON annotations(collection) WHERE collection IS NOT NULL;
+
+-- Caption embeddings indexes
+CREATE INDEX idx_captions_embeddings_video_id ON captions_embeddings(video_id, start_time);
+
+-- Caption words indexes
+CREATE INDEX idx_caption_words_norm ON caption_words(norm, video_id);
+CREATE INDEX idx_caption_words_caption_id ON caption_words(caption_id);
+
+-- Supercuts indexes
+CREATE INDEX idx_supercuts_created_at ON supercuts(created_at DESC);
+
+-- Clip exports indexes
+CREATE INDEX idx_clip_exports_scene_id ON clip_exports(scene_id);
+CREATE INDEX idx_clip_exports_video_id ON clip_exports(video_id);
+
+-- Caption QA indexes
+CREATE INDEX idx_caption_qa_reports_score ON caption_qa_reports(score);
+
+-- Caption translations indexes
+CREATE INDEX idx_caption_translations_video ON caption_translations(video_id, language, start_time);
+CREATE INDEX idx_caption_translations_text_search ON caption_translations USING gin(to_tsvector('simple', text));
+
+-- Scene entities indexes
+CREATE INDEX idx_scene_entities_video_id ON scene_entities(video_id);
+CREATE INDEX idx_scene_entities_normalized ON scene_entities(normalized, entity_type);
+CREATE INDEX idx_scene_entities_scene_id ON scene_entities(scene_id);
+
+-- Video topics indexes
+CREATE INDEX idx_video_topics_video_id ON video_topics(video_id, start_time);
+
+-- Highlight reels indexes
+CREATE INDEX idx_highlight_reels_video_id ON highlight_reels(video_id);
+CREATE INDEX idx_highlight_reels_created_at ON highlight_reels(created_at DESC);
+
+-- Artifacts indexes
+CREATE INDEX idx_artifacts_tier_accessed ON artifacts(tier, last_accessed_at);
+
 -- Processing jobs indexes
 CREATE INDEX idx_processing_jobs_video_id ON processing_jobs(video_id);
 CREATE INDEX idx_processing_jobs_status ON processing_jobs(status);
@@ -129,6 +645,47 @@ CREATE TRIGGER update_videos_updated_at
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
 
+-- Every video update, targeted or full-row, bumps its version so stale full-row saves conflict
+CREATE OR REPLACE FUNCTION bump_video_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER bump_videos_version
+    BEFORE UPDATE ON videos
+    FOR EACH ROW
+    EXECUTE FUNCTION bump_video_version();
+
+-- Legal hold enforcement at the DB layer
+CREATE OR REPLACE FUNCTION protect_locked_videos()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.locked THEN
+            RAISE EXCEPTION 'video % is locked (legal hold)', OLD.id USING ERRCODE = 'check_violation';
+        END IF;
+        RETURN OLD;
+    END IF;
+    IF OLD.locked AND NEW.locked AND (NEW.filepath IS DISTINCT FROM OLD.filepath OR NEW.file_hash IS DISTINCT FROM OLD.file_hash) THEN
+        RAISE EXCEPTION 'video % is locked (legal hold); source file cannot be replaced', OLD.id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER protect_locked_videos_delete
+    BEFORE DELETE ON videos
+    FOR EACH ROW
+    EXECUTE FUNCTION protect_locked_videos();
+
+CREATE TRIGGER protect_locked_videos_update
+    BEFORE UPDATE ON videos
+    FOR EACH ROW
+    EXECUTE FUNCTION protect_locked_videos();
+
 -- Views for common queries
 
 -- Video summary view with calculated statistics
@@ -150,6 +707,7 @@ LEFT JOIN (
         AVG(duration) as avg_scene_duration,
         COUNT(visual_embedding) as embeddings_count
     FROM scenes 
+    WHERE level = 'shot'
     GROUP BY video_id
 ) s ON v.id = s.video_id
 LEFT JOIN (